@@ -0,0 +1,36 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/cisco-open/fsoc/cmd/alias"
+	"github.com/cisco-open/fsoc/cmd/config"
+	"github.com/cisco-open/fsoc/cmdkit/cmdline"
+)
+
+func init() {
+	registerSubsystem(alias.NewSubCmd())
+}
+
+// expandAlias replaces a command alias defined in the config file with its definition, returning the
+// command line arguments to execute. The config file is read ahead of its loading for the command; if
+// it can't be read, the arguments are returned unchanged and the error is reported when it is loaded.
+func expandAlias(args []string) ([]string, error) {
+	aliases, err := config.ReadAliases(cmdline.FlagValue(rootCmd, args, "config"))
+	if err != nil || len(aliases) == 0 {
+		return args, nil
+	}
+	return alias.Expand(rootCmd, args, aliases)
+}
@@ -0,0 +1,141 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package alias provides the commands for managing command aliases, which are defined in the
+// config file and expanded by the root command before the command line is parsed
+package alias
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+
+	"github.com/cisco-open/fsoc/cmd/batch"
+	"github.com/cisco-open/fsoc/cmd/config"
+	"github.com/cisco-open/fsoc/output"
+)
+
+// nameRegexp matches valid alias names; viper (which reads the config file) folds keys to lower case
+// and treats dots as nesting, so neither upper case letters nor dots are allowed
+var nameRegexp = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+func NewSubCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "alias",
+		Short: "Manage command aliases",
+		Long: `Define shortcuts for frequently used commands. Aliases are kept in the config file, under "aliases",
+and can also be edited there:
+
+  aliases:
+    sl: solution list -o json
+    theme: knowledge get --type preferences:theme --layer-type TENANT --object $1
+
+When fsoc is run with an alias in place of a command, the alias is replaced with its definition, like git
+aliases. The arguments that follow the alias are appended to the definition, unless the definition has
+placeholders for them: $1 to $9 are replaced with the corresponding argument, $@ with all arguments and
+$$ with a literal $. Arguments not used by the $1 to $9 placeholders are appended.
+
+An alias can use other aliases, but it can't replace a command (or a plugin): aliases with the name of a
+command are ignored.`,
+		Example: `  # Define an alias for listing the solutions as JSON, used as "fsoc sl"
+  fsoc alias set sl "solution list -o json"
+
+  # Define an alias with a placeholder, used as "fsoc theme dark"
+  fsoc alias set theme 'knowledge get --type preferences:theme --layer-type TENANT --object $1'
+
+  fsoc alias list
+  fsoc alias delete sl`,
+		TraverseChildren: true,
+	}
+
+	cmd.AddCommand(newCmdList())
+	cmd.AddCommand(newCmdSet())
+	cmd.AddCommand(newCmdDelete())
+
+	return cmd
+}
+
+func newCmdList() *cobra.Command {
+	return &cobra.Command{
+		Use:         "list",
+		Short:       "List the command aliases",
+		Args:        cobra.NoArgs,
+		Annotations: map[string]string{config.AnnotationForConfigBypass: ""},
+		Run: func(cmd *cobra.Command, args []string) {
+			aliases := config.GetAliases()
+			names := make([]string, 0, len(aliases))
+			for name := range aliases {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			lines := make([][]string, len(names))
+			for i, name := range names {
+				lines[i] = []string{name, aliases[name]}
+			}
+			output.PrintCmdOutputCustom(cmd, aliases, &output.Table{
+				Headers: []string{"Alias", "Definition"},
+				Lines:   lines,
+			})
+		},
+	}
+}
+
+func newCmdSet() *cobra.Command {
+	return &cobra.Command{
+		Use:   "set NAME DEFINITION",
+		Short: "Define a command alias",
+		Long: `Define a command alias, replacing its previous definition, if any. The definition is a command line
+without "fsoc", which may have quoted arguments and the $1 to $9, $@ and $$ placeholders; quote it so that
+the shell passes it as a single argument and doesn't expand the placeholders itself.`,
+		Args:        cobra.ExactArgs(2),
+		Annotations: map[string]string{config.AnnotationForConfigBypass: ""},
+		Run: func(cmd *cobra.Command, args []string) {
+			name, definition := args[0], args[1]
+			if !nameRegexp.MatchString(name) {
+				log.Fatalf("Invalid alias name %q: use lower case letters, digits, dashes and underscores", name)
+			}
+			if isCommand(cmd.Root(), name) {
+				log.Fatalf("%q is an fsoc command and can't be an alias", name)
+			}
+			words, err := batch.SplitCommand(definition)
+			if err != nil {
+				log.Fatalf("Invalid alias definition: %v", err)
+			}
+			if len(words) == 0 {
+				log.Fatalf("The alias definition is empty")
+			}
+			config.SetAlias(name, definition)
+			output.PrintCmdStatus(cmd, fmt.Sprintf("Alias %q defined as %q.\n", name, definition))
+		},
+	}
+}
+
+func newCmdDelete() *cobra.Command {
+	return &cobra.Command{
+		Use:         "delete NAME",
+		Aliases:     []string{"del", "rm"},
+		Short:       "Delete a command alias",
+		Args:        cobra.ExactArgs(1),
+		Annotations: map[string]string{config.AnnotationForConfigBypass: ""},
+		Run: func(cmd *cobra.Command, args []string) {
+			if !config.DeleteAlias(args[0]) {
+				log.Fatalf("Alias %q is not defined", args[0])
+			}
+			output.PrintCmdStatus(cmd, fmt.Sprintf("Alias %q deleted.\n", args[0]))
+		},
+	}
+}
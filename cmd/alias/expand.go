@@ -0,0 +1,117 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alias
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cisco-open/fsoc/cmd/batch"
+	"github.com/cisco-open/fsoc/cmdkit/cmdline"
+)
+
+// placeholderRegexp matches the placeholders for the alias arguments: $1 to $9, $@ and the escaped $$
+var placeholderRegexp = regexp.MustCompile(`\$([1-9@$])`)
+
+// Expand replaces the alias in the command line arguments (without the program name) with its definition,
+// repeatedly if the definition starts with another alias. Arguments that are not an alias, or that name
+// a command of the root command, are returned unchanged.
+func Expand(root *cobra.Command, args []string, aliases map[string]string) ([]string, error) {
+	expanded := map[string]bool{}
+	for {
+		i := cmdline.CommandIndex(root, args)
+		if i < 0 {
+			return args, nil
+		}
+		name := args[i]
+		definition, found := aliases[name]
+		if !found || isCommand(root, name) {
+			return args, nil
+		}
+		if expanded[name] {
+			return nil, fmt.Errorf("alias %q refers to itself", name)
+		}
+		expanded[name] = true
+
+		words, err := expandDefinition(definition, args[i+1:])
+		if err != nil {
+			return nil, fmt.Errorf("alias %q: %w", name, err)
+		}
+		args = append(append([]string{}, args[:i]...), words...)
+	}
+}
+
+// expandDefinition splits the alias definition into arguments, replacing the placeholders with the
+// alias arguments; arguments that are not used by positional placeholders are appended, unless $@ is used
+func expandDefinition(definition string, args []string) ([]string, error) {
+	words, err := batch.SplitCommand(definition)
+	if err != nil {
+		return nil, err
+	}
+	used := 0
+	all := false
+	var result []string
+	for _, word := range words {
+		if word == "$@" {
+			result = append(result, args...)
+			all = true
+			continue
+		}
+		var missing int
+		word = placeholderRegexp.ReplaceAllStringFunc(word, func(ref string) string {
+			switch ref[1] {
+			case '$':
+				return "$"
+			case '@':
+				all = true
+				return strings.Join(args, " ")
+			}
+			n, _ := strconv.Atoi(ref[1:])
+			if n > used {
+				used = n
+			}
+			if n > len(args) {
+				missing = n
+				return ""
+			}
+			return args[n-1]
+		})
+		if missing > 0 {
+			return nil, fmt.Errorf("argument $%d is missing (%d given)", missing, len(args))
+		}
+		result = append(result, word)
+	}
+	if !all && used < len(args) {
+		result = append(result, args[used:]...)
+	}
+	return result, nil
+}
+
+// isCommand returns true if the root command has a subcommand (including plugins) with the name or alias
+func isCommand(root *cobra.Command, name string) bool {
+	if name == "help" || name == "completion" || strings.HasPrefix(name, "__") {
+		return true // added by cobra when executed
+	}
+	for _, c := range root.Commands() {
+		if c.Name() == name || c.HasAlias(name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alias
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testRoot() *cobra.Command {
+	root := &cobra.Command{Use: "fsoc"}
+	root.PersistentFlags().String("profile", "", "")
+	root.PersistentFlags().StringP("output", "o", "auto", "")
+	root.PersistentFlags().BoolP("verbose", "v", false, "")
+	root.AddCommand(&cobra.Command{Use: "solution", Aliases: []string{"sol"}})
+	root.AddCommand(&cobra.Command{Use: "knowledge"})
+	return root
+}
+
+func TestExpand(t *testing.T) {
+	aliases := map[string]string{
+		"sl":    "solution list -o json",
+		"theme": "knowledge get --type preferences:theme --object-id $1",
+		"q":     `uql "fetch $@"`,
+		"kg":    "knowledge get $@ -o json",
+		"sj":    "sl --fields $1",
+		"loop":  "loop2",
+		"loop2": "loop",
+		"sol":   "knowledge list",
+		"cost":  "echo $$1 $1",
+	}
+	root := testRoot()
+	for _, tc := range []struct {
+		args     []string
+		expected []string
+	}{
+		{[]string{"sl"}, []string{"solution", "list", "-o", "json"}},
+		{[]string{"--profile", "prod", "-v", "sl", "--wrap"}, []string{"--profile", "prod", "-v", "solution", "list", "-o", "json", "--wrap"}},
+		{[]string{"theme", "dark", "-o", "yaml"}, []string{"knowledge", "get", "--type", "preferences:theme", "--object-id", "dark", "-o", "yaml"}},
+		{[]string{"q", "id,", "name"}, []string{"uql", "fetch id, name"}},
+		{[]string{"kg", "--type", "a:b"}, []string{"knowledge", "get", "--type", "a:b", "-o", "json"}},
+		{[]string{"sj", "name"}, []string{"solution", "list", "-o", "json", "--fields", "name"}},
+		{[]string{"sol", "list"}, []string{"sol", "list"}}, // commands can't be replaced
+		{[]string{"cost", "x"}, []string{"echo", "$1", "x"}},
+		{[]string{"solution", "sl"}, []string{"solution", "sl"}},
+		{[]string{"--", "sl"}, []string{"--", "sl"}},
+	} {
+		actual, err := Expand(root, tc.args, aliases)
+		require.NoError(t, err, tc.args)
+		assert.Equal(t, tc.expected, actual, tc.args)
+	}
+
+	_, err := Expand(root, []string{"theme"}, aliases)
+	assert.ErrorContains(t, err, "$1 is missing")
+	_, err = Expand(root, []string{"loop"}, aliases)
+	assert.ErrorContains(t, err, "refers to itself")
+}
@@ -0,0 +1,355 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package apply provides the command that converges the tenant to a state declared in files: knowledge
+// objects and solution subscriptions
+package apply
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+
+	"github.com/cisco-open/fsoc/cmd/config"
+	"github.com/cisco-open/fsoc/cmdkit/onerror"
+	"github.com/cisco-open/fsoc/exitcode"
+	"github.com/cisco-open/fsoc/output"
+	"github.com/cisco-open/fsoc/platform/api"
+)
+
+func NewSubCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "apply -f PATH",
+		Short: "Converge the tenant to the knowledge objects and subscriptions declared in files",
+		Long: `Read the knowledge objects and solution subscriptions declared in YAML or JSON files, compare them with the
+tenant and create, update or delete objects and subscribe to or unsubscribe from solutions so that the tenant
+matches the declared state, e.g., for keeping the tenant configuration in a git repository.
+
+Each file can declare several resources, as separate YAML documents (--- separated). For directories, the
+.yaml, .yml and .json files directly in them are read, or in their whole tree with -R.
+
+  kind: KnowledgeObject
+  type: preferences:theme
+  layerType: TENANT        # layerId is optional for the TENANT, SOLUTION, LOCALUSER and GLOBALUSER layers
+  id: dark
+  data:
+    backgroundColor: black
+  ---
+  kind: SolutionSubscription
+  solution: spacefleet     # subscribed: false to declare that the tenant is not subscribed
+
+Objects are created if they don't exist and updated if their data differs; the id of an object must be the
+one its type assigns to its data (e.g., from the data's name). With --prune, the objects of the
+declared objects' types and layers that are not declared are deleted, and, if subscriptions are declared,
+the tenant is unsubscribed from the non-system solutions that are not declared.
+
+With --dry-run, the changes are displayed without applying them. To review the changes before applying them,
+e.g., in a pull request, save them with "fsoc apply plan" and apply the saved plan with --plan-file, which
+makes exactly the planned changes, failing if the tenant has changed since the plan was created.`,
+		Example: `  fsoc apply -f theme.yaml
+  fsoc apply -R -f ./config/ --dry-run
+  fsoc apply -R -f ./config/ --prune
+
+  # Apply a reviewed plan
+  fsoc apply plan -R -f ./config/ --out tenant.plan
+  fsoc apply --plan-file tenant.plan`,
+		Args: cobra.NoArgs,
+		Run:  applyResources,
+	}
+
+	addSourceFlags(cmd)
+	cmd.Flags().String("plan-file", "", "Apply the changes saved by \"fsoc apply plan\", instead of the declared resources")
+	cmd.MarkFlagsMutuallyExclusive("file", "plan-file")
+	cmd.MarkFlagsMutuallyExclusive("prune", "plan-file")
+	cmd.Flags().Bool("dry-run", false, "Display the changes without applying them")
+	onerror.AddFlag(cmd, onerror.Abort)
+
+	cmd.AddCommand(newPlanCmd())
+
+	return cmd
+}
+
+// addSourceFlags adds the flags that specify the declared resources
+func addSourceFlags(cmd *cobra.Command) {
+	cmd.Flags().StringArrayP("file", "f", nil, "File or directory declaring the resources (can be repeated)")
+	cmd.Flags().BoolP("recursive", "R", false, "Read the files in the subdirectories of the directories, too")
+	cmd.Flags().Bool("prune", false, "Delete the objects and unsubscribe from the solutions that are not declared (see \"fsoc apply --help\")")
+}
+
+func applyResources(cmd *cobra.Command, args []string) {
+	planPath, _ := cmd.Flags().GetString("plan-file")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	errs, err := onerror.New(cmd)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	var changes []change
+	if planPath != "" {
+		changes = readPlan(planPath)
+	} else {
+		changes = planResources(cmd)
+	}
+
+	counts := map[string]int{}
+	aborted := false
+	for i := range changes {
+		ch := &changes[i]
+		if dryRun || ch.Action == actionUnchanged {
+			counts[ch.Action]++
+			continue
+		}
+		if aborted {
+			ch.Error = "not applied: aborted"
+			continue
+		}
+		err := errs.Do(fmt.Sprintf("%s %s", ch.Kind, ch.Resource), func() error { return applyChange(ch) })
+		var abortErr *onerror.AbortError
+		switch {
+		case err == nil:
+			counts[ch.Action]++
+		case errors.As(err, &abortErr):
+			aborted = true
+			ch.Error = abortErr.Err.Error()
+		default:
+			ch.Error = err.Error()
+		}
+	}
+
+	printChanges(cmd, changes)
+	summary := fmt.Sprintf("%d created, %d updated, %d deleted, %d subscribed, %d unsubscribed, %d unchanged",
+		counts[actionCreate], counts[actionUpdate], counts[actionDelete], counts[actionSubscribe], counts[actionUnsubscribe], counts[actionUnchanged])
+	if dryRun {
+		output.PrintCmdStatus(cmd, fmt.Sprintf("Dry run, would be: %s.\n", summary))
+		return
+	}
+	failures := 0
+	for _, ch := range changes {
+		if ch.Error != "" {
+			failures++
+		}
+	}
+	if failures > 0 {
+		log.Fatalf("Failed to apply %d change(s); applied: %s", failures, summary)
+	}
+	output.PrintCmdStatus(cmd, fmt.Sprintf("Applied: %s.\n", summary))
+}
+
+// planResources returns the changes that converge the tenant to the resources declared in the files
+// specified with the command's flags
+func planResources(cmd *cobra.Command) []change {
+	paths, _ := cmd.Flags().GetStringArray("file")
+	recursive, _ := cmd.Flags().GetBool("recursive")
+	prune, _ := cmd.Flags().GetBool("prune")
+	if len(paths) == 0 {
+		log.WithField(exitcode.Field, exitcode.Usage).Fatal("The files declaring the resources must be specified with -f, or a saved plan with --plan-file")
+	}
+
+	resources, err := readResources(paths, recursive)
+	if err != nil {
+		log.WithField(exitcode.Field, exitcode.Usage).Fatalf("Failed to read the declared resources: %v", err)
+	}
+	if len(resources) == 0 {
+		log.WithField(exitcode.Field, exitcode.Usage).Fatalf("No resources declared in %s", strings.Join(paths, ", "))
+	}
+
+	collections := []collection{}
+	needSolutions := false
+	for i := range resources {
+		r := &resources[i]
+		if r.Kind == kindSubscription {
+			needSolutions = true
+			continue
+		}
+		c := resolveLayer(r)
+		if c.LayerID == "" {
+			log.WithField(exitcode.Field, exitcode.Usage).Fatalf("%s %s (%s): layerId is required for the %s layer", kindObject, r.name(), r.source, c.LayerType)
+		}
+		collections = append(collections, c)
+	}
+	state, err := getTenantState(collections, needSolutions)
+	if err != nil {
+		log.Fatalf("Failed to get the current state of the tenant: %v", err)
+	}
+	changes, err := plan(resources, resolveLayer, state, prune)
+	if err != nil {
+		log.WithField(exitcode.Field, exitcode.Validation).Fatal(err.Error())
+	}
+	return changes
+}
+
+// resolveLayer returns the collection of a declared object, with the default layer ID for its layer type
+func resolveLayer(r *resource) collection {
+	c := collection{Type: r.Type, LayerType: r.LayerType, LayerID: r.LayerID}
+	if c.LayerID == "" {
+		cfg := config.GetCurrentContext()
+		switch c.LayerType {
+		case "TENANT":
+			c.LayerID = cfg.Tenant
+		case "SOLUTION":
+			c.LayerID = strings.Split(c.Type, ":")[0]
+		case "LOCALUSER", "GLOBALUSER":
+			c.LayerID = cfg.User
+		}
+	}
+	return c
+}
+
+// getTenantState fetches the objects of the collections and, if needed, the tenant's solutions
+func getTenantState(collections []collection, needSolutions bool) (*tenantState, error) {
+	state := &tenantState{objects: map[collection]map[string]map[string]any{}, solutions: map[string]solutionState{}}
+	for _, c := range collections {
+		if _, fetched := state.objects[c]; fetched {
+			continue
+		}
+		objects, err := getObjects(c)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the %q objects in the %s layer: %w", c.Type, layerName(c), err)
+		}
+		state.objects[c] = objects
+	}
+	if needSolutions {
+		solutions, err := getSolutions()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the solutions: %w", err)
+		}
+		state.solutions = solutions
+	}
+	return state, nil
+}
+
+// getObjects returns the data of the objects in the collection, by ID
+func getObjects(c collection) (map[string]map[string]any, error) {
+	var page struct {
+		Items []struct {
+			ID   string         `json:"id"`
+			Data map[string]any `json:"data"`
+		} `json:"items"`
+	}
+	var res any
+	if err := api.JSONGetCollection(getObjectListUrl(c.Type), &res, &api.Options{Headers: layerHeaders(c)}); err != nil {
+		return nil, err
+	}
+	if err := convertValue(res, &page); err != nil {
+		return nil, err
+	}
+	objects := map[string]map[string]any{}
+	for _, item := range page.Items {
+		if item.Data == nil {
+			item.Data = map[string]any{}
+		}
+		objects[item.ID] = item.Data
+	}
+	return objects, nil
+}
+
+// getSolutions returns the subscription state of the tenant's solutions, by name
+func getSolutions() (map[string]solutionState, error) {
+	var page struct {
+		Items []struct {
+			Data struct {
+				Name         string `json:"name"`
+				IsSubscribed bool   `json:"isSubscribed"`
+				IsSystem     bool   `json:"isSystem"`
+			} `json:"data"`
+		} `json:"items"`
+	}
+	var res any
+	if err := api.JSONGetCollection(getSolutionListUrl(), &res, &api.Options{Headers: tenantHeaders()}); err != nil {
+		return nil, err
+	}
+	if err := convertValue(res, &page); err != nil {
+		return nil, err
+	}
+	solutions := map[string]solutionState{}
+	for _, item := range page.Items {
+		solutions[item.Data.Name] = solutionState{Subscribed: item.Data.IsSubscribed, System: item.Data.IsSystem}
+	}
+	return solutions, nil
+}
+
+// applyChange performs the change's action in the tenant
+func applyChange(ch *change) error {
+	var res any
+	switch ch.Action {
+	case actionCreate:
+		return api.JSONPost(getObjectListUrl(ch.Type), ch.Data, &res, &api.Options{Headers: layerHeaders(ch.collection()), Resources: []string{ch.Resource}})
+	case actionUpdate:
+		return api.JSONPut(getObjectUrl(ch.Type, ch.ID), ch.Data, &res, &api.Options{Headers: layerHeaders(ch.collection())})
+	case actionDelete:
+		options := &api.Options{Headers: layerHeaders(ch.collection())}
+		err := api.JSONDelete(getObjectUrl(ch.Type, ch.ID), &res, options)
+		if api.IsNotFound(err, options) {
+			return nil // already deleted
+		}
+		return err
+	case actionSubscribe, actionUnsubscribe:
+		body := map[string]bool{"isSubscribed": ch.Action == actionSubscribe}
+		return api.JSONPatch(getSolutionListUrl()+"/"+ch.Resource, body, &res, &api.Options{Headers: tenantHeaders()})
+	}
+	return nil
+}
+
+func printChanges(cmd *cobra.Command, changes []change) {
+	lines := make([][]string, len(changes))
+	for i, ch := range changes {
+		lines[i] = []string{ch.Kind, ch.Resource, ch.Layer, ch.Action, ch.Source, ch.Error}
+	}
+	output.PrintCmdOutputCustom(cmd, struct {
+		Items []change `json:"items"`
+		Total int      `json:"total"`
+	}{Items: changes, Total: len(changes)}, &output.Table{
+		Headers: []string{"Kind", "Resource", "Layer", "Action", "Source", "Error"},
+		Lines:   lines,
+	})
+}
+
+// convertValue converts a generic value, e.g., a collection, into a typed value through JSON
+func convertValue(in any, out any) error {
+	b, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, out)
+}
+
+func layerHeaders(c collection) map[string]string {
+	return map[string]string{
+		"layer-type": c.LayerType,
+		"layer-id":   c.LayerID,
+	}
+}
+
+func tenantHeaders() map[string]string {
+	return map[string]string{
+		"layer-type": "TENANT",
+		"layer-id":   config.GetCurrentContext().Tenant,
+	}
+}
+
+func getObjectUrl(fqtn, objId string) string {
+	return fmt.Sprintf("objstore/v1beta/objects/%s/%s", fqtn, objId)
+}
+
+func getObjectListUrl(fqtn string) string {
+	return fmt.Sprintf("objstore/v1beta/objects/%s", fqtn)
+}
+
+func getSolutionListUrl() string {
+	return "objstore/v1beta/objects/extensibility:solution"
+}
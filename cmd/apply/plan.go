@@ -0,0 +1,183 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apply
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/cisco-open/fsoc/cmdkit/jsondiff"
+)
+
+// Actions converging the tenant to the declared state, in the order they are applied: subscriptions
+// first, since objects may be of the subscribed solutions' types, and unsubscriptions last
+const (
+	actionSubscribe   = "subscribe"
+	actionCreate      = "create"
+	actionUpdate      = "update"
+	actionDelete      = "delete"
+	actionUnsubscribe = "unsubscribe"
+	actionUnchanged   = "unchanged"
+)
+
+var actionOrder = map[string]int{
+	actionSubscribe:   0,
+	actionCreate:      1,
+	actionUpdate:      2,
+	actionDelete:      3,
+	actionUnsubscribe: 4,
+	actionUnchanged:   5,
+}
+
+// solutionState is the subscription state of a solution in the tenant
+type solutionState struct {
+	Subscribed bool
+	System     bool
+}
+
+// tenantState is the current state of the declared resources in the tenant: the objects of the
+// collections of the declared objects, by ID, and the solutions, by name
+type tenantState struct {
+	objects   map[collection]map[string]map[string]any
+	solutions map[string]solutionState
+}
+
+// change is an action on a resource, planned and, unless it is a dry run, applied. Object changes
+// include the object's data before and after the change, so that a saved plan can be checked against
+// the tenant and applied exactly.
+type change struct {
+	Kind     string `json:"kind"`
+	Resource string `json:"resource"`
+	Layer    string `json:"layer,omitempty"`
+	Action   string `json:"action"`
+	Source   string `json:"source,omitempty"`
+	Error    string `json:"error,omitempty"`
+
+	Type      string         `json:"type,omitempty"`
+	LayerType string         `json:"layerType,omitempty"`
+	LayerID   string         `json:"layerId,omitempty"`
+	ID        string         `json:"id,omitempty"`
+	Before    map[string]any `json:"before,omitempty"`
+	Data      map[string]any `json:"data,omitempty"`
+}
+
+// newObjectChange returns the change of an object in the collection
+func newObjectChange(c collection, id string, action string, before map[string]any, data map[string]any) change {
+	return change{
+		Kind:      kindObject,
+		Resource:  c.Type + "/" + id,
+		Layer:     layerName(c),
+		Action:    action,
+		Type:      c.Type,
+		LayerType: c.LayerType,
+		LayerID:   c.LayerID,
+		ID:        id,
+		Before:    before,
+		Data:      data,
+	}
+}
+
+// collection returns the collection of the changed object
+func (ch *change) collection() collection {
+	return collection{Type: ch.Type, LayerType: ch.LayerType, LayerID: ch.LayerID}
+}
+
+// plan returns the changes that converge the tenant to the declared resources, ordered by action;
+// with prune, the objects in the declared objects' collections and the non-system solution
+// subscriptions that are not declared are deleted and unsubscribed, respectively
+func plan(resources []resource, layerIDs func(r *resource) collection, state *tenantState, prune bool) ([]change, error) {
+	changes := []change{}
+	declaredObjects := map[collection]map[string]bool{}
+	declaredSolutions := map[string]bool{}
+	for i := range resources {
+		r := &resources[i]
+		switch r.Kind {
+		case kindObject:
+			c := layerIDs(r)
+			if declaredObjects[c] == nil {
+				declaredObjects[c] = map[string]bool{}
+			}
+			if declaredObjects[c][r.ID] {
+				return nil, fmt.Errorf("%s %s is declared more than once (%s)", kindObject, r.name(), r.source)
+			}
+			declaredObjects[c][r.ID] = true
+
+			current, exists := state.objects[c][r.ID]
+			action := actionUnchanged
+			switch {
+			case !exists:
+				action = actionCreate
+			case len(jsondiff.Diff(current, r.Data)) > 0:
+				action = actionUpdate
+			}
+			if action == actionUnchanged {
+				current = nil // same as the data
+			}
+			ch := newObjectChange(c, r.ID, action, current, r.Data)
+			ch.Source = r.source
+			changes = append(changes, ch)
+		case kindSubscription:
+			if declaredSolutions[r.Solution] {
+				return nil, fmt.Errorf("%s %s is declared more than once (%s)", kindSubscription, r.Solution, r.source)
+			}
+			declaredSolutions[r.Solution] = true
+
+			current, exists := state.solutions[r.Solution]
+			if !exists {
+				return nil, fmt.Errorf("%s %s (%s): the solution does not exist in the tenant", kindSubscription, r.Solution, r.source)
+			}
+			ch := change{Kind: kindSubscription, Resource: r.Solution, Source: r.source, Action: actionUnchanged}
+			if r.subscribed() && !current.Subscribed {
+				ch.Action = actionSubscribe
+			} else if !r.subscribed() && current.Subscribed {
+				if current.System {
+					return nil, fmt.Errorf("%s %s (%s): cannot unsubscribe from a system solution", kindSubscription, r.Solution, r.source)
+				}
+				ch.Action = actionUnsubscribe
+			}
+			changes = append(changes, ch)
+		}
+	}
+
+	if prune {
+		pruned := []change{}
+		for c, objects := range state.objects {
+			for id := range objects {
+				if !declaredObjects[c][id] {
+					pruned = append(pruned, newObjectChange(c, id, actionDelete, objects[id], nil))
+				}
+			}
+		}
+		if len(declaredSolutions) > 0 {
+			for name, s := range state.solutions {
+				if s.Subscribed && !s.System && !declaredSolutions[name] {
+					pruned = append(pruned, change{Kind: kindSubscription, Resource: name, Action: actionUnsubscribe})
+				}
+			}
+		}
+		sort.Slice(pruned, func(i, j int) bool {
+			return pruned[i].Layer+" "+pruned[i].Resource < pruned[j].Layer+" "+pruned[j].Resource
+		})
+		changes = append(changes, pruned...)
+	}
+
+	// apply in the order of the actions, keeping the declaration order otherwise
+	sort.SliceStable(changes, func(i, j int) bool { return actionOrder[changes[i].Action] < actionOrder[changes[j].Action] })
+	return changes, nil
+}
+
+func layerName(c collection) string {
+	return c.LayerType + ":" + c.LayerID
+}
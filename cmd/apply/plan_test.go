@@ -0,0 +1,140 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apply
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const declared = `
+kind: KnowledgeObject
+type: preferences:theme
+layerType: TENANT
+id: dark
+data:
+  backgroundColor: black
+  opacity: 1
+---
+kind: KnowledgeObject
+type: preferences:theme
+layerType: TENANT
+id: light
+data:
+  backgroundColor: white
+---
+kind: KnowledgeObject
+type: preferences:theme
+layerType: TENANT
+id: green
+data:
+  backgroundColor: green
+---
+kind: SolutionSubscription
+solution: spacefleet
+---
+kind: SolutionSubscription
+solution: zodiac
+subscribed: false
+`
+
+func testLayer(r *resource) collection {
+	return collection{Type: r.Type, LayerType: r.LayerType, LayerID: "tenant1"}
+}
+
+func TestParseResources(t *testing.T) {
+	resources, err := parseResources(strings.NewReader(declared), "themes.yaml")
+	require.NoError(t, err)
+	require.Len(t, resources, 5)
+	assert.Equal(t, "preferences:theme/dark", resources[0].name())
+	assert.Equal(t, map[string]any{"backgroundColor": "black", "opacity": float64(1)}, resources[0].Data)
+	assert.Equal(t, "themes.yaml", resources[0].source)
+	assert.True(t, resources[3].subscribed())
+	assert.False(t, resources[4].subscribed())
+
+	for _, invalid := range []string{
+		`{type: preferences:theme, layerType: TENANT, id: dark}`,
+		`{kind: Theme, id: dark}`,
+		`{kind: KnowledgeObject, type: preferences:theme, id: dark}`,
+		`{kind: KnowledgeObject, type: preferences:theme, layerType: TENANT, id: dark, solution: x}`,
+		`{kind: SolutionSubscription}`,
+		`{kind: SolutionSubscription, solution: x, id: y}`,
+		`{kind: SolutionSubscription, solution: x, unknown: y}`,
+	} {
+		_, err := parseResources(strings.NewReader(invalid), "invalid.yaml")
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestPlan(t *testing.T) {
+	resources, err := parseResources(strings.NewReader(declared), "themes.yaml")
+	require.NoError(t, err)
+	themes := collection{Type: "preferences:theme", LayerType: "TENANT", LayerID: "tenant1"}
+	state := &tenantState{
+		objects: map[collection]map[string]map[string]any{
+			themes: {
+				"dark":  {"backgroundColor": "black", "opacity": float64(1)},
+				"light": {"backgroundColor": "grey"},
+				"blue":  {"backgroundColor": "blue"},
+			},
+		},
+		solutions: map[string]solutionState{
+			"spacefleet": {},
+			"zodiac":     {Subscribed: true},
+			"other":      {Subscribed: true},
+			"platform":   {Subscribed: true, System: true},
+		},
+	}
+
+	actions := func(changes []change) []string {
+		list := make([]string, len(changes))
+		for i, ch := range changes {
+			list[i] = ch.Action + " " + ch.Resource
+		}
+		return list
+	}
+
+	changes, err := plan(resources, testLayer, state, false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"subscribe spacefleet",
+		"create preferences:theme/green",
+		"update preferences:theme/light",
+		"unsubscribe zodiac",
+		"unchanged preferences:theme/dark",
+	}, actions(changes))
+
+	changes, err = plan(resources, testLayer, state, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"subscribe spacefleet",
+		"create preferences:theme/green",
+		"update preferences:theme/light",
+		"delete preferences:theme/blue",
+		"unsubscribe zodiac",
+		"unsubscribe other",
+		"unchanged preferences:theme/dark",
+	}, actions(changes))
+
+	// declaring a resource twice, or a solution that the tenant doesn't have, is an error
+	_, err = plan(append(resources, resources[0]), testLayer, state, false)
+	assert.ErrorContains(t, err, "more than once")
+	delete(state.solutions, "spacefleet")
+	_, err = plan(resources, testLayer, state, false)
+	assert.ErrorContains(t, err, "does not exist")
+}
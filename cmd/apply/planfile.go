@@ -0,0 +1,240 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apply
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/cisco-open/fsoc/cmd/config"
+	"github.com/cisco-open/fsoc/cmdkit/jsondiff"
+	"github.com/cisco-open/fsoc/exitcode"
+	"github.com/cisco-open/fsoc/output"
+)
+
+// planFileVersion is the version of the plan file format
+const planFileVersion = 1
+
+// maximum length of the values displayed in a plan
+const planValueDisplayLength = 80
+
+// planFile is a saved plan: the changes to make in the tenant it was created for
+type planFile struct {
+	Version   int      `json:"version"`
+	Profile   string   `json:"profile"`
+	URL       string   `json:"url"`
+	Tenant    string   `json:"tenant"`
+	CreatedAt string   `json:"createdAt"`
+	Changes   []change `json:"changes"`
+}
+
+// plan symbols and colors, by action
+var planSymbols = map[string]string{
+	actionCreate:      "+",
+	actionSubscribe:   "+",
+	actionUpdate:      "~",
+	actionDelete:      "-",
+	actionUnsubscribe: "-",
+}
+
+var planColors = map[string]*color.Color{
+	"+": color.New(color.FgGreen),
+	"~": color.New(color.FgYellow),
+	"-": color.New(color.FgRed),
+}
+
+func newPlanCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "plan -f PATH",
+		Short: "Display and save the changes that apply would make",
+		Long: `Compare the declared knowledge objects and solution subscriptions with the tenant and display the changes that
+"fsoc apply" would make: + for objects to create and solutions to subscribe to, ~ for objects to update, with
+their changed values, and - for objects to delete and solutions to unsubscribe from.
+
+With --out, the plan is saved into a file, e.g., for review in a pull request, that "fsoc apply --plan-file"
+applies exactly as planned. The plan file is JSON and includes the data of the changed objects.`,
+		Example: `  fsoc apply plan -R -f ./config/
+  fsoc apply plan -R -f ./config/ --prune --out tenant.plan
+  fsoc apply plan -f theme.yaml -o json`,
+		Args: cobra.NoArgs,
+		Run:  planChanges,
+	}
+
+	addSourceFlags(cmd)
+	_ = cmd.MarkFlagRequired("file")
+	cmd.Flags().String("out", "", "Save the plan into a file, to be applied with \"fsoc apply --plan-file\"")
+
+	return cmd
+}
+
+func planChanges(cmd *cobra.Command, args []string) {
+	changes := planResources(cmd)
+	ctx := config.GetCurrentContext()
+	plan := planFile{
+		Version:   planFileVersion,
+		Profile:   ctx.Name,
+		URL:       ctx.URL,
+		Tenant:    ctx.Tenant,
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+		Changes:   []change{},
+	}
+	unchanged := 0
+	for _, ch := range changes {
+		if ch.Action == actionUnchanged {
+			unchanged++
+			continue
+		}
+		plan.Changes = append(plan.Changes, ch)
+	}
+
+	out, _ := cmd.Flags().GetString("out")
+	if out != "" {
+		b, err := json.MarshalIndent(plan, "", "  ")
+		if err != nil {
+			log.Fatalf("Failed to save the plan: %v", err)
+		}
+		if err := os.WriteFile(out, append(b, '\n'), 0600); err != nil {
+			log.Fatalf("Failed to save the plan: %v", err)
+		}
+	}
+
+	if format, _ := cmd.Flags().GetString("output"); format == "auto" || format == "table" {
+		printPlan(output.GetOutWriter(cmd), plan.Changes, unchanged)
+		if out != "" {
+			output.PrintCmdStatus(cmd, fmt.Sprintf("Saved the plan into %q; apply it with \"fsoc apply --plan-file %s\".\n", out, out))
+		}
+	} else {
+		output.PrintCmdOutput(cmd, plan)
+	}
+}
+
+// printPlan displays the changes, color-coded, with the changed values of the objects
+func printPlan(w io.Writer, changes []change, unchanged int) {
+	counts := map[string]int{}
+	for _, ch := range changes {
+		counts[ch.Action]++
+		symbol := planSymbols[ch.Action]
+		c := planColors[symbol]
+		title := fmt.Sprintf("%s %s %s %s", symbol, ch.Action, ch.Kind, ch.Resource)
+		if ch.Layer != "" {
+			title += " (" + ch.Layer + ")"
+		}
+		fmt.Fprintln(w, c.Sprint(title))
+		if ch.Kind != kindObject {
+			continue
+		}
+		before, after := ch.Before, ch.Data
+		if before == nil {
+			before = map[string]any{}
+		}
+		if after == nil {
+			after = map[string]any{}
+		}
+		for _, d := range jsondiff.Diff(before, after) {
+			switch d.Change {
+			case jsondiff.Added:
+				fmt.Fprintln(w, planColors["+"].Sprintf("    + %s: %s", d.Path, jsondiff.DisplayValue(d.New, planValueDisplayLength)))
+			case jsondiff.Removed:
+				fmt.Fprintln(w, planColors["-"].Sprintf("    - %s: %s", d.Path, jsondiff.DisplayValue(d.Old, planValueDisplayLength)))
+			default:
+				fmt.Fprintln(w, planColors["~"].Sprintf("    ~ %s: %s -> %s", d.Path, jsondiff.DisplayValue(d.Old, planValueDisplayLength), jsondiff.DisplayValue(d.New, planValueDisplayLength)))
+			}
+		}
+	}
+
+	if len(changes) == 0 {
+		fmt.Fprintf(w, "No changes: the tenant matches the declared resources (%d unchanged).\n", unchanged)
+		return
+	}
+	fmt.Fprintf(w, "\nPlan: %d to create, %d to update, %d to delete, %d to subscribe, %d to unsubscribe (%d unchanged).\n",
+		counts[actionCreate], counts[actionUpdate], counts[actionDelete], counts[actionSubscribe], counts[actionUnsubscribe], unchanged)
+}
+
+// readPlan reads a plan file, returning its changes after checking that the plan is for the current
+// tenant and that the tenant has not changed since the plan was created
+func readPlan(path string) []change {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		log.WithField(exitcode.Field, exitcode.Usage).Fatalf("Failed to read the plan: %v", err)
+	}
+	var plan planFile
+	if err := json.Unmarshal(b, &plan); err != nil {
+		log.WithField(exitcode.Field, exitcode.Usage).Fatalf("Failed to parse the plan %q: %v", path, err)
+	}
+	if plan.Version != planFileVersion {
+		log.WithField(exitcode.Field, exitcode.Usage).Fatalf("Unsupported plan version %d in %q, expected %d", plan.Version, path, planFileVersion)
+	}
+	ctx := config.GetCurrentContext()
+	if plan.URL != ctx.URL || plan.Tenant != ctx.Tenant {
+		log.WithField(exitcode.Field, exitcode.Usage).Fatalf("The plan is for tenant %q at %s (profile %q), not for the current profile's tenant %q at %s", plan.Tenant, plan.URL, plan.Profile, ctx.Tenant, ctx.URL)
+	}
+
+	collections := []collection{}
+	needSolutions := false
+	for i := range plan.Changes {
+		ch := &plan.Changes[i]
+		if ch.Kind == kindSubscription {
+			needSolutions = true
+		} else {
+			collections = append(collections, ch.collection())
+		}
+	}
+	state, err := getTenantState(collections, needSolutions)
+	if err != nil {
+		log.Fatalf("Failed to get the current state of the tenant: %v", err)
+	}
+	if problems := checkPlan(plan.Changes, state); len(problems) > 0 {
+		log.Fatalf("The tenant has changed since the plan was created, create a new plan: %s", strings.Join(problems, "; "))
+	}
+	return plan.Changes
+}
+
+// checkPlan returns the planned changes that no longer apply to the tenant in its current state
+func checkPlan(changes []change, state *tenantState) []string {
+	problems := []string{}
+	for i := range changes {
+		ch := &changes[i]
+		if ch.Kind == kindSubscription {
+			s, exists := state.solutions[ch.Resource]
+			switch {
+			case !exists:
+				problems = append(problems, fmt.Sprintf("solution %s no longer exists", ch.Resource))
+			case s.Subscribed == (ch.Action == actionSubscribe):
+				problems = append(problems, fmt.Sprintf("the subscription to %s has changed", ch.Resource))
+			}
+			continue
+		}
+		current, exists := state.objects[ch.collection()][ch.ID]
+		switch {
+		case ch.Action == actionCreate && exists:
+			problems = append(problems, fmt.Sprintf("%s was created", ch.Resource))
+		case ch.Action != actionCreate && !exists:
+			problems = append(problems, fmt.Sprintf("%s was deleted", ch.Resource))
+		case ch.Action != actionCreate && len(jsondiff.Diff(ch.Before, current)) > 0:
+			problems = append(problems, fmt.Sprintf("%s was modified", ch.Resource))
+		}
+	}
+	sort.Strings(problems)
+	return problems
+}
@@ -0,0 +1,86 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apply
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintPlan(t *testing.T) {
+	noColor := color.NoColor
+	color.NoColor = true
+	defer func() { color.NoColor = noColor }()
+
+	themes := collection{Type: "preferences:theme", LayerType: "TENANT", LayerID: "tenant1"}
+	changes := []change{
+		{Kind: kindSubscription, Resource: "spacefleet", Action: actionSubscribe},
+		newObjectChange(themes, "green", actionCreate, nil, map[string]any{"backgroundColor": "green"}),
+		newObjectChange(themes, "light", actionUpdate, map[string]any{"backgroundColor": "grey", "old": true}, map[string]any{"backgroundColor": "white"}),
+		newObjectChange(themes, "blue", actionDelete, map[string]any{"backgroundColor": "blue"}, nil),
+	}
+
+	var b bytes.Buffer
+	printPlan(&b, changes, 2)
+	assert.Equal(t, `+ subscribe SolutionSubscription spacefleet
++ create KnowledgeObject preferences:theme/green (TENANT:tenant1)
+    + .backgroundColor: green
+~ update KnowledgeObject preferences:theme/light (TENANT:tenant1)
+    ~ .backgroundColor: grey -> white
+    - .old: true
+- delete KnowledgeObject preferences:theme/blue (TENANT:tenant1)
+    - .backgroundColor: blue
+
+Plan: 1 to create, 1 to update, 1 to delete, 1 to subscribe, 0 to unsubscribe (2 unchanged).
+`, b.String())
+
+	b.Reset()
+	printPlan(&b, nil, 3)
+	assert.Equal(t, "No changes: the tenant matches the declared resources (3 unchanged).\n", b.String())
+}
+
+func TestCheckPlan(t *testing.T) {
+	themes := collection{Type: "preferences:theme", LayerType: "TENANT", LayerID: "tenant1"}
+	changes := []change{
+		{Kind: kindSubscription, Resource: "spacefleet", Action: actionSubscribe},
+		newObjectChange(themes, "green", actionCreate, nil, map[string]any{"backgroundColor": "green"}),
+		newObjectChange(themes, "light", actionUpdate, map[string]any{"backgroundColor": "grey"}, map[string]any{"backgroundColor": "white"}),
+		newObjectChange(themes, "blue", actionDelete, map[string]any{"backgroundColor": "blue"}, nil),
+	}
+	state := &tenantState{
+		objects: map[collection]map[string]map[string]any{
+			themes: {
+				"light": {"backgroundColor": "grey"},
+				"blue":  {"backgroundColor": "blue"},
+			},
+		},
+		solutions: map[string]solutionState{"spacefleet": {}},
+	}
+	assert.Empty(t, checkPlan(changes, state))
+
+	state.objects[themes]["green"] = map[string]any{}
+	state.objects[themes]["light"] = map[string]any{"backgroundColor": "black"}
+	delete(state.objects[themes], "blue")
+	state.solutions["spacefleet"] = solutionState{Subscribed: true}
+	assert.Equal(t, []string{
+		"preferences:theme/blue was deleted",
+		"preferences:theme/green was created",
+		"preferences:theme/light was modified",
+		"the subscription to spacefleet has changed",
+	}, checkPlan(changes, state))
+}
@@ -0,0 +1,197 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apply
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Kinds of declared resources
+const (
+	kindObject       = "KnowledgeObject"
+	kindSubscription = "SolutionSubscription"
+)
+
+// resource is a declared resource: a knowledge object or a solution subscription
+type resource struct {
+	Kind string `yaml:"kind"`
+
+	// knowledge object
+	Type      string         `yaml:"type"`
+	LayerType string         `yaml:"layerType"`
+	LayerID   string         `yaml:"layerId"` // optional for the TENANT, SOLUTION and user layers
+	ID        string         `yaml:"id"`
+	Data      map[string]any `yaml:"data"`
+
+	// solution subscription
+	Solution   string `yaml:"solution"`
+	Subscribed *bool  `yaml:"subscribed"` // default true; false to declare that the tenant is not subscribed
+
+	source string // the file declaring the resource
+}
+
+// collection identifies the knowledge objects of a type in a layer
+type collection struct {
+	Type      string
+	LayerType string
+	LayerID   string
+}
+
+// resourceExts are the extensions of the files read from directories
+var resourceExts = map[string]bool{".yaml": true, ".yml": true, ".json": true}
+
+// readResources reads the resources declared in the files; for directories, the resource files directly
+// in them, or in their whole tree if recursive
+func readResources(paths []string, recursive bool) ([]resource, error) {
+	resources := []resource{}
+	for _, path := range paths {
+		files, err := resourceFiles(path, recursive)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			r, err := readResourceFile(file)
+			if err != nil {
+				return nil, err
+			}
+			resources = append(resources, r...)
+		}
+	}
+	return resources, nil
+}
+
+// resourceFiles returns the file, if the path is a file, or the resource files in the directory, sorted
+func resourceFiles(path string, recursive bool) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	files := []string{}
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != path && (!recursive || strings.HasPrefix(d.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if resourceExts[strings.ToLower(filepath.Ext(p))] && !strings.HasPrefix(d.Name(), ".") {
+			files = append(files, p)
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+func readResourceFile(name string) ([]resource, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	resources, err := parseResources(f, name)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return resources, nil
+}
+
+// parseResources parses the resources declared in a YAML stream (possibly with multiple documents) or JSON
+func parseResources(r io.Reader, source string) ([]resource, error) {
+	resources := []resource{}
+	decoder := yaml.NewDecoder(r)
+	decoder.KnownFields(true)
+	for {
+		var res resource
+		err := decoder.Decode(&res)
+		if errors.Is(err, io.EOF) {
+			return resources, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if res.Kind == "" && res.Type == "" && res.Solution == "" {
+			continue // empty document
+		}
+		if err := res.validate(); err != nil {
+			return nil, err
+		}
+		res.source = source
+		resources = append(resources, res)
+	}
+}
+
+func (r *resource) validate() error {
+	switch r.Kind {
+	case kindObject:
+		if r.Type == "" || r.LayerType == "" || r.ID == "" {
+			return fmt.Errorf("%s requires type, layerType and id", kindObject)
+		}
+		if r.Solution != "" || r.Subscribed != nil {
+			return fmt.Errorf("%s %s cannot have solution or subscribed", kindObject, r.name())
+		}
+		if r.Data == nil {
+			r.Data = map[string]any{}
+		}
+		// normalize the data as JSON, for comparing it with the tenant's objects
+		b, err := json.Marshal(r.Data)
+		if err != nil {
+			return fmt.Errorf("%s %s: invalid data: %w", kindObject, r.name(), err)
+		}
+		r.Data = nil
+		return json.Unmarshal(b, &r.Data)
+	case kindSubscription:
+		if r.Solution == "" {
+			return fmt.Errorf("%s requires solution", kindSubscription)
+		}
+		if r.Type != "" || r.LayerType != "" || r.LayerID != "" || r.ID != "" || r.Data != nil {
+			return fmt.Errorf("%s %s cannot have type, layerType, layerId, id or data", kindSubscription, r.Solution)
+		}
+		return nil
+	case "":
+		return errors.New("missing kind")
+	default:
+		return fmt.Errorf("unknown kind %q, expected %s or %s", r.Kind, kindObject, kindSubscription)
+	}
+}
+
+// name returns the identifier of the resource, for display
+func (r *resource) name() string {
+	if r.Kind == kindSubscription {
+		return r.Solution
+	}
+	return r.Type + "/" + r.ID
+}
+
+// subscribed returns true if the resource declares that the tenant is subscribed to the solution
+func (r *resource) subscribed() bool {
+	return r.Subscribed == nil || *r.Subscribed
+}
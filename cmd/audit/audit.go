@@ -0,0 +1,119 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package audit provides the opt-in audit trail of the commands run with fsoc, e.g., for compliance on
+// shared hosts, and the commands that configure and display it
+package audit
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+
+	"github.com/cisco-open/fsoc/cmd/config"
+	"github.com/cisco-open/fsoc/output"
+)
+
+func NewSubCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Keep and display an audit trail of the commands run with fsoc",
+		Long: `fsoc can keep an audit trail of the commands it runs, e.g., for compliance on shared jump hosts. The
+audit trail is off unless enabled with "fsoc audit enable"; it is separate from the debug log (--log).
+
+When enabled, a record is appended for each command, with its time, the user and host, the profile,
+the command, its arguments and flags, and its exit status. Values of flags and KEY=VALUE arguments that
+name secrets (e.g., --token or token=...) are redacted. The records are kept in a file per day (UTC),
+which is deleted once it is older than the retention period.
+
+Without a subcommand, this command displays whether the audit trail is on.`,
+		Example: `  fsoc audit enable
+  fsoc audit enable --dir /var/log/fsoc-audit --retention-days 365
+  fsoc audit show --since 7d
+  fsoc audit disable`,
+		Args:        cobra.NoArgs,
+		Annotations: map[string]string{config.AnnotationForConfigBypass: ""},
+		Run:         auditStatus,
+	}
+	cmd.AddCommand(newCmdEnable(), newCmdDisable(), newCmdShow())
+
+	return cmd
+}
+
+func newCmdEnable() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "enable",
+		Short: "Turn the audit trail on",
+		Long: `Turn the audit trail on, for all profiles. Use --dir to keep the audit trail in a directory other than
+the fsoc config directory, e.g., a directory collected by a log shipper, and --retention-days to set the
+number of days it is kept (0 to keep it forever).`,
+		Example: `  fsoc audit enable
+  fsoc audit enable --dir /var/log/fsoc-audit --retention-days 365`,
+		Args:        cobra.NoArgs,
+		Annotations: map[string]string{config.AnnotationForConfigBypass: ""},
+		Run:         auditEnable,
+	}
+	cmd.Flags().String("dir", "", "Directory of the audit trail (default is the audit directory in the fsoc config directory)")
+	cmd.Flags().Int("retention-days", config.DefaultAuditRetentionDays, "Number of days to keep the audit trail (0 to keep it forever)")
+
+	return cmd
+}
+
+func newCmdDisable() *cobra.Command {
+	return &cobra.Command{
+		Use:         "disable",
+		Short:       "Turn the audit trail off",
+		Long:        `Turn the audit trail off. The records already in the audit trail are kept.`,
+		Args:        cobra.NoArgs,
+		Annotations: map[string]string{config.AnnotationForConfigBypass: ""},
+		Run:         auditDisable,
+	}
+}
+
+func auditEnable(cmd *cobra.Command, args []string) {
+	dir, _ := cmd.Flags().GetString("dir")
+	retentionDays, _ := cmd.Flags().GetInt("retention-days")
+	if retentionDays < 0 {
+		_ = cmd.Usage()
+		log.Fatalf("--retention-days must not be negative")
+	}
+	if dir != "" {
+		var err error
+		if dir, err = filepath.Abs(dir); err != nil {
+			log.Fatalf("Invalid audit trail directory: %v", err)
+		}
+	}
+	config.SetAuditSettings(&config.AuditSettings{Enabled: true, Dir: dir, RetentionDays: retentionDays})
+	auditStatus(cmd, args)
+}
+
+func auditDisable(cmd *cobra.Command, args []string) {
+	config.SetAuditSettings(nil)
+	auditStatus(cmd, args)
+}
+
+func auditStatus(cmd *cobra.Command, args []string) {
+	settings := config.GetAuditSettings()
+	if !settings.Enabled {
+		output.PrintCmdStatus(cmd, "The audit trail is off.\n")
+		return
+	}
+	retention := "forever"
+	if settings.RetentionDays > 0 {
+		retention = fmt.Sprintf("for %d day(s)", settings.RetentionDays)
+	}
+	output.PrintCmdStatus(cmd, fmt.Sprintf("The audit trail is on, kept in %s %s.\n", settings.Dir, retention))
+}
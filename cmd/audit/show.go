@@ -0,0 +1,106 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+
+	"github.com/cisco-open/fsoc/cmd/config"
+	"github.com/cisco-open/fsoc/cmd/history"
+	"github.com/cisco-open/fsoc/output"
+)
+
+func newCmdShow() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "show",
+		Short: "Display the audit trail",
+		Long: `Display the records of the audit trail, oldest first. The audit trail covers all profiles and users
+that share its directory; use the flags to select the records of interest.`,
+		Example: `  fsoc audit show
+  fsoc audit show --since 7d --failed
+  fsoc audit show --command "solution push" --user alice -o json`,
+		Args:        cobra.NoArgs,
+		Annotations: map[string]string{config.AnnotationForConfigBypass: ""},
+		Run:         auditShow,
+	}
+	cmd.Flags().String("since", "", "Display records after a time: relative (e.g., 12h or 7d), a date (2023-06-06) or an RFC 3339 time")
+	cmd.Flags().String("until", "", "Display records before a time, in the same formats as --since")
+	cmd.Flags().String("command", "", "Display only the records of a command and its subcommands, e.g., \"solution\"")
+	cmd.Flags().String("user", "", "Display only the records of a user")
+	cmd.Flags().Bool("failed", false, "Display only the records of commands that failed")
+
+	return cmd
+}
+
+func auditShow(cmd *cobra.Command, args []string) {
+	command, _ := cmd.Flags().GetString("command")
+	user, _ := cmd.Flags().GetString("user")
+	failed, _ := cmd.Flags().GetBool("failed")
+	now := time.Now()
+	since, err := history.ParseTime(cmd, "since", now)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	until, err := history.ParseTime(cmd, "until", now)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	settings := config.GetAuditSettings()
+	if !settings.Enabled {
+		log.Warn("The audit trail is off; use \"fsoc audit enable\" to turn it on")
+	}
+	records, err := ReadRecords(settings.Dir)
+	if err != nil {
+		log.Fatalf("Failed to read the audit trail from %q: %v", settings.Dir, err)
+	}
+
+	selected := []Record{}
+	var lines [][]string
+	for _, r := range records {
+		if command != "" && r.Command != command && !strings.HasPrefix(r.Command, command+" ") {
+			continue
+		}
+		if user != "" && r.User != user || failed && r.ExitCode == 0 {
+			continue
+		}
+		if !since.IsZero() && r.Time.Before(since) || !until.IsZero() && r.Time.After(until) {
+			continue
+		}
+		selected = append(selected, r)
+		lines = append(lines, []string{
+			r.Time.Local().Format("2006-01-02 15:04:05"),
+			r.User,
+			r.Profile,
+			strings.TrimSpace(r.Command + " " + strings.Join(r.Args, " ")),
+			strconv.Itoa(r.ExitCode),
+			fmt.Sprintf("%.1fs", r.Duration),
+		})
+	}
+
+	output.PrintCmdOutputCustom(cmd, struct {
+		Items []Record `json:"items"`
+		Total int      `json:"total"`
+	}{Items: selected, Total: len(selected)}, &output.Table{
+		Headers: []string{"Time", "User", "Profile", "Command", "Exit Code", "Duration"},
+		Lines:   lines,
+	})
+}
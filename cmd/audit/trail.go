@@ -0,0 +1,297 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/cisco-open/fsoc/cmd/config"
+	"github.com/cisco-open/fsoc/exitcode"
+)
+
+// The audit trail is kept as JSON lines in a file per day (UTC), named audit-YYYY-MM-DD.jsonl. Records
+// are only appended; files are deleted as a whole once they are older than the retention period.
+const (
+	filePrefix = "audit-"
+	fileSuffix = ".jsonl"
+	dateLayout = "2006-01-02"
+)
+
+// SuppressEnvVar is the environment variable that, when set, disables recording commands, e.g., for
+// the steps run by a batch, which is recorded as a single command
+const SuppressEnvVar = "FSOC_AUDIT_SUPPRESS"
+
+// redacted replaces the values of secrets in the recorded arguments
+const redacted = "REDACTED"
+
+// secretNameRegexp matches the names of flags and KEY=VALUE arguments whose values are secrets
+var secretNameRegexp = regexp.MustCompile(`(?i)token|secret|password|passwd|credential|api[-_.]?key|private[-_.]?key|authorization|cookie`)
+
+// Record is a command recorded in the audit trail
+type Record struct {
+	Time     time.Time `json:"time"`
+	User     string    `json:"user,omitempty"`
+	Host     string    `json:"host,omitempty"`
+	Profile  string    `json:"profile"`
+	Command  string    `json:"command"`
+	Args     []string  `json:"args"` // flags given on the command line and positional arguments, secrets redacted
+	ExitCode int       `json:"exit_code"`
+	Error    string    `json:"error,omitempty"`
+	Duration float64   `json:"duration"` // in seconds
+}
+
+// current is the command being audited, between Start and Finish
+var current struct {
+	sync.Mutex
+	settings config.AuditSettings
+	record   *Record
+	started  time.Time
+}
+
+// Start begins auditing a command, if the audit trail is enabled; it should be called before the command
+// runs, once the profile is known
+func Start(cmd *cobra.Command, args []string) {
+	settings := config.GetAuditSettings()
+	if !settings.Enabled || os.Getenv(SuppressEnvVar) != "" {
+		return
+	}
+
+	record := &Record{
+		Profile: config.GetCurrentProfileName(),
+		Command: strings.TrimPrefix(strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()), " "),
+		Args:    commandLine(cmd.Flags(), args),
+	}
+	if u, err := user.Current(); err == nil {
+		record.User = u.Username
+	}
+	record.Host, _ = os.Hostname()
+
+	current.Lock()
+	defer current.Unlock()
+	current.settings = settings
+	current.record = record
+	current.started = time.Now()
+}
+
+// Finish records the command started with Start, with the exit status for the error, if any
+func Finish(err error) {
+	msg := ""
+	if err != nil {
+		msg = err.Error()
+	}
+	finish(msg, exitcode.Of(err))
+}
+
+// Handler returns a log handler that records the command when it fails with a fatal error (which exits
+// without returning through Finish)
+func Handler() log.Handler {
+	return log.HandlerFunc(func(e *log.Entry) error {
+		if e.Level == log.FatalLevel {
+			finish(e.Message, exitcode.ForEntry(e))
+		}
+		return nil
+	})
+}
+
+func finish(errorMessage string, code int) {
+	current.Lock()
+	defer current.Unlock()
+
+	if current.record == nil {
+		return
+	}
+	record := current.record
+	current.record = nil
+	record.Time = current.started.UTC()
+	record.Duration = time.Since(current.started).Seconds()
+	record.ExitCode = code
+	record.Error = errorMessage
+
+	if err := appendRecord(current.settings, record); err != nil {
+		log.Warnf("Failed to record the command in the audit trail: %v", err)
+	}
+	if err := prune(current.settings.Dir, current.settings.RetentionDays, time.Now()); err != nil {
+		log.Warnf("Failed to delete expired audit trail files: %v", err)
+	}
+}
+
+// commandLine returns the flags given on the command line and the positional arguments, with the
+// values of secrets redacted
+func commandLine(flags *pflag.FlagSet, args []string) []string {
+	line := []string{}
+	flags.VisitAll(func(f *pflag.Flag) {
+		if !f.Changed { // Visit misses the global flags parsed before the command, i.e., by the root command
+			return
+		}
+		values := []string{f.Value.String()}
+		if slice, ok := f.Value.(pflag.SliceValue); ok { // one flag per value, as given, e.g., --header
+			values = slice.GetSlice()
+		}
+		for _, v := range values {
+			line = append(line, "--"+f.Name+"="+redactValue(f.Name, v))
+		}
+	})
+	// arguments may contain unparsed flags, e.g., of plugins, whose values follow them
+	secretFlag := false
+	for _, arg := range args {
+		if secretFlag {
+			line = append(line, redacted)
+		} else {
+			line = append(line, redactArg(arg))
+		}
+		secretFlag = strings.HasPrefix(arg, "-") && !strings.Contains(arg, "=") && secretNameRegexp.MatchString(arg)
+	}
+	return line
+}
+
+// redactValue returns the value of a named flag, redacted if the flag is a secret; values of other
+// flags are redacted as arguments, e.g., "Authorization=Bearer x" in a --header flag
+func redactValue(name string, value string) string {
+	if secretNameRegexp.MatchString(name) && value != "" {
+		return redacted
+	}
+	return redactArg(value)
+}
+
+// redactArg redacts the value of a KEY=VALUE argument if the key names a secret, e.g., "token=x"
+func redactArg(arg string) string {
+	key, value, found := strings.Cut(arg, "=")
+	if found && value != "" && secretNameRegexp.MatchString(key) {
+		return key + "=" + redacted
+	}
+	return arg
+}
+
+func fileName(t time.Time) string {
+	return filePrefix + t.UTC().Format(dateLayout) + fileSuffix
+}
+
+// appendRecord appends a record to the day's file; each record is a single write, so that records of
+// concurrent commands are not interleaved
+func appendRecord(settings config.AuditSettings, record *Record) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(settings.Dir, 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(filepath.Join(settings.Dir, fileName(record.Time)), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// auditFiles returns the paths of the audit trail files in the directory with their dates, oldest first
+func auditFiles(dir string) ([]string, []time.Time, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil, nil
+		}
+		return nil, nil, err
+	}
+	var names []string
+	var dates []time.Time
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, filePrefix) || !strings.HasSuffix(name, fileSuffix) {
+			continue
+		}
+		date, err := time.Parse(dateLayout, strings.TrimSuffix(strings.TrimPrefix(name, filePrefix), fileSuffix))
+		if err != nil {
+			continue
+		}
+		names = append(names, filepath.Join(dir, name))
+		dates = append(dates, date)
+	}
+	return names, dates, nil // os.ReadDir sorts by name, i.e., by date
+}
+
+// prune deletes the files with records older than the retention period; 0 days keeps all files
+func prune(dir string, retentionDays int, now time.Time) error {
+	if retentionDays <= 0 {
+		return nil
+	}
+	names, dates, err := auditFiles(dir)
+	if err != nil {
+		return err
+	}
+	today := now.UTC().Truncate(24 * time.Hour)
+	cutoff := today.AddDate(0, 0, -retentionDays)
+	for i, name := range names {
+		if dates[i].Before(cutoff) {
+			if err := os.Remove(name); err != nil {
+				return err
+			}
+			log.WithField("file", name).Info("Deleted expired audit trail file")
+		}
+	}
+	return nil
+}
+
+// ReadRecords returns the records in the audit trail directory, oldest first
+func ReadRecords(dir string) ([]Record, error) {
+	names, _, err := auditFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	records := []Record{}
+	for _, name := range names {
+		if records, err = readFile(name, records); err != nil {
+			return nil, fmt.Errorf("failed to read %q: %w", name, err)
+		}
+	}
+	return records, nil
+}
+
+func readFile(name string, records []Record) ([]Record, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			log.Warnf("Skipping invalid record in the audit trail file %q: %v", name, err)
+			continue
+		}
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
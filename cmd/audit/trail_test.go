@@ -0,0 +1,83 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cisco-open/fsoc/exitcode"
+)
+
+func TestCommandLineRedactsSecrets(t *testing.T) {
+	cmd := &cobra.Command{Use: "set"}
+	cmd.Flags().String("token", "", "")
+	cmd.Flags().String("tenant", "", "")
+	cmd.Flags().StringArray("header", nil, "")
+	require.NoError(t, cmd.Flags().Parse([]string{"--token", "top-secret", "--tenant=t1", "--header", "Authorization=Bearer x"}))
+
+	line := commandLine(cmd.Flags(), []string{"url=https://x", "refresh_token=abc", "--client-secret", "s3", "--api-key=k", "query"})
+	assert.ElementsMatch(t, []string{"--token=REDACTED", "--tenant=t1", "--header=Authorization=REDACTED"}, line[:3])
+	assert.Equal(t, []string{"url=https://x", "refresh_token=REDACTED", "--client-secret", "REDACTED", "--api-key=REDACTED", "query"}, line[3:])
+}
+
+func TestRecordAndPrune(t *testing.T) {
+	dir := t.TempDir()
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set("audit.enabled", true)
+	viper.Set("audit.dir", dir)
+	viper.Set("audit.retention_days", 30)
+
+	root := &cobra.Command{Use: "fsoc"}
+	cmd := &cobra.Command{Use: "list"}
+	parent := &cobra.Command{Use: "solution"}
+	parent.AddCommand(cmd)
+	root.AddCommand(parent)
+
+	Start(cmd, []string{"a"})
+	Finish(nil)
+	Start(cmd, nil)
+	Finish(&exitcode.Error{Code: exitcode.NotFound, Err: errors.New("not found")})
+	Finish(nil) // not started, nothing recorded
+
+	records, err := ReadRecords(dir)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, "solution list", records[0].Command)
+	assert.Equal(t, []string{"a"}, records[0].Args)
+	assert.Equal(t, 0, records[0].ExitCode)
+	assert.Equal(t, exitcode.NotFound, records[1].ExitCode)
+	assert.Equal(t, "not found", records[1].Error)
+
+	// files older than the retention period are deleted
+	now := time.Now()
+	old := filepath.Join(dir, fileName(now.AddDate(0, 0, -31)))
+	recent := filepath.Join(dir, fileName(now.AddDate(0, 0, -29)))
+	require.NoError(t, os.WriteFile(old, nil, 0600))
+	require.NoError(t, os.WriteFile(recent, nil, 0600))
+	require.NoError(t, prune(dir, 30, now))
+	assert.NoFileExists(t, old)
+	assert.FileExists(t, recent)
+	assert.FileExists(t, filepath.Join(dir, fileName(now)))
+}
@@ -0,0 +1,40 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package auth provides commands that let external tools reuse fsoc's authentication with the platform
+package auth
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func NewSubCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "auth",
+		Short: "Share fsoc's authentication with other tools",
+		Long: `Provide the access token of the current profile to other tools, e.g., curl, Postman or scripts
+calling the platform APIs directly, or tools supporting the Kubernetes credential plugin protocol, so that
+they don't need a login of their own.
+
+The token is obtained with the profile's authentication method; if it is missing or about to expire,
+fsoc logs in first (refreshing the token when possible), just like for its own API calls.`,
+		Example:          `  curl -H "$(fsoc auth print-token --header -q)" https://MYTENANT.observe.appdynamics.com/knowledge-store/v1/types`,
+		TraverseChildren: true,
+	}
+
+	cmd.AddCommand(newCmdPrintToken())
+	cmd.AddCommand(newCmdExecCredential())
+
+	return cmd
+}
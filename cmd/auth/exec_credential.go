@@ -0,0 +1,156 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+
+	"github.com/cisco-open/fsoc/cmdkit/picker"
+	"github.com/cisco-open/fsoc/exitcode"
+	"github.com/cisco-open/fsoc/output"
+	"github.com/cisco-open/fsoc/platform/api"
+)
+
+// execInfoEnvVar is the environment variable in which a client passes the ExecCredential request
+// to a credential plugin
+const execInfoEnvVar = "KUBERNETES_EXEC_INFO"
+
+// execCredentialGroup is the API group of the client authentication protocol
+const execCredentialGroup = "client.authentication.k8s.io"
+
+// execCredentialVersions are the supported API versions of the ExecCredential object
+var execCredentialVersions = []string{execCredentialGroup + "/v1", execCredentialGroup + "/v1beta1"}
+
+// execCredential is the ExecCredential object of the client authentication protocol, both as the
+// request passed to the plugin and as the response it prints
+type execCredential struct {
+	APIVersion string                `json:"apiVersion"`
+	Kind       string                `json:"kind"`
+	Spec       execCredentialSpec    `json:"spec"`
+	Status     *execCredentialStatus `json:"status,omitempty"`
+}
+
+type execCredentialSpec struct {
+	Interactive bool `json:"interactive,omitempty"`
+}
+
+type execCredentialStatus struct {
+	Token               string `json:"token"`
+	ExpirationTimestamp string `json:"expirationTimestamp,omitempty"`
+}
+
+func newCmdExecCredential() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "exec-credential",
+		Short: "Act as a client-go credential plugin",
+		Long: `Print the access token of the current profile as an ExecCredential object of the Kubernetes client
+authentication protocol (client.authentication.k8s.io), so that fsoc can serve as the credential plugin
+of tools that support the protocol, e.g., in the "exec" section of a kubeconfig user.
+
+The request passed by the tool in the KUBERNETES_EXEC_INFO environment variable selects the API version
+of the response and whether fsoc may log in interactively, with a browser, if the token can't be refreshed.
+Without it, the API version is given by --api-version and an interactive login is allowed only when running
+on a terminal. The token's expiration time is included, so that the tool can cache it until it expires.`,
+		Example: `  fsoc auth exec-credential
+  fsoc auth exec-credential --profile prod --api-version client.authentication.k8s.io/v1beta1
+
+  # kubeconfig user entry
+  users:
+  - name: fsoc
+    user:
+      exec:
+        apiVersion: client.authentication.k8s.io/v1
+        command: fsoc
+        args: [auth, exec-credential, --profile, prod]
+        interactiveMode: IfAvailable`,
+		Args: cobra.NoArgs,
+		Run:  printExecCredential,
+	}
+
+	cmd.Flags().String("api-version", execCredentialVersions[0], "API version of the ExecCredential object, when not requested in "+execInfoEnvVar)
+	cmd.Flags().Duration("min-validity", defaultMinValidity, "Minimum remaining validity of the token; tokens expiring sooner are refreshed first")
+
+	return cmd
+}
+
+func printExecCredential(cmd *cobra.Command, args []string) {
+	apiVersion, _ := cmd.Flags().GetString("api-version")
+	minValidity, _ := cmd.Flags().GetDuration("min-validity")
+
+	interactive := picker.Interactive(cmd)
+	if info := os.Getenv(execInfoEnvVar); info != "" {
+		request, err := parseExecInfo(info)
+		if err != nil {
+			log.WithField(exitcode.Field, exitcode.Usage).Fatalf("Invalid %s: %v", execInfoEnvVar, err)
+		}
+		apiVersion, interactive = request.APIVersion, request.Spec.Interactive
+	}
+	if !isSupportedVersion(apiVersion) {
+		log.WithField(exitcode.Field, exitcode.Usage).Fatalf("Unsupported ExecCredential API version %q, must be one of %q", apiVersion, execCredentialVersions)
+	}
+
+	token, expires, err := api.CurrentToken(minValidity, interactive)
+	if err != nil {
+		code := exitcode.Of(err)
+		if code == exitcode.General {
+			code = exitcode.Auth
+		}
+		log.WithField(exitcode.Field, code).Fatalf("Failed to obtain an access token: %v", err)
+	}
+
+	// the protocol requires the JSON object on stdout, regardless of the output format
+	data, err := json.MarshalIndent(newExecCredential(apiVersion, token, expires), "", "  ")
+	if err != nil {
+		log.Fatalf("bug: failed to encode the ExecCredential: %v", err)
+	}
+	output.PrintCmdStatus(cmd, string(data)+"\n")
+}
+
+// parseExecInfo parses the ExecCredential request passed to the plugin
+func parseExecInfo(info string) (*execCredential, error) {
+	var request execCredential
+	if err := json.Unmarshal([]byte(info), &request); err != nil {
+		return nil, err
+	}
+	if request.Kind != "ExecCredential" {
+		return nil, fmt.Errorf("unexpected kind %q, expected \"ExecCredential\"", request.Kind)
+	}
+	return &request, nil
+}
+
+func isSupportedVersion(apiVersion string) bool {
+	for _, v := range execCredentialVersions {
+		if v == apiVersion {
+			return true
+		}
+	}
+	return false
+}
+
+// newExecCredential returns the ExecCredential response with the token; a zero expiration time
+// (unknown) is omitted, which lets the client use the token until it is rejected
+func newExecCredential(apiVersion string, token string, expires time.Time) *execCredential {
+	status := &execCredentialStatus{Token: token}
+	if !expires.IsZero() {
+		status.ExpirationTimestamp = expires.UTC().Format(time.RFC3339)
+	}
+	return &execCredential{APIVersion: apiVersion, Kind: "ExecCredential", Status: status}
+}
@@ -0,0 +1,54 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseExecInfo(t *testing.T) {
+	request, err := parseExecInfo(`{"apiVersion":"client.authentication.k8s.io/v1beta1","kind":"ExecCredential","spec":{"interactive":true}}`)
+	require.NoError(t, err)
+	assert.Equal(t, "client.authentication.k8s.io/v1beta1", request.APIVersion)
+	assert.True(t, request.Spec.Interactive)
+	assert.True(t, isSupportedVersion(request.APIVersion))
+
+	_, err = parseExecInfo(`{"apiVersion":"v1","kind":"Pod"}`)
+	assert.Error(t, err)
+	_, err = parseExecInfo(`not json`)
+	assert.Error(t, err)
+	assert.False(t, isSupportedVersion("client.authentication.k8s.io/v1alpha1"))
+}
+
+func TestNewExecCredential(t *testing.T) {
+	expires := time.Date(2023, 6, 1, 12, 0, 0, 0, time.FixedZone("CEST", 2*3600))
+	data, err := json.Marshal(newExecCredential("client.authentication.k8s.io/v1", "tkn", expires))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"apiVersion": "client.authentication.k8s.io/v1",
+		"kind": "ExecCredential",
+		"spec": {},
+		"status": {"token": "tkn", "expirationTimestamp": "2023-06-01T10:00:00Z"}
+	}`, string(data))
+
+	data, err = json.Marshal(newExecCredential("client.authentication.k8s.io/v1", "tkn", time.Time{}))
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "expirationTimestamp")
+}
@@ -0,0 +1,99 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+
+	"github.com/cisco-open/fsoc/cmd/config"
+	"github.com/cisco-open/fsoc/exitcode"
+	"github.com/cisco-open/fsoc/output"
+	"github.com/cisco-open/fsoc/platform/api"
+)
+
+// defaultMinValidity is how long the printed token must remain valid, unless specified otherwise;
+// tokens expiring sooner are refreshed first
+const defaultMinValidity = time.Minute
+
+// tokenInfo describes the access token, as displayed in machine formats
+type tokenInfo struct {
+	Profile   string     `json:"profile"`
+	Type      string     `json:"type"`
+	Token     string     `json:"token"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+func newCmdPrintToken() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "print-token",
+		Short: "Print the access token of the current profile",
+		Long: `Print the bearer access token of the current profile, or a complete Authorization header with --header,
+for use by other tools. If the token is missing or expires within --min-validity, fsoc logs in first.
+
+The token grants the principal's permissions to anyone who has it: don't store it in files or share it.
+A warning to this effect is displayed on the standard error unless --quiet is specified, so that scripts
+can capture the token alone from the standard output. With -o json or -o yaml, the token is displayed
+together with its type and expiration time.`,
+		Example: `  fsoc auth print-token
+  curl -H "$(fsoc auth print-token --header -q)" "$URL/knowledge-store/v1/types"
+  TOKEN=$(fsoc auth print-token -q --min-validity 10m)
+  fsoc auth print-token -o json`,
+		Args: cobra.NoArgs,
+		Run:  printToken,
+	}
+
+	cmd.Flags().Bool("header", false, "Print a complete HTTP header, \"Authorization: Bearer TOKEN\"")
+	cmd.Flags().Duration("min-validity", defaultMinValidity, "Minimum remaining validity of the token; tokens expiring sooner are refreshed first")
+
+	return cmd
+}
+
+func printToken(cmd *cobra.Command, args []string) {
+	header, _ := cmd.Flags().GetBool("header")
+	minValidity, _ := cmd.Flags().GetDuration("min-validity")
+
+	token, expires, err := api.CurrentToken(minValidity, true)
+	if err != nil {
+		code := exitcode.Of(err)
+		if code == exitcode.General {
+			code = exitcode.Auth
+		}
+		log.WithField(exitcode.Field, code).Fatalf("Failed to obtain an access token: %v", err)
+	}
+	log.Warn("The access token grants your permissions to anyone who has it; don't store it in files or share it")
+	if !expires.IsZero() {
+		log.Infof("The access token expires at %v", expires.Local().Format(time.RFC3339))
+	}
+
+	format, _ := cmd.Flags().GetString("output")
+	switch format {
+	case "", "auto", "table", "detail", "csv":
+		if header {
+			output.PrintCmdData(cmd, fmt.Sprintf("Authorization: Bearer %s\n", token))
+		} else {
+			output.PrintCmdData(cmd, token+"\n")
+		}
+	default:
+		info := tokenInfo{Profile: config.GetCurrentProfileName(), Type: "Bearer", Token: token}
+		if !expires.IsZero() {
+			info.ExpiresAt = &expires
+		}
+		output.PrintCmdOutput(cmd, info)
+	}
+}
@@ -0,0 +1,220 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package batch provides the command that executes a sequence of fsoc commands described in a file
+package batch
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/cisco-open/fsoc/cmd/audit"
+	"github.com/cisco-open/fsoc/cmd/config"
+	"github.com/cisco-open/fsoc/exitcode"
+	"github.com/cisco-open/fsoc/output"
+)
+
+// Step statuses in the summary
+const (
+	statusOK      = "ok"
+	statusFailed  = "failed"
+	statusIgnored = "failed (ignored)" // failed, continuing with the next step
+	statusSkipped = "skipped"          // not executed because an earlier step failed
+)
+
+// result is the outcome of a step, for the summary
+type result struct {
+	Step     string  `json:"step"`
+	Command  string  `json:"command"`
+	Status   string  `json:"status"`
+	ExitCode int     `json:"exitCode"`
+	Duration float64 `json:"duration"` // in seconds
+}
+
+func NewSubCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "batch -f FILE",
+		Short: "Execute a sequence of fsoc commands from a file",
+		Long: `Execute the fsoc commands described in a YAML batch file, in order, displaying a summary of the steps at
+the end, e.g., for multi-step provisioning.
+
+Each step runs an fsoc command (without "fsoc"), given as a command line string or as a list of arguments,
+with the profile and config file of the batch unless the command specifies others. A step can continue
+with the next ones when it fails (continueOnError) and can capture its output into a variable (capture),
+instead of displaying it. The command arguments can reference variables as ${NAME}: the ones defined in
+the file's vars (or with --var) and the captured outputs of the earlier steps. Use $${NAME} for a literal
+${NAME}.
+
+The batch stops at the first step that fails without continueOnError, with the step's exit code. When the
+audit trail is enabled, the batch is recorded as a single command.
+
+Batch file example:
+
+  vars:
+    solution: spacefleet
+  steps:
+    - name: subscribe
+      command: solution subscribe --name ${solution}
+      continueOnError: true
+    - name: find ship
+      command: [knowledge, get, --type, "${solution}:ship", --layer-type, TENANT, --fields, ".items[0].id", -o, json]
+      capture: ship
+    - command: knowledge get --type ${solution}:ship --layer-type TENANT --object ${ship}`,
+		Example: `  fsoc batch -f provision.yaml
+  fsoc batch -f provision.yaml --var solution=spacefleet-dev
+  generate-steps | fsoc batch -f -`,
+		Args:        cobra.NoArgs,
+		Run:         runBatch,
+		Annotations: map[string]string{config.AnnotationForConfigBypass: ""},
+	}
+
+	cmd.Flags().StringP("file", "f", "", "Batch file to execute (- for stdin)")
+	_ = cmd.MarkFlagRequired("file")
+	cmd.Flags().StringArray("var", nil, "Set a variable, as NAME=VALUE, overriding the file's vars (can be repeated)")
+
+	return cmd
+}
+
+func runBatch(cmd *cobra.Command, args []string) {
+	name, _ := cmd.Flags().GetString("file")
+	file, err := readFile(name)
+	if err != nil {
+		log.WithField(exitcode.Field, exitcode.Usage).Fatalf("Failed to read the batch file %q: %v", name, err)
+	}
+	vars := map[string]string{}
+	for k, v := range file.Vars {
+		vars[k] = v
+	}
+	settings, _ := cmd.Flags().GetStringArray("var")
+	for _, setting := range settings {
+		k, v, found := strings.Cut(setting, "=")
+		if !found || !variableNameRegexp.MatchString(k) {
+			log.WithField(exitcode.Field, exitcode.Usage).Fatalf("Invalid variable %q, expected NAME=VALUE", setting)
+		}
+		vars[k] = v
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		log.Fatalf("Failed to locate the fsoc executable: %v", err)
+	}
+	global := []string{"--profile", config.GetCurrentProfileName()}
+	if cfgFile := viper.ConfigFileUsed(); cfgFile != "" {
+		global = append(global, "--config", cfgFile)
+	}
+
+	// the steps receive Ctrl+C too (as part of the process group); the batch stops after the current step
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	defer signal.Stop(signals)
+
+	results := make([]result, len(file.Steps))
+	var stopErr error
+	for i, step := range file.Steps {
+		results[i] = result{Step: step.Name, Command: strings.Join(step.Command, " "), Status: statusSkipped}
+		if stopErr != nil {
+			continue
+		}
+		started := time.Now()
+		args, err := step.Command.expand(vars)
+		if err == nil {
+			results[i].Command = strings.Join(args, " ")
+			err = runStep(cmd, exe, append(global, args...), step.Capture, vars, name == "-")
+		} else {
+			err = &exitcode.Error{Code: exitcode.Usage, Err: err}
+		}
+		results[i].Duration = time.Since(started).Seconds()
+		results[i].ExitCode = exitcode.Of(err)
+		switch {
+		case err == nil:
+			results[i].Status = statusOK
+		case step.ContinueOnError:
+			results[i].Status = statusIgnored
+			log.Warnf("Step %q failed, continuing: %v", step.Name, err)
+		default:
+			results[i].Status = statusFailed
+			stopErr = fmt.Errorf("step %q failed: %w", step.Name, err)
+		}
+		select {
+		case <-signals:
+			if stopErr == nil {
+				stopErr = &exitcode.Error{Code: exitcode.General, Err: errors.New("interrupted")}
+			}
+		default:
+		}
+	}
+
+	lines := make([][]string, len(results))
+	for i, r := range results {
+		lines[i] = []string{r.Step, r.Status, strconv.Itoa(r.ExitCode), fmt.Sprintf("%.1fs", r.Duration), r.Command}
+	}
+	output.PrintCmdOutputCustom(cmd, struct {
+		Items []result `json:"items"`
+		Total int      `json:"total"`
+	}{Items: results, Total: len(results)}, &output.Table{
+		Headers: []string{"Step", "Status", "Exit Code", "Duration", "Command"},
+		Lines:   lines,
+	})
+
+	if stopErr != nil {
+		log.WithField(exitcode.Field, exitcode.Of(stopErr)).Fatalf("Batch stopped: %v", stopErr)
+	}
+}
+
+// runStep executes an fsoc command, capturing its output into the variable, if any; the command reads
+// the standard input, unless the batch file was read from it
+func runStep(cmd *cobra.Command, exe string, args []string, capture string, vars map[string]string, stdinUsed bool) error {
+	log.WithField("args", args).Info("Running batch step")
+
+	c := exec.CommandContext(cmd.Context(), exe, args...)
+	if !stdinUsed {
+		c.Stdin = os.Stdin
+	}
+	var captured bytes.Buffer
+	if capture != "" {
+		c.Stdout = &captured
+	} else {
+		c.Stdout = cmd.OutOrStdout()
+	}
+	c.Stderr = os.Stderr
+	c.Env = append(os.Environ(), audit.SuppressEnvVar+"=1")
+
+	err := c.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		code := exitErr.ExitCode()
+		if code <= 0 { // terminated by a signal
+			code = exitcode.General
+		}
+		return &exitcode.Error{Code: code, Err: err}
+	}
+	if err != nil {
+		return err
+	}
+	if capture != "" {
+		vars[capture] = strings.TrimRight(captured.String(), "\r\n")
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package batch
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// File is a batch file: the steps to execute, in order, with the initial values of the variables
+type File struct {
+	Vars  map[string]string `yaml:"vars"`
+	Steps []Step            `yaml:"steps"`
+}
+
+// Step is an fsoc command executed by the batch
+type Step struct {
+	Name            string  `yaml:"name"`
+	Command         Command `yaml:"command"`
+	ContinueOnError bool    `yaml:"continueOnError"` // continue with the next step if this one fails
+	Capture         string  `yaml:"capture"`         // variable set to the command's output, instead of displaying it
+}
+
+// Command is the arguments of an fsoc command, without "fsoc"; in the file, it is either a list of
+// arguments or a string that is split into arguments like a shell would (quotes and backslashes)
+type Command []string
+
+// UnmarshalYAML accepts the command as a list of arguments or as a command line string
+func (c *Command) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		args, err := SplitCommand(node.Value)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", node.Line, err)
+		}
+		*c = args
+		return nil
+	}
+	var args []string
+	if err := node.Decode(&args); err != nil {
+		return err
+	}
+	*c = args
+	return nil
+}
+
+// variableNameRegexp matches the names of the batch variables
+var variableNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// variableReferenceRegexp matches references to variables in the command arguments, ${NAME}, as well as
+// escaped references, $${NAME}, which stand for the literal text ${NAME}
+var variableReferenceRegexp = regexp.MustCompile(`\$(\$?)\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// readFile reads and validates a batch file; name "-" is the standard input
+func readFile(name string) (*File, error) {
+	var r io.Reader = os.Stdin
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	return parseFile(r)
+}
+
+func parseFile(r io.Reader) (*File, error) {
+	var file File
+	decoder := yaml.NewDecoder(r)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("the batch file has no steps")
+		}
+		return nil, err
+	}
+	if err := file.validate(); err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+// validate checks the steps and variable names, naming the steps that have no name after their position
+func (f *File) validate() error {
+	if len(f.Steps) == 0 {
+		return errors.New("the batch file has no steps")
+	}
+	for name := range f.Vars {
+		if !variableNameRegexp.MatchString(name) {
+			return fmt.Errorf("invalid variable name %q", name)
+		}
+	}
+	names := map[string]bool{}
+	for i := range f.Steps {
+		step := &f.Steps[i]
+		if step.Name == "" {
+			step.Name = fmt.Sprintf("step %d", i+1)
+		}
+		if names[step.Name] {
+			return fmt.Errorf("duplicate step name %q", step.Name)
+		}
+		names[step.Name] = true
+		if len(step.Command) == 0 {
+			return fmt.Errorf("%s: missing command", step.Name)
+		}
+		if step.Command[0] == "batch" {
+			return fmt.Errorf("%s: batches cannot run other batches", step.Name)
+		}
+		if step.Capture != "" && !variableNameRegexp.MatchString(step.Capture) {
+			return fmt.Errorf("%s: invalid variable name %q to capture the output", step.Name, step.Capture)
+		}
+	}
+	return nil
+}
+
+// expand replaces the references to variables in the command arguments with the variables' values,
+// failing if any of the referenced variables is not defined
+func (c Command) expand(vars map[string]string) ([]string, error) {
+	var missing []string
+	args := make([]string, len(c))
+	for i, arg := range c {
+		args[i] = variableReferenceRegexp.ReplaceAllStringFunc(arg, func(ref string) string {
+			groups := variableReferenceRegexp.FindStringSubmatch(ref)
+			if groups[1] != "" { // escaped
+				return ref[1:]
+			}
+			v, found := vars[groups[2]]
+			if !found {
+				missing = append(missing, groups[2])
+			}
+			return v
+		})
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("variable(s) not defined: %s", strings.Join(missing, ", "))
+	}
+	return args, nil
+}
+
+// SplitCommand splits a command line into arguments at unquoted whitespace, removing the quotes; single
+// quotes keep the text literally, while backslashes escape the next character outside of them
+func SplitCommand(line string) ([]string, error) {
+	args := []string{}
+	var arg strings.Builder
+	inArg := false
+	var quote rune
+	escaped := false
+	for _, r := range line {
+		switch {
+		case escaped:
+			arg.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				arg.WriteRune(r)
+			}
+		case r == '\\':
+			escaped = true
+			inArg = true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				arg.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in %q", line)
+	}
+	if escaped {
+		return nil, fmt.Errorf("trailing backslash in %q", line)
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args, nil
+}
@@ -0,0 +1,81 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package batch
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitCommand(t *testing.T) {
+	args, err := SplitCommand(`knowledge get --type "preferences:theme" --filter 'data.name eq "dark"' a\ b`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"knowledge", "get", "--type", "preferences:theme", "--filter", `data.name eq "dark"`, "a b"}, args)
+
+	args, err = SplitCommand(`  uql "fetch \"x\""  ''`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"uql", `fetch "x"`, ""}, args)
+
+	_, err = SplitCommand(`uql "fetch`)
+	assert.Error(t, err)
+	_, err = SplitCommand(`uql \`)
+	assert.Error(t, err)
+}
+
+func TestParseFile(t *testing.T) {
+	file, err := parseFile(strings.NewReader(`
+vars:
+  solution: spacefleet
+steps:
+  - name: subscribe
+    command: solution subscribe --name ${solution}
+    continueOnError: true
+  - command: [knowledge, get, --type, "${solution}:ship"]
+    capture: ships
+`))
+	require.NoError(t, err)
+	require.Len(t, file.Steps, 2)
+	assert.Equal(t, Command{"solution", "subscribe", "--name", "${solution}"}, file.Steps[0].Command)
+	assert.True(t, file.Steps[0].ContinueOnError)
+	assert.Equal(t, "step 2", file.Steps[1].Name)
+	assert.Equal(t, "ships", file.Steps[1].Capture)
+
+	for _, invalid := range []string{
+		``,
+		`steps: []`,
+		`steps: [{command: version, retries: 3}]`,
+		`steps: [{name: a, command: version}, {name: a, command: version}]`,
+		`steps: [{command: ""}]`,
+		`steps: [{command: batch -f other.yaml}]`,
+		`steps: [{command: version, capture: "not valid"}]`,
+		`{vars: {"a-b": x}, steps: [{command: version}]}`,
+	} {
+		_, err := parseFile(strings.NewReader(invalid))
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestExpand(t *testing.T) {
+	vars := map[string]string{"solution": "spacefleet", "id": "1"}
+	args, err := Command{"--type", "${solution}:ship", "--object=${id}", "$${id}"}.expand(vars)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"--type", "spacefleet:ship", "--object=1", "${id}"}, args)
+
+	_, err = Command{"${missing}"}.expand(vars)
+	assert.ErrorContains(t, err, "missing")
+}
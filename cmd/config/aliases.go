@@ -0,0 +1,85 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/apex/log"
+	"github.com/spf13/viper"
+)
+
+// GetAliases returns the command aliases defined in the config file, by name
+func GetAliases() map[string]string {
+	return viper.GetStringMapString("aliases")
+}
+
+// ReadAliases reads the command aliases from the config file at path (or the default config file if
+// path is empty) before the config is loaded, e.g., to expand an alias into the command to execute.
+// A config file that doesn't exist has no aliases.
+func ReadAliases(path string) (map[string]string, error) {
+	if path == "" {
+		path = DefaultConfigFilePath()
+	}
+	path = expandHomePath(path)
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return map[string]string{}, nil
+	}
+	v := viper.New()
+	v.SetConfigFile(path)
+	v.SetConfigType("yaml")
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("failed to read config file %q: %w", path, err)
+	}
+	return v.GetStringMapString("aliases"), nil
+}
+
+// SetAlias defines the named command alias in the config file, replacing its previous definition, if any
+func SetAlias(name string, definition string) {
+	modifyConfigFile(func(cfg *configFileContents) map[string]interface{} {
+		aliases := map[string]string{name: definition}
+		for n, d := range cfg.Aliases {
+			if n != name {
+				aliases[n] = d
+			}
+		}
+		return map[string]interface{}{"aliases": aliases}
+	})
+	log.WithField("alias", name).Info("Defined alias")
+}
+
+// DeleteAlias removes the named command alias from the config file, returning false if it doesn't exist
+func DeleteAlias(name string) bool {
+	deleted := false
+	modifyConfigFile(func(cfg *configFileContents) map[string]interface{} {
+		if _, found := cfg.Aliases[name]; !found {
+			return nil
+		}
+		deleted = true
+		aliases := map[string]string{}
+		for n, d := range cfg.Aliases {
+			if n != name {
+				aliases[n] = d
+			}
+		}
+		return map[string]interface{}{"aliases": aliases}
+	})
+	if deleted {
+		log.WithField("alias", name).Info("Deleted alias")
+	}
+	return deleted
+}
@@ -19,13 +19,12 @@
 package config
 
 import (
-	"errors"
-	"os"
+	"fmt"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/apex/log"
-	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -43,7 +42,7 @@ func NewSubCmd() *cobra.Command {
 
 	cmd.AddCommand(newCmdConfigGet())
 	cmd.AddCommand(newCmdConfigSet())
-	cmd.AddCommand(newCmdConfigUse())
+	cmd.AddCommand(newCmdConfigUse("use [PROFILE | -]"))
 	cmd.AddCommand(newCmdConfigList())
 
 	return cmd
@@ -52,25 +51,175 @@ func NewSubCmd() *cobra.Command {
 // GetCurrentContext returns the context (access profile) selected by the user
 // for the particular invocation of the fsoc utility. Returns nil if no current context is defined (and the
 // only command allowed in this state is `config set`, which will create the context).
+// References to environment variables in the profile values, ${NAME}, are resolved; the command fails
+// if any of the referenced variables is not set.
 // Note that GetCurrentContext returns a pointer into the config file's overall configuration; it can be
 // modified and then updated using ReplaceCurrentContext().
 func GetCurrentContext() *Context {
-	profile := GetCurrentProfileName()
+	ctx, err := LoadCurrentContext()
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	return ctx
+}
 
-	// read config file
-	cfg := getConfig()
-	if len(cfg.Contexts) == 0 {
-		return nil
+// LoadCurrentContext is like GetCurrentContext but returns an error, instead of failing the command,
+// if the config file can't be read or the references to environment variables can't be resolved, e.g.,
+// for diagnostics and for programs using fsoc as a library
+func LoadCurrentContext() (*Context, error) {
+	ctx, err := loadCurrentContextRaw()
+	if err != nil || ctx == nil {
+		return nil, err
+	}
+	if err := ctx.expandEnv(); err != nil {
+		return nil, fmt.Errorf("Failed to load profile %q: %w", ctx.Name, err)
+	}
+	if err := ctx.loadSecrets(); err != nil {
+		return nil, fmt.Errorf("Failed to load profile %q: %w", ctx.Name, err)
+	}
+	return ctx, nil
+}
+
+// HasCurrentContext returns true if the context selected by the user exists
+func HasCurrentContext() bool {
+	return getCurrentContextRaw() != nil
+}
+
+// getCurrentContextRaw returns the selected context as it is in the config file, without resolving the
+// references to environment variables, or nil if no current context is defined
+func getCurrentContextRaw() *Context {
+	ctx, err := loadCurrentContextRaw()
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	return ctx
+}
+
+// loadCurrentContextRaw is like getCurrentContextRaw but returns an error if the config file can't be read
+func loadCurrentContextRaw() (*Context, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
 	}
+	profile := currentProfileName(cfg)
 
 	// locate & return the named context
 	for _, c := range cfg.Contexts {
 		if c.Name == profile {
-			return &c
+			return &c, nil
 		}
 	}
 
-	return nil
+	return nil, nil
+}
+
+// TipsEnabled returns true unless contextual tips have been disabled in the config file
+func TipsEnabled() bool {
+	return !viper.GetBool("disable_tips")
+}
+
+// SetTipsEnabled enables or disables contextual tips in the config file
+func SetTipsEnabled(enabled bool) {
+	updateConfigFile(map[string]interface{}{"disable_tips": !enabled})
+}
+
+// TelemetrySettings configure the self-instrumentation of fsoc: when an endpoint is set, fsoc sends
+// its own traces and metrics (command durations, API calls and errors) to it over OTLP/HTTP
+type TelemetrySettings struct {
+	Endpoint string
+	Headers  map[string]string
+}
+
+// GetTelemetrySettings returns the self-instrumentation settings from the config file; the endpoint
+// is empty unless the self-instrumentation has been enabled
+func GetTelemetrySettings() TelemetrySettings {
+	settings := TelemetrySettings{Endpoint: viper.GetString("telemetry.endpoint")}
+	for _, header := range viper.GetStringSlice("telemetry.headers") { // stored as name=value
+		if name, value, found := strings.Cut(header, "="); found {
+			if settings.Headers == nil {
+				settings.Headers = map[string]string{}
+			}
+			settings.Headers[name] = value
+		}
+	}
+	return settings
+}
+
+// SetTelemetrySettings stores the self-instrumentation settings in the config file; nil disables it
+func SetTelemetrySettings(settings *TelemetrySettings) {
+	endpoint := ""
+	headers := []string{}
+	if settings != nil {
+		endpoint = settings.Endpoint
+		for name, value := range settings.Headers {
+			headers = append(headers, name+"="+value)
+		}
+		sort.Strings(headers)
+	}
+	updateConfigFile(map[string]interface{}{"telemetry.endpoint": endpoint, "telemetry.headers": headers})
+}
+
+// Consent to send anonymous usage analytics, as stored in the config file
+const (
+	AnalyticsGranted = "granted"
+	AnalyticsDenied  = "denied"
+)
+
+// GetAnalyticsConsent returns AnalyticsGranted or AnalyticsDenied if the user decided whether to send
+// anonymous usage analytics, or an empty string if the user hasn't been asked yet
+func GetAnalyticsConsent() string {
+	return viper.GetString("analytics.consent")
+}
+
+// SetAnalyticsConsent stores the user's decision to send anonymous usage analytics in the config file
+func SetAnalyticsConsent(granted bool) {
+	consent := AnalyticsDenied
+	if granted {
+		consent = AnalyticsGranted
+	}
+	updateConfigFile(map[string]interface{}{"analytics.consent": consent})
+}
+
+// AuditSettings configure the audit trail: when enabled, fsoc appends a record of each command to a
+// file per day in Dir, deleting the files older than RetentionDays (0 keeps them forever)
+type AuditSettings struct {
+	Enabled       bool
+	Dir           string
+	RetentionDays int
+}
+
+// DefaultAuditRetentionDays is the number of days the audit trail is kept, unless configured otherwise
+const DefaultAuditRetentionDays = 90
+
+// GetAuditSettings returns the audit trail settings from the config file; the audit trail is
+// disabled unless it has been enabled explicitly
+func GetAuditSettings() AuditSettings {
+	settings := AuditSettings{
+		Enabled:       viper.GetBool("audit.enabled"),
+		Dir:           viper.GetString("audit.dir"),
+		RetentionDays: DefaultAuditRetentionDays,
+	}
+	if viper.IsSet("audit.retention_days") {
+		settings.RetentionDays = viper.GetInt("audit.retention_days")
+	}
+	if settings.Dir == "" {
+		settings.Dir = filepath.Join(GetConfigDir(), "audit")
+	}
+	return settings
+}
+
+// SetAuditSettings stores the audit trail settings in the config file; nil disables the audit trail,
+// keeping its other settings. An empty directory selects the default.
+func SetAuditSettings(settings *AuditSettings) {
+	if settings == nil {
+		updateConfigFile(map[string]interface{}{"audit.enabled": false})
+		return
+	}
+	updateConfigFile(map[string]interface{}{
+		"audit.enabled":        settings.Enabled,
+		"audit.dir":            settings.Dir,
+		"audit.retention_days": settings.RetentionDays,
+	})
 }
 
 func checkUpgradeScheme(c *configFileContents) {
@@ -90,26 +239,38 @@ func checkUpgradeScheme(c *configFileContents) {
 		newContexts[i] = context
 	}
 	if needReWrite {
-		updateConfigFile(map[string]interface{}{
-			"contexts": newContexts,
-		})
 		c.Contexts = newContexts
+		err := writeConfigChanges(func(*configFileContents) map[string]interface{} {
+			return map[string]interface{}{"contexts": newContexts}
+		})
+		if err != nil {
+			log.Warnf("Failed to save the upgraded settings schema: %v", err)
+			return
+		}
 		log.Warnf("Config file updated to upgrade settings schema.")
 	}
 }
 
 func getConfig() configFileContents {
+	c, err := loadConfig()
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	return c
+}
+
+// loadConfig is like getConfig but returns an error if the config file can't be read
+func loadConfig() (configFileContents, error) {
 	// read config file with all contexts
 	var c configFileContents
-	err := viper.Unmarshal(&c)
-	if err != nil {
-		log.Fatalf("unable to read config: %v", err)
+	if err := viper.Unmarshal(&c); err != nil {
+		return c, fmt.Errorf("unable to read config: %w", err)
 	}
 
 	// check if scheme needs to be upgraded; do it and update file if so
 	checkUpgradeScheme(&c)
 
-	return c
+	return c, nil
 }
 
 // listContexts returns a list of context names which begin with `toComplete`,
@@ -126,120 +287,163 @@ func getConfig() configFileContents {
 // 	return ret
 // }
 
+// updateConfigFile sets the values of top-level or dotted keys in the config file
 func updateConfigFile(keyValues map[string]interface{}) {
-	var err error
-
-	// update values
-	for key, value := range keyValues {
-		viper.Set(key, value)
-	}
-	// set up config file in viper
-	viper.SetConfigType("yaml")
-	if viper.ConfigFileUsed() == "" {
-		home, _ := os.UserHomeDir()
-		configFileLocation := strings.Replace(DefaultConfigFile, "~", home, 1)
-		viper.SetConfigFile(configFileLocation)
-	}
-	viper.SetConfigPermissions(0600) // o=rw
-
-	// ensure file exists (viper fails to create it, likely a bug in viper)
-	ensureConfigFile()
+	modifyConfigFile(func(*configFileContents) map[string]interface{} {
+		return keyValues
+	})
+}
 
-	// update file contents
-	err = viper.WriteConfig()
-	if err != nil {
-		log.Fatalf("failed to write config file %q: %v", viper.ConfigFileUsed(), err)
+func updateContext(ctx *Context) {
+	if err := saveContext(ctx); err != nil {
+		log.Fatal(err.Error())
 	}
 }
 
-func ensureConfigFile() {
-	appFs := afero.NewOsFs()
-
-	// finalize the path to use
-	var fileLoc = viper.ConfigFileUsed()
-	if strings.Contains(fileLoc[:2], "~/") {
-		homeDir, _ := os.UserHomeDir()
-		fileLoc = strings.Replace(fileLoc, "~", homeDir, 1)
+// saveContext creates or replaces the context in the config file
+func saveContext(ctx *Context) error {
+	if ctx.Name == "" {
+		return fmt.Errorf("bug: context name cannot be empty when updating context")
 	}
-	configPath, _ := filepath.Abs(fileLoc)
 
-	// try to open the file, create it if it doesn't exist
-	_, err := appFs.Open(configPath)
-	if err != nil {
-		if errors.Is(err, os.ErrNotExist) {
-			_, err = appFs.Create(configPath)
-			if err != nil {
-				log.Fatalf("failed to create config file %q: %v", configPath, err)
+	contextExists := false
+	err := writeConfigChanges(func(cfg *configFileContents) map[string]interface{} {
+		var ctxPtr *Context
+		for idx, c := range cfg.Contexts {
+			if c.Name == ctx.Name {
+				ctxPtr = &cfg.Contexts[idx]
+				contextExists = true
+				break
 			}
-			viper.SetConfigFile(configPath)
-		} else {
-			log.Fatalf("failed to open config file %q: %v", configPath, err)
 		}
-	}
-}
 
-func updateContext(ctx *Context) {
-	contextExists := false
-	var ctxPtr *Context
+		// If context not found, create a new one
+		if !contextExists {
+			cfg.Contexts = append(cfg.Contexts, Context{Name: ctx.Name})
+			ctxPtr = &cfg.Contexts[len(cfg.Contexts)-1]
+		}
 
-	if ctx.Name == "" {
-		log.Fatalf("bug: context name cannot be empty when updating context")
-	}
+		// copy context, keeping the references to environment variables of unchanged values
+		updated := *ctx // copy, in case ctx is not what GetCurrentContext() had returned
+		restoreEnvReferences(&updated, ctxPtr)
+		updated.storeSecrets()
+		*ctxPtr = updated
 
-	cfg := getConfig()
-	for idx, c := range cfg.Contexts {
-		if c.Name == ctx.Name {
-			ctxPtr = &cfg.Contexts[idx]
-			contextExists = true
-			break
+		update := map[string]interface{}{"contexts": cfg.Contexts}
+		if !contextExists && len(cfg.Contexts) == 1 { // just created the first context, set it as current
+			update["current_context"] = ctx.Name
+			log.Infof("Setting context %s as current", ctx.Name)
 		}
+		return update
+	})
+	if err != nil {
+		return err
 	}
 
-	// If context not found, create a new one
-	if !contextExists {
-		ctx := Context{
-			Name: ctx.Name,
-		}
-		cfg.Contexts = append(cfg.Contexts, ctx)
-		ctxPtr = &cfg.Contexts[len(cfg.Contexts)-1]
+	if contextExists {
+		log.WithField("profile", ctx.Name).Info("Updated context")
+	} else {
+		log.WithField("profile", ctx.Name).Info("Created context")
 	}
+	return nil
+}
+
+// UpsertContext creates the named context or, if it exists, replaces all of its values. It is used by
+// commands that provision profiles, e.g., for sandbox tenants; the context is not made current.
+func UpsertContext(ctx *Context) {
+	updateContext(ctx)
+}
 
-	// copy context if needed
-	if ctx != ctxPtr {
-		*ctxPtr = *ctx // copy, in case ctx is not what GetCurrentContext() had returned
+// HasContext returns true if the config file has a context with the given name
+func HasContext(name string) bool {
+	for _, c := range getConfig().Contexts {
+		if c.Name == name {
+			return true
+		}
 	}
+	return false
+}
+
+// DeleteContext removes the named context from the config file, returning false if it doesn't exist.
+// If the context was the current one, the config file is left without a current context.
+func DeleteContext(name string) bool {
+	deleted := false
+	modifyConfigFile(func(cfg *configFileContents) map[string]interface{} {
+		contexts := make([]Context, 0, len(cfg.Contexts))
+		for _, c := range cfg.Contexts {
+			if c.Name != name {
+				contexts = append(contexts, c)
+			}
+		}
+		if len(contexts) == len(cfg.Contexts) {
+			return nil
+		}
+		deleted = true
 
-	update := map[string]interface{}{"contexts": cfg.Contexts}
-	if !contextExists && len(cfg.Contexts) == 1 { // just created the first context, set it as current
-		update["current_context"] = ctx.Name
-		log.Infof("Setting context %s as current", ctx.Name)
+		update := map[string]interface{}{"contexts": contexts}
+		if cfg.CurrentContext == name {
+			update["current_context"] = ""
+		}
+		if cfg.PreviousContext == name {
+			update["previous_context"] = ""
+		}
+		return update
+	})
+	if deleted {
+		deleteSecrets(name)
+		log.WithField("profile", name).Info("Deleted context")
 	}
-	updateConfigFile(update)
+	return deleted
+}
 
-	if contextExists {
-		log.WithField("profile", ctx.Name).Info("Updated context")
-	} else {
-		log.WithField("profile", ctx.Name).Info("Created context")
+// SetCurrentContext makes the named context current in the config file, remembering the
+// previously current one (see "fsoc use -")
+func SetCurrentContext(name string) error {
+	if !HasContext(name) {
+		return fmt.Errorf("no context exists with the name: %q", name)
 	}
+	modifyConfigFile(func(cfg *configFileContents) map[string]interface{} {
+		update := map[string]interface{}{"current_context": name}
+		previous := cfg.CurrentContext
+		if previous == "" {
+			previous = DefaultContext
+		}
+		if previous != name {
+			update["previous_context"] = previous
+		}
+		return update
+	})
+	return nil
 }
 
 // ReplaceCurrentContext updates the all values within the current context.
 // It accepts a Context structure, which may or may not be returned by GetCurrentContext().
 // Note that the Context.Name must match the current context.
 func ReplaceCurrentContext(ctx *Context) {
+	if err := SaveCurrentContext(ctx); err != nil {
+		log.Fatal(err.Error())
+	}
+}
+
+// SaveCurrentContext is like ReplaceCurrentContext but returns an error, instead of failing the command,
+// if the context can't be saved
+func SaveCurrentContext(ctx *Context) error {
 	// enforce that the *current* context is being replaced
-	curCtx := GetCurrentContext()
+	curCtx, err := loadCurrentContextRaw()
+	if err != nil {
+		return err
+	}
 	if curCtx == nil {
 		log.Errorf("Attempt to update current context as %q when there is no current context; update ignored", ctx.Name)
-		return
+		return nil
 	}
 	if ctx.Name != curCtx.Name {
 		log.Errorf("Attempt to update current context %q using non-matching context name %q; update ignored", curCtx.Name, ctx.Name)
-		return
+		return nil
 	}
 
 	// update context
-	updateContext(ctx)
+	return saveContext(ctx)
 }
 
 // SetSelectedProfile sets the name of the profile that should be used instead of the
@@ -255,10 +459,31 @@ func SetSelectedProfile(name string) {
 	selectedProfile = name
 }
 
+// UseProfile temporarily selects another profile, e.g., to make some of a command's platform calls
+// with the credentials of an agent principal, and returns the function that restores the
+// previously selected profile. It fails if the profile doesn't exist.
+func UseProfile(name string) (func(), error) {
+	previous := selectedProfile
+	selectedProfile = name
+	if !HasCurrentContext() {
+		selectedProfile = previous
+		return nil, fmt.Errorf("profile %q does not exist", name)
+	}
+	return func() { selectedProfile = previous }, nil
+}
+
 // GetCurrentProfileName returns the profile name that is used to select the context.
 // This is mostly the same as returned by GetCurrentContext().Name, except for the
 // case when a new profile is being created.
 func GetCurrentProfileName() string {
+	if selectedProfile != "" {
+		return selectedProfile
+	}
+	return currentProfileName(getConfig())
+}
+
+// currentProfileName returns the profile name that is used to select the context in the config file
+func currentProfileName(cfg configFileContents) string {
 	// start with default
 	profile := DefaultContext
 
@@ -267,7 +492,6 @@ func GetCurrentProfileName() string {
 		profile = selectedProfile
 	} else {
 		// get profile that is current for the config file
-		cfg := getConfig()
 		if cfg.CurrentContext == "" {
 			cfg.CurrentContext = DefaultContext // dealing with old "current-context" keys (temporary)
 		}
@@ -0,0 +1,73 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/slices"
+)
+
+// DefaultsConfigPrefix is the prefix of the "config set" arguments that set the profile's flag defaults
+const DefaultsConfigPrefix = "defaults."
+
+// DefaultableFlags lists the global flags whose defaults can be set in a profile. Flags that
+// take effect before the profile is read (e.g., --log-level) or that select it can't be included.
+var DefaultableFlags = []string{"output", "fields", "fields-file", "distinct", "timeout", "max-time", "no-compression", "offline"}
+
+// exclusiveFlags lists, for each defaultable flag, a flag that can't be used together with it; the
+// profile's default is not applied if the other flag is given on the command line
+var exclusiveFlags = map[string]string{"fields": "fields-file", "fields-file": "fields"}
+
+// parseDefaultSetting parses a "defaults.FLAG=VALUE" argument of the "config set" command; an empty
+// value removes the default
+func parseDefaultSetting(arg string) (string, string, error) {
+	name, value, found := strings.Cut(strings.TrimPrefix(arg, DefaultsConfigPrefix), "=")
+	if !found {
+		return "", "", fmt.Errorf("missing value in %q; expected %s%s=VALUE", arg, DefaultsConfigPrefix, name)
+	}
+	idx := slices.IndexFunc(DefaultableFlags, func(f string) bool { return normalizeKey(f) == normalizeKey(name) })
+	if idx < 0 {
+		return "", "", fmt.Errorf("flag %q can't have a default in the profile; must be one of %v", name, strings.Join(DefaultableFlags, ", "))
+	}
+	return DefaultableFlags[idx], value, nil
+}
+
+// ApplyProfileDefaults sets the flags of the command that were not given on the command line to the
+// defaults in the current profile, e.g., to produce JSON output for all commands using the profile.
+// The flags remain unchanged from pflag's point of view, as they were not specified explicitly.
+func ApplyProfileDefaults(cmd *cobra.Command) {
+	ctx := getCurrentContextRaw()
+	if ctx == nil {
+		return
+	}
+	for name, value := range ctx.Defaults {
+		if !slices.Contains(DefaultableFlags, name) {
+			log.Warnf("Ignoring the default for flag %q in profile %q: the flag can't have a default", name, ctx.Name)
+			continue
+		}
+		flag := cmd.Flags().Lookup(name)
+		if flag == nil || flag.Changed || cmd.Flags().Changed(exclusiveFlags[name]) {
+			continue
+		}
+		if err := flag.Value.Set(value); err != nil { // unlike FlagSet.Set, this doesn't mark the flag as changed
+			log.Fatalf("Invalid default for flag --%s in profile %q: %v", name, ctx.Name, err)
+		}
+		log.WithFields(log.Fields{"flag": name, "value": value, "profile": ctx.Name}).Info("Using flag default from profile")
+	}
+}
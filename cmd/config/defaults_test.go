@@ -0,0 +1,81 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDefaultSetting(t *testing.T) {
+	name, value, err := parseDefaultSetting("defaults.max_time=30s")
+	assert.Nil(t, err)
+	assert.Equal(t, "max-time", name)
+	assert.Equal(t, "30s", value)
+
+	for _, arg := range []string{"defaults.output", "defaults.profile=prod", "defaults.log-level=debug"} {
+		_, _, err := parseDefaultSetting(arg)
+		assert.NotNil(t, err, arg)
+	}
+
+	ctx := &Context{}
+	applySettings(t, ctx, false, "defaults.output=json", "defaults.timeout=1m")
+	assert.Equal(t, map[string]string{"output": "json", "timeout": "1m"}, ctx.Defaults)
+	applySettings(t, ctx, false, "defaults.timeout=")
+	assert.Equal(t, map[string]string{"output": "json"}, ctx.Defaults)
+}
+
+func TestApplyProfileDefaults(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set("contexts", []Context{{
+		Name:     "analytics",
+		Defaults: map[string]string{"output": "json", "timeout": "2m", "fields": ".id", "profile": "other"},
+	}})
+	viper.Set("current_context", "analytics")
+
+	newCmd := func() *cobra.Command {
+		cmd := &cobra.Command{Use: "test"}
+		cmd.Flags().StringP("output", "o", "auto", "")
+		cmd.Flags().String("fields", "", "")
+		cmd.Flags().String("fields-file", "", "")
+		cmd.Flags().Duration("timeout", 0, "")
+		return cmd
+	}
+
+	// defaults apply to the flags not given on the command line, without marking them as changed
+	cmd := newCmd()
+	require.NoError(t, cmd.Flags().Parse([]string{"-o", "yaml"}))
+	ApplyProfileDefaults(cmd)
+	output, _ := cmd.Flags().GetString("output")
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+	fields, _ := cmd.Flags().GetString("fields")
+	assert.Equal(t, "yaml", output)
+	assert.Equal(t, 2*time.Minute, timeout)
+	assert.Equal(t, ".id", fields)
+	assert.False(t, cmd.Flags().Changed("timeout"))
+
+	// a default is not applied when a conflicting flag is given
+	cmd = newCmd()
+	require.NoError(t, cmd.Flags().Parse([]string{"--fields-file", "f.jq"}))
+	ApplyProfileDefaults(cmd)
+	fields, _ = cmd.Flags().GetString("fields")
+	assert.Equal(t, "", fields)
+}
@@ -0,0 +1,100 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// envReferenceRegexp matches references to environment variables in profile values, ${NAME}, as well
+// as escaped references, $${NAME}, which stand for the literal text ${NAME}
+var envReferenceRegexp = regexp.MustCompile(`\$(\$?)\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// envFields returns the profile fields that may reference environment variables, by config key
+func (c *Context) envFields() map[string]*string {
+	return map[string]*string{
+		"url":                     &c.URL,
+		"tenant":                  &c.Tenant,
+		"user":                    &c.User,
+		"token":                   &c.Token,
+		"refresh_token":           &c.RefreshToken,
+		"csv_file":                &c.CsvFile,
+		"secret_file":             &c.SecretFile,
+		"auth-options." + AppdPty: &c.LocalAuthOptions.AppdPty,
+		"auth-options." + AppdTid: &c.LocalAuthOptions.AppdTid,
+		"auth-options." + AppdPid: &c.LocalAuthOptions.AppdPid,
+	}
+}
+
+// hasEnvReference returns true if the value references environment variables (or contains escaped references)
+func hasEnvReference(value string) bool {
+	return envReferenceRegexp.MatchString(value)
+}
+
+// expandEnvReferences replaces the references to environment variables in the value with the variables'
+// values, returning the names of the referenced variables that are not set
+func expandEnvReferences(value string) (string, []string) {
+	var missing []string
+	expanded := envReferenceRegexp.ReplaceAllStringFunc(value, func(ref string) string {
+		groups := envReferenceRegexp.FindStringSubmatch(ref)
+		if groups[1] != "" { // escaped
+			return ref[1:]
+		}
+		v, found := os.LookupEnv(groups[2])
+		if !found {
+			missing = append(missing, groups[2])
+		}
+		return v
+	})
+	return expanded, missing
+}
+
+// expandEnv resolves the references to environment variables in the profile values, failing if any of
+// the referenced variables is not set
+func (c *Context) expandEnv() error {
+	var problems []string
+	for key, field := range c.envFields() {
+		expanded, missing := expandEnvReferences(*field)
+		for _, name := range missing {
+			problems = append(problems, fmt.Sprintf("%s (referenced by %s)", name, key))
+		}
+		*field = expanded
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("environment variable(s) not set: %s", strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// restoreEnvReferences keeps the references to environment variables of the stored profile in the
+// updated profile, for the values that have not changed, so that the resolved values (e.g., secrets)
+// are not written into the config file
+func restoreEnvReferences(updated *Context, stored *Context) {
+	storedFields := stored.envFields()
+	for key, field := range updated.envFields() {
+		raw := *storedFields[key]
+		if !hasEnvReference(raw) {
+			continue
+		}
+		if expanded, missing := expandEnvReferences(raw); len(missing) == 0 && expanded == *field {
+			*field = raw
+		}
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("FSOC_TEST_SECRET", "s3cret")
+	t.Setenv("FSOC_TEST_HOST", "mytenant.example.com")
+
+	ctx := &Context{
+		Name:   "prod",
+		URL:    "https://${FSOC_TEST_HOST}",
+		Token:  "${FSOC_TEST_SECRET}",
+		Tenant: "$${FSOC_TEST_SECRET}-$FSOC_TEST_SECRET",
+	}
+	assert.Nil(t, ctx.expandEnv())
+	assert.Equal(t, "https://mytenant.example.com", ctx.URL)
+	assert.Equal(t, "s3cret", ctx.Token)
+	assert.Equal(t, "${FSOC_TEST_SECRET}-$FSOC_TEST_SECRET", ctx.Tenant)
+
+	ctx = &Context{Token: "${FSOC_TEST_UNSET}", SecretFile: "/creds/${FSOC_TEST_UNSET_TOO}.json"}
+	err := ctx.expandEnv()
+	assert.EqualError(t, err, "environment variable(s) not set: FSOC_TEST_UNSET (referenced by token), FSOC_TEST_UNSET_TOO (referenced by secret_file)")
+}
+
+func TestRestoreEnvReferences(t *testing.T) {
+	t.Setenv("FSOC_TEST_SECRET", "s3cret")
+
+	stored := &Context{Name: "prod", Token: "${FSOC_TEST_SECRET}", RefreshToken: "${FSOC_TEST_SECRET}", URL: "https://example.com"}
+	updated := *stored
+	assert.Nil(t, updated.expandEnv())
+	updated.RefreshToken = "new-refresh-token"
+
+	restoreEnvReferences(&updated, stored)
+	assert.Equal(t, "${FSOC_TEST_SECRET}", updated.Token)      // unchanged, reference kept
+	assert.Equal(t, "new-refresh-token", updated.RefreshToken) // changed
+	assert.Equal(t, "https://example.com", updated.URL)
+}
@@ -0,0 +1,124 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+)
+
+// Feature maturity levels
+const (
+	MaturityExperimental = "experimental" // may change or be removed without notice
+	MaturityAlpha        = "alpha"        // functionally complete but not yet stable
+	MaturityBeta         = "beta"         // stable, pending feedback before becoming generally available
+)
+
+// FeatureConfigPrefix is the prefix of the "config set" arguments that enable or disable features
+const FeatureConfigPrefix = "features."
+
+// Feature describes an experimental capability that is available only when enabled in the current profile
+type Feature struct {
+	Name        string `json:"name" yaml:"name"`
+	Maturity    string `json:"maturity" yaml:"maturity"`
+	Description string `json:"description" yaml:"description"`
+}
+
+var (
+	featuresMutex sync.Mutex
+	features      = map[string]Feature{}
+)
+
+// RegisterFeature makes a feature gate known to fsoc. Subsystems register their gates in init(), so
+// that they can be listed and enabled with `fsoc config set features.NAME=true`.
+func RegisterFeature(f Feature) {
+	featuresMutex.Lock()
+	defer featuresMutex.Unlock()
+
+	if _, exists := features[f.Name]; exists {
+		log.Fatalf("bug: feature %q registered more than once", f.Name)
+	}
+	features[f.Name] = f
+}
+
+// GetFeatures returns the registered features, sorted by name
+func GetFeatures() []Feature {
+	featuresMutex.Lock()
+	defer featuresMutex.Unlock()
+
+	list := make([]Feature, 0, len(features))
+	for _, f := range features {
+		list = append(list, f)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// LookupFeature returns the registered feature with the given name
+func LookupFeature(name string) (Feature, bool) {
+	featuresMutex.Lock()
+	defer featuresMutex.Unlock()
+
+	f, found := features[name]
+	return f, found
+}
+
+// IsFeatureEnabled returns true if the named feature is enabled in the current context
+func IsFeatureEnabled(name string) bool {
+	ctx := getCurrentContextRaw()
+	if ctx == nil {
+		return false
+	}
+	return ctx.Features[name]
+}
+
+// parseFeatureSetting parses a "features.NAME=BOOL" argument of the "config set" command
+func parseFeatureSetting(arg string) (string, bool, error) {
+	if !strings.HasPrefix(arg, FeatureConfigPrefix) {
+		return "", false, fmt.Errorf("unexpected argument %q; expected %sNAME=true|false", arg, FeatureConfigPrefix)
+	}
+	name, value, found := strings.Cut(strings.TrimPrefix(arg, FeatureConfigPrefix), "=")
+	if !found {
+		return "", false, fmt.Errorf("missing value in %q; expected %s%s=true|false", arg, FeatureConfigPrefix, name)
+	}
+	if _, registered := LookupFeature(name); !registered {
+		return "", false, fmt.Errorf("unknown feature %q; use \"fsoc features list\" to see the available features", name)
+	}
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		return "", false, fmt.Errorf("invalid value %q for feature %q; expected true or false", value, name)
+	}
+	return name, enabled, nil
+}
+
+// RequireFeature places a command, together with its subcommands, behind the named feature gate.
+// Gated commands are hidden from help and fail unless the feature is enabled in the current profile.
+func RequireFeature(cmd *cobra.Command, name string) *cobra.Command {
+	if _, registered := LookupFeature(name); !registered {
+		log.Fatalf("bug: command %q requires unregistered feature %q", cmd.Name(), name)
+	}
+	if cmd.Annotations == nil {
+		cmd.Annotations = map[string]string{}
+	}
+	cmd.Annotations[AnnotationForFeature] = name
+	cmd.Hidden = true
+	return cmd
+}
@@ -0,0 +1,45 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFeatureSetting(t *testing.T) {
+	RegisterFeature(Feature{Name: "test-feature", Maturity: MaturityExperimental})
+
+	name, enabled, err := parseFeatureSetting("features.test-feature=true")
+	assert.Nil(t, err)
+	assert.Equal(t, "test-feature", name)
+	assert.True(t, enabled)
+
+	_, enabled, err = parseFeatureSetting("features.test-feature=0")
+	assert.Nil(t, err)
+	assert.False(t, enabled)
+
+	for _, arg := range []string{"test-feature=true", "features.test-feature", "features.unknown=true", "features.test-feature=maybe"} {
+		_, _, err := parseFeatureSetting(arg)
+		assert.NotNil(t, err, arg)
+	}
+
+	cmd := RequireFeature(&cobra.Command{Use: "gated"}, "test-feature")
+	assert.True(t, cmd.Hidden)
+	assert.Equal(t, "test-feature", cmd.Annotations[AnnotationForFeature])
+	assert.Contains(t, GetFeatures(), Feature{Name: "test-feature", Maturity: MaturityExperimental})
+}
@@ -0,0 +1,166 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/spf13/viper"
+)
+
+// configLockTimeout limits the wait for other fsoc processes to finish updating the config file
+const configLockTimeout = 10 * time.Second
+
+// modifyConfigFile is like writeConfigChanges but fails the command if the config file can't be updated
+func modifyConfigFile(modify func(cfg *configFileContents) map[string]interface{}) {
+	if err := writeConfigChanges(modify); err != nil {
+		log.Fatal(err.Error())
+	}
+}
+
+// writeConfigChanges updates the config file with the values of top-level or dotted keys returned by
+// modify, which computes them from the file's current contents (nil for no changes). Concurrent fsoc
+// processes, e.g., parallel CI jobs refreshing tokens, update the file one at a time under an exclusive
+// lock; the file is re-read under the lock, so that their changes are not lost, and replaced atomically,
+// so that it is never seen partially written.
+func writeConfigChanges(modify func(cfg *configFileContents) map[string]interface{}) error {
+	path, err := configFilePath()
+	if err != nil {
+		return fmt.Errorf("failed to locate the config file: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create the config directory: %w", err)
+	}
+	unlock, err := lockConfigFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to lock config file %q: %w", path, err)
+	}
+	defer unlock()
+
+	// read the file's current contents, which other processes may have changed since it was loaded
+	v := viper.New()
+	v.SetConfigFile(path)
+	v.SetConfigType("yaml")
+	if _, err := os.Stat(path); err == nil {
+		if err := v.ReadInConfig(); err != nil {
+			return fmt.Errorf("failed to read config file %q: %w", path, err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to open config file %q: %w", path, err)
+	}
+	var cfg configFileContents
+	if err := v.Unmarshal(&cfg); err != nil {
+		return fmt.Errorf("unable to read config: %w", err)
+	}
+
+	keyValues := modify(&cfg)
+	if len(keyValues) == 0 {
+		return nil
+	}
+	for key, value := range keyValues {
+		v.Set(key, value)
+		viper.Set(key, value) // this process sees its changes even if it doesn't re-read the file
+	}
+	if err := writeConfigFile(v, path); err != nil {
+		return fmt.Errorf("failed to write config file %q: %w", path, err)
+	}
+	viper.SetConfigFile(path)
+	updatePromptState(v, path)
+	return nil
+}
+
+// configFilePath returns the absolute path of the config file, following symbolic links so that
+// they are preserved when the file is replaced
+func configFilePath() (string, error) {
+	path := viper.ConfigFileUsed()
+	if path == "" {
+		path = DefaultConfigFilePath()
+	}
+	path, err := filepath.Abs(expandHomePath(path))
+	if err != nil {
+		return "", err
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	return path, nil
+}
+
+// lockConfigFile takes an exclusive advisory lock on the config file, using a lock file next to it,
+// waiting for other fsoc processes to release it. It returns a function that releases the lock and
+// removes the lock file.
+func lockConfigFile(path string) (func(), error) {
+	lockPath := path + ".lock"
+	deadline := time.Now().Add(configLockTimeout)
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0600)
+		if err != nil {
+			return nil, err
+		}
+		locked, err := tryLockFile(f)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		if locked {
+			// the lock file may have been removed by the previous holder after we opened it
+			if isSameFile(f, lockPath) {
+				return func() {
+					_ = os.Remove(lockPath) // before unlocking, so that waiting processes retry
+					_ = unlockFile(f)
+					f.Close()
+				}, nil
+			}
+			_ = unlockFile(f)
+		}
+		f.Close()
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for another fsoc process to release %q", lockPath)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// isSameFile returns true if the open file is the file at path
+func isSameFile(f *os.File, path string) bool {
+	openInfo, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	pathInfo, err := os.Stat(path)
+	return err == nil && os.SameFile(openInfo, pathInfo)
+}
+
+// writeConfigFile writes the config to a temporary file and renames it over the config file
+func writeConfigFile(v *viper.Viper, path string) error {
+	// the extension of the temporary file selects the YAML format in viper
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.yaml")
+	if err != nil {
+		return err
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name()) // no-op once renamed
+
+	v.SetConfigPermissions(0600) // o=rw
+	if err := v.WriteConfigAs(tmp.Name()); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
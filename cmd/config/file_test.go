@@ -0,0 +1,110 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fsoc.yaml")
+
+	// holders of the lock never overlap
+	var mu sync.Mutex
+	holders, maxHolders := 0, 0
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock, err := lockConfigFile(path)
+			if !assert.NoError(t, err) {
+				return
+			}
+			mu.Lock()
+			holders++
+			if holders > maxHolders {
+				maxHolders = holders
+			}
+			mu.Unlock()
+			time.Sleep(5 * time.Millisecond)
+			mu.Lock()
+			holders--
+			mu.Unlock()
+			unlock()
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1, maxHolders)
+	_, err := os.Stat(path + ".lock")
+	assert.ErrorIs(t, err, os.ErrNotExist, "the lock file should be removed")
+}
+
+func TestModifyConfigFileKeepsConcurrentChanges(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "fsoc.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`contexts:
+  - name: a
+    auth_method: none
+    url: https://a.example.com
+  - name: b
+    auth_method: none
+    url: https://b.example.com
+current_context: a
+`), 0600))
+	viper.Reset()
+	defer viper.Reset()
+	viper.SetConfigFile(path)
+	viper.SetConfigType("yaml")
+	require.NoError(t, viper.ReadInConfig())
+
+	// another process refreshes b's token after this process loaded the file
+	other := viper.New()
+	other.SetConfigFile(path)
+	require.NoError(t, other.ReadInConfig())
+	var cfg configFileContents
+	require.NoError(t, other.Unmarshal(&cfg))
+	cfg.Contexts[1].Token = "token-b"
+	other.Set("contexts", cfg.Contexts)
+	require.NoError(t, other.WriteConfig())
+
+	// this process refreshes a's token
+	ctx := GetCurrentContext()
+	ctx.Token = "token-a"
+	ReplaceCurrentContext(ctx)
+
+	reread := viper.New()
+	reread.SetConfigFile(path)
+	require.NoError(t, reread.ReadInConfig())
+	require.NoError(t, reread.Unmarshal(&cfg))
+	require.Len(t, cfg.Contexts, 2)
+	assert.Equal(t, "token-a", cfg.Contexts[0].Token)
+	assert.Equal(t, "token-b", cfg.Contexts[1].Token)
+	assert.Equal(t, "a", cfg.CurrentContext)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	entries, _ := os.ReadDir(dir)
+	assert.Len(t, entries, 1, "temporary and lock files should be removed")
+}
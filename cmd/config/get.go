@@ -28,12 +28,21 @@ func newCmdConfigGet() *cobra.Command {
 	var cmd = &cobra.Command{
 		Use:   "get",
 		Short: "Displays the selected context",
-		Long:  `Displays the selected context`,
-		RunE:  configGetContext,
+		Long: `Displays the selected context, as it is in the config file.
+
+With --resolved, the settings in effect for the selected profile are displayed instead, with the source of
+each value: the command line, the environment (including the values referencing environment variables
+in the profile), the profile, the credential store or the built-in default. Use it to find out why fsoc
+uses a different tenant, profile or flag value than expected. Secrets are masked unless --unmask is given.`,
+		Example: `  fsoc config get
+  fsoc config get --resolved
+  fsoc config get --resolved --profile prod -o json`,
+		RunE: configGetContext,
 	}
 
 	cmd.Flags().StringP("output", "o", "", "Output format (human*, json, yaml)")
 	cmd.Flags().Bool("unmask", false, "Unmask secrets in output")
+	cmd.Flags().Bool("resolved", false, "Display the settings in effect, with the source of each value")
 
 	return cmd
 }
@@ -44,19 +53,26 @@ func configGetContext(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("Unexpected argument(s): %v", args)
 	}
 
-	// get current context and mask secret values
-	ctx := GetCurrentContext()
+	// get current context and mask secret values (references to environment variables are displayed as is)
+	ctx := getCurrentContextRaw()
 	if ctx == nil {
 		log.Fatalf("There is no current context, use `fsoc config set` to set up a context")
 	}
 	unmask, err := cmd.Flags().GetBool("unmask")
+	if resolved, _ := cmd.Flags().GetBool("resolved"); resolved {
+		printResolvedSettings(cmd, resolveSettings(cmd, ctx, err == nil && unmask))
+		return nil
+	}
 	if err != nil || !unmask {
-		if ctx.Token != "" {
-			ctx.Token = "(present)"
-		}
-		if ctx.RefreshToken != "" {
-			ctx.RefreshToken = "(present)"
+		for _, field := range ctx.secretFields() {
+			if *field == secretStoreMarker {
+				*field = "(present in credential store)"
+			} else if *field != "" && !hasEnvReference(*field) {
+				*field = "(present)"
+			}
 		}
+	} else if err := ctx.loadSecrets(); err != nil {
+		log.Fatalf("%v", err)
 	}
 
 	// "upgrade" config schema if needed
@@ -89,3 +105,17 @@ func configGetContext(cmd *cobra.Command, args []string) error {
 	})
 	return nil
 }
+
+func printResolvedSettings(cmd *cobra.Command, settings []resolvedSetting) {
+	lines := make([][]string, len(settings))
+	for i, s := range settings {
+		lines[i] = []string{s.Setting, s.Value, s.Source}
+	}
+	output.PrintCmdOutputCustom(cmd, struct {
+		Items []resolvedSetting `json:"items"`
+		Total int               `json:"total"`
+	}{Items: settings, Total: len(settings)}, &output.Table{
+		Headers: []string{"Setting", "Value", "Source"},
+		Lines:   lines,
+	})
+}
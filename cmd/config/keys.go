@@ -0,0 +1,197 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/apex/log"
+	"golang.org/x/exp/slices"
+)
+
+// profileKey describes a profile setting that can be set with "config set KEY=VALUE"
+type profileKey struct {
+	name    string                             // dotted path of the setting, as in the config file
+	aliases []string                           // other accepted names, e.g., the name of the corresponding flag
+	parse   func(value string) (string, error) // validates and normalizes the value, nil if any string is valid
+	set     func(ctx *Context, value string)
+}
+
+var profileKeys = []profileKey{
+	{"auth_method", []string{"auth"}, parseAuthMethod, func(ctx *Context, v string) { ctx.AuthMethod = v }},
+	{"url", nil, parseURL, func(ctx *Context, v string) { ctx.URL = v }},
+	{"tenant", nil, nil, func(ctx *Context, v string) { ctx.Tenant = v }},
+	{"user", nil, nil, func(ctx *Context, v string) { ctx.User = v }},
+	{"token", nil, nil, func(ctx *Context, v string) { ctx.Token = v }},
+	{"refresh_token", nil, nil, func(ctx *Context, v string) { ctx.RefreshToken = v }},
+	{"secret_file", nil, parseSecretFile, func(ctx *Context, v string) {
+		ctx.SecretFile = v
+		ctx.CsvFile = "" // CSV file is a backward-compatibility value only
+	}},
+	{"auth-options.appd-pid", []string{AppdPid}, nil, func(ctx *Context, v string) { ctx.LocalAuthOptions.AppdPid = v }},
+	{"auth-options.appd-tid", []string{AppdTid}, nil, func(ctx *Context, v string) { ctx.LocalAuthOptions.AppdTid = v }},
+	{"auth-options.appd-pty", []string{AppdPty}, nil, func(ctx *Context, v string) { ctx.LocalAuthOptions.AppdPty = v }},
+}
+
+func parseAuthMethod(value string) (string, error) {
+	if value != "" && !slices.Contains(GetAuthMethodsStringList(), value) {
+		return "", fmt.Errorf(`must be one of {"%v"}`, strings.Join(GetAuthMethodsStringList(), `", "`))
+	}
+	return value, nil
+}
+
+func parseURL(value string) (string, error) {
+	if value == "" || hasEnvReference(value) { // references are validated when used
+		return value, nil
+	}
+	return validateUrl(value)
+}
+
+func parseSecretFile(value string) (string, error) {
+	if value == "" || hasEnvReference(value) {
+		return value, nil
+	}
+	path, err := filepath.Abs(expandHomePath(value))
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(path); err != nil {
+		log.Warnf("The secret file %q is not accessible: %v", path, err)
+	}
+	return path, nil
+}
+
+// normalizeKey makes keys that differ only in case or in the use of ".", "-" and "_" as separators
+// equal, e.g., "auth.method" and "auth_method"
+func normalizeKey(key string) string {
+	return strings.ToLower(strings.NewReplacer(".", "_", "-", "_").Replace(key))
+}
+
+func lookupProfileKey(key string) (*profileKey, bool) {
+	normalized := normalizeKey(key)
+	for i, k := range profileKeys {
+		if normalizeKey(k.name) == normalized {
+			return &profileKeys[i], true
+		}
+		for _, alias := range k.aliases {
+			if normalizeKey(alias) == normalized {
+				return &profileKeys[i], true
+			}
+		}
+	}
+	return nil, false
+}
+
+// profileKeyNames returns the names of the keys that can be set, for help and error messages
+func profileKeyNames() []string {
+	names := []string{}
+	for _, k := range profileKeys {
+		names = append(names, k.name)
+	}
+	names = append(names, FeatureConfigPrefix+"NAME", DefaultsConfigPrefix+"FLAG")
+	sort.Strings(names)
+	return names
+}
+
+// profileSetting is a validated KEY=VALUE argument of "config set", applied to a context
+type profileSetting struct {
+	key   string
+	apply func(ctx *Context)
+}
+
+// parseProfileSetting parses and validates a KEY=VALUE argument of "config set". Unknown keys are
+// refused unless force is true, in which case they are stored in the profile as is.
+func parseProfileSetting(arg string, force bool) (*profileSetting, error) {
+	if strings.HasPrefix(arg, FeatureConfigPrefix) {
+		name, enabled, err := parseFeatureSetting(arg)
+		if err != nil {
+			return nil, err
+		}
+		return &profileSetting{key: FeatureConfigPrefix + name, apply: func(ctx *Context) {
+			if enabled {
+				if ctx.Features == nil {
+					ctx.Features = map[string]bool{}
+				}
+				ctx.Features[name] = true
+			} else {
+				delete(ctx.Features, name)
+			}
+			log.WithFields(log.Fields{"feature": name, "enabled": enabled}).Info("Updated feature setting")
+		}}, nil
+	}
+
+	if strings.HasPrefix(arg, DefaultsConfigPrefix) {
+		name, value, err := parseDefaultSetting(arg)
+		if err != nil {
+			return nil, err
+		}
+		return &profileSetting{key: DefaultsConfigPrefix + name, apply: func(ctx *Context) {
+			if value != "" {
+				if ctx.Defaults == nil {
+					ctx.Defaults = map[string]string{}
+				}
+				ctx.Defaults[name] = value
+			} else {
+				delete(ctx.Defaults, name)
+			}
+		}}, nil
+	}
+
+	key, value, found := strings.Cut(arg, "=")
+	if !found || key == "" {
+		return nil, fmt.Errorf("unexpected argument %q; expected KEY=VALUE", arg)
+	}
+	if k, known := lookupProfileKey(key); known {
+		// validate the value upfront, so that the config file is not modified if any is invalid
+		if k.parse != nil {
+			parsed, err := k.parse(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value %q for %q: %w", value, k.name, err)
+			}
+			value = parsed
+		}
+		return &profileSetting{key: k.name, apply: func(ctx *Context) { k.set(ctx, value) }}, nil
+	}
+	if normalizeKey(key) == "name" {
+		return nil, fmt.Errorf("the profile name can't be changed with %q; use --profile to select the profile", arg)
+	}
+	if !force {
+		return nil, fmt.Errorf("unknown key %q; must be one of %v (or use --force to set it anyway)", key, strings.Join(profileKeyNames(), ", "))
+	}
+	log.Warnf("Setting unknown key %q in the profile (--force)", key)
+	return &profileSetting{key: key, apply: func(ctx *Context) {
+		if ctx.Extra == nil {
+			ctx.Extra = map[string]any{}
+		}
+		setNestedValue(ctx.Extra, strings.Split(key, "."), value)
+	}}, nil
+}
+
+// setNestedValue sets the value at a dotted path in nested maps, creating them as needed
+func setNestedValue(m map[string]any, path []string, value any) {
+	for _, p := range path[:len(path)-1] {
+		next, ok := m[p].(map[string]any)
+		if !ok {
+			next = map[string]any{}
+			m[p] = next
+		}
+		m = next
+	}
+	m[path[len(path)-1]] = value
+}
@@ -0,0 +1,100 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func applySettings(t *testing.T, ctx *Context, force bool, args ...string) {
+	for _, arg := range args {
+		s, err := parseProfileSetting(arg, force)
+		require.NoError(t, err, arg)
+		s.apply(ctx)
+	}
+}
+
+func TestParseProfileSetting(t *testing.T) {
+	ctx := &Context{Name: "test"}
+	applySettings(t, ctx, false,
+		"auth.method=oauth", "Tenant=xyz", "url=mytenant.example.com", "refresh-token=r", "appd-pid=PID")
+	assert.Equal(t, AuthMethodOAuth, ctx.AuthMethod)
+	assert.Equal(t, "xyz", ctx.Tenant)
+	assert.Equal(t, "https://mytenant.example.com", ctx.URL)
+	assert.Equal(t, "r", ctx.RefreshToken)
+	assert.Equal(t, "PID", ctx.LocalAuthOptions.AppdPid)
+	assert.Nil(t, ctx.Extra)
+
+	// values are validated
+	for _, arg := range []string{"auth=bogus", "url=ftp://example.com", "features.nonexistent=true", "name=other", "novalue", "=x"} {
+		_, err := parseProfileSetting(arg, true)
+		assert.Error(t, err, arg)
+	}
+
+	// unknown keys require --force
+	_, err := parseProfileSetting("custom.level=debug", false)
+	assert.ErrorContains(t, err, "--force")
+	applySettings(t, ctx, true, "custom.level=debug", "custom.color=auto")
+	assert.Equal(t, map[string]any{"custom": map[string]any{"level": "debug", "color": "auto"}}, ctx.Extra)
+}
+
+func TestProfileSettingSecretFile(t *testing.T) {
+	ctx := &Context{CsvFile: "old.csv"}
+	applySettings(t, ctx, false, "secret_file=creds.json")
+	assert.True(t, filepath.IsAbs(ctx.SecretFile))
+	assert.Equal(t, "", ctx.CsvFile)
+
+	applySettings(t, ctx, false, "secret_file=${CREDS}")
+	assert.Equal(t, "${CREDS}", ctx.SecretFile)
+}
+
+func TestExtraKeysArePreserved(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fsoc.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`contexts:
+  - name: a
+    auth_method: none
+    url: https://a.example.com
+    custom:
+      level: debug
+current_context: a
+`), 0600))
+	viper.Reset()
+	defer viper.Reset()
+	viper.SetConfigFile(path)
+	viper.SetConfigType("yaml")
+	require.NoError(t, viper.ReadInConfig())
+
+	ctx := GetCurrentContext()
+	assert.Equal(t, map[string]any{"custom": map[string]any{"level": "debug"}}, ctx.Extra)
+	applySettings(t, ctx, false, "tenant=xyz")
+	ReplaceCurrentContext(ctx)
+
+	reread := viper.New()
+	reread.SetConfigFile(path)
+	require.NoError(t, reread.ReadInConfig())
+	var cfg configFileContents
+	require.NoError(t, reread.Unmarshal(&cfg))
+	require.Len(t, cfg.Contexts, 1)
+	assert.Equal(t, "xyz", cfg.Contexts[0].Tenant)
+	assert.Equal(t, map[string]any{"custom": map[string]any{"level": "debug"}}, cfg.Contexts[0].Extra)
+	b, _ := os.ReadFile(path)
+	assert.NotContains(t, string(b), "extra")
+}
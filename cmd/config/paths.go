@@ -0,0 +1,176 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/apex/log"
+)
+
+// Environment variables that locate fsoc's files
+const (
+	// ConfigHomeEnvVar overrides the directory of the config file and the other config files
+	ConfigHomeEnvVar = "FSOC_CONFIG_HOME"
+
+	// configDirEnvVar overrides the directory of the auxiliary config files only (e.g., saved queries)
+	configDirEnvVar = "FSOC_CONFIG_DIR"
+)
+
+// Names of fsoc's files and directories, see ConfigHome and StateHome
+const (
+	appDirName     = "fsoc"
+	configFileName = "config.yaml"
+	logFileName    = "fsoc.log"
+)
+
+// LegacyConfigFile is the location of the config file before the XDG base directories were adopted;
+// it is migrated to the config home automatically (see MigrateLegacyConfig)
+const LegacyConfigFile = "~/.fsoc"
+
+// Descriptions of the default locations, for help texts
+const (
+	ConfigHomeDescription = "$" + ConfigHomeEnvVar + ", or $XDG_CONFIG_HOME/fsoc, which is ~/.config/fsoc by default (%AppData%\\fsoc on Windows)"
+	DefaultConfigFile     = configFileName + " in " + ConfigHomeDescription
+)
+
+// ConfigHome returns the directory of fsoc's config files: $FSOC_CONFIG_HOME if set, or the fsoc
+// directory in the XDG config home, $XDG_CONFIG_HOME or ~/.config. On Windows, the XDG config home
+// defaults to the roaming application data directory, %AppData%.
+func ConfigHome() string {
+	if dir := os.Getenv(ConfigHomeEnvVar); dir != "" {
+		return dir
+	}
+	return filepath.Join(xdgDir("XDG_CONFIG_HOME", ".config", os.UserConfigDir), appDirName)
+}
+
+// StateHome returns the directory of fsoc's state files, e.g., its log: the fsoc directory in the
+// XDG state home, $XDG_STATE_HOME or ~/.local/state. On Windows, the XDG state home defaults to the
+// local application data directory, %LocalAppData%, which is not synchronized across machines.
+func StateHome() string {
+	return filepath.Join(xdgDir("XDG_STATE_HOME", filepath.Join(".local", "state"), os.UserCacheDir), appDirName)
+}
+
+// DefaultLogFile returns the default location of the fsoc log file, in the state home
+func DefaultLogFile() string {
+	return filepath.Join(StateHome(), logFileName)
+}
+
+// DefaultConfigFilePath returns the path of the config file used when none is specified: the one in the
+// config home or, if it doesn't exist yet but the legacy config file does (not migrated), the legacy one
+func DefaultConfigFilePath() string {
+	path := filepath.Join(ConfigHome(), configFileName)
+	if os.Getenv(ConfigHomeEnvVar) != "" || fileExists(path) {
+		return path
+	}
+	if legacy := expandHomePath(LegacyConfigFile); fileExists(legacy) {
+		return legacy
+	}
+	return path
+}
+
+// GetConfigDir returns the directory where fsoc keeps auxiliary configuration files (e.g., saved queries):
+// $FSOC_CONFIG_DIR if set, or the config home. The directory is not created automatically; callers that
+// write into it should create it as needed.
+func GetConfigDir() string {
+	if dir := os.Getenv(configDirEnvVar); dir != "" {
+		return dir
+	}
+	return ConfigHome()
+}
+
+// MigrateLegacyConfig moves the legacy config file (~/.fsoc) into the config home, unless it is overridden with $FSOC_CONFIG_HOME or already has a config file. A symbolic
+// link to the migrated config file is left in place of the legacy one, so that older fsoc versions
+// keep working. It returns the path of the migrated config file, or an empty string if nothing was migrated.
+func MigrateLegacyConfig() (string, error) {
+	if os.Getenv(ConfigHomeEnvVar) != "" {
+		return "", nil
+	}
+	home := ConfigHome()
+	path := filepath.Join(home, configFileName)
+	legacyFile := expandHomePath(LegacyConfigFile)
+	if fileExists(path) || !isRegularFile(legacyFile) {
+		return "", nil
+	}
+
+	if err := os.MkdirAll(home, 0700); err != nil {
+		return "", err
+	}
+	if err := moveFile(legacyFile, path); err != nil {
+		return "", fmt.Errorf("failed to move %s to %s: %w", legacyFile, path, err)
+	}
+	if err := os.Symlink(path, legacyFile); err != nil {
+		log.Infof("Failed to link %s to the migrated config file: %v", legacyFile, err)
+	}
+	return path, nil
+}
+
+// xdgDir returns the XDG base directory from the environment variable or, if it is not set (or not
+// an absolute path, as the XDG specification requires), the default subdirectory of the home directory.
+// On Windows, the directory returned by windowsDefault is used instead of the home subdirectory.
+func xdgDir(envVar string, defaultSubdir string, windowsDefault func() (string, error)) string {
+	if dir := os.Getenv(envVar); dir != "" && filepath.IsAbs(dir) {
+		return dir
+	}
+	if runtime.GOOS == "windows" {
+		if dir, err := windowsDefault(); err == nil {
+			return dir
+		}
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		log.Fatalf("failed to determine the home directory: %v", err)
+	}
+	return filepath.Join(home, defaultSubdir)
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func isRegularFile(path string) bool {
+	info, err := os.Lstat(path)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// moveFile renames a file or, if that fails (e.g., across file systems), copies it and removes the original
+func moveFile(from string, to string) error {
+	if err := os.Rename(from, to); err == nil {
+		return nil
+	}
+	src, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(to, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		os.Remove(to)
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	return os.Remove(from)
+}
@@ -0,0 +1,92 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setTestHome isolates the locations of fsoc's files in a temporary home directory
+func setTestHome(t *testing.T) string {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("XDG_STATE_HOME", "")
+	t.Setenv(ConfigHomeEnvVar, "")
+	t.Setenv(configDirEnvVar, "")
+	return home
+}
+
+func TestXDGLocations(t *testing.T) {
+	home := setTestHome(t)
+	assert.Equal(t, filepath.Join(home, ".config", "fsoc"), ConfigHome())
+	assert.Equal(t, filepath.Join(home, ".config", "fsoc", "config.yaml"), DefaultConfigFilePath())
+	assert.Equal(t, filepath.Join(home, ".config", "fsoc"), GetConfigDir())
+	assert.Equal(t, filepath.Join(home, ".local", "state", "fsoc", "fsoc.log"), DefaultLogFile())
+
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, "xdg"))
+	t.Setenv("XDG_STATE_HOME", "relative") // ignored, must be absolute
+	assert.Equal(t, filepath.Join(home, "xdg", "fsoc"), ConfigHome())
+	assert.Equal(t, filepath.Join(home, ".local", "state", "fsoc"), StateHome())
+
+	t.Setenv(ConfigHomeEnvVar, filepath.Join(home, "custom"))
+	assert.Equal(t, filepath.Join(home, "custom", "config.yaml"), DefaultConfigFilePath())
+	t.Setenv(configDirEnvVar, filepath.Join(home, "aux"))
+	assert.Equal(t, filepath.Join(home, "aux"), GetConfigDir())
+}
+
+func TestMigrateLegacyConfig(t *testing.T) {
+	home := setTestHome(t)
+	legacyFile := filepath.Join(home, ".fsoc")
+	require.NoError(t, os.WriteFile(legacyFile, []byte("current_context: a\n"), 0600))
+
+	// not migrated yet: the legacy config file is used
+	assert.Equal(t, legacyFile, DefaultConfigFilePath())
+	assert.Equal(t, filepath.Join(home, ".config", "fsoc"), GetConfigDir())
+
+	migrated, err := MigrateLegacyConfig()
+	require.NoError(t, err)
+	newFile := filepath.Join(home, ".config", "fsoc", "config.yaml")
+	assert.Equal(t, newFile, migrated)
+	assert.Equal(t, newFile, DefaultConfigFilePath())
+	assert.Equal(t, filepath.Join(home, ".config", "fsoc"), GetConfigDir())
+	data, err := os.ReadFile(newFile)
+	require.NoError(t, err)
+	assert.Equal(t, "current_context: a\n", string(data))
+
+	// the legacy file links to the new one, and isn't migrated again
+	if target, err := os.Readlink(legacyFile); err == nil {
+		assert.Equal(t, newFile, target)
+	}
+	migrated, err = MigrateLegacyConfig()
+	require.NoError(t, err)
+	assert.Empty(t, migrated)
+}
+
+func TestNoMigrationWithConfigHomeOverride(t *testing.T) {
+	home := setTestHome(t)
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".fsoc"), []byte("current_context: a\n"), 0600))
+	t.Setenv(ConfigHomeEnvVar, filepath.Join(home, "custom"))
+
+	migrated, err := MigrateLegacyConfig()
+	require.NoError(t, err)
+	assert.Empty(t, migrated)
+	assert.FileExists(t, filepath.Join(home, ".fsoc"))
+}
@@ -0,0 +1,145 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/spf13/viper"
+)
+
+// promptStateFileName is the name of the file, in the state home, that exposes the current profile of
+// the default config file to the shell prompt (see "fsoc shell-init")
+const promptStateFileName = "prompt"
+
+// PromptStateFile returns the path of the prompt state file. It has a "key=value" line for each of the
+// current profile's name ("profile"), tenant ID ("tenant") and the host name of its URL ("host");
+// the values are empty if there is no current profile.
+func PromptStateFile() string {
+	return filepath.Join(StateHome(), promptStateFileName)
+}
+
+// RefreshPromptState updates the prompt state file from the default config file, e.g., when the shell
+// integration is initialized, before fsoc had a chance to maintain the file
+func RefreshPromptState() error {
+	path := DefaultConfigFilePath()
+	var cfg configFileContents
+	if _, err := os.Stat(path); err == nil {
+		v := viper.New()
+		v.SetConfigFile(path)
+		v.SetConfigType("yaml")
+		if err := v.ReadInConfig(); err != nil {
+			return fmt.Errorf("failed to read config file %q: %w", path, err)
+		}
+		if err := v.Unmarshal(&cfg); err != nil {
+			return fmt.Errorf("failed to parse config file %q: %w", path, err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return writePromptState(&cfg)
+}
+
+// updatePromptState updates the prompt state file after the config file at path was modified, unless
+// it is not the default config file, which the shell prompt reflects
+func updatePromptState(v *viper.Viper, path string) {
+	if !isDefaultConfigFile(path) {
+		return
+	}
+	var cfg configFileContents
+	if err := v.Unmarshal(&cfg); err != nil {
+		log.Warnf("Failed to update the shell prompt state: %v", err)
+		return
+	}
+	if err := writePromptState(&cfg); err != nil {
+		log.Warnf("Failed to update the shell prompt state: %v", err)
+	}
+}
+
+// writePromptState writes the current profile of the config into the prompt state file, replacing it
+// atomically so that the shell never reads it partially written; the file is not touched if unchanged
+func writePromptState(cfg *configFileContents) error {
+	profile := cfg.CurrentContext
+	if profile == "" {
+		profile = DefaultContext
+	}
+	var state bytes.Buffer
+	found := false
+	for _, c := range cfg.Contexts {
+		if c.Name == profile {
+			found = true
+			fmt.Fprintf(&state, "profile=%s\ntenant=%s\nhost=%s\n", promptValue(c.Name), promptValue(c.Tenant), promptValue(urlHost(c.URL)))
+			break
+		}
+	}
+	if !found {
+		state.WriteString("profile=\ntenant=\nhost=\n")
+	}
+
+	path := PromptStateFile()
+	if existing, err := os.ReadFile(path); err == nil && bytes.Equal(existing, state.Bytes()) {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), promptStateFileName+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+	if _, err := tmp.Write(state.Bytes()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// isDefaultConfigFile returns true if path (absolute, with symbolic links resolved) is the default config file
+func isDefaultConfigFile(path string) bool {
+	defaultPath, err := filepath.Abs(DefaultConfigFilePath())
+	if err != nil {
+		return false
+	}
+	if resolved, err := filepath.EvalSymlinks(defaultPath); err == nil {
+		defaultPath = resolved
+	}
+	return path == defaultPath
+}
+
+// urlHost returns the host name of a URL, or an empty string if it cannot be parsed (e.g., a reference
+// to an environment variable)
+func urlHost(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
+
+// promptValue makes a value safe for the line-oriented prompt state file
+func promptValue(s string) string {
+	return strings.NewReplacer("\n", " ", "\r", " ").Replace(s)
+}
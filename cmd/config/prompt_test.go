@@ -0,0 +1,84 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPromptStateFollowsProfileSwitch(t *testing.T) {
+	home := setTestHome(t)
+	path := filepath.Join(home, ".config", "fsoc", "config.yaml")
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0700))
+	require.NoError(t, os.WriteFile(path, []byte(`contexts:
+  - name: dev
+    auth_method: none
+    url: https://dev.example.com
+    tenant: t-dev
+  - name: prod
+    auth_method: none
+    url: https://prod.example.com:443
+    tenant: t-prod
+current_context: dev
+`), 0600))
+	viper.Reset()
+	defer viper.Reset()
+	viper.SetConfigFile(path)
+	viper.SetConfigType("yaml")
+	require.NoError(t, viper.ReadInConfig())
+
+	require.NoError(t, RefreshPromptState())
+	assertPromptState(t, "profile=dev\ntenant=t-dev\nhost=dev.example.com\n")
+
+	require.NoError(t, SetCurrentContext("prod"))
+	assertPromptState(t, "profile=prod\ntenant=t-prod\nhost=prod.example.com\n")
+
+	DeleteContext("prod")
+	assertPromptState(t, "profile=\ntenant=\nhost=\n")
+}
+
+func TestPromptStateIgnoresOtherConfigFiles(t *testing.T) {
+	home := setTestHome(t)
+	path := filepath.Join(home, "other.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`contexts:
+  - name: a
+    auth_method: none
+  - name: b
+    auth_method: none
+current_context: a
+`), 0600))
+	viper.Reset()
+	defer viper.Reset()
+	viper.SetConfigFile(path)
+	viper.SetConfigType("yaml")
+	require.NoError(t, viper.ReadInConfig())
+
+	require.NoError(t, SetCurrentContext("b"))
+	_, err := os.Stat(PromptStateFile())
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func assertPromptState(t *testing.T, expected string) {
+	t.Helper()
+	state, err := os.ReadFile(PromptStateFile())
+	require.NoError(t, err)
+	assert.Equal(t, expected, string(state))
+}
@@ -0,0 +1,143 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/cisco-open/fsoc/cmdkit/offline"
+)
+
+// Sources of the resolved settings, see resolveSettings
+const (
+	sourceFlag          = "command line"
+	sourceConfigFile    = "config file"
+	sourceProfile       = "profile"
+	sourceEnv           = "environment"
+	sourceBuiltin       = "built-in default"
+	sourceSecretStore   = "credential store"
+	sourceDefaultConfig = "default location"
+)
+
+// resolvedSetting is a setting in effect for the current profile, with the value's origin
+type resolvedSetting struct {
+	Setting string `json:"setting" yaml:"setting"`
+	Value   string `json:"value" yaml:"value"`
+	Source  string `json:"source" yaml:"source"`
+}
+
+// resolveSettings returns the settings in effect for the command, with the current profile resolved as
+// the commands using it see it (references to environment variables expanded, secrets read from the
+// credential store), and where each value comes from. Secret values are masked unless unmask is true.
+func resolveSettings(cmd *cobra.Command, ctx *Context, unmask bool) []resolvedSetting {
+	var settings []resolvedSetting
+	add := func(setting, value, source string) {
+		settings = append(settings, resolvedSetting{Setting: setting, Value: value, Source: source})
+	}
+
+	// the config file and the profile selection
+	switch {
+	case cmd.Flags().Changed("config"):
+		add("config file", viper.ConfigFileUsed(), sourceFlag+" (--config)")
+	case os.Getenv(ConfigHomeEnvVar) != "":
+		add("config file", viper.ConfigFileUsed(), sourceEnv+" ($"+ConfigHomeEnvVar+")")
+	default:
+		add("config file", viper.ConfigFileUsed(), sourceDefaultConfig)
+	}
+	switch {
+	case cmd.Flags().Changed("profile"):
+		add("profile", ctx.Name, sourceFlag+" (--profile)")
+	case getConfig().CurrentContext != "":
+		add("profile", ctx.Name, sourceConfigFile+" (current_context)")
+	default:
+		add("profile", ctx.Name, sourceBuiltin)
+	}
+
+	// the profile fields, which may reference environment variables or be kept in the credential store
+	fields := ctx.envFields()
+	secrets := ctx.secretFields()
+	for _, k := range profileKeys {
+		key := k.name
+		raw := ctx.AuthMethod // the auth method can't reference environment variables
+		if field, found := fields[key]; found {
+			raw = *field
+		} else if key != "auth_method" {
+			continue
+		}
+		if raw == "" {
+			continue
+		}
+		value, source := raw, sourceProfile
+		switch {
+		case raw == secretStoreMarker:
+			source = sourceSecretStore
+			if osSecretStore == nil {
+				value = "(credential store not supported on this platform)"
+			} else if secret, err := osSecretStore.get(secretTarget(ctx.Name, key)); err != nil {
+				value = fmt.Sprintf("(failed to read: %v)", err)
+			} else {
+				value = secret
+				source += " (" + osSecretStore.name() + ")"
+			}
+		case hasEnvReference(raw):
+			var missing []string
+			value, missing = expandEnvReferences(raw)
+			source = fmt.Sprintf("%s (%s in the profile)", sourceEnv, raw)
+			if len(missing) > 0 {
+				source += ", not set: " + strings.Join(missing, ", ")
+			}
+		}
+		if _, secret := secrets[key]; secret && !unmask && !strings.HasPrefix(value, "(") {
+			value = "(present)"
+		}
+		add(key, value, source)
+	}
+
+	// the features enabled or disabled in the profile
+	names := make([]string, 0, len(ctx.Features))
+	for name := range ctx.Features {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		add(FeatureConfigPrefix+name, fmt.Sprint(ctx.Features[name]), sourceProfile)
+	}
+
+	// the global flags that can have defaults in the profile
+	for _, name := range DefaultableFlags {
+		flag := cmd.Flags().Lookup(name)
+		if flag == nil {
+			continue
+		}
+		_, hasDefault := ctx.Defaults[name]
+		switch {
+		case flag.Changed:
+			add("--"+name, flag.Value.String(), sourceFlag)
+		case name == offline.FlagName && os.Getenv(offline.EnvVar) != "":
+			add("--"+name, "true", sourceEnv+" ($"+offline.EnvVar+")")
+		case hasDefault:
+			add("--"+name, flag.Value.String(), sourceProfile+" (defaults."+name+")")
+		default:
+			add("--"+name, flag.Value.String(), sourceBuiltin)
+		}
+	}
+	return settings
+}
@@ -0,0 +1,85 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cisco-open/fsoc/cmdkit/offline"
+)
+
+func TestResolveSettings(t *testing.T) {
+	store := fakeSecretStore{}
+	saved := osSecretStore
+	osSecretStore = store
+	defer func() { osSecretStore = saved }()
+
+	path := filepath.Join(t.TempDir(), "fsoc.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("contexts: []\n"), 0600))
+	viper.Reset()
+	defer viper.Reset()
+	viper.SetConfigFile(path)
+	viper.SetConfigType("yaml")
+	require.NoError(t, viper.ReadInConfig())
+	UpsertContext(&Context{
+		Name:       "p",
+		AuthMethod: AuthMethodOAuth,
+		URL:        "https://${TENANT_HOST}",
+		Token:      "access",
+		Features:   map[string]bool{"beta": true},
+		Defaults:   map[string]string{"output": "json"},
+	})
+	t.Setenv("TENANT_HOST", "p.example.com")
+	t.Setenv(ConfigHomeEnvVar, "")
+	t.Setenv(offline.EnvVar, "1")
+
+	cmd := &cobra.Command{}
+	cmd.Flags().String("config", "", "")
+	cmd.Flags().String("profile", "", "")
+	cmd.Flags().String("output", "auto", "")
+	cmd.Flags().Duration("timeout", 0, "")
+	cmd.Flags().Bool(offline.FlagName, false, "")
+	require.NoError(t, cmd.ParseFlags([]string{"--profile", "p", "--timeout", "5m"}))
+	require.NoError(t, cmd.Flags().Lookup("output").Value.Set("json")) // as applied from the profile's defaults
+
+	ctx := getConfig().Contexts[0]
+	settings := map[string]resolvedSetting{}
+	for _, s := range resolveSettings(cmd, &ctx, false) {
+		settings[s.Setting] = s
+	}
+	assert.Equal(t, resolvedSetting{"config file", path, sourceDefaultConfig}, settings["config file"])
+	assert.Equal(t, resolvedSetting{"profile", "p", "command line (--profile)"}, settings["profile"])
+	assert.Equal(t, resolvedSetting{"auth_method", "oauth", sourceProfile}, settings["auth_method"])
+	assert.Equal(t, resolvedSetting{"url", "https://p.example.com", "environment (https://${TENANT_HOST} in the profile)"}, settings["url"])
+	assert.Equal(t, resolvedSetting{"token", "(present)", "credential store (the test store)"}, settings["token"])
+	assert.Equal(t, resolvedSetting{"features.beta", "true", sourceProfile}, settings["features.beta"])
+	assert.Equal(t, resolvedSetting{"--output", "json", "profile (defaults.output)"}, settings["--output"])
+	assert.Equal(t, resolvedSetting{"--timeout", "5m0s", sourceFlag}, settings["--timeout"])
+	assert.Equal(t, resolvedSetting{"--offline", "true", "environment ($FSOC_OFFLINE)"}, settings["--offline"])
+	assert.NotContains(t, settings, "tenant")
+
+	for _, s := range resolveSettings(cmd, &ctx, true) {
+		if s.Setting == "token" {
+			assert.Equal(t, "access", s.Value)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+
+	"github.com/apex/log"
+)
+
+// secretStoreMarker replaces, in the config file, the profile secrets that are kept in the operating
+// system's credential store (see osSecretStore)
+const secretStoreMarker = "@credential-store"
+
+// secretStore is a credential store of the operating system, in which secrets are identified by target names
+type secretStore interface {
+	name() string
+	get(target string) (string, error)
+	set(target string, secret string) error
+	delete(target string) error
+}
+
+// secretFields returns the profile fields that are kept in the credential store, by config key
+func (c *Context) secretFields() map[string]*string {
+	return map[string]*string{
+		"token":         &c.Token,
+		"refresh_token": &c.RefreshToken,
+	}
+}
+
+// secretTarget returns the name under which a profile secret is kept in the credential store. It includes
+// the config file's path, so that profiles with the same name in different config files don't collide.
+func secretTarget(profile string, key string) string {
+	path, err := configFilePath()
+	if err != nil {
+		path = DefaultConfigFilePath()
+	}
+	return fmt.Sprintf("fsoc:%s:%s:%s", path, profile, key)
+}
+
+// storeSecrets moves the profile secrets into the credential store, if the platform has one, replacing them
+// with secretStoreMarker. References to environment variables are left as they are. Secrets that fail to
+// be stored (e.g., because they are too large) are kept in the profile.
+func (c *Context) storeSecrets() {
+	if osSecretStore == nil {
+		return
+	}
+	for key, field := range c.secretFields() {
+		value := *field
+		switch {
+		case value == secretStoreMarker || hasEnvReference(value):
+			continue
+		case value == "":
+			if err := osSecretStore.delete(secretTarget(c.Name, key)); err != nil {
+				log.WithFields(log.Fields{"profile": c.Name, "key": key}).Infof("Failed to remove the secret from %s: %v", osSecretStore.name(), err)
+			}
+			continue
+		}
+		if err := osSecretStore.set(secretTarget(c.Name, key), value); err != nil {
+			log.WithFields(log.Fields{"profile": c.Name, "key": key}).Infof("Keeping the secret in the config file, failed to store it in %s: %v", osSecretStore.name(), err)
+			continue
+		}
+		*field = secretStoreMarker
+	}
+}
+
+// loadSecrets replaces the secretStoreMarker values of the profile with the secrets from the credential store
+func (c *Context) loadSecrets() error {
+	for key, field := range c.secretFields() {
+		if *field != secretStoreMarker {
+			continue
+		}
+		if osSecretStore == nil {
+			return fmt.Errorf("the %s of profile %q is kept in a credential store that is not supported on this platform; please log in again", key, c.Name)
+		}
+		secret, err := osSecretStore.get(secretTarget(c.Name, key))
+		if err != nil {
+			return fmt.Errorf("failed to read the %s of profile %q from %s: %w", key, c.Name, osSecretStore.name(), err)
+		}
+		*field = secret
+	}
+	return nil
+}
+
+// deleteSecrets removes the secrets of the named profile from the credential store, if any
+func deleteSecrets(profile string) {
+	if osSecretStore == nil {
+		return
+	}
+	for key := range (&Context{}).secretFields() {
+		if err := osSecretStore.delete(secretTarget(profile, key)); err != nil {
+			log.WithFields(log.Fields{"profile": profile, "key": key}).Infof("Failed to remove the secret from %s: %v", osSecretStore.name(), err)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSecretStore is an in-memory credential store
+type fakeSecretStore map[string]string
+
+func (s fakeSecretStore) name() string {
+	return "the test store"
+}
+
+func (s fakeSecretStore) get(target string) (string, error) {
+	secret, found := s[target]
+	if !found {
+		return "", errors.New("not found")
+	}
+	return secret, nil
+}
+
+func (s fakeSecretStore) set(target string, secret string) error {
+	s[target] = secret
+	return nil
+}
+
+func (s fakeSecretStore) delete(target string) error {
+	delete(s, target)
+	return nil
+}
+
+func TestSecretsInCredentialStore(t *testing.T) {
+	store := fakeSecretStore{}
+	saved := osSecretStore
+	osSecretStore = store
+	defer func() { osSecretStore = saved }()
+
+	path := filepath.Join(t.TempDir(), "fsoc.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("contexts: []\n"), 0600))
+	viper.Reset()
+	defer viper.Reset()
+	viper.SetConfigFile(path)
+	viper.SetConfigType("yaml")
+	require.NoError(t, viper.ReadInConfig())
+
+	UpsertContext(&Context{Name: "p", AuthMethod: AuthMethodOAuth, URL: "https://p.example.com", Token: "access", RefreshToken: "${REFRESH}"})
+	raw := getConfig().Contexts[0]
+	assert.Equal(t, secretStoreMarker, raw.Token)
+	assert.Equal(t, "${REFRESH}", raw.RefreshToken) // references to environment variables stay in the file
+	assert.Len(t, store, 1)
+
+	t.Setenv("REFRESH", "refresh")
+	viper.Set("current_context", "p")
+	ctx, err := LoadCurrentContext()
+	require.NoError(t, err)
+	assert.Equal(t, "access", ctx.Token)
+	assert.Equal(t, "refresh", ctx.RefreshToken)
+
+	// clearing a secret removes it from the store
+	ctx.Token = ""
+	UpsertContext(ctx)
+	assert.Empty(t, getConfig().Contexts[0].Token)
+	assert.Empty(t, store)
+
+	// without a credential store, the stored secrets can't be loaded
+	UpsertContext(&Context{Name: "p", AuthMethod: AuthMethodOAuth, URL: "https://p.example.com", Token: "access"})
+	osSecretStore = nil
+	_, err = LoadCurrentContext()
+	assert.Error(t, err)
+
+	osSecretStore = store
+	assert.True(t, DeleteContext("p"))
+	assert.Empty(t, store)
+}
@@ -33,7 +33,20 @@ var (
 	setContextLong = `Create or modify a context entry in an fsoc config file.
 
 Specifying a name that already exists will merge new fields on top of existing values for those fields.
-if on context name is specified, the current context is created/updated.`
+if on context name is specified, the current context is created/updated.
+
+Values can reference environment variables as ${NAME}, which are resolved each time the profile is used
+(e.g., to keep secrets out of the config file); using the profile fails if a referenced variable is not set.
+Use $${NAME} for a literal ${NAME}.
+
+Profile fields can also be set with KEY=VALUE arguments, where KEY is the field's name in the config file;
+".", "-" and "_" are interchangeable in keys (e.g., auth.method is the same as auth_method). Values are
+validated before the config file is modified. Keys that are not part of the profile are refused, unless
+--force is specified, in which case they are stored in the profile as given.
+
+Use defaults.FLAG=VALUE to set the value of a global flag for all commands using the profile, unless the
+flag is given on the command line. The flags that can have defaults are --output, --fields, --fields-file,
+--distinct, --timeout, --max-time, --no-compression and --offline.`
 
 	setContextExample = `
   # Set oauth credentials (recommended for interactive use)
@@ -47,17 +60,29 @@ if on context name is specified, the current context is created/updated.`
   # Set local access
   fsoc config set --auth=local url=http://localhost --appd-pid=PID --appd-tid=TID --appd-pty=PTY
 
+  # Set fields with KEY=VALUE arguments
+  fsoc config set auth.method=oauth tenant=xyz url=https://mytenant.observe.appdynamics.com
+
   # Set the token field on the "prod" context entry without touching other values
-  fsoc config set --profile prod --token=top-secret`
+  fsoc config set --profile prod --token=top-secret
+
+  # Keep the secret out of the config file, reading it from an environment variable when the profile is used
+  fsoc config set --profile prod --token='${FSOC_PROD_SECRET}'
+
+  # Enable an experimental feature in the current context (see "fsoc features list")
+  fsoc config set features.NAME=true
+
+  # Produce JSON output by default for all commands using the "analytics" profile (empty value removes it)
+  fsoc config set --profile analytics defaults.output=json defaults.timeout=2m`
 )
 
 func newCmdConfigSet() *cobra.Command {
 
 	var cmd = &cobra.Command{
-		Use:         "set [--profile CONTEXT] --auth=AUTH [flags]",
+		Use:         "set [--profile CONTEXT] [--auth=AUTH] [flags] [KEY=VALUE]...",
 		Short:       "Create or modify a context entry in an fsoc config file",
 		Long:        setContextLong,
-		Args:        cobra.MaximumNArgs(1),
+		Args:        cobra.ArbitraryArgs,
 		Example:     setContextExample,
 		Annotations: map[string]string{AnnotationForConfigBypass: ""},
 		Run:         configSetContext,
@@ -72,6 +97,7 @@ func newCmdConfigSet() *cobra.Command {
 	cmd.Flags().String("tenant", "", "Set tenant ID")
 	cmd.Flags().String("token", "", "Set token value (use --token=- to get from stdin)")
 	cmd.Flags().String("secret-file", "", "Set a credentials file to use for service principal (.json or .csv) or agent principal (.yaml)")
+	cmd.Flags().Bool("force", false, "Allow setting keys that are not part of the profile")
 	return cmd
 }
 
@@ -98,131 +124,162 @@ func validateUrl(providedUrl string) (string, error) {
 func configSetContext(cmd *cobra.Command, args []string) {
 	var contextName string
 
-	// Check that either context name or current context is specified
-	if len(args) > 0 {
-		_ = cmd.Help()
-		log.Fatalf("Unexpected args: %v", args)
+	// Parse and validate KEY=VALUE settings, the only positional arguments allowed
+	flags := cmd.Flags()
+	force, _ := flags.GetBool("force")
+	settings := make([]*profileSetting, 0, len(args))
+	for _, arg := range args {
+		setting, err := parseProfileSetting(arg, force)
+		if err != nil {
+			_ = cmd.Help()
+			log.Fatal(err.Error())
+		}
+		settings = append(settings, setting)
 	}
 
 	// Check that at least one value is specified (including empty)
-	flags := cmd.Flags()
-	valid := false
+	valid := len(settings) > 0
 	flags.VisitAll(func(flag *pflag.Flag) {
-		valid = valid || flag.Changed
+		valid = valid || (flag.Changed && flag.Name != "force")
 	})
 	if !valid {
 		optionNames := make([]string, 0)
 		flags.VisitAll(func(flag *pflag.Flag) {
-			optionNames = append(optionNames, "--"+flag.Name)
+			if flag.Name != "force" {
+				optionNames = append(optionNames, "--"+flag.Name)
+			}
 		})
-		log.Fatalf("at least one of %v must be specified", strings.Join(optionNames, ", "))
+		log.Fatalf("at least one of %v or KEY=VALUE must be specified", strings.Join(optionNames, ", "))
 	}
 
 	// Get context name (whether it exists or not)
 	contextName = GetCurrentProfileName()
 
-	// Try to locate the named context
-	contextExists := false
-	var ctxPtr *Context
-	cfg := getConfig()
-	for idx, c := range cfg.Contexts {
-		if c.Name == contextName {
-			ctxPtr = &cfg.Contexts[idx]
-			contextExists = true
-			break
+	// Read the token from stdin, if requested, before locking the config file
+	var token string
+	if flags.Changed("token") {
+		token, _ = flags.GetString("token")
+		if token == "-" { // token to come from stdin
+			scanner := bufio.NewScanner(os.Stdin)
+			scanner.Scan()
+			token = scanner.Text()
 		}
 	}
 
-	// If context not found, create a new one
-	if !contextExists {
-		log.Infof("context %q doesn't exist, creating it", contextName)
+	// Update the context in the config file's current contents
+	contextExists := false
+	modifyConfigFile(func(cfg *configFileContents) map[string]interface{} {
+		// Try to locate the named context
+		var ctxPtr *Context
+		for idx, c := range cfg.Contexts {
+			if c.Name == contextName {
+				ctxPtr = &cfg.Contexts[idx]
+				contextExists = true
+				break
+			}
+		}
+
+		// If context not found, create a new one
+		if !contextExists {
+			log.Infof("context %q doesn't exist, creating it", contextName)
 
-		ctx := Context{
-			Name: contextName,
+			ctx := Context{
+				Name: contextName,
+			}
+			cfg.Contexts = append(cfg.Contexts, ctx)
+			ctxPtr = &cfg.Contexts[len(cfg.Contexts)-1]
 		}
-		cfg.Contexts = append(cfg.Contexts, ctx)
-		ctxPtr = &cfg.Contexts[len(cfg.Contexts)-1]
-	}
 
-	// update only the fields for which flags were specified explicitly
-	if flags.Changed("server") {
-		providedServer, _ := flags.GetString("server")
-		constructedUrl := "https://" + providedServer
-		cleanedUrl, err := validateUrl(constructedUrl)
-		if err != nil {
-			log.Fatal(err.Error())
+		// update only the fields for which flags were specified explicitly
+		if flags.Changed("server") {
+			providedServer, _ := flags.GetString("server")
+			constructedUrl := "https://" + providedServer
+			cleanedUrl, err := validateUrl(constructedUrl)
+			if err != nil {
+				log.Fatal(err.Error())
+			}
+			log.Warnf("The --server option is now deprecated. In the future, please use --url instead. We will set the url to %q for you now", cleanedUrl)
+			ctxPtr.URL = cleanedUrl
 		}
-		log.Warnf("The --server option is now deprecated. In the future, please use --url instead. We will set the url to %q for you now", cleanedUrl)
-		ctxPtr.URL = cleanedUrl
-	}
-	if flags.Changed("url") {
-		providedUrl, _ := flags.GetString("url")
-		cleanedUrl, err := validateUrl(providedUrl)
-		if err != nil {
-			log.Fatal(err.Error())
+		if flags.Changed("url") {
+			providedUrl, _ := flags.GetString("url")
+			cleanedUrl := providedUrl
+			if !hasEnvReference(providedUrl) { // validated when used
+				var err error
+				cleanedUrl, err = validateUrl(providedUrl)
+				if err != nil {
+					log.Fatal(err.Error())
+				}
+			}
+			ctxPtr.URL = cleanedUrl
 		}
-		ctxPtr.URL = cleanedUrl
-	}
-	if flags.Changed("tenant") {
-		ctxPtr.Tenant, _ = flags.GetString("tenant")
-	}
-	if flags.Changed("token") {
-		value, _ := flags.GetString("token")
-		if value == "-" { // token to come from stdin
-			scanner := bufio.NewScanner(os.Stdin)
-			scanner.Scan()
-			ctxPtr.Token = scanner.Text()
-		} else {
-			ctxPtr.Token = value
+		if flags.Changed("tenant") {
+			ctxPtr.Tenant, _ = flags.GetString("tenant")
 		}
-	}
-	if flags.Changed("secret-file") {
+		if flags.Changed("token") {
+			ctxPtr.Token = token
+		}
+		if flags.Changed("secret-file") {
 
-		path, _ := flags.GetString("secret-file")
-		path = expandHomePath(path)
-		var err error
-		ctxPtr.SecretFile, err = filepath.Abs(path)
-		if err != nil {
-			ctxPtr.SecretFile = path
+			path, _ := flags.GetString("secret-file")
+			if hasEnvReference(path) {
+				ctxPtr.SecretFile = path
+			} else {
+				path = expandHomePath(path)
+				var err error
+				ctxPtr.SecretFile, err = filepath.Abs(path)
+				if err != nil {
+					ctxPtr.SecretFile = path
+				}
+			}
+			ctxPtr.CsvFile = "" // CSV file is a backward-compatibility value only
 		}
-		ctxPtr.CsvFile = "" // CSV file is a backward-compatibility value only
-	}
-	if flags.Changed("auth") {
-		val, _ := flags.GetString("auth")
-		if val != "" && !slices.Contains(GetAuthMethodsStringList(), val) {
-			log.Fatalf(`Invalid --auth method %q; must be one of {"%v"}`, val, strings.Join(GetAuthMethodsStringList(), `", "`))
+		if flags.Changed("auth") {
+			val, _ := flags.GetString("auth")
+			if val != "" && !slices.Contains(GetAuthMethodsStringList(), val) {
+				log.Fatalf(`Invalid --auth method %q; must be one of {"%v"}`, val, strings.Join(GetAuthMethodsStringList(), `", "`))
+			}
+			ctxPtr.AuthMethod = val
 		}
-		ctxPtr.AuthMethod = val
-	}
 
-	if ctxPtr.AuthMethod == AuthMethodLocal {
-		if flags.Changed(AppdPid) {
-			pid, _ := flags.GetString(AppdPid)
-			ctxPtr.LocalAuthOptions.AppdPid = pid
+		if ctxPtr.AuthMethod == AuthMethodLocal {
+			if flags.Changed(AppdPid) {
+				pid, _ := flags.GetString(AppdPid)
+				ctxPtr.LocalAuthOptions.AppdPid = pid
+			}
+			if flags.Changed(AppdPty) {
+				pty, _ := flags.GetString(AppdPty)
+				ctxPtr.LocalAuthOptions.AppdPty = pty
+			}
+			if flags.Changed(AppdTid) {
+				tid, _ := flags.GetString(AppdTid)
+				ctxPtr.LocalAuthOptions.AppdTid = tid
+			}
 		}
-		if flags.Changed(AppdPty) {
-			pty, _ := flags.GetString(AppdPty)
-			ctxPtr.LocalAuthOptions.AppdPty = pty
+
+		// KEY=VALUE settings are applied after the flags, in the order given
+		for _, setting := range settings {
+			setting.apply(ctxPtr)
+			log.WithField("key", setting.key).Debug("Applied profile setting")
 		}
-		if flags.Changed(AppdTid) {
-			tid, _ := flags.GetString(AppdTid)
-			ctxPtr.LocalAuthOptions.AppdTid = tid
+
+		// upgrade config format from CsvFile to SecretFile, opportunistically using the update
+		if ctxPtr.SecretFile == "" && ctxPtr.CsvFile != "" {
+			ctxPtr.SecretFile = ctxPtr.CsvFile
+			ctxPtr.CsvFile = ""
 		}
-	}
 
-	// upgrade config format from CsvFile to SecretFile, opportunistically using the update
-	if ctxPtr.SecretFile == "" && ctxPtr.CsvFile != "" {
-		ctxPtr.SecretFile = ctxPtr.CsvFile
-		ctxPtr.CsvFile = ""
-	}
+		// keep the secrets in the operating system's credential store, if available
+		ctxPtr.storeSecrets()
 
-	// update config file
-	update := map[string]interface{}{"contexts": cfg.Contexts}
-	if !contextExists && len(cfg.Contexts) == 1 { // just created the first context, set it as current
-		update["current_context"] = contextName
-		log.WithField("profile", contextName).Info("Setting context as current")
-	}
-	updateConfigFile(update)
+		// update config file
+		update := map[string]interface{}{"contexts": cfg.Contexts}
+		if !contextExists && len(cfg.Contexts) == 1 { // just created the first context, set it as current
+			update["current_context"] = contextName
+			log.WithField("profile", contextName).Info("Setting context as current")
+		}
+		return update
+	})
 
 	if contextExists {
 		log.WithField("profile", contextName).Info("Updated context")
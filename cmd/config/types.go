@@ -20,11 +20,10 @@ import (
 )
 
 const (
-	DefaultConfigFile = "~/.fsoc"
-	DefaultContext    = "default"
-	AppdPid           = "appd-pid"
-	AppdTid           = "appd-tid"
-	AppdPty           = "appd-pty"
+	DefaultContext = "default"
+	AppdPid        = "appd-pid"
+	AppdTid        = "appd-tid"
+	AppdPty        = "appd-pty"
 )
 
 // Supported authentication methods
@@ -47,23 +46,28 @@ const (
 
 const (
 	AnnotationForConfigBypass = "config/bypass-check"
+	// AnnotationForFeature marks a command (and its subcommands) as available only when the named feature is enabled
+	AnnotationForFeature = "config/feature"
 )
 
 // Struct Context defines a full configuration context (aka access profile). The Name
 // field contains the name of the context (which is unique within the config file);
 // the remaining fields define the access profile.
 type Context struct {
-	Name             string           `json:"name" yaml:"name"`
-	AuthMethod       string           `json:"auth_method,omitempty" yaml:"auth_method,omitempty" mapstructure:"auth_method"`
-	Server           string           `json:"server,omitempty" yaml:"server,omitempty"` // deprecated
-	URL              string           `json:"url,omitempty" yaml:"url,omitempty"`
-	Tenant           string           `json:"tenant,omitempty" yaml:"tenant,omitempty"`
-	User             string           `json:"user,omitempty" yaml:"user,omitempty"`
-	Token            string           `json:"token,omitempty" yaml:"token,omitempty"` // access token
-	RefreshToken     string           `json:"refresh_token,omitempty" yaml:"refresh_token,omitempty" mapstructure:"refresh_token"`
-	CsvFile          string           `json:"csv_file,omitempty" yaml:"csv_file,omitempty"`
-	SecretFile       string           `json:"secret_file,omitempty" yaml:"secret_file,omitempty" mapstructure:"secret_file"`
-	LocalAuthOptions LocalAuthOptions `json:"auth-options,omitempty" yaml:"auth-options,omitempty" mapstructure:"auth-options"`
+	Name             string            `json:"name" yaml:"name"`
+	AuthMethod       string            `json:"auth_method,omitempty" yaml:"auth_method,omitempty" mapstructure:"auth_method"`
+	Server           string            `json:"server,omitempty" yaml:"server,omitempty"` // deprecated
+	URL              string            `json:"url,omitempty" yaml:"url,omitempty"`
+	Tenant           string            `json:"tenant,omitempty" yaml:"tenant,omitempty"`
+	User             string            `json:"user,omitempty" yaml:"user,omitempty"`
+	Token            string            `json:"token,omitempty" yaml:"token,omitempty"` // access token
+	RefreshToken     string            `json:"refresh_token,omitempty" yaml:"refresh_token,omitempty" mapstructure:"refresh_token"`
+	CsvFile          string            `json:"csv_file,omitempty" yaml:"csv_file,omitempty"`
+	SecretFile       string            `json:"secret_file,omitempty" yaml:"secret_file,omitempty" mapstructure:"secret_file"`
+	LocalAuthOptions LocalAuthOptions  `json:"auth-options,omitempty" yaml:"auth-options,omitempty" mapstructure:"auth-options"`
+	Features         map[string]bool   `json:"features,omitempty" yaml:"features,omitempty"`
+	Defaults         map[string]string `json:"defaults,omitempty" yaml:"defaults,omitempty"`          // values of global flags not given on the command line
+	Extra            map[string]any    `json:"extra,omitempty" yaml:",inline" mapstructure:",remain"` // unknown keys, set with "config set --force"
 }
 
 type LocalAuthOptions struct {
@@ -80,8 +84,11 @@ func (opt *LocalAuthOptions) AddHeaders(req *http.Request) {
 
 // internal, to be renamed to lower case
 type configFileContents struct {
-	Contexts       []Context
-	CurrentContext string `mapstructure:"current_context" yaml:"current_context,omitempty" json:"current_context,omitempty"`
+	Contexts        []Context
+	CurrentContext  string            `mapstructure:"current_context" yaml:"current_context,omitempty" json:"current_context,omitempty"`
+	PreviousContext string            `mapstructure:"previous_context" yaml:"previous_context,omitempty" json:"previous_context,omitempty"`
+	DisableTips     bool              `mapstructure:"disable_tips" yaml:"disable_tips,omitempty" json:"disable_tips,omitempty"`
+	Aliases         map[string]string `mapstructure:"aliases" yaml:"aliases,omitempty" json:"aliases,omitempty"`
 }
 
 // GetAuthMethodsStringList returns the list of authentication methods as strings (for join, etc.)
@@ -16,21 +16,47 @@ package config
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/apex/log"
 	"github.com/spf13/cobra"
 
+	"github.com/cisco-open/fsoc/cmdkit/picker"
+	"github.com/cisco-open/fsoc/exitcode"
 	"github.com/cisco-open/fsoc/output"
 )
 
-func newCmdConfigUse() *cobra.Command {
+// previousProfileArg is the argument that selects the previously current profile
+const previousProfileArg = "-"
+
+// NewUseCmd returns the top-level "fsoc use" command, a shortcut for "fsoc config use"
+func NewUseCmd() *cobra.Command {
+	cmd := newCmdConfigUse("use PROFILE | -")
+	cmd.Short = "Switch to another profile (same as \"config use\")"
+	return cmd
+}
+
+func newCmdConfigUse(use string) *cobra.Command {
 
 	var cmd = &cobra.Command{
-		Use:   "use --profile CONTEXT_NAME",
+		Use:   use,
 		Short: "Set the current context in an fsoc config file",
-		Long:  `Set the current context in an fsoc config file`,
-		Args:  cobra.ExactArgs(0),
-		Run:   configUseContext,
+		Long: `Set the current context in an fsoc config file
+
+The profile can be given by its full name or by part of it, e.g., "prod" for "acme-prod-admin", as long
+as only one profile matches; when several profiles match and running interactively, the profile is chosen
+from a list of the matching profiles. Use "-" to switch back to the previously current profile.
+
+Without a profile name or --profile, the context is chosen from a searchable list of the profiles when
+running interactively (see --interactive).`,
+		Example: `  fsoc use prod
+  fsoc use -
+  fsoc config use --profile acme-prod-admin`,
+		Args: cobra.MaximumNArgs(1),
+		Run:  configUseContext,
+		Annotations: map[string]string{
+			AnnotationForConfigBypass: "", // the current profile may not exist
+		},
 	}
 
 	return cmd
@@ -38,20 +64,76 @@ func newCmdConfigUse() *cobra.Command {
 
 func configUseContext(cmd *cobra.Command, args []string) {
 	newContext := GetCurrentProfileName()
-	contextExists := false
 
 	cfg := getConfig()
-	for _, c := range cfg.Contexts {
-		if c.Name == newContext {
-			contextExists = true
-			break
+	switch {
+	case len(args) > 0 && cmd.Flags().Changed("profile"):
+		log.WithField(exitcode.Field, exitcode.Usage).Fatal("Specify the profile either as an argument or with --profile, not both")
+	case len(args) > 0 && args[0] == previousProfileArg:
+		if cfg.PreviousContext == "" {
+			log.WithField(exitcode.Field, exitcode.NotFound).Fatal("There is no previous profile to switch back to")
 		}
+		newContext = cfg.PreviousContext
+	case len(args) > 0:
+		newContext = matchProfile(cmd, cfg.Contexts, args[0])
+	case !cmd.Flags().Changed("profile") && picker.Interactive(cmd):
+		newContext = pickProfile(cfg.Contexts)
 	}
 
-	if !contextExists {
-		log.Fatalf("no context exists with the name: \"%s\"", newContext)
+	if err := SetCurrentContext(newContext); err != nil {
+		log.WithField(exitcode.Field, exitcode.NotFound).Fatal(err.Error())
 	}
-
-	updateConfigFile(map[string]interface{}{"current_context": newContext})
 	output.PrintCmdStatus(cmd, fmt.Sprintf("Switched to context \"%s\"\n", newContext))
 }
+
+// matchProfile returns the name of the profile matching the pattern, see findProfiles; if several
+// profiles match, the user chooses one of them when running interactively
+func matchProfile(cmd *cobra.Command, contexts []Context, pattern string) string {
+	names := make([]string, len(contexts))
+	for i, c := range contexts {
+		names[i] = c.Name
+	}
+	matches := findProfiles(names, pattern)
+	switch {
+	case len(matches) == 0:
+		log.WithField(exitcode.Field, exitcode.NotFound).Fatalf("No profile matches %q", pattern)
+	case len(matches) == 1:
+		return matches[0]
+	case picker.Interactive(cmd):
+		chosen, err := picker.Pick(matches, &picker.Options{Prompt: "Profile to use", Single: true})
+		if err != nil {
+			log.Fatalf("No profile chosen: %v", err)
+		}
+		return matches[chosen[0]]
+	}
+	log.WithField(exitcode.Field, exitcode.Usage).Fatalf("%q matches several profiles: %s", pattern, strings.Join(matches, ", "))
+	return "" // unreachable
+}
+
+// findProfiles returns the profile names matching a pattern: the name equal to the pattern, if any,
+// or else the names that fuzzy-match it (see picker.Match), best matches first
+func findProfiles(names []string, pattern string) []string {
+	for _, name := range names {
+		if name == pattern {
+			return []string{name}
+		}
+	}
+	var matches []string
+	for _, i := range picker.Filter(pattern, names) {
+		matches = append(matches, names[i])
+	}
+	return matches
+}
+
+// pickProfile lets the user choose one of the profiles interactively, returning its name
+func pickProfile(contexts []Context) string {
+	names := make([]string, len(contexts))
+	for i, c := range contexts {
+		names[i] = c.Name
+	}
+	chosen, err := picker.Pick(names, &picker.Options{Prompt: "Profile to use", Single: true})
+	if err != nil {
+		log.Fatalf("No profile chosen: %v", err)
+	}
+	return names[chosen[0]]
+}
@@ -0,0 +1,65 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindProfiles(t *testing.T) {
+	names := []string{"acme-prod-admin", "acme-staging", "prod", "dev"}
+	assert.Equal(t, []string{"prod"}, findProfiles(names, "prod"))
+	assert.Equal(t, []string{"acme-staging"}, findProfiles(names, "stag"))
+	assert.ElementsMatch(t, []string{"acme-prod-admin", "acme-staging"}, findProfiles(names, "acme"))
+	assert.Empty(t, findProfiles(names, "qa"))
+}
+
+func TestSetCurrentContextRemembersPrevious(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fsoc.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`contexts:
+  - name: a
+    auth_method: none
+    url: https://a.example.com
+  - name: b
+    auth_method: none
+    url: https://b.example.com
+current_context: a
+`), 0600))
+	viper.Reset()
+	defer viper.Reset()
+	viper.SetConfigFile(path)
+	viper.SetConfigType("yaml")
+	require.NoError(t, viper.ReadInConfig())
+
+	require.NoError(t, SetCurrentContext("b"))
+	cfg := getConfig()
+	assert.Equal(t, "b", cfg.CurrentContext)
+	assert.Equal(t, "a", cfg.PreviousContext)
+
+	// switching to the current profile keeps the previous one
+	require.NoError(t, SetCurrentContext("b"))
+	assert.Equal(t, "a", getConfig().PreviousContext)
+
+	require.NoError(t, SetCurrentContext("a"))
+	assert.Equal(t, "b", getConfig().PreviousContext)
+
+	assert.Error(t, SetCurrentContext("c"))
+}
@@ -0,0 +1,295 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doctor
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+	"golang.org/x/exp/slices"
+
+	"github.com/cisco-open/fsoc/cmd/config"
+	"github.com/cisco-open/fsoc/cmd/version"
+	"github.com/cisco-open/fsoc/platform/api"
+)
+
+// certExpiryWarning is how long before its expiration a TLS certificate is reported
+const certExpiryWarning = 14 * 24 * time.Hour
+
+// releasesURL is where fsoc releases are downloaded from
+const releasesURL = "https://github.com/cisco-open/fsoc/releases"
+
+// diagnosis keeps the state shared by the checks, e.g., the profile once it has been validated
+type diagnosis struct {
+	skipNetwork bool
+	configRead  bool
+	profile     *config.Context
+	tenantURL   *url.URL
+}
+
+func (d *diagnosis) checkConfigFile() checkResult {
+	r := checkResult{Check: "Config file"}
+	err := viper.ReadInConfig()
+	var notFound viper.ConfigFileNotFoundError
+	switch {
+	case errors.As(err, &notFound) || errors.Is(err, os.ErrNotExist):
+		r.Status, r.Details = statusFail, "no config file found"
+		r.Remedy = `Create a profile with "fsoc config set", e.g., fsoc config set --auth oauth --url https://MYTENANT.observe.appdynamics.com`
+	case err != nil:
+		r.Status, r.Details = statusFail, fmt.Sprintf("failed to read %s: %v", viper.ConfigFileUsed(), err)
+		r.Remedy = "Fix the syntax of the config file (YAML) or recreate it with \"fsoc config set\""
+	default:
+		d.configRead = true
+		r.Status, r.Details = statusPass, viper.ConfigFileUsed()
+	}
+	return r
+}
+
+func (d *diagnosis) checkProfile() checkResult {
+	r := checkResult{Check: "Profile"}
+	if !d.configRead {
+		r.Status, r.Details = statusSkip, "no config file"
+		return r
+	}
+	name := config.GetCurrentProfileName()
+	if !config.HasCurrentContext() {
+		r.Status, r.Details = statusFail, fmt.Sprintf("profile %q does not exist", name)
+		r.Remedy = fmt.Sprintf(`Create it with "fsoc config set --profile %s ..." or select another one with "fsoc config use"`, name)
+		return r
+	}
+	ctx, err := config.LoadCurrentContext()
+	if err != nil {
+		r.Status, r.Details = statusFail, err.Error()
+		r.Remedy = "Set the environment variables referenced by the profile"
+		return r
+	}
+
+	fix := func(details string, remedy string) checkResult {
+		r.Status, r.Details, r.Remedy = statusFail, fmt.Sprintf("profile %q: %s", name, details), remedy
+		return r
+	}
+	if !slices.Contains(config.GetAuthMethodsStringList(), ctx.AuthMethod) {
+		return fix(fmt.Sprintf("invalid auth method %q", ctx.AuthMethod),
+			fmt.Sprintf(`Set the auth method with "fsoc config set --auth METHOD", where METHOD is one of %s`, strings.Join(config.GetAuthMethodsStringList(), ", ")))
+	}
+	u, err := url.Parse(ctx.URL)
+	if ctx.URL == "" || err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		if ctx.URL != "" || (ctx.AuthMethod != config.AuthMethodServicePrincipal && ctx.AuthMethod != config.AuthMethodAgentPrincipal) {
+			return fix(fmt.Sprintf("invalid tenant URL %q", ctx.URL), `Set the URL with "fsoc config set --url https://MYTENANT.observe.appdynamics.com"`)
+		}
+		u = nil // principals' credentials files may provide the URL
+	}
+	if ctx.AuthMethod == config.AuthMethodServicePrincipal || ctx.AuthMethod == config.AuthMethodAgentPrincipal {
+		file := ctx.SecretFile
+		if file == "" {
+			file = ctx.CsvFile
+		}
+		if _, err := os.Stat(file); err != nil {
+			return fix(fmt.Sprintf("cannot access the credentials file %q", file), `Set the credentials file with "fsoc config set --secret-file FILE"`)
+		}
+	}
+
+	d.profile, d.tenantURL = ctx, u
+	r.Status = statusPass
+	r.Details = fmt.Sprintf("profile %q, auth method %s", name, ctx.AuthMethod)
+	if u != nil {
+		r.Details += ", " + u.Host
+	}
+	return r
+}
+
+func (d *diagnosis) checkDNS() checkResult {
+	r := checkResult{Check: "Tenant DNS"}
+	if d.skipNetwork || d.tenantURL == nil {
+		r.Status, r.Details = statusSkip, d.skipReason()
+		return r
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), networkTimeout)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupHost(ctx, d.tenantURL.Hostname())
+	if err != nil {
+		r.Status, r.Details = statusFail, err.Error()
+		r.Remedy = "Check the tenant URL in the profile and the network connection (VPN, DNS settings)"
+		return r
+	}
+	r.Status, r.Details = statusPass, fmt.Sprintf("%s resolves to %s", d.tenantURL.Hostname(), strings.Join(addrs, ", "))
+	return r
+}
+
+func (d *diagnosis) checkTLS() checkResult {
+	r := checkResult{Check: "Tenant TLS"}
+	if d.skipNetwork || d.tenantURL == nil {
+		r.Status, r.Details = statusSkip, d.skipReason()
+		return r
+	}
+	if d.tenantURL.Scheme != "https" {
+		r.Status, r.Details = statusSkip, "the tenant URL doesn't use TLS"
+		return r
+	}
+	address := d.tenantURL.Host
+	if d.tenantURL.Port() == "" {
+		address = net.JoinHostPort(d.tenantURL.Hostname(), "443")
+	}
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: networkTimeout}, "tcp", address, &tls.Config{ServerName: d.tenantURL.Hostname()})
+	if err != nil {
+		r.Status, r.Details = statusFail, err.Error()
+		r.Remedy = "Check the network connection and any proxy that intercepts TLS; the system's CA certificates must trust the tenant's certificate"
+		return r
+	}
+	defer conn.Close()
+
+	certs := conn.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		r.Status, r.Details = statusFail, "no server certificate"
+		return r
+	}
+	expires := certs[0].NotAfter
+	if time.Until(expires) < certExpiryWarning {
+		r.Status, r.Details = statusWarn, fmt.Sprintf("the certificate expires soon, at %v", expires.Local().Format(time.RFC3339))
+		r.Remedy = "Contact the tenant's administrators to renew the certificate"
+		return r
+	}
+	r.Status, r.Details = statusPass, fmt.Sprintf("certificate valid until %v", expires.Local().Format("2006-01-02"))
+	return r
+}
+
+func (d *diagnosis) checkToken(now time.Time) checkResult {
+	r := checkResult{Check: "Access token"}
+	if d.profile == nil {
+		r.Status, r.Details = statusSkip, "no valid profile"
+		return r
+	}
+	method := d.profile.AuthMethod
+	if method == config.AuthMethodNone || method == config.AuthMethodLocal {
+		r.Status, r.Details = statusSkip, fmt.Sprintf("the %s auth method doesn't use tokens", method)
+		return r
+	}
+	if d.profile.Token == "" {
+		if method == config.AuthMethodJWT {
+			r.Status, r.Details = statusFail, "no token"
+			r.Remedy = `Set the token with "fsoc config set --token TOKEN"`
+			return r
+		}
+		r.Status, r.Details = statusWarn, "not logged in yet; fsoc logs in on the next command"
+		r.Remedy = `Log in with "fsoc login"`
+		return r
+	}
+	expires, err := api.TokenExpiry(d.profile.Token)
+	if err != nil {
+		r.Status, r.Details = statusSkip, fmt.Sprintf("cannot determine the token expiration: %v", err)
+		return r
+	}
+	if expires.After(now) {
+		r.Status, r.Details = statusPass, fmt.Sprintf("valid until %v", expires.Local().Format(time.RFC3339))
+		return r
+	}
+	switch {
+	case method == config.AuthMethodJWT:
+		r.Status, r.Details = statusFail, fmt.Sprintf("expired at %v", expires.Local().Format(time.RFC3339))
+		r.Remedy = `Obtain a new token and set it with "fsoc config set --token TOKEN"`
+	case method == config.AuthMethodOAuth && d.profile.RefreshToken == "":
+		r.Status, r.Details = statusWarn, fmt.Sprintf("expired at %v", expires.Local().Format(time.RFC3339))
+		r.Remedy = `Log in again with "fsoc login"`
+	default:
+		r.Status, r.Details = statusPass, fmt.Sprintf("expired at %v; a new token is obtained automatically", expires.Local().Format(time.RFC3339))
+	}
+	return r
+}
+
+func (d *diagnosis) checkClockSkew() checkResult {
+	r := checkResult{Check: "Clock"}
+	if d.skipNetwork || d.tenantURL == nil || d.profile == nil {
+		r.Status, r.Details = statusSkip, d.skipReason()
+		if !d.skipNetwork && d.profile == nil {
+			r.Details = "no valid profile"
+		}
+		return r
+	}
+	method := d.profile.AuthMethod
+	if d.profile.Token == "" && method != config.AuthMethodNone && method != config.AuthMethodLocal {
+		r.Status, r.Details = statusSkip, "not logged in yet"
+		return r
+	}
+
+	// any response carries the server's time, whether or not the request succeeds
+	var out any
+	err := api.JSONGet("objstore/v1beta/types?max=1", &out, &api.Options{ReadOnly: true, Timeout: networkTimeout})
+	skew, found := api.GetClockSkew(d.profile.Name)
+	switch {
+	case !found && err != nil:
+		r.Status, r.Details = statusWarn, fmt.Sprintf("cannot determine the platform's time: %v", err)
+	case !found:
+		r.Status, r.Details = statusSkip, "the platform's response has no Date header"
+	case skew > api.ClockSkewThreshold || -skew > api.ClockSkewThreshold:
+		r.Status, r.Details = statusFail, fmt.Sprintf("the local clock differs from the platform's clock by %v", skew.Round(time.Second))
+		r.Remedy = "Synchronize the local clock, e.g., enable automatic time synchronization (NTP); tokens may be rejected otherwise"
+	default:
+		r.Status, r.Details = statusPass, fmt.Sprintf("within %v of the platform's clock", api.ClockSkewThreshold)
+	}
+	return r
+}
+
+func checkLogPath(path string) checkResult {
+	r := checkResult{Check: "Log file"}
+	dir := filepath.Dir(path)
+	file, err := os.CreateTemp(dir, ".fsoc-doctor-*")
+	if err != nil {
+		r.Status, r.Details = statusFail, fmt.Sprintf("cannot write to %s: %v", dir, err)
+		r.Remedy = "Use --log to place the log file in a writable directory"
+		return r
+	}
+	file.Close()
+	_ = os.Remove(file.Name())
+	r.Status, r.Details = statusPass, path
+	return r
+}
+
+func (d *diagnosis) checkVersion() checkResult {
+	r := checkResult{Check: "fsoc version"}
+	if d.skipNetwork {
+		r.Status, r.Details = statusSkip, "network checks skipped"
+		return r
+	}
+	current := version.GetVersionShort()
+	latest, err := version.GetLatestRelease(networkTimeout)
+	switch {
+	case err != nil:
+		r.Status, r.Details = statusWarn, fmt.Sprintf("%s; cannot determine the latest release: %v", current, err)
+	case version.IsDev():
+		r.Status, r.Details = statusSkip, fmt.Sprintf("%s is a development build; the latest release is %s", current, latest)
+	case version.IsOlderThan(latest):
+		r.Status, r.Details = statusWarn, fmt.Sprintf("%s is older than the latest release, %s", current, latest)
+		r.Remedy = "Download the latest release from " + releasesURL
+	default:
+		r.Status, r.Details = statusPass, current+" is the latest release"
+	}
+	return r
+}
+
+func (d *diagnosis) skipReason() string {
+	if d.skipNetwork {
+		return "network checks skipped"
+	}
+	return "no valid tenant URL"
+}
@@ -0,0 +1,74 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doctor
+
+import (
+	"encoding/base64"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cisco-open/fsoc/cmd/config"
+)
+
+func testToken(claims string) string {
+	return "eyJhbGciOiJub25lIn0." + base64.RawURLEncoding.EncodeToString([]byte(claims)) + ".sig"
+}
+
+func TestCheckToken(t *testing.T) {
+	now := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	expired := testToken(`{"exp":1685613600}`)
+	valid := testToken(`{"exp":1685635200}`)
+	for _, tc := range []struct {
+		profile *config.Context
+		status  string
+	}{
+		{nil, statusSkip},
+		{&config.Context{AuthMethod: config.AuthMethodNone}, statusSkip},
+		{&config.Context{AuthMethod: config.AuthMethodOAuth}, statusWarn},
+		{&config.Context{AuthMethod: config.AuthMethodJWT}, statusFail},
+		{&config.Context{AuthMethod: config.AuthMethodJWT, Token: valid}, statusPass},
+		{&config.Context{AuthMethod: config.AuthMethodJWT, Token: expired}, statusFail},
+		{&config.Context{AuthMethod: config.AuthMethodOAuth, Token: expired}, statusWarn},
+		{&config.Context{AuthMethod: config.AuthMethodOAuth, Token: expired, RefreshToken: "r"}, statusPass},
+		{&config.Context{AuthMethod: config.AuthMethodServicePrincipal, Token: expired}, statusPass},
+		{&config.Context{AuthMethod: config.AuthMethodServicePrincipal, Token: "opaque"}, statusSkip},
+	} {
+		r := (&diagnosis{profile: tc.profile}).checkToken(now)
+		assert.Equal(t, tc.status, r.Status, "%+v: %s", tc.profile, r.Details)
+	}
+}
+
+func TestCheckLogPath(t *testing.T) {
+	dir := t.TempDir()
+	assert.Equal(t, statusPass, checkLogPath(filepath.Join(dir, "fsoc.log")).Status)
+	assert.Equal(t, statusFail, checkLogPath(filepath.Join(dir, "missing", "fsoc.log")).Status)
+}
+
+func TestCheckClockSkewSkipped(t *testing.T) {
+	tenantURL, _ := url.Parse("https://tenant.example.com")
+	for _, d := range []*diagnosis{
+		{skipNetwork: true, tenantURL: tenantURL, profile: &config.Context{AuthMethod: config.AuthMethodNone}},
+		{tenantURL: tenantURL},
+		{profile: &config.Context{AuthMethod: config.AuthMethodNone}},
+		{tenantURL: tenantURL, profile: &config.Context{AuthMethod: config.AuthMethodOAuth}},
+	} {
+		r := d.checkClockSkew()
+		assert.Equal(t, statusSkip, r.Status, r.Details)
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package doctor provides the command that diagnoses the fsoc environment: the config file, the profile,
+// the reachability of the tenant, the token, the log file and the fsoc version
+package doctor
+
+import (
+	"time"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+
+	"github.com/cisco-open/fsoc/cmd/config"
+	"github.com/cisco-open/fsoc/cmdkit/offline"
+	"github.com/cisco-open/fsoc/output"
+)
+
+// networkTimeout limits the duration of each of the network checks
+const networkTimeout = 5 * time.Second
+
+// Check outcomes
+const (
+	statusPass = "PASS"
+	statusWarn = "WARN"
+	statusFail = "FAIL"
+	statusSkip = "SKIP"
+)
+
+// checkResult is the outcome of a check, as displayed
+type checkResult struct {
+	Check   string `json:"check"`
+	Status  string `json:"status"`
+	Details string `json:"details"`
+	Remedy  string `json:"remedy,omitempty"`
+}
+
+func NewSubCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Diagnose the fsoc environment",
+		Long: `Run a set of checks of the fsoc environment and display a pass/fail report, with the steps to fix the
+problems found:
+- the config file can be read and parsed
+- the current profile is complete and valid, including its environment variable references
+- the tenant's host name resolves (DNS) and its TLS certificate is valid and not about to expire
+- the profile's access token, if any, has not expired
+- the local clock is in sync with the platform's clock, as tokens may be rejected otherwise
+- the log file location is writable
+- fsoc is the latest released version
+
+The command exits with a non-zero status if any check fails; warnings don't affect the status. Use
+--profile to check a profile other than the current one and --skip-network to skip the checks that
+need network access.`,
+		Example: `  fsoc doctor
+  fsoc doctor --profile prod
+  fsoc doctor --skip-network -o json`,
+		Args:        cobra.NoArgs,
+		Run:         runDoctor,
+		Annotations: map[string]string{config.AnnotationForConfigBypass: ""},
+	}
+
+	cmd.Flags().Bool("skip-network", false, "Skip the checks that need network access (DNS, TLS, clock and version)")
+
+	return cmd
+}
+
+func runDoctor(cmd *cobra.Command, args []string) {
+	skipNetwork, _ := cmd.Flags().GetBool("skip-network")
+	logPath, _ := cmd.Flags().GetString("log")
+
+	d := &diagnosis{skipNetwork: skipNetwork || offline.Enabled()}
+	results := []checkResult{
+		d.checkConfigFile(),
+		d.checkProfile(),
+		d.checkDNS(),
+		d.checkTLS(),
+		d.checkToken(time.Now()),
+		d.checkClockSkew(),
+		checkLogPath(logPath),
+		d.checkVersion(),
+	}
+
+	failed := false
+	var lines [][]string
+	for _, r := range results {
+		lines = append(lines, []string{r.Check, r.Status, r.Details, r.Remedy})
+		failed = failed || r.Status == statusFail
+	}
+	output.PrintCmdOutputCustom(cmd, struct {
+		Items []checkResult `json:"items"`
+		Total int           `json:"total"`
+	}{Items: results, Total: len(results)}, &output.Table{
+		Headers: []string{"Check", "Status", "Details", "Remedy"},
+		Lines:   lines,
+	})
+	if failed {
+		log.Fatal("Some checks failed; see the remedies in the report")
+	}
+}
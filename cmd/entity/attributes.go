@@ -0,0 +1,157 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package entity
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+
+	"github.com/cisco-open/fsoc/cmd/config"
+	"github.com/cisco-open/fsoc/cmd/uql"
+	"github.com/cisco-open/fsoc/output"
+	"github.com/cisco-open/fsoc/platform/api"
+)
+
+// entityType is the subset of an FMM entity type definition that describes its attributes
+type entityType struct {
+	Namespace struct {
+		Name string `json:"name"`
+	} `json:"namespace"`
+	Name                 string `json:"name"`
+	AttributeDefinitions *struct {
+		Required   []string                       `json:"required"`
+		Attributes map[string]attributeDefinition `json:"attributes"`
+	} `json:"attributeDefinitions"`
+}
+
+type attributeDefinition struct {
+	Type        string `json:"type"`
+	Description string `json:"description"`
+}
+
+// attributeInfo is an attribute of an entity type, as displayed
+type attributeInfo struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Required    bool   `json:"required"`
+	Description string `json:"description"`
+}
+
+func newCmdAttributes() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "attributes TYPE",
+		Short: "List the attributes of an entity type",
+		Long: `List the attributes defined for an entity type in the tenant's knowledge store, with their types and
+descriptions. The attribute names can be used with "fsoc entity list --filter" and --attributes.`,
+		Example: `  fsoc entity attributes k8s:workload
+  fsoc entity attributes apm:service -o json`,
+		Args: cobra.ExactArgs(1),
+		Run:  listAttributes,
+	}
+	return cmd
+}
+
+func listAttributes(cmd *cobra.Command, args []string) {
+	typeName := args[0]
+	if !uql.IsEntityType(typeName) {
+		log.Fatalf("Invalid entity type %q: expected a fully qualified type, e.g., k8s:workload", typeName)
+	}
+	log.WithField("type", typeName).Info("Listing entity type attributes")
+
+	types, err := fetchEntityTypes()
+	if err != nil {
+		log.Fatalf("Failed to retrieve the entity types: %v", err)
+	}
+	t, found := types[typeName]
+	if !found {
+		log.Fatalf("Entity type %q not found", typeName)
+	}
+
+	attributes := typeAttributes(t)
+	var lines [][]string
+	for _, a := range attributes {
+		required := ""
+		if a.Required {
+			required = "yes"
+		}
+		lines = append(lines, []string{a.Name, a.Type, required, a.Description})
+	}
+	output.PrintCmdOutputCustom(cmd, struct {
+		Items []attributeInfo `json:"items"`
+		Total int             `json:"total"`
+	}{Items: attributes, Total: len(attributes)}, &output.Table{
+		Headers: []string{"Name", "Type", "Required", "Description"},
+		Lines:   lines,
+	})
+}
+
+// typeAttributes returns the attributes of an entity type, sorted by name
+func typeAttributes(t entityType) []attributeInfo {
+	attributes := []attributeInfo{}
+	if t.AttributeDefinitions == nil {
+		return attributes
+	}
+	required := map[string]bool{}
+	for _, name := range t.AttributeDefinitions.Required {
+		required[name] = true
+	}
+	for name, def := range t.AttributeDefinitions.Attributes {
+		attributes = append(attributes, attributeInfo{
+			Name:        name,
+			Type:        def.Type,
+			Required:    required[name],
+			Description: strings.TrimSpace(def.Description),
+		})
+	}
+	sort.Slice(attributes, func(i, j int) bool { return attributes[i].Name < attributes[j].Name })
+	return attributes
+}
+
+// fetchEntityTypes retrieves the entity types known to the tenant, indexed by fully qualified name
+func fetchEntityTypes() (map[string]entityType, error) {
+	headers := map[string]string{
+		"layer-type": "TENANT",
+		"layer-id":   config.GetCurrentContext().Tenant,
+	}
+	var res any
+	if err := api.JSONGetCollection("objstore/v1beta/objects/fmm:entity", &res, &api.Options{Headers: headers}); err != nil {
+		return nil, err
+	}
+
+	// re-parse the generic collection items into entity type definitions
+	b, err := json.Marshal(res)
+	if err != nil {
+		return nil, err
+	}
+	var collection struct {
+		Items []struct {
+			Data entityType `json:"data"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(b, &collection); err != nil {
+		return nil, fmt.Errorf("failed to parse the entity types: %w", err)
+	}
+
+	types := make(map[string]entityType, len(collection.Items))
+	for _, item := range collection.Items {
+		types[item.Data.Namespace.Name+":"+item.Data.Name] = item.Data
+	}
+	return types, nil
+}
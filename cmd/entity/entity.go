@@ -0,0 +1,44 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package entity provides commands to explore the entities observed by the platform, generating the
+// underlying UQL queries so that the entity model can be browsed without knowing UQL
+package entity
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func NewSubCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "entity",
+		Short: "Browse entities and entity types",
+		Long: `List and display the entities observed by the platform and the attributes of the entity types, and
+tag entities in bulk.
+
+These commands generate and run UQL queries; use --show-query to display the generated query, e.g.,
+as a starting point for more specific queries with "fsoc uql".`,
+		Example: `  fsoc entity list --type k8s:workload
+  fsoc entity get k8s:workload:Ry4Aa2JfNcqrEXampleId
+  fsoc entity attributes k8s:workload`,
+		TraverseChildren: true,
+	}
+
+	cmd.AddCommand(newCmdList())
+	cmd.AddCommand(newCmdGet())
+	cmd.AddCommand(newCmdAttributes())
+	cmd.AddCommand(newCmdTag())
+
+	return cmd
+}
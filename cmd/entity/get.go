@@ -0,0 +1,50 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package entity
+
+import (
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+)
+
+func newCmdGet() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "get ID",
+		Short: "Display an entity",
+		Long:  `Display an entity, with its type and attributes, by its ID (as shown by "fsoc entity list").`,
+		Example: `  fsoc entity get k8s:workload:Ry4Aa2JfNcqrEXampleId
+  fsoc entity get k8s:workload:Ry4Aa2JfNcqrEXampleId --since 7d -o yaml`,
+		Args: cobra.ExactArgs(1),
+		Run:  getEntity,
+	}
+
+	cmd.Flags().String("since", "", "Time range to look for the entity in, e.g., 1h or 7d (default the query's default range)")
+	cmd.Flags().Bool("show-query", false, "Display the generated UQL query instead of executing it")
+
+	return cmd
+}
+
+func getEntity(cmd *cobra.Command, args []string) {
+	since, _ := cmd.Flags().GetString("since")
+
+	query, err := getQuery(args[0], since)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	log.WithFields(log.Fields{"id": args[0], "query": query}).Info("Getting entity")
+	if err := runQuery(cmd, query); err != nil {
+		log.Fatalf("Failed to get entity: %v", err)
+	}
+}
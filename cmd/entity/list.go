@@ -0,0 +1,62 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package entity
+
+import (
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+)
+
+func newCmdList() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the entities of a type",
+		Long: `List the entities of a type, with their attributes. The entities can be filtered by the values of their
+attributes, with --filter attribute=value, and the displayed attributes can be limited with --attributes.
+
+Use "fsoc entity attributes TYPE" to see the attributes of an entity type.`,
+		Example: `  fsoc entity list --type k8s:workload
+  fsoc entity list --type k8s:workload --filter k8s.cluster.name=prod --attributes k8s.workload.name,k8s.namespace.name
+  fsoc entity list --type apm:service --since 1d -o json
+  fsoc entity list --type k8s:workload --filter k8s.cluster.name=prod --show-query`,
+		Args: cobra.NoArgs,
+		Run:  listEntities,
+	}
+
+	cmd.Flags().String("type", "", "Fully qualified entity type, e.g., k8s:workload")
+	_ = cmd.MarkFlagRequired("type")
+	cmd.Flags().StringArray("filter", nil, "Filter by attribute value, as attribute=value (can be repeated)")
+	cmd.Flags().StringSlice("attributes", nil, "Comma-separated list of attributes to display (default all)")
+	cmd.Flags().String("since", "", "Time range to look for entities in, e.g., 1h or 7d (default the query's default range)")
+	cmd.Flags().Bool("show-query", false, "Display the generated UQL query instead of executing it")
+
+	return cmd
+}
+
+func listEntities(cmd *cobra.Command, args []string) {
+	entityType, _ := cmd.Flags().GetString("type")
+	filters, _ := cmd.Flags().GetStringArray("filter")
+	attributes, _ := cmd.Flags().GetStringSlice("attributes")
+	since, _ := cmd.Flags().GetString("since")
+
+	query, err := listQuery(entityType, filters, attributes, since)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	log.WithFields(log.Fields{"type": entityType, "query": query}).Info("Listing entities")
+	if err := runQuery(cmd, query); err != nil {
+		log.Fatalf("Failed to list entities: %v", err)
+	}
+}
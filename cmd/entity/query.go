@@ -0,0 +1,100 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package entity
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cisco-open/fsoc/cmd/uql"
+	"github.com/cisco-open/fsoc/output"
+)
+
+var sinceRegexp = regexp.MustCompile(`^-?\d+[smhdw]$`)
+
+// listQuery generates the UQL query that lists the entities of a type, optionally filtered by attribute
+// values ("name=value") and with only the specified attributes
+func listQuery(entityType string, filters []string, attributes []string, since string) (string, error) {
+	if !uql.IsEntityType(entityType) {
+		return "", fmt.Errorf("invalid entity type %q: expected a fully qualified type, e.g., k8s:workload", entityType)
+	}
+
+	fields := []string{"id"}
+	if len(attributes) == 0 {
+		fields = append(fields, "attributes")
+	}
+	for _, attribute := range attributes {
+		if !uql.IsAttributeName(attribute) {
+			return "", fmt.Errorf("invalid attribute name %q", attribute)
+		}
+		fields = append(fields, fmt.Sprintf("attributes(%s)", attribute))
+	}
+
+	return fetchQuery(entityType, fields, filters, since)
+}
+
+// idsQuery generates the UQL query that lists the IDs of the entities of a type, optionally filtered
+// by attribute values ("name=value")
+func idsQuery(entityType string, filters []string, since string) (string, error) {
+	if !uql.IsEntityType(entityType) {
+		return "", fmt.Errorf("invalid entity type %q: expected a fully qualified type, e.g., k8s:workload", entityType)
+	}
+	return fetchQuery(entityType, []string{"id"}, filters, since)
+}
+
+// fetchQuery generates the UQL query that fetches the fields of the entities of a type matching the filters
+func fetchQuery(entityType string, fields []string, filters []string, since string) (string, error) {
+	filter, err := uql.AttributeFilter(filters)
+	if err != nil {
+		return "", err
+	}
+
+	query := fmt.Sprintf("FETCH %s FROM entities(%s)%s", strings.Join(fields, ", "), entityType, filter)
+	return withSince(query, since)
+}
+
+// getQuery generates the UQL query that retrieves an entity by ID
+func getQuery(id string, since string) (string, error) {
+	if !uql.IsEntityId(id) {
+		return "", fmt.Errorf("invalid entity ID %q: expected an ID like k8s:workload:Ry4Aa2JfNcqrEXampleId", id)
+	}
+	return withSince(fmt.Sprintf("FETCH id, type, attributes FROM entities(%s)", id), since)
+}
+
+// withSince adds the time range to the query; relative times without a sign are taken as in the past
+func withSince(query string, since string) (string, error) {
+	if since == "" {
+		return query, nil
+	}
+	if !sinceRegexp.MatchString(since) {
+		return "", fmt.Errorf("invalid time %q: expected a relative time, e.g., -1h or 7d", since)
+	}
+	if !strings.HasPrefix(since, "-") {
+		since = "-" + since
+	}
+	return query + " SINCE " + since, nil
+}
+
+// runQuery displays the generated query with --show-query or executes it and displays its results
+func runQuery(cmd *cobra.Command, query string) error {
+	if show, _ := cmd.Flags().GetBool("show-query"); show {
+		output.PrintCmdStatus(cmd, query+"\n")
+		return nil
+	}
+	return uql.QueryAndDisplay(cmd, query)
+}
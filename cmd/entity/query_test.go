@@ -0,0 +1,67 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package entity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListQuery(t *testing.T) {
+	tests := []struct {
+		entityType string
+		filters    []string
+		attributes []string
+		since      string
+		expected   string
+	}{
+		{"k8s:workload", nil, nil, "", "FETCH id, attributes FROM entities(k8s:workload)"},
+		{"k8s:workload", []string{"k8s.cluster.name=prod", "k8s.namespace.name = it's"}, []string{"k8s.workload.name"}, "1d",
+			`FETCH id, attributes(k8s.workload.name) FROM entities(k8s:workload)[attributes(k8s.cluster.name) = 'prod' && attributes(k8s.namespace.name) = 'it\'s'] SINCE -1d`},
+		{"apm:service", nil, nil, "-30m", "FETCH id, attributes FROM entities(apm:service) SINCE -30m"},
+	}
+	for _, tt := range tests {
+		query, err := listQuery(tt.entityType, tt.filters, tt.attributes, tt.since)
+		require.Nil(t, err)
+		assert.Equal(t, tt.expected, query)
+	}
+
+	for _, tt := range []struct {
+		entityType string
+		filters    []string
+		attributes []string
+		since      string
+	}{
+		{"workload", nil, nil, ""},
+		{"k8s:workload)", nil, nil, ""},
+		{"k8s:workload", []string{"k8s.cluster.name"}, nil, ""},
+		{"k8s:workload", nil, []string{"a) FROM x"}, ""},
+		{"k8s:workload", nil, nil, "yesterday"},
+	} {
+		_, err := listQuery(tt.entityType, tt.filters, tt.attributes, tt.since)
+		assert.Error(t, err, tt)
+	}
+}
+
+func TestGetQuery(t *testing.T) {
+	query, err := getQuery("k8s:workload:Ry4Aa2+JfNcq/rEX==", "")
+	require.Nil(t, err)
+	assert.Equal(t, "FETCH id, type, attributes FROM entities(k8s:workload:Ry4Aa2+JfNcq/rEX==)", query)
+
+	_, err = getQuery("k8s:workload", "")
+	assert.Error(t, err)
+}
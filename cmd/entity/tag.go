@@ -0,0 +1,241 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package entity
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+
+	"github.com/cisco-open/fsoc/cmd/uql"
+	"github.com/cisco-open/fsoc/cmdkit/progress"
+	"github.com/cisco-open/fsoc/output"
+	"github.com/cisco-open/fsoc/platform/api"
+)
+
+// entityTagsPath is the API path of the batch update of entity tags
+const entityTagsPath = "monitoring/v1/entities/tags"
+
+const defaultTagBatchSize = 100
+
+// tagUpdate is the request that sets and removes tags on a batch of entities
+type tagUpdate struct {
+	EntityIds []string          `json:"entityIds"`
+	Set       map[string]string `json:"set,omitempty"`
+	Remove    []string          `json:"remove,omitempty"`
+}
+
+// tagResult is the result of tagging an entity, as displayed
+type tagResult struct {
+	Id     string `json:"id" yaml:"id"`
+	Status string `json:"status" yaml:"status"`
+	Error  string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+func newCmdTag() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tag",
+		Short: "Set or remove tags on the entities of a type",
+		Long: `Set or remove tags on all entities of a type that match the filters. The matching entities are found with
+a UQL query (see "fsoc entity list") and updated in batches of --batch-size entities, with a single API call
+per batch.
+
+Use --dry-run to display the matching entities and the changes without updating them; it is recommended
+before tagging many entities, as the filters select all entities of the type if none are specified.`,
+		Example: `  fsoc entity tag --type k8s:workload --filter k8s.namespace.name=payments --set team=payments --dry-run
+  fsoc entity tag --type k8s:workload --filter k8s.namespace.name=payments --set team=payments --set tier=1
+  fsoc entity tag --type apm:service --filter service.name=cart --remove team`,
+		Args: cobra.NoArgs,
+		Run:  tagEntities,
+	}
+
+	cmd.Flags().String("type", "", "Fully qualified entity type, e.g., k8s:workload")
+	_ = cmd.MarkFlagRequired("type")
+	cmd.Flags().StringArray("filter", nil, "Filter by attribute value, as attribute=value (can be repeated)")
+	cmd.Flags().String("since", "", "Time range to look for entities in, e.g., 1h or 7d (default the query's default range)")
+	cmd.Flags().StringArray("set", nil, "Tag to set, as name=value (can be repeated)")
+	cmd.Flags().StringArray("remove", nil, "Name of a tag to remove (can be repeated)")
+	cmd.Flags().Int("batch-size", defaultTagBatchSize, "Number of entities updated with each API call")
+	cmd.Flags().Bool("dry-run", false, "Display the matching entities and the changes without updating them")
+	cmd.Flags().Bool("show-query", false, "Display the generated UQL query instead of executing it")
+
+	return cmd
+}
+
+func tagEntities(cmd *cobra.Command, args []string) {
+	entityType, _ := cmd.Flags().GetString("type")
+	filters, _ := cmd.Flags().GetStringArray("filter")
+	since, _ := cmd.Flags().GetString("since")
+	batchSize, _ := cmd.Flags().GetInt("batch-size")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+	update, err := tagChanges(cmd)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	if batchSize < 1 {
+		log.Fatalf("The batch size must be positive")
+	}
+	query, err := idsQuery(entityType, filters, since)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	if show, _ := cmd.Flags().GetBool("show-query"); show {
+		output.PrintCmdStatus(cmd, query+"\n")
+		return
+	}
+
+	log.WithFields(log.Fields{"type": entityType, "query": query}).Info("Finding the entities to tag")
+	ids := entityIds(uql.FetchQuery(cmd, query))
+	if len(ids) == 0 {
+		output.PrintCmdStatus(cmd, "No matching entities found.\n")
+		return
+	}
+
+	var results []tagResult
+	if dryRun {
+		for _, id := range ids {
+			results = append(results, tagResult{Id: id, Status: "would be tagged"})
+		}
+		output.PrintCmdStatus(cmd, fmt.Sprintf("Dry run: %d entities would be tagged with %s\n", len(ids), update.describe()))
+	} else {
+		results = applyTags(ids, update, batchSize)
+	}
+	printTagResults(cmd, results)
+
+	failed := 0
+	for _, r := range results {
+		if r.Error != "" {
+			failed++
+		}
+	}
+	if failed > 0 {
+		log.Fatalf("Failed to tag %d of %d entities", failed, len(results))
+	}
+}
+
+// tagChanges returns the update of the tags specified with --set and --remove, without entity IDs
+func tagChanges(cmd *cobra.Command) (*tagUpdate, error) {
+	sets, _ := cmd.Flags().GetStringArray("set")
+	removes, _ := cmd.Flags().GetStringArray("remove")
+	if len(sets) == 0 && len(removes) == 0 {
+		return nil, fmt.Errorf("specify the tags to set with --set or to remove with --remove")
+	}
+
+	update := &tagUpdate{Set: map[string]string{}}
+	for _, s := range sets {
+		name, value, found := strings.Cut(s, "=")
+		name = strings.TrimSpace(name)
+		if !found || !uql.IsAttributeName(name) {
+			return nil, fmt.Errorf("invalid tag %q: expected name=value, e.g., team=payments", s)
+		}
+		update.Set[name] = strings.TrimSpace(value)
+	}
+	for _, name := range removes {
+		name = strings.TrimSpace(name)
+		if !uql.IsAttributeName(name) {
+			return nil, fmt.Errorf("invalid tag name %q", name)
+		}
+		if _, found := update.Set[name]; found {
+			return nil, fmt.Errorf("tag %q cannot be both set and removed", name)
+		}
+		update.Remove = append(update.Remove, name)
+	}
+	return update, nil
+}
+
+// describe returns a description of the changes, e.g., "team=payments, without owner"
+func (u *tagUpdate) describe() string {
+	var changes []string
+	for name, value := range u.Set {
+		changes = append(changes, name+"="+value)
+	}
+	sort.Strings(changes)
+	for _, name := range u.Remove {
+		changes = append(changes, "without "+name)
+	}
+	return strings.Join(changes, ", ")
+}
+
+// entityIds returns the entity IDs in the first column of the query response
+func entityIds(response *uql.Response) []string {
+	var ids []string
+	if response.Main() == nil {
+		return ids
+	}
+	for _, row := range response.Main().Values() {
+		if len(row) > 0 {
+			if id, ok := row[0].(string); ok {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
+
+// applyTags updates the tags of the entities in batches, returning the result for each entity; a failed
+// batch doesn't stop the update of the following batches
+func applyTags(ids []string, changes *tagUpdate, batchSize int) []tagResult {
+	spinner := progress.Start("Entity tagging")
+	defer spinner.Hide()
+
+	results := make([]tagResult, 0, len(ids))
+	failed := false
+	for start := 0; start < len(ids); start += batchSize {
+		end := start + batchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+		spinner.Update(fmt.Sprintf("%d of %d entities", start, len(ids)))
+
+		batch := *changes
+		batch.EntityIds = ids[start:end]
+		var res any
+		err := api.JSONPost(entityTagsPath, &batch, &res, &api.Options{Resources: batch.EntityIds})
+		for _, id := range batch.EntityIds {
+			if err != nil {
+				results = append(results, tagResult{Id: id, Status: "failed", Error: err.Error()})
+			} else {
+				results = append(results, tagResult{Id: id, Status: "tagged"})
+			}
+		}
+		if err != nil {
+			failed = true
+			log.WithFields(log.Fields{"from": start + 1, "to": end}).Errorf("Failed to tag a batch of entities: %v", err)
+		} else {
+			log.WithFields(log.Fields{"from": start + 1, "to": end}).Info("Tagged a batch of entities")
+		}
+	}
+	spinner.Update(fmt.Sprintf("%d entities", len(ids)))
+	spinner.Stop(!failed)
+	return results
+}
+
+func printTagResults(cmd *cobra.Command, results []tagResult) {
+	lines := make([][]string, len(results))
+	for i, r := range results {
+		lines[i] = []string{r.Id, r.Status, r.Error}
+	}
+	output.PrintCmdOutputCustom(cmd, struct {
+		Items []tagResult `json:"items"`
+		Total int         `json:"total"`
+	}{Items: results, Total: len(results)}, &output.Table{
+		Headers: []string{"ID", "Status", "Error"},
+		Lines:   lines,
+	})
+}
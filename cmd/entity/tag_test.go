@@ -0,0 +1,53 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package entity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdsQuery(t *testing.T) {
+	query, err := idsQuery("k8s:workload", []string{"k8s.namespace.name=payments"}, "7d")
+	require.Nil(t, err)
+	assert.Equal(t, "FETCH id FROM entities(k8s:workload)[attributes(k8s.namespace.name) = 'payments'] SINCE -7d", query)
+
+	_, err = idsQuery("workload", nil, "")
+	assert.Error(t, err)
+}
+
+func TestTagChanges(t *testing.T) {
+	cmd := newCmdTag()
+	require.Nil(t, cmd.ParseFlags([]string{"--set", "team=payments", "--set", " tier = 1", "--remove", "owner"}))
+	update, err := tagChanges(cmd)
+	require.Nil(t, err)
+	assert.Equal(t, map[string]string{"team": "payments", "tier": "1"}, update.Set)
+	assert.Equal(t, []string{"owner"}, update.Remove)
+	assert.Equal(t, "team=payments, tier=1, without owner", update.describe())
+
+	for _, args := range [][]string{
+		{},
+		{"--set", "team"},
+		{"--set", "a b=c"},
+		{"--set", "team=x", "--remove", "team"},
+	} {
+		cmd := newCmdTag()
+		require.Nil(t, cmd.ParseFlags(args))
+		_, err := tagChanges(cmd)
+		assert.Error(t, err, args)
+	}
+}
@@ -0,0 +1,38 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package events provides commands to follow the events observed by the platform, e.g., logs,
+// generating the underlying UQL queries
+package events
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func NewSubCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "events",
+		Short: "Follow events",
+		Long: `Follow the events observed by the platform, e.g., log records, without writing UQL.
+
+These commands generate and run UQL queries; use --show-query to display the generated query, e.g.,
+as a starting point for more specific queries with "fsoc uql".`,
+		Example:          `  fsoc events tail --type logs:generic_record --filter k8s.namespace.name=prod --since -15m`,
+		TraverseChildren: true,
+	}
+
+	cmd.AddCommand(newCmdTail())
+
+	return cmd
+}
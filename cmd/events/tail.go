@@ -0,0 +1,124 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+
+	"github.com/cisco-open/fsoc/cmd/uql"
+	"github.com/cisco-open/fsoc/output"
+)
+
+var eventTypeRegexp = regexp.MustCompile(`^[\w.-]+:[\w.-]+$`)
+
+// tailOptions are the parameters of an event query
+type tailOptions struct {
+	eventType string
+	filters   []string
+	fields    []string
+	since     string
+}
+
+func newCmdTail() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tail --type TYPE",
+		Short: "Display events as they arrive",
+		Long: `Display the events of a type, optionally filtered by the values of their attributes with
+--filter attribute=value, and keep polling for new events at the specified interval until interrupted
+with Ctrl+C.
+
+The --since flag selects how far back to start, as a relative time (e.g., -15m or 1h) or an absolute time
+in RFC 3339 format (e.g., 2023-06-01T10:00:00Z). The platform does not provide a streaming endpoint for
+events, so new events are fetched by polling, following the query's next-page links.
+
+By default, the default fields of the event type are displayed; use --select to choose the fields, e.g.,
+--select timestamp,raw for log records. Each batch of new events is displayed in the chosen output
+format: table (default), json or yaml.`,
+		Example: `  fsoc events tail --type logs:generic_record
+  fsoc events tail --type logs:generic_record --filter k8s.namespace.name=prod --select timestamp,raw --since -15m
+  fsoc events tail --type logs:generic_record --interval 30s -o json
+  fsoc events tail --type logs:generic_record --filter severity=ERROR --show-query`,
+		Args: cobra.NoArgs,
+		Run:  eventsTail,
+	}
+
+	cmd.Flags().String("type", "", "Fully qualified type of the events, e.g., logs:generic_record")
+	_ = cmd.MarkFlagRequired("type")
+	cmd.Flags().StringArray("filter", nil, "Filter the events by attribute value, as attribute=value (can be repeated)")
+	cmd.Flags().StringSlice("select", nil, "Fields of the events to display, e.g., timestamp,raw (default fields of the event type)")
+	cmd.Flags().String("since", "-5m", "Start displaying events from this time")
+	cmd.Flags().Duration("interval", 10*time.Second, "Polling interval for new events")
+	cmd.Flags().Bool("show-query", false, "Display the generated UQL query instead of executing it")
+
+	return cmd
+}
+
+func eventsTail(cmd *cobra.Command, args []string) {
+	var opts tailOptions
+	opts.eventType, _ = cmd.Flags().GetString("type")
+	opts.filters, _ = cmd.Flags().GetStringArray("filter")
+	opts.fields, _ = cmd.Flags().GetStringSlice("select")
+	opts.since, _ = cmd.Flags().GetString("since")
+	interval, _ := cmd.Flags().GetDuration("interval")
+
+	query, err := eventQuery(opts)
+	if err != nil {
+		_ = cmd.Usage()
+		log.Fatalf("%v", err)
+	}
+	log.WithFields(log.Fields{"type": opts.eventType, "query": query}).Info("Following events")
+	if show, _ := cmd.Flags().GetBool("show-query"); show {
+		output.PrintCmdStatus(cmd, query+"\n")
+		return
+	}
+
+	if err := uql.QueryAndFollow(cmd, query, interval); err != nil {
+		log.Fatalf("Failed to follow events: %v", err)
+	}
+}
+
+// eventQuery generates the UQL query that fetches the events of a type
+func eventQuery(opts tailOptions) (string, error) {
+	if !eventTypeRegexp.MatchString(opts.eventType) {
+		return "", fmt.Errorf("invalid event type %q: expected a fully qualified type, e.g., logs:generic_record", opts.eventType)
+	}
+	filter, err := uql.AttributeFilter(opts.filters)
+	if err != nil {
+		return "", err
+	}
+	var fields string
+	if len(opts.fields) > 0 {
+		for _, field := range opts.fields {
+			if !uql.IsAttributeName(field) {
+				return "", fmt.Errorf("invalid field %q: expected a field name, e.g., timestamp", field)
+			}
+		}
+		fields = "{" + strings.Join(opts.fields, ", ") + "}"
+	}
+	since, err := uql.TimeExpression(opts.since)
+	if err != nil {
+		return "", fmt.Errorf("invalid --since: %w", err)
+	}
+	if since == "now" {
+		return "", fmt.Errorf("invalid --since: must be a time in the past")
+	}
+	return fmt.Sprintf("FETCH events(%s)%s%s SINCE %s", opts.eventType, filter, fields, since), nil
+}
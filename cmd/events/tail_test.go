@@ -0,0 +1,52 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventQuery(t *testing.T) {
+	query, err := eventQuery(tailOptions{eventType: "logs:generic_record", since: "-5m"})
+	require.Nil(t, err)
+	assert.Equal(t, "FETCH events(logs:generic_record) SINCE -5m", query)
+
+	query, err = eventQuery(tailOptions{
+		eventType: "logs:generic_record",
+		filters:   []string{"k8s.namespace.name=prod", "severity = ERROR"},
+		fields:    []string{"timestamp", "raw"},
+		since:     "15m",
+	})
+	require.Nil(t, err)
+	assert.Equal(t, "FETCH events(logs:generic_record)[attributes(k8s.namespace.name) = 'prod' && attributes(severity) = 'ERROR']{timestamp, raw} SINCE -15m", query)
+
+	query, err = eventQuery(tailOptions{eventType: "logs:generic_record", since: "2023-06-01T12:00:00+02:00"})
+	require.Nil(t, err)
+	assert.Equal(t, "FETCH events(logs:generic_record) SINCE 2023-06-01T10:00:00Z", query)
+
+	for _, opts := range []tailOptions{
+		{eventType: "generic_record", since: "-5m"},
+		{eventType: "logs:generic_record", filters: []string{"severity"}, since: "-5m"},
+		{eventType: "logs:generic_record", fields: []string{"raw}"}, since: "-5m"},
+		{eventType: "logs:generic_record", since: "yesterday"},
+		{eventType: "logs:generic_record", since: "now"},
+	} {
+		_, err := eventQuery(opts)
+		assert.Error(t, err, opts)
+	}
+}
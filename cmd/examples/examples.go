@@ -0,0 +1,100 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package examples provides the command that lists and searches the usage examples of the fsoc commands
+package examples
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+
+	"github.com/cisco-open/fsoc/cmd/config"
+	"github.com/cisco-open/fsoc/cmdkit/examples"
+	"github.com/cisco-open/fsoc/output"
+)
+
+func NewSubCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "examples [COMMAND...]",
+		Short: "List the usage examples of the commands",
+		Long: `List the usage examples from the help of a command and all of its subcommands, or of all commands if none
+is specified, grouped by command. Use --search to find examples by words in their commands, descriptions
+or command lines, e.g., to discover how to do something without knowing which command does it.`,
+		Example: `  fsoc examples solution
+  fsoc examples knowledge list
+  fsoc examples --search theme
+  fsoc examples --search "layer-type TENANT" -o json`,
+		Annotations: map[string]string{config.AnnotationForConfigBypass: ""},
+		Run:         listExamples,
+	}
+	cmd.Flags().StringArrayP("search", "s", nil, "Display only the examples containing the text (can be repeated; all must match)")
+	return cmd
+}
+
+func listExamples(cmd *cobra.Command, args []string) {
+	target := cmd.Root()
+	if len(args) > 0 {
+		var rest []string
+		var err error
+		target, rest, err = cmd.Root().Find(args)
+		if err != nil || len(rest) > 0 {
+			log.Fatalf("Unknown command %q", "fsoc "+strings.Join(args, " "))
+		}
+	}
+	list := examples.Collect(target)
+	if terms, _ := cmd.Flags().GetStringArray("search"); len(terms) > 0 {
+		list = examples.Search(list, terms)
+	}
+	if len(list) == 0 {
+		output.PrintCmdStatus(cmd, fmt.Sprintf("No examples found for %q.\n", target.CommandPath()))
+		return
+	}
+
+	if format, _ := cmd.Flags().GetString("output"); format == "" || format == "auto" {
+		printExamples(cmd, list)
+		return
+	}
+	lines := make([][]string, len(list))
+	for i, e := range list {
+		lines[i] = []string{e.Command, e.Description, e.Line}
+	}
+	output.PrintCmdOutputCustom(cmd, list, &output.Table{
+		Headers: []string{"Command", "Description", "Example"},
+		Lines:   lines,
+	})
+}
+
+// printExamples displays the examples grouped by command, in the layout of the commands' help
+func printExamples(cmd *cobra.Command, list []examples.Example) {
+	var sb strings.Builder
+	command, description := "", ""
+	for _, e := range list {
+		if e.Command != command {
+			if command != "" {
+				sb.WriteString("\n")
+			}
+			command, description = e.Command, ""
+			sb.WriteString(command + ":\n")
+		}
+		if e.Description != "" && e.Description != description {
+			description = e.Description
+			sb.WriteString("  # " + e.Description + "\n")
+		}
+		sb.WriteString("  " + e.Line + "\n")
+	}
+	output.PrintCmdStatus(cmd, sb.String())
+}
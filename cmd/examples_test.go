@@ -0,0 +1,32 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cisco-open/fsoc/cmdkit/examples"
+)
+
+// TestExamples verifies that the usage examples in the help of all commands are valid command lines
+func TestExamples(t *testing.T) {
+	list := examples.Collect(rootCmd)
+	assert.NotEmpty(t, list)
+	for _, e := range list {
+		assert.NoError(t, examples.Verify(rootCmd, e), "example of %q: %s", e.Command, e.Line)
+	}
+}
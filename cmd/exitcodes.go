@@ -0,0 +1,46 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cisco-open/fsoc/exitcode"
+)
+
+// exitCodesHelpTopic returns the "exit-codes" help topic, displayed with "fsoc help exit-codes"
+func exitCodesHelpTopic() *cobra.Command {
+	var sb strings.Builder
+	sb.WriteString(`fsoc exits with distinct codes for the different kinds of failures, so that scripts can react to them,
+e.g., retrying on server errors or timeouts but not on validation failures:
+
+`)
+	for _, c := range exitcode.Codes {
+		fmt.Fprintf(&sb, "  %-3d %-15s %s\n", c.Code, c.Name, c.Description)
+	}
+	sb.WriteString(`
+Commands may document more specific meanings of these codes, e.g., "solution push --wait" exits with 2 if
+the deployment fails and 3 if it doesn't complete in time. Plugins exit with their own exit codes.`)
+
+	// a command without a handler is displayed as an additional help topic
+	return &cobra.Command{
+		Use:   "exit-codes",
+		Short: "Exit codes of fsoc commands",
+		Long:  sb.String(),
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package features
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cisco-open/fsoc/cmd/config"
+	"github.com/cisco-open/fsoc/output"
+)
+
+type featureStatus struct {
+	config.Feature `yaml:",inline"`
+	Enabled        bool `json:"enabled" yaml:"enabled"`
+}
+
+func NewSubCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "features",
+		Short: "Manage experimental features",
+		Long: `Experimental capabilities of fsoc are available only after enabling them in the access profile.
+
+Use "fsoc features list" to see the available features and their maturity level and
+"fsoc config set features.NAME=true" to enable a feature in the current profile.`,
+		Example: `  fsoc features list
+  fsoc config set features.NAME=true
+  fsoc config set --profile dev features.NAME=false`,
+		TraverseChildren: true,
+	}
+
+	cmd.AddCommand(newCmdFeaturesList())
+
+	return cmd
+}
+
+func newCmdFeaturesList() *cobra.Command {
+	return &cobra.Command{
+		Use:         "list",
+		Short:       "List available experimental features",
+		Long:        `List the experimental features available in this version of fsoc, with their maturity level and whether they are enabled in the current profile.`,
+		Args:        cobra.NoArgs,
+		Annotations: map[string]string{config.AnnotationForConfigBypass: ""},
+		Run:         listFeatures,
+	}
+}
+
+func listFeatures(cmd *cobra.Command, args []string) {
+	features := config.GetFeatures()
+	if len(features) == 0 {
+		output.PrintCmdStatus(cmd, "There are no experimental features in this version of fsoc.\n")
+		return
+	}
+
+	items := make([]featureStatus, len(features))
+	lines := make([][]string, len(features))
+	for i, f := range features {
+		items[i] = featureStatus{Feature: f, Enabled: config.IsFeatureEnabled(f.Name)}
+		lines[i] = []string{f.Name, f.Maturity, fmt.Sprint(items[i].Enabled), f.Description}
+	}
+	output.PrintCmdOutputCustom(cmd, struct {
+		Items []featureStatus `json:"items"`
+		Total int             `json:"total"`
+	}{Items: items, Total: len(items)}, &output.Table{
+		Headers: []string{"Name", "Maturity", "Enabled", "Description"},
+		Lines:   lines,
+	})
+}
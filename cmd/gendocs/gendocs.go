@@ -30,11 +30,16 @@ import (
 	"github.com/spf13/cobra/doc"
 
 	"github.com/cisco-open/fsoc/cmd/config"
+	"github.com/cisco-open/fsoc/cmd/plugin"
+	"github.com/cisco-open/fsoc/cmdkit/examples"
 	"github.com/cisco-open/fsoc/output"
 )
 
 const TOCFileName = "pages.json"
 
+// ExamplesFileName is the name of the index of the commands' usage examples, for searching them
+const ExamplesFileName = "examples.json"
+
 // gendocsCmd represents the gendocs command
 var gendocsCmd = &cobra.Command{
 	Use:   "gendocs PATH",
@@ -91,7 +96,13 @@ func genDocs(cmd *cobra.Command, args []string) {
 		}
 	}
 
-	// generate full docs, assumes gendocs is a direct child of the root cmd
+	// generate full docs, assumes gendocs is a direct child of the root cmd; plugins are
+	// specific to this system, so they are not documented
+	for _, c := range cmd.Parent().Commands() {
+		if _, isPlugin := c.Annotations[plugin.AnnotationForPlugin]; isPlugin {
+			cmd.Parent().RemoveCommand(c)
+		}
+	}
 	output.PrintCmdStatus(cmd, "Generating documentation\n")
 	err = doc.GenMarkdownTree(cmd.Parent(), path)
 	if err != nil {
@@ -105,9 +116,43 @@ func genDocs(cmd *cobra.Command, args []string) {
 		log.Fatalf("Error generating fsoc docs table of contents: %v", err)
 	}
 
+	// generate examples index
+	output.PrintCmdStatus(cmd, "Generating examples index\n")
+	err = genExamplesIndex(cmd, path, fs)
+	if err != nil {
+		log.Fatalf("Error generating fsoc examples index: %v", err)
+	}
+
 	output.PrintCmdStatus(cmd, "Documentation generated successfully.\n")
 }
 
+// examplesIndexEntry is a usage example in the examples index, with the page documenting its command
+type examplesIndexEntry struct {
+	examples.Example
+	Content string `json:"content"`
+}
+
+func genExamplesIndex(cmd *cobra.Command, path string, fs *afero.Afero) error {
+	root := cmd.Parent() // gendocs is a top-level command, so its parent is the root
+
+	index := []examplesIndexEntry{}
+	for _, e := range examples.Collect(root) {
+		index = append(index, examplesIndexEntry{Example: e, Content: strings.ReplaceAll(e.Command, " ", "_") + ".md"})
+	}
+	jsIndex, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return fmt.Errorf("Failed to marshal the examples index to JSON: %v", err)
+	}
+
+	// write index to file (rw permissions & umask)
+	indexPath := filepath.Join(path, ExamplesFileName)
+	if err = fs.WriteFile(indexPath, jsIndex, 0666); err != nil {
+		return fmt.Errorf("Failed to write examples index file %v: %v", indexPath, err)
+	}
+
+	return nil
+}
+
 type tocEntry struct {
 	Title   string     `json:"title,omitempty"`
 	Content string     `json:"content,omitempty"`
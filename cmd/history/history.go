@@ -0,0 +1,126 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package history provides the command that displays the local history of the changes made to
+// platform resources with fsoc
+package history
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+
+	"github.com/cisco-open/fsoc/cmd/config"
+	"github.com/cisco-open/fsoc/output"
+	"github.com/cisco-open/fsoc/platform/api"
+)
+
+var relativeTimeRegexp = regexp.MustCompile(`^-?(\d+)([mhdw])$`)
+
+func NewSubCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "Display the local history of changes made to platform resources",
+		Long: `Display the local history of the API calls made by fsoc that change platform resources, e.g., solution
+deployments and knowledge object updates, with the profile, tenant and target resources of each call.
+
+The history is kept in the fsoc config directory and covers all profiles; queries and other calls that
+only read data are not recorded. Use --resource to find the changes of a resource, by its identifier
+(e.g., preferences:theme/dark), object ID (dark) or type (preferences:theme).`,
+		Example: `  fsoc history
+  fsoc history --resource spacefleet
+  fsoc history --since 2023-06-06 --until 2023-06-07
+  fsoc history --since 7d -o json`,
+		Args:        cobra.NoArgs,
+		Run:         showHistory,
+		Annotations: map[string]string{config.AnnotationForConfigBypass: ""},
+	}
+
+	cmd.Flags().String("resource", "", "Display only the changes of a resource (identifier, object ID or type)")
+	cmd.Flags().String("since", "", "Display changes after a time: relative (e.g., 12h or 7d), a date (2023-06-06) or an RFC 3339 time")
+	cmd.Flags().String("until", "", "Display changes before a time, in the same formats as --since")
+
+	return cmd
+}
+
+func showHistory(cmd *cobra.Command, args []string) {
+	resource, _ := cmd.Flags().GetString("resource")
+	now := time.Now()
+	since, err := ParseTime(cmd, "since", now)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	until, err := ParseTime(cmd, "until", now)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	entries, err := api.ReadHistory()
+	if err != nil {
+		log.Fatalf("Failed to read the history from %q: %v", api.HistoryPath(), err)
+	}
+	selected := []api.HistoryEntry{}
+	var lines [][]string
+	for _, e := range entries {
+		if resource != "" && !e.MatchesResource(resource) {
+			continue
+		}
+		if !since.IsZero() && e.Time.Before(since) || !until.IsZero() && e.Time.After(until) {
+			continue
+		}
+		selected = append(selected, e)
+		lines = append(lines, []string{
+			e.Time.Local().Format("2006-01-02 15:04:05"),
+			e.Profile,
+			e.Tenant,
+			e.Method,
+			strings.Join(e.Resources, ", "),
+			strconv.Itoa(e.Status),
+		})
+	}
+
+	output.PrintCmdOutputCustom(cmd, struct {
+		Items []api.HistoryEntry `json:"items"`
+		Total int                `json:"total"`
+	}{Items: selected, Total: len(selected)}, &output.Table{
+		Headers: []string{"Time", "Profile", "Tenant", "Method", "Resources", "Status"},
+		Lines:   lines,
+	})
+}
+
+// ParseTime parses a time flag: a relative time in the past (e.g., 12h or 7d), a date in local time or
+// an RFC 3339 time. It returns the zero time if the flag is not set.
+func ParseTime(cmd *cobra.Command, flag string, now time.Time) (time.Time, error) {
+	s, _ := cmd.Flags().GetString(flag)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if m := relativeTimeRegexp.FindStringSubmatch(s); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		unit := map[string]time.Duration{"m": time.Minute, "h": time.Hour, "d": 24 * time.Hour, "w": 7 * 24 * time.Hour}[m[2]]
+		return now.Add(-time.Duration(n) * unit), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --%s value %q: expected a relative time (e.g., 7d), a date (2023-06-06) or an RFC 3339 time", flag, s)
+}
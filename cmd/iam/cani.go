@@ -0,0 +1,146 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iam
+
+import (
+	"path"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+
+	"github.com/cisco-open/fsoc/exitcode"
+	"github.com/cisco-open/fsoc/output"
+)
+
+// canIResult is the outcome of evaluating whether a principal is authorized for an action
+type canIResult struct {
+	Principal string  `json:"principal" yaml:"principal"`
+	Action    string  `json:"action" yaml:"action"`
+	Resource  string  `json:"resource" yaml:"resource"`
+	Allowed   bool    `json:"allowed" yaml:"allowed"`
+	GrantedBy []grant `json:"grantedBy" yaml:"grantedBy"`
+}
+
+// grant identifies the role and permission that allow an action
+type grant struct {
+	Role       string `json:"role" yaml:"role"`
+	Permission string `json:"permission" yaml:"permission"`
+}
+
+func newCmdCanI() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "can-i ACTION RESOURCE_TYPE",
+		Short: "Check whether a principal is allowed to perform an action",
+		Long: `Check whether a principal (by default, the principal of the current profile) is allowed to perform an
+action on resources of a type, by evaluating the permissions of the roles assigned to it. The action can be
+an action classification (e.g., read, create, update or delete) or a specific action type (e.g.,
+solution:publish); the resource type can be, e.g., fmm:entity.
+
+The command prints "yes" and exits with 0 if the action is allowed, or prints "no" and exits with 1 otherwise;
+use -o yaml to see the roles and permissions that grant the action.`,
+		Example: `  fsoc iam can-i read fmm:entity
+  fsoc iam can-i create extensibility:solution -o yaml
+  fsoc iam can-i delete preferences:theme --principal 5f2a7b1e-0d7c-4b8e-9f3a-2c1d0e9b8a7f`,
+		Args: cobra.ExactArgs(2),
+		Run:  canI,
+	}
+	addPrincipalFlag(cmd, `The principal to check (default "me")`)
+	return cmd
+}
+
+func canI(cmd *cobra.Command, args []string) {
+	if !cmd.Flags().Changed("principal") {
+		_ = cmd.Flags().Set("principal", "me")
+	}
+	principal, err := getPrincipal(cmd)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	result := canIResult{Principal: principal, Action: args[0], Resource: args[1], GrantedBy: []grant{}}
+
+	roles, err := getRoles(principal)
+	if err != nil {
+		log.Fatalf("Failed to get the roles of principal %q: %v", principal, err)
+	}
+	for _, r := range roles {
+		permissions, err := getRolePermissions(r.ID)
+		if err != nil {
+			log.Fatalf("Failed to get the permissions of role %q: %v", r.ID, err)
+		}
+		for _, p := range permissions {
+			if p.allows(result.Action, result.Resource) {
+				result.GrantedBy = append(result.GrantedBy, grant{Role: r.ID, Permission: p.ID})
+			}
+		}
+	}
+	result.Allowed = len(result.GrantedBy) > 0
+
+	answer := map[bool]string{true: "yes", false: "no"}[result.Allowed]
+	lines := [][]string{}
+	grants := []string{}
+	for _, g := range result.GrantedBy {
+		lines = append(lines, []string{g.Role, g.Permission})
+		grants = append(grants, g.Role+"/"+g.Permission)
+	}
+	if format, _ := cmd.Flags().GetString("output"); format == "auto" || format == "table" {
+		output.PrintCmdStatus(cmd, answer+"\n")
+		if result.Allowed {
+			log.Infof("Action %q on %q is granted by %s", result.Action, result.Resource, strings.Join(grants, ", "))
+		} else {
+			log.Infof("None of the %d role(s) of principal %q grants action %q on %q", len(roles), principal, result.Action, result.Resource)
+		}
+	} else {
+		output.PrintCmdOutputCustom(cmd, result, &output.Table{
+			Headers: []string{"Role", "Permission"},
+			Lines:   lines,
+		})
+	}
+	if !result.Allowed {
+		log.WithField(exitcode.Field, exitcode.General).Fatalf("Action %q on %q is not permitted", result.Action, result.Resource)
+	}
+}
+
+// allows returns true if the permission allows the action on resources of the type
+func (p permission) allows(action string, resourceType string) bool {
+	for _, ar := range p.ActionAndResources {
+		if ar.matchesAction(action) && matchesResourceType(ar.Resource.Type, resourceType) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesAction returns true if the action is the permission's action type or classification (or a wildcard)
+func (ar actionAndResource) matchesAction(action string) bool {
+	for _, allowed := range []string{ar.Action.Type, ar.Action.Classification} {
+		if allowed == "*" || allowed != "" && strings.EqualFold(allowed, action) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesResourceType returns true if the resource type matches the pattern, which may use wildcards (e.g., "fmm:*")
+func matchesResourceType(pattern string, resourceType string) bool {
+	if pattern == "*" || strings.EqualFold(pattern, resourceType) {
+		return true
+	}
+	matched, err := path.Match(strings.ToLower(pattern), strings.ToLower(resourceType))
+	if err != nil {
+		log.Infof("Ignoring invalid resource type pattern %q: %v", pattern, err)
+	}
+	return matched
+}
@@ -0,0 +1,52 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iam
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPermissionAllows(t *testing.T) {
+	var p permission
+	require.Nil(t, json.Unmarshal([]byte(`{
+		"id": "entities:read",
+		"actionAndResources": [
+			{"action": {"classification": "READ"}, "resource": {"type": "fmm:*"}},
+			{"action": {"type": "solution:publish", "classification": "UPDATE"}, "resource": {"type": "extensibility:solution"}},
+			{"action": {"classification": "*"}, "resource": {"type": "preferences:theme"}}
+		]
+	}`), &p))
+
+	assert.True(t, p.allows("read", "fmm:entity"))
+	assert.True(t, p.allows("READ", "FMM:Entity"))
+	assert.False(t, p.allows("delete", "fmm:entity"))
+	assert.False(t, p.allows("read", "logs:record"))
+	assert.True(t, p.allows("solution:publish", "extensibility:solution"))
+	assert.True(t, p.allows("update", "extensibility:solution"))
+	assert.True(t, p.allows("delete", "preferences:theme"))
+	assert.False(t, (permission{}).allows("read", "fmm:entity"))
+}
+
+func TestActionDisplay(t *testing.T) {
+	var ar actionAndResource
+	ar.Action.Classification = "read"
+	assert.Equal(t, "READ", ar.action())
+	ar.Action.Type = "solution:publish"
+	assert.Equal(t, "solution:publish (READ)", ar.action())
+}
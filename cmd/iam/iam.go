@@ -0,0 +1,147 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package iam provides commands for managing service principals and for inspecting the roles
+// and permissions of principals, e.g., to find out why a request fails with 403 Forbidden
+package iam
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cisco-open/fsoc/cmd/config"
+	"github.com/cisco-open/fsoc/platform/api"
+)
+
+// role is a named set of permissions that can be assigned to principals
+type role struct {
+	ID          string       `json:"id" yaml:"id"`
+	DisplayName string       `json:"displayName,omitempty" yaml:"displayName,omitempty"`
+	Description string       `json:"description,omitempty" yaml:"description,omitempty"`
+	Permissions []permission `json:"permissions,omitempty" yaml:"permissions,omitempty"`
+}
+
+// permission allows actions on resources of given types
+type permission struct {
+	ID                 string              `json:"id" yaml:"id"`
+	DisplayName        string              `json:"displayName,omitempty" yaml:"displayName,omitempty"`
+	ActionAndResources []actionAndResource `json:"actionAndResources" yaml:"actionAndResources"`
+}
+
+type actionAndResource struct {
+	Action struct {
+		Classification string `json:"classification,omitempty" yaml:"classification,omitempty"` // e.g., READ
+		Type           string `json:"type,omitempty" yaml:"type,omitempty"`                     // e.g., solution:publish
+	} `json:"action" yaml:"action"`
+	Resource struct {
+		Type string `json:"type" yaml:"type"` // e.g., fmm:entity, * or a prefix like fmm:*
+	} `json:"resource" yaml:"resource"`
+}
+
+func NewSubCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "iam",
+		Short: "Manage service principals and inspect roles and permissions",
+		Long: `Inspect the roles defined in the tenant, the permissions they grant and the roles assigned to
+principals (users and service principals). "fsoc iam can-i" evaluates whether a principal is allowed
+to perform an action on a resource type, which helps with troubleshooting requests that fail with
+403 Forbidden.
+
+"fsoc iam service-principal" creates, lists, rotates and deletes the service principals used by automation,
+and "fsoc iam permissions" shows the permissions that fsoc commands require, to grant them only what they need.`,
+		Example: `  fsoc iam roles list
+  fsoc iam roles list --principal me
+  fsoc iam roles describe iam:observer
+  fsoc iam can-i read fmm:entity
+  fsoc iam service-principal create ci-deployer --secret-file ci-credentials.json
+  fsoc iam permissions solution push --roles`,
+		TraverseChildren: true,
+	}
+
+	cmd.AddCommand(newCmdRoles())
+	cmd.AddCommand(newCmdCanI())
+	cmd.AddCommand(newCmdServicePrincipal())
+	cmd.AddCommand(newCmdPermissions())
+
+	return cmd
+}
+
+// addPrincipalFlag adds the flag selecting the principal, with "me" meaning the principal of the current profile
+func addPrincipalFlag(cmd *cobra.Command, usage string) {
+	cmd.Flags().String("principal", "", usage+` ("me" for the principal of the current profile)`)
+}
+
+// getPrincipal returns the principal ID specified with --principal, resolving "me"
+func getPrincipal(cmd *cobra.Command) (string, error) {
+	principal, _ := cmd.Flags().GetString("principal")
+	if principal != "me" {
+		return principal, nil
+	}
+	if user := config.GetCurrentContext().User; user != "" {
+		return user, nil
+	}
+	return "", fmt.Errorf("the principal of profile %q is not known (it is available after \"fsoc login\" for user profiles); specify it with --principal=<id>", config.GetCurrentProfileName())
+}
+
+// getRoles returns the roles defined in the tenant or, if a principal is specified, the roles assigned to it
+func getRoles(principal string) ([]role, error) {
+	path := getRolesUrl()
+	if principal != "" {
+		path = getPrincipalRolesUrl(principal)
+	}
+	var res any
+	if err := api.JSONGetCollection(path, &res, nil); err != nil {
+		return nil, err
+	}
+	var page struct {
+		Items []role `json:"items"`
+	}
+	if err := convertValue(res, &page); err != nil {
+		return nil, err
+	}
+	return page.Items, nil
+}
+
+// getRolePermissions returns the permissions granted by a role
+func getRolePermissions(roleID string) ([]permission, error) {
+	var res any
+	if err := api.JSONGetCollection(getRolePermissionsUrl(roleID), &res, nil); err != nil {
+		return nil, err
+	}
+	var page struct {
+		Items []permission `json:"items"`
+	}
+	if err := convertValue(res, &page); err != nil {
+		return nil, err
+	}
+	return page.Items, nil
+}
+
+func getRolesUrl() string {
+	return "iam/policy-admin/v1beta2/roles"
+}
+
+func getRoleUrl(roleID string) string {
+	return getRolesUrl() + "/" + url.PathEscape(roleID)
+}
+
+func getRolePermissionsUrl(roleID string) string {
+	return getRoleUrl(roleID) + "/permissions"
+}
+
+func getPrincipalRolesUrl(principal string) string {
+	return "iam/policy-admin/v1beta2/principals/" + url.PathEscape(principal) + "/roles"
+}
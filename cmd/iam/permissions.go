@@ -0,0 +1,216 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iam
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/cisco-open/fsoc/cmd/config"
+	"github.com/cisco-open/fsoc/output"
+)
+
+// ExplainPermissionsFlag is the name of the global flag that displays the permissions a command requires
+// instead of executing it
+const ExplainPermissionsFlag = "explain-permissions"
+
+//go:embed permissions.yaml
+var permissionsYaml []byte
+
+// commandPermissions lists the platform permissions a command requires
+type commandPermissions struct {
+	Command     string               `json:"command" yaml:"command"`
+	Permissions []requiredPermission `json:"permissions" yaml:"permissions"`
+	Note        string               `json:"note,omitempty" yaml:"note,omitempty"`
+}
+
+// requiredPermission is an action on a resource type, in the form evaluated by "fsoc iam can-i"
+type requiredPermission struct {
+	Action    string   `json:"action" yaml:"action"`
+	Resource  string   `json:"resource" yaml:"resource"`
+	GrantedBy []string `json:"grantedBy,omitempty" yaml:"grantedBy,omitempty"` // roles, with --roles
+}
+
+func newCmdPermissions() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "permissions [COMMAND]",
+		Short: "Show the platform permissions that fsoc commands require",
+		Long: `Show the platform permissions (actions on resource types) that fsoc commands require, e.g., to create
+least-privilege roles for the service principals used by automation. Without arguments, all commands are
+listed; a command group, e.g., "solution", lists the commands in the group.
+
+With --roles, the roles of the tenant that grant each permission are shown as well.
+
+The global --explain-permissions flag shows the same information for any command instead of executing it.`,
+		Example: `  fsoc iam permissions
+  fsoc iam permissions solution push
+  fsoc iam permissions knowledge --roles
+  fsoc solution push --explain-permissions`,
+		Annotations: map[string]string{config.AnnotationForConfigBypass: ""},
+		Run:         explainPermissionsCmd,
+	}
+	cmd.Flags().Bool("roles", false, "Show the roles of the tenant that grant each permission")
+	return cmd
+}
+
+func explainPermissionsCmd(cmd *cobra.Command, args []string) {
+	target := cmd.Root()
+	if len(args) > 0 {
+		found, rest, err := cmd.Root().Find(args)
+		if err != nil || len(rest) > 0 || found == cmd.Root() {
+			log.Fatalf("Unknown command %q", strings.Join(args, " "))
+		}
+		target = found
+	}
+	withRoles, _ := cmd.Flags().GetBool("roles")
+	if err := printPermissions(cmd, target, withRoles); err != nil {
+		log.Fatal(err.Error())
+	}
+}
+
+// ExplainPermissions displays the platform permissions required by a command, or by the commands in a
+// command group, using the output format of cmd
+func ExplainPermissions(cmd *cobra.Command) {
+	if err := printPermissions(cmd, cmd, false); err != nil {
+		log.Fatal(err.Error())
+	}
+}
+
+func printPermissions(cmd *cobra.Command, target *cobra.Command, withRoles bool) error {
+	mapping, err := loadPermissions()
+	if err != nil {
+		return err
+	}
+	entries := permissionsFor(mapping, commandPath(target))
+	if len(entries) == 0 {
+		entries = []commandPermissions{{
+			Command:     commandPath(target),
+			Permissions: []requiredPermission{},
+			Note:        "The permissions of this command are not documented",
+		}}
+	}
+	if withRoles {
+		if err := addGrantingRoles(entries); err != nil {
+			return fmt.Errorf("Failed to get the roles of the tenant: %w", err)
+		}
+	}
+
+	headers := []string{"Command", "Action", "Resource Type", "Note"}
+	if withRoles {
+		headers = []string{"Command", "Action", "Resource Type", "Granted By", "Note"}
+	}
+	lines := [][]string{}
+	for _, e := range entries {
+		if len(e.Permissions) == 0 {
+			line := []string{e.Command, "-", "-", e.Note}
+			if withRoles {
+				line = []string{e.Command, "-", "-", "", e.Note}
+			}
+			lines = append(lines, line)
+			continue
+		}
+		for i, p := range e.Permissions {
+			note := ""
+			if i == 0 {
+				note = e.Note
+			}
+			line := []string{e.Command, p.Action, p.Resource, note}
+			if withRoles {
+				line = []string{e.Command, p.Action, p.Resource, strings.Join(p.GrantedBy, ", "), note}
+			}
+			lines = append(lines, line)
+		}
+	}
+	output.PrintCmdOutputCustom(cmd, struct {
+		Items []commandPermissions `json:"items"`
+		Total int                  `json:"total"`
+	}{Items: entries, Total: len(entries)}, &output.Table{
+		Headers: headers,
+		Lines:   lines,
+	})
+	return nil
+}
+
+// loadPermissions parses the embedded mapping of commands to the permissions they require
+func loadPermissions() ([]commandPermissions, error) {
+	var mapping []commandPermissions
+	if err := yaml.Unmarshal(permissionsYaml, &mapping); err != nil {
+		return nil, fmt.Errorf("Failed to parse the command permissions: %w", err)
+	}
+	for i := range mapping {
+		if mapping[i].Permissions == nil {
+			mapping[i].Permissions = []requiredPermission{}
+		}
+	}
+	return mapping, nil
+}
+
+// permissionsFor returns the entry of a command or, for a command group, the entries of the commands in it
+func permissionsFor(mapping []commandPermissions, path string) []commandPermissions {
+	var entries []commandPermissions
+	for _, e := range mapping {
+		if path == "" || e.Command == path || strings.HasPrefix(e.Command, path+" ") {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// commandPath returns the canonical path of a command without the root command name, e.g., "objstore get"
+// for "fsoc knowledge get"
+func commandPath(cmd *cobra.Command) string {
+	names := []string{}
+	for c := cmd; c.HasParent(); c = c.Parent() {
+		names = append([]string{c.Name()}, names...)
+	}
+	return strings.Join(names, " ")
+}
+
+// addGrantingRoles sets the roles of the tenant that grant each of the permissions
+func addGrantingRoles(entries []commandPermissions) error {
+	roles, err := getRoles("")
+	if err != nil {
+		return err
+	}
+	rolePermissions := make(map[string][]permission, len(roles))
+	for _, r := range roles {
+		permissions, err := getRolePermissions(r.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get the permissions of role %q: %w", r.ID, err)
+		}
+		rolePermissions[r.ID] = permissions
+	}
+
+	for i := range entries {
+		for j := range entries[i].Permissions {
+			p := &entries[i].Permissions[j]
+			p.GrantedBy = []string{}
+			for _, r := range roles {
+				for _, rp := range rolePermissions[r.ID] {
+					if rp.allows(p.Action, p.Resource) {
+						p.GrantedBy = append(p.GrantedBy, r.ID)
+						break
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iam
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadPermissions(t *testing.T) {
+	mapping, err := loadPermissions()
+	require.Nil(t, err)
+	require.NotEmpty(t, mapping)
+
+	seen := map[string]bool{}
+	for _, e := range mapping {
+		assert.False(t, seen[e.Command], "duplicate entry for %q", e.Command)
+		seen[e.Command] = true
+		assert.True(t, len(e.Permissions) > 0 || e.Note != "", "%q has neither permissions nor a note", e.Command)
+		for _, p := range e.Permissions {
+			assert.NotEmpty(t, p.Action, "%q has a permission without an action", e.Command)
+			assert.NotEmpty(t, p.Resource, "%q has a permission without a resource type", e.Command)
+		}
+	}
+	assert.True(t, seen["iam permissions"])
+}
+
+func TestPermissionsFor(t *testing.T) {
+	mapping := []commandPermissions{
+		{Command: "solution push"},
+		{Command: "solution pushall"},
+		{Command: "solutions list"},
+		{Command: "objstore get"},
+	}
+	commands := func(entries []commandPermissions) []string {
+		names := []string{}
+		for _, e := range entries {
+			names = append(names, e.Command)
+		}
+		return names
+	}
+
+	assert.Equal(t, []string{"solution push"}, commands(permissionsFor(mapping, "solution push")))
+	assert.Equal(t, []string{"solution push", "solution pushall"}, commands(permissionsFor(mapping, "solution")))
+	assert.Equal(t, 4, len(permissionsFor(mapping, "")))
+	assert.Empty(t, permissionsFor(mapping, "uql"))
+}
+
+func TestCommandPath(t *testing.T) {
+	root := &cobra.Command{Use: "fsoc"}
+	group := &cobra.Command{Use: "objstore", Aliases: []string{"knowledge"}}
+	get := &cobra.Command{Use: "get", Run: func(*cobra.Command, []string) {}}
+	group.AddCommand(get)
+	root.AddCommand(group)
+
+	found, _, err := root.Find([]string{"knowledge", "get"})
+	require.Nil(t, err)
+	assert.Equal(t, "objstore get", commandPath(found))
+	assert.Equal(t, "", commandPath(root))
+}
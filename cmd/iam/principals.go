@@ -0,0 +1,274 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iam
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+
+	"github.com/cisco-open/fsoc/cmd/config"
+	"github.com/cisco-open/fsoc/output"
+	"github.com/cisco-open/fsoc/platform/api"
+)
+
+// servicePrincipal is a client that authenticates with a client ID and secret, used for automation
+type servicePrincipal struct {
+	ID                string `json:"id" yaml:"id"`
+	DisplayName       string `json:"displayName" yaml:"displayName"`
+	Description       string `json:"description,omitempty" yaml:"description,omitempty"`
+	AuthType          string `json:"authType,omitempty" yaml:"authType,omitempty"`
+	HasRotatedSecrets bool   `json:"hasRotatedSecrets,omitempty" yaml:"hasRotatedSecrets,omitempty"`
+	CreatedAt         string `json:"createdAt,omitempty" yaml:"createdAt,omitempty"`
+	UpdatedAt         string `json:"updatedAt,omitempty" yaml:"updatedAt,omitempty"`
+	ClientSecret      string `json:"clientSecret,omitempty" yaml:"clientSecret,omitempty"` // returned only on create and rotate
+}
+
+// servicePrincipalCredentials is the credentials file format used by the service-principal auth method
+// (fsoc config set --auth service-principal --secret-file FILE)
+type servicePrincipalCredentials struct {
+	TenantID string `json:"Tenant ID"`
+	TokenURL string `json:"Token URL"`
+	ClientID string `json:"Client ID"`
+	Secret   string `json:"Secret"`
+}
+
+func newCmdServicePrincipal() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "service-principal",
+		Aliases: []string{"service-principals", "sp"},
+		Short:   "Manage service principals",
+		Long: `Create, list, rotate and delete the service principals of the tenant. Service principals are
+credentials for automation, e.g., CI pipelines that deploy solutions.
+
+The client secret of a service principal is returned only when it is created or rotated. Use --secret-file
+to save it into a credentials file that fsoc can log in with:
+
+  fsoc config set --profile ci --auth service-principal --secret-file ci-credentials.json`,
+		TraverseChildren: true,
+	}
+
+	createCmd := &cobra.Command{
+		Use:   "create NAME",
+		Short: "Create a service principal",
+		Example: `  fsoc iam service-principal create ci-deployer --description "Deploys solutions from CI" --secret-file ci-credentials.json
+  fsoc iam service-principal create ci-deployer -o json`,
+		Args: cobra.ExactArgs(1),
+		Run:  createServicePrincipal,
+	}
+	createCmd.Flags().String("description", "", "Description of the service principal")
+	addSecretFileFlag(createCmd)
+
+	listCmd := &cobra.Command{
+		Use:     "list",
+		Short:   "List the service principals of the tenant",
+		Example: `  fsoc iam service-principal list`,
+		Args:    cobra.NoArgs,
+		Run:     listServicePrincipals,
+	}
+
+	rotateCmd := &cobra.Command{
+		Use:   "rotate ID",
+		Short: "Rotate the client secret of a service principal",
+		Long: `Generate a new client secret for a service principal. With --secret-file, the secret in the credentials
+file is replaced (the file is created if it does not exist). The command asks for confirmation unless the
+--yes flag is specified, since clients using the previous secret need to be updated.`,
+		Example: `  fsoc iam service-principal rotate 5f2a7b1e-0d7c-4b8e-9f3a-2c1d0e9b8a7f --secret-file ci-credentials.json`,
+		Args:    cobra.ExactArgs(1),
+		Run:     rotateServicePrincipal,
+	}
+	addSecretFileFlag(rotateCmd)
+	rotateCmd.Flags().BoolP("yes", "y", false, "Rotate without asking for confirmation")
+
+	deleteCmd := &cobra.Command{
+		Use:   "delete ID",
+		Short: "Delete a service principal",
+		Long: `Delete a service principal; clients using its credentials can no longer authenticate. The command asks
+for confirmation unless the --yes flag is specified.`,
+		Example: `  fsoc iam service-principal delete 5f2a7b1e-0d7c-4b8e-9f3a-2c1d0e9b8a7f`,
+		Args:    cobra.ExactArgs(1),
+		Run:     deleteServicePrincipal,
+	}
+	deleteCmd.Flags().BoolP("yes", "y", false, "Delete without asking for confirmation")
+
+	cmd.AddCommand(createCmd, listCmd, rotateCmd, deleteCmd)
+	return cmd
+}
+
+func addSecretFileFlag(cmd *cobra.Command) {
+	cmd.Flags().String("secret-file", "", `Save the credentials into a file for "fsoc config set --auth service-principal --secret-file"`)
+}
+
+func createServicePrincipal(cmd *cobra.Command, args []string) {
+	description, _ := cmd.Flags().GetString("description")
+	body := map[string]any{
+		"displayName": args[0],
+		"description": description,
+		"authType":    "client_secret_basic",
+	}
+	var sp servicePrincipal
+	if err := api.JSONPost(getServicePrincipalsUrl(), body, &sp, nil); err != nil {
+		log.Fatalf("Failed to create service principal %q: %v", args[0], err)
+	}
+	printServicePrincipalSecret(cmd, &sp, fmt.Sprintf("Created service principal %q with client ID %q.\n", sp.DisplayName, sp.ID))
+}
+
+func listServicePrincipals(cmd *cobra.Command, args []string) {
+	var res any
+	if err := api.JSONGetCollection(getServicePrincipalsUrl(), &res, nil); err != nil {
+		log.Fatalf("Failed to get the service principals: %v", err)
+	}
+	var page struct {
+		Items []servicePrincipal `json:"items"`
+	}
+	if err := convertValue(res, &page); err != nil {
+		log.Fatalf("Failed to parse the service principals: %v", err)
+	}
+
+	lines := make([][]string, len(page.Items))
+	for i, sp := range page.Items {
+		lines[i] = []string{sp.ID, sp.DisplayName, sp.Description, sp.CreatedAt}
+	}
+	output.PrintCmdOutputCustom(cmd, struct {
+		Items []servicePrincipal `json:"items"`
+		Total int                `json:"total"`
+	}{Items: page.Items, Total: len(page.Items)}, &output.Table{
+		Headers: []string{"ID", "Name", "Description", "Created"},
+		Lines:   lines,
+	})
+}
+
+func rotateServicePrincipal(cmd *cobra.Command, args []string) {
+	id := args[0]
+	if yes, _ := cmd.Flags().GetBool("yes"); !yes {
+		if !confirm(cmd, fmt.Sprintf("Rotate the client secret of service principal %q? Clients using the previous secret will need the new one.", id)) {
+			output.PrintCmdStatus(cmd, "Rotation canceled.\n")
+			return
+		}
+	}
+
+	var sp servicePrincipal
+	if err := api.JSONPost(getServicePrincipalUrl(id)+"/rotate-secret", nil, &sp, nil); err != nil {
+		log.Fatalf("Failed to rotate the secret of service principal %q: %v", id, err)
+	}
+	if sp.ID == "" {
+		sp.ID = id
+	}
+	printServicePrincipalSecret(cmd, &sp, fmt.Sprintf("Rotated the client secret of service principal %q.\n", sp.ID))
+}
+
+func deleteServicePrincipal(cmd *cobra.Command, args []string) {
+	id := args[0]
+	if yes, _ := cmd.Flags().GetBool("yes"); !yes {
+		if !confirm(cmd, fmt.Sprintf("Delete service principal %q? Clients using its credentials will no longer be able to authenticate.", id)) {
+			output.PrintCmdStatus(cmd, "Delete canceled.\n")
+			return
+		}
+	}
+
+	var res any
+	if err := api.JSONDelete(getServicePrincipalUrl(id), &res, nil); err != nil {
+		log.Fatalf("Failed to delete service principal %q: %v", id, err)
+	}
+	output.PrintCmdStatus(cmd, fmt.Sprintf("Deleted service principal %q.\n", id))
+}
+
+// printServicePrincipalSecret saves the new secret of a service principal into the --secret-file, if specified,
+// or displays it otherwise
+func printServicePrincipalSecret(cmd *cobra.Command, sp *servicePrincipal, status string) {
+	if sp.ClientSecret == "" {
+		log.Fatalf("The response for service principal %q does not include a client secret", sp.ID)
+	}
+
+	path, _ := cmd.Flags().GetString("secret-file")
+	if path == "" {
+		output.PrintCmdOutputCustom(cmd, sp, &output.Table{
+			Headers: []string{"Client ID", "Name", "Client Secret"},
+			Lines:   [][]string{{sp.ID, sp.DisplayName, sp.ClientSecret}},
+		})
+		output.PrintCmdStatus(cmd, status)
+		log.Warn("Save the client secret now: it cannot be retrieved later")
+		return
+	}
+
+	if err := writeCredentialsFile(path, sp.ID, sp.ClientSecret); err != nil {
+		log.Fatalf("Failed to save the credentials into %q: %v; the client secret is %q", path, err, sp.ClientSecret)
+	}
+	output.PrintCmdStatus(cmd, status)
+	output.PrintCmdStatus(cmd, fmt.Sprintf("Saved the credentials into %q. To use them, run:\n  fsoc config set --profile <name> --auth service-principal --secret-file %s\n", path, path))
+}
+
+// writeCredentialsFile writes (or updates) a service principal credentials file, readable only by the user
+func writeCredentialsFile(path string, clientID string, secret string) error {
+	var credentials servicePrincipalCredentials
+	b, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(b, &credentials); err != nil {
+			return fmt.Errorf("the existing file is not a credentials file: %v", err)
+		}
+		if credentials.ClientID != "" && credentials.ClientID != clientID {
+			return fmt.Errorf("the existing file has the credentials of another service principal, %q", credentials.ClientID)
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return err
+	}
+
+	ctx := config.GetCurrentContext()
+	if credentials.TenantID == "" {
+		credentials.TenantID = ctx.Tenant
+	}
+	if credentials.TokenURL == "" && ctx.URL != "" && ctx.Tenant != "" {
+		credentials.TokenURL = strings.TrimSuffix(ctx.URL, "/") + "/auth/" + ctx.Tenant + "/default/oauth2/token"
+	}
+	if credentials.TenantID == "" {
+		log.Warnf("The tenant ID of profile %q is not known; specify it with \"fsoc config set --tenant\" for the profile using the credentials", config.GetCurrentProfileName())
+	}
+	credentials.ClientID = clientID
+	credentials.Secret = secret
+
+	b, err = json.MarshalIndent(credentials, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(b, '\n'), 0600)
+}
+
+// confirm asks the user a yes/no question, returning true only if the answer is yes. It fails if
+// the input is not a terminal, since there is no one to answer.
+func confirm(cmd *cobra.Command, question string) bool {
+	if fi, err := os.Stdin.Stat(); err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+		log.Fatal("Confirmation is required but the input is not a terminal; use the --yes flag to confirm")
+	}
+	output.PrintCmdStatus(cmd, question+" [y/N] ")
+	answer, _ := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+func getServicePrincipalsUrl() string {
+	return "administration/v1beta/clients/services"
+}
+
+func getServicePrincipalUrl(id string) string {
+	return getServicePrincipalsUrl() + "/" + url.PathEscape(id)
+}
@@ -0,0 +1,136 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iam
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+
+	"github.com/cisco-open/fsoc/output"
+	"github.com/cisco-open/fsoc/platform/api"
+)
+
+func newCmdRoles() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:              "roles",
+		Short:            "List and describe roles",
+		TraverseChildren: true,
+	}
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the roles of the tenant or of a principal",
+		Long: `List the roles defined in the tenant or, with --principal, the roles assigned to a principal.
+Use "fsoc iam roles describe" to see the permissions of a role.`,
+		Example: `  fsoc iam roles list
+  fsoc iam roles list --principal me
+  fsoc iam roles list --principal 5f2a7b1e-0d7c-4b8e-9f3a-2c1d0e9b8a7f`,
+		Args: cobra.NoArgs,
+		Run:  listRoles,
+	}
+	addPrincipalFlag(listCmd, "List the roles assigned to the principal")
+
+	describeCmd := &cobra.Command{
+		Use:   "describe ROLE",
+		Short: "Show the permissions granted by a role",
+		Long:  `Show a role and the permissions it grants, with the actions and resource types each permission allows.`,
+		Example: `  fsoc iam roles describe iam:observer
+  fsoc iam roles describe iam:observer -o yaml`,
+		Args: cobra.ExactArgs(1),
+		Run:  describeRole,
+	}
+
+	cmd.AddCommand(listCmd, describeCmd)
+	return cmd
+}
+
+func listRoles(cmd *cobra.Command, args []string) {
+	principal, err := getPrincipal(cmd)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	roles, err := getRoles(principal)
+	if err != nil {
+		log.Fatalf("Failed to get the roles: %v", err)
+	}
+
+	lines := make([][]string, len(roles))
+	for i, r := range roles {
+		lines[i] = []string{r.ID, r.DisplayName, r.Description}
+	}
+	output.PrintCmdOutputCustom(cmd, struct {
+		Items []role `json:"items"`
+		Total int    `json:"total"`
+	}{Items: roles, Total: len(roles)}, &output.Table{
+		Headers: []string{"ID", "Name", "Description"},
+		Lines:   lines,
+	})
+}
+
+func describeRole(cmd *cobra.Command, args []string) {
+	var r role
+	if err := api.JSONGet(getRoleUrl(args[0]), &r, nil); err != nil {
+		log.Fatalf("Failed to get role %q: %v", args[0], err)
+	}
+	permissions, err := getRolePermissions(r.ID)
+	if err != nil {
+		log.Fatalf("Failed to get the permissions of role %q: %v", r.ID, err)
+	}
+	r.Permissions = permissions
+
+	if format, _ := cmd.Flags().GetString("output"); format == "auto" || format == "table" {
+		output.PrintCmdStatus(cmd, "Role:        "+r.ID+"\n")
+		output.PrintCmdStatus(cmd, "Name:        "+r.DisplayName+"\n")
+		output.PrintCmdStatus(cmd, "Description: "+r.Description+"\n\n")
+	}
+
+	lines := [][]string{}
+	for _, p := range r.Permissions {
+		for _, ar := range p.ActionAndResources {
+			lines = append(lines, []string{p.ID, ar.action(), ar.Resource.Type})
+		}
+		if len(p.ActionAndResources) == 0 {
+			lines = append(lines, []string{p.ID, "", ""})
+		}
+	}
+	output.PrintCmdOutputCustom(cmd, r, &output.Table{
+		Headers: []string{"Permission", "Action", "Resource Type"},
+		Lines:   lines,
+	})
+}
+
+// action returns the display form of the action, e.g., "READ" or "solution:publish (UPDATE)"
+func (ar actionAndResource) action() string {
+	switch {
+	case ar.Action.Type != "" && ar.Action.Classification != "":
+		return ar.Action.Type + " (" + strings.ToUpper(ar.Action.Classification) + ")"
+	case ar.Action.Type != "":
+		return ar.Action.Type
+	default:
+		return strings.ToUpper(ar.Action.Classification)
+	}
+}
+
+// convertValue converts a generic JSON value into a typed one
+func convertValue(in any, out any) error {
+	b, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, out)
+}
@@ -15,9 +15,14 @@
 package login
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/apex/log"
 	"github.com/spf13/cobra"
 
+	"github.com/cisco-open/fsoc/cmd/config"
+	"github.com/cisco-open/fsoc/exitcode"
 	"github.com/cisco-open/fsoc/output"
 	"github.com/cisco-open/fsoc/platform/api"
 )
@@ -29,22 +34,20 @@ var loginCmd = &cobra.Command{
 	Long: `This command logs in the principal specified in the profile, obtaining a temporary JWT token
 that will be automatically used by other commands.
 
+With --refresh-only, the command obtains a new token without any interactive steps, using the
+refresh token of an OAuth session or the credentials of a service or agent principal, and displays
+its expiration. It fails instead of starting a new browser login, so it can be run periodically,
+e.g., from cron, to keep a session warm.
+
 Usage:
-	fsoc login`,
+	fsoc login
+	fsoc login --refresh-only`,
 	Run:              login,
 	TraverseChildren: true,
 }
 
 func init() {
-	// Here you will define your flags and configuration settings.
-
-	// Cobra supports Persistent Flags which will work for this command
-	// and all subcommands, e.g.:
-	// loginCmd.PersistentFlags().String("foo", "", "A help for foo")
-
-	// Cobra supports local flags which will only run when this command
-	// is called directly, e.g.:
-	// loginCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+	loginCmd.Flags().Bool("refresh-only", false, "Refresh the token of the current session without an interactive login, failing if it can't be refreshed")
 }
 
 func NewSubCmd() *cobra.Command {
@@ -52,8 +55,31 @@ func NewSubCmd() *cobra.Command {
 }
 
 func login(cmd *cobra.Command, args []string) {
+	if refreshOnly, _ := cmd.Flags().GetBool("refresh-only"); refreshOnly {
+		refresh(cmd)
+		return
+	}
 	if err := api.Login(); err != nil {
 		log.Fatalf("Login failed: %v", err)
 	}
 	output.PrintCmdStatus(cmd, "Login completed successfully.\n")
 }
+
+func refresh(cmd *cobra.Command) {
+	if err := api.RefreshLogin(); err != nil {
+		code := exitcode.Of(err)
+		if code == exitcode.General {
+			code = exitcode.Auth
+		}
+		log.WithField(exitcode.Field, code).Fatalf("Token refresh failed: %v", err)
+	}
+
+	expires, err := api.TokenExpiry(config.GetCurrentContext().Token)
+	if err != nil {
+		log.Infof("Cannot determine the expiration of the new token: %v", err)
+		output.PrintCmdStatus(cmd, "Token refreshed successfully.\n")
+		return
+	}
+	output.PrintCmdStatus(cmd, fmt.Sprintf("Token refreshed successfully; it expires at %v (in %v).\n",
+		expires.Local().Format(time.RFC3339), time.Until(expires).Round(time.Second)))
+}
@@ -27,6 +27,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/cisco-open/fsoc/cmd/uql"
+	"github.com/cisco-open/fsoc/cmdkit/interrupt"
 )
 
 var (
@@ -98,6 +99,7 @@ func NewSubCmd() *cobra.Command {
 	cmd.Flags().StringVarP(&formatFlag, "format", "t", `{{.Message}}`, "format individual rows (Go template), may refer to: Message, Timestamp, Severity, EntityId, SpanId, TraceId")
 	cmd.Flags().StringVarP(&minSeverityFlag, "severity", "l", "", "minimum severity level")
 	cmd.MarkFlagsMutuallyExclusive("count", "follow")
+	cmd.AddCommand(newCmdShow())
 	return cmd
 }
 
@@ -161,14 +163,15 @@ type eventResult struct {
 }
 
 func followLogs(initialResponse *uql.Response, formatter rowFormatter, limit int, p printer) error {
-	interrupt := make(chan os.Signal, 1)
-	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	interrupt.Disable() // following until interrupted is not a failure
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
 	eventResults := make(chan eventResult, 1)
 	eventResults <- eventResult{data: extractEventDataSet(initialResponse)}
 
 	for {
 		select {
-		case <-interrupt:
+		case <-signals:
 			return nil
 		case followResult := <-eventResults:
 			if followResult.err != nil {
@@ -0,0 +1,121 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logs
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+
+	"github.com/cisco-open/fsoc/cmd/config"
+	"github.com/cisco-open/fsoc/logfile"
+	"github.com/cisco-open/fsoc/output"
+)
+
+// logEntry is an entry of the fsoc log file, as written by the JSON log handler
+type logEntry struct {
+	Fields    map[string]any `json:"fields"`
+	Level     string         `json:"level"`
+	Timestamp time.Time      `json:"timestamp"`
+	Message   string         `json:"message"`
+}
+
+func newCmdShow() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "show",
+		Short: "Display the fsoc log",
+		Long: `Display the log of the last fsoc command, e.g., to investigate a failure. This command is not logged itself.
+
+The log of each fsoc command replaces the previous one, which is kept with the suffix .1, and so on, up to
+the number of log files specified with --log-keep. Use --previous to display the log of the command
+before the last one, or --previous=N for older ones. A log file is also rotated when it reaches the size
+limit specified with --log-max-size.`,
+		Example: `  fsoc logs show
+  fsoc logs show --previous
+  fsoc logs show --previous=3 --raw`,
+		Args:        cobra.NoArgs,
+		Run:         showLog,
+		Annotations: map[string]string{config.AnnotationForConfigBypass: "", logfile.AnnotationForNoLogFile: ""},
+	}
+
+	cmd.Flags().Int("previous", 0, "Display the log of an earlier command: 1 for the one before the last, etc.")
+	cmd.Flags().Lookup("previous").NoOptDefVal = "1"
+	cmd.Flags().Bool("raw", false, "Display the log entries as they are stored, as JSON lines")
+
+	return cmd
+}
+
+func showLog(cmd *cobra.Command, args []string) {
+	location, _ := cmd.Flags().GetString("log")
+	previous, _ := cmd.Flags().GetInt("previous")
+	raw, _ := cmd.Flags().GetBool("raw")
+	if previous < 0 {
+		log.Fatalf("The --previous value must not be negative, found %d", previous)
+	}
+
+	path := logfile.Path(location, previous)
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			log.Fatalf("There is no log file %q; see --log-keep for the number of log files kept", path)
+		}
+		log.Fatalf("Failed to open the log file: %v", err)
+	}
+	defer file.Close()
+
+	var sb strings.Builder
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16<<20)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if raw {
+			sb.WriteString(line + "\n")
+			continue
+		}
+		sb.WriteString(formatLogEntry(line) + "\n")
+	}
+	if err := scanner.Err(); err != nil {
+		log.Fatalf("Failed to read the log file %q: %v", path, err)
+	}
+	output.PrintCmdStatus(cmd, sb.String())
+}
+
+// formatLogEntry formats a log file line as text, with the entry's fields sorted by name; lines
+// that are not log entries are returned unchanged
+func formatLogEntry(line string) string {
+	var entry logEntry
+	if err := json.Unmarshal([]byte(line), &entry); err != nil || entry.Timestamp.IsZero() {
+		return line
+	}
+
+	names := make([]string, 0, len(entry.Fields))
+	for name := range entry.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	s := fmt.Sprintf("%s %-5s %s", entry.Timestamp.Local().Format("2006-01-02T15:04:05.000"), strings.ToUpper(entry.Level), entry.Message)
+	for _, name := range names {
+		s += fmt.Sprintf(" %s=%v", name, entry.Fields[name])
+	}
+	return s
+}
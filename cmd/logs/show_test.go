@@ -0,0 +1,31 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatLogEntry(t *testing.T) {
+	timestamp := time.Date(2023, 6, 1, 10, 0, 0, 123e6, time.UTC)
+	line := `{"fields":{"profile":"prod","command":"get"},"level":"warn","timestamp":"` + timestamp.Format(time.RFC3339Nano) + `","message":"Retrying"}`
+	assert.Equal(t, timestamp.Local().Format("2006-01-02T15:04:05.000")+" WARN  Retrying command=get profile=prod", formatLogEntry(line))
+
+	assert.Equal(t, "not a log entry", formatLogEntry("not a log entry"))
+	assert.Equal(t, `{"other":"json"}`, formatLogEntry(`{"other":"json"}`))
+}
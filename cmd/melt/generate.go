@@ -0,0 +1,168 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package melt
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+
+	"github.com/cisco-open/fsoc/cmd/config"
+	"github.com/cisco-open/fsoc/output"
+	"github.com/cisco-open/fsoc/platform/melt"
+)
+
+var meltGenerateCmd = &cobra.Command{
+	Use:   "generate SPECFILE",
+	Short: "Generates synthetic telemetry from a spec file and sends it",
+	Long: `This command fabricates realistic telemetry from a declarative spec file and sends it to the FSO Platform
+Ingestion services, e.g., for load-testing dashboards and solution logic. The spec defines the entity types
+and how many entities of each to generate, the metrics of each entity with their value ranges, patterns
+(random, sine, ramp or constant), jitter and dimensions (one time series per combination of dimension
+values), and the logs, events and spans each entity emits within the time range.
+
+Example spec:
+
+  timerange: 1h
+  interval: 1m
+  entities:
+  - typename: k8s:workload
+    count: 5
+    attributes:
+      k8s.workload.name: cart-{index}
+    metrics:
+    - typename: k8s:cpu_usage
+      unit: "{cores}"
+      min: 0.5
+      max: 4
+      pattern: sine
+      jitter: 0.1
+      dimensions:
+        container: [app, sidecar]
+    logs:
+    - body: "Processed order {n}"
+      severity: INFO
+      count: 20
+    events:
+    - typename: k8s:pod_restart
+      count: 1
+    spans:
+    - name: GET /cart
+      count: 50
+      minduration: 20ms
+      maxduration: 800ms
+      errorrate: 0.02
+
+Use --save to write the generated telemetry as a fsoc telemetry data model .yaml file instead of sending it,
+e.g., to send it later with "fsoc melt push", and --seed to generate the same values repeatedly.
+
+Sending requires a profile with the "agent-principal" auth method (see "fsoc melt push").`,
+	Example: `  fsoc melt generate load-spec.yaml --profile agent
+  fsoc melt generate load-spec.yaml --time-range 24h --interval 5m --profile agent
+  fsoc melt generate load-spec.yaml --seed 42 --save load-melt.yaml`,
+	Args: cobra.ExactArgs(1),
+	Run:  meltGenerate,
+}
+
+func init() {
+	meltGenerateCmd.Flags().String("time-range", "", "How far back the telemetry goes, e.g., 1h (overrides the spec)")
+	meltGenerateCmd.Flags().String("interval", "", "The interval between metric data points, e.g., 30s (overrides the spec)")
+	meltGenerateCmd.Flags().Int64("seed", 0, "Seed for the random values, to generate the same telemetry repeatedly (default random)")
+	meltGenerateCmd.Flags().Int("batch-size", 100, "The number of entities sent per request")
+	meltGenerateCmd.Flags().String("save", "", "Write the generated telemetry to this fsoc telemetry data model .yaml file instead of sending it")
+	meltGenerateCmd.Flags().Bool("dry-run", false, "Generate the telemetry and display what would be sent, without sending it")
+	meltCmd.AddCommand(meltGenerateCmd)
+}
+
+func meltGenerate(cmd *cobra.Command, args []string) {
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	saveFile, _ := cmd.Flags().GetString("save")
+	batchSize, _ := cmd.Flags().GetInt("batch-size")
+	if batchSize < 1 {
+		log.Fatalf("Invalid --batch-size value %d", batchSize)
+	}
+	send := !dryRun && saveFile == ""
+	if ctx := config.GetCurrentContext(); send && ctx.AuthMethod != config.AuthMethodAgentPrincipal {
+		_ = cmd.Help()
+		log.Fatalf("This command requires a profile with \"agent-principal\" auth method, found %q instead", ctx.AuthMethod)
+	}
+
+	specFileName := args[0]
+	data, err := readDataFile(cmd, specFileName)
+	if err != nil {
+		log.Fatalf("Can't read spec file %q: %v", specFileName, err)
+	}
+	spec, err := melt.ParseGeneratorSpec(data)
+	if err != nil {
+		log.Fatalf("Invalid spec file %q: %v", specFileName, err)
+	}
+	if cmd.Flags().Changed("time-range") || cmd.Flags().Changed("interval") {
+		if cmd.Flags().Changed("time-range") {
+			spec.TimeRange, _ = cmd.Flags().GetString("time-range")
+		}
+		if cmd.Flags().Changed("interval") {
+			spec.Interval, _ = cmd.Flags().GetString("interval")
+		}
+		if err := spec.Validate(); err != nil {
+			log.Fatalf("Invalid spec: %v", err)
+		}
+	}
+
+	seed := time.Now().UnixNano()
+	if cmd.Flags().Changed("seed") {
+		seed, _ = cmd.Flags().GetInt64("seed")
+	}
+	fsoData := spec.Generate(time.Now(), rand.New(rand.NewSource(seed)))
+	for _, entity := range fsoData.Melt {
+		entity.SetAttribute("telemetry.sdk.name", "fsoc-melt")
+	}
+	log.WithFields(log.Fields{"entities": len(fsoData.Melt), "seed": seed}).Info("Generated telemetry")
+
+	if saveFile != "" {
+		writeDataFile(fsoData, saveFile)
+		output.PrintCmdStatus(cmd, fmt.Sprintf("Wrote the generated telemetry to %s\n", saveFile))
+		return
+	}
+	if send {
+		exportBatches(cmd, fsoData.Melt, batchSize)
+	}
+	printPushSummary(cmd, countTelemetry(fsoData.Melt), !send)
+}
+
+// exportBatches sends the telemetry of the entities, in batches of entities to keep the requests small
+func exportBatches(cmd *cobra.Command, entities []*melt.Entity, batchSize int) {
+	exp := &melt.Exporter{}
+	batches := (len(entities) + batchSize - 1) / batchSize
+	for i := 0; i < batches; i++ {
+		end := (i + 1) * batchSize
+		if end > len(entities) {
+			end = len(entities)
+		}
+		batch := entities[i*batchSize : end]
+		output.PrintCmdStatus(cmd, fmt.Sprintf("Sending batch %d of %d (%d entities)...\n", i+1, batches, len(batch)))
+		if err := exp.ExportMetrics(batch); err != nil {
+			log.Fatalf("Error exporting metrics: %v", err)
+		}
+		if err := exp.ExportLogs(batch); err != nil {
+			log.Fatalf("Error exporting logs: %v", err)
+		}
+		if err := exp.ExportSpans(batch); err != nil {
+			log.Fatalf("Error exporting spans: %v", err)
+		}
+	}
+}
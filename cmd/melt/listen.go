@@ -0,0 +1,185 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package melt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+
+	"github.com/cisco-open/fsoc/cmd/config"
+	"github.com/cisco-open/fsoc/cmdkit/interrupt"
+	"github.com/cisco-open/fsoc/output"
+	"github.com/cisco-open/fsoc/platform/melt"
+)
+
+// receivedTelemetry describes a request received by the OTLP receiver
+type receivedTelemetry struct {
+	Time      string          `json:"time"`
+	Protocol  string          `json:"protocol"`
+	Counts    melt.OTLPCounts `json:"counts"`
+	Forwarded bool            `json:"forwarded"`
+	Error     string          `json:"error,omitempty"`
+}
+
+var meltListenCmd = &cobra.Command{
+	Use:   "listen",
+	Short: "Runs a local OTLP receiver that forwards telemetry to the platform",
+	Long: `This command runs a local OTLP receiver, over gRPC and HTTP, and forwards the telemetry it receives to the
+FSO Platform Ingestion services, authenticated with the current profile. This allows pointing a stock
+OpenTelemetry SDK or collector at fsoc during development, e.g., with
+OTEL_EXPORTER_OTLP_ENDPOINT=http://localhost:4317 (gRPC) or http://localhost:4318 (HTTP), without
+configuring the platform credentials in the application.
+
+The HTTP receiver accepts the protobuf and JSON encodings on /v1/metrics, /v1/logs and /v1/traces. Each
+received request is displayed with the number of metrics, log records and spans in it; use -o json to
+display each as a JSON line. Failures to forward are returned to the sender, so that it can retry.
+
+Forwarding requires a profile with the "agent-principal" auth method (see "fsoc melt push"); use --no-forward
+to only display the received telemetry. The receiver runs until interrupted with Ctrl+C.`,
+	Example: `  fsoc melt listen --profile agent
+  fsoc melt listen --port 4317 --http-port 0 --profile agent
+  fsoc melt listen --no-forward -o json`,
+	Args: cobra.NoArgs,
+	Run:  meltListen,
+}
+
+func init() {
+	meltListenCmd.Flags().String("address", "127.0.0.1", "The network address to listen on")
+	meltListenCmd.Flags().Int("port", 4317, "The port of the OTLP gRPC receiver (0 to disable)")
+	meltListenCmd.Flags().Int("http-port", 4318, "The port of the OTLP/HTTP receiver (0 to disable)")
+	meltListenCmd.Flags().Bool("no-forward", false, "Display the received telemetry without forwarding it to the platform")
+	meltCmd.AddCommand(meltListenCmd)
+}
+
+func meltListen(cmd *cobra.Command, args []string) {
+	address, _ := cmd.Flags().GetString("address")
+	grpcPort, _ := cmd.Flags().GetInt("port")
+	httpPort, _ := cmd.Flags().GetInt("http-port")
+	noForward, _ := cmd.Flags().GetBool("no-forward")
+	if grpcPort == 0 && httpPort == 0 {
+		log.Fatalf("At least one of the gRPC and HTTP receivers must be enabled")
+	}
+	if ctx := config.GetCurrentContext(); !noForward && ctx.AuthMethod != config.AuthMethodAgentPrincipal {
+		_ = cmd.Help()
+		log.Fatalf("This command requires a profile with \"agent-principal\" auth method, found %q instead", ctx.AuthMethod)
+	}
+
+	// requests may arrive concurrently; forward one at a time, sharing the profile's authentication
+	var mu sync.Mutex
+	exp := &melt.Exporter{}
+	receiver := &melt.Receiver{Handle: func(protocol string, d *melt.OTLPData) error {
+		mu.Lock()
+		defer mu.Unlock()
+		received := &receivedTelemetry{
+			Time:     time.Now().UTC().Format(time.RFC3339Nano),
+			Protocol: protocol,
+			Counts:   d.Counts(),
+		}
+		var err error
+		if !noForward {
+			err = exp.ExportOTLP(d)
+			received.Forwarded = err == nil
+			if err != nil {
+				received.Error = err.Error()
+			}
+		}
+		printReceived(cmd, received)
+		return err
+	}}
+
+	interrupt.Disable() // listening until interrupted is not a failure
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	var wg sync.WaitGroup
+
+	if grpcPort != 0 {
+		listener := listenOn(address, grpcPort)
+		server := grpc.NewServer()
+		receiver.RegisterGRPC(server)
+		fmt.Fprintf(os.Stderr, "OTLP gRPC receiver listening on %s\n", listener.Addr())
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := server.Serve(listener); err != nil {
+				log.Fatalf("OTLP gRPC receiver failed: %v", err)
+			}
+		}()
+		go func() {
+			<-ctx.Done()
+			server.GracefulStop()
+		}()
+	}
+	if httpPort != 0 {
+		listener := listenOn(address, httpPort)
+		server := &http.Server{Handler: receiver, ReadHeaderTimeout: 10 * time.Second}
+		fmt.Fprintf(os.Stderr, "OTLP/HTTP receiver listening on http://%s\n", listener.Addr())
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Fatalf("OTLP/HTTP receiver failed: %v", err)
+			}
+		}()
+		go func() {
+			<-ctx.Done()
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			_ = server.Shutdown(shutdownCtx)
+		}()
+	}
+	if noForward {
+		fmt.Fprintln(os.Stderr, "Telemetry is displayed but not forwarded (--no-forward)")
+	}
+	fmt.Fprintln(os.Stderr, "Press Ctrl+C to stop")
+	wg.Wait()
+}
+
+func listenOn(address string, port int) net.Listener {
+	listener, err := net.Listen("tcp", net.JoinHostPort(address, fmt.Sprint(port)))
+	if err != nil {
+		log.Fatalf("Failed to listen on %s:%d: %v", address, port, err)
+	}
+	return listener
+}
+
+// printReceived displays a received request, as a JSON line with -o json or as text otherwise
+func printReceived(cmd *cobra.Command, r *receivedTelemetry) {
+	if format, _ := cmd.Flags().GetString("output"); format == "json" {
+		b, _ := json.Marshal(r)
+		output.PrintCmdStatus(cmd, string(b)+"\n")
+		return
+	}
+	c := r.Counts
+	status := "received"
+	switch {
+	case r.Error != "":
+		status = "failed to forward: " + r.Error
+	case r.Forwarded:
+		status = "forwarded"
+	}
+	output.PrintCmdStatus(cmd, fmt.Sprintf("%s %s: %d metric(s), %d log record(s), %d span(s) %s\n", r.Time, r.Protocol, c.Metrics, c.LogRecords, c.Spans, status))
+}
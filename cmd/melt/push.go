@@ -1,14 +1,13 @@
 package melt
 
 import (
+	"fmt"
 	"io"
-	"math/rand"
 	"os"
 	"time"
 
 	"github.com/apex/log"
 	"github.com/spf13/cobra"
-	"gopkg.in/yaml.v2"
 
 	"github.com/cisco-open/fsoc/cmd/config"
 	"github.com/cisco-open/fsoc/output"
@@ -17,59 +16,118 @@ import (
 
 var meltPushCmd = &cobra.Command{
 	Use:   "push DATAFILE",
-	Short: "Generates OTLP telemetry based on fsoc telemetry data model .yaml",
-	Long: `This command generates OTLP payload based on a fsoc telemetry data models and sends the data to the FSO Platform Ingestion services.
-	
-	To properly use the command you will need to create a fsoc profile using an agent principal yaml:
-	fsoc config set --profile <agent-principal-profile> --auth agent-principal --secret-file <agent-principal.yaml>
-	
-	Then you will use the agent principal profile as part of the command:
-	fsoc melt push <fsocdatamodel>.yaml --profile <agent-principal-profile> `,
+	Short: "Sends telemetry from a fsoc telemetry data model .yaml or an OTLP JSON file",
+	Long: `This command sends metrics, events, logs and traces to the FSO Platform Ingestion services, so that solution
+developers can generate test telemetry without setting up a collector. The data file can be:
+
+  - a fsoc telemetry data model .yaml (see "fsoc melt model"), with entities and their metrics, logs, events
+    and spans; metrics without data points get random values for the last 5 minutes, and logs, events and
+    spans without timestamps get the current time
+  - an OTLP JSON file, with an export request for metrics, logs and/or traces, or several of them, one per
+    line, as written by the OpenTelemetry collector's file exporter; the data is sent as is
+
+Use "-" as DATAFILE to read the data from the standard input, and --dry-run to check the data without sending it.
+
+To properly use the command you will need to create a fsoc profile using an agent principal yaml:
+fsoc config set --profile <agent-principal-profile> --auth agent-principal --secret-file <agent-principal.yaml>
+
+Then you will use the agent principal profile as part of the command:
+fsoc melt push <fsocdatamodel>.yaml --profile <agent-principal-profile> `,
+	Example: `  fsoc melt push mysolution-1.0.0-melt.yaml --profile agent
+  fsoc melt push traces.json --profile agent
+  otelcol-export | fsoc melt push - --profile agent --dry-run`,
 	TraverseChildren: true,
 	Args:             cobra.ExactArgs(1),
 	Run:              meltSend,
 }
 
 func init() {
+	meltPushCmd.Flags().Bool("dry-run", false, "Parse the data file and display what would be sent, without sending it")
 	meltCmd.AddCommand(meltPushCmd)
 }
 
 func meltSend(cmd *cobra.Command, args []string) {
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
 	ctx := config.GetCurrentContext()
-	if ctx.AuthMethod != config.AuthMethodAgentPrincipal {
+	if ctx.AuthMethod != config.AuthMethodAgentPrincipal && !dryRun {
 		_ = cmd.Help()
 		log.Fatalf("This command requires a profile with \"agent-principal\" auth method, found %q instead", ctx.AuthMethod)
 	}
 	dataFileName := args[0]
-	sendDataFromFile(cmd, dataFileName)
-}
 
-func sendDataFromFile(cmd *cobra.Command, dataFileName string) {
-	fsoData, err := loadDataFile(dataFileName)
+	data, err := readDataFile(cmd, dataFileName)
 	if err != nil {
-		log.Fatalf("Can't open data file %q: %v", dataFileName, err)
+		log.Fatalf("Can't read data file %q: %v", dataFileName, err)
+	}
+	if melt.IsOTLPJSON(data) {
+		sendOTLPData(cmd, dataFileName, data, dryRun)
+		return
 	}
+	sendDataFromFile(cmd, dataFileName, data, dryRun)
+}
 
-	for _, entity := range fsoData.Melt {
-		entity.SetAttribute("telemetry.sdk.name", "fsoc-melt")
-		for _, m := range entity.Metrics {
-			et := time.Now()
-			if len(m.DataPoints) == 0 {
-				for i := 1; i < 6; i++ {
-					st := et.Add(time.Minute * -1)
-					et = st
-					m.AddDataPoint(st.UnixNano(), et.UnixNano(), rand.Float64()*50)
-				}
-			}
+func readDataFile(cmd *cobra.Command, fileName string) ([]byte, error) {
+	if fileName == "-" {
+		return io.ReadAll(cmd.InOrStdin())
+	}
+	return os.ReadFile(fileName)
+}
+
+func sendOTLPData(cmd *cobra.Command, dataFileName string, data []byte, dryRun bool) {
+	otlpData, err := melt.ParseOTLPJSON(data)
+	if err != nil {
+		log.Fatalf("Failed to parse OTLP JSON file %q: %v", dataFileName, err)
+	}
+	counts := otlpData.Counts()
+	if !dryRun {
+		output.PrintCmdStatus(cmd, "Sending OTLP telemetry... \n")
+		exp := &melt.Exporter{}
+		if err := exp.ExportOTLP(otlpData); err != nil {
+			log.Fatalf("Error exporting telemetry: %v", err)
 		}
+	}
+	printPushSummary(cmd, counts, dryRun)
+}
+
+func sendDataFromFile(cmd *cobra.Command, dataFileName string, data []byte, dryRun bool) {
+	fsoData, err := melt.ParseFsocData(data)
+	if err != nil {
+		log.Fatalf("Failed to parse fsoc telemetry model file %q: %v", dataFileName, err)
+	}
+	fsoData.FillDefaults(time.Now())
+
+	if !dryRun {
+		exportMeltStraight(cmd, fsoData)
+	}
+	printPushSummary(cmd, countTelemetry(fsoData.Melt), dryRun)
+}
+
+// countTelemetry returns the number of metrics, log records, events and spans of the entities
+func countTelemetry(entities []*melt.Entity) melt.OTLPCounts {
+	var counts melt.OTLPCounts
+	for _, entity := range entities {
+		counts.Metrics += len(entity.Metrics)
+		counts.LogRecords += len(entity.Logs)
+		counts.Spans += len(entity.Spans)
 		for _, l := range entity.Logs {
-			if l.Timestamp == 0 {
-				l.Timestamp = time.Now().UnixNano()
+			if l.IsEvent {
+				counts.Events++
 			}
 		}
 	}
+	return counts
+}
 
-	exportMeltStraight(cmd, fsoData)
+func printPushSummary(cmd *cobra.Command, counts melt.OTLPCounts, dryRun bool) {
+	verb := "Sent"
+	if dryRun {
+		verb = "Dry run: would send"
+	}
+	output.PrintCmdOutputCustom(cmd, counts, &output.Table{
+		Headers: []string{"Metrics", "Log Records", "Events", "Spans"},
+		Lines:   [][]string{{fmt.Sprint(counts.Metrics), fmt.Sprint(counts.LogRecords), fmt.Sprint(counts.Events), fmt.Sprint(counts.Spans)}},
+	})
+	output.PrintCmdStatus(cmd, fmt.Sprintf("%s %d metric(s), %d log record(s) (%d of them events) and %d span(s).\n", verb, counts.Metrics, counts.LogRecords, counts.Events, counts.Spans))
 }
 
 func exportMeltStraight(cmd *cobra.Command, fsoData *melt.FsocData) {
@@ -93,24 +151,9 @@ func exportMelt(cmd *cobra.Command, fsoData melt.FsocData) {
 		log.Fatalf("Error exporting logs: %s", err)
 	}
 
-}
-
-func loadDataFile(fileName string) (*melt.FsocData, error) {
-	var fsoData *melt.FsocData
-
-	dataFile, err := os.Open(fileName)
-	if err != nil {
-		log.Fatalf("Can't open the file named %q: %v", fileName, err)
-	}
-
-	defer dataFile.Close()
-
-	dataBytes, _ := io.ReadAll(dataFile)
-
-	err = yaml.Unmarshal(dataBytes, &fsoData)
+	output.PrintCmdStatus(cmd, "\nExporting spans... \n")
+	err = exp.ExportSpans(fsoData.Melt)
 	if err != nil {
-		log.Fatalf("Failed to parse fsoc telemetry model file: %v", err)
+		log.Fatalf("Error exporting spans: %s", err)
 	}
-
-	return fsoData, nil
 }
@@ -0,0 +1,39 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package metrics provides commands to query the metrics of the entities observed by the platform,
+// generating the underlying UQL queries
+package metrics
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func NewSubCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "metrics",
+		Short: "Query metrics",
+		Long: `Query the metric time series of the entities observed by the platform, without writing UQL.
+
+These commands generate and run UQL queries; use --show-query to display the generated query, e.g.,
+as a starting point for more specific queries with "fsoc uql".`,
+		Example: `  fsoc metrics query infra:system.cpu.utilization --type k8s:workload --from -1h --rollup 5m --filter k8s.namespace.name=prod
+  fsoc metrics query apm:response_time --type apm:service --sparkline`,
+		TraverseChildren: true,
+	}
+
+	cmd.AddCommand(newCmdQuery())
+
+	return cmd
+}
@@ -0,0 +1,384 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+
+	"github.com/cisco-open/fsoc/cmd/uql"
+	"github.com/cisco-open/fsoc/output"
+)
+
+var (
+	metricRegexp = regexp.MustCompile(`^[\w.-]+:[\w.-]+$`)
+)
+
+// sparklineWidth is the maximum number of characters of a sparkline
+const sparklineWidth = 60
+
+// queryOptions are the parameters of a metric query
+type queryOptions struct {
+	metric     string
+	entityType string
+	entityId   string
+	filters    []string
+	from       string
+	to         string
+	rollup     time.Duration
+}
+
+// dataPoint is a value of a metric time series, as displayed
+type dataPoint struct {
+	Entity    string    `json:"entity"`
+	Source    string    `json:"source,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	Value     float64   `json:"value"`
+}
+
+// timeSeries is the time series of a metric for an entity (and source)
+type timeSeries struct {
+	Entity string
+	Source string
+	Points []dataPoint
+}
+
+// seriesSummary is a time series summarized as a sparkline, as displayed
+type seriesSummary struct {
+	Entity    string  `json:"entity"`
+	Source    string  `json:"source,omitempty"`
+	Min       float64 `json:"min"`
+	Max       float64 `json:"max"`
+	Last      float64 `json:"last"`
+	Sparkline string  `json:"sparkline"`
+}
+
+func newCmdQuery() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "query METRIC (--type TYPE | --entity ID)",
+		Short: "Display the time series of a metric",
+		Long: `Display the time series of a metric for the entities of a type, optionally filtered by the values of their
+attributes with --filter attribute=value, or for a single entity.
+
+The time range is specified with --from and --to, as relative times (e.g., -1h or 2d), "now" or absolute
+times in RFC 3339 format (e.g., 2023-06-01T10:00:00Z). The --rollup flag selects the granularity of the data
+points, which otherwise is chosen by the platform based on the time range.
+
+The data points are displayed as a table, one per row; use -o json, yaml, jsonl or csv for other formats.
+With --sparkline, each time series is summarized in one row, with a sparkline of its values.`,
+		Example: `  fsoc metrics query infra:system.cpu.utilization --type k8s:workload --from -1h --to now --rollup 5m --filter k8s.namespace.name=prod
+  fsoc metrics query apm:response_time --entity apm:service:Ry4Aa2JfNcqrEXampleId --from -1d --rollup 1h -o csv
+  fsoc metrics query apm:response_time --type apm:service --sparkline
+  fsoc metrics query apm:response_time --type apm:service --show-query`,
+		Args: cobra.ExactArgs(1),
+		Run:  metricsQuery,
+	}
+
+	cmd.Flags().String("type", "", "Fully qualified type of the entities to query, e.g., k8s:workload")
+	cmd.Flags().String("entity", "", "ID of the entity to query, instead of --type")
+	cmd.MarkFlagsMutuallyExclusive("type", "entity")
+	cmd.Flags().StringArray("filter", nil, "Filter the entities by attribute value, as attribute=value (can be repeated)")
+	cmd.Flags().String("from", "-1h", "Start of the time range")
+	cmd.Flags().String("to", "now", "End of the time range")
+	cmd.Flags().Duration("rollup", 0, "Granularity of the data points, e.g., 1m or 5m (default chosen by the platform)")
+	cmd.Flags().Bool("sparkline", false, "Summarize each time series with a sparkline")
+	cmd.Flags().Bool("show-query", false, "Display the generated UQL query instead of executing it")
+
+	return cmd
+}
+
+func metricsQuery(cmd *cobra.Command, args []string) {
+	opts := queryOptions{metric: args[0]}
+	opts.entityType, _ = cmd.Flags().GetString("type")
+	opts.entityId, _ = cmd.Flags().GetString("entity")
+	opts.filters, _ = cmd.Flags().GetStringArray("filter")
+	opts.from, _ = cmd.Flags().GetString("from")
+	opts.to, _ = cmd.Flags().GetString("to")
+	opts.rollup, _ = cmd.Flags().GetDuration("rollup")
+
+	query, err := metricQuery(opts)
+	if err != nil {
+		_ = cmd.Usage()
+		log.Fatalf("%v", err)
+	}
+	log.WithFields(log.Fields{"metric": opts.metric, "query": query}).Info("Querying metric")
+	if show, _ := cmd.Flags().GetBool("show-query"); show {
+		output.PrintCmdStatus(cmd, query+"\n")
+		return
+	}
+
+	response := uql.FetchQuery(cmd, query)
+	series := extractSeries(response.Main())
+
+	if sparkline, _ := cmd.Flags().GetBool("sparkline"); sparkline {
+		printSparklines(cmd, series)
+		return
+	}
+	points := []dataPoint{}
+	var lines [][]string
+	for _, s := range series {
+		for _, p := range s.Points {
+			points = append(points, p)
+			lines = append(lines, []string{p.Entity, p.Source, p.Timestamp.Format(time.RFC3339), formatValue(p.Value)})
+		}
+	}
+	output.PrintCmdOutputCustom(cmd, struct {
+		Items []dataPoint `json:"items"`
+		Total int         `json:"total"`
+	}{Items: points, Total: len(points)}, &output.Table{
+		Headers: []string{"Entity", "Source", "Timestamp", "Value"},
+		Lines:   lines,
+	})
+}
+
+func printSparklines(cmd *cobra.Command, series []timeSeries) {
+	summaries := []seriesSummary{}
+	var lines [][]string
+	for _, s := range series {
+		if len(s.Points) == 0 {
+			continue
+		}
+		values := make([]float64, len(s.Points))
+		for i, p := range s.Points {
+			values[i] = p.Value
+		}
+		summary := seriesSummary{
+			Entity:    s.Entity,
+			Source:    s.Source,
+			Min:       minOf(values),
+			Max:       maxOf(values),
+			Last:      values[len(values)-1],
+			Sparkline: sparkline(values, sparklineWidth),
+		}
+		summaries = append(summaries, summary)
+		lines = append(lines, []string{summary.Entity, summary.Source, summary.Sparkline, formatValue(summary.Min), formatValue(summary.Max), formatValue(summary.Last)})
+	}
+	output.PrintCmdOutputCustom(cmd, struct {
+		Items []seriesSummary `json:"items"`
+		Total int             `json:"total"`
+	}{Items: summaries, Total: len(summaries)}, &output.Table{
+		Headers: []string{"Entity", "Source", "Trend", "Min", "Max", "Last"},
+		Lines:   lines,
+	})
+}
+
+// metricQuery generates the UQL query that fetches the time series of a metric
+func metricQuery(opts queryOptions) (string, error) {
+	if !metricRegexp.MatchString(opts.metric) {
+		return "", fmt.Errorf("invalid metric %q: expected a fully qualified metric type, e.g., infra:system.cpu.utilization", opts.metric)
+	}
+
+	var entities, filter string
+	switch {
+	case opts.entityId != "":
+		if !uql.IsEntityId(opts.entityId) {
+			return "", fmt.Errorf("invalid entity ID %q: expected an ID like k8s:workload:Ry4Aa2JfNcqrEXampleId", opts.entityId)
+		}
+		if len(opts.filters) > 0 {
+			return "", fmt.Errorf("--filter cannot be used with --entity")
+		}
+		entities = opts.entityId
+	case opts.entityType != "":
+		if !uql.IsEntityType(opts.entityType) {
+			return "", fmt.Errorf("invalid entity type %q: expected a fully qualified type, e.g., k8s:workload", opts.entityType)
+		}
+		var err error
+		if filter, err = uql.AttributeFilter(opts.filters); err != nil {
+			return "", err
+		}
+		entities = opts.entityType
+	default:
+		return "", fmt.Errorf("the entities to query must be specified with --type or --entity")
+	}
+
+	from, err := uql.TimeExpression(opts.from)
+	if err != nil {
+		return "", fmt.Errorf("invalid --from: %w", err)
+	}
+	to, err := uql.TimeExpression(opts.to)
+	if err != nil {
+		return "", fmt.Errorf("invalid --to: %w", err)
+	}
+
+	query := fmt.Sprintf("FETCH id, metrics(%s) FROM entities(%s)%s SINCE %s UNTIL %s", opts.metric, entities, filter, from, to)
+	if opts.rollup != 0 {
+		granularity, err := isoDuration(opts.rollup)
+		if err != nil {
+			return "", fmt.Errorf("invalid --rollup: %w", err)
+		}
+		query += fmt.Sprintf(" LIMITS metrics.granularityDuration(%s)", granularity)
+	}
+	return query, nil
+}
+
+// isoDuration formats a duration in ISO 8601 format, e.g., PT5M, as used by UQL
+func isoDuration(d time.Duration) (string, error) {
+	switch {
+	case d < time.Second || d%time.Second != 0:
+		return "", fmt.Errorf("%v is not a positive number of seconds", d)
+	case d%time.Hour == 0:
+		return fmt.Sprintf("PT%dH", d/time.Hour), nil
+	case d%time.Minute == 0:
+		return fmt.Sprintf("PT%dM", d/time.Minute), nil
+	default:
+		return fmt.Sprintf("PT%dS", d/time.Second), nil
+	}
+}
+
+// extractSeries extracts the time series from the main data set of a metric query's response: for each
+// entity (row), the metric data has a row per source, with the data points as a nested time series
+func extractSeries(main *uql.DataSet) []timeSeries {
+	series := []timeSeries{}
+	if main == nil || main.Model() == nil {
+		return series
+	}
+	idIndex, metricsIndex := -1, -1
+	for i, f := range main.Model().Fields {
+		switch {
+		case f.Alias == "id":
+			idIndex = i
+		case f.Model != nil && metricsIndex < 0:
+			metricsIndex = i
+		}
+	}
+	if metricsIndex < 0 {
+		return series
+	}
+
+	for _, row := range main.Values() {
+		entity := ""
+		if idIndex >= 0 && idIndex < len(row) {
+			entity = fmt.Sprint(row[idIndex])
+		}
+		if metricsIndex >= len(row) {
+			continue
+		}
+		metrics := complexOf(row[metricsIndex])
+		if metrics == nil {
+			continue
+		}
+		sourceIndex, pointsIndex := -1, -1
+		for i, f := range metrics.Model().Fields {
+			switch {
+			case f.Alias == "source":
+				sourceIndex = i
+			case f.Model != nil && pointsIndex < 0:
+				pointsIndex = i
+			}
+		}
+		for _, metricsRow := range metrics.Values() {
+			s := timeSeries{Entity: entity}
+			if sourceIndex >= 0 && sourceIndex < len(metricsRow) && metricsRow[sourceIndex] != nil {
+				s.Source = fmt.Sprint(metricsRow[sourceIndex])
+			}
+			if pointsIndex >= 0 && pointsIndex < len(metricsRow) {
+				s.Points = extractPoints(complexOf(metricsRow[pointsIndex]), s.Entity, s.Source)
+			}
+			series = append(series, s)
+		}
+	}
+	return series
+}
+
+// extractPoints extracts the data points of a time series, sorted by time
+func extractPoints(ts uql.Complex, entity, source string) []dataPoint {
+	points := []dataPoint{}
+	if ts == nil {
+		return points
+	}
+	timeIndex, valueIndex := -1, -1
+	for i, f := range ts.Model().Fields {
+		switch {
+		case f.Type == "timestamp" && timeIndex < 0:
+			timeIndex = i
+		case f.Alias == "value":
+			valueIndex = i
+		case (f.Type == "number" || f.Type == "double" || f.Type == "long") && valueIndex < 0:
+			valueIndex = i
+		}
+	}
+	if timeIndex < 0 || valueIndex < 0 {
+		return points
+	}
+	for _, row := range ts.Values() {
+		if timeIndex >= len(row) || valueIndex >= len(row) {
+			continue
+		}
+		t, ok := row[timeIndex].(time.Time)
+		if !ok {
+			continue
+		}
+		value, ok := toFloat(row[valueIndex])
+		if !ok {
+			continue
+		}
+		points = append(points, dataPoint{Entity: entity, Source: source, Timestamp: t, Value: value})
+	}
+	sort.SliceStable(points, func(i, j int) bool { return points[i].Timestamp.Before(points[j].Timestamp) })
+	return points
+}
+
+// complexOf returns the nested data of a cell, or nil if there is none
+func complexOf(v any) uql.Complex {
+	switch c := v.(type) {
+	case *uql.DataSet:
+		if c != nil && c.Model() != nil {
+			return c
+		}
+	case uql.ComplexData:
+		if c.Model() != nil {
+			return c
+		}
+	}
+	return nil
+}
+
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+func formatValue(v float64) string {
+	return fmt.Sprintf("%g", v)
+}
+
+func minOf(values []float64) float64 {
+	m := math.Inf(1)
+	for _, v := range values {
+		m = math.Min(m, v)
+	}
+	return m
+}
+
+func maxOf(values []float64) float64 {
+	m := math.Inf(-1)
+	for _, v := range values {
+		m = math.Max(m, v)
+	}
+	return m
+}
@@ -0,0 +1,97 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cisco-open/fsoc/cmd/uql"
+)
+
+func TestMetricQuery(t *testing.T) {
+	query, err := metricQuery(queryOptions{
+		metric:     "infra:system.cpu.utilization",
+		entityType: "k8s:workload",
+		filters:    []string{"k8s.namespace.name=prod"},
+		from:       "-1h",
+		to:         "now",
+		rollup:     5 * time.Minute,
+	})
+	require.Nil(t, err)
+	assert.Equal(t, "FETCH id, metrics(infra:system.cpu.utilization) FROM entities(k8s:workload)[attributes(k8s.namespace.name) = 'prod'] SINCE -1h UNTIL now LIMITS metrics.granularityDuration(PT5M)", query)
+
+	query, err = metricQuery(queryOptions{metric: "apm:response_time", entityId: "apm:service:Ry4Aa2+Jf==", from: "2d", to: "2023-06-01T12:00:00+02:00"})
+	require.Nil(t, err)
+	assert.Equal(t, "FETCH id, metrics(apm:response_time) FROM entities(apm:service:Ry4Aa2+Jf==) SINCE -2d UNTIL 2023-06-01T10:00:00Z", query)
+
+	for _, opts := range []queryOptions{
+		{metric: "cpu", entityType: "k8s:workload", from: "-1h", to: "now"},
+		{metric: "apm:response_time", from: "-1h", to: "now"},
+		{metric: "apm:response_time", entityId: "apm:service:x", filters: []string{"a=b"}, from: "-1h", to: "now"},
+		{metric: "apm:response_time", entityType: "apm:service", from: "yesterday", to: "now"},
+		{metric: "apm:response_time", entityType: "apm:service", from: "-1h", to: "now", rollup: 1500 * time.Millisecond},
+	} {
+		_, err := metricQuery(opts)
+		assert.Error(t, err, opts)
+	}
+}
+
+func TestIsoDuration(t *testing.T) {
+	for d, expected := range map[time.Duration]string{
+		30 * time.Second: "PT30S",
+		90 * time.Second: "PT90S",
+		5 * time.Minute:  "PT5M",
+		2 * time.Hour:    "PT2H",
+	} {
+		s, err := isoDuration(d)
+		require.Nil(t, err)
+		assert.Equal(t, expected, s)
+	}
+}
+
+func TestExtractSeries(t *testing.T) {
+	pointsModel := &uql.Model{Fields: []uql.ModelField{{Alias: "timestamp", Type: "timestamp"}, {Alias: "value", Type: "number"}}}
+	metricsModel := &uql.Model{Fields: []uql.ModelField{{Alias: "source", Type: "string"}, {Alias: "metrics", Type: "timeseries", Model: pointsModel}}}
+	t0 := time.Date(2023, 6, 1, 10, 0, 0, 0, time.UTC)
+	main := &uql.DataSet{
+		DataModel: &uql.Model{Fields: []uql.ModelField{{Alias: "id", Type: "string"}, {Alias: "metrics", Type: "complex", Model: metricsModel}}},
+		Data: [][]any{
+			{"k8s:workload:a", &uql.DataSet{DataModel: metricsModel, Data: [][]any{
+				{"infra", uql.ComplexData{DataModel: pointsModel, Data: [][]any{{t0.Add(time.Minute), 2}, {t0, 1.5}}}},
+			}}},
+			{"k8s:workload:b", (*uql.DataSet)(nil)},
+		},
+	}
+
+	series := extractSeries(main)
+	require.Len(t, series, 1)
+	assert.Equal(t, "k8s:workload:a", series[0].Entity)
+	assert.Equal(t, "infra", series[0].Source)
+	assert.Equal(t, []dataPoint{
+		{Entity: "k8s:workload:a", Source: "infra", Timestamp: t0, Value: 1.5},
+		{Entity: "k8s:workload:a", Source: "infra", Timestamp: t0.Add(time.Minute), Value: 2},
+	}, series[0].Points)
+}
+
+func TestSparkline(t *testing.T) {
+	assert.Equal(t, "▁▂▃▄▅▆▇█", sparkline([]float64{0, 1, 2, 3, 4, 5, 6, 7}, 60))
+	assert.Equal(t, "▅▅▅", sparkline([]float64{3, 3, 3}, 60))
+	assert.Equal(t, "▁█", sparkline([]float64{0, 0, 10, 10}, 2))
+	assert.Equal(t, "", sparkline(nil, 60))
+}
@@ -0,0 +1,57 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"math"
+	"strings"
+)
+
+var sparkTicks = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline renders the values as a line of block characters of increasing height, e.g., ▁▃▅█▆▂.
+// If there are more values than the width, consecutive values are averaged to fit it.
+func sparkline(values []float64, width int) string {
+	if len(values) == 0 {
+		return ""
+	}
+	if width > 0 && len(values) > width {
+		values = downsample(values, width)
+	}
+	low, high := minOf(values), maxOf(values)
+	var sb strings.Builder
+	for _, v := range values {
+		tick := len(sparkTicks) / 2 // flat series are displayed in the middle
+		if high > low {
+			tick = int(math.Round((v - low) / (high - low) * float64(len(sparkTicks)-1)))
+		}
+		sb.WriteRune(sparkTicks[tick])
+	}
+	return sb.String()
+}
+
+// downsample averages consecutive values into the specified number of values
+func downsample(values []float64, n int) []float64 {
+	result := make([]float64, n)
+	for i := range result {
+		start, end := i*len(values)/n, (i+1)*len(values)/n
+		sum := 0.0
+		for _, v := range values[start:end] {
+			sum += v
+		}
+		result[i] = sum / float64(end-start)
+	}
+	return result
+}
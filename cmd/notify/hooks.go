@@ -0,0 +1,123 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"time"
+
+	"github.com/cisco-open/fsoc/cmdkit/offline"
+)
+
+// timeout of posting the result to the webhook
+const webhookTimeout = 10 * time.Second
+
+// Result is the payload reporting the completion of a command, posted to the webhook as JSON and passed
+// to the notification command on its standard input. Text is the notification message, the field that
+// chat webhooks (e.g., Slack's and Teams') display.
+type Result struct {
+	Text       string    `json:"text"`
+	Command    string    `json:"command"`
+	Profile    string    `json:"profile,omitempty"`
+	Status     string    `json:"status"` // succeeded or failed
+	ExitCode   int       `json:"exitCode"`
+	Error      string    `json:"error,omitempty"`
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt"`
+	Duration   float64   `json:"duration"` // in seconds
+}
+
+func newResult(command string, profile string, started time.Time, duration time.Duration, message string, errorMessage string, code int) *Result {
+	status := "succeeded"
+	if errorMessage != "" || code != 0 {
+		status = "failed"
+	}
+	return &Result{
+		Text:       message,
+		Command:    command,
+		Profile:    profile,
+		Status:     status,
+		ExitCode:   code,
+		Error:      errorMessage,
+		StartedAt:  started.UTC(),
+		FinishedAt: started.Add(duration).UTC(),
+		Duration:   duration.Seconds(),
+	}
+}
+
+// postResult posts the result as JSON to the webhook URL
+func postResult(url string, result *Result) error {
+	if err := offline.Check("Posting to the webhook"); err != nil {
+		return err
+	}
+	body, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Timeout: webhookTimeout}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		text, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorLength))
+		return fmt.Errorf("%s: %s", resp.Status, bytes.TrimSpace(text))
+	}
+	return nil
+}
+
+// runHook runs the command line with the shell, passing the result as JSON on the standard input and
+// its main fields in environment variables
+func runHook(commandLine string, result *Result) error {
+	body, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	name, args := shellCommand(runtime.GOOS, commandLine)
+	c := exec.Command(name, args...)
+	c.Stdin = bytes.NewReader(body)
+	c.Stdout = os.Stderr // keep the command's output apart from fsoc's
+	c.Stderr = os.Stderr
+	c.Env = append(os.Environ(), hookEnvironment(result)...)
+	return c.Run()
+}
+
+// hookEnvironment returns the environment variables that pass the result to the notification command
+func hookEnvironment(result *Result) []string {
+	return []string{
+		"FSOC_NOTIFY_COMMAND=" + result.Command,
+		"FSOC_NOTIFY_STATUS=" + result.Status,
+		"FSOC_NOTIFY_EXIT_CODE=" + strconv.Itoa(result.ExitCode),
+		"FSOC_NOTIFY_ERROR=" + result.Error,
+		"FSOC_NOTIFY_TEXT=" + result.Text,
+	}
+}
+
+// shellCommand returns the command that runs a command line with the platform's shell
+func shellCommand(goos string, commandLine string) (string, []string) {
+	if goos == "windows" {
+		return "cmd", []string{"/C", commandLine}
+	}
+	return "sh", []string{"-c", commandLine}
+}
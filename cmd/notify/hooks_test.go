@@ -0,0 +1,86 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewResult(t *testing.T) {
+	started := time.Date(2023, 6, 6, 10, 0, 0, 0, time.UTC)
+	result := newResult("solution push", "prod", started, 90*time.Second, "done", "", 0)
+	assert.Equal(t, "succeeded", result.Status)
+	assert.Equal(t, started.Add(90*time.Second), result.FinishedAt)
+	assert.Equal(t, 90.0, result.Duration)
+
+	result = newResult("solution push", "prod", started, time.Second, "failed", "validation failed", 8)
+	assert.Equal(t, "failed", result.Status)
+	assert.Equal(t, 8, result.ExitCode)
+}
+
+func TestPostResult(t *testing.T) {
+	var received Result
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		if received.Status == "failed" {
+			http.Error(w, "invalid_payload", http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	result := newResult("solution push", "prod", time.Now(), time.Second, "Command completed", "", 0)
+	require.NoError(t, postResult(server.URL, result))
+	assert.Equal(t, "Command completed", received.Text)
+	assert.Equal(t, "solution push", received.Command)
+
+	result.Status = "failed"
+	assert.ErrorContains(t, postResult(server.URL, result), "invalid_payload")
+}
+
+func TestRunHook(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+	out := filepath.Join(t.TempDir(), "result")
+	result := newResult("solution push", "prod", time.Now(), time.Second, "Command completed", "", 0)
+	require.NoError(t, runHook(`cat > "`+out+`" && echo "$FSOC_NOTIFY_STATUS $FSOC_NOTIFY_EXIT_CODE" >> "`+out+`"`, result))
+
+	b, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `"command":"solution push"`)
+	assert.Contains(t, string(b), "succeeded 0\n")
+
+	assert.Error(t, runHook("exit 3", result))
+}
+
+func TestShellCommand(t *testing.T) {
+	name, args := shellCommand("linux", "echo hi")
+	assert.Equal(t, "sh", name)
+	assert.Equal(t, []string{"-c", "echo hi"}, args)
+	name, args = shellCommand("windows", "echo hi")
+	assert.Equal(t, "cmd", name)
+	assert.Equal(t, []string{"/C", "echo hi"}, args)
+}
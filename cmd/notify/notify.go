@@ -0,0 +1,163 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package notify reports the completion of a command, if requested: with a desktop notification
+// (--notify), by posting the result to a webhook (--notify-url) and/or by running a local command
+// (--notify-command), e.g., for ChatOps notifications of long-running operations
+package notify
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+
+	"github.com/cisco-open/fsoc/cmd/config"
+	"github.com/cisco-open/fsoc/exitcode"
+)
+
+// Names of the root command's flags that request notifications
+const (
+	FlagName        = "notify"
+	URLFlagName     = "notify-url"
+	CommandFlagName = "notify-command"
+)
+
+// maximum length of the error message included in a notification
+const maxErrorLength = 200
+
+const notificationTitle = "fsoc"
+
+// current tracks the command being executed, for notifying once it completes
+var current struct {
+	sync.Mutex
+	command string
+	started time.Time
+	active  bool   // a notification was requested
+	desktop bool   // show a desktop notification
+	url     string // webhook to post the result to
+	hook    string // local command to run with the result
+}
+
+// Start begins tracking a command, if a notification was requested for it; it should be called before
+// the command runs
+func Start(cmd *cobra.Command) {
+	current.Lock()
+	defer current.Unlock()
+
+	current.desktop, _ = cmd.Flags().GetBool(FlagName)
+	current.url, _ = cmd.Flags().GetString(URLFlagName)
+	current.hook, _ = cmd.Flags().GetString(CommandFlagName)
+	current.active = current.desktop || current.url != "" || current.hook != ""
+	current.command = strings.TrimPrefix(strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()), " ")
+	current.started = time.Now()
+}
+
+// Finish sends the requested notifications for the command started with Start, reporting the error, if any
+func Finish(err error) {
+	msg := ""
+	if err != nil {
+		msg = err.Error()
+	}
+	finish(msg, exitcode.Of(err))
+}
+
+// Handler returns a log handler that sends the notifications when a command fails with a fatal error
+// (which exits without returning through Finish)
+func Handler() log.Handler {
+	return log.HandlerFunc(func(e *log.Entry) error {
+		if e.Level == log.FatalLevel {
+			finish(e.Message, exitcode.ForEntry(e))
+		}
+		return nil
+	})
+}
+
+func finish(errorMessage string, code int) {
+	current.Lock()
+	defer current.Unlock()
+
+	if !current.active {
+		return
+	}
+	current.active = false
+
+	duration := time.Since(current.started)
+	message := notificationMessage(current.command, duration, errorMessage)
+	if current.desktop {
+		name, args, err := notifyCommand(runtime.GOOS, notificationTitle, message)
+		if err == nil {
+			err = exec.Command(name, args...).Run()
+		}
+		if err != nil {
+			log.Infof("Failed to show the desktop notification: %v", err)
+		}
+	}
+	if current.url == "" && current.hook == "" {
+		return
+	}
+	result := newResult(current.command, config.GetCurrentProfileName(), current.started, duration, message, errorMessage, code)
+	if current.url != "" {
+		if err := postResult(current.url, result); err != nil {
+			log.Warnf("Failed to post the result to the --%s webhook: %v", URLFlagName, err)
+		}
+	}
+	if current.hook != "" {
+		if err := runHook(current.hook, result); err != nil {
+			log.Warnf("Failed to run the --%s command: %v", CommandFlagName, err)
+		}
+	}
+}
+
+// notificationMessage returns the text of the notification for a completed command
+func notificationMessage(command string, duration time.Duration, errorMessage string) string {
+	duration = duration.Round(time.Second)
+	if errorMessage == "" {
+		return fmt.Sprintf("Command %q completed in %v", command, duration)
+	}
+	if len(errorMessage) > maxErrorLength {
+		errorMessage = errorMessage[:maxErrorLength-3] + "..."
+	}
+	return fmt.Sprintf("Command %q failed after %v: %s", command, duration, errorMessage)
+}
+
+// notifyCommand returns the program and arguments that show a notification on the operating system,
+// using tools available by default: osascript on macOS, notify-send on Linux and PowerShell on Windows
+func notifyCommand(goos string, title string, message string) (string, []string, error) {
+	switch goos {
+	case "darwin":
+		quote := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+		script := fmt.Sprintf(`display notification "%s" with title "%s"`, quote.Replace(message), quote.Replace(title))
+		return "osascript", []string{"-e", script}, nil
+	case "linux", "freebsd", "openbsd", "netbsd":
+		return "notify-send", []string{"--app-name", title, title, message}, nil
+	case "windows":
+		quote := strings.NewReplacer(`'`, `''`)
+		script := fmt.Sprintf(`[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null
+$template = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$text = $template.GetElementsByTagName('text')
+$text.Item(0).AppendChild($template.CreateTextNode('%s')) > $null
+$text.Item(1).AppendChild($template.CreateTextNode('%s')) > $null
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('%s').Show([Windows.UI.Notifications.ToastNotification]::new($template))`,
+			quote.Replace(title), quote.Replace(message), quote.Replace(title))
+		return "powershell", []string{"-NoProfile", "-NonInteractive", "-Command", script}, nil
+	default:
+		return "", nil, fmt.Errorf("desktop notifications are not supported on %s", goos)
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotificationMessage(t *testing.T) {
+	assert.Equal(t, `Command "solution push" completed in 1m3s`, notificationMessage("solution push", 62700*time.Millisecond, ""))
+	assert.Equal(t, `Command "solution push" failed after 5s: timed out`, notificationMessage("solution push", 5*time.Second, "timed out"))
+
+	msg := notificationMessage("x", 0, strings.Repeat("e", 500))
+	assert.True(t, strings.HasSuffix(msg, "..."))
+	assert.Less(t, len(msg), 250)
+}
+
+func TestNotifyCommand(t *testing.T) {
+	name, args, err := notifyCommand("darwin", "fsoc", `say "hi"`)
+	require.Nil(t, err)
+	assert.Equal(t, "osascript", name)
+	assert.Equal(t, []string{"-e", `display notification "say \"hi\"" with title "fsoc"`}, args)
+
+	name, args, err = notifyCommand("linux", "fsoc", "done")
+	require.Nil(t, err)
+	assert.Equal(t, "notify-send", name)
+	assert.Equal(t, []string{"--app-name", "fsoc", "fsoc", "done"}, args)
+
+	name, args, err = notifyCommand("windows", "fsoc", "it's done")
+	require.Nil(t, err)
+	assert.Equal(t, "powershell", name)
+	assert.Contains(t, args[len(args)-1], "CreateTextNode('it''s done')")
+
+	_, _, err = notifyCommand("plan9", "fsoc", "done")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,375 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package objstore
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+
+	"github.com/cisco-open/fsoc/cmd/config"
+	"github.com/cisco-open/fsoc/cmdkit/interrupt"
+	"github.com/cisco-open/fsoc/cmdkit/jsondiff"
+	"github.com/cisco-open/fsoc/cmdkit/onerror"
+	"github.com/cisco-open/fsoc/cmdkit/progress"
+	"github.com/cisco-open/fsoc/cmdkit/selector"
+	"github.com/cisco-open/fsoc/output"
+	"github.com/cisco-open/fsoc/platform/api"
+)
+
+// copyResult is the outcome of copying a single object
+type copyResult struct {
+	ID       string            `json:"id"`
+	TargetID string            `json:"targetId"`
+	Action   string            `json:"action"`
+	Changes  []jsondiff.Change `json:"changes,omitempty"`
+	Error    string            `json:"error,omitempty"`
+}
+
+// idMapping is a rule renaming object IDs: either an exact ID or a regular expression
+// with a replacement template
+type idMapping struct {
+	from        string
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+func getCopyObjectsCmd() *cobra.Command {
+	copyCmd := &cobra.Command{
+		Use:   "copy",
+		Short: "Copy knowledge objects from one tenant to another",
+		Long: `This command reads the knowledge objects of a type using one profile (--from-profile) and writes them
+into the tenant of another profile (--to-profile), creating the objects that don't exist there and updating
+the ones that do. It is the equivalent of "fsoc knowledge export" followed by "fsoc knowledge import",
+without the intermediate directory, and is typically used to promote objects from a development tenant
+to a production one.
+
+The objects can be selected with a SCIM filter (--filter) and/or a label selector (-l) on the object data.
+
+The object IDs can be changed with --map-id OLD=NEW, for a single object, and with --map-id-regex
+PATTERN=REPLACEMENT, which renames the IDs matching the regular expression (the replacement can refer to
+the pattern's groups as $1, $2, etc.). The rules are applied in order and the first matching rule wins.
+String values in the object data that are equal to a renamed ID, including identifying properties and
+references between the copied objects, are replaced with the new ID.
+
+With --dry-run, nothing is written: each object is compared with the object in the target tenant and the
+differences are displayed.`,
+		Example: `  # Preview, then promote the dashboards' themes from dev to prod
+  fsoc knowledge copy --type dashui:theme --from-profile dev --to-profile prod --dry-run
+  fsoc knowledge copy --type dashui:theme --from-profile dev --to-profile prod
+
+  # Copy the selected objects, renaming their IDs
+  fsoc knowledge copy --type dashui:theme --from-profile dev --to-profile prod --filter 'data.team eq "ops"' --map-id-regex '^dev-(.*)=prod-$1'`,
+		Args:             cobra.ExactArgs(0),
+		Run:              copyObjects,
+		TraverseChildren: true,
+	}
+
+	copyCmd.Flags().String("type", "", "The fully qualified type name of the objects (e.g., dashui:theme)")
+	_ = copyCmd.MarkFlagRequired("type")
+	copyCmd.Flags().String("from-profile", "", "The profile of the tenant to copy the objects from")
+	_ = copyCmd.MarkFlagRequired("from-profile")
+	copyCmd.Flags().String("to-profile", "", "The profile of the tenant to copy the objects to")
+	_ = copyCmd.MarkFlagRequired("to-profile")
+	copyCmd.Flags().String("layer-type", "TENANT", "The layer-type of the objects")
+	copyCmd.Flags().String("layer-id", "", "The layer-id of the objects. Optional for TENANT and SOLUTION layers")
+	copyCmd.Flags().String("filter", "", "A SCIM filter selecting the objects (e.g., 'data.name sw \"ops-\"')")
+	selector.AddFlag(copyCmd)
+	copyCmd.Flags().StringArray("map-id", nil, "Rename the object with the ID OLD to NEW, as OLD=NEW (can be repeated)")
+	copyCmd.Flags().StringArray("map-id-regex", nil, "Rename the object IDs matching a regular expression, as PATTERN=REPLACEMENT (can be repeated)")
+	copyCmd.Flags().Bool("dry-run", false, "Display the changes to the target tenant without making them")
+	onerror.AddFlag(copyCmd, onerror.Skip)
+
+	return copyCmd
+}
+
+func copyObjects(cmd *cobra.Command, args []string) {
+	objType, _ := cmd.Flags().GetString("type")
+	layerType, _ := cmd.Flags().GetString("layer-type")
+	fromProfile, _ := cmd.Flags().GetString("from-profile")
+	toProfile, _ := cmd.Flags().GetString("to-profile")
+	filter, _ := cmd.Flags().GetString("filter")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	if fromProfile == toProfile {
+		log.Fatal("The --from-profile and --to-profile must be different profiles")
+	}
+	exact, _ := cmd.Flags().GetStringArray("map-id")
+	patterns, _ := cmd.Flags().GetStringArray("map-id-regex")
+	mappings, err := parseIDMappings(exact, patterns)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	errs, err := onerror.New(cmd)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	filter, err = selector.GetFilter(cmd, "data.", filter)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	// read the objects from the source tenant
+	restore, err := config.UseProfile(fromProfile)
+	if err != nil {
+		log.Fatalf("Invalid --from-profile: %v", err)
+	}
+	objects, err := listObjects(cmd, objType, layerType, filter)
+	restore()
+	if err != nil {
+		log.Fatalf("Failed to get the %q objects from profile %q: %v", objType, fromProfile, err)
+	}
+
+	// rename the objects, replacing the references to the renamed objects in the data
+	renamed := map[string]string{}
+	ids := make([]string, len(objects))
+	for i, object := range objects {
+		ids[i], _ = object["id"].(string)
+		if newID := mapObjectID(ids[i], mappings); newID != ids[i] {
+			renamed[ids[i]] = newID
+		}
+	}
+	for _, m := range mappings {
+		if m.pattern == nil {
+			if _, found := renamed[m.from]; !found {
+				log.Warnf("No %q object with the ID %q is copied", objType, m.from)
+			}
+		}
+	}
+
+	restore, err = config.UseProfile(toProfile)
+	if err != nil {
+		log.Fatalf("Invalid --to-profile: %v", err)
+	}
+	defer restore()
+	headers, err := getLayerHeaders(cmd, layerType, objType)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	spinner := progress.Start(fmt.Sprintf("Copy of the %q objects", objType))
+	results := []copyResult{}
+	counts := map[string]int{}
+	for i, object := range objects {
+		if interrupt.Interrupted() {
+			break
+		}
+		if ids[i] == "" {
+			log.Warnf("Skipping a %q object without an id", objType)
+			continue
+		}
+		spinner.Update(fmt.Sprintf("%d of %d", i+1, len(objects)))
+		result := copyResult{ID: ids[i], TargetID: ids[i]}
+		if newID, found := renamed[ids[i]]; found {
+			result.TargetID = newID
+		}
+		data, _ := object["data"].(map[string]any)
+		data, _ = replaceIDs(data, renamed).(map[string]any)
+		if dryRun {
+			result.Action, result.Changes, err = compareTargetObject(objType, result.TargetID, data, headers)
+		} else {
+			result.Action, err = writeTargetObject(objType, result, data, headers, errs)
+		}
+		var abortErr *onerror.AbortError
+		aborted := errors.As(err, &abortErr)
+		if aborted {
+			err = abortErr.Err
+		}
+		if err != nil {
+			result.Action = "failed"
+			result.Error = err.Error()
+		}
+		counts[result.Action]++
+		results = append(results, result)
+		if aborted {
+			break
+		}
+	}
+	spinner.Stop(counts["failed"] == 0)
+
+	printCopyResults(cmd, results, dryRun)
+	if dryRun {
+		output.PrintCmdStatus(cmd, fmt.Sprintf("Dry run: %d %q object(s) would be created, %d updated and %d are unchanged in profile %q.\n", counts["create"], objType, counts["update"], counts["unchanged"], toProfile))
+		return
+	}
+	if interrupt.Interrupted() || len(results) < len(objects) {
+		log.Fatalf("Copy stopped after %d of %d object(s)", len(results), len(objects))
+	}
+	if counts["failed"] > 0 {
+		log.Fatalf("Failed to copy %d of %d %q object(s) to profile %q", counts["failed"], len(results), objType, toProfile)
+	}
+	output.PrintCmdStatus(cmd, fmt.Sprintf("Copied %d %q object(s) from profile %q to profile %q: %d created, %d updated.\n", len(results), objType, fromProfile, toProfile, counts["created"], counts["updated"]))
+}
+
+// listObjects returns the objects of a type in the layer, selected by the filter, using the current profile
+func listObjects(cmd *cobra.Command, objType string, layerType string, filter string) ([]map[string]any, error) {
+	headers, err := getLayerHeaders(cmd, layerType, objType)
+	if err != nil {
+		return nil, err
+	}
+	objUrl := getObjectListUrl(objType)
+	if filter != "" {
+		objUrl += "?" + url.Values{"filter": []string{filter}}.Encode()
+	}
+	var res any
+	if err := api.JSONGetCollection(objUrl, &res, &api.Options{Headers: headers}); err != nil {
+		return nil, err
+	}
+	var page struct {
+		Items []map[string]any `json:"items"`
+	}
+	if err := convertValue(res, &page); err != nil {
+		return nil, err
+	}
+	return page.Items, nil
+}
+
+// compareTargetObject returns whether the object would be created, updated or left unchanged in
+// the target tenant, with the changes to its data
+func compareTargetObject(objType string, id string, data map[string]any, headers map[string]string) (string, []jsondiff.Change, error) {
+	var res struct {
+		Data map[string]any `json:"data"`
+	}
+	options := &api.Options{Headers: headers}
+	err := api.JSONGet(getObjectUrl(objType, id), &res, options)
+	if api.IsNotFound(err, options) {
+		return "create", nil, nil
+	} else if err != nil {
+		return "", nil, err
+	}
+	var current, wanted any
+	if err := convertValue(res.Data, &current); err != nil {
+		return "", nil, err
+	}
+	if err := convertValue(data, &wanted); err != nil {
+		return "", nil, err
+	}
+	changes := jsondiff.Diff(current, wanted)
+	if len(changes) == 0 {
+		return "unchanged", nil, nil
+	}
+	return "update", changes, nil
+}
+
+// writeTargetObject creates the object in the target tenant or, if it already exists, replaces its data
+func writeTargetObject(objType string, r copyResult, data map[string]any, headers map[string]string, errs *onerror.Handler) (string, error) {
+	action := ""
+	err := errs.Do(fmt.Sprintf("object %q", r.ID), func() error {
+		var res any
+		options := &api.Options{Headers: headers, Resources: []string{objType + "/" + r.TargetID}}
+		err := api.JSONPost(getObjectListUrl(objType), data, &res, options)
+		if err != nil && options.ResponseStatus == http.StatusConflict {
+			action = "updated"
+			return api.JSONPut(getObjectUrl(objType, r.TargetID), data, &res, &api.Options{Headers: headers})
+		}
+		action = "created"
+		return err
+	})
+	return action, err
+}
+
+// parseIDMappings parses the OLD=NEW and PATTERN=REPLACEMENT ID mapping rules
+func parseIDMappings(exact []string, patterns []string) ([]idMapping, error) {
+	mappings := []idMapping{}
+	for _, rule := range exact {
+		from, to, found := strings.Cut(rule, "=")
+		if !found || from == "" || to == "" {
+			return nil, fmt.Errorf("Invalid --map-id %q: the rule must be OLD=NEW", rule)
+		}
+		mappings = append(mappings, idMapping{from: from, replacement: to})
+	}
+	for _, rule := range patterns {
+		from, to, found := strings.Cut(rule, "=")
+		if !found || from == "" {
+			return nil, fmt.Errorf("Invalid --map-id-regex %q: the rule must be PATTERN=REPLACEMENT", rule)
+		}
+		pattern, err := regexp.Compile(from)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid --map-id-regex %q: %v", rule, err)
+		}
+		mappings = append(mappings, idMapping{from: from, pattern: pattern, replacement: to})
+	}
+	return mappings, nil
+}
+
+// mapObjectID returns the new ID of an object, using the first matching rule, or the ID itself
+func mapObjectID(id string, mappings []idMapping) string {
+	for _, m := range mappings {
+		if m.pattern == nil && m.from == id {
+			return m.replacement
+		}
+		if m.pattern != nil && m.pattern.MatchString(id) {
+			return m.pattern.ReplaceAllString(id, m.replacement)
+		}
+	}
+	return id
+}
+
+// replaceIDs returns a copy of a JSON value with the string values that are renamed IDs replaced
+// with the new IDs
+func replaceIDs(v any, renamed map[string]string) any {
+	switch v := v.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for k, e := range v {
+			m[k] = replaceIDs(e, renamed)
+		}
+		return m
+	case []any:
+		a := make([]any, len(v))
+		for i, e := range v {
+			a[i] = replaceIDs(e, renamed)
+		}
+		return a
+	case string:
+		if newID, found := renamed[v]; found {
+			return newID
+		}
+	}
+	return v
+}
+
+func printCopyResults(cmd *cobra.Command, results []copyResult, dryRun bool) {
+	lines := make([][]string, len(results))
+	for i, r := range results {
+		lines[i] = []string{r.ID, r.TargetID, r.Action, r.Error}
+	}
+	output.PrintCmdOutputCustom(cmd, struct {
+		Items []copyResult `json:"items"`
+		Total int          `json:"total"`
+	}{Items: results, Total: len(results)}, &output.Table{
+		Headers: []string{"ID", "Target ID", "Action", "Error"},
+		Lines:   lines,
+	})
+	if !dryRun {
+		return
+	}
+	for _, r := range results {
+		if len(r.Changes) == 0 {
+			continue
+		}
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "\nChanges to object %q:\n", r.TargetID)
+		for _, c := range r.Changes {
+			fmt.Fprintf(&sb, "  %s %s: %s -> %s\n", c.Change, c.Path, jsondiff.DisplayValue(c.Old, revisionValueDisplayLength), jsondiff.DisplayValue(c.New, revisionValueDisplayLength))
+		}
+		output.PrintCmdStatus(cmd, sb.String())
+	}
+}
@@ -15,10 +15,7 @@
 package objstore
 
 import (
-	"encoding/json"
 	"fmt"
-	"io"
-	"os"
 
 	"github.com/apex/log"
 	"github.com/spf13/cobra"
@@ -32,8 +29,13 @@ var objStoreInsertCmd = &cobra.Command{
 	Short: "Create a new object of a given type",
 	Long: `This command allows the creation of a new object of a given type in the Object Store.
 
+The object data can be provided in a JSON or YAML file or, with --object-file=-, from the standard input.
+Before the object is created, its data is validated against the schema of its type, reporting the location
+of each error in the data; use --no-validate to skip the validation.
+
 Example:
   fsoc objstore create --type<fully-qualified-typename> --object-file=<fully-qualified-path> --layer-type=<valid-layer-type> [--layer-id=<valid-layer-id>]
+  cat theme.yaml | fsoc knowledge create --type=preferences:theme --layer-type=TENANT -f -
 `,
 
 	Args:             cobra.ExactArgs(0),
@@ -46,9 +48,8 @@ func getCreateObjectCmd() *cobra.Command {
 		String("type", "", "The fully qualified type name of the object")
 	_ = objStoreInsertCmd.MarkPersistentFlagRequired("type")
 
-	objStoreInsertCmd.Flags().
-		String("object-file", "", "The fully qualified path to the json file containing the object data")
-	_ = objStoreInsertCmd.MarkPersistentFlagRequired("objectFile")
+	addObjectFileFlag(objStoreInsertCmd, "The path to the file containing the object data")
+	addValidationFlag(objStoreInsertCmd)
 
 	objStoreInsertCmd.Flags().
 		String("layer-type", "", "The layer-type that the created object will be added to")
@@ -65,39 +66,20 @@ func insertObject(cmd *cobra.Command, args []string) {
 	objType, _ := cmd.Flags().GetString("type")
 
 	objJsonFilePath, _ := cmd.Flags().GetString("object-file")
-	objectFile, err := os.Open(objJsonFilePath)
+	objectStruct, err := readObjectData(cmd, objJsonFilePath)
 	if err != nil {
-		log.Fatalf("Can't find the object definition file named %q", objJsonFilePath)
+		log.Fatalf("%v. Make sure the object definition has all the required field and is valid according to the type definition.", err)
 	}
-	defer objectFile.Close()
 
-	objectBytes, _ := io.ReadAll(objectFile)
-	var objectStruct map[string]interface{}
-	err = json.Unmarshal(objectBytes, &objectStruct)
-	if err != nil {
-		log.Fatalf("Failed to parse object data from file %q: %v. Make sure the object definition has all the required field and is valid according to the type definition.", objJsonFilePath, err)
-	}
+	checkObjectData(cmd, objType, objectStruct)
 
 	layerType, _ := cmd.Flags().GetString("layer-type")
-	layerID := getCorrectLayerID(layerType, objType)
-
-	if layerID == "" {
-		if !cmd.Flags().Changed("layer-id") {
-			log.Fatal("Unable to set layer-id flag from given context. Please specify a unique layer-id value with the --layer-id flag")
-		}
-		layerID, err = cmd.Flags().GetString("layer-id")
-		if err != nil {
-			log.Fatalf("error trying to get %q flag value: %w", "layer-id", err)
-		}
-	}
-
-	headers := map[string]string{
-		"layer-type": layerType,
-		"layer-id":   layerID,
+	headers, err := getLayerHeaders(cmd, layerType, objType)
+	if err != nil {
+		log.Fatal(err.Error())
 	}
 
 	var res any
-	// objJsonStr, err := json.Marshal(objectStruct)
 	err = api.JSONPost(getObjStoreObjectUrl()+"/"+objType, objectStruct, &res, &api.Options{Headers: headers})
 	if err != nil {
 		log.Fatalf("Failed to create object: %v", err)
@@ -133,9 +115,7 @@ func getCreatePatchObjectCmd() *cobra.Command {
 		String("target-object-id", "", "The id of the object for which you want to create a patched object at a lower layer")
 	_ = objStoreInsertPatchedObjectCmd.MarkPersistentFlagRequired("target-object-id")
 
-	objStoreInsertPatchedObjectCmd.Flags().
-		String("object-file", "", "The fully qualified path to the json file containing the object definition")
-	_ = objStoreInsertPatchedObjectCmd.MarkPersistentFlagRequired("objectFile")
+	addObjectFileFlag(objStoreInsertPatchedObjectCmd, "The path to the file containing the object definition")
 
 	objStoreInsertPatchedObjectCmd.Flags().
 		String("target-layer-type", "", "The layer-type at which the patch object will be created. For inheritance purposes, this should always be a `lower` layer than the target object's layer")
@@ -149,18 +129,9 @@ func insertPatchObject(cmd *cobra.Command, args []string) {
 	parentObjId, _ := cmd.Flags().GetString("target-object-id")
 
 	objJsonFilePath, _ := cmd.Flags().GetString("object-file")
-	objectFile, err := os.Open(objJsonFilePath)
-	if err != nil {
-		log.Fatalf("Can't find the object definition file %q", objJsonFilePath)
-		return
-	}
-	defer objectFile.Close()
-
-	objectBytes, _ := io.ReadAll(objectFile)
-	var objectStruct map[string]interface{}
-	err = json.Unmarshal(objectBytes, &objectStruct)
+	objectStruct, err := readObjectData(cmd, objJsonFilePath)
 	if err != nil {
-		log.Fatalf("Failed to parse object data from file %q: %v. Make sure the object definition has all the required fields and is valid according to the type definition.", objJsonFilePath, err)
+		log.Fatalf("%v. Make sure the object definition has all the required fields and is valid according to the type definition.", err)
 	}
 
 	layerType, _ := cmd.Flags().GetString("target-layer-type")
@@ -15,11 +15,14 @@
 package objstore
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/apex/log"
 	"github.com/spf13/cobra"
 
+	"github.com/cisco-open/fsoc/cmdkit/onerror"
+	"github.com/cisco-open/fsoc/cmdkit/picker"
 	"github.com/cisco-open/fsoc/output"
 	"github.com/cisco-open/fsoc/platform/api"
 )
@@ -35,6 +38,11 @@ Usage:
     --object-id=<object id> \
     --layer-type=[SOLUTION|ACCOUNT|GLOBALUSER|TENANT|LOCALUSER] \
     --layer-id=<respective-layer-id>
+
+With --pick instead of --object-id, the objects to delete are chosen interactively among the objects of the
+type in the layer; without --object-id, they are also chosen this way when running interactively (see
+--interactive). When deleting an object fails, the user is asked whether to retry, skip it or abort (see
+--on-error).
 `,
 
 	Args:             cobra.ExactArgs(0),
@@ -58,42 +66,73 @@ func getDeleteObjectCmd() *cobra.Command {
 	objStoreDeleteCmd.Flags().
 		String("layer-id", "", "The layer-id of the updated object. Optional for TENANT and SOLUTION layers ")
 
+	picker.AddFlag(objStoreDeleteCmd)
+	objStoreDeleteCmd.MarkFlagsMutuallyExclusive("object-id", picker.FlagName)
+	onerror.AddFlag(objStoreDeleteCmd, onerror.Abort)
+
 	return objStoreDeleteCmd
 
 }
 
 func deleteObject(cmd *cobra.Command, args []string) {
-	var err error
-
-	objType, _ := cmd.Flags().GetString("type")
-
-	layerType, _ := cmd.Flags().GetString("layer-type")
-	layerID := getCorrectLayerID(layerType, objType)
+	objType, objId, headers := getObjectFlags(cmd)
+	errs, err := onerror.New(cmd)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	objIds := []string{objId}
+	if picker.Requested(cmd) || objId == "" && picker.Interactive(cmd) {
+		objIds = pickObjects(objType, headers, "Objects to delete")
+	} else if objId == "" {
+		log.Fatal("Object id cannot be empty, use --object-id=<id> or --pick")
+	}
 
-	if layerID == "" {
-		if !cmd.Flags().Changed("layer-id") {
-			log.Fatalf("Unable to set layer-id flag from given context. Please specify a unique layer-id value with the --layer-id flag")
+	for _, objId := range objIds {
+		urlStrf := getObjStoreObjectUrl() + "/%s/%s"
+		objectUrl := fmt.Sprintf(urlStrf, objType, objId)
+
+		output.PrintCmdStatus(cmd, (fmt.Sprintf("Deleting object %q of type %q\n", objId, objType)))
+		err := errs.Do(fmt.Sprintf("object %q", objId), func() error {
+			var res any
+			return api.JSONDelete(objectUrl, &res, &api.Options{Headers: headers})
+		})
+		if errors.Is(err, onerror.ErrSkipped) {
+			continue
 		}
-		layerID, err = cmd.Flags().GetString("layer-id")
 		if err != nil {
-			log.Fatalf("error trying to get %q flag value: %w", "layer-id", err)
+			log.Fatalf("Failed to delete object: %v", err)
 		}
+		output.PrintCmdStatus(cmd, "Object was successfully deleted.\n")
 	}
+}
 
-	headers := map[string]string{
-		"layer-type": layerType,
-		"layer-id":   layerID,
-	}
-
+// pickObjects lets the user choose objects of the type in the layer interactively (see --pick),
+// returning the ids of the chosen objects
+func pickObjects(objType string, headers map[string]string, prompt string) []string {
 	var res any
-	objId, _ := cmd.Flags().GetString("object-id")
-	urlStrf := getObjStoreObjectUrl() + "/%s/%s"
-	objectUrl := fmt.Sprintf(urlStrf, objType, objId)
+	if err := api.JSONGetCollection(getObjectListUrl(objType), &res, &api.Options{Headers: headers}); err != nil {
+		log.Fatalf("Failed to get the %q objects: %v", objType, err)
+	}
+	var page struct {
+		Items []struct {
+			ID string `json:"id"`
+		} `json:"items"`
+	}
+	if err := convertValue(res, &page); err != nil {
+		log.Fatalf("Failed to parse the %q objects: %v", objType, err)
+	}
 
-	output.PrintCmdStatus(cmd, (fmt.Sprintf("Deleting object %q of type %q\n", objId, objType)))
-	err = api.JSONDelete(objectUrl, &res, &api.Options{Headers: headers})
+	ids := make([]string, len(page.Items))
+	for i, item := range page.Items {
+		ids[i] = item.ID
+	}
+	chosen, err := picker.Pick(ids, &picker.Options{Prompt: prompt})
 	if err != nil {
-		log.Fatalf("Failed to delete object: %v", err)
+		log.Fatalf("No objects chosen: %v", err)
+	}
+	picked := make([]string, len(chosen))
+	for i, index := range chosen {
+		picked[i] = ids[index]
 	}
-	output.PrintCmdStatus(cmd, "Object was successfully deleted.\n")
+	return picked
 }
@@ -22,6 +22,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/cisco-open/fsoc/cmdkit"
+	"github.com/cisco-open/fsoc/cmdkit/selector"
 )
 
 func newGetObjectCmd() *cobra.Command {
@@ -44,6 +45,9 @@ func newGetObjectCmd() *cobra.Command {
 
   # Get list of objects filtering by a data field
   fsoc obj get --type preferences:theme --layer-type TENANT --filter "data.backgroundColor eq \"green\""
+
+  # Get list of objects, including the deleted ones that can be restored
+  fsoc obj get --type preferences:theme --layer-type TENANT --include-deleted
   `,
 		Args: cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -64,6 +68,8 @@ func newGetObjectCmd() *cobra.Command {
 		Var(&ltFlag, "layer-type", fmt.Sprintf("Valid value: %q, %q, %q, %q, %q", solution, account, globalUser, tenant, localUser))
 
 	getCmd.PersistentFlags().String("filter", "", "Filter condition in SCIM filter format for getting objects")
+	getCmd.PersistentFlags().Bool("include-deleted", false, "Include deleted objects that can be restored (for types that support soft deletion)")
+	selector.AddFlag(getCmd)
 	_ = getCmd.MarkPersistentFlagRequired("type")
 	// _ = getCmd.MarkPersistentFlagRequired("object")
 	//_ = getCmd.MarkPersistentFlagRequired("layer-id")
@@ -72,6 +78,47 @@ func newGetObjectCmd() *cobra.Command {
 	return getCmd
 }
 
+func newListObjectsCmd() *cobra.Command {
+	ltFlag := unknown
+
+	// listCmd represents the list objects command
+	listCmd := &cobra.Command{
+		Use:     "list",
+		Short:   "Fetch a list of objects of a given type from the object store.",
+		Aliases: []string{"ls"},
+		Long: `Fetch the objects of a given type from the object store, optionally filtered by a condition on their data.
+The condition can be specified as a SCIM filter (--filter) and/or as a label selector (-l) on the fields of the
+object data.`,
+		Example: `  # List themes
+  fsoc knowledge list --type preferences:theme --layer-type TENANT
+
+  # List objects filtering by a data field
+  fsoc knowledge list --type preferences:theme --layer-type TENANT --filter "data.backgroundColor eq \"green\""
+
+  # List objects selecting them by data fields
+  fsoc knowledge list --type preferences:theme --layer-type TENANT -l "backgroundColor in (green,blue),!deprecated"
+  `,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return getObject(cmd, args, ltFlag)
+		},
+		TraverseChildren: true,
+	}
+
+	listCmd.Flags().
+		String("type", "", "Fully qualified type name. It will be formed by combining the solution which defined the type and the type name.")
+	listCmd.Flags().String("layer-id", "", "Layer ID objects belong to.")
+	listCmd.Flags().
+		Var(&ltFlag, "layer-type", fmt.Sprintf("Valid value: %q, %q, %q, %q, %q", solution, account, globalUser, tenant, localUser))
+	listCmd.Flags().String("filter", "", "Filter condition in SCIM filter format for getting objects")
+	listCmd.Flags().Bool("include-deleted", false, "Include deleted objects that can be restored (for types that support soft deletion)")
+	selector.AddFlag(listCmd)
+	_ = listCmd.MarkFlagRequired("type")
+	_ = listCmd.MarkFlagRequired("layer-type")
+
+	return listCmd
+}
+
 func newGetTypeCmd() *cobra.Command {
 	// getTypeCmd represents the get type command
 	getTypeCmd := &cobra.Command{
@@ -119,9 +166,12 @@ func getObject(cmd *cobra.Command, args []string, ltFlag layerType) error {
 		return fmt.Errorf("error trying to get %q flag value: %w", "type", err)
 	}
 
-	objID, err := cmd.Flags().GetString("object")
-	if err != nil {
-		return fmt.Errorf("error trying to get %q flag value: %w", "object", err)
+	var objID string
+	if cmd.Flags().Lookup("object") != nil { // not defined for list
+		objID, err = cmd.Flags().GetString("object")
+		if err != nil {
+			return fmt.Errorf("error trying to get %q flag value: %w", "object", err)
+		}
 	}
 
 	var layerType string = string(ltFlag)
@@ -140,22 +190,32 @@ func getObject(cmd *cobra.Command, args []string, ltFlag layerType) error {
 	}
 
 	// execute command and print output
+	query := url.Values{}
+	if includeDeleted, _ := cmd.Flags().GetBool("include-deleted"); includeDeleted {
+		query.Set("includeDeleted", "true")
+	}
 	var objStoreUrl string
 	if objID != "" {
 		objStoreUrl = getObjectUrl(fqtn, objID)
 	} else {
-		if cmd.Flags().Changed("filter") {
-			filterCriteria, err := cmd.Flags().GetString("filter")
-			if err != nil {
-				return fmt.Errorf("error trying to get %q flag value: %w", "filter", err)
-			}
-			query := fmt.Sprintf("filter=%s", url.QueryEscape(filterCriteria))
-			fqtn = fqtn + "?" + query
+		filterCriteria, err := cmd.Flags().GetString("filter")
+		if err != nil {
+			return fmt.Errorf("error trying to get %q flag value: %w", "filter", err)
+		}
+		filterCriteria, err = selector.GetFilter(cmd, "data.", filterCriteria)
+		if err != nil {
+			return err
+		}
+		if filterCriteria != "" {
+			query.Set("filter", filterCriteria)
 		}
 		objStoreUrl = getObjectListUrl(fqtn)
 	}
+	if len(query) > 0 {
+		objStoreUrl += "?" + query.Encode()
+	}
 
-	cmdkit.FetchAndPrint(cmd, objStoreUrl, &cmdkit.FetchAndPrintOptions{Headers: headers})
+	cmdkit.FetchAndPrint(cmd, objStoreUrl, &cmdkit.FetchAndPrintOptions{Headers: headers, IsCollection: objID == ""})
 	return nil
 }
 
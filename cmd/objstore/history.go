@@ -0,0 +1,244 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package objstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+
+	"github.com/cisco-open/fsoc/cmdkit/jsondiff"
+	"github.com/cisco-open/fsoc/output"
+	"github.com/cisco-open/fsoc/platform/api"
+)
+
+// maximum length of a value displayed in the revision diff table
+const revisionValueDisplayLength = 40
+
+// objectRevision is a stored revision of a knowledge object
+type objectRevision struct {
+	Revision  int            `json:"revision" yaml:"revision"`
+	UpdatedAt string         `json:"updatedAt,omitempty" yaml:"updatedAt,omitempty"`
+	UpdatedBy string         `json:"updatedBy,omitempty" yaml:"updatedBy,omitempty"`
+	Data      map[string]any `json:"data,omitempty" yaml:"data,omitempty"`
+}
+
+func getObjectHistoryCmd() *cobra.Command {
+	historyCmd := &cobra.Command{
+		Use:   "history",
+		Short: "List the revision history of a knowledge object",
+		Long: `This command lists the stored revisions of a knowledge object, newest first, for the object types
+for which the platform keeps revisions. The "diff" and "restore" subcommands compare revisions and
+restore the object to a previous revision, e.g., to recover from an accidental update.
+
+Usage:
+  fsoc objstore history \
+    --type=<fully-qualified-typename> \
+    --object-id=<object id> \
+    --layer-type=[SOLUTION|ACCOUNT|GLOBALUSER|TENANT|LOCALUSER] \
+    [--layer-id=<respective-layer-id>]`,
+		Example: `  # List the revisions of a theme
+  fsoc knowledge history --type preferences:theme --object-id dark --layer-type TENANT
+
+  # Show what changed between revisions 3 and 5, and between revision 5 and the current object
+  fsoc knowledge history diff --type preferences:theme --object-id dark --layer-type TENANT --from 3 --to 5
+  fsoc knowledge history diff --type preferences:theme --object-id dark --layer-type TENANT --from 5
+
+  # Restore revision 3, after reviewing the changes that it would make
+  fsoc knowledge history restore --type preferences:theme --object-id dark --layer-type TENANT --revision 3 --dry-run
+  fsoc knowledge history restore --type preferences:theme --object-id dark --layer-type TENANT --revision 3`,
+		Args:             cobra.ExactArgs(0),
+		Run:              listObjectHistory,
+		TraverseChildren: true,
+	}
+
+	historyCmd.PersistentFlags().
+		String("type", "", "The fully qualified type name of the object")
+	_ = historyCmd.MarkPersistentFlagRequired("type")
+
+	historyCmd.PersistentFlags().
+		String("object-id", "", "The id of the knowledge object")
+	_ = historyCmd.MarkPersistentFlagRequired("object-id")
+
+	historyCmd.PersistentFlags().
+		String("layer-type", "", "The layer-type of the object")
+	_ = historyCmd.MarkPersistentFlagRequired("layer-type")
+
+	historyCmd.PersistentFlags().
+		String("layer-id", "", "The layer-id of the object. Optional for TENANT and SOLUTION layers")
+
+	diffCmd := &cobra.Command{
+		Use:              "diff",
+		Short:            "Show the differences between two revisions of a knowledge object",
+		Long:             `This command shows the values that differ between two revisions of a knowledge object. Without --to, the revision is compared with the current object.`,
+		Args:             cobra.ExactArgs(0),
+		Run:              diffObjectRevisions,
+		TraverseChildren: true,
+	}
+	diffCmd.Flags().Int("from", 0, "The revision to compare")
+	_ = diffCmd.MarkFlagRequired("from")
+	diffCmd.Flags().Int("to", 0, "The revision to compare with (default is the current object)")
+	historyCmd.AddCommand(diffCmd)
+
+	restoreCmd := &cobra.Command{
+		Use:   "restore",
+		Short: "Restore a knowledge object to a previous revision",
+		Long: `This command replaces the data of a knowledge object with the data of a previous revision, creating a
+new revision. The changes that are applied are displayed; with --dry-run, the object is not modified.`,
+		Args:             cobra.ExactArgs(0),
+		Run:              restoreObjectRevision,
+		TraverseChildren: true,
+	}
+	restoreCmd.Flags().Int("revision", 0, "The revision to restore")
+	_ = restoreCmd.MarkFlagRequired("revision")
+	restoreCmd.Flags().Bool("dry-run", false, "Display the changes without modifying the object")
+	historyCmd.AddCommand(restoreCmd)
+
+	return historyCmd
+}
+
+func listObjectHistory(cmd *cobra.Command, args []string) {
+	objType, objId, headers := getObjectFlags(cmd)
+
+	var res any
+	options := &api.Options{Headers: headers}
+	err := api.JSONGetCollection(getObjectRevisionsUrl(objType, objId), &res, options)
+	if err != nil {
+		log.Fatalf("Failed to get the revisions of object %q: %v", objId, revisionsError(err, options))
+	}
+	var revisions struct {
+		Items []objectRevision `json:"items"`
+	}
+	if err := convertValue(res, &revisions); err != nil {
+		log.Fatalf("Failed to parse the revisions of object %q: %v", objId, err)
+	}
+	sort.Slice(revisions.Items, func(i, j int) bool { return revisions.Items[i].Revision > revisions.Items[j].Revision })
+
+	lines := make([][]string, len(revisions.Items))
+	for i, r := range revisions.Items {
+		lines[i] = []string{strconv.Itoa(r.Revision), r.UpdatedAt, r.UpdatedBy}
+	}
+	output.PrintCmdOutputCustom(cmd, struct {
+		Items []objectRevision `json:"items"`
+		Total int              `json:"total"`
+	}{Items: revisions.Items, Total: len(revisions.Items)}, &output.Table{
+		Headers: []string{"Revision", "Updated At", "Updated By"},
+		Lines:   lines,
+	})
+}
+
+func diffObjectRevisions(cmd *cobra.Command, args []string) {
+	objType, objId, headers := getObjectFlags(cmd)
+	from, _ := cmd.Flags().GetInt("from")
+	to, _ := cmd.Flags().GetInt("to")
+
+	fromData := getObjectRevisionData(objType, objId, from, headers)
+	var toData map[string]any
+	toTitle := "Current"
+	if cmd.Flags().Changed("to") {
+		toData = getObjectRevisionData(objType, objId, to, headers)
+		toTitle = fmt.Sprintf("Revision %d", to)
+	} else {
+		toData = getCurrentObjectData(objType, objId, headers)
+	}
+
+	printRevisionDiff(cmd, jsondiff.Diff(fromData, toData), fmt.Sprintf("Revision %d", from), toTitle)
+}
+
+func restoreObjectRevision(cmd *cobra.Command, args []string) {
+	objType, objId, headers := getObjectFlags(cmd)
+	revision, _ := cmd.Flags().GetInt("revision")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+	revisionData := getObjectRevisionData(objType, objId, revision, headers)
+	changes := jsondiff.Diff(getCurrentObjectData(objType, objId, headers), revisionData)
+	if len(changes) == 0 {
+		output.PrintCmdStatus(cmd, fmt.Sprintf("Object %q already matches revision %d, nothing to restore.\n", objId, revision))
+		return
+	}
+	printRevisionDiff(cmd, changes, "Current", fmt.Sprintf("Revision %d", revision))
+	if dryRun {
+		output.PrintCmdStatus(cmd, "Dry run, the object was not modified.\n")
+		return
+	}
+
+	var res any
+	output.PrintCmdStatus(cmd, fmt.Sprintf("Restoring object %q to revision %d\n", objId, revision))
+	err := api.JSONPut(fmt.Sprintf(getObjStoreObjectUrl()+"/%s/%s", objType, objId), revisionData, &res, &api.Options{Headers: headers})
+	if err != nil {
+		log.Fatalf("Object restore failed: %v", err)
+	}
+	output.PrintCmdStatus(cmd, "Object restored successfully.\n")
+}
+
+func getObjectRevisionData(objType string, objId string, revision int, headers map[string]string) map[string]any {
+	var res objectRevision
+	options := &api.Options{Headers: headers}
+	err := api.JSONGet(fmt.Sprintf("%s/%d", getObjectRevisionsUrl(objType, objId), revision), &res, options)
+	if err != nil {
+		log.Fatalf("Failed to get revision %d of object %q: %v", revision, objId, revisionsError(err, options))
+	}
+	return res.Data
+}
+
+func getCurrentObjectData(objType string, objId string, headers map[string]string) map[string]any {
+	var res struct {
+		Data map[string]any `json:"data"`
+	}
+	err := api.JSONGet(fmt.Sprintf(getObjStoreObjectUrl()+"/%s/%s", objType, objId), &res, &api.Options{Headers: headers})
+	if err != nil {
+		log.Fatalf("Failed to get object %q: %v", objId, err)
+	}
+	return res.Data
+}
+
+func printRevisionDiff(cmd *cobra.Command, changes []jsondiff.Change, fromTitle string, toTitle string) {
+	lines := make([][]string, len(changes))
+	for i, c := range changes {
+		lines[i] = []string{c.Path, c.Change, jsondiff.DisplayValue(c.Old, revisionValueDisplayLength), jsondiff.DisplayValue(c.New, revisionValueDisplayLength)}
+	}
+	output.PrintCmdOutputCustom(cmd, struct {
+		Items []jsondiff.Change `json:"items"`
+		Total int               `json:"total"`
+	}{Items: changes, Total: len(changes)}, &output.Table{
+		Headers: []string{"Path", "Change", fromTitle, toTitle},
+		Lines:   lines,
+	})
+}
+
+// revisionsError explains a "not found" error, which is returned for types without revision history
+func revisionsError(err error, options *api.Options) error {
+	if api.IsNotFound(err, options) {
+		return fmt.Errorf("%w (the object or revision does not exist, or the platform does not keep revisions for objects of this type)", err)
+	}
+	return err
+}
+
+// convertValue converts a generic JSON value into a typed one
+func convertValue(in any, out any) error {
+	b, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, out)
+}
+
+func getObjectRevisionsUrl(fqtn string, objId string) string {
+	return fmt.Sprintf("%s/%s/%s/revisions", getObjStoreObjectUrl(), fqtn, objId)
+}
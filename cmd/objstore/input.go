@@ -0,0 +1,115 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package objstore
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/cisco-open/fsoc/cmdkit/jsonpatch"
+)
+
+// readObjectData reads the data of an object from a JSON or YAML file or, if the path is "-",
+// from the standard input
+func readObjectData(cmd *cobra.Command, path string) (map[string]any, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(cmd.InOrStdin())
+		path = "standard input"
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the object data from %s: %w", path, err)
+	}
+	return parseObjectData(data, path)
+}
+
+// parseObjectData parses object data in JSON or YAML format; source describes where the data comes from
+func parseObjectData(data []byte, source string) (map[string]any, error) {
+	var object map[string]any
+	var err error
+	if trimmed := bytes.TrimSpace(data); bytes.HasPrefix(trimmed, []byte("{")) {
+		err = json.Unmarshal(trimmed, &object)
+	} else {
+		err = yaml.Unmarshal(data, &object)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the object data from %s as JSON or YAML: %w", source, err)
+	}
+	if object == nil {
+		return nil, fmt.Errorf("no object data found in %s", source)
+	}
+	return object, nil
+}
+
+// readPatchOperations reads a JSON Patch, a list of operations in JSON or YAML format, from the file
+// or, if path is "-", from the standard input
+func readPatchOperations(cmd *cobra.Command, path string) ([]jsonpatch.Operation, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(cmd.InOrStdin())
+		path = "standard input"
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the patch from %s: %w", path, err)
+	}
+	var ops []jsonpatch.Operation
+	if trimmed := bytes.TrimSpace(data); bytes.HasPrefix(trimmed, []byte("[")) {
+		err = json.Unmarshal(trimmed, &ops)
+	} else {
+		err = yaml.Unmarshal(data, &ops)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the patch from %s as a list of JSON Patch operations: %w", path, err)
+	}
+	if len(ops) == 0 {
+		return nil, fmt.Errorf("no patch operations found in %s", path)
+	}
+	return ops, nil
+}
+
+// addObjectFileFlag adds the flag that specifies the file with the object data
+func addObjectFileFlag(cmd *cobra.Command, usage string) {
+	cmd.Flags().
+		StringP("object-file", "f", "", usage+` (JSON or YAML; "-" reads from the standard input)`)
+	_ = cmd.MarkFlagRequired("object-file")
+}
+
+// getLayerHeaders returns the headers specifying the layer of an object of the type, using the
+// --layer-id flag if specified or the layer ID determined from the context otherwise
+func getLayerHeaders(cmd *cobra.Command, layerType string, objType string) (map[string]string, error) {
+	layerID, _ := cmd.Flags().GetString("layer-id")
+	if layerID == "" {
+		layerID = getCorrectLayerID(layerType, objType)
+		if layerID == "" {
+			return nil, fmt.Errorf("Unable to set layer-id flag from given context. Please specify a unique layer-id value with the --layer-id flag")
+		}
+	}
+	return map[string]string{
+		"layer-type": layerType,
+		"layer-id":   layerID,
+	}, nil
+}
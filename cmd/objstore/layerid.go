@@ -17,6 +17,9 @@ package objstore
 import (
 	"strings"
 
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+
 	"github.com/cisco-open/fsoc/cmd/config"
 )
 
@@ -36,3 +39,16 @@ func getCorrectLayerID(layerType string, fqtn string) string {
 
 	return layerID
 }
+
+// getObjectFlags returns the type and the id of the object and the headers specifying its layer
+func getObjectFlags(cmd *cobra.Command) (string, string, map[string]string) {
+	objType, _ := cmd.Flags().GetString("type")
+	objId, _ := cmd.Flags().GetString("object-id")
+	layerType, _ := cmd.Flags().GetString("layer-type")
+
+	headers, err := getLayerHeaders(cmd, layerType, objType)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	return objType, objId, headers
+}
@@ -23,7 +23,7 @@ func NewSubCmd() *cobra.Command {
 	objStoreCmd := &cobra.Command{
 		Use:     "objstore",
 		Short:   "Perform objectstore interactions.",
-		Aliases: []string{"obj", "objs"},
+		Aliases: []string{"obj", "objs", "knowledge"},
 		Long: `
 ---------------------------------------------------------------
         ___.         __           __                           
@@ -35,21 +35,42 @@ func NewSubCmd() *cobra.Command {
 ----------------------------------------------------------------
 Perform objectstore interactions.
 See <docs url>`,
-		Example: `# Get object type
-  fsoc objstore get-type --type=<typeName> --solution=<solutionName>"
+		Example: `# List object types
+  fsoc knowledge types
+# Describe object type
+  fsoc knowledge describe-type preferences:theme
+# Get object type
+  fsoc knowledge get-type --type preferences:theme
 # Get object
-  fsoc obj get --type=<typeName> --object=<objectId> --layer-id=<layerId> --layer-type=SOLUTION|ACCOUNT|GLOBALUSER|TENANT|LOCALUSER
-# Get object
-  fsoc obj create --type=<fully-qualified-typename> --object-file=<fully-qualified-path> --layer-type=SOLUTION|ACCOUNT|GLOBALUSER|TENANT|LOCALUSER [--layer-id=<respective-layer-id>] `,
+  fsoc knowledge get --type preferences:theme --object dark --layer-type TENANT
+# List objects
+  fsoc knowledge list --type preferences:theme --layer-type TENANT --filter "data.backgroundColor eq \"green\""
+# Create object
+  fsoc knowledge create --type preferences:theme --object-file theme.json --layer-type TENANT
+# Export objects into a directory and import them into another tenant
+  fsoc knowledge export --type preferences:theme --layer-type TENANT --dir ./themes
+  fsoc knowledge import --dir ./themes --profile other
+# List object revisions
+  fsoc knowledge history --type preferences:theme --object-id dark --layer-type TENANT`,
 		TraverseChildren: true,
 	}
 
 	objStoreCmd.AddCommand(newGetObjectCmd())
+	objStoreCmd.AddCommand(newListObjectsCmd())
 	objStoreCmd.AddCommand(newGetTypeCmd())
+	objStoreCmd.AddCommand(newListTypesCmd())
+	objStoreCmd.AddCommand(newDescribeTypeCmd())
 	objStoreCmd.AddCommand(getCreateObjectCmd())
 	objStoreCmd.AddCommand(getUpdateObjectCmd())
 	objStoreCmd.AddCommand(getDeleteObjectCmd())
 	objStoreCmd.AddCommand(getCreatePatchObjectCmd())
+	objStoreCmd.AddCommand(getPatchObjectCmd())
+	objStoreCmd.AddCommand(getObjectHistoryCmd())
+	objStoreCmd.AddCommand(getRestoreObjectCmd())
+	objStoreCmd.AddCommand(getPurgeObjectCmd())
+	objStoreCmd.AddCommand(getExportObjectsCmd())
+	objStoreCmd.AddCommand(getImportObjectsCmd())
+	objStoreCmd.AddCommand(getCopyObjectsCmd())
 
 	return objStoreCmd
 }
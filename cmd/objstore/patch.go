@@ -0,0 +1,176 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package objstore
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/cisco-open/fsoc/cmdkit/jsonpatch"
+	"github.com/cisco-open/fsoc/exitcode"
+	"github.com/cisco-open/fsoc/output"
+	"github.com/cisco-open/fsoc/platform/api"
+)
+
+// patch types
+const (
+	mergePatch = "merge-patch" // RFC 7386
+	jsonPatch  = "json-patch"  // RFC 6902
+)
+
+const defaultPatchRetries = 3
+
+var objStorePatchCmd = &cobra.Command{
+	Use:   "patch",
+	Short: "Update some of the fields of an existent knowledge object",
+	Long: `This command updates only some of the fields of an existent knowledge object, applying a patch provided in
+a JSON or YAML file (or, with --patch-file=-, in the standard input). Two kinds of patches are supported:
+
+- merge-patch (default): a JSON Merge Patch (RFC 7386), which contains the fields to update, leaving the
+  other fields unchanged; fields set to null are removed.
+- json-patch: a JSON Patch (RFC 6902), which is a list of operations (add, remove, replace, move, copy and
+  test) on the values at JSON pointers, e.g., [{"op": "replace", "path": "/colors/0", "value": "green"}].
+  The patch is applied to the current object, which is then replaced. A failed test operation stops the
+  update, e.g., to update a field only if it has the expected value.
+
+To avoid overwriting concurrent changes, the object is updated only if it hasn't changed since it was
+read (If-Match on the object's revision). If it has, the object is read again and the patch re-applied,
+up to --retries times. Use "update" to replace all the fields of an object.
+
+The --id and --patch-file flags are aliases of --object-id and --object-file, respectively.`,
+	Example: `  echo '{"backgroundColor": "green"}' | fsoc knowledge patch --type=preferences:theme --object-id=dark --layer-type=TENANT -f -
+  fsoc knowledge patch --type preferences:theme --id dark --layer-type TENANT --patch-file ops.json --patch-type json-patch`,
+	Args:             cobra.ExactArgs(0),
+	Run:              patchObject,
+	TraverseChildren: true,
+}
+
+func getPatchObjectCmd() *cobra.Command {
+	addObjectFlags(objStorePatchCmd)
+	addObjectFileFlag(objStorePatchCmd, "The path to the file containing the patch")
+	objStorePatchCmd.Flags().String("patch-type", mergePatch, fmt.Sprintf("The type of the patch: %s or %s", mergePatch, jsonPatch))
+	objStorePatchCmd.Flags().Int("retries", defaultPatchRetries, "Number of times to re-apply the patch if the object was changed concurrently")
+	objStorePatchCmd.Flags().SetNormalizeFunc(func(f *pflag.FlagSet, name string) pflag.NormalizedName {
+		switch name {
+		case "id":
+			name = "object-id"
+		case "patch-file":
+			name = "object-file"
+		}
+		return pflag.NormalizedName(name)
+	})
+	return objStorePatchCmd
+}
+
+func patchObject(cmd *cobra.Command, args []string) {
+	objType, objId, headers := getObjectFlags(cmd)
+	patchType, _ := cmd.Flags().GetString("patch-type")
+	retries, _ := cmd.Flags().GetInt("retries")
+	if retries < 0 {
+		log.WithField(exitcode.Field, exitcode.Usage).Fatal("The --retries flag cannot be negative")
+	}
+
+	patchFilePath, _ := cmd.Flags().GetString("object-file")
+	var apply func(current map[string]any) (any, error)
+	switch patchType {
+	case mergePatch:
+		patch, err := readObjectData(cmd, patchFilePath)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		apply = func(map[string]any) (any, error) { return patch, nil } // merged by the platform
+	case jsonPatch:
+		ops, err := readPatchOperations(cmd, patchFilePath)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		apply = func(current map[string]any) (any, error) { return jsonpatch.Apply(current, ops) }
+	default:
+		log.WithField(exitcode.Field, exitcode.Usage).Fatalf("Invalid patch type %q: expected %s or %s", patchType, mergePatch, jsonPatch)
+	}
+
+	output.PrintCmdStatus(cmd, fmt.Sprintf("Updating object %q with the %s from %q\n", objId, patchType, patchFilePath))
+	objectUrl := fmt.Sprintf(getObjStoreObjectUrl()+"/%s/%s", objType, objId)
+	for attempt := 1; ; attempt++ {
+		current, revision, err := getObjectForUpdate(objectUrl, headers)
+		if err != nil {
+			log.Fatalf("Failed to get object %q: %v", objId, err)
+		}
+		body, err := apply(current)
+		if err != nil {
+			log.Fatalf("Failed to apply the patch: %v", err)
+		}
+
+		options := &api.Options{Headers: copyHeaders(headers)}
+		if revision != "" {
+			options.Headers["If-Match"] = revision
+		} else {
+			log.Warn("The platform didn't provide the object's revision; concurrent changes may be overwritten")
+		}
+		var res any
+		if patchType == mergePatch {
+			err = api.JSONPatch(objectUrl, body, &res, options)
+		} else {
+			err = api.JSONPut(objectUrl, body, &res, options)
+		}
+		if err == nil {
+			break
+		}
+		if !isConflict(options.ResponseStatus) || attempt > retries {
+			log.Fatalf("Object patch failed: %v", err)
+		}
+		log.Warnf("Object %q was changed concurrently, applying the patch again (%d of %d)", objId, attempt, retries)
+	}
+	output.PrintCmdStatus(cmd, "Object updated successfully.\n")
+}
+
+// getObjectForUpdate returns the data of the object at the URL and its revision, for the If-Match header:
+// the ETag of the response or, if none, the object's revision number as a quoted entity tag (e.g., "3");
+// the revision is empty if neither is provided
+func getObjectForUpdate(objectUrl string, headers map[string]string) (map[string]any, string, error) {
+	var res struct {
+		Data     map[string]any `json:"data"`
+		Revision *int           `json:"revision"`
+	}
+	options := &api.Options{Headers: headers}
+	if err := api.JSONGet(objectUrl, &res, options); err != nil {
+		return nil, "", err
+	}
+	if etag := http.Header(options.ResponseHeaders).Get("ETag"); etag != "" {
+		return res.Data, etag, nil
+	}
+	if res.Revision != nil {
+		return res.Data, strconv.Quote(strconv.Itoa(*res.Revision)), nil
+	}
+	return res.Data, "", nil
+}
+
+// isConflict returns true for the response statuses of an update rejected because the object changed
+func isConflict(status int) bool {
+	return status == http.StatusPreconditionFailed || status == http.StatusConflict
+}
+
+func copyHeaders(headers map[string]string) map[string]string {
+	result := make(map[string]string, len(headers)+1)
+	for name, value := range headers {
+		result[name] = value
+	}
+	return result
+}
@@ -0,0 +1,138 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package objstore
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+
+	"github.com/cisco-open/fsoc/output"
+	"github.com/cisco-open/fsoc/platform/api"
+)
+
+var objStoreRestoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "Restore a deleted knowledge object",
+	Long: `This command restores a knowledge object that was deleted, for the object types for which the platform
+keeps deleted objects (soft deletion). Deleted objects can be listed with "fsoc objstore get --include-deleted".
+
+Usage:
+  fsoc objstore restore \
+    --type=<fully-qualified-typename> \
+    --object-id=<object id> \
+    --layer-type=[SOLUTION|ACCOUNT|GLOBALUSER|TENANT|LOCALUSER] \
+    [--layer-id=<respective-layer-id>]
+`,
+	Args:             cobra.ExactArgs(0),
+	Run:              restoreObject,
+	TraverseChildren: true,
+}
+
+var objStorePurgeCmd = &cobra.Command{
+	Use:   "purge",
+	Short: "Permanently delete a knowledge object",
+	Long: `This command permanently deletes a knowledge object, whether it is active or was already deleted, so
+that it can no longer be restored. The command asks for confirmation unless the --yes flag is specified;
+the flag is required when the input is not a terminal.
+
+Usage:
+  fsoc objstore purge \
+    --type=<fully-qualified-typename> \
+    --object-id=<object id> \
+    --layer-type=[SOLUTION|ACCOUNT|GLOBALUSER|TENANT|LOCALUSER] \
+    [--layer-id=<respective-layer-id>] \
+    [--yes]
+`,
+	Args:             cobra.ExactArgs(0),
+	Run:              purgeObject,
+	TraverseChildren: true,
+}
+
+func getRestoreObjectCmd() *cobra.Command {
+	addObjectFlags(objStoreRestoreCmd)
+	return objStoreRestoreCmd
+}
+
+func getPurgeObjectCmd() *cobra.Command {
+	addObjectFlags(objStorePurgeCmd)
+	objStorePurgeCmd.Flags().BoolP("yes", "y", false, "Purge without asking for confirmation")
+	return objStorePurgeCmd
+}
+
+// addObjectFlags adds the flags identifying a single object (see getObjectFlags)
+func addObjectFlags(cmd *cobra.Command) {
+	cmd.Flags().
+		String("type", "", "The fully qualified type name of the object")
+	_ = cmd.MarkFlagRequired("type")
+
+	cmd.Flags().
+		String("object-id", "", "The id of the knowledge object")
+	_ = cmd.MarkFlagRequired("object-id")
+
+	cmd.Flags().
+		String("layer-type", "", "The layer-type of the object")
+	_ = cmd.MarkFlagRequired("layer-type")
+
+	cmd.Flags().
+		String("layer-id", "", "The layer-id of the object. Optional for TENANT and SOLUTION layers")
+}
+
+func restoreObject(cmd *cobra.Command, args []string) {
+	objType, objId, headers := getObjectFlags(cmd)
+
+	var res any
+	output.PrintCmdStatus(cmd, fmt.Sprintf("Restoring deleted object %q of type %q\n", objId, objType))
+	err := api.JSONPost(fmt.Sprintf(getObjStoreObjectUrl()+"/%s/%s/restore", objType, objId), nil, &res, &api.Options{Headers: headers})
+	if err != nil {
+		log.Fatalf("Failed to restore object: %v", err)
+	}
+	output.PrintCmdStatus(cmd, "Object was successfully restored.\n")
+}
+
+func purgeObject(cmd *cobra.Command, args []string) {
+	objType, objId, headers := getObjectFlags(cmd)
+
+	if yes, _ := cmd.Flags().GetBool("yes"); !yes {
+		if !confirm(cmd, fmt.Sprintf("Permanently delete object %q of type %q? It cannot be restored afterwards.", objId, objType)) {
+			output.PrintCmdStatus(cmd, "Purge canceled.\n")
+			return
+		}
+	}
+
+	var res any
+	output.PrintCmdStatus(cmd, fmt.Sprintf("Purging object %q of type %q\n", objId, objType))
+	err := api.JSONDelete(fmt.Sprintf(getObjStoreObjectUrl()+"/%s/%s?purge=true", objType, objId), &res, &api.Options{Headers: headers})
+	if err != nil {
+		log.Fatalf("Failed to purge object: %v", err)
+	}
+	output.PrintCmdStatus(cmd, "Object was successfully purged.\n")
+}
+
+// confirm asks the user a yes/no question, returning true only if the answer is yes. It fails if
+// the input is not a terminal, since there is no one to answer.
+func confirm(cmd *cobra.Command, question string) bool {
+	if fi, err := os.Stdin.Stat(); err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+		log.Fatal("Confirmation is required but the input is not a terminal; use the --yes flag to confirm")
+	}
+	output.PrintCmdStatus(cmd, question+" [y/N] ")
+	answer, _ := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
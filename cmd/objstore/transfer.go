@@ -0,0 +1,617 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package objstore
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+	"github.com/xeipuuv/gojsonschema"
+
+	"github.com/cisco-open/fsoc/cmd/config"
+	"github.com/cisco-open/fsoc/cmdkit/encryption"
+	"github.com/cisco-open/fsoc/cmdkit/interrupt"
+	"github.com/cisco-open/fsoc/cmdkit/onerror"
+	"github.com/cisco-open/fsoc/cmdkit/progress"
+	"github.com/cisco-open/fsoc/cmdkit/selector"
+	"github.com/cisco-open/fsoc/output"
+	"github.com/cisco-open/fsoc/platform/api"
+)
+
+const (
+	exportManifestFileName = "manifest.json"
+	importStateFileName    = "import-state.json"
+	exportObjectsDirName   = "objects"
+	exportKeyFileName      = "key.age"
+	encryptedFileExt       = ".age"
+)
+
+// exportManifest describes the objects exported into a directory
+type exportManifest struct {
+	Type       string           `json:"type"`
+	LayerType  string           `json:"layerType"`
+	LayerID    string           `json:"layerId,omitempty"`
+	Profile    string           `json:"profile,omitempty"`
+	ExportedAt string           `json:"exportedAt"`
+	Encrypted  bool             `json:"encrypted,omitempty"` // object files are encrypted in the age format
+	KeyFile    string           `json:"keyFile,omitempty"`   // passphrase-encrypted age secret key for the object files
+	Objects    []exportedObject `json:"objects"`
+}
+
+// exportedObject is an object in the export directory, with the file relative to the directory
+type exportedObject struct {
+	ID   string `json:"id"`
+	File string `json:"file"`
+}
+
+// importState records the progress of importing an export directory, allowing an interrupted
+// import to be resumed. It is valid only for the same target environment and layer.
+type importState struct {
+	Profile   string            `json:"profile"`
+	LayerType string            `json:"layerType"`
+	LayerID   string            `json:"layerId"`
+	Imported  map[string]string `json:"imported"` // object ID -> action (created or updated)
+}
+
+// importResult is the outcome of importing a single object
+type importResult struct {
+	ID     string `json:"id"`
+	File   string `json:"file"`
+	Action string `json:"action"`
+	Error  string `json:"error,omitempty"`
+
+	aborted bool // the import is to be aborted after this object
+}
+
+func getExportObjectsCmd() *cobra.Command {
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export the knowledge objects of a type into a directory",
+		Long: `This command saves the knowledge objects of a given type and layer into a directory, one JSON file per object,
+together with a manifest listing the exported objects. The directory can be imported into the same or another
+tenant with "fsoc knowledge import", e.g., for backup or for migration between tenants.
+
+The objects can be selected with a SCIM filter (--filter) and/or a label selector (-l) on the object data.
+
+Since the objects often contain sensitive configuration, the object files can be encrypted in the age format
+(https://age-encryption.org), either to one or more age public keys (--recipient, --recipients-file) or with
+a passphrase (--passphrase). The manifest is not encrypted; it lists only the object IDs. Encrypted exports are
+decrypted by "fsoc knowledge import" or with the age tool. With a passphrase, the objects are encrypted to a new
+age key that is saved in the directory encrypted with the passphrase, so that the slow passphrase-based key
+derivation is done once rather than for each object.`,
+		Example: `  # Back up all themes of the tenant
+  fsoc knowledge export --type preferences:theme --layer-type TENANT --dir ./backup/themes
+
+  # Export only the green themes
+  fsoc knowledge export --type preferences:theme --layer-type TENANT --dir ./green -l backgroundColor=green
+
+  # Back up the themes encrypted to the age public key of the operations team
+  fsoc knowledge export --type preferences:theme --layer-type TENANT --dir ./backup/themes --recipient age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p
+
+  # Back up the themes encrypted with a passphrase taken from the environment
+  FSOC_PASSPHRASE=... fsoc knowledge export --type preferences:theme --layer-type TENANT --dir ./backup/themes --passphrase`,
+		Args:             cobra.ExactArgs(0),
+		Run:              exportObjects,
+		TraverseChildren: true,
+	}
+
+	exportCmd.Flags().String("type", "", "The fully qualified type name of the objects")
+	_ = exportCmd.MarkFlagRequired("type")
+	exportCmd.Flags().String("layer-type", "", "The layer-type of the objects")
+	_ = exportCmd.MarkFlagRequired("layer-type")
+	exportCmd.Flags().String("layer-id", "", "The layer-id of the objects. Optional for TENANT and SOLUTION layers")
+	exportCmd.Flags().String("dir", "", "The directory to export the objects into (created if it does not exist)")
+	_ = exportCmd.MarkFlagRequired("dir")
+	exportCmd.Flags().String("filter", "", "Filter condition in SCIM filter format for selecting the objects")
+	selector.AddFlag(exportCmd)
+	encryption.AddEncryptFlags(exportCmd)
+
+	return exportCmd
+}
+
+func getImportObjectsCmd() *cobra.Command {
+	importCmd := &cobra.Command{
+		Use:   "import",
+		Short: "Import knowledge objects from a directory created by export",
+		Long: `This command creates the knowledge objects saved in a directory by "fsoc knowledge export". Objects that
+already exist are updated with the exported data.
+
+The objects are imported in batches (--batch-size), each batch using several concurrent requests (--concurrency).
+The progress is saved in the directory after each batch, so that an interrupted import can be resumed by running
+the same command again: objects already imported into the same tenant and layer are skipped, unless --restart
+is specified. With --dry-run, the objects that would be imported are displayed without modifying the tenant.
+
+The data of each object is validated against the schema of its type before it is sent; objects with invalid
+data are reported and not imported. Use --no-validate to skip the validation.
+
+When importing an object fails, the user is asked whether to retry it, skip it or abort the import; without a
+terminal, the failed objects are skipped, unless --on-error specifies otherwise.
+
+The objects are imported into the layer they were exported from, unless --layer-type and/or --layer-id are specified.
+
+Encrypted exports are decrypted with the age secret keys in the --identity files or, without them, with the
+passphrase used for the export, which is prompted for or taken from the FSOC_PASSPHRASE environment variable.`,
+		Example: `  # Preview, then restore a backup of themes
+  fsoc knowledge import --dir ./backup/themes --dry-run
+  fsoc knowledge import --dir ./backup/themes
+
+  # Migrate objects into another tenant, with more concurrent requests
+  fsoc knowledge import --dir ./backup/themes --profile other-tenant --concurrency 8
+
+  # Restore a backup encrypted to an age public key
+  fsoc knowledge import --dir ./backup/themes --identity ~/.config/age/ops.txt`,
+		Args:             cobra.ExactArgs(0),
+		Run:              importObjects,
+		TraverseChildren: true,
+	}
+
+	importCmd.Flags().String("dir", "", "The directory with the exported objects")
+	_ = importCmd.MarkFlagRequired("dir")
+	importCmd.Flags().String("layer-type", "", "The layer-type to import the objects into (default is the exported layer-type)")
+	importCmd.Flags().String("layer-id", "", "The layer-id to import the objects into. Optional for TENANT and SOLUTION layers")
+	importCmd.Flags().Int("batch-size", 50, "The number of objects imported between saves of the import progress")
+	importCmd.Flags().Int("concurrency", 4, "The number of objects imported concurrently")
+	importCmd.Flags().Bool("dry-run", false, "Display the objects that would be imported without importing them")
+	importCmd.Flags().Bool("restart", false, "Import all objects, ignoring the progress of a previous import")
+	addValidationFlag(importCmd)
+	onerror.AddFlag(importCmd, onerror.Skip)
+	encryption.AddDecryptFlags(importCmd)
+
+	return importCmd
+}
+
+func exportObjects(cmd *cobra.Command, args []string) {
+	objType, _ := cmd.Flags().GetString("type")
+	layerType, _ := cmd.Flags().GetString("layer-type")
+	dir, _ := cmd.Flags().GetString("dir")
+	filter, _ := cmd.Flags().GetString("filter")
+
+	headers, err := getLayerHeaders(cmd, layerType, objType)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	recipients, err := encryption.Recipients(cmd)
+	if err != nil {
+		log.Fatalf("Failed to set up the encryption: %v", err)
+	}
+	var keyFile string
+	if len(recipients) == 1 {
+		if _, ok := recipients[0].(*encryption.ScryptRecipient); ok {
+			keyFile = exportKeyFileName
+		}
+	}
+	filter, err = selector.GetFilter(cmd, "data.", filter)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	objUrl := getObjectListUrl(objType)
+	if filter != "" {
+		objUrl += "?" + url.Values{"filter": []string{filter}}.Encode()
+	}
+
+	spinner := progress.Start(fmt.Sprintf("Export of the %q objects", objType))
+	defer spinner.Hide()
+	var res any
+	if err := api.JSONGetCollection(objUrl, &res, &api.Options{Headers: headers}); err != nil {
+		log.Fatalf("Failed to get the %q objects: %v", objType, err)
+	}
+	var page struct {
+		Items []map[string]any `json:"items"`
+	}
+	if err := convertValue(res, &page); err != nil {
+		log.Fatalf("Failed to parse the %q objects: %v", objType, err)
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, exportObjectsDirName), 0755); err != nil {
+		log.Fatalf("Failed to create the export directory: %v", err)
+	}
+	if keyFile != "" {
+		if recipients, err = writeExportKey(filepath.Join(dir, keyFile), recipients); err != nil {
+			log.Fatalf("Failed to save the export key: %v", err)
+		}
+	}
+	manifest := exportManifest{
+		Type:       objType,
+		LayerType:  layerType,
+		LayerID:    headers["layer-id"],
+		Profile:    config.GetCurrentProfileName(),
+		ExportedAt: time.Now().UTC().Format(time.RFC3339),
+		Encrypted:  recipients != nil,
+		KeyFile:    keyFile,
+		Objects:    []exportedObject{},
+	}
+	usedNames := map[string]bool{}
+	lines := [][]string{}
+	for i, object := range page.Items {
+		spinner.Update(fmt.Sprintf("saving %d of %d", i+1, len(page.Items)))
+		id, _ := object["id"].(string)
+		if id == "" {
+			log.Warnf("Skipping a %q object without an id", objType)
+			continue
+		}
+		file := filepath.ToSlash(filepath.Join(exportObjectsDirName, exportFileName(id, usedNames)))
+		if recipients != nil {
+			file += encryptedFileExt
+			err = writeEncryptedJSONFile(filepath.Join(dir, file), object, recipients)
+		} else {
+			err = writeJSONFile(filepath.Join(dir, file), object)
+		}
+		if err != nil {
+			log.Fatalf("Failed to save object %q: %v", id, err)
+		}
+		manifest.Objects = append(manifest.Objects, exportedObject{ID: id, File: file})
+		lines = append(lines, []string{id, file})
+	}
+	if err := writeJSONFile(filepath.Join(dir, exportManifestFileName), manifest); err != nil {
+		log.Fatalf("Failed to save the export manifest: %v", err)
+	}
+	spinner.Update(fmt.Sprintf("%d saved", len(manifest.Objects)))
+	spinner.Stop(true)
+
+	output.PrintCmdOutputCustom(cmd, manifest, &output.Table{
+		Headers: []string{"ID", "File"},
+		Lines:   lines,
+	})
+	output.PrintCmdStatus(cmd, fmt.Sprintf("Exported %d %q object(s) into %q.\n", len(manifest.Objects), objType, dir))
+}
+
+func importObjects(cmd *cobra.Command, args []string) {
+	dir, _ := cmd.Flags().GetString("dir")
+	batchSize, _ := cmd.Flags().GetInt("batch-size")
+	concurrency, _ := cmd.Flags().GetInt("concurrency")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	restart, _ := cmd.Flags().GetBool("restart")
+	if batchSize < 1 || concurrency < 1 {
+		log.Fatal("The --batch-size and --concurrency values must be positive")
+	}
+	errs, err := onerror.New(cmd)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	var manifest exportManifest
+	if err := readJSONFile(filepath.Join(dir, exportManifestFileName), &manifest); err != nil {
+		log.Fatalf("Failed to read the export manifest: %v. Make sure %q was created by \"fsoc knowledge export\"", err, dir)
+	}
+	layerType, _ := cmd.Flags().GetString("layer-type")
+	if layerType == "" {
+		layerType = manifest.LayerType
+	}
+	headers, err := getLayerHeaders(cmd, layerType, manifest.Type)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	var identities []encryption.Identity
+	if manifest.Encrypted {
+		if identities, err = encryption.Identities(cmd); err != nil {
+			log.Fatalf("Failed to set up the decryption of the exported objects: %v", err)
+		}
+		if paths, _ := cmd.Flags().GetStringArray("identity"); manifest.KeyFile != "" && len(paths) == 0 {
+			if identities, err = readExportKey(filepath.Join(dir, manifest.KeyFile), identities); err != nil {
+				log.Fatalf("Failed to read the export key: %v", err)
+			}
+		}
+		// fail early if the objects cannot be decrypted, rather than reporting each object
+		if len(manifest.Objects) > 0 {
+			if _, err := readImportData(cmd, dir, manifest.Objects[0], nil, identities); err != nil {
+				log.Fatal(err.Error())
+			}
+		}
+	}
+
+	var schema *gojsonschema.Schema
+	if noValidate, _ := cmd.Flags().GetBool("no-validate"); !noValidate {
+		if schema, err = getTypeSchema(manifest.Type); err != nil {
+			log.Warnf("Skipping the validation of the object data: %v", err)
+		}
+	}
+
+	// load the progress of a previous import into the same target, if any
+	statePath := filepath.Join(dir, importStateFileName)
+	state := importState{
+		Profile:   config.GetCurrentProfileName(),
+		LayerType: layerType,
+		LayerID:   headers["layer-id"],
+		Imported:  map[string]string{},
+	}
+	if !restart {
+		var previous importState
+		err := readJSONFile(statePath, &previous)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Warnf("Ignoring the progress of the previous import: %v", err)
+		} else if err == nil && previous.Profile == state.Profile && previous.LayerType == state.LayerType && previous.LayerID == state.LayerID && previous.Imported != nil {
+			state.Imported = previous.Imported
+		}
+	}
+
+	pending := []exportedObject{}
+	for _, o := range manifest.Objects {
+		if _, done := state.Imported[o.ID]; !done {
+			pending = append(pending, o)
+		}
+	}
+	if skipped := len(manifest.Objects) - len(pending); skipped > 0 {
+		output.PrintCmdStatus(cmd, fmt.Sprintf("Resuming the previous import: skipping %d object(s) already imported (use --restart to import them again)\n", skipped))
+	}
+
+	if dryRun {
+		results := make([]importResult, len(pending))
+		valid := 0
+		for i, o := range pending {
+			results[i] = importResult{ID: o.ID, File: o.File, Action: "import"}
+			if _, err := readImportData(cmd, dir, o, schema, identities); err != nil {
+				results[i].Action = "invalid"
+				results[i].Error = err.Error()
+				continue
+			}
+			valid++
+		}
+		printImportResults(cmd, results)
+		output.PrintCmdStatus(cmd, fmt.Sprintf("Dry run: %d %q object(s) would be imported into the %s layer %q.\n", valid, manifest.Type, layerType, headers["layer-id"]))
+		return
+	}
+
+	// import in batches, saving the progress after each batch
+	results := []importResult{}
+	failures := 0
+	aborted := false
+	for start := 0; start < len(pending) && !aborted && !interrupt.Interrupted(); start += batchSize {
+		end := start + batchSize
+		if end > len(pending) {
+			end = len(pending)
+		}
+		batch := importBatch(cmd, dir, manifest.Type, pending[start:end], headers, schema, identities, errs, concurrency)
+		for _, r := range batch {
+			aborted = aborted || r.aborted
+			if r.Error != "" {
+				failures++
+				continue
+			}
+			state.Imported[r.ID] = r.Action
+		}
+		results = append(results, batch...)
+		if err := writeJSONFile(statePath, state); err != nil {
+			log.Warnf("Failed to save the import progress: %v", err)
+		}
+		log.WithFields(log.Fields{"imported": end, "total": len(pending)}).Info("Import progress")
+	}
+
+	printImportResults(cmd, results)
+	if interrupt.Interrupted() {
+		log.Fatalf("Import interrupted after importing %d of %d object(s); the progress is saved, run the command again to resume it", len(results)-failures, len(pending))
+	}
+	if aborted {
+		log.Fatalf("Import aborted after importing %d of %d object(s); run the command again to resume it", len(pending)-failures, len(pending))
+	}
+	if failures > 0 {
+		log.Fatalf("Failed to import %d of %d object(s); run the command again to retry them", failures, len(pending))
+	}
+	output.PrintCmdStatus(cmd, fmt.Sprintf("Imported %d %q object(s).\n", len(pending), manifest.Type))
+}
+
+// importBatch imports the objects with a pool of workers, returning the results in the same order as the objects
+// (objects not imported because of an abort are reported as such)
+func importBatch(cmd *cobra.Command, dir string, objType string, objects []exportedObject, headers map[string]string, schema *gojsonschema.Schema, identities []encryption.Identity, errs *onerror.Handler, concurrency int) []importResult {
+	results := make([]importResult, len(objects))
+	indexes := make(chan int)
+	workers := concurrency
+	if workers > len(objects) {
+		workers = len(objects)
+	}
+
+	var aborted atomic.Bool
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if aborted.Load() {
+					results[i] = importResult{ID: objects[i].ID, File: objects[i].File, Action: "not imported", Error: "import aborted"}
+					continue
+				}
+				if interrupt.Interrupted() {
+					results[i] = importResult{ID: objects[i].ID, File: objects[i].File, Action: "not imported", Error: "import interrupted"}
+					continue
+				}
+				results[i] = importObject(cmd, dir, objType, objects[i], headers, schema, identities, errs)
+				if results[i].aborted {
+					aborted.Store(true)
+				}
+			}
+		}()
+	}
+	for i := range objects {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return results
+}
+
+// importObject creates an exported object or, if it already exists, replaces its data, handling
+// failures per --on-error
+func importObject(cmd *cobra.Command, dir string, objType string, o exportedObject, headers map[string]string, schema *gojsonschema.Schema, identities []encryption.Identity, errs *onerror.Handler) importResult {
+	result := importResult{ID: o.ID, File: o.File}
+	data, err := readImportData(cmd, dir, o, schema, identities)
+	if err != nil {
+		result.Action = "invalid"
+		result.Error = err.Error()
+		return result
+	}
+
+	err = errs.Do(fmt.Sprintf("object %q", o.ID), func() error {
+		var res any
+		options := &api.Options{Headers: headers, Resources: []string{objType + "/" + o.ID}}
+		err := api.JSONPost(getObjectListUrl(objType), data, &res, options)
+		if err != nil && options.ResponseStatus == http.StatusConflict {
+			result.Action = "updated"
+			return api.JSONPut(getObjectUrl(objType, o.ID), data, &res, &api.Options{Headers: headers})
+		}
+		result.Action = "created"
+		return err
+	})
+	if err != nil {
+		var abortErr *onerror.AbortError
+		result.aborted = errors.As(err, &abortErr)
+		if result.aborted {
+			err = abortErr.Err
+		}
+		result.Action = "failed"
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// readImportData reads the data of an exported object, decrypting it with the identities if it is encrypted,
+// and validates it against the schema, if any
+func readImportData(cmd *cobra.Command, dir string, o exportedObject, schema *gojsonschema.Schema, identities []encryption.Identity) (map[string]any, error) {
+	path := filepath.Join(dir, filepath.FromSlash(o.File))
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the object data from %s: %w", path, err)
+	}
+	if encryption.IsEncrypted(raw) {
+		if identities == nil {
+			return nil, fmt.Errorf("%s is encrypted but the export manifest is not marked as encrypted", path)
+		}
+		if raw, err = encryption.Decrypt(raw, identities...); err != nil {
+			return nil, fmt.Errorf("failed to decrypt %s: %w", path, err)
+		}
+	}
+	object, err := parseObjectData(raw, path)
+	if err != nil {
+		return nil, err
+	}
+	data := object
+	if d, ok := object["data"].(map[string]any); ok {
+		data = d // exported object, with the data and the object metadata
+	}
+	if schema != nil {
+		if violations := validateObjectData(schema, data); len(violations) > 0 {
+			messages := make([]string, len(violations))
+			for i, v := range violations {
+				messages[i] = v.String()
+			}
+			return nil, fmt.Errorf("invalid data: %s", strings.Join(messages, "; "))
+		}
+	}
+	return data, nil
+}
+
+func printImportResults(cmd *cobra.Command, results []importResult) {
+	lines := make([][]string, len(results))
+	for i, r := range results {
+		lines[i] = []string{r.ID, r.Action, r.Error}
+	}
+	output.PrintCmdOutputCustom(cmd, struct {
+		Items []importResult `json:"items"`
+		Total int            `json:"total"`
+	}{Items: results, Total: len(results)}, &output.Table{
+		Headers: []string{"ID", "Action", "Error"},
+		Lines:   lines,
+	})
+}
+
+var unsafeFileNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// exportFileName returns a file name for an object, derived from its id, that is valid on all platforms
+// and unique (ignoring case) among the names already used
+func exportFileName(id string, used map[string]bool) string {
+	base := strings.Trim(unsafeFileNameChars.ReplaceAllString(id, "_"), ".")
+	if base == "" {
+		base = "object"
+	}
+	name := base + ".json"
+	for n := 2; used[strings.ToLower(name)]; n++ {
+		name = fmt.Sprintf("%s-%d.json", base, n)
+	}
+	used[strings.ToLower(name)] = true
+	return name
+}
+
+func writeJSONFile(path string, v any) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(b, '\n'), 0644)
+}
+
+// writeExportKey generates the age key that the objects of an export are encrypted to, and saves it encrypted
+// to the recipients (a passphrase), returning the key's recipient
+func writeExportKey(path string, recipients []encryption.Recipient) ([]encryption.Recipient, error) {
+	key, err := encryption.GenerateIdentity()
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := encryption.Encrypt([]byte(key.String()+"\n"), recipients...)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, encrypted, 0600); err != nil {
+		return nil, err
+	}
+	return []encryption.Recipient{key.Recipient()}, nil
+}
+
+// readExportKey decrypts the age key saved by writeExportKey
+func readExportKey(path string, identities []encryption.Identity) ([]encryption.Identity, error) {
+	encrypted, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	decrypted, err := encryption.Decrypt(encrypted, identities...)
+	if err != nil {
+		return nil, err
+	}
+	return encryption.ParseIdentities(bytes.NewReader(decrypted))
+}
+
+// writeEncryptedJSONFile writes the value as JSON encrypted to the recipients, readable only by the user
+func writeEncryptedJSONFile(path string, v any, recipients []encryption.Recipient) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	encrypted, err := encryption.Encrypt(append(b, '\n'), recipients...)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, encrypted, 0600)
+}
+
+func readJSONFile(path string, v any) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
@@ -0,0 +1,234 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package objstore
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/slices"
+
+	"github.com/cisco-open/fsoc/output"
+	"github.com/cisco-open/fsoc/platform/api"
+)
+
+// knowledgeType is the definition of a knowledge object type
+type knowledgeType struct {
+	Name                  string         `json:"name" yaml:"name"`
+	Solution              string         `json:"solution,omitempty" yaml:"solution,omitempty"`
+	AllowedLayers         []string       `json:"allowedLayers,omitempty" yaml:"allowedLayers,omitempty"`
+	IdentifyingProperties []string       `json:"identifyingProperties,omitempty" yaml:"identifyingProperties,omitempty"`
+	SecureProperties      []string       `json:"secureProperties,omitempty" yaml:"secureProperties,omitempty"`
+	IdGeneration          map[string]any `json:"idGeneration,omitempty" yaml:"idGeneration,omitempty"`
+	JsonSchema            map[string]any `json:"jsonSchema,omitempty" yaml:"jsonSchema,omitempty"`
+}
+
+// fqtn returns the fully qualified name of the type (solution:name)
+func (t knowledgeType) fqtn() string {
+	if t.Solution == "" || strings.Contains(t.Name, ":") {
+		return t.Name
+	}
+	return t.Solution + ":" + t.Name
+}
+
+// typeProperty is a property of a type's JSON schema, flattened with a JSON pointer path
+type typeProperty struct {
+	Path        string `json:"path" yaml:"path"`
+	Type        string `json:"type,omitempty" yaml:"type,omitempty"`
+	Required    bool   `json:"required" yaml:"required"`
+	Identifying bool   `json:"identifying" yaml:"identifying"`
+	Secure      bool   `json:"secure" yaml:"secure"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+}
+
+func newListTypesCmd() *cobra.Command {
+	typesCmd := &cobra.Command{
+		Use:   "types",
+		Short: "List the knowledge object types",
+		Long: `List the knowledge object types available in the tenant, with their allowed layers and identifying
+properties. Use "describe-type" to see the properties of a type.`,
+		Example: `  # List all types
+  fsoc knowledge types
+
+  # List the types defined by a solution
+  fsoc knowledge types --solution preferences`,
+		Args:             cobra.NoArgs,
+		Run:              listTypes,
+		TraverseChildren: true,
+	}
+	typesCmd.Flags().String("solution", "", "List only the types defined by the solution")
+	return typesCmd
+}
+
+func newDescribeTypeCmd() *cobra.Command {
+	describeTypeCmd := &cobra.Command{
+		Use:   "describe-type FQTN",
+		Short: "Describe a knowledge object type",
+		Long: `Describe a knowledge object type: its allowed layers and identifying properties, and the properties
+defined by its JSON schema, with their types and whether they are required, identifying or secure.
+With --schema, only the JSON schema of the type is displayed, e.g., for use in an editor.`,
+		Example: `  fsoc knowledge describe-type preferences:theme
+  fsoc knowledge describe-type preferences:theme --schema > theme.schema.json`,
+		Args:             cobra.ExactArgs(1),
+		Run:              describeType,
+		TraverseChildren: true,
+	}
+	describeTypeCmd.Flags().Bool("schema", false, "Display only the JSON schema of the type")
+	return describeTypeCmd
+}
+
+func listTypes(cmd *cobra.Command, args []string) {
+	solutionName, _ := cmd.Flags().GetString("solution")
+
+	var res any
+	if err := api.JSONGetCollection(getTypesUrl(), &res, nil); err != nil {
+		log.Fatalf("Failed to get the knowledge types: %v", err)
+	}
+	var page struct {
+		Items []knowledgeType `json:"items"`
+	}
+	if err := convertValue(res, &page); err != nil {
+		log.Fatalf("Failed to parse the knowledge types: %v", err)
+	}
+
+	types := []knowledgeType{}
+	for _, t := range page.Items {
+		if solutionName == "" || strings.HasPrefix(t.fqtn(), solutionName+":") {
+			types = append(types, t)
+		}
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i].fqtn() < types[j].fqtn() })
+
+	lines := make([][]string, len(types))
+	for i, t := range types {
+		lines[i] = []string{t.fqtn(), strings.Join(t.AllowedLayers, ", "), strings.Join(t.IdentifyingProperties, ", ")}
+	}
+	output.PrintCmdOutputCustom(cmd, struct {
+		Items []knowledgeType `json:"items"`
+		Total int             `json:"total"`
+	}{Items: types, Total: len(types)}, &output.Table{
+		Headers: []string{"Type", "Allowed Layers", "Identifying Properties"},
+		Lines:   lines,
+	})
+}
+
+func describeType(cmd *cobra.Command, args []string) {
+	fqtn := args[0]
+	var t knowledgeType
+	if err := api.JSONGet(getTypeUrl(fqtn), &t, nil); err != nil {
+		log.Fatalf("Failed to get knowledge type %q: %v", fqtn, err)
+	}
+
+	if schemaOnly, _ := cmd.Flags().GetBool("schema"); schemaOnly {
+		if err := output.PrintJson(cmd, t.JsonSchema); err != nil {
+			log.Fatalf("Failed to display the schema: %v", err)
+		}
+		return
+	}
+
+	properties := schemaProperties(t.JsonSchema, "", t.IdentifyingProperties, t.SecureProperties)
+	if format, _ := cmd.Flags().GetString("output"); format == "auto" || format == "table" {
+		output.PrintCmdStatus(cmd, fmt.Sprintf("Type:                   %s\n", t.fqtn()))
+		output.PrintCmdStatus(cmd, fmt.Sprintf("Allowed layers:         %s\n", strings.Join(t.AllowedLayers, ", ")))
+		output.PrintCmdStatus(cmd, fmt.Sprintf("Identifying properties: %s\n\n", strings.Join(t.IdentifyingProperties, ", ")))
+	}
+
+	lines := make([][]string, len(properties))
+	for i, p := range properties {
+		lines[i] = []string{p.Path, p.Type, yesNo(p.Required), yesNo(p.Identifying), yesNo(p.Secure), p.Description}
+	}
+	output.PrintCmdOutputCustom(cmd, t, &output.Table{
+		Headers: []string{"Property", "Type", "Required", "Identifying", "Secure", "Description"},
+		Lines:   lines,
+	})
+}
+
+// schemaProperties flattens the properties of an object JSON schema, recursing into nested objects.
+// Property paths are JSON pointers (e.g., "/spec/name"), as used for identifying and secure properties.
+func schemaProperties(schema map[string]any, prefix string, identifying []string, secure []string) []typeProperty {
+	props, _ := schema["properties"].(map[string]any)
+	required := map[string]bool{}
+	if list, ok := schema["required"].([]any); ok {
+		for _, name := range list {
+			required[fmt.Sprint(name)] = true
+		}
+	}
+
+	names := make([]string, 0, len(props))
+	for name := range props {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	properties := []typeProperty{}
+	for _, name := range names {
+		propSchema, _ := props[name].(map[string]any)
+		path := prefix + "/" + name
+		p := typeProperty{
+			Path:        path,
+			Type:        schemaType(propSchema),
+			Required:    required[name],
+			Identifying: propertyListed(identifying, path),
+			Secure:      propertyListed(secure, path),
+		}
+		p.Description, _ = propSchema["description"].(string)
+		properties = append(properties, p)
+		if _, nested := propSchema["properties"]; nested {
+			properties = append(properties, schemaProperties(propSchema, path, identifying, secure)...)
+		}
+	}
+	return properties
+}
+
+// propertyListed returns true if the property, specified by its JSON pointer, is in the list, which
+// may specify properties either as JSON pointers or as JSONPath expressions (e.g., "$.spec.name")
+func propertyListed(list []string, pointer string) bool {
+	return slices.Contains(list, pointer) || slices.Contains(list, "$"+strings.ReplaceAll(pointer, "/", "."))
+}
+
+// schemaType returns a short description of the type of a property, e.g., "string" or "array of integer"
+func schemaType(schema map[string]any) string {
+	t := fmt.Sprint(schema["type"])
+	if schema["type"] == nil {
+		switch {
+		case schema["$ref"] != nil:
+			return fmt.Sprint(schema["$ref"])
+		case schema["enum"] != nil:
+			t = "enum"
+		default:
+			return ""
+		}
+	}
+	if t == "array" {
+		if items, ok := schema["items"].(map[string]any); ok && items["type"] != nil {
+			return "array of " + schemaType(items)
+		}
+	}
+	return t
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return ""
+}
+
+func getTypesUrl() string {
+	return "objstore/v1beta/types"
+}
@@ -15,10 +15,7 @@
 package objstore
 
 import (
-	"encoding/json"
 	"fmt"
-	"io"
-	"os"
 
 	"github.com/apex/log"
 	"github.com/spf13/cobra"
@@ -42,9 +39,10 @@ var objStoreUpdateCmd = &cobra.Command{
 	Flags/Options:
 	--type - Flag to indicate the fully qualified type name of the object that you would like to update
 	--object-id - Flag to indicate the ID of the object that you want to update
-	--object-file - Flag to indicate the fully qualified path (from your root directory) to the file containing the definition of the object that you want to update. Please note that update internally calls HTTP PUT so you will need to specify all fields in the object (even if you are updating just one field)
+	--object-file, -f - Flag to indicate the path to the JSON or YAML file containing the definition of the object that you want to update ("-" reads it from the standard input). Please note that update internally calls HTTP PUT so you will need to specify all fields in the object (even if you are updating just one field)
 	--layer-type - Flag to indicate the layer at which the object you would like to update exists
-	--layer-id - OPTIONAL Flag to specify a custom layer ID for the object that you would like to update.  This is calculated automatically for all layers currently supported but can be overridden with this flag`,
+	--layer-id - OPTIONAL Flag to specify a custom layer ID for the object that you would like to update.  This is calculated automatically for all layers currently supported but can be overridden with this flag
+	--no-validate - OPTIONAL Flag to skip validating the object data against the schema of its type before sending it`,
 
 	Args:             cobra.ExactArgs(0),
 	Run:              updateObject,
@@ -60,9 +58,8 @@ func getUpdateObjectCmd() *cobra.Command {
 		String("object-id", "", "The id of the knowledge object been updated")
 	_ = objStoreUpdateCmd.MarkPersistentFlagRequired("type")
 
-	objStoreUpdateCmd.Flags().
-		String("object-file", "", "The fully qualified path to the json file containing the knowledge object data definition")
-	_ = objStoreUpdateCmd.MarkPersistentFlagRequired("objectFile")
+	addObjectFileFlag(objStoreUpdateCmd, "The path to the file containing the knowledge object data definition")
+	addValidationFlag(objStoreUpdateCmd)
 
 	objStoreUpdateCmd.Flags().
 		String("layer-type", "", "The layer-type of the updated object")
@@ -79,35 +76,17 @@ func updateObject(cmd *cobra.Command, args []string) {
 	objType, _ := cmd.Flags().GetString("type")
 
 	objJsonFilePath, _ := cmd.Flags().GetString("object-file")
-	objectFile, err := os.Open(objJsonFilePath)
+	objectStruct, err := readObjectData(cmd, objJsonFilePath)
 	if err != nil {
-		log.Fatalf("Can't find the object definition file named %s", objJsonFilePath)
+		log.Fatalf("%v. Make sure the object definition has all the required field and is valid according to the type definition.", err)
 	}
-	defer objectFile.Close()
 
-	objectBytes, _ := io.ReadAll(objectFile)
-	var objectStruct map[string]interface{}
-	err = json.Unmarshal(objectBytes, &objectStruct)
-	if err != nil {
-		log.Fatalf("Can't parse file %q. Make sure the object definition has all the required field and is valid according to the type definition.", objJsonFilePath)
-	}
+	checkObjectData(cmd, objType, objectStruct)
 
 	layerType, _ := cmd.Flags().GetString("layer-type")
-	layerID := getCorrectLayerID(layerType, objType)
-
-	if layerID == "" {
-		if !cmd.Flags().Changed("layer-id") {
-			log.Fatal("Unable to set layer-id flag from given context. Please specify a unique layer-id value with the --layer-id flag")
-		}
-		layerID, err = cmd.Flags().GetString("layer-id")
-		if err != nil {
-			log.Fatalf("error trying to get %q flag value: %w", "layer-id", err)
-		}
-	}
-
-	headers := map[string]string{
-		"layer-type": layerType,
-		"layer-id":   layerID,
+	headers, err := getLayerHeaders(cmd, layerType, objType)
+	if err != nil {
+		log.Fatal(err.Error())
 	}
 
 	var res any
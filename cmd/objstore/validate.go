@@ -0,0 +1,160 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package objstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+	"github.com/xeipuuv/gojsonschema"
+
+	"github.com/cisco-open/fsoc/cmd/config"
+	"github.com/cisco-open/fsoc/cmdkit/offline"
+	"github.com/cisco-open/fsoc/output"
+	"github.com/cisco-open/fsoc/platform/api"
+)
+
+// type schemas are cached in the config directory, per profile, for schemaCacheTTL
+const (
+	schemaCacheDirName = "schema-cache"
+	schemaCacheTTL     = time.Hour
+)
+
+// schemaViolation is a validation error of object data, located by a JSON pointer (e.g., "/spec/name")
+type schemaViolation struct {
+	Pointer string
+	Message string
+}
+
+func (v schemaViolation) String() string {
+	pointer := v.Pointer
+	if pointer == "" {
+		pointer = "/"
+	}
+	return pointer + ": " + v.Message
+}
+
+// addValidationFlag adds the flag that skips the client-side validation of the object data
+func addValidationFlag(cmd *cobra.Command) {
+	cmd.Flags().Bool("no-validate", false, "Skip validating the object data against the type's schema before sending it")
+}
+
+// checkObjectData validates the object data against the schema of its type, unless --no-validate is
+// specified, and fails the command if the data is not valid. If the schema cannot be obtained, the data
+// is sent without validation (the platform validates it as well).
+func checkObjectData(cmd *cobra.Command, fqtn string, data map[string]any) {
+	if noValidate, _ := cmd.Flags().GetBool("no-validate"); noValidate {
+		return
+	}
+	schema, err := getTypeSchema(fqtn)
+	if err != nil {
+		log.Warnf("Skipping the validation of the object data: %v", err)
+		return
+	}
+	violations := validateObjectData(schema, data)
+	if len(violations) == 0 {
+		return
+	}
+	var sb strings.Builder
+	for _, v := range violations {
+		sb.WriteString("  " + v.String() + "\n")
+	}
+	output.PrintCmdStatus(cmd, fmt.Sprintf("The object data is not valid for type %q:\n%s", fqtn, sb.String()))
+	log.Fatalf("Object data failed validation with %d error(s); fix the data or use --no-validate to send it anyway", len(violations))
+}
+
+// validateObjectData validates the object data against the type's JSON schema, returning the violations found
+func validateObjectData(schema *gojsonschema.Schema, data map[string]any) []schemaViolation {
+	result, err := schema.Validate(gojsonschema.NewGoLoader(data))
+	if err != nil {
+		return []schemaViolation{{Message: fmt.Sprintf("schema validation failed: %v", err)}}
+	}
+	violations := []schemaViolation{}
+	for _, e := range result.Errors() {
+		pointer := fieldPointer(e.Field())
+		if e.Type() == "required" {
+			pointer += "/" + escapePointerToken(fmt.Sprint(e.Details()["property"]))
+		}
+		violations = append(violations, schemaViolation{Pointer: pointer, Message: e.Description()})
+	}
+	return violations
+}
+
+// fieldPointer converts a gojsonschema field path (e.g., "spec.items.0") into a JSON pointer ("/spec/items/0")
+func fieldPointer(field string) string {
+	if field == "" || field == gojsonschema.STRING_ROOT_SCHEMA_PROPERTY {
+		return ""
+	}
+	tokens := strings.Split(field, ".")
+	for i, t := range tokens {
+		tokens[i] = escapePointerToken(t)
+	}
+	return "/" + strings.Join(tokens, "/")
+}
+
+func escapePointerToken(token string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
+
+// getTypeSchema returns the compiled JSON schema of a knowledge type, fetching it from the platform
+// unless it was cached recently
+func getTypeSchema(fqtn string) (*gojsonschema.Schema, error) {
+	cachePath := filepath.Join(config.GetConfigDir(), schemaCacheDirName, config.GetCurrentProfileName(), strings.ReplaceAll(fqtn, ":", "_")+".json")
+
+	var jsonSchema map[string]any
+	// in offline mode, a cached schema is used regardless of its age
+	if fi, err := os.Stat(cachePath); err == nil && (time.Since(fi.ModTime()) < schemaCacheTTL || offline.Enabled()) {
+		if err := readJSONFile(cachePath, &jsonSchema); err != nil {
+			log.Infof("Ignoring the cached schema of type %q: %v", fqtn, err)
+			jsonSchema = nil
+		}
+	}
+	if jsonSchema == nil {
+		var t knowledgeType
+		if err := api.JSONGet(getTypeUrl(fqtn), &t, nil); err != nil {
+			return nil, fmt.Errorf("failed to get knowledge type %q: %w", fqtn, err)
+		}
+		if t.JsonSchema == nil {
+			return nil, fmt.Errorf("knowledge type %q has no schema", fqtn)
+		}
+		jsonSchema = t.JsonSchema
+		if err := writeSchemaCache(cachePath, jsonSchema); err != nil {
+			log.Infof("Failed to cache the schema of type %q: %v", fqtn, err)
+		}
+	}
+
+	schema, err := gojsonschema.NewSchema(gojsonschema.NewGoLoader(jsonSchema))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the schema of type %q: %w", fqtn, err)
+	}
+	return schema, nil
+}
+
+func writeSchemaCache(path string, schema map[string]any) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	b, err := json.Marshal(schema)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0600)
+}
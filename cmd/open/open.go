@@ -0,0 +1,133 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package open provides commands that open the pages of the tenant's user interface in the browser
+package open
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/pkg/browser"
+	"github.com/spf13/cobra"
+
+	"github.com/cisco-open/fsoc/cmd/config"
+	"github.com/cisco-open/fsoc/cmd/uql"
+	"github.com/cisco-open/fsoc/exitcode"
+	"github.com/cisco-open/fsoc/output"
+)
+
+// Paths of the user interface pages, relative to the tenant URL
+const (
+	homePath     = "/ui/"
+	entityPath   = "/ui/observe/entity/"
+	solutionPath = "/ui/solutions/"
+)
+
+func NewSubCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "open",
+		Short: "Open the tenant's user interface in the browser",
+		Long: `Open a page of the tenant's user interface in the default browser, constructing its URL from the
+current profile: the home page or, with the subcommands, the page of an entity or a solution.
+
+Use --print to display the URL instead, e.g., to share it or when no browser is available.`,
+		Example: `  fsoc open
+  fsoc open entity k8s:workload:Ry4Aa2JfNcqrEXampleId
+  fsoc open solution spacefleet --print
+  fsoc open --profile prod`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			openPage(cmd, homePath)
+		},
+		TraverseChildren: true,
+	}
+	cmd.PersistentFlags().Bool("print", false, "Display the URL instead of opening it in the browser")
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "entity ID",
+		Short: "Open the page of an entity",
+		Long:  `Open the page of an entity in the tenant's user interface, by its ID (as shown by "fsoc entity list").`,
+		Example: `  fsoc open entity k8s:workload:Ry4Aa2JfNcqrEXampleId
+  fsoc open entity apm:service:Tx3oVrjC1eqzREXampleId --print`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if !uql.IsEntityId(args[0]) {
+				log.WithField(exitcode.Field, exitcode.Usage).Fatalf("Invalid entity ID %q: expected an ID like k8s:workload:Ry4Aa2JfNcqrEXampleId", args[0])
+			}
+			openPage(cmd, entityPath+url.PathEscape(args[0]))
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "solution NAME",
+		Short: "Open the page of a solution",
+		Long:  `Open the page of a solution in the tenant's user interface, by its name (as shown by "fsoc solution list").`,
+		Example: `  fsoc open solution spacefleet
+  fsoc open solution spacefleet --print`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			openPage(cmd, solutionPath+url.PathEscape(args[0]))
+		},
+	})
+
+	return cmd
+}
+
+// openPage opens the page at the path of the tenant's user interface in the browser, or displays its URL
+func openPage(cmd *cobra.Command, path string) {
+	pageURL, err := pageURL(config.GetCurrentContext().URL, path)
+	if err != nil {
+		log.WithField(exitcode.Field, exitcode.ConfigMissing).Fatal(err.Error())
+	}
+
+	if printURL, _ := cmd.Flags().GetBool("print"); printURL {
+		output.PrintCmdStatus(cmd, pageURL+"\n")
+		return
+	}
+	log.WithField("url", pageURL).Info("Opening the page in the browser")
+	if err := openBrowser(pageURL); err != nil {
+		log.Fatalf("Failed to open the browser (use --print to display the URL instead): %v", err)
+	}
+}
+
+// pageURL returns the URL of the page at the path of the user interface of the tenant with the URL
+func pageURL(tenantURL string, path string) (string, error) {
+	if tenantURL == "" {
+		return "", fmt.Errorf("the current profile has no URL; set it with \"fsoc config set --url\"")
+	}
+	u, err := url.Parse(tenantURL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("invalid URL %q in the current profile", tenantURL)
+	}
+	return u.Scheme + "://" + u.Host + path, nil
+}
+
+// openBrowser opens the URL in the default browser, logging what the browser displays
+func openBrowser(url string) error {
+	var out bytes.Buffer
+	origStdout, origStderr := browser.Stdout, browser.Stderr
+	browser.Stdout, browser.Stderr = &out, &out
+	defer func() {
+		browser.Stdout, browser.Stderr = origStdout, origStderr
+	}()
+
+	err := browser.OpenURL(url)
+	if msg := strings.TrimSpace(out.String()); msg != "" {
+		log.Infof("Browser launch: %v", msg)
+	}
+	return err
+}
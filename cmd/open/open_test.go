@@ -0,0 +1,37 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package open
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPageURL(t *testing.T) {
+	u, err := pageURL("https://acme.observe.appdynamics.com", entityPath+"k8s:workload:Ry4Aa2JfNcqrEXampleId")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://acme.observe.appdynamics.com/ui/observe/entity/k8s:workload:Ry4Aa2JfNcqrEXampleId", u)
+
+	// the tenant URL's path is not part of the user interface's URL
+	u, err = pageURL("https://acme.observe.appdynamics.com/", homePath)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://acme.observe.appdynamics.com/ui/", u)
+
+	_, err = pageURL("", homePath)
+	assert.Error(t, err)
+	_, err = pageURL("acme.observe.appdynamics.com", homePath)
+	assert.Error(t, err)
+}
@@ -0,0 +1,105 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+
+	"github.com/cisco-open/fsoc/cmdkit/interrupt"
+	"github.com/cisco-open/fsoc/exitcode"
+)
+
+// outputFormats maps the extensions of output files to the output formats inferred from them
+var outputFormats = map[string]string{
+	".json":   "json",
+	".jsonl":  "jsonl",
+	".ndjson": "jsonl",
+	".yaml":   "yaml",
+	".yml":    "yaml",
+	".csv":    "csv",
+}
+
+// outputFile is the file receiving the command's output, if requested with --output-file. The output is
+// written to a temporary file, which replaces the requested file only if the command succeeds, so
+// that scripts never see partial output.
+var outputFile struct {
+	path    string
+	temp    *os.File
+	cleanup func() // removes the temporary file unless renamed, also if fsoc is interrupted
+}
+
+// openOutputFile redirects the command's output to a temporary file for --output-file, selecting the
+// output format from the file's extension unless --output is specified. Log messages, progress
+// indicators and status messages (see output.PrintCmdStatus) remain on stderr.
+func openOutputFile(cmd *cobra.Command) {
+	path, _ := cmd.Flags().GetString("output-file")
+	if path == "" {
+		return
+	}
+	if format, found := outputFormats[strings.ToLower(filepath.Ext(path))]; found && !cmd.Flags().Changed("output") {
+		if err := cmd.Flags().Set("output", format); err != nil {
+			log.Fatalf("Failed to select the %q output format for %q: %v", format, path, err)
+		}
+	}
+
+	temp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		log.Fatalf("Failed to create the output file %q: %v", path, err)
+	}
+	_ = temp.Chmod(0644) // as for other files created by the user, rather than private as temporary files
+	outputFile.path = path
+	outputFile.temp = temp
+	outputFile.cleanup = interrupt.OnCleanup(func() {
+		_ = temp.Close()
+		_ = os.Remove(temp.Name())
+	})
+	cmd.SetOut(temp)
+	log.WithFields(log.Fields{"path": path, "temp": temp.Name()}).Info("Writing output to file")
+}
+
+// closeOutputFile moves the output to the requested file if the command succeeded, or discards it
+func closeOutputFile(err error) error {
+	temp := outputFile.temp
+	if temp == nil {
+		return err
+	}
+	outputFile.temp = nil
+	closeErr := temp.Close()
+	if err == nil && closeErr == nil {
+		closeErr = os.Rename(temp.Name(), outputFile.path)
+	}
+	outputFile.cleanup() // after a successful rename, there is nothing left to remove
+	if err == nil && closeErr != nil {
+		return exitcode.Wrap(fmt.Errorf("failed to write the output file %q: %w", outputFile.path, closeErr))
+	}
+	return err
+}
+
+// outputFileHandler returns a log handler that discards the output file when a command fails with a
+// fatal error (which exits without returning through Execute)
+func outputFileHandler() log.Handler {
+	return log.HandlerFunc(func(e *log.Entry) error {
+		if e.Level == log.FatalLevel {
+			_ = closeOutputFile(fmt.Errorf("%s", e.Message))
+		}
+		return nil
+	})
+}
@@ -0,0 +1,89 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cisco-open/fsoc/cmd/config"
+	"github.com/cisco-open/fsoc/output"
+)
+
+var pluginCmd = &cobra.Command{
+	Use:   "plugin",
+	Short: "Manage fsoc plugins",
+	Long: `Plugins extend fsoc with external subcommands, without changing fsoc itself. Any executable on the PATH
+whose name starts with "fsoc-" is a plugin: e.g., "fsoc-hello" runs as "fsoc hello". All the arguments
+after the plugin's name are passed to it unchanged, so fsoc's own flags, e.g., --profile, must precede
+the plugin's name: "fsoc --profile prod hello --name world".
+
+Plugins receive the resolved profile in environment variables:
+  FSOC_PROFILE      the profile name
+  FSOC_CONFIG       the config file
+  FSOC_URL          the profile's URL
+  FSOC_TENANT       the profile's tenant ID
+  FSOC_AUTH_METHOD  the profile's authentication method
+  FSOC_TOKEN        a valid access token of the profile, refreshed if needed
+  FSOC_OUTPUT       the value of the --output flag
+  FSOC_EXECUTABLE   the fsoc executable, e.g., to run "fsoc login" or other commands
+
+Plugins can't override built-in commands; if several plugins have the same name, the first one on the PATH
+is used. Set FSOC_NO_PLUGINS=1 to disable plugins.`,
+	Annotations: map[string]string{config.AnnotationForConfigBypass: ""},
+}
+
+var pluginListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the plugins found on the PATH",
+	Long: `List the plugins found on the PATH, in PATH order. Plugins that are hidden by a built-in command or
+by an earlier plugin with the same name are shown as shadowed.`,
+	Example:     `  fsoc plugin list`,
+	Args:        cobra.NoArgs,
+	Run:         listPlugins,
+	Annotations: map[string]string{config.AnnotationForConfigBypass: ""},
+}
+
+func init() {
+	pluginCmd.AddCommand(pluginListCmd)
+}
+
+// NewSubCmd returns the plugin command
+func NewSubCmd() *cobra.Command {
+	return pluginCmd
+}
+
+func listPlugins(cmd *cobra.Command, args []string) {
+	plugins := Discover(os.Getenv("PATH"))
+	lines := [][]string{}
+	for i, p := range plugins {
+		if isBuiltin(cmd.Root(), p.Name) {
+			plugins[i].Shadowed = true
+		}
+		status := "active"
+		if plugins[i].Shadowed {
+			status = "shadowed"
+		}
+		lines = append(lines, []string{p.Name, p.Path, status})
+	}
+	output.PrintCmdOutputCustom(cmd, struct {
+		Items []Plugin `json:"items"`
+		Total int      `json:"total"`
+	}{Items: plugins, Total: len(plugins)}, &output.Table{
+		Headers: []string{"Name", "Path", "Status"},
+		Lines:   lines,
+	})
+}
@@ -0,0 +1,301 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package plugin extends fsoc with external subcommands: executables named fsoc-<name> found on the
+// PATH can be run as "fsoc <name>", similar to kubectl plugins.
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+
+	"github.com/cisco-open/fsoc/cmd/config"
+	"github.com/cisco-open/fsoc/cmdkit/cmdline"
+	"github.com/cisco-open/fsoc/cmdkit/interrupt"
+	"github.com/cisco-open/fsoc/exitcode"
+	"github.com/cisco-open/fsoc/platform/api"
+)
+
+// Prefix is the prefix of the plugin executables' names
+const Prefix = "fsoc-"
+
+// AnnotationForPlugin marks the commands that run plugins
+const AnnotationForPlugin = "fsoc/plugin"
+
+// DisableEnvVar is the environment variable that disables plugin discovery when set to a non-empty value
+const DisableEnvVar = "FSOC_NO_PLUGINS"
+
+// tokenMinValidity is the minimum remaining validity of the access token passed to a plugin; an access
+// token that expires sooner is refreshed first
+const tokenMinValidity = 5 * time.Minute
+
+// windowsExtensions are the executable file extensions recognized on Windows
+var windowsExtensions = []string{".exe", ".bat", ".cmd"}
+
+// Plugin describes a plugin executable
+type Plugin struct {
+	Name string `json:"name" yaml:"name"`
+	Path string `json:"path" yaml:"path"`
+
+	// Shadowed is true if the plugin is hidden by a built-in command or by another plugin with
+	// the same name earlier on the PATH
+	Shadowed bool `json:"shadowed" yaml:"shadowed"`
+}
+
+// Discover returns the plugins found in the directories of a PATH list, in PATH order. Plugins
+// with the same name as a plugin earlier on the PATH are marked as shadowed.
+func Discover(pathList string) []Plugin {
+	plugins := []Plugin{}
+	seen := map[string]bool{}
+	for _, dir := range filepath.SplitList(pathList) {
+		if dir == "" {
+			continue
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue // PATH often includes directories that don't exist
+		}
+		for _, entry := range entries {
+			name, ok := pluginName(entry.Name(), runtime.GOOS)
+			if !ok {
+				continue
+			}
+			path := filepath.Join(dir, entry.Name())
+			if !isExecutable(path) {
+				continue
+			}
+			plugins = append(plugins, Plugin{Name: name, Path: path, Shadowed: seen[name]})
+			seen[name] = true
+		}
+	}
+	return plugins
+}
+
+// Lookup returns the plugin with the name that is first on the PATH list, if any
+func Lookup(pathList string, name string) (Plugin, bool) {
+	fileNames := []string{Prefix + name}
+	if runtime.GOOS == "windows" {
+		fileNames = fileNames[:0]
+		for _, ext := range windowsExtensions {
+			fileNames = append(fileNames, Prefix+name+ext)
+		}
+	}
+	for _, dir := range filepath.SplitList(pathList) {
+		if dir == "" {
+			continue
+		}
+		for _, fileName := range fileNames {
+			path := filepath.Join(dir, fileName)
+			if isExecutable(path) {
+				return Plugin{Name: name, Path: path}, true
+			}
+		}
+	}
+	return Plugin{}, false
+}
+
+// pluginName returns the name of the subcommand for a plugin file name, e.g., "hello" for fsoc-hello
+func pluginName(fileName string, goos string) (string, bool) {
+	if !strings.HasPrefix(fileName, Prefix) {
+		return "", false
+	}
+	name := strings.TrimPrefix(fileName, Prefix)
+	if goos == "windows" {
+		ext := strings.ToLower(filepath.Ext(name))
+		found := false
+		for _, e := range windowsExtensions {
+			if ext == e {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return "", false
+		}
+		name = name[:len(name)-len(ext)]
+	}
+	if name == "" || strings.ContainsAny(name, " \t") {
+		return "", false
+	}
+	return name, true
+}
+
+func isExecutable(path string) bool {
+	info, err := os.Stat(path) // follows symlinks
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+	return runtime.GOOS == "windows" || info.Mode().Perm()&0111 != 0
+}
+
+// NewCommand returns a command that runs a plugin, passing it the command line arguments that follow
+// the plugin's name unchanged
+func NewCommand(p Plugin) *cobra.Command {
+	return &cobra.Command{
+		Use:                p.Name,
+		Short:              fmt.Sprintf("Run the %q plugin (%v)", p.Name, p.Path),
+		DisableFlagParsing: true,
+		SilenceErrors:      true, // the plugin reports its own errors
+		SilenceUsage:       true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return run(cmd, p, args)
+		},
+		Annotations: map[string]string{
+			config.AnnotationForConfigBypass: "",
+			AnnotationForPlugin:              p.Path,
+		},
+	}
+}
+
+// run runs the plugin, returning an error with the plugin's exit code if it fails
+func run(cmd *cobra.Command, p Plugin, args []string) error {
+	c := exec.Command(p.Path, args...)
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	c.Env = append(os.Environ(), Environment(cmd)...)
+	log.WithFields(log.Fields{"plugin": p.Name, "path": p.Path}).Info("Running plugin")
+
+	// the plugin receives Ctrl+C too (as part of the process group) and decides how to handle it
+	interrupt.Disable()
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	defer signal.Stop(signals)
+
+	err := c.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		log.WithFields(log.Fields{"plugin": p.Name, "exit_code": exitErr.ExitCode()}).Info("Plugin failed")
+		return &exitcode.Error{Code: exitErr.ExitCode(), Err: fmt.Errorf("plugin %q exited with code %d", p.Name, exitErr.ExitCode())}
+	}
+	if err != nil {
+		log.Fatalf("Failed to run plugin %q: %v", p.Path, err)
+	}
+	return nil
+}
+
+// Environment returns the environment variables that pass the resolved profile to a plugin, as
+// NAME=value strings. Only the profile name is passed if the profile doesn't exist. The access token
+// is refreshed if it expires soon, logging in if needed.
+func Environment(cmd *cobra.Command) []string {
+	env := []string{
+		"FSOC_PROFILE=" + config.GetCurrentProfileName(),
+		"FSOC_CONFIG=" + viper.ConfigFileUsed(),
+	}
+	if exe, err := os.Executable(); err == nil {
+		env = append(env, "FSOC_EXECUTABLE="+exe)
+	}
+	if format, _ := cmd.Flags().GetString("output"); format != "" {
+		env = append(env, "FSOC_OUTPUT="+format)
+	}
+	if ctx, err := config.LoadCurrentContext(); err == nil && ctx != nil {
+		env = append(env,
+			"FSOC_URL="+ctx.URL,
+			"FSOC_TENANT="+ctx.Tenant,
+			"FSOC_AUTH_METHOD="+ctx.AuthMethod,
+		)
+		if ctx.AuthMethod != config.AuthMethodNone && ctx.AuthMethod != config.AuthMethodLocal {
+			token, _, err := api.CurrentToken(tokenMinValidity, true)
+			if err != nil {
+				log.Warnf("Running the plugin without an access token: %v", err)
+			} else {
+				env = append(env, "FSOC_TOKEN="+token)
+			}
+		}
+	}
+	return env
+}
+
+// Register adds the command of the plugin that the command line arguments run to the root command,
+// unless plugin discovery is disabled. The PATH is searched only if the arguments don't run a built-in
+// command, since plugins can't override the built-in commands; for shell completion, all the plugins
+// found on the PATH are added.
+//
+// Register returns the command line arguments to execute. For a plugin, the root command's flags given
+// before the plugin's name (e.g., --profile) are applied and removed, so that the plugin runs with the
+// selected profile and receives only the arguments that follow its name.
+func Register(root *cobra.Command, args []string) ([]string, error) {
+	if os.Getenv(DisableEnvVar) != "" {
+		return args, nil
+	}
+	i := cmdline.CommandIndex(root, args)
+	if i < 0 {
+		return args, nil
+	}
+	name := args[i]
+	if name == cobra.ShellCompRequestCmd || name == cobra.ShellCompNoDescRequestCmd {
+		for _, p := range Discover(os.Getenv("PATH")) {
+			if !p.Shadowed && !isBuiltin(root, p.Name) {
+				root.AddCommand(NewCommand(p))
+			}
+		}
+		return args, nil
+	}
+	if isBuiltin(root, name) {
+		return args, nil
+	}
+	p, found := Lookup(os.Getenv("PATH"), name)
+	if !found {
+		return args, nil
+	}
+	root.AddCommand(NewCommand(p))
+	if err := parseRootFlags(root, args[:i]); errors.Is(err, pflag.ErrHelp) {
+		return args, nil // cobra displays the help
+	} else if err != nil {
+		return nil, err
+	}
+	return args[i:], nil
+}
+
+// parseRootFlags sets the root command's flags from the command line arguments that precede the
+// plugin's name, since the plugin's command doesn't parse flags
+func parseRootFlags(root *cobra.Command, args []string) error {
+	flags := pflag.NewFlagSet(root.Name(), pflag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	flags.AddFlagSet(root.PersistentFlags())
+	flags.AddFlagSet(root.Flags())
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return fmt.Errorf("unexpected argument %q before the plugin name", flags.Arg(0))
+	}
+	return nil
+}
+
+// isBuiltin returns true if the root command has a built-in subcommand with the name or alias
+func isBuiltin(root *cobra.Command, name string) bool {
+	if name == "help" || name == "completion" || strings.HasPrefix(name, "__") {
+		return true // added by cobra when executed
+	}
+	for _, c := range root.Commands() {
+		if _, isPlugin := c.Annotations[AnnotationForPlugin]; !isPlugin && (c.Name() == name || c.HasAlias(name)) {
+			return true
+		}
+	}
+	return false
+}
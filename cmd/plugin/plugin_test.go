@@ -0,0 +1,166 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cisco-open/fsoc/cmd/config"
+)
+
+func TestPluginName(t *testing.T) {
+	for _, tc := range []struct {
+		file, goos, name string
+		ok               bool
+	}{
+		{"fsoc-hello", "linux", "hello", true},
+		{"fsoc-hello-world", "darwin", "hello-world", true},
+		{"fsoc-", "linux", "", false},
+		{"kubectl-hello", "linux", "", false},
+		{"fsoc-hello.exe", "windows", "hello", true},
+		{"fsoc-hello.CMD", "windows", "hello", true},
+		{"fsoc-hello.txt", "windows", "", false},
+		{"fsoc-hello", "windows", "", false},
+	} {
+		name, ok := pluginName(tc.file, tc.goos)
+		assert.Equal(t, tc.ok, ok, tc.file)
+		assert.Equal(t, tc.name, name, tc.file)
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugin executables are identified by extension on Windows")
+	}
+	dir1, dir2 := t.TempDir(), t.TempDir()
+	write := func(dir, name string, mode os.FileMode) {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"), mode))
+	}
+	write(dir1, "fsoc-hello", 0755)
+	write(dir1, "fsoc-notexec", 0644)
+	write(dir1, "other", 0755)
+	write(dir2, "fsoc-hello", 0755)
+	write(dir2, "fsoc-version", 0755)
+
+	pathList := dir1 + string(os.PathListSeparator) + "/nonexistent" + string(os.PathListSeparator) + dir2
+	plugins := Discover(pathList)
+	assert.Equal(t, []Plugin{
+		{Name: "hello", Path: filepath.Join(dir1, "fsoc-hello")},
+		{Name: "hello", Path: filepath.Join(dir2, "fsoc-hello"), Shadowed: true},
+		{Name: "version", Path: filepath.Join(dir2, "fsoc-version")},
+	}, plugins)
+
+	p, found := Lookup(pathList, "hello")
+	assert.True(t, found)
+	assert.Equal(t, Plugin{Name: "hello", Path: filepath.Join(dir1, "fsoc-hello")}, p)
+	_, found = Lookup(pathList, "notexec")
+	assert.False(t, found)
+
+	// the plugin that is run is registered, unless a built-in command has the same name
+	newRoot := func() *cobra.Command {
+		root := &cobra.Command{Use: "fsoc"}
+		root.PersistentFlags().String("profile", "", "")
+		root.PersistentFlags().Bool("quiet", false, "")
+		root.AddCommand(&cobra.Command{Use: "version"})
+		return root
+	}
+	t.Setenv("PATH", pathList)
+	root := newRoot()
+	args, err := Register(root, []string{"--profile", "prod", "--quiet", "hello", "--name", "world"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"hello", "--name", "world"}, args)
+	profile, _ := root.PersistentFlags().GetString("profile")
+	assert.Equal(t, "prod", profile)
+	cmd, _, err := root.Find(args)
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir1, "fsoc-hello"), cmd.Annotations[AnnotationForPlugin])
+	assert.Len(t, root.Commands(), 2)
+
+	root = newRoot()
+	_, err = Register(root, []string{"--unknown", "hello"})
+	assert.Error(t, err)
+
+	root = newRoot()
+	args, err = Register(root, []string{"--profile", "prod", "version"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"--profile", "prod", "version"}, args)
+	cmd, _, _ = root.Find([]string{"version"})
+	assert.NotContains(t, cmd.Annotations, AnnotationForPlugin)
+	assert.Len(t, root.Commands(), 1)
+
+	// as for cobra, there is no command after "--"
+	root = newRoot()
+	args, err = Register(root, []string{"--", "hello"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"--", "hello"}, args)
+	assert.Len(t, root.Commands(), 1)
+}
+
+func TestRunWithRootFlags(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test plugin is a shell script")
+	}
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out.txt")
+	script := "#!/bin/sh\necho \"$* $FSOC_PROFILE $FSOC_TENANT $FSOC_TOKEN\" > " + out + "\n"
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "fsoc-hello"), []byte(script), 0755))
+	t.Setenv("PATH", dir)
+
+	configFile := filepath.Join(dir, "config.yaml")
+	assert.NoError(t, os.WriteFile(configFile, []byte(`contexts:
+  - name: dev
+    auth_method: jwt
+    url: https://dev.example.com
+    tenant: t1
+    token: dev-token
+  - name: prod
+    auth_method: jwt
+    url: https://prod.example.com
+    tenant: t2
+    token: prod-token
+current_context: dev
+`), 0600))
+	viper.SetConfigFile(configFile)
+	assert.NoError(t, viper.ReadInConfig())
+	defer viper.Reset()
+
+	// a root command that selects the profile like fsoc's (without traversing the subcommands' flags)
+	root := &cobra.Command{
+		Use: "fsoc",
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			if profile, _ := cmd.Flags().GetString("profile"); profile != "" {
+				restore, err := config.UseProfile(profile)
+				assert.NoError(t, err)
+				t.Cleanup(restore)
+			}
+		},
+	}
+	root.PersistentFlags().String("profile", "", "")
+	args, err := Register(root, []string{"--profile", "prod", "hello", "--profile", "other", "--name", "w"})
+	assert.NoError(t, err)
+	root.SetArgs(args)
+	assert.NoError(t, root.Execute())
+
+	data, err := os.ReadFile(out)
+	assert.NoError(t, err)
+	assert.Equal(t, "--profile other --name w prod t2 prod-token\n", string(data))
+}
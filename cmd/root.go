@@ -17,26 +17,49 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
-	"path"
+	"strings"
 
 	"github.com/apex/log"
 	"github.com/apex/log/handlers/json"
 	"github.com/apex/log/handlers/multi"
+	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 
+	"github.com/cisco-open/fsoc/cmd/audit"
 	"github.com/cisco-open/fsoc/cmd/config"
+	"github.com/cisco-open/fsoc/cmd/iam"
+	"github.com/cisco-open/fsoc/cmd/notify"
+	"github.com/cisco-open/fsoc/cmd/plugin"
+	"github.com/cisco-open/fsoc/cmd/telemetry"
+	"github.com/cisco-open/fsoc/cmd/tips"
 	"github.com/cisco-open/fsoc/cmd/version"
+	"github.com/cisco-open/fsoc/cmdkit/interrupt"
+	"github.com/cisco-open/fsoc/cmdkit/offline"
+	"github.com/cisco-open/fsoc/cmdkit/picker"
+	"github.com/cisco-open/fsoc/cmdkit/progress"
+	"github.com/cisco-open/fsoc/exitcode"
+	"github.com/cisco-open/fsoc/logfile"
 	"github.com/cisco-open/fsoc/logfilter"
+	"github.com/cisco-open/fsoc/output"
+	"github.com/cisco-open/fsoc/platform/api"
 )
 
 var cfgFile string
+
+// outcome of the legacy config migration, reported once logging is set up
+var migratedConfigFile string
+var migrationErr error
 var cfgProfile string
 var outputFormat string
 
+// cancelTimeout releases the context with the deadline set by --timeout, if any
+var cancelTimeout context.CancelFunc
+
 // rootCmd represents the base command when called without any subcommands
 // TODO: replace github link "for more info" with Cisco DevNet link for fsoc once published
 var rootCmd = &cobra.Command{
@@ -65,7 +88,54 @@ NOTE: fsoc is in alpha; breaking changes may occur`,
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute(ctx context.Context) error {
-	return rootCmd.ExecuteContext(ctx)
+	args, err := expandAlias(os.Args[1:])
+	if err != nil {
+		return err
+	}
+	args, err = plugin.Register(rootCmd, args)
+	if err != nil {
+		return err
+	}
+	rootCmd.SetArgs(args)
+	if explainPermissions(args) {
+		return nil
+	}
+	markRunErrors(rootCmd)
+	ctx, stopSignals := interrupt.NotifyContext(ctx)
+	err = rootCmd.ExecuteContext(ctx)
+	err = closeOutputFile(err)
+	if cancelTimeout != nil {
+		cancelTimeout()
+	}
+	stopSignals()
+	interrupt.RunCleanups()
+	tips.Finish(err)
+	notify.Finish(err)
+	telemetry.Finish(err)
+	audit.Finish(err)
+	return err
+}
+
+// ExitCode returns the exit code for an error returned by Execute
+func ExitCode(err error) int {
+	var e *exitcode.Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return exitcode.Usage // errors not returned by the commands are cobra's command line errors
+}
+
+// markRunErrors wraps the errors returned by the commands with their exit codes, distinguishing
+// them from the command line errors detected by cobra
+func markRunErrors(cmd *cobra.Command) {
+	if run := cmd.RunE; run != nil {
+		cmd.RunE = func(cmd *cobra.Command, args []string) error {
+			return exitcode.Wrap(run(cmd, args))
+		}
+	}
+	for _, c := range cmd.Commands() {
+		markRunErrors(c)
+	}
 }
 
 func init() {
@@ -77,10 +147,33 @@ func init() {
 
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", fmt.Sprintf("config file (default is %s)", config.DefaultConfigFile))
 	rootCmd.PersistentFlags().StringVar(&cfgProfile, "profile", "", "access profile (default is current or \"default\")")
-	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", "auto", "output format (auto, table, detail, json, yaml)")
-	rootCmd.PersistentFlags().String("fields", "", "perform specified fields transform/extract JQ expression")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", "auto", "output format (auto, table, detail, json, yaml, jsonl, csv, go-template=TEMPLATE, go-template-file=FILE)")
+	rootCmd.PersistentFlags().String("output-file", "", "write the output to a file instead of stdout, with the output format inferred from its extension (.json, .jsonl, .yaml, .csv) unless -o is specified")
+	rootCmd.PersistentFlags().String("fields", "", "perform specified fields transform/extract JQ expression: a list of fields (e.g., \"id, name:.data.name\") or a jq program run on the whole output")
+	rootCmd.PersistentFlags().String("fields-file", "", "read the --fields JQ expression or program from a file, e.g., for long programs")
+	rootCmd.PersistentFlags().String("distinct", "", "remove duplicate entries, comparing the specified comma-separated fields (or * for entire entries)")
+	rootCmd.PersistentFlags().StringArray("columns", nil, "table column defined as name=JQ expression, evaluated on each row (can be repeated)")
+	rootCmd.PersistentFlags().Bool("flatten", false, "with -o yaml, display each item of a list as a separate YAML document (--- separated), e.g., for yq or kubectl-style tools")
+	rootCmd.PersistentFlags().Bool("wrap", false, "wrap long table cells over multiple lines, instead of truncating them to fit the terminal")
+	rootCmd.PersistentFlags().Bool("no-color", false, "display the output without color (also when the NO_COLOR environment variable is set)")
 	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "Enable detailed output")
-	rootCmd.PersistentFlags().String("log", path.Join(os.TempDir(), "fsoc.log"), "determines the location of the fsoc log file")
+	rootCmd.PersistentFlags().String("log-level", "", fmt.Sprintf("level of the log messages displayed on the console (%s); default warn, or info with --verbose", strings.Join(logfilter.LevelNames, ", ")))
+	rootCmd.PersistentFlags().BoolP("quiet", "q", false, "display only errors, without warnings, progress indicators or tips, e.g., for scripts")
+	rootCmd.MarkFlagsMutuallyExclusive("quiet", "verbose")
+	rootCmd.MarkFlagsMutuallyExclusive("quiet", "log-level")
+	rootCmd.PersistentFlags().Duration("timeout", 0, "maximum time for the command to complete, e.g., 5m; platform API calls still in progress fail with a timeout (default no limit)")
+	rootCmd.PersistentFlags().Bool("no-compression", false, "disable the compression of platform API requests and responses, e.g., when troubleshooting or on fast links")
+	rootCmd.PersistentFlags().Bool(offline.FlagName, false, fmt.Sprintf("guarantee that no network calls are made: commands that need the platform or other network access fail immediately, while commands working from local files and caches proceed (also when %s is set)", offline.EnvVar))
+	rootCmd.PersistentFlags().Duration("max-time", 0, "maximum time to spend fetching paged results, e.g., 30s; output is truncated when exceeded (default no limit)")
+	rootCmd.PersistentFlags().Bool(picker.InteractiveFlagName, false, "prompt with a searchable list for a solution, profile or object missing from the command line, instead of failing (default when running on a terminal; use --interactive=false to disable)")
+	rootCmd.PersistentFlags().Bool(notify.FlagName, false, "show a desktop notification when the command completes, e.g., for long-running commands like solution push --wait")
+	rootCmd.PersistentFlags().String(notify.URLFlagName, "", "post the command's result as JSON to a webhook URL when it completes, e.g., a Slack or Teams incoming webhook")
+	rootCmd.PersistentFlags().String(notify.CommandFlagName, "", "run a shell command when the command completes, with the result as JSON on its stdin and in FSOC_NOTIFY_* environment variables")
+	rootCmd.PersistentFlags().String("log", config.DefaultLogFile(), "determines the location of the fsoc log file")
+	rootCmd.PersistentFlags().Int("log-keep", logfile.DefaultKeep, "number of log files to keep, including the current run's; older runs' logs are kept with suffixes .1, .2, etc.")
+	rootCmd.PersistentFlags().Int64("log-max-size", logfile.DefaultMaxSize, "maximum size of a log file, in bytes, after which it is rotated (0 for no limit)")
+	rootCmd.PersistentFlags().Bool(iam.ExplainPermissionsFlag, false, "show the platform permissions the command requires, instead of executing it")
+	rootCmd.AddCommand(exitCodesHelpTopic())
 	rootCmd.SetOut(os.Stdout)
 	rootCmd.SetErr(os.Stderr)
 	rootCmd.SetIn(os.Stdin)
@@ -92,14 +185,10 @@ func initConfig() {
 		// Use config file from the flag.
 		viper.SetConfigFile(cfgFile)
 	} else {
-		// Find home directory.
-		home, err := os.UserHomeDir()
-		cobra.CheckErr(err)
-
-		// Search config in home directory with name ".fsoc" (without extension).
-		viper.AddConfigPath(home)
+		// move the config from the legacy home directory dotfiles to the XDG config directory, once
+		migratedConfigFile, migrationErr = config.MigrateLegacyConfig()
+		viper.SetConfigFile(config.DefaultConfigFilePath())
 		viper.SetConfigType("yaml")
-		viper.SetConfigName(".fsoc")
 	}
 
 	viper.AutomaticEnv() // read in environment variables that match
@@ -126,24 +215,62 @@ func helperFlagFormatter(fs *pflag.FlagSet) string {
 // before the command's handler is executed
 func preExecHook(cmd *cobra.Command, args []string) {
 	logLocation, _ := cmd.Flags().GetString("log")
-	var file *os.File
-	var cliHandler log.Handler
+	logKeep, _ := cmd.Flags().GetInt("log-keep")
+	logMaxSize, _ := cmd.Flags().GetInt64("log-max-size")
+	quiet, _ := cmd.Flags().GetBool("quiet")
+	if noColor, _ := cmd.Flags().GetBool("no-color"); noColor {
+		color.NoColor = true // fatih/color also honors NO_COLOR
+	} else if !output.EnableANSI() {
+		color.NoColor = true // legacy Windows console
+	}
 
+	// select the console log level: warnings by default, info with --verbose or as set by --log-level
+	consoleLevel := log.WarnLevel
 	if verbose, _ := cmd.Flags().GetBool("verbose"); verbose {
-		cliHandler = logfilter.New(os.Stderr, log.InfoLevel)
+		consoleLevel = log.InfoLevel
+	}
+	if cmd.Flags().Changed("log-level") {
+		name, _ := cmd.Flags().GetString("log-level")
+		level, err := logfilter.ParseLevel(name)
+		if err != nil {
+			_ = cmd.Usage()
+			log.WithField(exitcode.Field, exitcode.Usage).Fatalf("%v", err)
+		}
+		consoleLevel = level
+	}
+	if quiet {
+		consoleLevel = log.ErrorLevel
+	}
+	cliHandler := progress.Handler(logfilter.New(os.Stderr, consoleLevel), consoleLevel)
+	if consoleLevel < log.InfoLevel {
+		log.SetLevel(consoleLevel) // the log file has the same detail as the console
 	} else {
-		cliHandler = logfilter.New(os.Stderr, log.WarnLevel)
+		log.SetLevel(log.InfoLevel)
 	}
-	log.SetLevel(log.InfoLevel)
 
-	_ = os.Truncate(logLocation, 0)
-	file, err := os.Create(logLocation)
-	if err != nil {
-		log.Warnf("failed to create log at %s", logLocation)
-		log.SetHandler(cliHandler)
+	if _, noLogFile := cmd.Annotations[logfile.AnnotationForNoLogFile]; noLogFile {
+		log.SetHandler(multi.New(cliHandler, tips.Handler(), notify.Handler(), telemetry.Handler(), audit.Handler(), outputFileHandler(), interrupt.Handler(), exitcode.Handler()))
+	} else if file, err := logfile.Open(logLocation, logKeep, logMaxSize); err != nil {
+		log.SetHandler(multi.New(cliHandler, tips.Handler(), notify.Handler(), telemetry.Handler(), audit.Handler(), outputFileHandler(), interrupt.Handler(), exitcode.Handler()))
+		log.Warnf("failed to create log at %s: %v", logLocation, err)
 	} else {
 		jsonHandler := json.New(file)
-		log.SetHandler(multi.New(cliHandler, jsonHandler))
+		log.SetHandler(multi.New(cliHandler, jsonHandler, tips.Handler(), notify.Handler(), telemetry.Handler(), audit.Handler(), outputFileHandler(), interrupt.Handler(), exitcode.Handler()))
+	}
+
+	// track the command's outcome for contextual tips (not shown in quiet mode)
+	if !quiet {
+		tips.Start(cmd)
+	}
+	progress.SetQuiet(quiet)
+
+	// track the command's completion for the notifications, if requested
+	notify.Start(cmd)
+
+	if migrationErr != nil {
+		log.Warnf("Failed to migrate the config to %s, keeping %s: %v", config.ConfigHome(), config.LegacyConfigFile, migrationErr)
+	} else if migratedConfigFile != "" {
+		log.Warnf("The fsoc config file has moved from %s to %s", config.LegacyConfigFile, migratedConfigFile)
 	}
 
 	log.WithFields(version.GetVersion()).Info("fsoc version")
@@ -167,12 +294,12 @@ func preExecHook(cmd *cobra.Command, args []string) {
 	bypass := bypassConfig(cmd) || cmd.Name() == "help" || isCompletionCommand(cmd)
 
 	// try to read the config file.and profile
-	err = viper.ReadInConfig()
+	err := viper.ReadInConfig()
 	if err == nil {
 		profile := config.GetCurrentProfileName()
-		exists := config.GetCurrentContext() != nil
+		exists := config.HasCurrentContext()
 		if !exists && !bypass {
-			log.Fatalf("fsoc is not fully configured: missing profile %q; please use \"fsoc config set\" to configure it", profile)
+			log.WithField(exitcode.Field, exitcode.ConfigMissing).Fatalf("fsoc is not fully configured: missing profile %q; please use \"fsoc config set\" to configure it", profile)
 		}
 		log.WithFields(log.Fields{
 			"config_file": viper.ConfigFileUsed(),
@@ -184,9 +311,91 @@ func preExecHook(cmd *cobra.Command, args []string) {
 		if bypass {
 			log.Infof("Unable to read config file (%v), proceeding without a config", err)
 		} else {
-			log.Fatalf("fsoc is not configured, please use \"fsoc config set\" to configure an initial context")
+			log.WithField(exitcode.Field, exitcode.ConfigMissing).Fatalf("fsoc is not configured, please use \"fsoc config set\" to configure an initial context")
 		}
 	}
+
+	// apply the profile's defaults to the flags not given on the command line
+	config.ApplyProfileDefaults(cmd)
+
+	// write the output to a file, if requested, inferring the output format from its extension
+	openOutputFile(cmd)
+
+	// make the command's API calls with its context, canceled when interrupted, limiting their time if requested
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if timeout, _ := cmd.Flags().GetDuration("timeout"); timeout > 0 {
+		ctx, cancelTimeout = context.WithTimeout(ctx, timeout)
+	}
+	api.SetContext(ctx)
+
+	// set the time budget for fetching paged results
+	maxTime, _ := cmd.Flags().GetDuration("max-time")
+	api.SetMaxPagingTime(maxTime)
+
+	// negotiate the compression of API requests and responses, unless disabled
+	noCompression, _ := cmd.Flags().GetBool("no-compression")
+	api.SetCompression(!noCompression)
+
+	// disable all network access in offline mode
+	offlineMode, _ := cmd.Flags().GetBool(offline.FlagName)
+	offline.Set(offlineMode || os.Getenv(offline.EnvVar) != "")
+	if offline.Enabled() {
+		log.Info("Offline mode: network access is disabled")
+	}
+
+	// collect the command's telemetry, if the self-instrumentation or the usage analytics are enabled
+	// in the config file, asking for consent to the usage analytics on the first interactive run
+	telemetry.AskConsent(cmd)
+	telemetry.Start(cmd)
+
+	// record the command in the audit trail, if enabled in the config file
+	audit.Start(cmd, args)
+
+	// experimental commands must be enabled in the profile before use
+	if cmd.Name() != "help" && !isCompletionCommand(cmd) {
+		checkFeatureGate(cmd)
+	}
+}
+
+// checkFeatureGate fails the command if it, or any of its parents, requires an experimental
+// feature that is not enabled in the current profile
+func checkFeatureGate(cmd *cobra.Command) {
+	for c := cmd; c != nil; c = c.Parent() {
+		name, gated := c.Annotations[config.AnnotationForFeature]
+		if gated && !config.IsFeatureEnabled(name) {
+			log.Fatalf("%q is an experimental command that requires the %q feature; enable it with \"fsoc config set %s%s=true\"", c.CommandPath(), name, config.FeatureConfigPrefix, name)
+		}
+	}
+}
+
+// explainPermissions displays the permissions the command requires if --explain-permissions is specified,
+// returning true if it did. This happens before the command is executed, so that required arguments and
+// flags, as well as the config, are not needed.
+func explainPermissions(args []string) bool {
+	explain := false
+	for _, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if arg == "--"+iam.ExplainPermissionsFlag || arg == "--"+iam.ExplainPermissionsFlag+"=true" {
+			explain = true
+		}
+	}
+	if !explain {
+		return false
+	}
+	cmd, flags, err := rootCmd.Find(args)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	if err := cmd.ParseFlags(flags); err != nil {
+		log.Fatalf("%v", err)
+	}
+	iam.ExplainPermissions(cmd)
+	return true
 }
 
 func bypassConfig(cmd *cobra.Command) bool {
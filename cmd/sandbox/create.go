@@ -0,0 +1,168 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sandbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+
+	"github.com/cisco-open/fsoc/cmd/config"
+	"github.com/cisco-open/fsoc/output"
+)
+
+var sandboxIdRegexp = regexp.MustCompile(`^[\w.-]+$`)
+
+// secretFileContents is the service principal credentials file of a sandbox profile, in the format
+// of the credentials files downloaded from the platform
+type secretFileContents struct {
+	TenantID string `json:"Tenant ID"`
+	TokenURL string `json:"Token URL,omitempty"`
+	ClientID string `json:"Client ID"`
+	Secret   string `json:"Secret"`
+}
+
+func newCmdCreate() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create a sandbox tenant and a profile for it",
+		Long: `Request an ephemeral sandbox tenant from the developer program and provision an fsoc profile for it.
+
+The profile is named "sandbox" unless specified with --name; use --use to make it the current profile,
+or select it with --profile. The sandbox is available until it expires, as requested with --duration
+(the developer program may cap it), or until deleted with "fsoc sandbox delete".`,
+		Example: `  fsoc sandbox create
+  fsoc sandbox create --name trial --duration 2h --use`,
+		Args: cobra.NoArgs,
+		Run:  createSandbox,
+	}
+
+	cmd.Flags().String("name", "sandbox", "Name of the profile to create for the sandbox")
+	cmd.Flags().Duration("duration", 4*time.Hour, "Requested lifetime of the sandbox")
+	cmd.Flags().Bool("use", false, "Make the sandbox profile the current profile")
+
+	return bypassConfig(cmd)
+}
+
+func createSandbox(cmd *cobra.Command, args []string) {
+	name, _ := cmd.Flags().GetString("name")
+	duration, _ := cmd.Flags().GetDuration("duration")
+	use, _ := cmd.Flags().GetBool("use")
+	if duration < time.Minute {
+		log.Fatalf("The sandbox duration must be at least 1m, found %v", duration)
+	}
+
+	// only one sandbox can be active at a time
+	records := pruneAndReport(cmd)
+	if len(records) > 0 {
+		r := records[0]
+		log.Fatalf("Sandbox %q (profile %q) is active until %v; only one sandbox can be active at a time, delete it first with \"fsoc sandbox delete\"",
+			r.ID, r.Profile, r.ExpiresAt.Local().Format(time.RFC3339))
+	}
+	if config.HasContext(name) {
+		log.Fatalf("Profile %q exists already; use --name to choose another profile name for the sandbox", name)
+	}
+
+	programURL := programURL(cmd)
+	log.WithFields(log.Fields{"program": programURL, "duration": duration}).Info("Requesting a sandbox")
+	sandbox, err := newProgramClient(programURL).create(&sandboxRequest{
+		Name:     name,
+		Duration: fmt.Sprintf("PT%dM", duration/time.Minute),
+	})
+	if err != nil {
+		log.Fatalf("Failed to create a sandbox: %v", err)
+	}
+	if !sandboxIdRegexp.MatchString(sandbox.ID) {
+		log.Fatalf("The developer program returned an invalid sandbox ID %q", sandbox.ID)
+	}
+
+	// provision the profile: service principal if the sandbox comes with credentials, OAuth login otherwise
+	record := sandboxRecord{
+		ID:         sandbox.ID,
+		Profile:    name,
+		ProgramURL: programURL,
+		URL:        sandbox.URL,
+		Tenant:     sandbox.TenantID,
+		ExpiresAt:  sandbox.ExpiresAt,
+		Token:      sandbox.Token,
+	}
+	ctx := config.Context{Name: name, URL: sandbox.URL, Tenant: sandbox.TenantID, AuthMethod: config.AuthMethodOAuth}
+	if sandbox.Credentials != nil {
+		record.SecretFile, err = writeSecretFile(sandbox)
+		if err != nil {
+			if err := teardown(&record); err != nil {
+				log.Warnf("Failed to tear down sandbox %q: %v", record.ID, err)
+			}
+			log.Fatalf("Failed to save the sandbox credentials: %v", err)
+		}
+		ctx.AuthMethod = config.AuthMethodServicePrincipal
+		ctx.SecretFile = record.SecretFile
+	}
+	config.UpsertContext(&ctx)
+	mustSaveRegistry(append(records, record))
+	if use {
+		if err := config.SetCurrentContext(name); err != nil {
+			log.Fatalf("Failed to make the sandbox profile current: %v", err)
+		}
+	}
+
+	message := fmt.Sprintf("Created sandbox %q at %s, with profile %q", sandbox.ID, sandbox.URL, name)
+	if !sandbox.ExpiresAt.IsZero() {
+		message += fmt.Sprintf("; it expires at %v", sandbox.ExpiresAt.Local().Format(time.RFC3339))
+	}
+	output.PrintCmdStatus(cmd, message+".\n")
+	if ctx.AuthMethod == config.AuthMethodOAuth {
+		output.PrintCmdStatus(cmd, fmt.Sprintf("Log into the sandbox with \"fsoc login --profile %s\".\n", name))
+	} else if !use {
+		output.PrintCmdStatus(cmd, fmt.Sprintf("Use it with --profile %s, or make it current with \"fsoc config use --profile %s\".\n", name, name))
+	}
+}
+
+// writeSecretFile saves the sandbox credentials into a private file in the fsoc config directory
+func writeSecretFile(sandbox *sandboxResponse) (string, error) {
+	b, err := json.MarshalIndent(secretFileContents{
+		TenantID: sandbox.TenantID,
+		TokenURL: sandbox.Credentials.TokenURL,
+		ClientID: sandbox.Credentials.ClientID,
+		Secret:   sandbox.Credentials.ClientSecret,
+	}, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(config.GetConfigDir(), 0700); err != nil {
+		return "", err
+	}
+	path := filepath.Join(config.GetConfigDir(), "sandbox-"+sandbox.ID+".json")
+	return path, os.WriteFile(path, b, 0600)
+}
+
+// pruneAndReport tears down the expired sandboxes, reporting each, and returns the remaining ones
+func pruneAndReport(cmd *cobra.Command) []sandboxRecord {
+	records, removed := pruneExpired(mustLoadRegistry(), time.Now())
+	if len(removed) == 0 {
+		return records
+	}
+	mustSaveRegistry(records)
+	for _, r := range removed {
+		output.PrintCmdStatus(cmd, fmt.Sprintf("Sandbox %q expired; removed profile %q.\n", r.ID, r.Profile))
+	}
+	return records
+}
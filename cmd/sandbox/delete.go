@@ -0,0 +1,71 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sandbox
+
+import (
+	"fmt"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+
+	"github.com/cisco-open/fsoc/output"
+)
+
+func newCmdDelete() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "delete [PROFILE]",
+		Short: "Tear down a sandbox and remove its profile",
+		Long: `Tear down a sandbox tenant before it expires and remove its profile. The sandbox is selected by the name
+of its profile, which can be omitted if there is only one sandbox.`,
+		Example: `  fsoc sandbox delete
+  fsoc sandbox delete trial`,
+		Aliases: []string{"del"},
+		Args:    cobra.MaximumNArgs(1),
+		Run:     deleteSandbox,
+	}
+
+	return bypassConfig(cmd)
+}
+
+func deleteSandbox(cmd *cobra.Command, args []string) {
+	records := pruneAndReport(cmd)
+
+	index := -1
+	switch {
+	case len(args) == 1:
+		for i, r := range records {
+			if r.Profile == args[0] {
+				index = i
+			}
+		}
+		if index < 0 {
+			log.Fatalf("There is no active sandbox with profile %q", args[0])
+		}
+	case len(records) == 1:
+		index = 0
+	case len(records) == 0:
+		output.PrintCmdStatus(cmd, "There are no active sandboxes.\n")
+		return
+	default:
+		log.Fatalf("There are %d active sandboxes; specify the profile of the one to delete", len(records))
+	}
+
+	record := records[index]
+	if err := teardown(&record); err != nil {
+		log.Fatalf("Failed to tear down sandbox %q: %v", record.ID, err)
+	}
+	mustSaveRegistry(append(records[:index], records[index+1:]...))
+	output.PrintCmdStatus(cmd, fmt.Sprintf("Deleted sandbox %q and removed profile %q.\n", record.ID, record.Profile))
+}
@@ -0,0 +1,64 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sandbox
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cisco-open/fsoc/output"
+)
+
+// sandboxInfo describes a sandbox, as displayed
+type sandboxInfo struct {
+	ID        string    `json:"id"`
+	Profile   string    `json:"profile"`
+	URL       string    `json:"url"`
+	Tenant    string    `json:"tenant,omitempty"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+func newCmdList() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "list",
+		Short:   "List the active sandboxes",
+		Long:    `List the active sandbox tenants created with "fsoc sandbox create" and their profiles. Expired sandboxes are torn down.`,
+		Example: `  fsoc sandbox list`,
+		Aliases: []string{"ls"},
+		Args:    cobra.NoArgs,
+		Run:     listSandboxes,
+	}
+
+	return bypassConfig(cmd)
+}
+
+func listSandboxes(cmd *cobra.Command, args []string) {
+	records := pruneAndReport(cmd)
+
+	items := []sandboxInfo{}
+	var lines [][]string
+	for _, r := range records {
+		items = append(items, sandboxInfo{ID: r.ID, Profile: r.Profile, URL: r.URL, Tenant: r.Tenant, ExpiresAt: r.ExpiresAt})
+		lines = append(lines, []string{r.Profile, r.ID, r.URL, r.Tenant, r.ExpiresAt.Local().Format(time.RFC3339)})
+	}
+	output.PrintCmdOutputCustom(cmd, struct {
+		Items []sandboxInfo `json:"items"`
+		Total int           `json:"total"`
+	}{Items: items, Total: len(items)}, &output.Table{
+		Headers: []string{"Profile", "ID", "URL", "Tenant", "Expires"},
+		Lines:   lines,
+	})
+}
@@ -0,0 +1,183 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sandbox
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cisco-open/fsoc/cmdkit/offline"
+)
+
+// requestTimeout limits the duration of the developer program API calls
+const requestTimeout = 2 * time.Minute
+
+// errSandboxNotFound is returned when deleting a sandbox that the developer program no longer has
+var errSandboxNotFound = errors.New("sandbox not found")
+
+// sandboxRequest is the body of a request for a new sandbox
+type sandboxRequest struct {
+	Name     string `json:"name,omitempty"`
+	Duration string `json:"duration,omitempty"` // ISO 8601, e.g., PT4H; capped by the developer program
+}
+
+// sandboxCredentials are the service principal credentials of a sandbox tenant
+type sandboxCredentials struct {
+	ClientID     string `json:"clientId"`
+	ClientSecret string `json:"clientSecret"`
+	TokenURL     string `json:"tokenUrl,omitempty"`
+}
+
+// sandboxResponse describes a sandbox tenant provided by the developer program
+type sandboxResponse struct {
+	ID          string              `json:"id"`
+	URL         string              `json:"url"`
+	TenantID    string              `json:"tenantId"`
+	ExpiresAt   time.Time           `json:"expiresAt"`
+	Token       string              `json:"token"` // authorizes tearing down the sandbox
+	Credentials *sandboxCredentials `json:"credentials,omitempty"`
+}
+
+// rateLimitError is returned when the developer program rejects a request for a new sandbox because
+// of its rate limit
+type rateLimitError struct {
+	RetryAfter time.Duration // zero if not known
+}
+
+func (e *rateLimitError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("sandbox requests are rate limited, please try again in %v", e.RetryAfter.Round(time.Second))
+	}
+	return "sandbox requests are rate limited, please try again later"
+}
+
+// programClient calls the sandbox API of the developer program
+type programClient struct {
+	baseURL string
+	client  *http.Client
+}
+
+func newProgramClient(baseURL string) *programClient {
+	return &programClient{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// create requests a new sandbox
+func (p *programClient) create(req *sandboxRequest) (*sandboxResponse, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	resp, respBytes, err := p.do(http.MethodPost, p.baseURL+"/sandboxes", body, "")
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusNotImplemented:
+		return nil, fmt.Errorf("sandboxes are not available from the developer program at %s", p.baseURL)
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return nil, &rateLimitError{RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())}
+	case resp.StatusCode/100 != 2:
+		return nil, responseError(resp, respBytes)
+	}
+
+	var sandbox sandboxResponse
+	if err := json.Unmarshal(respBytes, &sandbox); err != nil {
+		return nil, fmt.Errorf("failed to parse the sandbox description: %w", err)
+	}
+	if sandbox.ID == "" || sandbox.URL == "" {
+		return nil, fmt.Errorf("the developer program returned an incomplete sandbox description")
+	}
+	return &sandbox, nil
+}
+
+// delete tears down a sandbox, using the token returned when it was created
+func (p *programClient) delete(id string, token string) error {
+	resp, respBytes, err := p.do(http.MethodDelete, p.baseURL+"/sandboxes/"+id, nil, token)
+	if err != nil {
+		return err
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return errSandboxNotFound
+	case resp.StatusCode/100 != 2:
+		return responseError(resp, respBytes)
+	}
+	return nil
+}
+
+func (p *programClient) do(method string, url string, body []byte, token string) (*http.Response, []byte, error) {
+	if err := offline.Check("Calling the developer program"); err != nil {
+		return nil, nil, err
+	}
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create a request for %q: %w", url, err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s request to %q failed: %w", method, url, err)
+	}
+	defer resp.Body.Close()
+	respBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed reading the response to %s %q: %w", method, url, err)
+	}
+	return resp, respBytes, nil
+}
+
+// responseError returns an error for a failed request, with the message from the response, if any
+func responseError(resp *http.Response, respBytes []byte) error {
+	var problem struct {
+		Title  string `json:"title"`
+		Detail string `json:"detail"`
+	}
+	if json.Unmarshal(respBytes, &problem) == nil && (problem.Title != "" || problem.Detail != "") {
+		return fmt.Errorf("developer program request failed, status %q: %s", resp.Status, strings.TrimSpace(problem.Title+" "+problem.Detail))
+	}
+	return fmt.Errorf("developer program request failed, status %q", resp.Status)
+}
+
+// parseRetryAfter parses the value of a Retry-After header, in seconds or as an HTTP date, returning
+// zero if it is missing or invalid
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
@@ -0,0 +1,85 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sandbox
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProgramClient(t *testing.T) {
+	var rateLimited bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/sandboxes":
+			if rateLimited {
+				w.Header().Set("Retry-After", "120")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			var req sandboxRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			assert.Equal(t, sandboxRequest{Name: "trial", Duration: "PT60M"}, req)
+			_, _ = w.Write([]byte(`{"id":"sb1","url":"https://sb1.example.com","tenantId":"t1","expiresAt":"2023-06-01T10:00:00Z","token":"tok",
+				"credentials":{"clientId":"c","clientSecret":"s"}}`))
+		case r.Method == http.MethodDelete && r.URL.Path == "/sandboxes/sb1":
+			assert.Equal(t, "Bearer tok", r.Header.Get("Authorization"))
+		case r.Method == http.MethodDelete:
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"title":"Bad request","detail":"unexpected"}`))
+		}
+	}))
+	defer server.Close()
+	client := newProgramClient(server.URL + "/")
+
+	sandbox, err := client.create(&sandboxRequest{Name: "trial", Duration: "PT60M"})
+	require.NoError(t, err)
+	assert.Equal(t, "sb1", sandbox.ID)
+	assert.Equal(t, "t1", sandbox.TenantID)
+	assert.Equal(t, time.Date(2023, 6, 1, 10, 0, 0, 0, time.UTC), sandbox.ExpiresAt)
+	assert.Equal(t, &sandboxCredentials{ClientID: "c", ClientSecret: "s"}, sandbox.Credentials)
+
+	rateLimited = true
+	_, err = client.create(&sandboxRequest{Name: "trial", Duration: "PT60M"})
+	var rateErr *rateLimitError
+	require.ErrorAs(t, err, &rateErr)
+	assert.Equal(t, 2*time.Minute, rateErr.RetryAfter)
+
+	assert.NoError(t, client.delete("sb1", "tok"))
+	assert.ErrorIs(t, client.delete("sb2", "tok"), errSandboxNotFound)
+
+	_, err = newProgramClient(server.URL + "/other").create(&sandboxRequest{})
+	assert.ErrorContains(t, err, "Bad request unexpected")
+	server.Config.Handler = http.NotFoundHandler()
+	_, err = client.create(&sandboxRequest{})
+	assert.ErrorContains(t, err, "not available")
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2023, 6, 1, 10, 0, 0, 0, time.UTC)
+	assert.Equal(t, 30*time.Second, parseRetryAfter("30", now))
+	assert.Equal(t, 90*time.Second, parseRetryAfter("Thu, 01 Jun 2023 10:01:30 GMT", now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("Thu, 01 Jun 2023 09:00:00 GMT", now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("", now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("soon", now))
+}
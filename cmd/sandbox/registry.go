@@ -0,0 +1,133 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sandbox
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/apex/log"
+
+	"github.com/cisco-open/fsoc/cmd/config"
+)
+
+// registryFileName is the file, in the fsoc config directory, that keeps track of the sandboxes
+const registryFileName = "sandboxes.json"
+
+// sandboxRecord keeps track of a sandbox and the profile provisioned for it
+type sandboxRecord struct {
+	ID         string    `json:"id"`
+	Profile    string    `json:"profile"`
+	ProgramURL string    `json:"programUrl"`
+	URL        string    `json:"url"`
+	Tenant     string    `json:"tenant,omitempty"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+	Token      string    `json:"token,omitempty"`
+	SecretFile string    `json:"secretFile,omitempty"`
+}
+
+func (r *sandboxRecord) expired(now time.Time) bool {
+	return !r.ExpiresAt.IsZero() && !now.Before(r.ExpiresAt)
+}
+
+func registryPath() string {
+	return filepath.Join(config.GetConfigDir(), registryFileName)
+}
+
+// loadRegistry reads the sandbox records; a missing file is an empty registry
+func loadRegistry() ([]sandboxRecord, error) {
+	b, err := os.ReadFile(registryPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var records []sandboxRecord
+	if err := json.Unmarshal(b, &records); err != nil {
+		return nil, fmt.Errorf("failed to parse %q: %w", registryPath(), err)
+	}
+	return records, nil
+}
+
+// saveRegistry writes the sandbox records; the file contains the sandbox tokens and is private
+func saveRegistry(records []sandboxRecord) error {
+	if err := os.MkdirAll(config.GetConfigDir(), 0700); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(registryPath(), b, 0600)
+}
+
+// mustLoadRegistry reads the sandbox records, failing the command if they can't be read
+func mustLoadRegistry() []sandboxRecord {
+	records, err := loadRegistry()
+	if err != nil {
+		log.Fatalf("Failed to read the sandbox list: %v", err)
+	}
+	return records
+}
+
+// mustSaveRegistry writes the sandbox records, failing the command if they can't be written
+func mustSaveRegistry(records []sandboxRecord) {
+	if err := saveRegistry(records); err != nil {
+		log.Fatalf("Failed to update the sandbox list: %v", err)
+	}
+}
+
+// teardown deletes a sandbox from the developer program (tolerating sandboxes that are gone already)
+// and removes its profile and credentials file
+func teardown(r *sandboxRecord) error {
+	if r.Token != "" {
+		err := newProgramClient(r.ProgramURL).delete(r.ID, r.Token)
+		if err != nil && !errors.Is(err, errSandboxNotFound) {
+			return err
+		}
+	}
+	config.DeleteContext(r.Profile)
+	if r.SecretFile != "" {
+		if err := os.Remove(r.SecretFile); err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Warnf("Failed to remove the sandbox credentials file %q: %v", r.SecretFile, err)
+		}
+	}
+	return nil
+}
+
+// pruneExpired tears down the expired sandboxes, returning the remaining records. Failures to reach the
+// developer program are logged, keeping the record to retry later.
+func pruneExpired(records []sandboxRecord, now time.Time) ([]sandboxRecord, []sandboxRecord) {
+	var remaining, removed []sandboxRecord
+	for _, r := range records {
+		if !r.expired(now) {
+			remaining = append(remaining, r)
+			continue
+		}
+		if err := teardown(&r); err != nil {
+			log.Warnf("Failed to tear down expired sandbox %q: %v", r.ID, err)
+			remaining = append(remaining, r)
+			continue
+		}
+		log.WithFields(log.Fields{"sandbox": r.ID, "profile": r.Profile}).Info("Tore down expired sandbox")
+		removed = append(removed, r)
+	}
+	return remaining, removed
+}
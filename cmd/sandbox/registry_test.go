@@ -0,0 +1,42 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sandbox
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	t.Setenv("FSOC_CONFIG_DIR", t.TempDir())
+
+	records, err := loadRegistry()
+	require.NoError(t, err)
+	assert.Empty(t, records)
+
+	expires := time.Date(2023, 6, 1, 10, 0, 0, 0, time.UTC)
+	saved := []sandboxRecord{{ID: "sb1", Profile: "sandbox", ProgramURL: "https://program", URL: "https://sb1", ExpiresAt: expires, Token: "tok"}}
+	require.NoError(t, saveRegistry(saved))
+	records, err = loadRegistry()
+	require.NoError(t, err)
+	assert.Equal(t, saved, records)
+
+	assert.False(t, records[0].expired(expires.Add(-time.Second)))
+	assert.True(t, records[0].expired(expires))
+	assert.False(t, (&sandboxRecord{}).expired(expires), "sandboxes without expiration don't expire")
+}
@@ -0,0 +1,74 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sandbox provides commands to obtain ephemeral sandbox tenants from the developer program,
+// with a profile provisioned for each
+package sandbox
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cisco-open/fsoc/cmd/config"
+)
+
+// DefaultProgramURL is the developer program endpoint that provides the sandbox tenants
+const DefaultProgramURL = "https://developer.observe.appdynamics.com/sandbox/v1"
+
+// programURLEnvVar is the environment variable that overrides the developer program endpoint
+const programURLEnvVar = "FSOC_SANDBOX_URL"
+
+func NewSubCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sandbox",
+		Short: "Manage ephemeral sandbox tenants",
+		Long: `Obtain an ephemeral sandbox tenant from the developer program, where available, to try fsoc and develop
+solutions without a tenant of your own. Each sandbox gets its own fsoc profile, which is removed when the
+sandbox is deleted or expires.
+
+Sandboxes are rate-limited: only one sandbox can be active at a time, and the developer program limits how
+often sandboxes can be requested. Expired sandboxes are torn down, and their profiles removed, by the
+sandbox commands.`,
+		Example: `  fsoc sandbox create --use
+  fsoc sandbox list
+  fsoc sandbox delete`,
+		TraverseChildren: true,
+	}
+
+	cmd.PersistentFlags().String("program-url", "", "URL of the developer program's sandbox API (default "+DefaultProgramURL+" or $"+programURLEnvVar+")")
+
+	cmd.AddCommand(newCmdCreate())
+	cmd.AddCommand(newCmdList())
+	cmd.AddCommand(newCmdDelete())
+
+	return cmd
+}
+
+// programURL returns the developer program endpoint to use
+func programURL(cmd *cobra.Command) string {
+	if url, _ := cmd.Flags().GetString("program-url"); url != "" {
+		return url
+	}
+	if url := os.Getenv(programURLEnvVar); url != "" {
+		return url
+	}
+	return DefaultProgramURL
+}
+
+// bypassConfig marks a sandbox command as not requiring a current profile
+func bypassConfig(cmd *cobra.Command) *cobra.Command {
+	cmd.Annotations = map[string]string{config.AnnotationForConfigBypass: ""}
+	return cmd
+}
@@ -0,0 +1,172 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package shellinit provides the command that generates the shell integration of fsoc for the prompt
+package shellinit
+
+import (
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+
+	"github.com/cisco-open/fsoc/cmd/config"
+	"github.com/cisco-open/fsoc/output"
+)
+
+// stateFilePlaceholder is replaced with the quoted path of the prompt state file in the snippets
+const stateFilePlaceholder = "@STATE_FILE@"
+
+// promptFunction is the name of the shell function that prints the current profile for the prompt
+const promptFunction = "fsoc_ps1"
+
+type shell struct {
+	function string // defines the prompt function
+	hook     string // adds the prompt function to the prompt
+	quote    func(string) string
+}
+
+var shells = map[string]shell{
+	"bash": {function: posixFunction, hook: bashHook, quote: posixQuote},
+	"zsh":  {function: posixFunction, hook: zshHook, quote: posixQuote},
+	"fish": {function: fishFunction, hook: fishHook, quote: fishQuote},
+}
+
+func NewSubCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "shell-init bash|zsh|fish",
+		Short: "Generate the shell integration that displays the current profile in the prompt",
+		Long: `Generate a shell snippet that displays the current fsoc profile and the host of its tenant in the
+shell prompt, e.g., "(fsoc:prod@acme.observe.appdynamics.com) ". Load it from the shell's startup file.
+
+The snippet defines the ` + promptFunction + ` function, which reads the prompt state file that fsoc updates whenever
+the current profile of the default config file changes (e.g., with "fsoc use"), so that displaying the
+prompt doesn't run fsoc. The function is added to the beginning of the prompt unless --no-prompt is
+specified, in which case it can be placed in a custom prompt instead.
+
+The prompt state file (in the fsoc state directory) has "profile", "tenant" and "host" lines in the
+key=value format, for other prompt frameworks to use.`,
+		Example: `  # bash: add to ~/.bashrc
+  eval "$(fsoc shell-init bash)"
+  # zsh: add to ~/.zshrc
+  eval "$(fsoc shell-init zsh)"
+  # fish: add to ~/.config/fish/config.fish
+  fsoc shell-init fish | source
+  # Define the function only, to use it in a custom prompt as $(fsoc_ps1)
+  eval "$(fsoc shell-init bash --no-prompt)"`,
+		Args:        cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+		ValidArgs:   []string{"bash", "zsh", "fish"},
+		Annotations: map[string]string{config.AnnotationForConfigBypass: ""},
+		Run:         shellInit,
+	}
+	cmd.Flags().Bool("no-prompt", false, "Only define the "+promptFunction+" function, without adding it to the prompt")
+	return cmd
+}
+
+func shellInit(cmd *cobra.Command, args []string) {
+	sh := shells[args[0]]
+	noPrompt, _ := cmd.Flags().GetBool("no-prompt")
+
+	// the state file may not exist yet, e.g., if the profile hasn't been switched since fsoc was upgraded
+	if err := config.RefreshPromptState(); err != nil {
+		log.Warnf("Failed to update the shell prompt state: %v", err)
+	}
+
+	snippet := sh.function
+	if !noPrompt {
+		snippet += sh.hook
+	}
+	output.PrintCmdData(cmd, strings.ReplaceAll(snippet, stateFilePlaceholder, sh.quote(config.PromptStateFile())))
+}
+
+// posixQuote quotes a string for bash and zsh
+func posixQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// fishQuote quotes a string for fish
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+}
+
+const posixFunction = `# fsoc shell integration: displays the current fsoc profile in the prompt
+_fsoc_ps1_state_file=@STATE_FILE@
+fsoc_ps1() {
+  local key value profile="" host=""
+  [ -r "$_fsoc_ps1_state_file" ] || return 0
+  while IFS='=' read -r key value; do
+    case "$key" in
+      profile) profile="$value" ;;
+      host) host="$value" ;;
+    esac
+  done < "$_fsoc_ps1_state_file"
+  [ -n "$profile" ] || return 0
+  if [ -n "$host" ]; then
+    printf '(fsoc:%s@%s) ' "$profile" "$host"
+  else
+    printf '(fsoc:%s) ' "$profile"
+  fi
+}
+`
+
+const bashHook = `case "$PS1" in
+  *fsoc_ps1*) ;;
+  *) PS1='$(fsoc_ps1)'"$PS1" ;;
+esac
+`
+
+const zshHook = `setopt PROMPT_SUBST
+case "$PROMPT" in
+  *fsoc_ps1*) ;;
+  *) PROMPT='$(fsoc_ps1)'"$PROMPT" ;;
+esac
+`
+
+const fishFunction = `# fsoc shell integration: displays the current fsoc profile in the prompt
+set -g _fsoc_ps1_state_file @STATE_FILE@
+function fsoc_ps1 --description 'Print the current fsoc profile for the prompt'
+    test -r $_fsoc_ps1_state_file; or return 0
+    set -l profile
+    set -l host
+    while read -l line
+        set -l kv (string split -m 1 = -- $line)
+        switch $kv[1]
+            case profile
+                set profile $kv[2]
+            case host
+                set host $kv[2]
+        end
+    end <$_fsoc_ps1_state_file
+    test -n "$profile"; or return 0
+    if test -n "$host"
+        printf '(fsoc:%s@%s) ' $profile $host
+    else
+        printf '(fsoc:%s) ' $profile
+    end
+end
+`
+
+const fishHook = `if functions -q fish_prompt; and not functions -q _fsoc_original_fish_prompt
+    functions -c fish_prompt _fsoc_original_fish_prompt
+    function _fsoc_restore_status
+        return $argv[1]
+    end
+    function fish_prompt
+        set -l last_status $status
+        fsoc_ps1
+        _fsoc_restore_status $last_status
+        _fsoc_original_fish_prompt
+    end
+end
+`
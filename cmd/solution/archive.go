@@ -0,0 +1,260 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package solution
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"errors"
+	"hash/crc32"
+	"io"
+	"os"
+	"runtime"
+	"unicode/utf8"
+
+	"github.com/cisco-open/fsoc/cmdkit/interrupt"
+)
+
+// spoolMemoryLimit is the size of compressed data kept in memory for a single archive entry;
+// larger entries are spooled to a temporary file, which bounds the memory used while archiving
+const spoolMemoryLimit = 4 << 20
+
+var errArchiveCanceled = errors.New("archiving canceled")
+
+// archiveEntry is a file or directory to be added to an archive; its contents are compressed
+// by a worker and the result delivered on the result channel
+type archiveEntry struct {
+	name   string                        // name in the archive
+	isDir  bool                          // directory entries have no contents
+	open   func() (io.ReadCloser, error) // source of the entry's contents
+	result chan compressedEntry          // buffered, receives exactly one value
+}
+
+// compressedEntry is the deflated contents of an archive entry
+type compressedEntry struct {
+	header *zip.FileHeader
+	data   *spool
+	err    error
+}
+
+// archivePipeline writes a zip archive using a pipeline: entries are read and compressed
+// concurrently by a pool of workers and written to the archive in the order they were added.
+// The number of entries in flight is bounded, as is the memory used for each of them.
+type archivePipeline struct {
+	ordered chan *archiveEntry // entries in archive order, consumed by the writer
+	jobs    chan *archiveEntry // entries to compress, consumed by the workers
+	done    chan struct{}      // closed by the writer when it stops early due to an error
+	written chan error         // the writer's outcome
+}
+
+// newArchivePipeline starts the workers and the writer of an archive pipeline writing into w.
+// If workers is not positive, one worker per CPU is started.
+func newArchivePipeline(w io.Writer, workers int) *archivePipeline {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	p := &archivePipeline{
+		ordered: make(chan *archiveEntry, 2*workers),
+		jobs:    make(chan *archiveEntry),
+		done:    make(chan struct{}),
+		written: make(chan error, 1),
+	}
+	for i := 0; i < workers; i++ {
+		go p.compressor()
+	}
+	go p.writer(zip.NewWriter(w))
+	return p
+}
+
+// add queues an entry to be archived, blocking while too many entries are in flight.
+// It returns errArchiveCanceled if the writer has stopped due to an error.
+func (p *archivePipeline) add(e *archiveEntry) error {
+	e.result = make(chan compressedEntry, 1)
+	select {
+	case p.ordered <- e:
+	case <-p.done:
+		return errArchiveCanceled
+	}
+	if e.isDir {
+		e.result <- compressedEntry{header: &zip.FileHeader{Name: e.name + "/"}}
+		return nil
+	}
+	select {
+	case p.jobs <- e:
+		return nil
+	case <-p.done:
+		e.result <- compressedEntry{err: errArchiveCanceled}
+		return errArchiveCanceled
+	}
+}
+
+// close waits for all entries to be written and finalizes the archive. If err is not nil
+// (e.g., because adding the entries failed), the archive is abandoned and err is returned.
+func (p *archivePipeline) close(err error) error {
+	close(p.jobs)
+	close(p.ordered)
+	writeErr := <-p.written
+	if err != nil && !errors.Is(err, errArchiveCanceled) {
+		return err
+	}
+	return writeErr
+}
+
+func (p *archivePipeline) compressor() {
+	for e := range p.jobs {
+		e.result <- compressEntry(e)
+	}
+}
+
+func (p *archivePipeline) writer(zw *zip.Writer) {
+	var err error
+	for e := range p.ordered {
+		r := <-e.result
+		if err == nil {
+			err = r.err
+			if err == nil {
+				err = writeCompressedEntry(zw, r)
+			}
+			if err != nil {
+				close(p.done) // stop producing entries; keep draining the queued ones
+			}
+		}
+		if r.data != nil {
+			r.data.Close()
+		}
+	}
+	if err == nil {
+		err = zw.Close()
+	}
+	p.written <- err
+}
+
+// compressEntry reads and deflates the contents of an entry
+func compressEntry(e *archiveEntry) compressedEntry {
+	src, err := e.open()
+	if err != nil {
+		return compressedEntry{err: err}
+	}
+	defer src.Close()
+
+	data := &spool{}
+	fw, err := flate.NewWriter(data, flate.DefaultCompression)
+	if err != nil {
+		return compressedEntry{err: err}
+	}
+	crc := crc32.NewIEEE()
+	size, err := io.Copy(io.MultiWriter(fw, crc), src)
+	if err == nil {
+		err = fw.Close()
+	}
+	if err != nil {
+		data.Close()
+		return compressedEntry{err: err}
+	}
+
+	header := &zip.FileHeader{
+		Name:               e.name,
+		Method:             zip.Deflate,
+		CRC32:              crc.Sum32(),
+		CompressedSize64:   uint64(data.size),
+		UncompressedSize64: uint64(size),
+	}
+	return compressedEntry{header: header, data: data}
+}
+
+func writeCompressedEntry(zw *zip.Writer, r compressedEntry) error {
+	if !isASCII(r.header.Name) && utf8.ValidString(r.header.Name) {
+		r.header.Flags |= 0x800 // UTF-8 name, as set by zip.Writer.CreateHeader
+	}
+	if r.data == nil { // directory
+		_, err := zw.CreateHeader(r.header)
+		return err
+	}
+	w, err := zw.CreateRaw(r.header)
+	if err != nil {
+		return err
+	}
+	src, err := r.data.reader()
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, src)
+	return err
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// spool buffers data in memory up to spoolMemoryLimit and in a temporary file beyond it
+type spool struct {
+	buf     bytes.Buffer
+	file    *os.File
+	size    int64
+	cleanup func() // removes the temporary file, also if fsoc is interrupted
+}
+
+func (s *spool) Write(p []byte) (int, error) {
+	if s.file == nil && s.buf.Len()+len(p) > spoolMemoryLimit {
+		f, err := os.CreateTemp("", "fsoc-archive-")
+		if err != nil {
+			return 0, err
+		}
+		s.file = f
+		s.cleanup = interrupt.OnCleanup(func() {
+			f.Close()
+			os.Remove(f.Name())
+		})
+		if _, err := s.buf.WriteTo(f); err != nil {
+			return 0, err
+		}
+	}
+	var n int
+	var err error
+	if s.file != nil {
+		n, err = s.file.Write(p)
+	} else {
+		n, err = s.buf.Write(p)
+	}
+	s.size += int64(n)
+	return n, err
+}
+
+// reader returns a reader of the spooled data; it can be called only once
+func (s *spool) reader() (io.Reader, error) {
+	if s.file == nil {
+		return &s.buf, nil
+	}
+	if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return s.file, nil
+}
+
+// Close releases the spooled data, removing the temporary file if any
+func (s *spool) Close() error {
+	if s.file == nil {
+		s.buf = bytes.Buffer{}
+		return nil
+	}
+	s.cleanup()
+	return nil
+}
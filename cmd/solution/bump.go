@@ -0,0 +1,287 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package solution
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+
+	"github.com/cisco-open/fsoc/output"
+)
+
+// version parts that can be bumped
+const (
+	bumpMajor = "major"
+	bumpMinor = "minor"
+	bumpPatch = "patch"
+)
+
+const changelogFileName = "CHANGELOG.md"
+
+var solutionBumpCmd = &cobra.Command{
+	Use:   "bump [major|minor|patch]",
+	Short: "Increment the version of a solution",
+	Long: `This command increments the solution version in the manifest.json file of the solution in the
+directory specified with --directory (default is the current directory). The part of the version to
+increment is major, minor or patch (default); the less significant parts are reset to 0, e.g., bumping
+the minor version of 1.2.3 produces 1.3.0.
+
+Unless --no-changelog is specified, an entry for the new version is added at the top of the solution's
+CHANGELOG.md file (which is created if needed), with the message specified with --message.
+
+With --git-tag, the changes to the manifest and the changelog are committed in the git repository
+containing the solution and the commit is tagged with <solution name>-v<version>.
+
+The version can also be bumped as part of deploying the solution with "fsoc solution push --bump".
+
+Examples:
+  fsoc solution bump
+  fsoc solution bump minor -m "Add the spacefleet dashboard"
+  fsoc solution bump major --directory mysolution --git-tag`,
+	Args:             cobra.MatchAll(cobra.MaximumNArgs(1), cobra.OnlyValidArgs),
+	ValidArgs:        []string{bumpMajor, bumpMinor, bumpPatch},
+	Run:              bumpSolution,
+	TraverseChildren: true,
+}
+
+func getSolutionBumpCmd() *cobra.Command {
+	solutionBumpCmd.Flags().String("directory", ".", "Path to the solution root directory")
+	solutionBumpCmd.Flags().StringP("message", "m", "", "Description of the changes, recorded in the changelog")
+	solutionBumpCmd.Flags().Bool("no-changelog", false, "Do not add an entry to the changelog")
+	solutionBumpCmd.Flags().Bool("git-tag", false, "Commit the changes and tag the commit in the git repository containing the solution")
+	return solutionBumpCmd
+}
+
+func bumpSolution(cmd *cobra.Command, args []string) {
+	part := bumpPatch
+	if len(args) > 0 {
+		part = args[0]
+	}
+	solutionPath, _ := cmd.Flags().GetString("directory")
+	message, _ := cmd.Flags().GetString("message")
+	noChangelog, _ := cmd.Flags().GetBool("no-changelog")
+	gitTag, _ := cmd.Flags().GetBool("git-tag")
+
+	if !isSolutionPackageRoot(solutionPath) {
+		log.Fatalf("%q is not a solution root directory", solutionPath)
+	}
+	if gitTag {
+		if err := checkGitRepo(solutionPath); err != nil {
+			log.Fatalf("Cannot tag the new version: %v", err)
+		}
+	}
+
+	name, oldVersion, newVersion, err := bumpSolutionVersion(solutionPath, part, message, !noChangelog)
+	if err != nil {
+		log.Fatalf("Failed to bump the solution version: %v", err)
+	}
+	output.PrintCmdStatus(cmd, fmt.Sprintf("Solution %s version bumped from %s to %s\n", name, oldVersion, newVersion))
+
+	if gitTag {
+		tag, err := tagSolutionVersion(solutionPath, name, newVersion, !noChangelog)
+		if err != nil {
+			log.Fatalf("Failed to tag the new version: %v", err)
+		}
+		output.PrintCmdStatus(cmd, fmt.Sprintf("Created git tag %s\n", tag))
+	}
+}
+
+// bump returns the version with the specified part incremented and the less significant parts reset
+func (v semver) bump(part string) (semver, error) {
+	switch part {
+	case bumpMajor:
+		return semver{v[0] + 1, 0, 0}, nil
+	case bumpMinor:
+		return semver{v[0], v[1] + 1, 0}, nil
+	case bumpPatch:
+		return semver{v[0], v[1], v[2] + 1}, nil
+	default:
+		return v, fmt.Errorf("invalid version part %q, must be one of %s, %s or %s", part, bumpMajor, bumpMinor, bumpPatch)
+	}
+}
+
+var manifestVersionRegexp = regexp.MustCompile(`("solutionVersion"\s*:\s*)"([^"]*)"`)
+
+// bumpSolutionVersion increments the version in the solution's manifest and, optionally, records
+// the new version in the changelog. The manifest is edited in place, preserving its formatting.
+// It returns the solution name with the old and the new version.
+func bumpSolutionVersion(solutionPath string, part string, message string, changelog bool) (string, semver, semver, error) {
+	manifest, err := getSolutionManifest(solutionPath)
+	if err != nil {
+		return "", semver{}, semver{}, fmt.Errorf("failed to read the solution manifest: %w", err)
+	}
+	oldVersion, err := parseSemver(manifest.SolutionVersion)
+	if err != nil {
+		return "", semver{}, semver{}, fmt.Errorf("the manifest has an invalid solution version: %w", err)
+	}
+	newVersion, err := oldVersion.bump(part)
+	if err != nil {
+		return "", semver{}, semver{}, err
+	}
+
+	manifestPath := filepath.Join(solutionPath, "manifest.json")
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return "", semver{}, semver{}, err
+	}
+	loc := manifestVersionRegexp.FindSubmatchIndex(data)
+	if loc == nil {
+		return "", semver{}, semver{}, fmt.Errorf("solutionVersion not found in %q", manifestPath)
+	}
+	var updated bytes.Buffer
+	updated.Write(data[:loc[4]])
+	updated.WriteString(newVersion.String())
+	updated.Write(data[loc[5]:])
+	if err := os.WriteFile(manifestPath, updated.Bytes(), 0644); err != nil {
+		return "", semver{}, semver{}, fmt.Errorf("failed to update %q: %w", manifestPath, err)
+	}
+	log.WithFields(log.Fields{"solution": manifest.Name, "old_version": oldVersion, "new_version": newVersion}).Info("Updated the solution version")
+
+	if changelog {
+		if err := addChangelogEntry(filepath.Join(solutionPath, changelogFileName), newVersion, message, time.Now()); err != nil {
+			return "", semver{}, semver{}, fmt.Errorf("failed to update the changelog: %w", err)
+		}
+	}
+	return manifest.Name, oldVersion, newVersion, nil
+}
+
+// addChangelogEntry inserts an entry for the version above the entries for previous versions,
+// creating the changelog file if it does not exist
+func addChangelogEntry(changelogPath string, version semver, message string, date time.Time) error {
+	if message == "" {
+		message = "Version " + version.String()
+	}
+	entry := fmt.Sprintf("## %s - %s\n\n- %s\n\n", version, date.Format("2006-01-02"), message)
+
+	data, err := os.ReadFile(changelogPath)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		data = []byte("# Changelog\n\n")
+	}
+	content := string(data)
+	pos := strings.Index(content, "\n## ")
+	switch {
+	case strings.HasPrefix(content, "## "):
+		pos = 0
+	case pos >= 0:
+		pos++
+	default:
+		if content != "" && !strings.HasSuffix(content, "\n\n") {
+			content = strings.TrimRight(content, "\n") + "\n\n"
+		}
+		pos = len(content)
+	}
+	return os.WriteFile(changelogPath, []byte(content[:pos]+entry+content[pos:]), 0644)
+}
+
+// changelogEntry is the section of the changelog for a version
+type changelogEntry struct {
+	Version semver
+	Heading string // e.g., "1.2.3 - 2023-05-01"
+	Body    string
+}
+
+var changelogHeadingRegexp = regexp.MustCompile(`^##\s+\[?v?(\d+(?:\.\d+){0,2})\]?`)
+
+// readChangelog returns the entries of the changelog for the versions in the range (from, to],
+// as they appear in the file (usually newest first); entries whose heading does not start with a
+// version are skipped. A missing changelog has no entries.
+func readChangelog(changelogPath string, from semver, to semver) ([]changelogEntry, error) {
+	data, err := os.ReadFile(changelogPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var entries []changelogEntry
+	var current *changelogEntry
+	var body []string
+	flush := func() {
+		if current != nil {
+			current.Body = strings.TrimSpace(strings.Join(body, "\n"))
+			if current.Version.compare(from) > 0 && current.Version.compare(to) <= 0 {
+				entries = append(entries, *current)
+			}
+		}
+		current, body = nil, nil
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(line, "## ") || strings.HasPrefix(line, "# ") {
+			flush()
+			if m := changelogHeadingRegexp.FindStringSubmatch(line); m != nil {
+				if v, err := parseSemver(m[1]); err == nil {
+					current = &changelogEntry{Version: v, Heading: strings.TrimSpace(strings.TrimPrefix(line, "##"))}
+				}
+			}
+			continue
+		}
+		body = append(body, line)
+	}
+	flush()
+	return entries, nil
+}
+
+// checkGitRepo returns an error if the directory is not within a git working tree
+func checkGitRepo(dir string) error {
+	if _, err := runGit(dir, "rev-parse", "--is-inside-work-tree"); err != nil {
+		return fmt.Errorf("%q is not in a git repository: %w", dir, err)
+	}
+	return nil
+}
+
+// tagSolutionVersion commits the manifest (and the changelog) of the solution and tags the commit
+// with <name>-v<version>, returning the tag
+func tagSolutionVersion(solutionPath string, name string, version semver, changelog bool) (string, error) {
+	tag := fmt.Sprintf("%s-v%s", name, version)
+	files := []string{"manifest.json"}
+	if changelog {
+		files = append(files, changelogFileName)
+	}
+	if _, err := runGit(solutionPath, append([]string{"add", "--"}, files...)...); err != nil {
+		return "", err
+	}
+	if _, err := runGit(solutionPath, append([]string{"commit", "-m", fmt.Sprintf("Release %s version %s", name, version), "--"}, files...)...); err != nil {
+		return "", err
+	}
+	if _, err := runGit(solutionPath, "tag", "-a", tag, "-m", fmt.Sprintf("%s version %s", name, version)); err != nil {
+		return "", err
+	}
+	return tag, nil
+}
+
+// runGit runs a git command in the directory, returning its output
+func runGit(dir string, args ...string) (string, error) {
+	gitCmd := exec.Command("git", args...)
+	gitCmd.Dir = dir
+	out, err := gitCmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(string(out)))
+	}
+	return string(out), nil
+}
@@ -0,0 +1,43 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package solution
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBump(t *testing.T) {
+	for _, tc := range []struct {
+		version  semver
+		part     string
+		expected semver
+	}{
+		{semver{1, 2, 3}, bumpMajor, semver{2, 0, 0}},
+		{semver{1, 2, 3}, bumpMinor, semver{1, 3, 0}},
+		{semver{1, 2, 3}, bumpPatch, semver{1, 2, 4}},
+		{semver{0, 0, 0}, bumpPatch, semver{0, 0, 1}},
+		{semver{0, 9, 9}, bumpMinor, semver{0, 10, 0}},
+	} {
+		v, err := tc.version.bump(tc.part)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.expected, v, "%v bump %s", tc.version, tc.part)
+	}
+
+	v, err := semver{1, 2, 3}.bump("build")
+	assert.Error(t, err)
+	assert.Equal(t, semver{1, 2, 3}, v)
+}
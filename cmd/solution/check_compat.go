@@ -0,0 +1,207 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package solution
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+
+	"github.com/cisco-open/fsoc/cmd/config"
+	"github.com/cisco-open/fsoc/output"
+	"github.com/cisco-open/fsoc/platform/api"
+)
+
+var solutionCheckCompatCmd = &cobra.Command{
+	Use:   "check-compat",
+	Short: "Check if the target environment supports your solution",
+	Long: `This command compares the dependencies and the object types used by the solution in the current
+directory with what is available in the environment of the current profile (or the one selected with --profile),
+reporting unsupported features before the solution is pushed.
+
+The following are checked:
+  - each dependency is available in the target tenant (or is vendored, see "fsoc solution vendor")
+  - each object type used by the solution is known to the target tenant, is defined by the solution
+    itself or is provided by one of its dependencies
+
+Examples:
+  fsoc solution check-compat
+  fsoc solution check-compat --profile staging
+  fsoc solution check-compat --directory=mysolution -o json`,
+	Args:             cobra.ExactArgs(0),
+	Run:              checkSolutionCompat,
+	TraverseChildren: true,
+}
+
+const (
+	compatOK          = "ok"
+	compatUnsupported = "unsupported"
+	compatMissing     = "missing"
+	compatVendored    = "vendored"
+	compatDependency  = "from dependency"
+	compatSelf        = "defined by solution"
+)
+
+type compatCheck struct {
+	Kind    string `json:"kind"`
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Details string `json:"details,omitempty"`
+}
+
+func (c *compatCheck) isFailure() bool {
+	return c.Status == compatMissing || c.Status == compatUnsupported
+}
+
+func getSolutionCheckCompatCmd() *cobra.Command {
+	solutionCheckCompatCmd.Flags().String("directory", ".", "Path to the solution root directory")
+
+	return solutionCheckCompatCmd
+}
+
+func checkSolutionCompat(cmd *cobra.Command, args []string) {
+	solutionPath, _ := cmd.Flags().GetString("directory")
+	if !isSolutionPackageRoot(solutionPath) {
+		log.Fatalf("%q is not a solution root directory", solutionPath)
+	}
+	manifest, err := getSolutionManifest(solutionPath)
+	if err != nil {
+		log.Fatalf("Failed to read solution manifest: %v", err)
+	}
+
+	output.PrintCmdStatus(cmd, fmt.Sprintf("Checking solution %s against the environment of profile %q\n", manifest.Name, config.GetCurrentProfileName()))
+
+	solutions, err := getTenantSolutions()
+	if err != nil {
+		log.Fatalf("Failed to get the list of solutions available in the tenant: %v", err)
+	}
+	vendored := map[string]bool{}
+	lock, err := readVendorLock(filepath.Join(solutionPath, vendorDirName))
+	if err != nil {
+		log.Warnf("Failed to read the vendor lock file, ignoring vendored dependencies: %v", err)
+	} else if lock != nil {
+		for _, dep := range lock.Dependencies {
+			vendored[dep.Name] = true
+		}
+	}
+
+	checks := checkDependenciesCompat(manifest, solutions, vendored)
+	checks = append(checks, checkTypesCompat(manifest, solutions)...)
+
+	failures := 0
+	for _, c := range checks {
+		if c.isFailure() {
+			failures++
+		}
+	}
+
+	output.PrintCmdOutputCustom(cmd, struct {
+		Items []compatCheck `json:"items"`
+		Total int           `json:"total"`
+	}{Items: checks, Total: len(checks)}, &output.Table{
+		Headers: []string{"Kind", "Name", "Status", "Details"},
+		Lines:   compatLines(checks),
+	})
+
+	if failures > 0 {
+		log.Fatalf("Solution %s is not compatible with the target environment: %d problem(s) found", manifest.Name, failures)
+	}
+	output.PrintCmdStatus(cmd, fmt.Sprintf("Solution %s is compatible with the target environment\n", manifest.Name))
+}
+
+func checkDependenciesCompat(manifest *Manifest, solutions map[string]SolutionDef, vendored map[string]bool) []compatCheck {
+	var checks []compatCheck
+	for _, spec := range manifest.Dependencies {
+		dep := dependencyName(spec)
+		check := compatCheck{Kind: "dependency", Name: dep}
+		if solution, found := solutions[dep]; found {
+			check.Status = compatOK
+			if solution.IsSystem {
+				check.Details = "system solution"
+			}
+		} else if vendored[dep] {
+			check.Status = compatVendored
+			check.Details = "not in tenant, available in the vendor bundle"
+		} else {
+			check.Status = compatMissing
+			check.Details = "solution not available in the tenant"
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+func checkTypesCompat(manifest *Manifest, solutions map[string]SolutionDef) []compatCheck {
+	headers := map[string]string{
+		"layer-type": "TENANT",
+		"layer-id":   config.GetCurrentContext().Tenant,
+	}
+
+	var checks []compatCheck
+	seen := map[string]bool{}
+	for _, objDef := range manifest.Objects {
+		typeName := objDef.Type
+		if seen[typeName] {
+			continue
+		}
+		seen[typeName] = true
+
+		check := compatCheck{Kind: "type", Name: typeName}
+		namespace, _, found := strings.Cut(typeName, ":")
+		if !found {
+			check.Status = compatUnsupported
+			check.Details = "not a fully qualified type name"
+			checks = append(checks, check)
+			continue
+		}
+		if namespace == manifest.Name {
+			check.Status = compatSelf
+			checks = append(checks, check)
+			continue
+		}
+
+		var typeDef any
+		options := &api.Options{Headers: headers}
+		err := api.JSONGet(getTypeUrl(typeName), &typeDef, options)
+		switch {
+		case err == nil:
+			check.Status = compatOK
+		case api.IsNotFound(err, options):
+			if _, installed := solutions[namespace]; !installed && checkDependencyExists(namespace, manifest) {
+				// type will become available once the dependency is installed
+				check.Status = compatDependency
+				check.Details = fmt.Sprintf("provided by solution %s", namespace)
+			} else {
+				check.Status = compatUnsupported
+				check.Details = "type not known to the target environment"
+			}
+		default:
+			log.Fatalf("Failed to check type %q: %v", typeName, err)
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+func compatLines(checks []compatCheck) [][]string {
+	lines := make([][]string, len(checks))
+	for i, c := range checks {
+		lines[i] = []string{c.Kind, c.Name, c.Status, c.Details}
+	}
+	return lines
+}
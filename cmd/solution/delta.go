@@ -0,0 +1,242 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package solution
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+
+	"github.com/cisco-open/fsoc/cmd/config"
+	"github.com/cisco-open/fsoc/output"
+)
+
+// pushStateFileName is the file in the state home that records the contents of the last push of each
+// solution, so that an unchanged solution is not pushed again
+const pushStateFileName = "solution-pushes.json"
+
+// pushState is the contents of the push state file
+type pushState struct {
+	Tenants map[string]*tenantPushState `json:"tenants"` // keyed by tenant ID
+}
+
+type tenantPushState struct {
+	Solutions map[string]*pushedSolution `json:"solutions,omitempty"` // keyed by deployed solution name
+}
+
+// pushedSolution records the last push of a solution. Only a push whose installation is confirmed is
+// used as the base of the next push.
+type pushedSolution struct {
+	Version   string            `json:"version"`
+	PushedAt  time.Time         `json:"pushedAt"`
+	Installed bool              `json:"installed,omitempty"` // the installation of the pushed version succeeded
+	Files     map[string]string `json:"files"`               // SHA-256 of each archived file's contents, keyed by its path in the archive
+}
+
+// pushRecord is an uploaded solution push, recorded in the push state; confirm marks it as installed
+type pushRecord struct {
+	state  *pushState
+	pushed *pushedSolution
+}
+
+// uploadSolutionDiff uploads the solution archive like postSolutionArchive, unless the solution is unchanged
+// since it was last pushed from this machine and installed. It returns the time the upload started and the
+// record of the push, which the caller confirms once the installation succeeds, or nil if the solution is
+// unchanged and was not uploaded.
+func uploadSolutionDiff(cmd *cobra.Command, archivePath string, solutionName string, version string, retries int) (time.Time, *pushRecord, error) {
+	files, err := hashArchiveFiles(archivePath)
+	if err != nil || solutionName == "" {
+		if err != nil {
+			log.Warnf("Failed to read the solution archive, pushing the whole solution: %v", err)
+		}
+		started, err := postSolutionArchive(cmd, archivePath, solutionName, retries)
+		return started, &pushRecord{}, err
+	}
+
+	tenantID := config.GetCurrentContext().Tenant
+	state := loadPushState()
+	tenant := state.tenant(tenantID)
+	previous := tenant.Solutions[solutionName]
+	if previous != nil && !previous.Installed && !confirmInstalled(solutionName, previous) {
+		log.WithField("solution", solutionName).Info("The installation of the previous push is not confirmed, pushing the whole solution")
+		previous = nil
+	}
+	changed, removed := diffArchiveFiles(previous, files)
+	if previous == nil {
+		log.WithField("solution", solutionName).Info("No previous push recorded, pushing the solution")
+	} else if len(changed) == 0 && len(removed) == 0 && previous.Version == version {
+		output.PrintCmdStatus(cmd, fmt.Sprintf("Solution %s version %s is unchanged since it was pushed on %s; nothing to push.\n",
+			solutionName, version, previous.PushedAt.Local().Format(time.RFC1123)))
+		return time.Time{}, nil, nil
+	}
+	log.WithFields(log.Fields{"solution": solutionName, "changed": len(changed), "removed": len(removed)}).Info("Pushing the solution")
+	started, err := postSolutionArchive(cmd, archivePath, solutionName, retries)
+	if err != nil {
+		return started, &pushRecord{}, err
+	}
+
+	// record the push, to be used as the base of the next one once its installation is confirmed
+	record := &pushRecord{state: state, pushed: &pushedSolution{Version: version, PushedAt: started.UTC(), Files: files}}
+	tenant.Solutions[solutionName] = record.pushed
+	if err := state.save(); err != nil {
+		log.Warnf("Failed to record the push of solution %s: %v", solutionName, err)
+	}
+	return started, record, nil
+}
+
+// confirm records that the installation of the pushed solution succeeded
+func (r *pushRecord) confirm() {
+	if r.pushed == nil {
+		return
+	}
+	r.pushed.Installed = true
+	if err := r.state.save(); err != nil {
+		log.Warnf("Failed to record the installation of the pushed solution: %v", err)
+	}
+}
+
+// confirmInstalled checks whether the installation of a push that was not waited for succeeded
+func confirmInstalled(solutionName string, pushed *pushedSolution) bool {
+	status, err := getInstallStatus(solutionName, pushed.Version)
+	if err != nil {
+		log.Warnf("Failed to get the installation status of solution %s: %v", solutionName, err)
+		return false
+	}
+	pushed.Installed = status.StatusData.SolutionVersion == pushed.Version && !statusPredates(status, pushed.PushedAt) &&
+		status.StatusData.SuccessfulInstall
+	return pushed.Installed
+}
+
+// hashArchiveFiles returns the SHA-256 of the contents of each file in the archive, keyed by path
+func hashArchiveFiles(archivePath string) (map[string]string, error) {
+	r, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	hashes := map[string]string{}
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		h := sha256.New()
+		_, err = io.Copy(h, rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", f.Name, err)
+		}
+		hashes[f.Name] = hex.EncodeToString(h.Sum(nil))
+	}
+	return hashes, nil
+}
+
+// diffArchiveFiles returns the sorted paths of the files that were added or changed and of the files that
+// were removed since the previous push; all files are changed if there was no previous push
+func diffArchiveFiles(previous *pushedSolution, files map[string]string) (changed []string, removed []string) {
+	var previousFiles map[string]string
+	if previous != nil {
+		previousFiles = previous.Files
+	}
+	for name, hash := range files {
+		if previousFiles[name] != hash {
+			changed = append(changed, name)
+		}
+	}
+	for name := range previousFiles {
+		if _, found := files[name]; !found {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(changed)
+	sort.Strings(removed)
+	return changed, removed
+}
+
+func pushStatePath() string {
+	return filepath.Join(config.StateHome(), pushStateFileName)
+}
+
+// loadPushState reads the push state file; a missing or unreadable file yields an empty state
+func loadPushState() *pushState {
+	state := &pushState{}
+	data, err := os.ReadFile(pushStatePath())
+	if err == nil {
+		err = json.Unmarshal(data, state)
+	}
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Warnf("Failed to read the solution push state, ignoring it: %v", err)
+		state = &pushState{}
+	}
+	if state.Tenants == nil {
+		state.Tenants = map[string]*tenantPushState{}
+	}
+	return state
+}
+
+// tenant returns the push state of the tenant, creating it if needed
+func (s *pushState) tenant(id string) *tenantPushState {
+	t := s.Tenants[id]
+	if t == nil {
+		t = &tenantPushState{}
+		s.Tenants[id] = t
+	}
+	if t.Solutions == nil {
+		t.Solutions = map[string]*pushedSolution{}
+	}
+	return t
+}
+
+// save writes the push state file, replacing it atomically
+func (s *pushState) save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := pushStatePath()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	temp, err := os.CreateTemp(filepath.Dir(path), "."+pushStateFileName+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = temp.Write(data)
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(temp.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(temp.Name())
+	}
+	return err
+}
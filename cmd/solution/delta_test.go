@@ -0,0 +1,50 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package solution
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffArchiveFiles(t *testing.T) {
+	previous := &pushedSolution{Files: map[string]string{
+		"manifest.json":       "m1",
+		"objects/a.json":      "a1",
+		"objects/b.json":      "b1",
+		"types/removed.json":  "r1",
+		"objects/gone/x.json": "x1",
+	}}
+	for _, tc := range []struct {
+		name     string
+		previous *pushedSolution
+		files    map[string]string
+		changed  []string
+		removed  []string
+	}{
+		{"first push", nil, map[string]string{"manifest.json": "m1", "objects/a.json": "a1"}, []string{"manifest.json", "objects/a.json"}, nil},
+		{"unchanged", previous, previous.Files, nil, nil},
+		{"changed, added and removed", previous,
+			map[string]string{"manifest.json": "m2", "objects/a.json": "a1", "objects/b.json": "b1", "objects/c.json": "c1"},
+			[]string{"manifest.json", "objects/c.json"}, []string{"objects/gone/x.json", "types/removed.json"}},
+		{"all removed", previous, map[string]string{}, nil,
+			[]string{"manifest.json", "objects/a.json", "objects/b.json", "objects/gone/x.json", "types/removed.json"}},
+	} {
+		changed, removed := diffArchiveFiles(tc.previous, tc.files)
+		assert.Equal(t, tc.changed, changed, tc.name)
+		assert.Equal(t, tc.removed, removed, tc.name)
+	}
+}
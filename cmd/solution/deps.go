@@ -0,0 +1,295 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package solution
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+
+	"github.com/cisco-open/fsoc/output"
+)
+
+// dependency statuses
+const (
+	depOK             = "ok"
+	depMissing        = "missing"
+	depIncompatible   = "incompatible"
+	depVersionUnknown = "version unknown"
+	depCycle          = "cycle"
+	depInvalid        = "invalid"
+)
+
+// depNode is a solution in the dependency tree
+type depNode struct {
+	Name         string     `json:"name" yaml:"name"`
+	Required     string     `json:"required,omitempty" yaml:"required,omitempty"` // version constraint, if any
+	Installed    string     `json:"installed,omitempty" yaml:"installed,omitempty"`
+	Status       string     `json:"status,omitempty" yaml:"status,omitempty"`
+	Details      string     `json:"details,omitempty" yaml:"details,omitempty"`
+	Dependencies []*depNode `json:"dependencies,omitempty" yaml:"dependencies,omitempty"`
+}
+
+func (n *depNode) isFailure() bool {
+	return n.Status == depMissing || n.Status == depIncompatible || n.Status == depCycle || n.Status == depInvalid
+}
+
+var solutionDepsCmd = &cobra.Command{
+	Use:   "deps [NAME]",
+	Short: "Display the dependency tree of a solution",
+	Long: `This command displays the transitive dependencies of a solution, as a tree. The dependencies of the
+named solution are taken from the tenant; without a name, the dependencies of the solution in the directory
+specified with --directory (default is the current directory) are used. The dependencies of the dependencies
+are always taken from the tenant.
+
+A dependency in the manifest can require a version of the solution with "name@constraint", where the constraint
+is a version (MAJOR.MINOR.PATCH) optionally preceded by an operator: =, >, >=, <, <=, ^ (same major version) or
+~ (same major and minor version). For example, "zodiac@^1.2.0".
+
+The status of each dependency shows whether it is installed in the tenant at a version that satisfies the
+constraint. With --check, the command fails if any dependency is missing, has an incompatible version or
+is part of a dependency cycle.
+
+In addition to the usual output formats, -o dot produces a graph in the DOT language, which can be rendered
+with graphviz.
+
+Examples:
+  fsoc solution deps
+  fsoc solution deps spacefleet -o json
+  fsoc solution deps --check --profile staging
+  fsoc solution deps spacefleet -o dot | dot -Tpng > deps.png`,
+	Args:             cobra.MaximumNArgs(1),
+	Run:              solutionDeps,
+	TraverseChildren: true,
+}
+
+func getSolutionDepsCmd() *cobra.Command {
+	solutionDepsCmd.Flags().String("directory", ".", "Path to the solution root directory (used when no solution name is specified)")
+	solutionDepsCmd.Flags().Bool("check", false, "Fail if any dependency is missing or installed at an incompatible version")
+	return solutionDepsCmd
+}
+
+func solutionDeps(cmd *cobra.Command, args []string) {
+	solutions, err := getTenantSolutions()
+	if err != nil {
+		log.Fatalf("Failed to get the list of solutions available in the tenant: %v", err)
+	}
+
+	var root *depNode
+	var rootDeps []string
+	if len(args) > 0 {
+		solution, found := solutions[args[0]]
+		if !found {
+			log.Fatalf("Solution %q is not available in the tenant", args[0])
+		}
+		root = &depNode{Name: solution.Name, Installed: solution.Version}
+		rootDeps = solution.Dependencies
+	} else {
+		solutionPath, _ := cmd.Flags().GetString("directory")
+		if !isSolutionPackageRoot(solutionPath) {
+			log.Fatalf("%q is not a solution root directory", solutionPath)
+		}
+		manifest, err := getSolutionManifest(solutionPath)
+		if err != nil {
+			log.Fatalf("Failed to read solution manifest: %v", err)
+		}
+		root = &depNode{Name: manifest.Name, Installed: manifest.SolutionVersion, Details: "local"}
+		rootDeps = manifest.Dependencies
+	}
+	root.Dependencies = resolveDependencies(rootDeps, solutions, []string{root.Name})
+
+	failures := countDependencyFailures(root)
+	if format, _ := cmd.Flags().GetString("output"); format == "dot" {
+		output.PrintCmdData(cmd, dependencyGraphDot(root))
+	} else {
+		output.PrintCmdOutputCustom(cmd, root, &output.Table{
+			Headers: []string{"Solution", "Required", "Installed", "Status", "Details"},
+			Lines:   dependencyTreeLines(root, "", ""),
+		})
+	}
+
+	if check, _ := cmd.Flags().GetBool("check"); check {
+		if failures > 0 {
+			log.Fatalf("Solution %s has %d dependency problem(s)", root.Name, failures)
+		}
+		output.PrintCmdStatus(cmd, fmt.Sprintf("All dependencies of solution %s are satisfied\n", root.Name))
+	}
+}
+
+// parseDependency parses a dependency specification, "name" or "name@constraint"
+func parseDependency(spec string) (string, *versionConstraint, error) {
+	name, constraintSpec, found := strings.Cut(spec, "@")
+	if !found {
+		return spec, nil, nil
+	}
+	constraint, err := parseVersionConstraint(constraintSpec)
+	if err != nil {
+		return name, nil, err
+	}
+	return name, &constraint, nil
+}
+
+// dependencyName returns the name of the solution in a dependency specification
+func dependencyName(spec string) string {
+	name, _, _ := strings.Cut(spec, "@")
+	return name
+}
+
+// resolveDependencies builds the dependency subtrees for the dependency specifications,
+// using the solutions available in the tenant; path holds the solutions on the way from
+// the root, to detect cycles
+func resolveDependencies(specs []string, solutions map[string]SolutionDef, path []string) []*depNode {
+	nodes := make([]*depNode, 0, len(specs))
+	for _, spec := range specs {
+		name, constraint, err := parseDependency(spec)
+		node := &depNode{Name: name}
+		nodes = append(nodes, node)
+		if err != nil {
+			node.Status = depInvalid
+			node.Details = err.Error()
+			continue
+		}
+		if constraint != nil {
+			node.Required = constraint.String()
+		}
+		for _, ancestor := range path {
+			if ancestor == name {
+				node.Status = depCycle
+				node.Details = strings.Join(append(path, name), " -> ")
+				break
+			}
+		}
+		if node.Status == depCycle {
+			continue
+		}
+
+		solution, found := solutions[name]
+		if !found {
+			node.Status = depMissing
+			node.Details = "not available in the tenant"
+			continue
+		}
+		node.Installed = solution.Version
+		node.Status = dependencyVersionStatus(constraint, solution.Version)
+		if solution.IsSystem {
+			node.Details = "system solution"
+		}
+		node.Dependencies = resolveDependencies(solution.Dependencies, solutions, append(path[:len(path):len(path)], name))
+	}
+	return nodes
+}
+
+// dependencyVersionStatus checks the installed version against the constraint
+func dependencyVersionStatus(constraint *versionConstraint, installed string) string {
+	if constraint == nil {
+		return depOK
+	}
+	if installed == "" {
+		return depVersionUnknown
+	}
+	v, err := parseSemver(installed)
+	if err != nil {
+		return depVersionUnknown
+	}
+	if !constraint.allows(v) {
+		return depIncompatible
+	}
+	return depOK
+}
+
+func countDependencyFailures(node *depNode) int {
+	count := 0
+	if node.isFailure() {
+		count++
+	}
+	for _, dep := range node.Dependencies {
+		count += countDependencyFailures(dep)
+	}
+	return count
+}
+
+// dependencyTreeLines returns the table lines for the tree, with the solution names indented
+// to show the tree structure
+func dependencyTreeLines(node *depNode, prefix string, childPrefix string) [][]string {
+	lines := [][]string{{prefix + node.Name, node.Required, node.Installed, node.Status, node.Details}}
+	for i, dep := range node.Dependencies {
+		if i == len(node.Dependencies)-1 {
+			lines = append(lines, dependencyTreeLines(dep, childPrefix+"└── ", childPrefix+"    ")...)
+		} else {
+			lines = append(lines, dependencyTreeLines(dep, childPrefix+"├── ", childPrefix+"│   ")...)
+		}
+	}
+	return lines
+}
+
+// dependencyGraphDot returns the dependency graph in the DOT language, with each solution and
+// each dependency edge appearing once; unsatisfied dependencies are highlighted
+func dependencyGraphDot(root *depNode) string {
+	nodes := map[string]string{} // name -> attributes
+	edges := map[string]bool{}
+	var walk func(n *depNode)
+	walk = func(n *depNode) {
+		if _, seen := nodes[n.Name]; !seen || n.Status == depMissing || n.Status == depInvalid {
+			label := n.Name
+			if n.Installed != "" {
+				label += "\n" + n.Installed
+			}
+			attrs := fmt.Sprintf("label=%q", label)
+			if n.Status == depMissing || n.Status == depInvalid {
+				attrs += ", color=red, fontcolor=red"
+			}
+			nodes[n.Name] = attrs
+		}
+		for _, dep := range n.Dependencies {
+			var attrs []string
+			if dep.Required != "" {
+				attrs = append(attrs, fmt.Sprintf("label=%q", dep.Required))
+			}
+			if dep.isFailure() {
+				attrs = append(attrs, "color=red", "fontcolor=red")
+			}
+			edge := fmt.Sprintf("%q -> %q", n.Name, dep.Name)
+			if len(attrs) > 0 {
+				edge += " [" + strings.Join(attrs, ", ") + "]"
+			}
+			edges[edge] = true
+			walk(dep)
+		}
+	}
+	walk(root)
+
+	var sb strings.Builder
+	sb.WriteString("digraph dependencies {\n")
+	for _, name := range sortedKeys(nodes) {
+		sb.WriteString(fmt.Sprintf("  %q [%s];\n", name, nodes[name]))
+	}
+	for _, edge := range sortedKeys(edges) {
+		sb.WriteString("  " + edge + ";\n")
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -1,21 +1,44 @@
 package solution
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"io/fs"
 	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/apex/log"
 	"github.com/spf13/cobra"
 
 	"github.com/cisco-open/fsoc/cmd/config"
+	"github.com/cisco-open/fsoc/cmdkit/interrupt"
+	"github.com/cisco-open/fsoc/cmdkit/picker"
+	"github.com/cisco-open/fsoc/output"
 	"github.com/cisco-open/fsoc/platform/api"
 )
 
 var solutionDescribeCmd = &cobra.Command{
-	Use:   "describe --name=<solution>",
-	Short: "",
-	Long:  ``,
-	Run:   solutionDescribe,
+	Use:   "describe [DIRECTORY | SOLUTION]",
+	Short: "Describe the contents of a solution",
+	Long: `This command produces an inventory of a solution's contents: its declared types, the objects it
+provides per type and layer, its dashboards, its metric definitions and its dependencies.
+
+The solution can be a local solution directory (the current directory if nothing is specified) or the
+name of a solution deployed in the current tenant, in which case its bundle is downloaded and inspected.
+
+The inventory is displayed as a table, as JSON/YAML with -o, or as a Markdown document with --markdown,
+which is convenient for including in solution reviews.`,
+	Example: `  fsoc solution describe
+  fsoc solution describe ./spacefleet --markdown > spacefleet.md
+  fsoc solution describe spacefleet -o json
+  fsoc solution describe --pick`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  solutionDescribe,
 }
 
 type Solution struct {
@@ -29,39 +52,464 @@ type Solution struct {
 	DisplayName    string `json:"displayName"`
 }
 
+// defaultObjectLayer is the layer in which the objects provided by a solution are stored,
+// unless an object specifies otherwise
+const defaultObjectLayer = "SOLUTION"
+
+// solutionReport is the inventory of a solution's contents
+type solutionReport struct {
+	Name         string            `json:"name"`
+	Version      string            `json:"version"`
+	Description  string            `json:"description,omitempty"`
+	Contact      string            `json:"contact,omitempty"`
+	HomePage     string            `json:"homepage,omitempty"`
+	GitRepoUrl   string            `json:"gitRepoUrl,omitempty"`
+	Source       string            `json:"source"`
+	UpdatedAt    string            `json:"updatedAt,omitempty"`
+	Dependencies []string          `json:"dependencies"`
+	Types        []reportType      `json:"types"`
+	Objects      []reportObjects   `json:"objects"`
+	Dashboards   []reportDashboard `json:"dashboards"`
+	Metrics      []reportMetric    `json:"metrics"`
+	Problems     []string          `json:"problems,omitempty"`
+	files        map[string][]byte // file contents, by path relative to the solution root
+	typeLayers   map[string]string // default layer of the solution's own types, by type name
+	objectIndex  map[[2]string]int // index in Objects, by type and layer
+}
+
+type reportType struct {
+	Name          string   `json:"name"`
+	AllowedLayers []string `json:"allowedLayers,omitempty"`
+	File          string   `json:"file"`
+}
+
+type reportObjects struct {
+	Type   string `json:"type"`
+	Layer  string `json:"layer"`
+	Count  int    `json:"count"`
+	Source string `json:"source"`
+}
+
+type reportDashboard struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+	File string `json:"file"`
+}
+
+type reportMetric struct {
+	Name        string `json:"name"`
+	ContentType string `json:"contentType,omitempty"`
+	Category    string `json:"category,omitempty"`
+	Unit        string `json:"unit,omitempty"`
+	File        string `json:"file"`
+}
+
 func getSolutionDescribeCmd() *cobra.Command {
 	solutionDescribeCmd.Flags().
-		String("solution", "", "The name of the solution to describe")
-	_ = solutionDescribeCmd.MarkFlagRequired("solution")
+		String("solution", "", "The name of the deployed solution to describe")
+	solutionDescribeCmd.Flags().
+		Bool("markdown", false, "Display the inventory as a Markdown document")
+	picker.AddFlag(solutionDescribeCmd)
+	solutionDescribeCmd.MarkFlagsMutuallyExclusive("solution", picker.FlagName)
 
 	return solutionDescribeCmd
 }
 
 func solutionDescribe(cmd *cobra.Command, args []string) {
-	log.Info("Fetching the details of the specified solutions...")
 	solution, _ := cmd.Flags().GetString("solution")
+	markdown, _ := cmd.Flags().GetBool("markdown")
+	if solution != "" && len(args) > 0 {
+		log.Fatal("Specify either a directory/solution argument or --solution, not both")
+	}
+
+	var reports []*solutionReport
+	switch {
+	case picker.Requested(cmd):
+		for _, name := range pickSolutions("Solutions to describe", nil) {
+			reports = append(reports, describeDeployedSolution(name))
+		}
+	case solution != "":
+		reports = append(reports, describeDeployedSolution(solution))
+	case len(args) > 0 && isLocalDir(args[0]):
+		reports = append(reports, describeSolutionDir(args[0]))
+	case len(args) > 0:
+		reports = append(reports, describeDeployedSolution(args[0]))
+	case !picker.Interactive(cmd) || fileExists("manifest.json"):
+		reports = append(reports, describeSolutionDir("."))
+	default:
+		for _, name := range pickSolutions("Solutions to describe", nil) {
+			reports = append(reports, describeDeployedSolution(name))
+		}
+	}
+
+	for i, report := range reports {
+		if markdown {
+			if i > 0 {
+				output.PrintCmdStatus(cmd, "\n")
+			}
+			writeReportMarkdown(output.GetOutWriter(cmd), report)
+			continue
+		}
+		printReport(cmd, report)
+	}
+}
 
-	cfg := config.GetCurrentContext()
-	layerID := cfg.Tenant
+// describeSolutionDir builds the inventory of a local solution directory
+func describeSolutionDir(dir string) *solutionReport {
+	log.WithField("directory", dir).Info("Describing local solution")
+	files := map[string][]byte{}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(d.Name(), ".json") {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = data
+		return nil
+	})
+	if err != nil {
+		log.Fatalf("Failed to read the solution directory %q: %v", dir, err)
+	}
+	if _, found := files["manifest.json"]; !found {
+		log.Fatalf("%q is not a solution package root folder", dir)
+	}
 
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		absDir = dir
+	}
+	report, err := newSolutionReport(files, absDir)
+	if err != nil {
+		log.Fatalf("Failed to describe the solution in %q: %v", dir, err)
+	}
+	return report
+}
+
+// describeDeployedSolution downloads the bundle of a solution deployed in the current tenant and builds its inventory
+func describeDeployedSolution(name string) *solutionReport {
+	log.WithField("solution", name).Info("Describing deployed solution")
 	headers := map[string]string{
 		"layer-type": "TENANT",
-		"layer-id":   layerID,
+		"layer-id":   config.GetCurrentContext().Tenant,
+	}
+	var solution Solution
+	if err := api.JSONGet(getSolutionDescribeUrl(url.PathEscape(name)), &solution, &api.Options{Headers: headers}); err != nil {
+		log.Fatalf("Failed to get solution %q: %v", name, err)
+	}
+
+	tempDir, err := os.MkdirTemp("", "fsoc-describe-")
+	if err != nil {
+		log.Fatalf("Failed to create a temporary directory: %v", err)
+	}
+	defer interrupt.OnCleanup(func() { os.RemoveAll(tempDir) })()
+	archivePath := filepath.Join(tempDir, name+".zip")
+	if _, err := downloadSolutionBundle(name, "", archivePath); err != nil {
+		log.Fatalf("Failed to download solution %q: %v", name, err)
+	}
+	data, err := os.ReadFile(archivePath)
+	if err != nil {
+		log.Fatalf("Failed to read the solution bundle: %v", err)
+	}
+	files, err := readArchiveFiles(data)
+	if err != nil {
+		log.Fatalf("Failed to read the solution bundle: %v", err)
+	}
+
+	report, err := newSolutionReport(files, "tenant "+config.GetCurrentContext().Tenant)
+	if err != nil {
+		log.Fatalf("Failed to describe solution %q: %v", name, err)
+	}
+	report.UpdatedAt = solution.UpdatedAt
+	return report
+}
+
+// newSolutionReport builds the inventory of a solution from its files, keyed by their slash-separated
+// path relative to the solution root
+func newSolutionReport(files map[string][]byte, source string) (*solutionReport, error) {
+	data, found := files["manifest.json"]
+	if !found {
+		return nil, fmt.Errorf("the solution has no manifest.json")
 	}
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse the manifest: %w", err)
+	}
+
+	r := &solutionReport{
+		Name:         manifest.Name,
+		Version:      manifest.SolutionVersion,
+		Description:  manifest.Description,
+		Contact:      manifest.Contact,
+		HomePage:     manifest.HomePage,
+		GitRepoUrl:   manifest.GitRepoUrl,
+		Source:       source,
+		Dependencies: append([]string{}, manifest.Dependencies...),
+		Types:        []reportType{},
+		Objects:      []reportObjects{},
+		Dashboards:   []reportDashboard{},
+		Metrics:      []reportMetric{},
+		files:        files,
+		typeLayers:   map[string]string{},
+		objectIndex:  map[[2]string]int{},
+	}
+	sort.Strings(r.Dependencies)
+
+	for _, file := range manifest.Types {
+		r.addType(manifest.Name, strings.TrimPrefix(file, "./"))
+	}
+	for _, objDef := range manifest.Objects {
+		r.addObjects(objDef)
+	}
+	sort.SliceStable(r.Objects, func(i, j int) bool {
+		if r.Objects[i].Type != r.Objects[j].Type {
+			return r.Objects[i].Type < r.Objects[j].Type
+		}
+		return r.Objects[i].Layer < r.Objects[j].Layer
+	})
+	return r, nil
+}
+
+// addType adds a knowledge type defined by the solution to the inventory
+func (r *solutionReport) addType(namespace string, file string) {
+	var typeDef KnowledgeDef
+	data, found := r.files[file]
+	if !found {
+		r.Problems = append(r.Problems, fmt.Sprintf("type definition file %q not found", file))
+		return
+	}
+	if err := json.Unmarshal(data, &typeDef); err != nil {
+		r.Problems = append(r.Problems, fmt.Sprintf("failed to parse type definition file %q: %v", file, err))
+		return
+	}
+	name := namespace + ":" + typeDef.Name
+	r.Types = append(r.Types, reportType{Name: name, AllowedLayers: typeDef.AllowedLayers, File: file})
+	if len(typeDef.AllowedLayers) == 1 {
+		r.typeLayers[name] = typeDef.AllowedLayers[0]
+	}
+}
+
+// addObjects adds the objects provided by a manifest's objects entry to the inventory
+func (r *solutionReport) addObjects(objDef ComponentDef) {
+	var source string
+	var fileNames []string
+	switch {
+	case objDef.ObjectsFile != "":
+		source = strings.TrimPrefix(objDef.ObjectsFile, "./")
+		if _, found := r.files[source]; found {
+			fileNames = []string{source}
+		}
+	case objDef.ObjectsDir != "":
+		source = strings.TrimSuffix(strings.TrimPrefix(objDef.ObjectsDir, "./"), "/")
+		for name := range r.files {
+			if strings.HasPrefix(name, source+"/") {
+				fileNames = append(fileNames, name)
+			}
+		}
+		sort.Strings(fileNames)
+	}
+	if len(fileNames) == 0 {
+		r.Problems = append(r.Problems, fmt.Sprintf("no objects found for type %q", objDef.Type))
+		return
+	}
+
+	for _, fileName := range fileNames {
+		var value any
+		if err := json.Unmarshal(r.files[fileName], &value); err != nil {
+			r.Problems = append(r.Problems, fmt.Sprintf("failed to parse objects file %q: %v", fileName, err))
+			continue
+		}
+		for _, object := range splitObjects(value) {
+			r.addObject(objDef.Type, source, fileName, object.value)
+		}
+	}
+}
+
+// addObject counts a single object and, for dashboards and metric definitions, lists it
+func (r *solutionReport) addObject(typeName string, source string, fileName string, value any) {
+	fields, _ := value.(map[string]any)
+	layer, _ := fields["layerType"].(string)
+	if layer == "" {
+		layer = r.typeLayers[typeName]
+	}
+	if layer == "" {
+		layer = defaultObjectLayer
+	}
+
+	key := [2]string{typeName, layer}
+	if i, found := r.objectIndex[key]; found {
+		r.Objects[i].Count++
+	} else {
+		r.objectIndex[key] = len(r.Objects)
+		r.Objects = append(r.Objects, reportObjects{Type: typeName, Layer: layer, Count: 1, Source: source})
+	}
+
+	switch {
+	case strings.HasPrefix(typeName, "dashui:"):
+		r.Dashboards = append(r.Dashboards, reportDashboard{Type: typeName, Name: objectName(fields), File: fileName})
+	case typeName == "fmm:metric":
+		var metric FmmMetric
+		data, _ := json.Marshal(value)
+		_ = json.Unmarshal(data, &metric)
+		name := objectName(fields)
+		if metric.FmmTypeDef != nil && metric.Name != "" {
+			name = metric.Name
+			if metric.Namespace.Name != "" {
+				name = metric.Namespace.Name + ":" + metric.Name
+			}
+		}
+		r.Metrics = append(r.Metrics, reportMetric{
+			Name:        name,
+			ContentType: string(metric.ContentType),
+			Category:    string(metric.Category),
+			Unit:        metric.Unit,
+			File:        fileName,
+		})
+	}
+}
+
+// objectName returns the most descriptive name of an object: its name, display name or ID
+func objectName(fields map[string]any) string {
+	for _, field := range []string{"name", "displayName", "id"} {
+		if name, ok := fields[field].(string); ok && name != "" {
+			return name
+		}
+	}
+	return "-"
+}
+
+// printReport displays the inventory in the selected output format; human formats show
+// the solution's details followed by a table of its contents
+func printReport(cmd *cobra.Command, r *solutionReport) {
+	lines := [][]string{}
+	for _, dep := range r.Dependencies {
+		lines = append(lines, []string{"dependency", dep, ""})
+	}
+	for _, t := range r.Types {
+		lines = append(lines, []string{"type", t.Name, t.File})
+	}
+	for _, o := range r.Objects {
+		lines = append(lines, []string{"objects", o.Type, fmt.Sprintf("%d in %s layer, from %s", o.Count, o.Layer, o.Source)})
+	}
+	for _, d := range r.Dashboards {
+		lines = append(lines, []string{"dashboard", d.Name, d.Type})
+	}
+	for _, m := range r.Metrics {
+		lines = append(lines, []string{"metric", m.Name, joinNonEmpty(", ", m.ContentType, m.Category, m.Unit)})
+	}
+
+	format, _ := cmd.Flags().GetString("output")
+	if format == "" || format == "auto" || format == "table" {
+		header := fmt.Sprintf("Solution %s version %s (%s)\n", r.Name, r.Version, r.Source)
+		if r.Description != "" {
+			header += r.Description + "\n"
+		}
+		output.PrintCmdStatus(cmd, header+"\n")
+	}
+	output.PrintCmdOutputCustom(cmd, r, &output.Table{
+		Headers: []string{"Kind", "Name", "Details"},
+		Lines:   lines,
+	})
+	for _, problem := range r.Problems {
+		log.Warnf("Problem in solution %s: %s", r.Name, problem)
+	}
+}
+
+// writeReportMarkdown writes the inventory as a Markdown document
+func writeReportMarkdown(w io.Writer, r *solutionReport) {
+	fmt.Fprintf(w, "# Solution %s\n\n", r.Name)
+	if r.Description != "" {
+		fmt.Fprintf(w, "%s\n\n", r.Description)
+	}
+	fmt.Fprintf(w, "| Property | Value |\n| --- | --- |\n")
+	fmt.Fprintf(w, "| Version | %s |\n", markdownCell(r.Version))
+	fmt.Fprintf(w, "| Source | %s |\n", markdownCell(r.Source))
+	for _, prop := range [][2]string{{"Updated", r.UpdatedAt}, {"Contact", r.Contact}, {"Home page", r.HomePage}, {"Repository", r.GitRepoUrl}} {
+		if prop[1] != "" {
+			fmt.Fprintf(w, "| %s | %s |\n", prop[0], markdownCell(prop[1]))
+		}
+	}
+
+	fmt.Fprintf(w, "\n## Dependencies\n\n")
+	if len(r.Dependencies) == 0 {
+		fmt.Fprintf(w, "None\n")
+	}
+	for _, dep := range r.Dependencies {
+		fmt.Fprintf(w, "- %s\n", dep)
+	}
+
+	writeMarkdownTable(w, "Types", []string{"Type", "Allowed layers", "File"}, len(r.Types), func(i int) []string {
+		t := r.Types[i]
+		return []string{t.Name, strings.Join(t.AllowedLayers, ", "), t.File}
+	})
+	writeMarkdownTable(w, "Objects", []string{"Type", "Layer", "Count", "Source"}, len(r.Objects), func(i int) []string {
+		o := r.Objects[i]
+		return []string{o.Type, o.Layer, strconv.Itoa(o.Count), o.Source}
+	})
+	writeMarkdownTable(w, "Dashboards", []string{"Name", "Type", "File"}, len(r.Dashboards), func(i int) []string {
+		d := r.Dashboards[i]
+		return []string{d.Name, d.Type, d.File}
+	})
+	writeMarkdownTable(w, "Metrics", []string{"Metric", "Content type", "Category", "Unit"}, len(r.Metrics), func(i int) []string {
+		m := r.Metrics[i]
+		return []string{m.Name, m.ContentType, m.Category, m.Unit}
+	})
+
+	if len(r.Problems) > 0 {
+		fmt.Fprintf(w, "\n## Problems\n\n")
+		for _, problem := range r.Problems {
+			fmt.Fprintf(w, "- %s\n", problem)
+		}
+	}
+}
+
+// writeMarkdownTable writes a section with a table of n rows, or "None" if there are no rows
+func writeMarkdownTable(w io.Writer, title string, headers []string, n int, row func(i int) []string) {
+	fmt.Fprintf(w, "\n## %s\n\n", title)
+	if n == 0 {
+		fmt.Fprintf(w, "None\n")
+		return
+	}
+	fmt.Fprintf(w, "| %s |\n|%s\n", strings.Join(headers, " | "), strings.Repeat(" --- |", len(headers)))
+	for i := 0; i < n; i++ {
+		cells := row(i)
+		for j := range cells {
+			cells[j] = markdownCell(cells[j])
+		}
+		fmt.Fprintf(w, "| %s |\n", strings.Join(cells, " | "))
+	}
+}
+
+// markdownCell escapes a value for use in a Markdown table cell
+func markdownCell(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "|", "\\|"), "\n", " ")
+}
+
+func joinNonEmpty(sep string, values ...string) string {
+	var parts []string
+	for _, v := range values {
+		if v != "" {
+			parts = append(parts, v)
+		}
+	}
+	return strings.Join(parts, sep)
+}
+
+func isLocalDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
 
-	log.Infof("Getting details of the '%s' solution", solution)
-	var res Solution
-	_ = api.JSONGet(getSolutionDescribeUrl(url.PathEscape(solution)), &res, &api.Options{Headers: headers})
-	fmt.Printf("ID: %s\n", res.ID)
-	fmt.Printf("LayerID: %s\n", res.LayerID)
-	fmt.Printf("layerType: %s\n", res.LayerType)
-	fmt.Printf("ObjectMimeType: %s\n", res.ObjectMimeType)
-	fmt.Printf("TargetObjectId: %s\n", res.TargetObjectId)
-	fmt.Printf("CreatedAt: %s\n", res.CreatedAt)
-	fmt.Printf("UpdatedAt: %s\n", res.UpdatedAt)
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
 }
 
 func getSolutionDescribeUrl(id string) string {
-	//println(id)
 	return "objstore/v1beta/objects/extensibility:solution/" + id
 }
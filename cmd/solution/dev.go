@@ -0,0 +1,344 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package solution
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/cobra"
+
+	"github.com/cisco-open/fsoc/cmdkit/interrupt"
+	"github.com/cisco-open/fsoc/cmdkit/progress"
+	"github.com/cisco-open/fsoc/output"
+)
+
+const (
+	defaultDevDebounce = time.Second
+	devInstallTimeout  = 5 * time.Minute
+)
+
+var solutionDevCmd = &cobra.Command{
+	Use:   "dev",
+	Short: "Push the solution automatically whenever its files change",
+	Long: `This command runs a development loop for solution authors: it watches the solution directory for
+changes and, each time files change, validates the solution locally and pushes it to the current tenant.
+
+The solution is always deployed as an isolated copy (see "fsoc solution push --isolate"), so that the
+development loop never replaces the solution that others use: the tag is specified with --tag or taken
+from the FSOC_SOLUTION_TAG environment variable, or derived from the user name.
+
+Changes are debounced: the solution is pushed once no file has changed for the --debounce time, so that
+saving several files at once results in a single push. Files excluded by .fsocignore and editor
+temporary files are not watched. After each push, the command waits for the solution to be installed,
+unless --no-wait is specified, and displays the outcome of the last deployment. A solution that fails
+the local validation (missing files or invalid JSON) is not pushed; the errors are displayed instead,
+along with any warnings about the schemas. An unchanged solution is not pushed
+again (see "fsoc solution push --diff"), unless --full is specified.
+
+The command runs until interrupted with Ctrl+C.`,
+	Example: `  fsoc solution dev
+  fsoc solution dev --tag jd --debounce 3s
+  fsoc solution dev --directory mysolution --no-wait`,
+	Args:             cobra.NoArgs,
+	Run:              solutionDev,
+	TraverseChildren: true,
+}
+
+func getSolutionDevCmd() *cobra.Command {
+	solutionDevCmd.Flags().String("directory", ".", "Path to the solution root directory")
+	solutionDevCmd.Flags().String("tag", "", fmt.Sprintf("Tag of the isolated deployment (default: the %s environment variable or derived from the user name)", solutionTagEnvVar))
+	solutionDevCmd.Flags().Duration("debounce", defaultDevDebounce, "Time without changes to wait for before pushing the solution")
+	solutionDevCmd.Flags().Bool("no-wait", false, "Don't wait for the solution to be installed after each push")
+	solutionDevCmd.Flags().Bool("full", false, "Push the solution each time, even if it is unchanged")
+
+	return solutionDevCmd
+}
+
+// devDeployment is the outcome of an attempt to deploy the solution in the development loop
+type devDeployment struct {
+	solution string
+	version  string
+	ok       bool
+	outcome  string
+	duration time.Duration
+}
+
+func solutionDev(cmd *cobra.Command, args []string) {
+	dir, _ := cmd.Flags().GetString("directory")
+	debounce, _ := cmd.Flags().GetDuration("debounce")
+	noWait, _ := cmd.Flags().GetBool("no-wait")
+	full, _ := cmd.Flags().GetBool("full")
+	tag, _ := cmd.Flags().GetString("tag")
+	if tag == "" {
+		tag = defaultIsolationTag()
+		if tag == "" {
+			log.Fatalf("Cannot determine the isolation tag; please set %s or use --tag", solutionTagEnvVar)
+		}
+	}
+	if !solutionTagRegexp.MatchString(tag) {
+		log.Fatalf("Invalid tag %q: only lowercase letters and digits are allowed", tag)
+	}
+	if !isSolutionPackageRoot(dir) {
+		log.Fatalf("%q is not a solution root directory", dir)
+	}
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		log.Fatalf("Failed to determine the solution directory: %v", err)
+	}
+
+	tempDir, err := os.MkdirTemp("", "fsoc-dev-")
+	if err != nil {
+		log.Fatalf("Failed to create a temporary directory: %v", err)
+	}
+	defer interrupt.OnCleanup(func() { os.RemoveAll(tempDir) })()
+
+	watcher, err := newSolutionWatcher(root)
+	if err != nil {
+		log.Fatalf("Failed to watch the solution directory: %v", err)
+	}
+	defer watcher.Close()
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	deploy := func() {
+		d := deploySolutionDev(ctx, cmd, root, tempDir, tag, full, !noWait)
+		if ctx.Err() != nil {
+			return
+		}
+		printDevDeployment(cmd, d)
+		output.PrintCmdStatus(cmd, fmt.Sprintf("Watching %s for changes (press Ctrl+C to stop)\n", dir))
+	}
+	deploy()
+
+	var pending <-chan time.Time // fires when the changes have settled
+	for {
+		select {
+		case <-ctx.Done():
+			output.PrintCmdStatus(cmd, "Stopped watching the solution.\n")
+			return
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if watcher.relevant(event) {
+				log.WithFields(log.Fields{"file": event.Name, "op": event.Op.String()}).Info("Solution file changed")
+				pending = time.After(debounce)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Warnf("Error watching the solution files: %v", err)
+		case <-pending:
+			pending = nil
+			deploy()
+		}
+	}
+}
+
+// deploySolutionDev validates the solution in the directory and, if valid, pushes it isolated with the tag,
+// waiting for it to be installed if requested. Unless full is true, an unchanged solution is not pushed
+// again (see uploadSolutionDiff). Errors are reported in the outcome rather than exiting.
+func deploySolutionDev(ctx context.Context, cmd *cobra.Command, root string, tempDir string, tag string, full bool, wait bool) devDeployment {
+	started := time.Now()
+	d := devDeployment{}
+	finish := func(ok bool, format string, a ...any) devDeployment {
+		d.ok = ok
+		d.outcome = fmt.Sprintf(format, a...)
+		d.duration = time.Since(started)
+		return d
+	}
+
+	manifest, err := getSolutionManifest(root)
+	if err != nil {
+		return finish(false, "failed to read the solution manifest: %v", err)
+	}
+	d.solution = isolatedSolutionName(manifest.Name, tag)
+	d.version = manifest.SolutionVersion
+
+	if issues := validateSolutionDir(root); len(issues) > 0 {
+		printValidationIssues(cmd, issues)
+		if errCount := countErrors(issues); errCount > 0 {
+			return finish(false, "not pushed, %d error(s) found by the local validation", errCount)
+		}
+	}
+
+	archivePath, err := createSolutionArchive(root, filepath.Join(tempDir, manifest.Name+".zip"), "", tag, 0)
+	if err != nil {
+		return finish(false, "failed to package the solution: %v", err)
+	}
+	output.PrintCmdStatus(cmd, fmt.Sprintf("Deploying solution %s version %s\n", d.solution, d.version))
+	var pushStartTime time.Time
+	record := &pushRecord{}
+	if full {
+		pushStartTime, err = postSolutionArchive(cmd, archivePath, d.solution, defaultUploadRetries)
+	} else {
+		pushStartTime, record, err = uploadSolutionDiff(cmd, archivePath, d.solution, d.version, defaultUploadRetries)
+		if err == nil && record == nil {
+			return finish(true, "unchanged, not pushed")
+		}
+	}
+	if err != nil {
+		return finish(false, "push failed: %v", err)
+	}
+	if !wait {
+		return finish(true, "pushed")
+	}
+
+	installed, message, err := waitForDevInstall(ctx, d.solution, d.version, pushStartTime)
+	switch {
+	case err != nil:
+		return finish(false, "pushed, but the installation status is unknown: %v", err)
+	case !installed:
+		return finish(false, "installation failed: %s", message)
+	}
+	record.confirm()
+	return finish(true, "installed")
+}
+
+// waitForDevInstall polls the installation status of the solution version pushed at the given time,
+// like waitForDeployment, returning whether the installation succeeded and its message
+func waitForDevInstall(ctx context.Context, solutionName string, solutionVersion string, since time.Time) (bool, string, error) {
+	spinner := progress.Start("Solution installation")
+	deadline := time.Now().Add(devInstallTimeout)
+	for {
+		status, err := getInstallStatus(solutionName, solutionVersion)
+		if err != nil {
+			spinner.Stop(false)
+			return false, "", err
+		}
+		if status.StatusData.SolutionVersion == solutionVersion && !statusPredates(status, since) {
+			spinner.Stop(status.StatusData.SuccessfulInstall)
+			return status.StatusData.SuccessfulInstall, status.StatusData.InstallMessage, nil
+		}
+		if time.Now().After(deadline) {
+			spinner.Stop(false)
+			return false, "", fmt.Errorf("timed out after %v", devInstallTimeout)
+		}
+		spinner.Update(fmt.Sprintf("waiting for %v", time.Since(since).Round(time.Second)))
+		select {
+		case <-ctx.Done():
+			spinner.Stop(false)
+			return false, "", ctx.Err()
+		case <-time.After(deploymentPollInterval):
+		}
+	}
+}
+
+// printDevDeployment displays the outcome of the last deployment
+func printDevDeployment(cmd *cobra.Command, d devDeployment) {
+	mark := "OK"
+	if !d.ok {
+		mark = "FAILED"
+	}
+	solution := ""
+	if d.solution != "" {
+		solution = fmt.Sprintf(" %s version %s", d.solution, d.version)
+	}
+	output.PrintCmdStatus(cmd, fmt.Sprintf("[%s] %s: last deployment of solution%s: %s (%v)\n",
+		time.Now().Format("15:04:05"), mark, solution, d.outcome, d.duration.Round(time.Second)))
+}
+
+// solutionWatcher watches the files of a solution directory that are included in its archive,
+// recursively, adding the directories created while watching
+type solutionWatcher struct {
+	*fsnotify.Watcher
+	root  string
+	rules *ignoreRules
+}
+
+func newSolutionWatcher(root string) (*solutionWatcher, error) {
+	rules, err := loadIgnoreRules(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", fsocIgnoreFileName, err)
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	w := &solutionWatcher{Watcher: watcher, root: root, rules: rules}
+	if err := w.addDirs(root); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// addDirs watches the directory and its subdirectories, except the ignored ones
+func (w *solutionWatcher) addDirs(dir string) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		if path != w.root && w.rules.ignored(w.relPath(path), true) {
+			return filepath.SkipDir
+		}
+		return w.Add(path)
+	})
+}
+
+func (w *solutionWatcher) relPath(path string) string {
+	rel, err := filepath.Rel(w.root, path)
+	if err != nil {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
+
+// relevant returns true if the change affects the solution archive; it starts watching new directories
+// and reloads the ignore rules when .fsocignore changes
+func (w *solutionWatcher) relevant(event fsnotify.Event) bool {
+	rel := w.relPath(event.Name)
+	if rel == fsocIgnoreFileName {
+		if rules, err := loadIgnoreRules(w.root); err != nil {
+			log.Warnf("Failed to read %s: %v", fsocIgnoreFileName, err)
+		} else {
+			w.rules = rules
+		}
+		return true
+	}
+	if event.Op == fsnotify.Chmod || isEditorTempFile(filepath.Base(event.Name)) {
+		return false
+	}
+	info, err := os.Stat(event.Name)
+	isDir := err == nil && info.IsDir()
+	if w.rules.ignored(rel, isDir) {
+		return false
+	}
+	if isDir && event.Op.Has(fsnotify.Create) {
+		if err := w.addDirs(event.Name); err != nil {
+			log.Warnf("Failed to watch directory %s: %v", event.Name, err)
+		}
+	}
+	return true
+}
+
+// isEditorTempFile returns true for the backup, swap and lock files that editors create while editing
+func isEditorTempFile(name string) bool {
+	return strings.HasSuffix(name, "~") || strings.HasSuffix(name, ".swp") || strings.HasSuffix(name, ".swx") ||
+		strings.HasPrefix(name, ".#") || name == "4913" // vim's check for writable directories
+}
@@ -0,0 +1,270 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package solution
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/slices"
+
+	"github.com/cisco-open/fsoc/cmdkit/interrupt"
+	"github.com/cisco-open/fsoc/cmdkit/jsondiff"
+	"github.com/cisco-open/fsoc/exitcode"
+	"github.com/cisco-open/fsoc/output"
+)
+
+// file and value change kinds
+const (
+	diffAdded   = jsondiff.Added
+	diffRemoved = jsondiff.Removed
+	diffChanged = jsondiff.Changed
+)
+
+// maximum length of a value displayed in the diff table
+const diffValueDisplayLength = 40
+
+type fileDiff struct {
+	File    string       `json:"file" yaml:"file"`
+	Status  string       `json:"status" yaml:"status"`
+	Engine  string       `json:"engine,omitempty" yaml:"engine,omitempty"` // diff engine used for a changed file
+	Changes []diffChange `json:"changes,omitempty" yaml:"changes,omitempty"`
+}
+
+type diffChange = jsondiff.Change
+
+var solutionDiffCmd = &cobra.Command{
+	Use:   "diff [DIR]",
+	Short: "Compare a solution directory with the deployed solution",
+	Long: `This command downloads the solution currently deployed in the tenant specified in the profile and
+compares it with the solution in the specified directory (or the current directory), so that the changes
+can be reviewed before pushing the solution.
+
+The files added, removed and changed in the local directory are listed, together with the differences
+within each changed file, as found by a diff engine. By default (--engine=auto), the engine is selected
+by the file type and size:
+  - json: for JSON files, such as the manifest, the knowledge types and objects, the individual values
+    that differ are listed, ignoring formatting and the order of object members. Array elements that
+    have an "id" or "name" field are matched by it, so that reordering them is not reported as a change.
+    This is most useful for large objects, where a line diff is hard to follow.
+  - line: for other text files, the lines that differ are listed.
+  - summary: for binary files and text files too large for a line diff, only the sizes are listed.
+An engine can also be selected explicitly; the summary is used for files the engine cannot compare.
+
+Alternatively, each file that differs can be passed to an external diff tool with --diff-tool (or the
+FSOC_DIFF_TOOL environment variable). The tool is run with the paths of the deployed and the local
+versions of the file appended to its command line.
+
+Files excluded by .fsocignore are not compared.
+
+The solution to compare with is the one named in the local manifest, unless specified with --name.
+A solution bundle .zip file can be used instead of the deployed solution with --solution-bundle.
+
+Examples:
+  fsoc solution diff
+  fsoc solution diff mysolution --name=mysolution-dev
+  fsoc solution diff --solution-bundle=mysolution-1.0.0.zip -o json
+  fsoc solution diff --engine=line
+  fsoc solution diff --diff-tool="diff -u"`,
+	Args:             cobra.MaximumNArgs(1),
+	Run:              diffSolution,
+	TraverseChildren: true,
+}
+
+func getSolutionDiffCmd() *cobra.Command {
+	solutionDiffCmd.Flags().String("name", "", "name of the deployed solution to compare with (default is the name in the local manifest)")
+	solutionDiffCmd.Flags().String("solution-bundle", "", "solution bundle .zip file to compare with, instead of the deployed solution")
+	solutionDiffCmd.Flags().Bool("exit-code", false, "exit with code 1 if there are differences")
+	solutionDiffCmd.Flags().String("engine", diffEngineAuto, fmt.Sprintf("diff engine for changed files, one of {%s}", strings.Join(diffEngineNames(), ", ")))
+	solutionDiffCmd.Flags().String("diff-tool", os.Getenv("FSOC_DIFF_TOOL"), "external diff tool command to run for each file that differs, e.g., \"diff -u\"")
+	solutionDiffCmd.MarkFlagsMutuallyExclusive("name", "solution-bundle")
+	solutionDiffCmd.MarkFlagsMutuallyExclusive("engine", "diff-tool")
+	return solutionDiffCmd
+}
+
+func diffSolution(cmd *cobra.Command, args []string) {
+	engine, _ := cmd.Flags().GetString("engine")
+	if !slices.Contains(diffEngineNames(), engine) {
+		log.Fatalf("Invalid --engine %q; must be one of {%s}", engine, strings.Join(diffEngineNames(), ", "))
+	}
+	diffTool, _ := cmd.Flags().GetString("diff-tool")
+
+	solutionPath := "."
+	if len(args) > 0 {
+		solutionPath = args[0]
+	}
+	if !isSolutionPackageRoot(solutionPath) {
+		log.Fatalf("%q is not a solution package root folder", solutionPath)
+	}
+	manifest, err := getSolutionManifest(solutionPath)
+	if err != nil {
+		log.Fatalf("Failed to read the solution manifest: %v", err)
+	}
+
+	// archive the local directory the same way it would be pushed
+	localArchive := &bytes.Buffer{}
+	if err := zipSolutionDir(localArchive, solutionPath, archiveOptions{rootName: manifest.Name}); err != nil {
+		log.Fatalf("Failed to read the solution directory %q: %v", solutionPath, err)
+	}
+	localFiles, err := readArchiveFiles(localArchive.Bytes())
+	if err != nil {
+		log.Fatalf("Failed to read the solution directory %q: %v", solutionPath, err)
+	}
+
+	// get the deployed solution (or the specified bundle)
+	bundlePath, _ := cmd.Flags().GetString("solution-bundle")
+	if bundlePath == "" {
+		name, _ := cmd.Flags().GetString("name")
+		if name == "" {
+			name = manifest.Name
+		}
+		var cleanup func()
+		bundlePath, cleanup = downloadSolutionToTemp(name)
+		defer cleanup()
+	}
+	bundleData, err := os.ReadFile(bundlePath)
+	if err != nil {
+		log.Fatalf("Failed to read the solution bundle: %v", err)
+	}
+	remoteFiles, err := readArchiveFiles(bundleData)
+	if err != nil {
+		log.Fatalf("Failed to read the solution bundle: %v", err)
+	}
+
+	diffs := diffSolutionFiles(remoteFiles, localFiles, engine)
+	if len(diffs) == 0 {
+		output.PrintCmdStatus(cmd, "No differences found.\n")
+		return
+	}
+	if diffTool != "" {
+		if err := runDiffTool(cmd, diffTool, diffs, remoteFiles, localFiles); err != nil {
+			log.Fatal(err.Error())
+		}
+	} else {
+		printSolutionDiff(cmd, diffs)
+	}
+	if exitCode, _ := cmd.Flags().GetBool("exit-code"); exitCode {
+		log.WithField(exitcode.Field, exitcode.General).Fatalf("Found differences in %d file(s)", len(diffs))
+	}
+}
+
+// downloadSolutionToTemp downloads the deployed solution into a temporary directory and
+// returns the path of the downloaded bundle and the function that removes the directory
+func downloadSolutionToTemp(solutionName string) (string, func()) {
+	dir, err := os.MkdirTemp("", "fsoc-diff-")
+	if err != nil {
+		log.Fatalf("Failed to create a temporary directory: %v", err)
+	}
+	cleanup := interrupt.OnCleanup(func() { os.RemoveAll(dir) })
+	bundlePath := filepath.Join(dir, getSolutionNameWithZip(solutionName))
+	if _, err := downloadSolutionBundle(solutionName, "", bundlePath); err != nil {
+		cleanup()
+		log.Fatalf("Failed to download solution %q: %v", solutionName, err)
+	}
+	return bundlePath, cleanup
+}
+
+// readArchiveFiles returns the contents of the files in a solution archive, keyed by their
+// slash-separated path relative to the solution root (i.e., without the top-level folder)
+func readArchiveFiles(data []byte) (map[string][]byte, error) {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	// the manifest locates the solution root: either the archive root or a top-level folder
+	root := ""
+	for _, f := range reader.File {
+		dir, base := path.Split(f.Name)
+		if base == "manifest.json" && strings.Count(dir, "/") <= 1 {
+			root = dir
+			break
+		}
+	}
+
+	files := map[string][]byte{}
+	for _, f := range reader.File {
+		if f.FileInfo().IsDir() || !strings.HasPrefix(f.Name, root) {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		contents := &bytes.Buffer{}
+		_, err = contents.ReadFrom(rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %q: %w", f.Name, err)
+		}
+		files[strings.TrimPrefix(f.Name, root)] = contents.Bytes()
+	}
+	return files, nil
+}
+
+// diffSolutionFiles compares the files of two solutions using the specified diff engine (or
+// automatically selected engines), returning the differences sorted by file name
+func diffSolutionFiles(oldFiles map[string][]byte, newFiles map[string][]byte, engine string) []fileDiff {
+	diffs := []fileDiff{}
+	for name, newData := range newFiles {
+		oldData, found := oldFiles[name]
+		if !found {
+			diffs = append(diffs, fileDiff{File: name, Status: diffAdded})
+			continue
+		}
+		if bytes.Equal(oldData, newData) {
+			continue
+		}
+		changes, usedEngine := diffFile(engine, name, oldData, newData)
+		if len(changes) > 0 { // otherwise equivalent, e.g., differing only in formatting
+			diffs = append(diffs, fileDiff{File: name, Status: diffChanged, Engine: usedEngine, Changes: changes})
+		}
+	}
+	for name := range oldFiles {
+		if _, found := newFiles[name]; !found {
+			diffs = append(diffs, fileDiff{File: name, Status: diffRemoved})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].File < diffs[j].File })
+	return diffs
+}
+
+func printSolutionDiff(cmd *cobra.Command, diffs []fileDiff) {
+	lines := [][]string{}
+	for _, d := range diffs {
+		if len(d.Changes) == 0 {
+			lines = append(lines, []string{d.File, d.Status, "", "", ""})
+			continue
+		}
+		for _, c := range d.Changes {
+			lines = append(lines, []string{d.File, c.Change, c.Path, jsondiff.DisplayValue(c.Old, diffValueDisplayLength), jsondiff.DisplayValue(c.New, diffValueDisplayLength)})
+		}
+	}
+	output.PrintCmdOutputCustom(cmd, struct {
+		Items []fileDiff `json:"items"`
+		Total int        `json:"total"`
+	}{Items: diffs, Total: len(diffs)}, &output.Table{
+		Headers: []string{"File", "Change", "Path", "Deployed", "Local"},
+		Lines:   lines,
+	})
+}
@@ -0,0 +1,241 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package solution
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/slices"
+
+	"github.com/cisco-open/fsoc/cmdkit/jsondiff"
+)
+
+// diff engine names, as used with the --engine flag
+const (
+	diffEngineAuto    = "auto"
+	diffEngineJSON    = "json"
+	diffEngineLine    = "line"
+	diffEngineSummary = "summary"
+)
+
+// lineDiffMaxCells limits the line diff to files whose line counts, multiplied, do not exceed it;
+// beyond that a line diff is both expensive and too long to be useful
+const lineDiffMaxCells = 4_000_000
+
+// diffEngine compares the deployed and the local contents of a file
+type diffEngine interface {
+	// diff returns the changes between the contents; ok is false if the engine cannot
+	// compare them (e.g., JSON diff of invalid JSON), in which case another engine is tried
+	diff(oldData []byte, newData []byte) (changes []diffChange, ok bool)
+}
+
+var diffEngines = map[string]diffEngine{
+	diffEngineJSON:    jsonDiffEngine{},
+	diffEngineLine:    lineDiffEngine{},
+	diffEngineSummary: summaryDiffEngine{},
+}
+
+// diffEngineNames returns the names accepted by the --engine flag
+func diffEngineNames() []string {
+	return []string{diffEngineAuto, diffEngineJSON, diffEngineLine, diffEngineSummary}
+}
+
+// selectDiffEngines returns the names of the engines to try, in order, for a changed file.
+// The automatic selection uses the semantic JSON diff for JSON files, which is meaningful
+// regardless of their size and formatting, a line diff for other text files (and JSON files
+// that fail to parse) and a size summary for binary files and text files too large for a line diff.
+func selectDiffEngines(engine string, file string) []string {
+	switch {
+	case engine != diffEngineAuto:
+		return []string{engine, diffEngineSummary}
+	case path.Ext(file) == ".json":
+		return []string{diffEngineJSON, diffEngineLine, diffEngineSummary}
+	default:
+		return []string{diffEngineLine, diffEngineSummary}
+	}
+}
+
+// diffFile compares the two versions of a changed file using the first engine that can handle them.
+// It returns the changes and the name of the engine used; no changes means that the contents are
+// equivalent (e.g., JSON that differs only in formatting).
+func diffFile(engine string, file string, oldData []byte, newData []byte) ([]diffChange, string) {
+	for _, name := range selectDiffEngines(engine, file) {
+		if changes, ok := diffEngines[name].diff(oldData, newData); ok {
+			return changes, name
+		}
+		log.WithFields(log.Fields{"file": file, "engine": name}).Info("Diff engine cannot compare the file, trying the next one")
+	}
+	return nil, "" // unreachable, the summary engine handles all contents
+}
+
+// jsonDiffEngine compares JSON documents value by value (see jsondiff.Diff)
+type jsonDiffEngine struct{}
+
+func (jsonDiffEngine) diff(oldData []byte, newData []byte) ([]diffChange, bool) {
+	var oldValue, newValue any
+	if json.Unmarshal(oldData, &oldValue) != nil || json.Unmarshal(newData, &newValue) != nil {
+		return nil, false
+	}
+	return jsondiff.Diff(oldValue, newValue), true
+}
+
+// lineDiffEngine compares text files line by line, using the longest common subsequence of lines
+type lineDiffEngine struct{}
+
+func (lineDiffEngine) diff(oldData []byte, newData []byte) ([]diffChange, bool) {
+	if !isText(oldData) || !isText(newData) {
+		return nil, false
+	}
+	oldLines, newLines := splitLines(oldData), splitLines(newData)
+	if len(oldLines)*len(newLines) > lineDiffMaxCells {
+		return nil, false
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of oldLines[i:] and newLines[j:]
+	lcs := make([][]int32, len(oldLines)+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, len(newLines)+1)
+	}
+	for i := len(oldLines) - 1; i >= 0; i-- {
+		for j := len(newLines) - 1; j >= 0; j-- {
+			if oldLines[i] == newLines[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	// walk the table, collecting runs of removed and added lines; a run of removed lines followed
+	// by added lines is reported as changed lines, pairwise
+	changes := []diffChange{}
+	var removed, added []int
+	flush := func() {
+		for k := 0; k < len(removed) || k < len(added); k++ {
+			switch {
+			case k >= len(added):
+				changes = append(changes, diffChange{Path: lineDiffPath(removed[k]), Change: diffRemoved, Old: oldLines[removed[k]]})
+			case k >= len(removed):
+				changes = append(changes, diffChange{Path: lineDiffPath(added[k]), Change: diffAdded, New: newLines[added[k]]})
+			default:
+				changes = append(changes, diffChange{Path: lineDiffPath(added[k]), Change: diffChanged, Old: oldLines[removed[k]], New: newLines[added[k]]})
+			}
+		}
+		removed, added = nil, nil
+	}
+	i, j := 0, 0
+	for i < len(oldLines) || j < len(newLines) {
+		switch {
+		case i < len(oldLines) && j < len(newLines) && oldLines[i] == newLines[j]:
+			flush()
+			i++
+			j++
+		case j >= len(newLines) || (i < len(oldLines) && lcs[i+1][j] >= lcs[i][j+1]):
+			removed = append(removed, i)
+			i++
+		default:
+			added = append(added, j)
+			j++
+		}
+	}
+	flush()
+	return changes, true
+}
+
+func lineDiffPath(index int) string {
+	return fmt.Sprintf("line %d", index+1)
+}
+
+func splitLines(data []byte) []string {
+	text := strings.TrimSuffix(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+// isText returns true if the data looks like text: valid UTF-8 without NUL characters
+func isText(data []byte) bool {
+	return utf8.Valid(data) && !bytes.ContainsRune(data, 0)
+}
+
+// summaryDiffEngine reports only that the contents changed, with their sizes
+type summaryDiffEngine struct{}
+
+func (summaryDiffEngine) diff(oldData []byte, newData []byte) ([]diffChange, bool) {
+	return []diffChange{{
+		Change: diffChanged,
+		Old:    fmt.Sprintf("%d bytes", len(oldData)),
+		New:    fmt.Sprintf("%d bytes", len(newData)),
+	}}, true
+}
+
+// runDiffTool runs an external diff tool for each file that differs, passing it the paths of
+// temporary copies of the deployed and the local version (an empty file stands for a missing one).
+// The tool is specified as a command with optional arguments, e.g., "diff -u" or "code --diff --wait".
+func runDiffTool(cmd *cobra.Command, tool string, diffs []fileDiff, oldFiles map[string][]byte, newFiles map[string][]byte) error {
+	args := strings.Fields(tool)
+	if len(args) == 0 {
+		return fmt.Errorf("the diff tool command is empty")
+	}
+	dir, err := os.MkdirTemp("", "fsoc-difftool-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	for _, d := range diffs {
+		oldPath := filepath.Join(dir, "deployed", filepath.FromSlash(d.File))
+		newPath := filepath.Join(dir, "local", filepath.FromSlash(d.File))
+		if err := writeDiffToolFile(oldPath, oldFiles[d.File]); err != nil {
+			return err
+		}
+		if err := writeDiffToolFile(newPath, newFiles[d.File]); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "=== %s (%s)\n", d.File, d.Status)
+		toolCmd := exec.Command(args[0], append(slices.Clone(args[1:]), oldPath, newPath)...)
+		toolCmd.Stdin = os.Stdin
+		toolCmd.Stdout = cmd.OutOrStdout()
+		toolCmd.Stderr = cmd.ErrOrStderr()
+		if err := toolCmd.Run(); err != nil {
+			// diff tools commonly exit with 1 when the files differ
+			if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.ExitCode() > 1 {
+				return fmt.Errorf("diff tool %q failed for %q: %w", args[0], d.File, err)
+			}
+		}
+	}
+	return nil
+}
+
+func writeDiffToolFile(filePath string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(filePath), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(filePath, data, 0600)
+}
@@ -15,52 +15,170 @@ package solution
 
 import (
 	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/apex/log"
 	"github.com/spf13/cobra"
 
+	"github.com/cisco-open/fsoc/cmdkit/interrupt"
 	"github.com/cisco-open/fsoc/output"
 	"github.com/cisco-open/fsoc/platform/api"
 )
 
 var solutionDownloadCmd = &cobra.Command{
-	Use:   "download --name=SOLUTION",
+	Use:   "download [NAME]",
 	Short: "Download solution",
-	Long: `This command allows the current tenant specified in the profile to download a solution bundle archive into the current directory or the directory specified in command argument.
+	Long: `This command allows the current tenant specified in the profile to download a deployed solution, either as a
+solution bundle archive or extracted into a directory, e.g., to edit a solution whose source is not available
+or to back it up.
 
-Example: fsoc solution download --name=spacefleet`,
-	Args:             cobra.ExactArgs(0),
+By default, the currently deployed version is downloaded as NAME.zip into the current directory. A specific
+version can be downloaded with --version. With --dir, the solution's files are extracted into the specified
+directory, ready to be modified and pushed again.
+
+Examples:
+  fsoc solution download spacefleet
+  fsoc solution download spacefleet --version=1.2.0 --archive=backup/spacefleet-1.2.0.zip
+  fsoc solution download spacefleet --dir=spacefleet`,
+	Args:             cobra.MaximumNArgs(1),
 	Run:              downloadSolution,
 	TraverseChildren: true,
 }
 
 func getSolutionDownloadCmd() *cobra.Command {
-	solutionDownloadCmd.Flags().String("name", "", "name of the solution to download (required)")
-	_ = solutionDownloadCmd.MarkFlagRequired("name")
+	solutionDownloadCmd.Flags().String("name", "", "name of the solution to download")
+	_ = solutionDownloadCmd.Flags().MarkDeprecated("name", "please specify the solution name as an argument instead.")
+	solutionDownloadCmd.Flags().String("version", "", "version of the solution to download (default is the currently deployed version)")
+	solutionDownloadCmd.Flags().String("archive", "", "path of the solution bundle archive to create (default is NAME.zip)")
+	solutionDownloadCmd.Flags().String("dir", "", "directory to extract the solution into, instead of saving the archive")
+	solutionDownloadCmd.Flags().Bool("force", false, "extract into the directory even if it is not empty, overwriting existing files")
+	solutionDownloadCmd.MarkFlagsMutuallyExclusive("archive", "dir")
 	return solutionDownloadCmd
 }
 
 func downloadSolution(cmd *cobra.Command, args []string) {
 	solutionName, _ := cmd.Flags().GetString("name")
+	if len(args) > 0 {
+		if solutionName != "" && solutionName != args[0] {
+			log.Fatalf("Conflicting solution names %q and %q", args[0], solutionName)
+		}
+		solutionName = args[0]
+	}
 	if solutionName == "" {
-		log.Fatalf("Solution name cannot be empty, use --name=<solution-name>")
+		_ = cmd.Usage()
+		log.Fatalf("Solution name cannot be empty")
 	}
+	version, _ := cmd.Flags().GetString("version")
+	archivePath, _ := cmd.Flags().GetString("archive")
+	dir, _ := cmd.Flags().GetString("dir")
+	force, _ := cmd.Flags().GetBool("force")
 
-	var solutionNameWithZipExtension = getSolutionNameWithZip(solutionName)
+	if dir != "" {
+		if err := checkExtractDir(dir, force); err != nil {
+			log.Fatal(err.Error())
+		}
+		tempDir, err := os.MkdirTemp("", "fsoc-download-")
+		if err != nil {
+			log.Fatalf("Failed to create a temporary directory: %v", err)
+		}
+		defer interrupt.OnCleanup(func() { os.RemoveAll(tempDir) })()
+		archivePath = filepath.Join(tempDir, getSolutionNameWithZip(solutionName))
+	} else if archivePath == "" {
+		archivePath = getSolutionNameWithZip(solutionName)
+	}
 
+	manifest, err := downloadSolutionBundle(solutionName, version, archivePath)
+	if err != nil {
+		log.Fatalf("Solution download command failed: %v", err)
+	}
+
+	if dir != "" {
+		count, err := extractSolutionBundle(archivePath, dir)
+		if err != nil {
+			log.Fatalf("Failed to extract the solution into %q: %v", dir, err)
+		}
+		output.PrintCmdStatus(cmd, fmt.Sprintf("Solution %s version %s extracted into %q (%d files).\n", manifest.Name, manifest.SolutionVersion, dir, count))
+		return
+	}
+
+	message := fmt.Sprintf("Solution %s version %s downloaded successfully as %q.\n", manifest.Name, manifest.SolutionVersion, archivePath)
+	output.PrintCmdStatus(cmd, message)
+}
+
+// downloadSolutionBundle downloads the solution bundle archive into the specified file and
+// returns its manifest. If version is not empty, that version of the solution is downloaded.
+func downloadSolutionBundle(solutionName string, version string, archivePath string) (*Manifest, error) {
+	if err := os.MkdirAll(filepath.Dir(archivePath), 0755); err != nil {
+		return nil, err
+	}
 	headers := map[string]string{
 		"stage":            "STABLE",
 		"tag":              "stable",
-		"solutionFileName": solutionNameWithZipExtension,
+		"solutionFileName": archivePath,
+	}
+	path := getSolutionDownloadUrl(solutionName)
+	if version != "" {
+		path += "?solutionVersion=" + url.QueryEscape(version)
 	}
-	httpOptions := api.Options{Headers: headers}
 	bufRes := make([]byte, 0)
-	if err := api.HTTPGet(getSolutionDownloadUrl(solutionName), &bufRes, &httpOptions); err != nil {
-		log.Fatalf("Solution download command failed: %v", err)
+	if err := api.HTTPGet(path, &bufRes, &api.Options{Headers: headers}); err != nil {
+		return nil, err
 	}
 
-	message := fmt.Sprintf("Solution bundle %q downloaded successfully.\n", solutionName)
-	output.PrintCmdStatus(cmd, message)
+	manifest, err := readManifestFromArchive(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("the downloaded solution bundle is not valid: %w", err)
+	}
+	if version != "" && manifest.SolutionVersion != version {
+		os.Remove(archivePath)
+		return nil, fmt.Errorf("version %s of solution %s was requested but version %s was received", version, solutionName, manifest.SolutionVersion)
+	}
+	return manifest, nil
+}
+
+// checkExtractDir verifies that a solution can be extracted into the directory: it must not exist
+// or be empty, unless force is set
+func checkExtractDir(dir string, force bool) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("Failed to access directory %q: %w", dir, err)
+	}
+	if len(entries) > 0 && !force {
+		return fmt.Errorf("Directory %q is not empty; use --force to extract into it anyway", dir)
+	}
+	return nil
+}
+
+// extractSolutionBundle extracts the files of the solution in the archive into the directory,
+// placing the solution root (i.e., the manifest) directly in it. Returns the number of files extracted.
+func extractSolutionBundle(archivePath string, dir string) (int, error) {
+	data, err := os.ReadFile(archivePath)
+	if err != nil {
+		return 0, err
+	}
+	files, err := readArchiveFiles(data)
+	if err != nil {
+		return 0, err
+	}
+	for name, contents := range files {
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		if rel, err := filepath.Rel(dir, target); err != nil || strings.HasPrefix(rel, "..") {
+			return 0, fmt.Errorf("invalid file name %q in the archive", name)
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return 0, err
+		}
+		if err := os.WriteFile(target, contents, 0644); err != nil {
+			return 0, err
+		}
+	}
+	return len(files), nil
 }
 
 func getSolutionDownloadUrl(solutionName string) string {
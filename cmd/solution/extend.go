@@ -368,7 +368,7 @@ func appendDependency(solutionName string, manifest *Manifest) {
 func checkDependencyExists(solutionName string, manifest *Manifest) bool {
 	hasDependency := false
 	for _, deps := range manifest.Dependencies {
-		if deps == solutionName {
+		if dependencyName(deps) == solutionName {
 			hasDependency = true
 		}
 	}
@@ -0,0 +1,158 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package solution
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const fsocIgnoreFileName = ".fsocignore"
+
+// defaultIgnorePatterns are excluded from solution archives even without a .fsocignore file
+var defaultIgnorePatterns = []string{".git/", ".DS_Store", fsocIgnoreFileName, "/" + testSpecFileName}
+
+// ignoreRule is a single pattern of a .fsocignore file
+type ignoreRule struct {
+	regexp  *regexp.Regexp
+	negate  bool // pattern starts with "!", re-including matching files
+	dirOnly bool // pattern ends with "/", matching only directories
+}
+
+// ignoreRules decides which files of a solution directory are excluded from the solution archive.
+// The .fsocignore file uses the same syntax as .gitignore: one pattern per line, blank lines and
+// lines starting with # are ignored, "!" negates a pattern, a trailing "/" matches only directories,
+// a pattern containing a "/" (other than a trailing one) is relative to the solution root and
+// "*", "?" and "**" are wildcards. The last matching pattern wins.
+type ignoreRules struct {
+	rules []ignoreRule
+}
+
+// loadIgnoreRules reads the .fsocignore file in the solution directory, if present
+func loadIgnoreRules(solutionPath string) (*ignoreRules, error) {
+	r := &ignoreRules{}
+	for _, p := range defaultIgnorePatterns {
+		if err := r.add(p); err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := os.Open(filepath.Join(solutionPath, fsocIgnoreFileName))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return r, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if err := r.add(line); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", fsocIgnoreFileName, lineNo, err)
+		}
+	}
+	return r, scanner.Err()
+}
+
+func (r *ignoreRules) add(pattern string) error {
+	rule := ignoreRule{}
+	if strings.HasPrefix(pattern, "!") {
+		rule.negate = true
+		pattern = pattern[1:]
+	}
+	if strings.HasSuffix(pattern, "/") {
+		rule.dirOnly = true
+		pattern = strings.TrimSuffix(pattern, "/")
+	}
+	anchored := strings.Contains(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+	if pattern == "" {
+		return fmt.Errorf("invalid empty pattern")
+	}
+
+	expr := globToRegexp(pattern)
+	if !anchored {
+		expr = "(.*/)?" + expr
+	}
+	re, err := regexp.Compile("^" + expr + "$")
+	if err != nil {
+		return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	rule.regexp = re
+	r.rules = append(r.rules, rule)
+	return nil
+}
+
+// ignored returns true if the file or directory, specified by its slash-separated path
+// relative to the solution root, is to be excluded from the archive
+func (r *ignoreRules) ignored(relPath string, isDir bool) bool {
+	ignored := false
+	for _, rule := range r.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if rule.regexp.MatchString(relPath) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// globToRegexp converts a .gitignore-style glob into a regular expression
+func globToRegexp(glob string) string {
+	var sb strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			sb.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			sb.WriteString(".*")
+			i++
+		case c == '*':
+			sb.WriteString("[^/]*")
+		case c == '?':
+			sb.WriteString("[^/]")
+		case c == '[':
+			if end := strings.IndexByte(glob[i:], ']'); end > 0 {
+				class := glob[i+1 : i+end]
+				if strings.HasPrefix(class, "!") {
+					class = "^" + class[1:]
+				}
+				sb.WriteString("[" + class + "]")
+				i += end
+			} else {
+				sb.WriteString(regexp.QuoteMeta(string(c)))
+			}
+		case c == '\\' && i+1 < len(glob):
+			i++
+			sb.WriteString(regexp.QuoteMeta(string(glob[i])))
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return sb.String()
+}
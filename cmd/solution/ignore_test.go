@@ -0,0 +1,97 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package solution
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The expected outcomes match "git check-ignore" with the patterns in a .gitignore file
+func TestIgnoreRules(t *testing.T) {
+	for _, tc := range []struct {
+		patterns []string
+		path     string
+		isDir    bool
+		ignored  bool
+	}{
+		{[]string{"*.log"}, "a.log", false, true},
+		{[]string{"*.log"}, "dir/a.log", false, true},
+		{[]string{"*.log"}, "a.logx", false, false},
+		{[]string{"/root.txt"}, "root.txt", false, true},
+		{[]string{"/root.txt"}, "sub/root.txt", false, false},
+		{[]string{"build/"}, "build", true, true},
+		{[]string{"build/"}, "build", false, false},
+		{[]string{"build/"}, "src/build", true, true},
+		{[]string{"doc/*.txt"}, "doc/a.txt", false, true},
+		{[]string{"doc/*.txt"}, "doc/sub/a.txt", false, false},
+		{[]string{"doc/*.txt"}, "x/doc/a.txt", false, false},
+		{[]string{"**/foo"}, "foo", false, true},
+		{[]string{"**/foo"}, "a/b/foo", false, true},
+		{[]string{"a/**/b"}, "a/b", false, true},
+		{[]string{"a/**/b"}, "a/x/y/b", false, true},
+		{[]string{"a/**/b"}, "ax/b", false, false},
+		{[]string{"abc/**"}, "abc/x", false, true},
+		{[]string{"abc/**"}, "abc/x/y", false, true},
+		{[]string{"?.json"}, "a.json", false, true},
+		{[]string{"?.json"}, "ab.json", false, false},
+		{[]string{"?.json"}, "d/a.json", false, true},
+		{[]string{"[ab].txt"}, "a.txt", false, true},
+		{[]string{"[ab].txt"}, "c.txt", false, false},
+		{[]string{"[!ab].txt"}, "c.txt", false, true},
+		{[]string{"[!ab].txt"}, "a.txt", false, false},
+		{[]string{"*.json", "!manifest.json"}, "manifest.json", false, false},
+		{[]string{"*.json", "!manifest.json"}, "objects/a.json", false, true},
+		{[]string{"!keep.log", "*.log"}, "keep.log", false, true},
+		{[]string{`\#notes`}, "#notes", false, true},
+		{[]string{"foo"}, "foo", true, true},
+		{[]string{"foo"}, "x/foo", false, true},
+		{[]string{"foo/bar"}, "a/foo/bar", false, false},
+		{[]string{"foo/bar"}, "foo/bar", true, true},
+		{[]string{"*"}, "anything/at/all", false, true},
+	} {
+		r := &ignoreRules{}
+		for _, p := range tc.patterns {
+			require.NoError(t, r.add(p))
+		}
+		assert.Equal(t, tc.ignored, r.ignored(tc.path, tc.isDir), "patterns %q, path %q (dir %v)", tc.patterns, tc.path, tc.isDir)
+	}
+}
+
+func TestGlobToRegexp(t *testing.T) {
+	for glob, expected := range map[string]string{
+		"*.json":    `[^/]*\.json`,
+		"a?c":       `a[^/]c`,
+		"**/x":      `(.*/)?x`,
+		"x/**":      `x/.*`,
+		"[!a-c]":    `[^a-c]`,
+		"[abc":      `\[abc`,
+		`\*.txt`:    `\*\.txt`,
+		"file+name": `file\+name`,
+	} {
+		assert.Equal(t, expected, globToRegexp(glob), glob)
+	}
+}
+
+func TestIgnoreRulesDefaults(t *testing.T) {
+	r, err := loadIgnoreRules(t.TempDir())
+	require.NoError(t, err)
+	assert.True(t, r.ignored(".git", true))
+	assert.True(t, r.ignored("objects/.DS_Store", false))
+	assert.True(t, r.ignored(fsocIgnoreFileName, false))
+	assert.False(t, r.ignored("manifest.json", false))
+}
@@ -15,9 +15,12 @@
 package solution
 
 import (
+	"bufio"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"regexp"
 	"strings"
 
 	"github.com/apex/log"
@@ -32,59 +35,99 @@ var solutionInitCmd = &cobra.Command{
 	Short: "Create a new solution",
 	Long: `This command creates a skeleton of a solution in the current directory.
 
-Example: 
+The solution is created in a subdirectory with the solution's name and contains a solution manifest,
+the folder structure for knowledge types and objects and, optionally, example components.
+The solution properties can be provided with flags; if the --name flag is not provided, fsoc asks
+for each of the properties interactively (similar to "npm init"). Use --yes to accept the default
+values for all properties not provided with flags. The example components are added only when
+requested with the --include-... flags, or when accepted in the interactive mode.
+
+Examples:
 
    fsoc solution init --name=testSolution --include-service --include-knowledge
+   fsoc solution init
    
 Creates a subdirectory named "testSolution" in the current directory and populates
 it with a solution manifest and objects for it. The optional --include-... flags
 define what objects are added to the solution. Once the solution is created,
 the "solution extend" command can be used to add more objects.`,
+	Args:             cobra.NoArgs,
 	Run:              generateSolutionPackage,
 	Annotations:      map[string]string{config.AnnotationForConfigBypass: ""},
 	TraverseChildren: true,
 }
 
 // Planned options:
-//    include-service - Flag to include sample service component
 //    include-metric - Flag to include sample metric type component
-//    include-knowledge - Flag to include sample knowledge type component
 //    include-meltworkflow - Flag to include sample melt workflow
 //    include-dash-ui - Flag to include sample dash-ui template
 
+// initOptions are the properties of a solution to be created
+type initOptions struct {
+	name             string
+	description      string
+	solutionVersion  string
+	contact          string
+	homePage         string
+	gitRepoUrl       string
+	includeService   bool
+	includeKnowledge bool
+	includeObjects   bool
+}
+
+var solutionNameRegexp = regexp.MustCompile(`^[a-z][a-z0-9]*$`)
+
 func getInitSolutionCmd() *cobra.Command {
 	solutionInitCmd.Flags().
-		String("name", "", "The name of the new solution (required)")
-	_ = solutionInitCmd.MarkFlagRequired("name")
+		String("name", "", "The name of the new solution (prompted for if not provided)")
+	solutionInitCmd.Flags().
+		String("description", "description of your solution", "The description of the solution")
+	solutionInitCmd.Flags().
+		String("solution-version", "1.0.0", "The initial version of the solution")
+	solutionInitCmd.Flags().
+		String("contact", "the email for this solution's point of contact", "The email of the solution's point of contact")
+	solutionInitCmd.Flags().
+		String("homepage", "the url for this solution's homepage", "The URL of the solution's homepage")
+	solutionInitCmd.Flags().
+		String("git-repo-url", "the url for the git repo holding your solution", "The URL of the git repository holding the solution")
 
 	solutionInitCmd.Flags().
 		Bool("include-service", true, "Add a service component definition to this solution")
 	solutionInitCmd.Flags().
 		Bool("include-knowledge", true, "Add a knowledge type definition to this solution")
+	solutionInitCmd.Flags().
+		Bool("include-objects", true, "Add an example object of the knowledge type to this solution (requires --include-knowledge)")
+	solutionInitCmd.Flags().
+		BoolP("yes", "y", false, "Do not prompt, use the default values for the properties not provided with flags")
 
 	return solutionInitCmd
 }
 
 func generateSolutionPackage(cmd *cobra.Command, args []string) {
-
-	solutionName, _ := cmd.Flags().GetString("name")
-	solutionName = strings.ToLower(solutionName)
-
-	if len(solutionName) == 0 {
-		log.Fatal("A non-empty flag \"--name\" is required.")
+	opts := getInitOptions(cmd)
+	if !solutionNameRegexp.MatchString(opts.name) {
+		log.Fatalf("Invalid solution name %q: must start with a letter and contain only lowercase letters and digits", opts.name)
+	}
+	if _, err := os.Stat(opts.name); err == nil {
+		log.Fatalf("Solution init failed - %q already exists", opts.name)
 	}
 
-	output.PrintCmdStatus(cmd, fmt.Sprintf("Preparing the %s solution package folder structure... \n", solutionName))
+	output.PrintCmdStatus(cmd, fmt.Sprintf("Preparing the %s solution package folder structure... \n", opts.name))
 
-	if err := os.Mkdir(solutionName, os.ModePerm); err != nil {
+	if err := os.Mkdir(opts.name, os.ModePerm); err != nil {
 		log.Fatalf("Solution init failed - %v", err)
 	}
 
-	manifest := createInitialSolutionManifest(solutionName)
+	manifest := createInitialSolutionManifest(opts.name)
+	manifest.Description = opts.description
+	manifest.SolutionVersion = opts.solutionVersion
+	manifest.Contact = opts.contact
+	manifest.HomePage = opts.homePage
+	manifest.GitRepoUrl = opts.gitRepoUrl
 
-	if cmd.Flags().Changed("include-service") {
+	if opts.includeService {
 		output.PrintCmdStatus(cmd, "Adding the service-component.json \n")
-		folderName := solutionName + "/services"
+		folderName := opts.name + "/services"
 		fileName := "service-component.json"
 
 		manifest.Dependencies = append(manifest.Dependencies, "zodiac")
@@ -100,19 +143,132 @@ func generateSolutionPackage(cmd *cobra.Command, args []string) {
 		createComponentFile(serviceComp, folderName, fileName)
 	}
 
-	if cmd.Flags().Changed("include-knowledge") {
+	if opts.includeKnowledge {
 		output.PrintCmdStatus(cmd, "Adding the knowledge-component.json \n")
-		folderName := solutionName + "/knowledge"
+		folderName := opts.name + "/knowledge"
 		fileName := "knowledge-component.json"
 		manifest.Types = append(manifest.Types, fmt.Sprintf("knowledge/%s", fileName))
 
 		knowledgeComp := createKnowledgeComponent(manifest)
 		createComponentFile(knowledgeComp, folderName, fileName)
+
+		if opts.includeObjects {
+			objectsDir := fmt.Sprintf("objects/%s", knowledgeComp.Name)
+			output.PrintCmdStatus(cmd, fmt.Sprintf("Adding the %s/sample.json \n", objectsDir))
+			manifest.Objects = append(manifest.Objects, ComponentDef{
+				Type:       fmt.Sprintf("%s:%s", opts.name, knowledgeComp.Name),
+				ObjectsDir: objectsDir,
+			})
+			appendFolder(opts.name + "/objects")
+			createComponentFile(createKnowledgeObject(), opts.name+"/"+objectsDir, "sample.json")
+		}
 	}
 
 	output.PrintCmdStatus(cmd, "Adding the manifest.json \n")
-	createSolutionManifestFile(solutionName, manifest)
+	createSolutionManifestFile(opts.name, manifest)
 
+	output.PrintCmdStatus(cmd, fmt.Sprintf("Solution %s created in %q; use \"fsoc solution extend\" from that directory to add more components\n", opts.name, opts.name))
+}
+
+// getInitOptions collects the solution properties from the flags and, if the solution
+// name is not provided and --yes is not specified, by prompting the user
+func getInitOptions(cmd *cobra.Command) *initOptions {
+	flags := cmd.Flags()
+	opts := &initOptions{}
+	opts.name, _ = flags.GetString("name")
+	opts.description, _ = flags.GetString("description")
+	opts.solutionVersion, _ = flags.GetString("solution-version")
+	opts.contact, _ = flags.GetString("contact")
+	opts.homePage, _ = flags.GetString("homepage")
+	opts.gitRepoUrl, _ = flags.GetString("git-repo-url")
+	opts.includeService, _ = flags.GetBool("include-service")
+	opts.includeKnowledge, _ = flags.GetBool("include-knowledge")
+	opts.includeObjects, _ = flags.GetBool("include-objects")
+
+	yes, _ := flags.GetBool("yes")
+	if flags.Changed("name") || yes {
+		if opts.name == "" {
+			log.Fatal("A non-empty flag \"--name\" is required.")
+		}
+		opts.name = strings.ToLower(opts.name)
+		// the components are added only when requested explicitly; they are offered only interactively
+		opts.includeService = opts.includeService && flags.Changed("include-service")
+		opts.includeKnowledge = opts.includeKnowledge && flags.Changed("include-knowledge")
+		return opts
+	}
+
+	// interactive mode: ask for each property not provided with flags
+	output.PrintCmdStatus(cmd, "This utility will walk you through creating a new solution.\nPress ^C at any time to quit.\n\n")
+	p := &prompter{in: bufio.NewReader(cmd.InOrStdin()), cmd: cmd}
+	for opts.name == "" {
+		opts.name = strings.ToLower(p.promptString("solution name", ""))
+	}
+	stringProps := []struct {
+		flag  string
+		label string
+		value *string
+	}{
+		{"description", "description", &opts.description},
+		{"solution-version", "version", &opts.solutionVersion},
+		{"contact", "contact email", &opts.contact},
+		{"homepage", "homepage", &opts.homePage},
+		{"git-repo-url", "git repository", &opts.gitRepoUrl},
+	}
+	for _, prop := range stringProps {
+		if !flags.Changed(prop.flag) {
+			*prop.value = p.promptString(prop.label, *prop.value)
+		}
+	}
+	if !flags.Changed("include-service") {
+		opts.includeService = p.promptBool("include a sample service component", opts.includeService)
+	}
+	if !flags.Changed("include-knowledge") {
+		opts.includeKnowledge = p.promptBool("include a sample knowledge type", opts.includeKnowledge)
+	}
+	if opts.includeKnowledge && !flags.Changed("include-objects") {
+		opts.includeObjects = p.promptBool("include a sample knowledge object", opts.includeObjects)
+	}
+	return opts
+}
+
+// prompter asks the user for values on the command's input
+type prompter struct {
+	in  *bufio.Reader
+	cmd *cobra.Command
+}
+
+func (p *prompter) promptString(label string, defaultValue string) string {
+	if defaultValue != "" {
+		output.PrintCmdStatus(p.cmd, fmt.Sprintf("%s: (%s) ", label, defaultValue))
+	} else {
+		output.PrintCmdStatus(p.cmd, fmt.Sprintf("%s: ", label))
+	}
+	line, err := p.in.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		log.Fatalf("Failed to read %s: %v", label, err)
+	}
+	if line = strings.TrimSpace(line); line != "" {
+		return line
+	}
+	return defaultValue
+}
+
+func (p *prompter) promptBool(label string, defaultValue bool) bool {
+	def := "y/N"
+	if defaultValue {
+		def = "Y/n"
+	}
+	for {
+		answer := strings.ToLower(p.promptString(fmt.Sprintf("%s? [%s]", label, def), ""))
+		switch answer {
+		case "":
+			return defaultValue
+		case "y", "yes":
+			return true
+		case "n", "no":
+			return false
+		}
+	}
 }
 
 func createInitialSolutionManifest(solutionName string) *Manifest {
@@ -176,13 +332,21 @@ func createKnowledgeComponent(manifest *Manifest) *KnowledgeDef {
 		Name:             "dataCollectorConfiguration",
 		AllowedLayers:    []string{"TENANT"},
 		IdGeneration:     idGen,
-		SecureProperties: []string{"$.collectorTargetApiKey"},
+		SecureProperties: []string{"$.cloudCollectorTargetApiKey"},
 		JsonSchema:       jsonSchema,
 	}
 
 	return knowledgeComponent
 }
 
+// createKnowledgeObject creates an example object of the knowledge type created by createKnowledgeComponent
+func createKnowledgeObject() map[string]interface{} {
+	return map[string]interface{}{
+		"cloudCollectorTargetURL":    "https://collector.example.com/api",
+		"cloudCollectorTargetApiKey": "replace-with-the-api-key",
+	}
+}
+
 func createComponentFile(compDef any, folderName string, fileName string) {
 	if _, err := os.Stat(folderName); os.IsNotExist(err) {
 		if err := os.Mkdir(folderName, os.ModePerm); err != nil {
@@ -0,0 +1,119 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package solution
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// solutionTagEnvVar specifies the default tag for --isolate, e.g., a developer's initials
+const solutionTagEnvVar = "FSOC_SOLUTION_TAG"
+
+// tags are appended to solution names, so they must keep the names valid (see solutionNameRegexp)
+var solutionTagRegexp = regexp.MustCompile(`^[a-z0-9]+$`)
+
+// addIsolationFlags adds the flags that select an isolated deployment of a solution
+func addIsolationFlags(cmd *cobra.Command) {
+	cmd.Flags().String("tag", "", "Isolate the solution by appending the tag to its name and to the type references within it")
+	cmd.Flags().Bool("isolate", false, fmt.Sprintf("Isolate the solution using the tag in the %s environment variable or, if not set, derived from the user name", solutionTagEnvVar))
+	cmd.MarkFlagsMutuallyExclusive("tag", "isolate")
+}
+
+// getIsolationTag returns the tag selected with the isolation flags or an empty string if the
+// solution is not to be isolated
+func getIsolationTag(cmd *cobra.Command) (string, error) {
+	tag, _ := cmd.Flags().GetString("tag")
+	if isolate, _ := cmd.Flags().GetBool("isolate"); isolate {
+		tag = defaultIsolationTag()
+		if tag == "" {
+			return "", fmt.Errorf("cannot determine the isolation tag; please set %s or use --tag", solutionTagEnvVar)
+		}
+	}
+	if tag != "" && !solutionTagRegexp.MatchString(tag) {
+		return "", fmt.Errorf("invalid tag %q: only lowercase letters and digits are allowed", tag)
+	}
+	return tag, nil
+}
+
+// defaultIsolationTag returns the tag from the environment or, if not set, the user name with
+// all characters not allowed in a tag removed
+func defaultIsolationTag() string {
+	if tag := os.Getenv(solutionTagEnvVar); tag != "" {
+		return tag
+	}
+	u, err := user.Current()
+	if err != nil {
+		return ""
+	}
+	name := u.Username
+	if i := strings.LastIndexAny(name, `\`); i >= 0 {
+		name = name[i+1:] // strip the Windows domain
+	}
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return -1
+	}, strings.ToLower(name))
+}
+
+// solutionIsolation rewrites the files of a solution so that it can be deployed under an isolated name,
+// alongside the original solution and other isolated copies of it
+type solutionIsolation struct {
+	name         string         // original solution name
+	isolatedName string         // solution name with the tag appended
+	refRegexp    *regexp.Regexp // matches references to the solution's types, e.g., "spacefleet:ship"
+}
+
+func newSolutionIsolation(name string, tag string) *solutionIsolation {
+	return &solutionIsolation{
+		name:         name,
+		isolatedName: isolatedSolutionName(name, tag),
+		refRegexp:    regexp.MustCompile(`(^|[^A-Za-z0-9_])` + regexp.QuoteMeta(name) + `:`),
+	}
+}
+
+// isolatedSolutionName returns the name under which a solution is deployed with the tag
+func isolatedSolutionName(name string, tag string) string {
+	return name + tag
+}
+
+// rewriteReferences replaces the references to the solution's types (<name>:<type>) with
+// references to the isolated solution's types
+func (s *solutionIsolation) rewriteReferences(data []byte) []byte {
+	return s.refRegexp.ReplaceAll(data, []byte("${1}"+s.isolatedName+":"))
+}
+
+// rewriteManifest sets the isolated solution name in the manifest, in addition to rewriting the
+// references in it
+func (s *solutionIsolation) rewriteManifest(manifest map[string]any) (map[string]any, error) {
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, err
+	}
+	var rewritten map[string]any
+	if err := json.Unmarshal(s.rewriteReferences(data), &rewritten); err != nil {
+		return nil, err
+	}
+	rewritten["name"] = s.isolatedName
+	return rewritten, nil
+}
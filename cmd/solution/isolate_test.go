@@ -0,0 +1,52 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package solution
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRewriteReferences(t *testing.T) {
+	s := newSolutionIsolation("spacefleet", "jdoe")
+	for input, expected := range map[string]string{
+		`{"type": "spacefleet:ship"}`:                     `{"type": "spacefleetjdoe:ship"}`,
+		`"spacefleet:ship,spacefleet:crew"`:               `"spacefleetjdoe:ship,spacefleetjdoe:crew"`,
+		`spacefleet:ship`:                                 `spacefleetjdoe:ship`,
+		`{"fmm:namespace": "spacefleet"}`:                 `{"fmm:namespace": "spacefleet"}`,
+		`{"type": "myspacefleet:ship"}`:                   `{"type": "myspacefleet:ship"}`,
+		`{"type": "space_spacefleet:ship"}`:               `{"type": "space_spacefleet:ship"}`,
+		`{"type": "other:spacefleet"}`:                    `{"type": "other:spacefleet"}`,
+		`FETCH id FROM entities(spacefleet:ship)[attr=1]`: `FETCH id FROM entities(spacefleetjdoe:ship)[attr=1]`,
+	} {
+		assert.Equal(t, expected, string(s.rewriteReferences([]byte(input))), input)
+	}
+}
+
+func TestRewriteManifest(t *testing.T) {
+	s := newSolutionIsolation("spacefleet", "jdoe")
+	manifest, err := s.rewriteManifest(map[string]any{
+		"name":         "spacefleet",
+		"dependencies": []any{"zodiac"},
+		"objects":      []any{map[string]any{"type": "spacefleet:ship", "objectsDir": "objects/ship"}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"name":         "spacefleetjdoe",
+		"dependencies": []any{"zodiac"},
+		"objects":      []any{map[string]any{"type": "spacefleetjdoe:ship", "objectsDir": "objects/ship"}},
+	}, manifest)
+}
@@ -15,11 +15,17 @@
 package solution
 
 import (
+	"fmt"
+	"net/url"
+	"sort"
+
 	"github.com/apex/log"
 	"github.com/spf13/cobra"
 
 	"github.com/cisco-open/fsoc/cmd/config"
 	"github.com/cisco-open/fsoc/cmdkit"
+	"github.com/cisco-open/fsoc/cmdkit/picker"
+	"github.com/cisco-open/fsoc/cmdkit/selector"
 	"github.com/cisco-open/fsoc/output"
 )
 
@@ -28,8 +34,11 @@ var solutionListCmd = &cobra.Command{
 	Short: "List all solutions available in this tenant",
 	Long: `This command list all the solutions that are deployed in the current tenant specified in the profile.
 
+Solutions can be selected by their attributes with a label selector, e.g., -l isSystem=false
+or -l "name in (spacefleet,zodiac)".
+
 Usage:
-	fsoc solution list`,
+	fsoc solution list [-l <selector>]`,
 	Run:              getSolutionList,
 	TraverseChildren: true,
 	Annotations: map[string]string{
@@ -38,6 +47,11 @@ Usage:
 	},
 }
 
+func getSolutionListCmd() *cobra.Command {
+	selector.AddFlag(solutionListCmd)
+	return solutionListCmd
+}
+
 func getSolutionList(cmd *cobra.Command, args []string) {
 	log.Info("Fetching the list of solutions...")
 
@@ -49,8 +63,47 @@ func getSolutionList(cmd *cobra.Command, args []string) {
 		"layer-id":   layerID,
 	}
 
+	path := getSolutionListUrl()
+	filter, err := selector.GetFilter(cmd, "data.", "")
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	if filter != "" {
+		path += "?filter=" + url.QueryEscape(filter)
+	}
+
 	// get data and display
-	cmdkit.FetchAndPrint(cmd, getSolutionListUrl(), &cmdkit.FetchAndPrintOptions{Headers: headers, IsCollection: true})
+	cmdkit.FetchAndPrint(cmd, path, &cmdkit.FetchAndPrintOptions{Headers: headers, IsCollection: true})
+}
+
+// pickSolutions lets the user choose solutions of the tenant interactively (see --pick), among
+// the ones accepted by the include function (or all, if nil), returning the chosen names
+func pickSolutions(prompt string, include func(SolutionDef) bool) []string {
+	solutions, err := getTenantSolutions()
+	if err != nil {
+		log.Fatalf("Failed to get the list of solutions: %v", err)
+	}
+	names := []string{}
+	for name, s := range solutions {
+		if include == nil || include(s) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	items := make([]string, len(names))
+	for i, name := range names {
+		items[i] = fmt.Sprintf("%s (%s)", name, solutions[name].Version)
+	}
+	chosen, err := picker.Pick(items, &picker.Options{Prompt: prompt})
+	if err != nil {
+		log.Fatalf("No solutions chosen: %v", err)
+	}
+	picked := make([]string, len(chosen))
+	for i, index := range chosen {
+		picked[i] = names[index]
+	}
+	return picked
 }
 
 func getSolutionListUrl() string {
@@ -15,15 +15,17 @@
 package solution
 
 import (
-	"archive/zip"
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"path"
 	"path/filepath"
 
 	"github.com/apex/log"
 	"github.com/spf13/cobra"
+	"golang.org/x/exp/slices"
 
 	"github.com/cisco-open/fsoc/output"
 )
@@ -31,10 +33,29 @@ import (
 var solutionPackageCmd = &cobra.Command{
 	Use:   "package",
 	Short: "Build and package a solution",
-	Long: `This command allows the current tenant specified in the profile to build and package a solution bundle to be deployed into the FSO Platform.
+	Long: `This command builds the deployable solution archive (.zip) from a solution directory, without
+deploying it, so that the archive can be stored (e.g., as a CI artifact) and pushed later with
+"fsoc solution push --solution-bundle".
+
+Files and directories listed in the solution's .fsocignore file are excluded from the archive.
+The file uses the .gitignore syntax; .git/ and the .fsocignore file itself are always excluded.
+
+The solution version in the archived manifest can be pinned with the --solution-version flag,
+without modifying the solution directory. The path of the created archive is displayed once done.
+
+To allow several developers to deploy their own copies of a solution to a shared tenant, the solution
+can be isolated with --tag=TAG: the tag is appended to the solution name in the archived manifest and
+to the references to the solution's types (<name>:<type>) in the archived JSON files, so that each tag
+produces a separate solution. With --isolate, the tag is taken from the FSOC_SOLUTION_TAG environment
+variable or derived from the user name. Tags may contain only lowercase letters and digits.
 
 Usage:
-	fsoc solution package --solution-package=<solution-package-root-path>`,
+	fsoc solution package --solution-package=<solution-package-root-path>
+
+Examples:
+  fsoc solution package --solution-package=mysolution
+  fsoc solution package --solution-package=mysolution --solution-version=1.2.3 --archive=dist/mysolution.zip
+  fsoc solution package --solution-package=mysolution --tag=jdoe`,
 	Args:             cobra.ExactArgs(0),
 	Run:              packageSolution,
 	TraverseChildren: true,
@@ -42,8 +63,12 @@ Usage:
 
 func getSolutionPackageCmd() *cobra.Command {
 	solutionPackageCmd.Flags().
-		String("solution-package", "", "The fully qualified path name for the solution package .zip file")
-	_ = solutionPackageCmd.MarkFlagRequired("solution-package")
+		String("solution-package", ".", "The path of the solution package root folder")
+	solutionPackageCmd.Flags().
+		String("solution-version", "", "Solution version to set in the archived manifest (default is the version in the manifest)")
+	solutionPackageCmd.Flags().
+		String("archive", "", "The path of the archive file to create (default is <solution-folder-name>.zip in the current directory)")
+	addIsolationFlags(solutionPackageCmd)
 
 	return solutionPackageCmd
 
@@ -62,92 +87,190 @@ func packageSolution(cmd *cobra.Command, args []string) {
 	if err != nil {
 		log.Fatalf("Failed to read solution manifest: %v", err)
 	}
+	version, _ := cmd.Flags().GetString("solution-version")
+	if version == "" {
+		version = manifest.SolutionVersion
+	}
+	archivePath, _ := cmd.Flags().GetString("archive")
+	tag, err := getIsolationTag(cmd)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	solutionName := manifest.Name
+	if tag != "" {
+		solutionName = isolatedSolutionName(manifest.Name, tag)
+	}
 
 	var message string
-	message = fmt.Sprintf("Generating solution %s - %s bundle archive \n", manifest.Name, manifest.SolutionVersion)
+	message = fmt.Sprintf("Generating solution %s - %s bundle archive \n", solutionName, version)
 	log.WithFields(log.Fields{
 		"solution-package": solutionPackagePath,
+		"version":          version,
 	}).Info(message)
 
 	output.PrintCmdStatus(cmd, message)
-	solutionArchive := generateZip(cmd, solutionPackagePath)
-	solutionArchive.Close()
+	archivePath, err = createSolutionArchive(solutionPackagePath, archivePath, version, tag, 0)
+	if err != nil {
+		log.Fatalf("Failed to create the solution archive: %v", err)
+	}
 
-	message = fmt.Sprintf("Solution %s - %s bundle is ready. \n", manifest.Name, manifest.SolutionVersion)
+	message = fmt.Sprintf("Solution %s - %s bundle is ready: %s\n", solutionName, version, archivePath)
 	output.PrintCmdStatus(cmd, message)
 }
 
 func generateZip(cmd *cobra.Command, sltnPackagePath string) *os.File {
-	// splitPath := strings.Split(sltnPackagePath, "/")
-	// solutionName := splitPath[len(splitPath)-1]
-	solutionName := filepath.Base(sltnPackagePath)
-	archiveFileName := fmt.Sprintf("%s.zip", solutionName)
-	output.PrintCmdStatus(cmd, fmt.Sprintf("Creating %s archive... \n", archiveFileName))
-	archive, err := os.Create(archiveFileName)
-	if err != nil {
-		panic(err)
-	}
-	defer archive.Close()
-	zipWriter := zip.NewWriter(archive)
+	output.PrintCmdStatus(cmd, fmt.Sprintf("Creating %s.zip archive... \n", filepath.Base(sltnPackagePath)))
+	return generateZipNoCmd(sltnPackagePath, "", 0)
+}
 
-	fsocWorkingDir, err := os.Getwd()
+// createSolutionArchive creates the deployable archive of the solution directory, excluding the files
+// matched by .fsocignore. If archivePath is empty, the archive is created in the current directory and
+// named after the solution directory. If version is not empty, it is set as the solution version in the
+// archived manifest. If tag is not empty, the archived solution is isolated with the tag (see
+// solutionIsolation). Up to workers files are compressed concurrently, one per CPU if workers is not
+// positive. Returns the absolute path of the created archive.
+func createSolutionArchive(solutionPath string, archivePath string, version string, tag string, workers int) (string, error) {
+	absSolutionPath, err := filepath.Abs(solutionPath)
 	if err != nil {
-		log.Fatalf("Couldn't read the current working directory: %v", err)
+		return "", err
 	}
-
-	solutionRootFolder := filepath.Dir(sltnPackagePath)
-	err = os.Chdir(solutionRootFolder)
+	rootName := filepath.Base(absSolutionPath)
+	if archivePath == "" {
+		archivePath = rootName + ".zip"
+	}
+	absArchivePath, err := filepath.Abs(archivePath)
 	if err != nil {
-		log.Fatalf("Couldn't switch working folder to solution package folder: %v", err)
+		return "", err
 	}
 
-	defer func() {
-		err := os.Chdir(fsocWorkingDir)
+	var isolation *solutionIsolation
+	if tag != "" {
+		manifest, err := getSolutionManifest(absSolutionPath)
 		if err != nil {
-			log.Fatalf("Couldn't switch working folder back to starting working folder: %v", err)
+			return "", fmt.Errorf("failed to read the solution manifest: %w", err)
 		}
-	}()
+		isolation = newSolutionIsolation(manifest.Name, tag)
+	}
 
-	err = filepath.Walk(solutionName,
-		func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				return err
-			}
-			addFileToZip(zipWriter, path, info)
-			return nil
-		})
+	archive, err := os.Create(absArchivePath)
 	if err != nil {
-		log.Fatalf("Error traversing the folder: %v", err)
+		return "", fmt.Errorf("failed to create the archive file %q: %w", archivePath, err)
 	}
-	zipWriter.Close()
-
-	return archive
-}
+	defer archive.Close()
 
-func addFileToZip(zipWriter *zip.Writer, fileName string, info os.FileInfo) {
-	newFile, err := os.Open(fileName)
+	err = zipSolutionDir(archive, absSolutionPath, archiveOptions{
+		rootName:        rootName,
+		skipFile:        absArchivePath,
+		solutionVersion: version,
+		isolation:       isolation,
+		workers:         workers,
+	})
 	if err != nil {
-		log.Fatalf("Couldn't open file %q: %v", fileName, err)
+		return "", err
 	}
-	defer newFile.Close()
+	return absArchivePath, archive.Close()
+}
+
+// archiveOptions define how a solution directory is archived by zipSolutionDir
+type archiveOptions struct {
+	rootName        string   // name of the top-level folder in the archive
+	skipDirs        []string // top-level directories to skip, in addition to the .fsocignore rules
+	skipFile        string   // absolute path of a file to skip (e.g., the archive being created)
+	solutionVersion string   // if not empty, overrides the solution version in the archived manifest
+	workers         int      // number of files compressed concurrently, one per CPU if not positive
+
+	// if not nil, the archived solution is renamed and its type references rewritten for an isolated deployment
+	isolation *solutionIsolation
+}
 
-	if info.IsDir() {
-		fileName = fileName + string(os.PathSeparator)
+// zipSolutionDir writes a solution archive of the solution directory into w, placing the
+// files under the opts.rootName folder and skipping the files matched by .fsocignore.
+// Files are read and compressed concurrently, with bounded memory (see archivePipeline),
+// so that large solutions can be archived without loading them into memory.
+func zipSolutionDir(w io.Writer, solutionPath string, opts archiveOptions) error {
+	rules, err := loadIgnoreRules(solutionPath)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", fsocIgnoreFileName, err)
 	}
 
-	fileName = filepath.ToSlash(fileName)
+	pipeline := newArchivePipeline(w, opts.workers)
+	err = filepath.Walk(solutionPath, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(solutionPath, filePath)
+		if err != nil {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
+		if relPath != "." {
+			if rules.ignored(relPath, info.IsDir()) || (info.IsDir() && slices.Contains(opts.skipDirs, relPath)) {
+				log.WithField("path", relPath).Info("Excluding path from the solution archive")
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if absPath, _ := filepath.Abs(filePath); absPath == opts.skipFile {
+				return nil
+			}
+		}
 
-	archWriter, err := zipWriter.Create(fileName)
+		entry := &archiveEntry{name: path.Join(opts.rootName, relPath), isDir: info.IsDir()}
+		switch {
+		case info.IsDir():
+			// directories have no contents
+		case relPath == "manifest.json" && (opts.solutionVersion != "" || opts.isolation != nil):
+			entry.open = func() (io.ReadCloser, error) {
+				var buf bytes.Buffer
+				if err := writeArchivedManifest(&buf, filePath, opts.solutionVersion, opts.isolation); err != nil {
+					return nil, err
+				}
+				return io.NopCloser(&buf), nil
+			}
+		case path.Ext(relPath) == ".json" && opts.isolation != nil:
+			entry.open = func() (io.ReadCloser, error) {
+				data, err := os.ReadFile(filePath)
+				if err != nil {
+					return nil, err
+				}
+				return io.NopCloser(bytes.NewReader(opts.isolation.rewriteReferences(data))), nil
+			}
+		default:
+			entry.open = func() (io.ReadCloser, error) {
+				return os.Open(filePath)
+			}
+		}
+		return pipeline.add(entry)
+	})
+	return pipeline.close(err)
+}
 
+// writeArchivedManifest writes the manifest with the solution version replaced, if version is not empty,
+// and isolated, if isolation is not nil, preserving all other fields
+func writeArchivedManifest(w io.Writer, manifestPath string, version string, isolation *solutionIsolation) error {
+	data, err := os.ReadFile(manifestPath)
 	if err != nil {
-		log.Fatalf("Couldn't create archive writer for file: %v", err)
+		return err
 	}
-
-	if !info.IsDir() {
-		if _, err := io.Copy(archWriter, newFile); err != nil {
-			log.Fatalf("Couldn't write file to architve: %v", err)
+	var manifest map[string]any
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return fmt.Errorf("failed to parse the manifest: %w", err)
+	}
+	if isolation != nil {
+		if manifest, err = isolation.rewriteManifest(manifest); err != nil {
+			return fmt.Errorf("failed to isolate the manifest: %w", err)
 		}
 	}
+	if version != "" {
+		manifest["solutionVersion"] = version
+	}
+	data, err = json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
 }
 
 func isSolutionPackageRoot(path string) bool {
@@ -15,11 +15,11 @@
 package solution
 
 import (
-	"archive/zip"
 	"bytes"
 	"fmt"
 	"io"
 	"mime/multipart"
+	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -29,23 +29,68 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/cisco-open/fsoc/cmd/config"
+	"github.com/cisco-open/fsoc/cmdkit/onerror"
+	"github.com/cisco-open/fsoc/cmdkit/progress"
+	"github.com/cisco-open/fsoc/exitcode"
 	"github.com/cisco-open/fsoc/output"
 	"github.com/cisco-open/fsoc/platform/api"
 )
 
+const (
+	deploymentPollInterval = 3 * time.Second
+	uploadRetryDelay       = 2 * time.Second // initial delay before retrying a failed upload, doubled for each retry
+	defaultUploadRetries   = 3
+)
+
 var solutionPushCmd = &cobra.Command{
 	Use:   "push",
 	Short: "Deploy your solution",
 	Long: `This command allows the current tenant specified in the profile to deploy a solution to the FSO Platform.
 
+The upload progress is displayed while the solution is being sent and the deployment job ID, if
+provided by the platform, is displayed once the upload completes.
+
+With the --wait flag, the command polls the deployment status until the solution is installed,
+the installation fails or the wait time expires. The command exits with code 0 if the solution was
+installed successfully, 2 if the installation failed and 3 if the wait time expired.
+
+With the --bump flag, the solution version in the manifest is incremented before the solution is
+deployed and an entry for the new version is added to the solution's changelog (see "fsoc solution
+bump"). The part of the version to increment can be specified as --bump=major, --bump=minor or
+--bump=patch (default). With --git-tag, the bumped version is also committed and tagged in the git
+repository containing the solution once the solution is deployed.
+
+To avoid clobbering each other's deployments when several developers work on the same solution in
+a shared tenant, each developer can deploy an isolated copy of the solution with --tag=TAG or
+--isolate: the tag is appended to the solution name and to the references to the solution's types
+(see "fsoc solution package"). The solution directory is not modified.
+
+Large solutions are packaged by compressing their files concurrently, one file per CPU unless
+--concurrency is specified, and the archive is streamed from disk as it is uploaded rather than
+loaded into memory. The platform accepts the archive in a single upload, so there is no chunking:
+instead, an upload that fails with a transient error (e.g., a network error or a 5xx/429 response)
+is retried from the start, up to --retries times, with an increasing delay between attempts.
+
+With the --diff flag, a solution that hasn't changed since it was last pushed from this machine to the
+tenant and successfully installed is not pushed again; a changed solution is pushed as a whole. The
+solution is also pushed when the installation of the previous push is not confirmed. The contents of
+the last push are recorded in the fsoc state directory.
+
 Examples:
   fsoc solution push
   fsoc solution push -w
   fsoc solution push -w=60
   fsoc solution push --solution-bundle=mysolution.zip
-
-The first command deploys a solution from the current directory. The second command
-deploys a solution from an existing archive file.`,
+  fsoc solution push --bump=minor --git-tag
+  fsoc solution push --isolate
+  fsoc solution push --diff
+
+The first command deploys a solution from the current directory. The second and third commands
+also wait for the solution to be installed, for up to 5 minutes and 60 seconds, respectively. The
+fourth command deploys a solution from an existing archive file. The fifth command increments the
+minor version of the solution before deploying it and tags the new version in git. The sixth
+command deploys an isolated copy of the solution, named with the user's tag. The last command
+pushes the solution only if it changed since the last push.`,
 	Args:             cobra.ExactArgs(0),
 	Run:              pushSolution,
 	TraverseChildren: true,
@@ -55,22 +100,49 @@ func getSolutionPushCmd() *cobra.Command {
 	solutionPushCmd.Flags().
 		String("solution-bundle", "", "fully qualified path name for the solution bundle .zip file")
 
-	solutionPushCmd.Flags().IntP("wait", "w", -1, "Wait (in seconds) for the solution to be deployed (not supported when uisng --solution-bundle)")
+	solutionPushCmd.Flags().IntP("wait", "w", -1, "Wait (in seconds) for the solution to be deployed; 0 waits indefinitely")
 	solutionPushCmd.Flag("wait").NoOptDefVal = "300"
 
-	solutionPushCmd.MarkFlagsMutuallyExclusive("solution-bundle", "wait")
+	solutionPushCmd.Flags().String("bump", "", "Increment the solution version (major, minor or patch) before deploying")
+	solutionPushCmd.Flag("bump").NoOptDefVal = bumpPatch
+	solutionPushCmd.Flags().Bool("git-tag", false, "Commit and tag the bumped version in git after deploying (requires --bump)")
+	solutionPushCmd.MarkFlagsMutuallyExclusive("solution-bundle", "bump")
+
+	solutionPushCmd.Flags().Int("concurrency", 0, "Number of files to compress concurrently when packaging the solution (default: one per CPU)")
+	solutionPushCmd.Flags().Int("retries", defaultUploadRetries, "Number of times to retry the upload if it fails with a transient error")
+	solutionPushCmd.Flags().Bool("diff", false, "Skip the push if the solution is unchanged since the last installed push")
+
+	addIsolationFlags(solutionPushCmd)
+	solutionPushCmd.MarkFlagsMutuallyExclusive("solution-bundle", "tag")
+	solutionPushCmd.MarkFlagsMutuallyExclusive("solution-bundle", "isolate")
+
 	return solutionPushCmd
 
 }
 
 func pushSolution(cmd *cobra.Command, args []string) {
 	manifestPath := ""
-	var solutionName string
-	var solutionVersion string
+	var manifest *Manifest
 
 	waitFlag, _ := cmd.Flags().GetInt("wait")
 	solutionBundlePath, _ := cmd.Flags().GetString("solution-bundle")
+	bumpPart, _ := cmd.Flags().GetString("bump")
+	gitTag, _ := cmd.Flags().GetBool("git-tag")
+	concurrency, _ := cmd.Flags().GetInt("concurrency")
+	retries, _ := cmd.Flags().GetInt("retries")
+	diff, _ := cmd.Flags().GetBool("diff")
+	if retries < 0 {
+		log.WithField(exitcode.Field, exitcode.Usage).Fatal("The --retries flag cannot be negative")
+	}
+	if gitTag && bumpPart == "" {
+		log.Fatal("The --git-tag flag requires --bump")
+	}
+	tag, err := getIsolationTag(cmd)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
 	var solutionArchivePath string
+	var solutionName string // deployed solution name, which differs from the manifest's if isolated
 	if solutionBundlePath == "" {
 		currentDir, err := os.Getwd()
 		if err != nil {
@@ -81,150 +153,299 @@ func pushSolution(cmd *cobra.Command, args []string) {
 			log.Fatal("solution-bundle / current dir path doesn't point to a solution package root folder")
 		}
 
-		manifest, _ := getSolutionManifest(manifestPath)
-		solutionName = manifest.Name
-		solutionVersion = manifest.SolutionVersion
+		if bumpPart != "" {
+			if gitTag {
+				if err := checkGitRepo(manifestPath); err != nil {
+					log.Fatalf("Cannot tag the new version: %v", err)
+				}
+			}
+			name, oldVersion, newVersion, err := bumpSolutionVersion(manifestPath, bumpPart, "", true)
+			if err != nil {
+				log.Fatalf("Failed to bump the solution version: %v", err)
+			}
+			output.PrintCmdStatus(cmd, fmt.Sprintf("Solution %s version bumped from %s to %s\n", name, oldVersion, newVersion))
+		}
+
+		manifest, err = getSolutionManifest(manifestPath)
+		if err != nil {
+			log.Fatalf("Failed to read the solution manifest in %q: %v", manifestPath, err)
+		}
 
-		solutionArchive := generateZipNoCmd(manifestPath)
+		solutionArchive := generateZipNoCmd(manifestPath, tag, concurrency)
 		solutionArchivePath = filepath.Base(solutionArchive.Name())
+		if tag != "" {
+			log.WithFields(log.Fields{"solution": manifest.Name, "tag": tag}).Info("Isolating the solution")
+			solutionName = isolatedSolutionName(manifest.Name, tag)
+		}
 
 	} else {
 		manifestPath = solutionBundlePath
 		solutionArchivePath = manifestPath
+
+		var err error
+		manifest, err = readManifestFromArchive(solutionArchivePath)
+		if err != nil {
+			if waitFlag >= 0 {
+				log.Fatalf("Failed to read the solution manifest from %q, needed to wait for the deployment: %v", solutionArchivePath, err)
+			}
+			log.Warnf("Failed to read the solution manifest from %q: %v", solutionArchivePath, err)
+			manifest = &Manifest{}
+		}
 	}
 
-	//message := fmt.Sprintf("Deploying solution %s - %s", manifest.Name, manifest.SolutionVersion)
 	message := "Deploying solution"
+	if solutionName == "" {
+		solutionName = manifest.Name
+	}
+	if solutionName != "" {
+		message = fmt.Sprintf("Deploying solution %s version %s", solutionName, manifest.SolutionVersion)
+	}
 
 	log.WithFields(log.Fields{
 		"solution-package": solutionBundlePath,
 	}).Info(message)
 
-	file, err := os.Open(solutionArchivePath)
-	if err != nil {
-		log.Fatalf("Failed to open file %q: %v", solutionArchivePath, err)
+	output.PrintCmdStatus(cmd, fmt.Sprintf("%v\n", message))
+	var pushStartTime time.Time
+	pushed := &pushRecord{}
+	if diff {
+		started, record, err := uploadSolutionDiff(cmd, solutionArchivePath, solutionName, manifest.SolutionVersion, retries)
+		if err != nil {
+			log.Fatalf("Solution command failed: %v", err)
+		}
+		if record == nil {
+			return
+		}
+		pushStartTime = started
+		pushed = record
+	} else {
+		pushStartTime = uploadSolutionArchive(cmd, solutionArchivePath, solutionName, retries)
 	}
-	defer file.Close()
 
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
+	if waitFlag >= 0 {
+		waitForDeployment(cmd, solutionName, manifest.SolutionVersion, time.Duration(waitFlag)*time.Second, pushStartTime)
+		pushed.confirm()
+	}
 
-	fw, err := writer.CreateFormFile("file", solutionArchivePath)
-	if err != nil {
-		log.Fatalf("Failed to create form file: %v", err)
+	if gitTag {
+		version, _ := parseSemver(manifest.SolutionVersion) // validated when bumped
+		gitTagName, err := tagSolutionVersion(manifestPath, manifest.Name, version, true)
+		if err != nil {
+			log.Fatalf("Failed to tag the new version: %v", err)
+		}
+		output.PrintCmdStatus(cmd, fmt.Sprintf("Created git tag %s\n", gitTagName))
+	}
+
+	if waitFlag >= 0 {
+		output.PrintCmdStatus(cmd, fmt.Sprintf("Solution %s version %s was successfully installed.\n", solutionName, manifest.SolutionVersion))
+		return
 	}
 
-	_, err = io.Copy(fw, file)
+	message = fmt.Sprintf("Solution bundle %q was successfully deployed.\n", solutionArchivePath)
+	output.PrintCmdStatus(cmd, message)
+}
+
+// uploadSolutionArchive uploads the solution archive to the platform for deployment, displaying
+// the upload progress and the deployment job ID. The archive is streamed from disk and the upload is
+// retried up to retries times if it fails with a transient error. It returns the time the upload
+// started. The solution name, if known, is recorded in the local history.
+func uploadSolutionArchive(cmd *cobra.Command, solutionArchivePath string, solutionName string, retries int) time.Time {
+	pushStartTime, err := postSolutionArchive(cmd, solutionArchivePath, solutionName, retries)
 	if err != nil {
-		log.Fatalf("Failed to copy file %q into file writer: %v", solutionArchivePath, err)
+		log.Fatalf("Solution command failed: %v", err)
 	}
+	return pushStartTime
+}
 
-	writer.Close()
+// postSolutionArchive uploads the solution archive as uploadSolutionArchive does, returning an error
+// instead of exiting if the upload fails
+func postSolutionArchive(cmd *cobra.Command, solutionArchivePath string, solutionName string, retries int) (time.Time, error) {
+	body, contentType, err := newMultipartFileBody("file", solutionArchivePath)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to prepare the upload of %q: %w", solutionArchivePath, err)
+	}
 
 	headers := map[string]string{
 		"stage":        "STABLE",
 		"tag":          "stable",
 		"operation":    "UPLOAD",
-		"Content-Type": writer.FormDataContentType(),
+		"Content-Type": contentType,
 	}
 
 	var res any
+	var pushStartTime time.Time
+	options := api.Options{Headers: headers}
+	if solutionName != "" {
+		options.Resources = []string{"extensibility:solution/" + solutionName}
+	}
+	delay := uploadRetryDelay
+	for attempt := 0; ; attempt++ {
+		pushStartTime = time.Now()
+		if quiet, _ := cmd.Flags().GetBool("quiet"); !quiet {
+			options.UploadProgress = progress.NewBar("Uploading " + filepath.Base(solutionArchivePath)).Update
+		}
+		err = api.HTTPPostStream(getSolutionPushUrl(), body, &res, &options)
+		if err == nil || attempt >= retries || !onerror.IsTransient(err) {
+			break
+		}
+		log.Warnf("Upload failed, retrying in %v (%d of %d): %v", delay, attempt+1, retries, err)
+		time.Sleep(delay)
+		delay *= 2
+	}
+	if err != nil {
+		return pushStartTime, err
+	}
 
-	output.PrintCmdStatus(cmd, fmt.Sprintf("%v\n", message))
-
-	err = api.HTTPPost(getSolutionPushUrl(), body.Bytes(), &res, &api.Options{Headers: headers})
+	if jobID := deploymentJobID(res, options.ResponseHeaders); jobID != "" {
+		log.WithField("job_id", jobID).Info("Solution deployment job created")
+		output.PrintCmdStatus(cmd, fmt.Sprintf("Deployment job ID: %s\n", jobID))
+	}
+	return pushStartTime, nil
+}
 
+// newMultipartFileBody returns a multipart/form-data request body with the file as the
+// fieldName form file, and its content type. The file is read from disk each time the
+// body is sent, so that it is neither loaded into memory nor copied.
+func newMultipartFileBody(fieldName string, filePath string) (*api.StreamBody, string, error) {
+	info, err := os.Stat(filePath)
 	if err != nil {
-		log.Fatalf("Solution command failed: %v", err)
+		return nil, "", err
 	}
 
-	if waitFlag >= 0 && solutionName != "" && solutionVersion != "" {
-		var duration string
-		if waitFlag > 0 {
-			duration = fmt.Sprintf("for %d seconds", waitFlag)
-		} else {
-			duration = "indefinitely"
-		}
-		fmt.Printf("Waiting %s for solution %s version %s to be installed...", duration, solutionName, solutionVersion)
+	// render the multipart framing around the file's contents
+	framing := &bytes.Buffer{}
+	writer := multipart.NewWriter(framing)
+	if _, err := writer.CreateFormFile(fieldName, filepath.Base(filePath)); err != nil {
+		return nil, "", fmt.Errorf("failed to create form file: %w", err)
+	}
+	head := append([]byte(nil), framing.Bytes()...)
+	framing.Reset()
+	if err := writer.Close(); err != nil {
+		return nil, "", err
+	}
+	tail := framing.Bytes()
 
-		filter := fmt.Sprintf(`data.solutionName eq "%s" and data.solutionVersion eq "%s"`, solutionName, solutionVersion)
-		query := fmt.Sprintf("?order=%s&filter=%s&max=1", url.QueryEscape("desc"), url.QueryEscape(filter))
+	body := &api.StreamBody{
+		Open: func() (io.ReadCloser, error) {
+			file, err := os.Open(filePath)
+			if err != nil {
+				return nil, err
+			}
+			return struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(head), file, bytes.NewReader(tail)), file}, nil
+		},
+		Size: int64(len(head)) + info.Size() + int64(len(tail)),
+	}
+	return body, writer.FormDataContentType(), nil
+}
+
+// waitForDeployment polls the installation status of the solution version until it is installed
+// successfully, the installation fails (exiting with exitcode.Failed) or the timeout
+// expires (exiting with exitcode.Timeout). A zero timeout waits indefinitely. Status
+// records created before the push are ignored, so that a redeployment of the same version
+// does not report the outcome of a previous deployment.
+func waitForDeployment(cmd *cobra.Command, solutionName string, solutionVersion string, timeout time.Duration, since time.Time) {
+	var duration string
+	if timeout > 0 {
+		duration = fmt.Sprintf("for %v", timeout)
+	} else {
+		duration = "indefinitely"
+	}
+	output.PrintCmdStatus(cmd, fmt.Sprintf("Waiting %s for solution %s version %s to be installed...", duration, solutionName, solutionVersion))
 
-		headers := map[string]string{
-			"layer-type": "TENANT",
-			"layer-id":   config.GetCurrentContext().Tenant,
+	waitStartTime := time.Now()
+
+	// the spinner, if displayed, shows the outcome; otherwise, it is appended to the message above
+	spinner := progress.Start("Solution installation")
+	if spinner.Active() {
+		output.PrintCmdStatus(cmd, "\n")
+	}
+	finish := func(ok bool, outcome string) {
+		if spinner.Active() {
+			spinner.Stop(ok)
+		} else {
+			output.PrintCmdStatus(cmd, " "+outcome+"\n")
 		}
-		var statusData StatusData
-		waitStartTime := time.Now()
-		for statusData.SolutionVersion != solutionVersion {
-			if waitFlag > 0 {
-				if time.Since(waitStartTime).Seconds() > float64(waitFlag) {
-					fmt.Println("Timeout")
-					log.Fatalf("Failed to validate solution %s version %s was installed", solutionName, solutionVersion)
-				}
+	}
+	for {
+		status, err := getInstallStatus(solutionName, solutionVersion)
+		if err != nil {
+			log.Fatalf("Error fetching the installation status: %v", err)
+		}
+		if status.StatusData.SolutionVersion == solutionVersion && !statusPredates(status, since) {
+			if !status.StatusData.SuccessfulInstall {
+				finish(false, "Failed")
+				log.WithField(exitcode.Field, exitcode.Failed).Fatalf("Installation of solution %s version %s failed: %s", solutionName, solutionVersion, status.StatusData.InstallMessage)
 			}
-			fmt.Printf(".")
-			status := getObject(fmt.Sprintf(getSolutionInstallUrl(), query), headers)
-			statusData = status.StatusData
-			time.Sleep(3 * time.Second)
+			finish(true, "Done")
+			return
 		}
-		if !statusData.SuccessfulInstall {
-			fmt.Println("Failed")
-			log.Fatalf("Installation failed: %s", statusData.InstallMessage)
+		if timeout > 0 && time.Since(waitStartTime) > timeout {
+			finish(false, "Timeout")
+			log.WithField(exitcode.Field, exitcode.Timeout).Fatalf("Timed out waiting for solution %s version %s to be installed; use \"fsoc solution status\" to check on the deployment", solutionName, solutionVersion)
 		}
-		fmt.Println(" Done")
+		spinner.Update(fmt.Sprintf("waiting for %v", time.Since(waitStartTime).Round(time.Second)))
+		time.Sleep(deploymentPollInterval)
 	}
-	// message = fmt.Sprintf("Solution %s - %s was successfully deployed.", manifest.Name, manifest.SolutionVersion)
-	message = fmt.Sprintf("Solution bundle %q was successfully deployed.\n", solutionArchivePath)
-	output.PrintCmdStatus(cmd, message)
 }
 
-func getSolutionPushUrl() string {
-	return "solnmgmt/v1beta/solutions"
+// getInstallStatus returns the latest installation status of the solution version, which is empty if
+// the version has not been installed yet
+func getInstallStatus(solutionName string, solutionVersion string) (StatusItem, error) {
+	filter := fmt.Sprintf(`data.solutionName eq "%s" and data.solutionVersion eq "%s"`, solutionName, solutionVersion)
+	query := fmt.Sprintf("?order=%s&filter=%s&max=1", url.QueryEscape("desc"), url.QueryEscape(filter))
+	headers := map[string]string{
+		"layer-type": "TENANT",
+		"layer-id":   config.GetCurrentContext().Tenant,
+	}
+	return fetchStatusObject(fmt.Sprintf(getSolutionInstallUrl(), query), headers)
 }
 
-func generateZipNoCmd(sltnPackagePath string) *os.File {
-	// splitPath := strings.Split(sltnPackagePath, "/")
-	// solutionName := splitPath[len(splitPath)-1]
-	solutionName := filepath.Base(sltnPackagePath)
-	archiveFileName := fmt.Sprintf("%s.zip", solutionName)
-	archive, err := os.Create(archiveFileName)
+// statusPredates returns true if the status record was created before the given (local) time,
+// correcting for the measured clock skew; records without a parseable creation time are assumed
+// to be recent
+func statusPredates(status StatusItem, t time.Time) bool {
+	createdAt, err := time.Parse(time.RFC3339, status.CreatedAt)
 	if err != nil {
-		log.Fatalf("Failed to create a bundle archive %q: %v", archiveFileName, err)
+		return false
 	}
-	defer archive.Close()
-	zipWriter := zip.NewWriter(archive)
-
-	fsocWorkingDir, err := os.Getwd()
-	if err != nil {
-		log.Fatalf("Couldn't read the working directory: %v", err)
+	if skew, ok := api.GetClockSkew(config.GetCurrentProfileName()); ok {
+		t = t.Add(-skew) // convert to the platform's clock
 	}
+	return createdAt.Before(t.Add(-time.Second)) // platform timestamps may be truncated to seconds
+}
 
-	solutionRootFolder := filepath.Dir(sltnPackagePath)
-	err = os.Chdir(solutionRootFolder)
-	if err != nil {
-		log.Fatalf("Couldn't switch working folder to solution package folder: %v", err)
+// deploymentJobID extracts the deployment job ID from the push response, if provided
+func deploymentJobID(res any, headers map[string][]string) string {
+	if m, ok := res.(map[string]any); ok {
+		for _, field := range []string{"jobId", "deploymentId", "id"} {
+			if id, ok := m[field]; ok && id != nil {
+				return fmt.Sprint(id)
+			}
+		}
+	}
+	if ids := http.Header(headers).Values("Job-Id"); len(ids) > 0 {
+		return ids[0]
 	}
+	return ""
+}
 
-	defer func() {
-		err := os.Chdir(fsocWorkingDir)
-		if err != nil {
-			log.Fatalf("Couldn't switch working folder back to the original one: %v", err)
-		}
-	}()
+func getSolutionPushUrl() string {
+	return "solnmgmt/v1beta/solutions"
+}
 
-	err = filepath.Walk(solutionName,
-		func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				return err
-			}
-			addFileToZip(zipWriter, path, info)
-			return nil
-		})
+func generateZipNoCmd(sltnPackagePath string, tag string, workers int) *os.File {
+	archivePath, err := createSolutionArchive(sltnPackagePath, "", "", tag, workers)
 	if err != nil {
-		log.Fatalf("Error traversing the solution folder: %v", err)
+		log.Fatalf("Failed to create a bundle archive for %q: %v", sltnPackagePath, err)
 	}
-	zipWriter.Close()
+	archive, err := os.Open(archivePath)
+	if err != nil {
+		log.Fatalf("Failed to open the bundle archive %q: %v", archivePath, err)
+	}
+	defer archive.Close()
 
 	return archive
 }
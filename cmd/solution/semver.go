@@ -0,0 +1,112 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package solution
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// semver is a parsed MAJOR.MINOR.PATCH solution version
+type semver [3]int
+
+// parseSemver parses a MAJOR.MINOR.PATCH version; missing minor and patch parts are assumed to be 0
+func parseSemver(s string) (semver, error) {
+	var v semver
+	parts := strings.Split(strings.TrimPrefix(strings.TrimSpace(s), "v"), ".")
+	if len(parts) > 3 || parts[0] == "" {
+		return v, fmt.Errorf("invalid version %q, expected MAJOR.MINOR.PATCH", s)
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return v, fmt.Errorf("invalid version %q, expected MAJOR.MINOR.PATCH", s)
+		}
+		v[i] = n
+	}
+	return v, nil
+}
+
+func (v semver) String() string {
+	return fmt.Sprintf("%d.%d.%d", v[0], v[1], v[2])
+}
+
+// compare returns -1, 0 or 1 if v is lower than, equal to or higher than other
+func (v semver) compare(other semver) int {
+	for i := range v {
+		switch {
+		case v[i] < other[i]:
+			return -1
+		case v[i] > other[i]:
+			return 1
+		}
+	}
+	return 0
+}
+
+// versionConstraint is a requirement on a version: an operator (=, >, >=, <, <=, ^ or ~) and a version.
+// "^1.2.3" allows versions >= 1.2.3 with the same major version and "~1.2.3" the same major and minor.
+type versionConstraint struct {
+	op      string
+	version semver
+}
+
+var constraintOperators = []string{">=", "<=", ">", "<", "=", "^", "~"} // longest first
+
+func parseVersionConstraint(s string) (versionConstraint, error) {
+	s = strings.TrimSpace(s)
+	op := "="
+	for _, candidate := range constraintOperators {
+		if strings.HasPrefix(s, candidate) {
+			op = candidate
+			s = strings.TrimPrefix(s, candidate)
+			break
+		}
+	}
+	v, err := parseSemver(s)
+	if err != nil {
+		return versionConstraint{}, err
+	}
+	return versionConstraint{op: op, version: v}, nil
+}
+
+func (c versionConstraint) String() string {
+	if c.op == "=" {
+		return c.version.String()
+	}
+	return c.op + c.version.String()
+}
+
+// allows returns true if the version satisfies the constraint
+func (c versionConstraint) allows(v semver) bool {
+	cmp := v.compare(c.version)
+	switch c.op {
+	case ">=":
+		return cmp >= 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case "<":
+		return cmp < 0
+	case "^":
+		return cmp >= 0 && v[0] == c.version[0]
+	case "~":
+		return cmp >= 0 && v[0] == c.version[0] && v[1] == c.version[1]
+	default:
+		return cmp == 0
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package solution
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVersionConstraint(t *testing.T) {
+	for _, tc := range []struct {
+		input    string
+		expected versionConstraint
+		str      string
+	}{
+		{"1.2.3", versionConstraint{"=", semver{1, 2, 3}}, "1.2.3"},
+		{"=1.2.3", versionConstraint{"=", semver{1, 2, 3}}, "1.2.3"},
+		{">=1.2", versionConstraint{">=", semver{1, 2, 0}}, ">=1.2.0"},
+		{"<=2", versionConstraint{"<=", semver{2, 0, 0}}, "<=2.0.0"},
+		{">1.0.0", versionConstraint{">", semver{1, 0, 0}}, ">1.0.0"},
+		{"<1.0.0", versionConstraint{"<", semver{1, 0, 0}}, "<1.0.0"},
+		{"^1.2.3", versionConstraint{"^", semver{1, 2, 3}}, "^1.2.3"},
+		{" ~v1.2.3 ", versionConstraint{"~", semver{1, 2, 3}}, "~1.2.3"},
+	} {
+		c, err := parseVersionConstraint(tc.input)
+		require.NoError(t, err, tc.input)
+		assert.Equal(t, tc.expected, c, tc.input)
+		assert.Equal(t, tc.str, c.String(), tc.input)
+	}
+
+	for _, input := range []string{"", ">=", "1.2.3.4", "a.b.c", "1.-2.3", "=>1.2.3"} {
+		_, err := parseVersionConstraint(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestVersionConstraintAllows(t *testing.T) {
+	for _, tc := range []struct {
+		constraint string
+		version    semver
+		allowed    bool
+	}{
+		{"1.2.3", semver{1, 2, 3}, true},
+		{"1.2.3", semver{1, 2, 4}, false},
+		{">=1.2.3", semver{1, 2, 3}, true},
+		{">=1.2.3", semver{1, 2, 2}, false},
+		{">1.2.3", semver{1, 2, 3}, false},
+		{">1.2.3", semver{2, 0, 0}, true},
+		{"<=1.2.3", semver{1, 2, 3}, true},
+		{"<1.2.3", semver{1, 2, 3}, false},
+		{"<1.2.3", semver{0, 9, 9}, true},
+		{"^1.2.3", semver{1, 9, 0}, true},
+		{"^1.2.3", semver{1, 2, 2}, false},
+		{"^1.2.3", semver{2, 0, 0}, false},
+		{"~1.2.3", semver{1, 2, 9}, true},
+		{"~1.2.3", semver{1, 3, 0}, false},
+	} {
+		c, err := parseVersionConstraint(tc.constraint)
+		require.NoError(t, err)
+		assert.Equal(t, tc.allowed, c.allows(tc.version), "%s allows %v", tc.constraint, tc.version)
+	}
+}
@@ -42,20 +42,28 @@ func init() {
 }
 
 func NewSubCmd() *cobra.Command {
-	solutionCmd.AddCommand(solutionListCmd)
+	solutionCmd.AddCommand(getSolutionListCmd())
 	solutionCmd.AddCommand(getInitSolutionCmd())
 	solutionCmd.AddCommand(getSubscribeSolutionCmd())
 	solutionCmd.AddCommand(getUnsubscribeSolutionCmd())
 	solutionCmd.AddCommand(getSolutionExtendCmd())
 	solutionCmd.AddCommand(getSolutionPackageCmd())
 	solutionCmd.AddCommand(getSolutionPushCmd())
+	solutionCmd.AddCommand(getSolutionDevCmd())
+	solutionCmd.AddCommand(getSolutionBumpCmd())
+	solutionCmd.AddCommand(getSolutionUpgradeCmd())
 	solutionCmd.AddCommand(getAuthorCmd())
 	solutionCmd.AddCommand(getSolutionDownloadCmd())
 	solutionCmd.AddCommand(getSolutionValidateCmd())
+	solutionCmd.AddCommand(getSolutionDiffCmd())
+	solutionCmd.AddCommand(getSolutionDepsCmd())
 	solutionCmd.AddCommand(GetSolutionForkCommand())
 	solutionCmd.AddCommand(getSolutionCheckCmd())
 	solutionCmd.AddCommand(getSolutionStatusCmd())
 	solutionCmd.AddCommand(getSolutionDescribeCmd())
+	solutionCmd.AddCommand(getSolutionVendorCmd())
+	solutionCmd.AddCommand(getSolutionCheckCompatCmd())
+	solutionCmd.AddCommand(getSolutionTestCmd())
 	solutionListCmd.Flags().StringP("output", "o", "", "Output format (human*, json, yaml)")
 
 	return solutionCmd
@@ -72,20 +72,23 @@ func getSolutionStatusCmd() *cobra.Command {
 }
 
 func getObject(url string, headers map[string]string) StatusItem {
-	var res ResponseBlob
-	var emptyData StatusItem
-
-	err := api.JSONGet(url, &res, &api.Options{Headers: headers})
-
+	status, err := fetchStatusObject(url, headers)
 	if err != nil {
 		log.Fatalf("Error fetching status object %q: %v", url, err)
 	}
+	return status
+}
 
+// fetchStatusObject returns the first status object at the URL, or an empty one if there is none
+func fetchStatusObject(url string, headers map[string]string) (StatusItem, error) {
+	var res ResponseBlob
+	if err := api.JSONGet(url, &res, &api.Options{Headers: headers}); err != nil {
+		return StatusItem{}, err
+	}
 	if len(res.Items) > 0 {
-		return res.Items[0]
-	} else {
-		return emptyData
+		return res.Items[0], nil
 	}
+	return StatusItem{}, nil
 }
 
 func fetchValuesAndPrint(operation string, query string, requestHeaders map[string]string, cmd *cobra.Command) {
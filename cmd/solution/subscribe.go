@@ -15,12 +15,15 @@
 package solution
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/apex/log"
 	"github.com/spf13/cobra"
 
 	"github.com/cisco-open/fsoc/cmd/config"
+	"github.com/cisco-open/fsoc/cmdkit/onerror"
+	"github.com/cisco-open/fsoc/cmdkit/picker"
 	"github.com/cisco-open/fsoc/output"
 	"github.com/cisco-open/fsoc/platform/api"
 )
@@ -35,7 +38,11 @@ var solutionSubscribeCmd = &cobra.Command{
 	Long: `This command allows the current tenant specified in the profile to subscribe to a solution.
 
 Example:
-	fsoc solution subscribe --name=spacefleet`,
+	fsoc solution subscribe --name=spacefleet
+
+With --pick, the solutions to subscribe to are chosen interactively among the ones the tenant is not subscribed to.
+Without --name, they are also chosen this way when running interactively (see --interactive).
+When subscribing to a solution fails, the user is asked whether to retry, skip it or abort (see --on-error).`,
 	Args:             cobra.ExactArgs(0),
 	Run:              subscribeToSolution,
 	TraverseChildren: true,
@@ -44,18 +51,16 @@ Example:
 func getSubscribeSolutionCmd() *cobra.Command {
 	solutionSubscribeCmd.Flags().
 		String("name", "", "The name of the solution the tenant is subscribing to")
-	_ = solutionSubscribeCmd.MarkFlagRequired("name")
+	picker.AddFlag(solutionSubscribeCmd)
+	solutionSubscribeCmd.MarkFlagsMutuallyExclusive("name", picker.FlagName)
+	onerror.AddFlag(solutionSubscribeCmd, onerror.Abort)
 
 	return solutionSubscribeCmd
 
 }
 
-func manageSubscription(cmd *cobra.Command, args []string, isSubscribed bool) {
-	solutionName, _ := cmd.Flags().GetString("name")
-	if solutionName == "" {
-		log.Fatal("Solution name cannot be empty, use --name=<solution>")
-	}
-
+// manageSubscription subscribes to or unsubscribes from a solution, handling failures per --on-error
+func manageSubscription(cmd *cobra.Command, solutionName string, isSubscribed bool, errs *onerror.Handler) {
 	var message string
 	if isSubscribed {
 		message = "Subscribing to solution"
@@ -66,18 +71,13 @@ func manageSubscription(cmd *cobra.Command, args []string, isSubscribed bool) {
 		"solution": solutionName,
 	}).Info(message)
 
-	cfg := config.GetCurrentContext()
-	layerID := cfg.Tenant
-
-	headers := map[string]string{
-		"layer-type": "TENANT",
-		"layer-id":   layerID,
+	layerID := config.GetCurrentContext().Tenant
+	err := errs.Do("solution "+solutionName, func() error {
+		return setSubscription(solutionName, isSubscribed)
+	})
+	if errors.Is(err, onerror.ErrSkipped) {
+		return
 	}
-
-	subscribe := subscriptionStruct{IsSubscribed: isSubscribed}
-
-	var res any
-	err := api.JSONPatch(getSolutionSubscribeUrl()+"/"+solutionName, &subscribe, &res, &api.Options{Headers: headers})
 	if err != nil {
 		log.Fatalf("Solution command failed: %v", err)
 	}
@@ -91,8 +91,33 @@ func manageSubscription(cmd *cobra.Command, args []string, isSubscribed bool) {
 	output.PrintCmdStatus(cmd, message)
 }
 
+// setSubscription subscribes the current tenant to the solution or unsubscribes it from the solution
+func setSubscription(solutionName string, isSubscribed bool) error {
+	headers := map[string]string{
+		"layer-type": "TENANT",
+		"layer-id":   config.GetCurrentContext().Tenant,
+	}
+	subscribe := subscriptionStruct{IsSubscribed: isSubscribed}
+	var res any
+	return api.JSONPatch(getSolutionSubscribeUrl()+"/"+solutionName, &subscribe, &res, &api.Options{Headers: headers})
+}
+
 func subscribeToSolution(cmd *cobra.Command, args []string) {
-	manageSubscription(cmd, args, true)
+	errs, err := onerror.New(cmd)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	solutionName, _ := cmd.Flags().GetString("name")
+	if picker.Requested(cmd) || solutionName == "" && picker.Interactive(cmd) {
+		for _, name := range pickSolutions("Solutions to subscribe to", func(s SolutionDef) bool { return !s.IsSubscribed }) {
+			manageSubscription(cmd, name, true, errs)
+		}
+		return
+	}
+	if solutionName == "" {
+		log.Fatal("Solution name cannot be empty, use --name=<solution> or --pick")
+	}
+	manageSubscription(cmd, solutionName, true, errs)
 }
 
 func getSolutionSubscribeUrl() string {
@@ -0,0 +1,463 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package solution
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+
+	"github.com/cisco-open/fsoc/cmd/config"
+	"github.com/cisco-open/fsoc/cmd/uql"
+	"github.com/cisco-open/fsoc/cmdkit/junit"
+	"github.com/cisco-open/fsoc/exitcode"
+	"github.com/cisco-open/fsoc/output"
+	"github.com/cisco-open/fsoc/platform/api"
+	"github.com/cisco-open/fsoc/platform/melt"
+)
+
+// Test results
+const (
+	testPass = "PASS"
+	testFail = "FAIL"
+	testSkip = "SKIP"
+)
+
+var solutionTestCmd = &cobra.Command{
+	Use:   "test",
+	Short: "Test your solution in an isolated deployment",
+	Long: `This command tests the solution in the current directory (or the one specified with --directory) end to end:
+
+  1. Deploy an isolated copy of the solution, named with the test tag (see "fsoc solution push --tag"),
+     and subscribe the tenant to it
+  2. Inject the test inputs: knowledge objects and MELT telemetry (fsoc telemetry model .yaml or OTLP JSON files)
+  3. Run the tests, each asserting the rows returned by a UQL query, the entities of a type or a knowledge
+     object; each test is retried until it passes or its timeout expires, since the platform processes the
+     inputs asynchronously
+  4. Display the results and, with --junit, write them as a JUnit XML report for CI systems
+  5. Delete the input objects and unsubscribe from the isolated solution, unless --keep is specified
+
+The tests are defined in solution-tests.yaml in the solution directory (excluded from the solution archive),
+or in the file specified with --file. References to the solution's types (e.g., spacefleet:ship) in the
+file, the input objects and the telemetry are rewritten to refer to the isolated solution's types. Keep the
+input files in a directory listed in .fsocignore, so that they are not deployed with the solution:
+
+  timeout: 5m       # time for each test to pass (default 5m)
+  interval: 15s     # time between the attempts of a test (default 15s)
+  inputs:
+    objects:
+      - type: spacefleet:shipConfig
+        layerType: TENANT            # default; SOLUTION is the isolated solution, others need layerId
+        file: testdata/config.json   # or data: {...}; paths are relative to the test file
+    melt:
+      - testdata/ships.yaml
+  tests:
+    - name: ships are reported
+      entity:
+        type: spacefleet:ship
+        attributes: {name: enterprise}
+      expect:
+        count: 1
+    - name: fuel is measured
+      uql: FETCH id, metrics(spacefleet:fuel) FROM entities(spacefleet:ship) SINCE -10m
+      expect:
+        minCount: 1
+    - name: config is applied
+      object: {type: spacefleet:shipConfig, id: enterprise}
+      expect:
+        contains:
+          - data: {speed: 5}
+      timeout: 1m
+
+The expectations are: count (exact number of rows), minCount and contains (values that must each be
+contained in a row: objects must have the specified members, arrays must have a matching element for
+each element). Object tests return the object as the only row, or no rows if it doesn't exist.
+
+Sending telemetry requires an agent principal: use --melt-profile to name a profile with the agent
+principal's credentials. The command exits with code 1 if any test fails.`,
+	Example: `  fsoc solution test
+  fsoc solution test --tag ci42 --junit results.xml
+  fsoc solution test --melt-profile agent --run "fuel" --keep`,
+	Args:             cobra.ExactArgs(0),
+	Run:              testSolution,
+	TraverseChildren: true,
+}
+
+func getSolutionTestCmd() *cobra.Command {
+	solutionTestCmd.Flags().String("directory", ".", "Path to the solution root directory")
+	solutionTestCmd.Flags().StringP("file", "f", "", fmt.Sprintf("Path to the test file (default %s in the solution directory)", testSpecFileName))
+	solutionTestCmd.Flags().String("tag", "", "Tag of the isolated deployment (default: \"test\" followed by the tag of --isolate)")
+	solutionTestCmd.Flags().String("run", "", "Run only the tests whose names match the regular expression")
+	solutionTestCmd.Flags().String("melt-profile", "", "Profile with the agent principal credentials for sending the telemetry inputs (default: the current profile)")
+	solutionTestCmd.Flags().String("junit", "", "Write the results as a JUnit XML report to the file")
+	solutionTestCmd.Flags().Int("wait", 300, "Wait (in seconds) for the solution to be deployed; 0 waits indefinitely")
+	solutionTestCmd.Flags().Bool("keep", false, "Keep the input objects and the subscription to the isolated solution after the tests")
+	return solutionTestCmd
+}
+
+// testResult is the outcome of a test, as displayed
+type testResult struct {
+	Name     string `json:"name"`
+	Result   string `json:"result"`
+	Attempts int    `json:"attempts"`
+	Duration string `json:"duration"`
+	Details  string `json:"details,omitempty"`
+
+	duration time.Duration
+}
+
+// testRun holds the state of a run of a solution's tests
+type testRun struct {
+	cmd      *cobra.Command
+	spec     *testSpec
+	solution string // name of the isolated solution
+	created  []createdObject
+}
+
+// createdObject is an input object created by a test run, to be deleted after the tests
+type createdObject struct {
+	path    string
+	headers map[string]string
+}
+
+func testSolution(cmd *cobra.Command, args []string) {
+	solutionPath, _ := cmd.Flags().GetString("directory")
+	specPath, _ := cmd.Flags().GetString("file")
+	tag, _ := cmd.Flags().GetString("tag")
+	runFilter, _ := cmd.Flags().GetString("run")
+	meltProfile, _ := cmd.Flags().GetString("melt-profile")
+	junitPath, _ := cmd.Flags().GetString("junit")
+	wait, _ := cmd.Flags().GetInt("wait")
+	keep, _ := cmd.Flags().GetBool("keep")
+
+	if !isSolutionPackageRoot(solutionPath) {
+		log.Fatalf("%q is not a solution root directory", solutionPath)
+	}
+	manifest, err := getSolutionManifest(solutionPath)
+	if err != nil {
+		log.Fatalf("Failed to read solution manifest: %v", err)
+	}
+	if specPath == "" {
+		specPath = filepath.Join(solutionPath, testSpecFileName)
+	}
+	spec, err := readTestSpec(specPath)
+	if err != nil {
+		log.WithField(exitcode.Field, exitcode.Usage).Fatalf("Failed to read the tests: %v", err)
+	}
+	if runFilter != "" {
+		re, err := regexp.Compile(runFilter)
+		if err != nil {
+			log.WithField(exitcode.Field, exitcode.Usage).Fatalf("Invalid --run expression: %v", err)
+		}
+		var tests []testCase
+		for _, t := range spec.Tests {
+			if re.MatchString(t.Name) {
+				tests = append(tests, t)
+			}
+		}
+		if len(tests) == 0 {
+			log.WithField(exitcode.Field, exitcode.Usage).Fatalf("No tests match %q", runFilter)
+		}
+		spec.Tests = tests
+	}
+	if tag == "" {
+		tag = "test" + defaultIsolationTag()
+	}
+	if !solutionTagRegexp.MatchString(tag) {
+		log.WithField(exitcode.Field, exitcode.Usage).Fatalf("Invalid tag %q: only lowercase letters and digits are allowed", tag)
+	}
+	isolation := newSolutionIsolation(manifest.Name, tag)
+	if err := spec.isolate(isolation); err != nil {
+		log.Fatalf("Failed to isolate the tests: %v", err)
+	}
+	run := &testRun{cmd: cmd, spec: spec, solution: isolation.isolatedName}
+	started := time.Now()
+
+	// deploy and subscribe
+	output.PrintCmdStatus(cmd, fmt.Sprintf("Deploying solution %s version %s for testing\n", run.solution, manifest.SolutionVersion))
+	archivePath, err := createSolutionArchive(solutionPath, filepath.Join(os.TempDir(), run.solution+".zip"), "", tag, 0)
+	if err != nil {
+		log.Fatalf("Failed to create the solution archive: %v", err)
+	}
+	pushStartTime := uploadSolutionArchive(cmd, archivePath, run.solution, defaultUploadRetries)
+	os.Remove(archivePath)
+	waitForDeployment(cmd, run.solution, manifest.SolutionVersion, time.Duration(wait)*time.Second, pushStartTime)
+	if err := setSubscription(run.solution, true); err != nil {
+		log.Fatalf("Failed to subscribe to solution %s: %v", run.solution, err)
+	}
+
+	// inject the inputs and run the tests, skipping them if the inputs can't be injected
+	var results []testResult
+	if err := run.injectInputs(isolation, meltProfile); err != nil {
+		log.Errorf("Failed to inject the test inputs: %v", err)
+		for _, t := range spec.Tests {
+			results = append(results, testResult{Name: t.Name, Result: testSkip, Details: "inputs could not be injected"})
+		}
+	} else {
+		for _, t := range spec.Tests {
+			results = append(results, run.runTest(t))
+		}
+	}
+
+	if !keep {
+		run.cleanup()
+	}
+
+	failed := run.report(results, started, junitPath)
+	if failed > 0 {
+		log.Fatalf("%d of %d test(s) failed", failed, len(results))
+	}
+}
+
+// injectInputs creates the input objects and sends the input telemetry, with the melt profile, if specified
+func (r *testRun) injectInputs(isolation *solutionIsolation, meltProfile string) error {
+	for _, o := range r.spec.Inputs.Objects {
+		data := o.Data
+		if o.File != "" {
+			fileData, err := os.ReadFile(filepath.Join(r.spec.dir, o.File))
+			if err != nil {
+				return err
+			}
+			parsed, err := parseTestData(isolation.rewriteReferences(fileData), o.File)
+			if err != nil {
+				return err
+			}
+			data = parsed
+		}
+		headers, err := r.layerHeaders(o)
+		if err != nil {
+			return err
+		}
+		output.PrintCmdStatus(r.cmd, fmt.Sprintf("Creating a %s object\n", o.Type))
+		var res map[string]any
+		if err := api.JSONPost(getObjStoreUrl()+"/"+o.Type, data, &res, &api.Options{Headers: headers}); err != nil {
+			return fmt.Errorf("failed to create a %s object: %w", o.Type, err)
+		}
+		if id, ok := res["id"].(string); ok {
+			r.created = append(r.created, createdObject{path: getObjStoreUrl() + "/" + o.Type + "/" + url.PathEscape(id), headers: headers})
+		}
+	}
+
+	if len(r.spec.Inputs.Melt) == 0 {
+		return nil
+	}
+	if meltProfile != "" {
+		restore, err := config.UseProfile(meltProfile)
+		if err != nil {
+			return err
+		}
+		defer restore()
+	}
+	exp := &melt.Exporter{}
+	for _, file := range r.spec.Inputs.Melt {
+		data, err := os.ReadFile(filepath.Join(r.spec.dir, file))
+		if err != nil {
+			return err
+		}
+		data = isolation.rewriteReferences(data)
+		output.PrintCmdStatus(r.cmd, fmt.Sprintf("Sending the telemetry in %s\n", file))
+		if melt.IsOTLPJSON(data) {
+			otlpData, err := melt.ParseOTLPJSON(data)
+			if err != nil {
+				return fmt.Errorf("failed to parse %q: %w", file, err)
+			}
+			if err := exp.ExportOTLP(otlpData); err != nil {
+				return fmt.Errorf("failed to send %q: %w", file, err)
+			}
+			continue
+		}
+		fsoData, err := melt.ParseFsocData(data)
+		if err != nil {
+			return fmt.Errorf("failed to parse %q: %w", file, err)
+		}
+		fsoData.FillDefaults(time.Now())
+		for _, export := range []func([]*melt.Entity) error{exp.ExportMetrics, exp.ExportLogs, exp.ExportSpans} {
+			if err := export(fsoData.Melt); err != nil {
+				return fmt.Errorf("failed to send %q: %w", file, err)
+			}
+		}
+	}
+	return nil
+}
+
+// runTest runs a test until it passes or its timeout expires
+func (r *testRun) runTest(t testCase) testResult {
+	timeout := t.Timeout
+	if timeout == 0 {
+		timeout = r.spec.Timeout
+	}
+	output.PrintCmdStatus(r.cmd, fmt.Sprintf("Running test %q\n", t.Name))
+	start := time.Now()
+	deadline := start.Add(timeout)
+	result := testResult{Name: t.Name}
+	for {
+		result.Attempts++
+		rows, err := r.fetchRows(t)
+		if err == nil {
+			err = t.Expect.check(rows)
+		}
+		if err == nil {
+			result.Result = testPass
+			break
+		}
+		log.WithFields(log.Fields{"test": t.Name, "attempt": result.Attempts, "error": err}).Info("Test attempt failed")
+		if time.Now().Add(r.spec.Interval).After(deadline) {
+			result.Result = testFail
+			result.Details = err.Error()
+			break
+		}
+		time.Sleep(r.spec.Interval)
+	}
+	result.duration = time.Since(start).Round(time.Millisecond)
+	result.Duration = result.duration.String()
+	return result
+}
+
+// fetchRows returns the rows a test asserts: the rows of the query's results or the object, if it exists
+func (r *testRun) fetchRows(t testCase) ([]any, error) {
+	if t.Object != nil {
+		headers, err := r.layerHeaders(*t.Object)
+		if err != nil {
+			return nil, err
+		}
+		var object any
+		err = api.JSONGet(getObjStoreUrl()+"/"+t.Object.Type+"/"+url.PathEscape(t.Object.ID), &object, &api.Options{Headers: headers})
+		if exitcode.Of(err) == exitcode.NotFound {
+			return []any{}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return []any{object}, nil
+	}
+
+	query, err := t.query()
+	if err != nil {
+		return nil, err
+	}
+	response, err := uql.ExecuteQuery(&uql.Query{Str: query}, uql.ApiVersion1)
+	if err != nil {
+		return nil, err
+	}
+	if response.HasErrors() {
+		var messages []string
+		for _, e := range response.Errors() {
+			messages = append(messages, fmt.Sprintf("%s: %s", e.Title, e.Detail))
+		}
+		return nil, fmt.Errorf("query errors: %s", strings.Join(messages, "; "))
+	}
+	return uql.JSONData(response)
+}
+
+// layerHeaders returns the layer headers for an object: the TENANT layer unless specified, with the
+// tenant as its layer ID, and the SOLUTION layer with the isolated solution as its layer ID
+func (r *testRun) layerHeaders(o testObject) (map[string]string, error) {
+	layerType := strings.ToUpper(o.LayerType)
+	if layerType == "" {
+		layerType = "TENANT"
+	}
+	layerID := o.LayerID
+	if layerID == "" {
+		switch layerType {
+		case "TENANT":
+			layerID = config.GetCurrentContext().Tenant
+		case "SOLUTION":
+			layerID = r.solution
+		default:
+			return nil, fmt.Errorf("layerId is required for layer type %s", layerType)
+		}
+	}
+	return map[string]string{"layer-type": layerType, "layer-id": layerID}, nil
+}
+
+// cleanup deletes the input objects and unsubscribes from the isolated solution; failures are
+// reported but don't fail the command
+func (r *testRun) cleanup() {
+	output.PrintCmdStatus(r.cmd, fmt.Sprintf("Cleaning up: deleting %d input object(s) and unsubscribing from solution %s\n", len(r.created), r.solution))
+	for _, o := range r.created {
+		var res any
+		if err := api.JSONDelete(o.path, &res, &api.Options{Headers: o.headers}); err != nil {
+			log.Warnf("Failed to delete the input object %s: %v", o.path, err)
+		}
+	}
+	if err := setSubscription(r.solution, false); err != nil {
+		log.Warnf("Failed to unsubscribe from solution %s: %v", r.solution, err)
+	}
+}
+
+// report displays the results and writes the JUnit report, if requested; it returns the number
+// of failed tests
+func (r *testRun) report(results []testResult, started time.Time, junitPath string) int {
+	suite := junit.Suite{Name: r.solution}
+	var lines [][]string
+	for _, res := range results {
+		lines = append(lines, []string{res.Name, res.Result, fmt.Sprint(res.Attempts), res.Duration, res.Details})
+		c := junit.Case{Name: res.Name, Class: r.solution, Duration: res.duration}
+		switch res.Result {
+		case testFail:
+			c.Failure = res.Details
+			c.Output = fmt.Sprintf("%d attempt(s)", res.Attempts)
+		case testSkip:
+			c.Skipped = res.Details
+		}
+		suite.Cases = append(suite.Cases, c)
+	}
+	output.PrintCmdOutputCustom(r.cmd, struct {
+		Items []testResult `json:"items"`
+		Total int          `json:"total"`
+	}{Items: results, Total: len(results)}, &output.Table{
+		Headers: []string{"Test", "Result", "Attempts", "Duration", "Details"},
+		Lines:   lines,
+	})
+
+	if junitPath != "" {
+		if err := writeJUnitReport(junitPath, started, suite); err != nil {
+			log.Errorf("Failed to write the JUnit report: %v", err)
+		} else {
+			output.PrintCmdStatus(r.cmd, fmt.Sprintf("JUnit report written to %s\n", junitPath))
+		}
+	}
+
+	failed := suite.Failed()
+	for _, res := range results {
+		if res.Result == testSkip {
+			failed++ // skipped due to a setup failure
+		}
+	}
+	return failed
+}
+
+func writeJUnitReport(path string, started time.Time, suite junit.Suite) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := junit.Write(f, started, suite); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func getObjStoreUrl() string {
+	return "objstore/v1beta/objects"
+}
@@ -0,0 +1,322 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package solution
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/cisco-open/fsoc/cmd/uql"
+)
+
+// testSpecFileName is the default name of the file with a solution's tests, in the solution directory
+const testSpecFileName = "solution-tests.yaml"
+
+// Defaults of the tests' limits
+const (
+	defaultTestTimeout  = 5 * time.Minute
+	defaultTestInterval = 15 * time.Second
+)
+
+// testSpec defines the tests of a solution: the inputs injected into the tenant once the solution is
+// deployed and the tests asserting the results, which are retried until they pass or time out
+type testSpec struct {
+	Timeout  time.Duration `yaml:"timeout"`  // default time for each test to pass
+	Interval time.Duration `yaml:"interval"` // time between the attempts of a test
+	Inputs   testInputs    `yaml:"inputs"`
+	Tests    []testCase    `yaml:"tests"`
+
+	dir string // directory of the spec file, to which input file paths are relative
+}
+
+// testInputs are the data injected into the tenant before the tests are run
+type testInputs struct {
+	Objects []testObject `yaml:"objects"`
+	Melt    []string     `yaml:"melt"` // fsoc telemetry model .yaml or OTLP JSON files
+}
+
+// testObject is a knowledge object created before the tests are run and deleted afterwards
+type testObject struct {
+	Type      string         `yaml:"type"`
+	ID        string         `yaml:"id"` // for object tests only; the ID of an input object is in its data
+	LayerType string         `yaml:"layerType"`
+	LayerID   string         `yaml:"layerId"`
+	File      string         `yaml:"file"`
+	Data      map[string]any `yaml:"data"`
+}
+
+// testCase is a named assertion on the rows returned by a UQL query, the entities of a type or a
+// knowledge object
+type testCase struct {
+	Name    string        `yaml:"name"`
+	UQL     string        `yaml:"uql"`
+	Entity  *testEntity   `yaml:"entity"`
+	Object  *testObject   `yaml:"object"`
+	Expect  testExpect    `yaml:"expect"`
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// testEntity selects the entities of a type, optionally with the specified attribute values
+type testEntity struct {
+	Type       string            `yaml:"type"`
+	Attributes map[string]string `yaml:"attributes"`
+	Since      string            `yaml:"since"`
+}
+
+// testExpect is the expected outcome of a test case; all specified conditions must be met
+type testExpect struct {
+	Count    *int  `yaml:"count"`    // exact number of rows
+	MinCount *int  `yaml:"minCount"` // minimum number of rows
+	Contains []any `yaml:"contains"` // values that must each match a row (see matchesSubset)
+}
+
+// readTestSpec reads and validates a test spec file
+func readTestSpec(path string) (*testSpec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	spec := &testSpec{}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(spec); err != nil {
+		return nil, fmt.Errorf("failed to parse %q: %w", path, err)
+	}
+	spec.dir = filepath.Dir(path)
+	if spec.Timeout == 0 {
+		spec.Timeout = defaultTestTimeout
+	}
+	if spec.Interval == 0 {
+		spec.Interval = defaultTestInterval
+	}
+	if err := spec.validate(); err != nil {
+		return nil, fmt.Errorf("invalid test spec %q: %w", path, err)
+	}
+	return spec, nil
+}
+
+func (s *testSpec) validate() error {
+	if len(s.Tests) == 0 {
+		return errors.New("no tests defined")
+	}
+	for i, o := range s.Inputs.Objects {
+		if o.Type == "" {
+			return fmt.Errorf("input object #%d: missing type", i+1)
+		}
+		if o.ID != "" {
+			return fmt.Errorf("input object #%d: the object ID must be in its data", i+1)
+		}
+		if (o.File == "") == (o.Data == nil) {
+			return fmt.Errorf("input object #%d: exactly one of file and data must be specified", i+1)
+		}
+	}
+	names := map[string]bool{}
+	for i, t := range s.Tests {
+		if t.Name == "" {
+			return fmt.Errorf("test #%d: missing name", i+1)
+		}
+		if names[t.Name] {
+			return fmt.Errorf("test %q: duplicate name", t.Name)
+		}
+		names[t.Name] = true
+		sources := 0
+		for _, set := range []bool{t.UQL != "", t.Entity != nil, t.Object != nil} {
+			if set {
+				sources++
+			}
+		}
+		if sources != 1 {
+			return fmt.Errorf("test %q: exactly one of uql, entity and object must be specified", t.Name)
+		}
+		if t.Entity != nil && !uql.IsEntityType(t.Entity.Type) {
+			return fmt.Errorf("test %q: invalid entity type %q", t.Name, t.Entity.Type)
+		}
+		if t.Object != nil && (t.Object.Type == "" || t.Object.ID == "" || t.Object.File != "" || t.Object.Data != nil) {
+			return fmt.Errorf("test %q: object must have a type, an id and an optional layer, without file or data", t.Name)
+		}
+		if t.Expect.Count == nil && t.Expect.MinCount == nil && len(t.Expect.Contains) == 0 {
+			return fmt.Errorf("test %q: expect must specify count, minCount or contains", t.Name)
+		}
+	}
+	return nil
+}
+
+// isolate rewrites the references to the solution's types in the queries, types and object data of the
+// spec, so that they refer to the isolated solution
+func (s *testSpec) isolate(isolation *solutionIsolation) error {
+	rewrite := func(str string) string { return string(isolation.rewriteReferences([]byte(str))) }
+	for i := range s.Inputs.Objects {
+		o := &s.Inputs.Objects[i]
+		o.Type = rewrite(o.Type)
+		if o.Data != nil {
+			data, err := isolateValue(isolation, o.Data)
+			if err != nil {
+				return err
+			}
+			o.Data = data.(map[string]any)
+		}
+	}
+	for i := range s.Tests {
+		t := &s.Tests[i]
+		t.UQL = rewrite(t.UQL)
+		if t.Entity != nil {
+			t.Entity.Type = rewrite(t.Entity.Type)
+		}
+		if t.Object != nil {
+			t.Object.Type = rewrite(t.Object.Type)
+		}
+	}
+	return nil
+}
+
+// isolateValue rewrites the references to the solution's types in a JSON value
+func isolateValue(isolation *solutionIsolation, value any) (any, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var rewritten any
+	err = json.Unmarshal(isolation.rewriteReferences(data), &rewritten)
+	return rewritten, err
+}
+
+// query returns the UQL query of a uql or entity test
+func (t *testCase) query() (string, error) {
+	if t.UQL != "" {
+		return t.UQL, nil
+	}
+	var filters []string
+	for name, value := range t.Entity.Attributes {
+		filters = append(filters, name+"="+value)
+	}
+	sort.Strings(filters)
+	filter, err := uql.AttributeFilter(filters)
+	if err != nil {
+		return "", err
+	}
+	query := fmt.Sprintf("FETCH id, type, attributes FROM entities(%s)%s", t.Entity.Type, filter)
+	if t.Entity.Since != "" {
+		since, err := uql.TimeExpression(t.Entity.Since)
+		if err != nil {
+			return "", err
+		}
+		query += " SINCE " + since
+	}
+	return query, nil
+}
+
+// check returns an error describing the first unmet expectation for the rows, if any
+func (e *testExpect) check(rows []any) error {
+	if e.Count != nil && len(rows) != *e.Count {
+		return fmt.Errorf("expected %d row(s), found %d", *e.Count, len(rows))
+	}
+	if e.MinCount != nil && len(rows) < *e.MinCount {
+		return fmt.Errorf("expected at least %d row(s), found %d", *e.MinCount, len(rows))
+	}
+	for _, expected := range e.Contains {
+		normalized, err := normalizeJSON(expected)
+		if err != nil {
+			return err
+		}
+		found := false
+		for _, row := range rows {
+			if matchesSubset(normalized, row) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			data, _ := json.Marshal(normalized)
+			return fmt.Errorf("no row matches %s", data)
+		}
+	}
+	return nil
+}
+
+// normalizeJSON converts a value parsed from YAML to the types of a value parsed from JSON
+func normalizeJSON(value any) (any, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var normalized any
+	err = json.Unmarshal(data, &normalized)
+	return normalized, err
+}
+
+// matchesSubset returns true if the actual value contains the expected value: objects must have the
+// expected members (and may have others), arrays must have a matching element for each expected
+// element and other values must be equal
+func matchesSubset(expected any, actual any) bool {
+	switch exp := expected.(type) {
+	case map[string]any:
+		act, ok := actual.(map[string]any)
+		if !ok {
+			return false
+		}
+		for k, v := range exp {
+			if av, found := act[k]; !found || !matchesSubset(v, av) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		act, ok := actual.([]any)
+		if !ok {
+			return false
+		}
+		for _, v := range exp {
+			found := false
+			for _, av := range act {
+				if matchesSubset(v, av) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+		return true
+	case string:
+		// values of other types match their text, e.g., "5" matches 5
+		if s, ok := actual.(string); ok {
+			return s == exp
+		}
+		return actual != nil && fmt.Sprint(actual) == exp
+	}
+	return reflect.DeepEqual(expected, actual)
+}
+
+// parseTestData parses the data of an input object, in JSON or YAML format
+func parseTestData(data []byte, source string) (map[string]any, error) {
+	var object map[string]any
+	if err := yaml.Unmarshal(data, &object); err != nil {
+		return nil, fmt.Errorf("failed to parse %q as JSON or YAML: %w", source, err)
+	}
+	if object == nil {
+		return nil, errors.New("no object data found in " + source)
+	}
+	return object, nil
+}
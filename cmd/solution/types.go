@@ -58,6 +58,7 @@ type SolutionDef struct {
 	IsSubscribed bool     `json:"isSubscribed,omitempty"`
 	IsSystem     bool     `json:"isSystem,omitempty"`
 	Name         string   `json:"name,omitempty"`
+	Version      string   `json:"solutionVersion,omitempty"`
 }
 
 type FmmTypeDef struct {
@@ -21,6 +21,8 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/cisco-open/fsoc/cmd/config"
+	"github.com/cisco-open/fsoc/cmdkit/onerror"
+	"github.com/cisco-open/fsoc/cmdkit/picker"
 	"github.com/cisco-open/fsoc/platform/api"
 )
 
@@ -30,7 +32,11 @@ var solutionUnsubscribeCmd = &cobra.Command{
 	Long: `This command allows the current tenant specified in the profile to unsubscribe from a solution.
 
 Example:
-  fsoc solution unsubscribe --name=spacefleet`,
+  fsoc solution unsubscribe --name=spacefleet
+
+With --pick, the solutions to unsubscribe from are chosen interactively among the subscribed, non-system solutions.
+Without --name, they are also chosen this way when running interactively (see --interactive).
+When unsubscribing from a solution fails, the user is asked whether to retry, skip it or abort (see --on-error).`,
 	Args:             cobra.ExactArgs(0),
 	Run:              unsubscribeFromSolution,
 	TraverseChildren: true,
@@ -39,16 +45,28 @@ Example:
 func getUnsubscribeSolutionCmd() *cobra.Command {
 	solutionUnsubscribeCmd.Flags().
 		String("name", "", "The name of the solution the tenant is unsubscribing from")
-	_ = solutionUnsubscribeCmd.MarkFlagRequired("name")
+	picker.AddFlag(solutionUnsubscribeCmd)
+	solutionUnsubscribeCmd.MarkFlagsMutuallyExclusive("name", picker.FlagName)
+	onerror.AddFlag(solutionUnsubscribeCmd, onerror.Abort)
 
 	return solutionUnsubscribeCmd
 
 }
 
 func unsubscribeFromSolution(cmd *cobra.Command, args []string) {
+	errs, err := onerror.New(cmd)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
 	solutionName, _ := cmd.Flags().GetString("name")
+	if picker.Requested(cmd) || solutionName == "" && picker.Interactive(cmd) {
+		for _, name := range pickSolutions("Solutions to unsubscribe from", func(s SolutionDef) bool { return s.IsSubscribed && !s.IsSystem }) {
+			manageSubscription(cmd, name, false, errs)
+		}
+		return
+	}
 	if solutionName == "" {
-		log.Fatal("Solution name cannot be empty, use --name=<solution>")
+		log.Fatal("Solution name cannot be empty, use --name=<solution> or --pick")
 	}
 
 	isSystemSolution, err := isSystemSolution(solutionName)
@@ -58,7 +76,7 @@ func unsubscribeFromSolution(cmd *cobra.Command, args []string) {
 	if isSystemSolution {
 		log.Fatalf("Cannot unsubscribe tenant from solution %s because it is a system solution\n", solutionName)
 	} else {
-		manageSubscription(cmd, args, false)
+		manageSubscription(cmd, solutionName, false, errs)
 	}
 }
 
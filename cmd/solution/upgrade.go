@@ -0,0 +1,182 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package solution
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+
+	"github.com/cisco-open/fsoc/cmd/config"
+	"github.com/cisco-open/fsoc/output"
+)
+
+var solutionUpgradeCmd = &cobra.Command{
+	Use:   "upgrade",
+	Short: "Upgrade a deployed solution to the version in a solution directory",
+	Long: `This command upgrades the solution deployed in the tenant to the version of the solution in the
+directory specified with --directory (default is the current directory), in a guided sequence of steps:
+
+  1. Check the installed version, which must be older than the version in the directory (unless --force)
+  2. Show the changes between the versions, from the solution's CHANGELOG.md (see "fsoc solution bump")
+  3. Check that the dependencies and object types used by the new version are available in the tenant
+     (see "fsoc solution check-compat"); failed checks stop the upgrade unless --skip-checks is specified
+  4. Ask for confirmation, unless --yes is specified, then deploy the solution and wait for its installation
+  5. Verify that the tenant reports the new version as installed
+
+The command exits with code 2 if the installation fails and 3 if it does not complete within the wait
+time (see "fsoc solution push --wait"). A solution that is not installed yet is installed as if upgraded.
+
+Examples:
+  fsoc solution upgrade
+  fsoc solution upgrade --directory mysolution --yes --wait 600`,
+	Args:             cobra.ExactArgs(0),
+	Run:              upgradeSolution,
+	TraverseChildren: true,
+}
+
+func getSolutionUpgradeCmd() *cobra.Command {
+	solutionUpgradeCmd.Flags().String("directory", ".", "Path to the solution root directory")
+	solutionUpgradeCmd.Flags().IntP("wait", "w", 300, "Wait (in seconds) for the solution to be installed; 0 waits indefinitely")
+	solutionUpgradeCmd.Flags().BoolP("yes", "y", false, "Upgrade without asking for confirmation")
+	solutionUpgradeCmd.Flags().Bool("force", false, "Deploy even if the installed version is the same or newer")
+	solutionUpgradeCmd.Flags().Bool("skip-checks", false, "Upgrade even if the compatibility checks fail")
+	return solutionUpgradeCmd
+}
+
+func upgradeSolution(cmd *cobra.Command, args []string) {
+	solutionPath, _ := cmd.Flags().GetString("directory")
+	wait, _ := cmd.Flags().GetInt("wait")
+	yes, _ := cmd.Flags().GetBool("yes")
+	force, _ := cmd.Flags().GetBool("force")
+	skipChecks, _ := cmd.Flags().GetBool("skip-checks")
+
+	if !isSolutionPackageRoot(solutionPath) {
+		log.Fatalf("%q is not a solution root directory", solutionPath)
+	}
+	manifest, err := getSolutionManifest(solutionPath)
+	if err != nil {
+		log.Fatalf("Failed to read solution manifest: %v", err)
+	}
+	newVersion, err := parseSemver(manifest.SolutionVersion)
+	if err != nil {
+		log.Fatalf("The manifest has an invalid solution version: %v", err)
+	}
+
+	// step 1: installed version
+	output.PrintCmdStatus(cmd, fmt.Sprintf("Step 1/5: checking the installed version of solution %s in the environment of profile %q\n", manifest.Name, config.GetCurrentProfileName()))
+	solutions, err := getTenantSolutions()
+	if err != nil {
+		log.Fatalf("Failed to get the list of solutions available in the tenant: %v", err)
+	}
+	var oldVersion semver
+	if installed, found := solutions[manifest.Name]; !found {
+		output.PrintCmdStatus(cmd, fmt.Sprintf("  Solution %s is not installed; version %s will be installed\n", manifest.Name, newVersion))
+	} else {
+		oldVersion, err = parseSemver(installed.Version)
+		if err != nil {
+			log.Warnf("The installed version %q cannot be parsed: %v", installed.Version, err)
+		}
+		output.PrintCmdStatus(cmd, fmt.Sprintf("  Installed version: %s, new version: %s\n", installed.Version, newVersion))
+		if newVersion.compare(oldVersion) <= 0 {
+			if !force {
+				log.Fatalf("The installed version %s is not older than version %s; use \"fsoc solution bump\" to increment the version or --force to deploy anyway", installed.Version, newVersion)
+			}
+			log.Warn("The installed version is not older than the new version, deploying anyway")
+		}
+	}
+
+	// step 2: changes
+	output.PrintCmdStatus(cmd, "Step 2/5: changes since the installed version\n")
+	entries, err := readChangelog(filepath.Join(solutionPath, changelogFileName), oldVersion, newVersion)
+	if err != nil {
+		log.Warnf("Failed to read the changelog: %v", err)
+	}
+	if len(entries) == 0 {
+		output.PrintCmdStatus(cmd, fmt.Sprintf("  No entries found in %s\n", changelogFileName))
+	}
+	for _, e := range entries {
+		output.PrintCmdStatus(cmd, fmt.Sprintf("  %s\n", e.Heading))
+		if e.Body != "" {
+			output.PrintCmdStatus(cmd, indentLines(e.Body, "    ")+"\n")
+		}
+	}
+
+	// step 3: compatibility
+	output.PrintCmdStatus(cmd, "Step 3/5: checking compatibility with the environment\n")
+	checks := checkDependenciesCompat(manifest, solutions, map[string]bool{})
+	checks = append(checks, checkTypesCompat(manifest, solutions)...)
+	failures := 0
+	for _, c := range checks {
+		if c.isFailure() {
+			failures++
+			output.PrintCmdStatus(cmd, fmt.Sprintf("  %s %s: %s (%s)\n", c.Kind, c.Name, c.Status, c.Details))
+		}
+	}
+	if failures > 0 {
+		if !skipChecks {
+			log.Fatalf("Solution %s is not compatible with the environment: %d problem(s) found; use --skip-checks to upgrade anyway", manifest.Name, failures)
+		}
+		log.Warnf("Ignoring %d compatibility problem(s)", failures)
+	} else {
+		output.PrintCmdStatus(cmd, fmt.Sprintf("  All %d checks passed\n", len(checks)))
+	}
+
+	// step 4: deploy
+	if !yes {
+		if fi, err := os.Stdin.Stat(); err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+			log.Fatal("Confirmation is required but the input is not a terminal; use the --yes flag to confirm")
+		}
+		p := &prompter{in: bufio.NewReader(cmd.InOrStdin()), cmd: cmd}
+		if !p.promptBool(fmt.Sprintf("Upgrade solution %s to version %s", manifest.Name, newVersion), false) {
+			output.PrintCmdStatus(cmd, "Upgrade canceled.\n")
+			return
+		}
+	}
+	output.PrintCmdStatus(cmd, fmt.Sprintf("Step 4/5: deploying solution %s version %s\n", manifest.Name, newVersion))
+	archive := generateZipNoCmd(solutionPath, "", 0)
+	defer os.Remove(archive.Name())
+	pushStartTime := uploadSolutionArchive(cmd, archive.Name(), manifest.Name, defaultUploadRetries)
+	waitForDeployment(cmd, manifest.Name, manifest.SolutionVersion, time.Duration(wait)*time.Second, pushStartTime)
+
+	// step 5: verify
+	output.PrintCmdStatus(cmd, "Step 5/5: verifying the upgrade\n")
+	solutions, err = getTenantSolutions()
+	if err != nil {
+		log.Fatalf("Failed to get the list of solutions available in the tenant: %v", err)
+	}
+	installed, found := solutions[manifest.Name]
+	if !found || installed.Version != manifest.SolutionVersion {
+		log.Fatalf("The installation succeeded but the tenant reports solution %s at version %q instead of %s", manifest.Name, installed.Version, newVersion)
+	}
+	output.PrintCmdStatus(cmd, fmt.Sprintf("Solution %s was successfully upgraded to version %s.\n", manifest.Name, newVersion))
+}
+
+// indentLines prefixes each line of the text with the indentation
+func indentLines(text string, indent string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = indent + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -51,38 +51,71 @@ func getSolutionValidateUrl() string {
 func getSolutionValidateCmd() *cobra.Command {
 	solutionValidateCmd.Flags().
 		String("solution-bundle", "", "The fully qualified path name for the solution bundle .zip file that you want to validate")
+	solutionValidateCmd.Flags().
+		Bool("local", false, "Perform only the local checks, without uploading the solution to the platform for validation")
+	solutionValidateCmd.MarkFlagsMutuallyExclusive("solution-bundle", "local")
 
 	return solutionValidateCmd
 }
 
 var solutionValidateCmd = &cobra.Command{
-	Use:   "validate",
+	Use:   "validate [DIR]",
 	Short: "Validate your solution package",
-	Long: `This command allows the current tenant specified in the profile to upload the specified solution bundle for the purpose of validating its contents
+	Long: `This command validates the solution in the specified directory (or the current directory) and, unless
+the --local flag is specified, uploads it to the current tenant specified in the profile to validate its contents there.
+
+Before uploading, the solution is checked locally:
+  - all files and directories referenced by the manifest must exist and contain valid JSON
+  - the manifest is checked against an approximation of its JSON schema
+  - objects of the knowledge types defined by the solution are validated against the types' JSON schemas
+All problems found are reported at once, with the file and line where they occur. Missing files and invalid
+JSON are errors, and the solution is uploaded only if there are none; the schema findings are reported as
+warnings, leaving the final say to the platform's validation.
+
+A solution bundle .zip file can be validated with the --solution-bundle flag; it is not checked locally.
 
 Example:
+  fsoc solution validate
+  fsoc solution validate mysolution --local
   fsoc solution validate --solution-bundle=mysolution.zip`,
-	Args:             cobra.ExactArgs(0),
+	Args:             cobra.MaximumNArgs(1),
 	Run:              validateSolution,
 	TraverseChildren: true,
 }
 
 func validateSolution(cmd *cobra.Command, args []string) {
-	manifestPath := ""
 	solutionBundlePath, _ := cmd.Flags().GetString("solution-bundle")
+	localOnly, _ := cmd.Flags().GetBool("local")
+	if solutionBundlePath != "" && len(args) > 0 {
+		log.Fatal("A solution directory cannot be specified together with the --solution-bundle flag")
+	}
 	var solutionArchivePath string
 	if solutionBundlePath == "" {
-		currentDir, err := os.Getwd()
-		if err != nil {
-			log.Fatal("Please run this command in a folder with a solution or use the --solution-bundle flag")
+		manifestPath := "."
+		if len(args) > 0 {
+			manifestPath = args[0]
 		}
-		manifestPath = currentDir
 		if !isSolutionPackageRoot(manifestPath) {
-			log.Fatal("solution-bundle / current dir path doesn't point to a solution package root folder")
+			log.Fatalf("%q is not a solution package root folder; please run this command in a folder with a solution or use the --solution-bundle flag", manifestPath)
+		}
+
+		issues := validateSolutionDir(manifestPath)
+		if len(issues) > 0 {
+			printValidationIssues(cmd, issues)
+		}
+		if errCount := countErrors(issues); errCount > 0 {
+			log.Fatalf("%d error(s) found while validating the solution locally", errCount)
+		}
+		if localOnly {
+			output.PrintCmdStatus(cmd, fmt.Sprintf("Solution in %q passed the local validation.\n", manifestPath))
+			return
 		}
-		_, _ = getSolutionManifest(manifestPath)
 
-		solutionArchive := generateZipNoCmd(manifestPath)
+		absPath, err := filepath.Abs(manifestPath)
+		if err != nil {
+			log.Fatalf("Failed to determine the solution directory: %v", err)
+		}
+		solutionArchive := generateZipNoCmd(absPath, "", 0)
 		solutionArchivePath = filepath.Base(solutionArchive.Name())
 	} else {
 		solutionArchivePath = solutionBundlePath
@@ -99,7 +132,7 @@ func validateSolution(cmd *cobra.Command, args []string) {
 	body := &bytes.Buffer{}
 	writer := multipart.NewWriter(body)
 
-	fw, err := writer.CreateFormFile("file", solutionArchivePath)
+	fw, err := writer.CreateFormFile("file", filepath.Base(solutionArchivePath))
 	if err != nil {
 		log.Fatalf("Failed to create form file: %v", err)
 	}
@@ -121,7 +154,7 @@ func validateSolution(cmd *cobra.Command, args []string) {
 
 	var res Result
 
-	err = api.HTTPPost(getSolutionValidateUrl(), body.Bytes(), &res, &api.Options{Headers: headers})
+	err = api.HTTPPost(getSolutionValidateUrl(), body.Bytes(), &res, &api.Options{Headers: headers, ReadOnly: true})
 	if err != nil {
 		log.Fatalf("Solution validate request failed: %v", err)
 	}
@@ -137,6 +170,20 @@ func validateSolution(cmd *cobra.Command, args []string) {
 	}
 }
 
+func printValidationIssues(cmd *cobra.Command, issues []validationIssue) {
+	lines := make([][]string, len(issues))
+	for i, issue := range issues {
+		lines[i] = []string{issue.location(), issue.Severity, issue.Message}
+	}
+	output.PrintCmdOutputCustom(cmd, struct {
+		Items []validationIssue `json:"items"`
+		Total int               `json:"total"`
+	}{Items: issues, Total: len(issues)}, &output.Table{
+		Headers: []string{"Location", "Severity", "Message"},
+		Lines:   lines,
+	})
+}
+
 func getSolutionValidationErrorsString(total int, errors Errors) string {
 	var message = fmt.Sprintf("\n%d errors detected while validating solution package\n", total)
 	for _, err := range errors.Items {
@@ -0,0 +1,438 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package solution
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/apex/log"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// manifestSchema is an approximation of the solution manifest's schema, which is enforced only by the
+// platform; the local findings against it are reported as warnings
+const manifestSchema = `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "title": "Solution manifest",
+  "type": "object",
+  "required": ["manifestVersion", "name", "solutionVersion", "dependencies"],
+  "properties": {
+    "manifestVersion": { "type": "string", "minLength": 1 },
+    "name": { "type": "string", "pattern": "^[a-zA-Z][a-zA-Z0-9_]*$" },
+    "solutionVersion": { "type": "string", "pattern": "^[0-9]+\\.[0-9]+\\.[0-9]+$" },
+    "dependencies": { "type": "array", "items": { "type": "string", "minLength": 1 } },
+    "description": { "type": "string" },
+    "contact": { "type": "string" },
+    "homepage": { "type": "string" },
+    "gitRepoUrl": { "type": "string" },
+    "readme": { "type": "string" },
+    "objects": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "required": ["type"],
+        "properties": {
+          "type": { "type": "string", "pattern": "^[^:]+:[^:]+$" },
+          "objectsFile": { "type": "string" },
+          "objectsDir": { "type": "string" }
+        }
+      }
+    },
+    "types": { "type": "array", "items": { "type": "string", "minLength": 1 } }
+  }
+}`
+
+// issue severities: errors (missing files and invalid JSON) fail the validation, warnings are only reported
+const (
+	severityError   = "error"
+	severityWarning = "warning"
+)
+
+// validationIssue is a problem found in a solution's files during local validation
+type validationIssue struct {
+	File     string `json:"file"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+func (i validationIssue) location() string {
+	if i.Line == 0 {
+		return i.File
+	}
+	return fmt.Sprintf("%s:%d:%d", i.File, i.Line, i.Column)
+}
+
+// countErrors returns the number of issues that fail the validation
+func countErrors(issues []validationIssue) int {
+	count := 0
+	for _, issue := range issues {
+		if issue.Severity == severityError {
+			count++
+		}
+	}
+	return count
+}
+
+// jsonFile is a parsed JSON file of a solution, with the positions of its values for error reporting
+type jsonFile struct {
+	name    string // path relative to the solution root
+	data    []byte
+	value   any
+	offsets map[string]int64 // offsets of values, keyed by gojsonschema field path (e.g., "objects.0.type")
+}
+
+// localValidator collects the issues found in a solution directory
+type localValidator struct {
+	root   string
+	issues []validationIssue
+}
+
+// validateSolutionDir checks the solution in the directory without contacting the platform:
+// all files referenced by the manifest must exist and be valid JSON (errors), while the manifest,
+// the type definitions and the objects of the knowledge types defined by the solution are checked
+// against their JSON schemas (warnings). All issues found are returned.
+func validateSolutionDir(root string) []validationIssue {
+	v := &localValidator{root: root}
+
+	manifestFile := v.readJSONFile("manifest.json")
+	if manifestFile == nil {
+		return v.issues
+	}
+	schema, err := gojsonschema.NewSchema(gojsonschema.NewStringLoader(manifestSchema))
+	if err != nil {
+		log.Fatalf("bug: invalid manifest schema: %v", err)
+	}
+	v.validateAgainstSchema(manifestFile, "", manifestFile.value, schema)
+
+	var manifest Manifest
+	if err := json.Unmarshal(manifestFile.data, &manifest); err != nil {
+		v.addIssue(manifestFile, "", severityError, fmt.Sprintf("Failed to parse the manifest: %v", err))
+		return v.issues
+	}
+
+	typeSchemas := v.validateTypes(&manifest, manifestFile)
+	v.validateObjects(&manifest, manifestFile, typeSchemas)
+
+	return v.issues
+}
+
+// validateTypes checks the knowledge type definition files and returns the types' schemas, keyed by fully qualified type name
+func (v *localValidator) validateTypes(manifest *Manifest, manifestFile *jsonFile) map[string]*gojsonschema.Schema {
+	typeSchemas := map[string]*gojsonschema.Schema{}
+	for i, typeFileName := range manifest.Types {
+		if !v.checkExists(manifestFile, fmt.Sprintf("types.%d", i), typeFileName, false) {
+			continue
+		}
+		typeFile := v.readJSONFile(typeFileName)
+		if typeFile == nil {
+			continue
+		}
+		for _, obj := range splitObjects(typeFile.value) {
+			path := obj.path
+			typeDef, ok := obj.value.(map[string]any)
+			if !ok {
+				v.addIssue(typeFile, path, severityWarning, "A type definition must be a JSON object")
+				continue
+			}
+			name, _ := typeDef["name"].(string)
+			if name == "" {
+				v.addIssue(typeFile, path, severityWarning, `The type definition is missing its "name"`)
+				continue
+			}
+			jsonSchema, found := typeDef["jsonSchema"]
+			if !found {
+				v.addIssue(typeFile, path, severityWarning, fmt.Sprintf("Type %q is missing its \"jsonSchema\"", name))
+				continue
+			}
+			schema, err := gojsonschema.NewSchema(gojsonschema.NewGoLoader(jsonSchema))
+			if err != nil {
+				v.addIssue(typeFile, joinPath(path, "jsonSchema"), severityWarning, fmt.Sprintf("Type %q has an invalid JSON schema: %v", name, err))
+				continue
+			}
+			typeSchemas[manifest.Name+":"+name] = schema
+		}
+	}
+	return typeSchemas
+}
+
+// validateObjects checks the object files referenced by the manifest, validating the objects of
+// the knowledge types defined by the solution against the types' schemas. The object files are
+// parsed and validated concurrently; the issues are reported in the order of the files.
+func (v *localValidator) validateObjects(manifest *Manifest, manifestFile *jsonFile, typeSchemas map[string]*gojsonschema.Schema) {
+	var jobs []objectFileJob
+	for i, objDef := range manifest.Objects {
+		path := fmt.Sprintf("objects.%d", i)
+		var fileNames []string
+		switch {
+		case objDef.ObjectsFile != "":
+			if v.checkExists(manifestFile, path+".objectsFile", objDef.ObjectsFile, false) {
+				fileNames = append(fileNames, objDef.ObjectsFile)
+			}
+		case objDef.ObjectsDir != "":
+			if v.checkExists(manifestFile, path+".objectsDir", objDef.ObjectsDir, true) {
+				fileNames = append(fileNames, v.listJSONFiles(objDef.ObjectsDir)...)
+			}
+		default:
+			v.addIssue(manifestFile, path, severityWarning, fmt.Sprintf("Objects of type %q must specify either \"objectsFile\" or \"objectsDir\"", objDef.Type))
+		}
+
+		for _, fileName := range fileNames {
+			jobs = append(jobs, objectFileJob{fileName: fileName, schema: typeSchemas[objDef.Type]})
+		}
+	}
+
+	for _, issues := range v.runObjectFileJobs(jobs) {
+		v.issues = append(v.issues, issues...)
+	}
+}
+
+// objectFileJob is the validation of a single object file, against the schema of its type (if known)
+type objectFileJob struct {
+	fileName string
+	schema   *gojsonschema.Schema
+}
+
+// runObjectFileJobs validates the object files with a pool of workers, returning the issues
+// found in each file, in the same order as the jobs
+func (v *localValidator) runObjectFileJobs(jobs []objectFileJob) [][]validationIssue {
+	results := make([][]validationIssue, len(jobs))
+	indexes := make(chan int)
+	workers := runtime.NumCPU()
+	if workers > len(jobs) {
+		workers = len(jobs)
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i] = v.validateObjectFile(jobs[i])
+			}
+		}()
+	}
+	for i := range jobs {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return results
+}
+
+// validateObjectFile parses an object file and validates its objects, returning the issues found.
+// It is safe to call concurrently, as it records the issues in a separate validator.
+func (v *localValidator) validateObjectFile(job objectFileJob) []validationIssue {
+	fv := &localValidator{root: v.root}
+	objFile := fv.readJSONFile(job.fileName)
+	if objFile == nil || job.schema == nil {
+		return fv.issues
+	}
+	for _, obj := range splitObjects(objFile.value) {
+		fv.validateAgainstSchema(objFile, obj.path, obj.value, job.schema)
+	}
+	return fv.issues
+}
+
+// checkExists verifies that a file or directory referenced from a JSON file exists
+func (v *localValidator) checkExists(from *jsonFile, path string, name string, isDir bool) bool {
+	info, err := os.Stat(filepath.Join(v.root, name))
+	switch {
+	case err != nil:
+		v.addIssue(from, path, severityError, fmt.Sprintf("Referenced file or directory %q does not exist", name))
+		return false
+	case isDir && !info.IsDir():
+		v.addIssue(from, path, severityError, fmt.Sprintf("Referenced path %q is not a directory", name))
+		return false
+	case !isDir && info.IsDir():
+		v.addIssue(from, path, severityError, fmt.Sprintf("Referenced path %q is a directory, expected a file", name))
+		return false
+	}
+	return true
+}
+
+// listJSONFiles returns the JSON files in a directory and its subdirectories, relative to the solution root
+func (v *localValidator) listJSONFiles(dir string) []string {
+	var files []string
+	err := filepath.WalkDir(filepath.Join(v.root, dir), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.HasSuffix(d.Name(), ".json") {
+			rel, err := filepath.Rel(v.root, path)
+			if err != nil {
+				return err
+			}
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		v.issues = append(v.issues, validationIssue{File: dir, Severity: severityError, Message: fmt.Sprintf("Failed to list directory: %v", err)})
+	}
+	sort.Strings(files)
+	return files
+}
+
+// readJSONFile reads and parses a JSON file, recording an issue and returning nil if that fails
+func (v *localValidator) readJSONFile(name string) *jsonFile {
+	data, err := os.ReadFile(filepath.Join(v.root, name))
+	if err != nil {
+		v.issues = append(v.issues, validationIssue{File: name, Severity: severityError, Message: fmt.Sprintf("Failed to read file: %v", err)})
+		return nil
+	}
+	file := &jsonFile{name: name, data: data}
+	if err := json.Unmarshal(data, &file.value); err != nil {
+		issue := validationIssue{File: name, Severity: severityError, Message: fmt.Sprintf("Invalid JSON: %v", err)}
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			issue.Line, issue.Column = lineAndColumn(data, syntaxErr.Offset)
+		}
+		v.issues = append(v.issues, issue)
+		return nil
+	}
+	file.offsets = jsonOffsets(data)
+	return file
+}
+
+// validateAgainstSchema validates a value located at path in the file against the schema
+func (v *localValidator) validateAgainstSchema(file *jsonFile, path string, value any, schema *gojsonschema.Schema) {
+	result, err := schema.Validate(gojsonschema.NewGoLoader(value))
+	if err != nil {
+		v.addIssue(file, path, severityWarning, fmt.Sprintf("Schema validation failed: %v", err))
+		return
+	}
+	for _, e := range result.Errors() {
+		field := e.Field()
+		if field == "(root)" {
+			field = ""
+		}
+		v.addIssue(file, joinPath(path, field), severityWarning, e.String())
+	}
+}
+
+// addIssue records an issue with the severity at the position of the value at path in the file
+func (v *localValidator) addIssue(file *jsonFile, path string, severity string, message string) {
+	issue := validationIssue{File: file.name, Severity: severity, Message: message}
+	if offset, found := file.offsets[path]; found {
+		issue.Line, issue.Column = lineAndColumn(file.data, offset)
+	}
+	v.issues = append(v.issues, issue)
+}
+
+// jsonObject is a value at a path within a JSON document
+type jsonObject struct {
+	path  string
+	value any
+}
+
+// splitObjects returns the objects in a file's value: the elements of an array or the value itself
+func splitObjects(value any) []jsonObject {
+	if list, ok := value.([]any); ok {
+		objects := make([]jsonObject, len(list))
+		for i, item := range list {
+			objects[i] = jsonObject{path: strconv.Itoa(i), value: item}
+		}
+		return objects
+	}
+	return []jsonObject{{path: "", value: value}}
+}
+
+func joinPath(path string, field string) string {
+	switch {
+	case path == "":
+		return field
+	case field == "":
+		return path
+	default:
+		return path + "." + field
+	}
+}
+
+// jsonOffsets maps the paths of all values in a valid JSON document to their offsets.
+// Object members are located at their key; paths use the gojsonschema field notation.
+func jsonOffsets(data []byte) map[string]int64 {
+	offsets := map[string]int64{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	var walk func(path string) error
+	walk = func(path string) error {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		delim, ok := tok.(json.Delim)
+		if !ok || (delim != '{' && delim != '[') {
+			return nil
+		}
+		for i := 0; dec.More(); i++ {
+			start := skipSeparators(data, dec.InputOffset())
+			var childPath string
+			if delim == '{' {
+				keyTok, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				childPath = joinPath(path, fmt.Sprint(keyTok))
+			} else {
+				childPath = joinPath(path, strconv.Itoa(i))
+			}
+			offsets[childPath] = start
+			if err := walk(childPath); err != nil {
+				return err
+			}
+		}
+		_, err = dec.Token() // closing delimiter
+		return err
+	}
+	offsets[""] = skipSeparators(data, 0)
+	if err := walk(""); err != nil && err != io.EOF {
+		return offsets // best effort, positions are informational only
+	}
+	return offsets
+}
+
+// skipSeparators advances the offset past whitespace and JSON separators
+func skipSeparators(data []byte, offset int64) int64 {
+	for offset < int64(len(data)) && strings.IndexByte(" \t\r\n,:", data[offset]) >= 0 {
+		offset++
+	}
+	return offset
+}
+
+// lineAndColumn converts a byte offset into 1-based line and column numbers
+func lineAndColumn(data []byte, offset int64) (int, int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	prefix := data[:offset]
+	line := bytes.Count(prefix, []byte("\n")) + 1
+	column := int(offset) - bytes.LastIndexByte(prefix, '\n')
+	return line, column
+}
@@ -0,0 +1,417 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package solution
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+
+	"github.com/cisco-open/fsoc/cmd/config"
+	"github.com/cisco-open/fsoc/cmdkit/interrupt"
+	"github.com/cisco-open/fsoc/output"
+	"github.com/cisco-open/fsoc/platform/api"
+)
+
+const (
+	vendorDirName      = "vendor"
+	vendorLockFileName = "vendor.json"
+	bundleIndexName    = "bundle.json"
+)
+
+var solutionVendorCmd = &cobra.Command{
+	Use:   "vendor",
+	Short: "Download the solution's dependencies for offline installation",
+	Long: `This command downloads the dependencies declared in the solution manifest (including their own
+dependencies) into the vendor/ directory of the solution and pins their versions in vendor/vendor.json.
+
+Once pinned, the same versions are downloaded on subsequent runs and their archives must not have changed;
+a vendored archive is replaced only after the download is verified. Use --update to re-resolve the
+dependencies to their current versions. System solutions, which are present on every tenant, are not vendored.
+
+With --bundle, a self-contained bundle archive is also created. It contains the solution and all its
+vendored dependencies, along with the order in which they need to be installed; it is intended
+for air-gapped tenants where the solution's dependencies cannot be resolved.
+
+Examples:
+  fsoc solution vendor
+  fsoc solution vendor --update
+  fsoc solution vendor --directory=mysolution --bundle`,
+	Args:             cobra.ExactArgs(0),
+	Run:              vendorSolution,
+	TraverseChildren: true,
+}
+
+// vendorLock is the content of the vendor lock file, listing the vendored dependencies in installation order
+type vendorLock struct {
+	Solution     string               `json:"solution"`
+	Dependencies []vendoredDependency `json:"dependencies"`
+}
+
+type vendoredDependency struct {
+	Name         string   `json:"name"`
+	Version      string   `json:"version"`
+	File         string   `json:"file"`
+	Sha256       string   `json:"sha256"`
+	Dependencies []string `json:"dependencies,omitempty"`
+}
+
+// bundleIndex describes the content of a self-contained solution bundle
+type bundleIndex struct {
+	Solution        string   `json:"solution"`
+	SolutionVersion string   `json:"solutionVersion"`
+	InstallOrder    []string `json:"installOrder"`
+}
+
+func getSolutionVendorCmd() *cobra.Command {
+	solutionVendorCmd.Flags().String("directory", ".", "Path to the solution root directory")
+	solutionVendorCmd.Flags().Bool("update", false, "Re-resolve dependencies to their current versions instead of the pinned ones")
+	solutionVendorCmd.Flags().Bool("bundle", false, "Create a self-contained bundle archive with the solution and its dependencies")
+
+	return solutionVendorCmd
+}
+
+func vendorSolution(cmd *cobra.Command, args []string) {
+	solutionPath, _ := cmd.Flags().GetString("directory")
+	update, _ := cmd.Flags().GetBool("update")
+	createBundle, _ := cmd.Flags().GetBool("bundle")
+
+	if !isSolutionPackageRoot(solutionPath) {
+		log.Fatalf("%q is not a solution root directory", solutionPath)
+	}
+	manifest, err := getSolutionManifest(solutionPath)
+	if err != nil {
+		log.Fatalf("Failed to read solution manifest: %v", err)
+	}
+
+	vendorPath := filepath.Join(solutionPath, vendorDirName)
+	if err := os.MkdirAll(vendorPath, 0755); err != nil {
+		log.Fatalf("Failed to create the vendor directory %q: %v", vendorPath, err)
+	}
+
+	// load pinned versions, if any
+	pinned := map[string]vendoredDependency{}
+	if !update {
+		lock, err := readVendorLock(vendorPath)
+		if err != nil {
+			log.Fatalf("Failed to read the vendor lock file: %v", err)
+		}
+		if lock != nil {
+			for _, dep := range lock.Dependencies {
+				pinned[dep.Name] = dep
+			}
+		}
+	}
+
+	systemSolutions, err := getSystemSolutions()
+	if err != nil {
+		log.Fatalf("Failed to get the list of solutions: %v", err)
+	}
+
+	v := &vendorer{
+		cmd:        cmd,
+		vendorPath: vendorPath,
+		pinned:     pinned,
+		system:     systemSolutions,
+		visited:    map[string]bool{},
+	}
+	for _, dep := range manifest.Dependencies {
+		if err := v.vendor(dep); err != nil {
+			log.Fatalf("Failed to vendor dependency %q: %v", dep, err)
+		}
+	}
+
+	lock := vendorLock{Solution: manifest.Name, Dependencies: v.installOrder}
+	if err := writeVendorLock(vendorPath, &lock); err != nil {
+		log.Fatalf("Failed to write the vendor lock file: %v", err)
+	}
+	output.PrintCmdStatus(cmd, fmt.Sprintf("Vendored %d dependencies of solution %s into %s\n", len(lock.Dependencies), manifest.Name, vendorPath))
+
+	if createBundle {
+		bundleName := fmt.Sprintf("%s-%s-bundle.zip", manifest.Name, manifest.SolutionVersion)
+		if err := writeSolutionBundle(bundleName, solutionPath, manifest, &lock); err != nil {
+			log.Fatalf("Failed to create the solution bundle: %v", err)
+		}
+		output.PrintCmdStatus(cmd, fmt.Sprintf("Self-contained bundle %s created\n", bundleName))
+	}
+}
+
+// vendorer downloads dependencies recursively, depth first, so that
+// installOrder lists each dependency after its own dependencies
+type vendorer struct {
+	cmd          *cobra.Command
+	vendorPath   string
+	pinned       map[string]vendoredDependency
+	system       map[string]bool
+	visited      map[string]bool
+	installOrder []vendoredDependency
+}
+
+func (v *vendorer) vendor(spec string) error {
+	name, constraint, err := parseDependency(spec)
+	if err != nil {
+		return fmt.Errorf("invalid version constraint: %w", err)
+	}
+	if v.visited[name] {
+		return nil
+	}
+	v.visited[name] = true
+	if v.system[name] {
+		log.WithField("solution", name).Info("Skipping system solution")
+		return nil
+	}
+
+	// download the pinned version or, if the dependency is not pinned, the version required by the
+	// manifest or the current one
+	pin, pinned := v.pinned[name]
+	version := ""
+	if pinned {
+		version = pin.Version
+	} else if constraint != nil && constraint.op == "=" {
+		version = constraint.version.String()
+	}
+
+	// download into a temporary file, so that the vendored archive is replaced only once the download
+	// is verified
+	fileName := name + ".zip"
+	filePath := filepath.Join(v.vendorPath, fileName)
+	tempFile, err := os.CreateTemp(v.vendorPath, "."+name+"-*.zip")
+	if err != nil {
+		return err
+	}
+	tempFile.Close()
+	tempPath := tempFile.Name()
+	defer interrupt.OnCleanup(func() { os.Remove(tempPath) })()
+
+	if version != "" {
+		output.PrintCmdStatus(v.cmd, fmt.Sprintf("Downloading dependency %s version %s\n", name, version))
+	} else {
+		output.PrintCmdStatus(v.cmd, fmt.Sprintf("Downloading dependency %s\n", name))
+	}
+	depManifest, err := downloadSolutionBundle(name, version, tempPath)
+	if err != nil {
+		return err
+	}
+	if constraint != nil {
+		if sv, err := parseSemver(depManifest.SolutionVersion); err != nil || !constraint.allows(sv) {
+			return fmt.Errorf("version %s does not satisfy the required version %s", depManifest.SolutionVersion, constraint)
+		}
+	}
+	checksum, err := fileChecksum(tempPath)
+	if err != nil {
+		return err
+	}
+	if pinned && pin.Sha256 != checksum {
+		return fmt.Errorf("the archive of the pinned version %s has changed (sha256 %s, expected %s); use --update to accept it", pin.Version, checksum, pin.Sha256)
+	}
+	if err := os.Rename(tempPath, filePath); err != nil {
+		return fmt.Errorf("failed to save the vendored archive: %w", err)
+	}
+
+	// vendor the dependency's own dependencies first
+	for _, dep := range depManifest.Dependencies {
+		if err := v.vendor(dep); err != nil {
+			return fmt.Errorf("dependency %q: %w", dep, err)
+		}
+	}
+
+	v.installOrder = append(v.installOrder, vendoredDependency{
+		Name:         name,
+		Version:      depManifest.SolutionVersion,
+		File:         fileName,
+		Sha256:       checksum,
+		Dependencies: depManifest.Dependencies,
+	})
+	return nil
+}
+
+// getSystemSolutions returns the set of system solutions, which are always available
+func getSystemSolutions() (map[string]bool, error) {
+	solutions, err := getTenantSolutions()
+	if err != nil {
+		return nil, err
+	}
+	system := map[string]bool{}
+	for name, solution := range solutions {
+		if solution.IsSystem {
+			system[name] = true
+		}
+	}
+	return system, nil
+}
+
+// getTenantSolutions returns the solutions available in the current tenant, by name
+func getTenantSolutions() (map[string]SolutionDef, error) {
+	headers := map[string]string{
+		"layer-type": "TENANT",
+		"layer-id":   config.GetCurrentContext().Tenant,
+	}
+	var res any
+	if err := api.JSONGetCollection(getSolutionListUrl(), &res, &api.Options{Headers: headers}); err != nil {
+		return nil, err
+	}
+
+	// re-parse the generic collection items into solution objects
+	b, err := json.Marshal(res)
+	if err != nil {
+		return nil, err
+	}
+	var collection struct {
+		Items []struct {
+			Data SolutionDef `json:"data"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(b, &collection); err != nil {
+		return nil, err
+	}
+
+	solutions := map[string]SolutionDef{}
+	for _, item := range collection.Items {
+		solutions[item.Data.Name] = item.Data
+	}
+	return solutions, nil
+}
+
+// readManifestFromArchive reads the manifest of a solution archive; the manifest is
+// expected either at the root or in the single top-level folder of the archive
+func readManifestFromArchive(archivePath string) (*Manifest, error) {
+	reader, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	for _, f := range reader.File {
+		dir, base := path.Split(f.Name)
+		if base != "manifest.json" || strings.Count(dir, "/") > 1 {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		var manifest Manifest
+		if err := json.NewDecoder(rc).Decode(&manifest); err != nil {
+			return nil, err
+		}
+		return &manifest, nil
+	}
+	return nil, fmt.Errorf("manifest.json not found in %q", archivePath)
+}
+
+func fileChecksum(filePath string) (string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func readVendorLock(vendorPath string) (*vendorLock, error) {
+	data, err := os.ReadFile(filepath.Join(vendorPath, vendorLockFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var lock vendorLock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, err
+	}
+	return &lock, nil
+}
+
+func writeVendorLock(vendorPath string, lock *vendorLock) error {
+	data, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(vendorPath, vendorLockFileName), data, 0644)
+}
+
+// writeSolutionBundle creates an archive containing the solution itself (without the vendor
+// directory), the vendored dependencies and an index with the installation order
+func writeSolutionBundle(bundleName string, solutionPath string, manifest *Manifest, lock *vendorLock) error {
+	bundle, err := os.Create(bundleName)
+	if err != nil {
+		return err
+	}
+	defer bundle.Close()
+	zipWriter := zip.NewWriter(bundle)
+
+	index := bundleIndex{Solution: manifest.Name, SolutionVersion: manifest.SolutionVersion}
+	for _, dep := range lock.Dependencies {
+		w, err := zipWriter.Create("solutions/" + dep.File)
+		if err != nil {
+			return err
+		}
+		if err := copyFileInto(w, filepath.Join(solutionPath, vendorDirName, dep.File)); err != nil {
+			return err
+		}
+		index.InstallOrder = append(index.InstallOrder, dep.Name)
+	}
+
+	// the solution's own archive
+	w, err := zipWriter.Create("solutions/" + manifest.Name + ".zip")
+	if err != nil {
+		return err
+	}
+	if err := zipSolutionDir(w, solutionPath, archiveOptions{rootName: manifest.Name, skipDirs: []string{vendorDirName}}); err != nil {
+		return err
+	}
+	index.InstallOrder = append(index.InstallOrder, manifest.Name)
+
+	w, err = zipWriter.Create(bundleIndexName)
+	if err != nil {
+		return err
+	}
+	indexData, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(indexData); err != nil {
+		return err
+	}
+
+	return zipWriter.Close()
+}
+
+func copyFileInto(w io.Writer, filePath string) error {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
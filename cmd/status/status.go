@@ -0,0 +1,192 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package status provides the command that checks the health of the platform services used by fsoc,
+// to tell platform outages apart from problems with the command, the profile or the data
+package status
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+
+	"github.com/cisco-open/fsoc/exitcode"
+	"github.com/cisco-open/fsoc/output"
+	"github.com/cisco-open/fsoc/platform/api"
+)
+
+// Service statuses
+const (
+	statusUp       = "UP"
+	statusDegraded = "DEGRADED"
+	statusDown     = "DOWN"
+	statusDenied   = "DENIED"
+)
+
+// Defaults of the probes' limits
+const (
+	defaultProbeTimeout  = 10 * time.Second
+	defaultSlowThreshold = 3 * time.Second
+)
+
+// probe checks a platform service with a lightweight, read-only request
+type probe struct {
+	service string
+	call    func(options *api.Options) error
+}
+
+// probes are the platform services checked, in display order
+var probes = []probe{
+	{"Knowledge store", func(options *api.Options) error {
+		var out any
+		return api.JSONGet("objstore/v1beta/types?max=1", &out, options)
+	}},
+	{"Query (UQL)", func(options *api.Options) error {
+		var out any
+		query := map[string]string{"query": "FETCH id FROM entities(k8s:cluster) LIMITS id.count(1)"}
+		return api.JSONPost("monitoring/v1/query/execute", query, &out, options)
+	}},
+	{"Ingestion", func(options *api.Options) error {
+		var out any
+		return api.JSONGet("data/v1/", &out, options)
+	}},
+	{"Solution management", func(options *api.Options) error {
+		var out any
+		return api.JSONGet("solnmgmt/v1beta/solutions", &out, options)
+	}},
+}
+
+// serviceStatus is the outcome of a service's probe, as displayed
+type serviceStatus struct {
+	Service string `json:"service"`
+	Status  string `json:"status"`
+	Latency string `json:"latency"`
+	Details string `json:"details"`
+}
+
+func NewSubCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Check the health of the platform services",
+		Long: `Check the platform services that fsoc uses for the current tenant and display their status at a glance,
+to tell platform outages apart from problems with the command, the profile or the data.
+
+Each service is probed with a lightweight, read-only request and reported as:
+- UP: the service responded (a response rejecting the probe itself, e.g., 404, still shows that it is up)
+- DEGRADED: the service responded slower than --slow or throttled the request
+- DOWN: the service failed (5xx), timed out or could not be reached
+- DENIED: the profile's credentials were rejected or lack the permissions for the probe
+
+The command exits with code 9 if any service is down, 6 if none is down but some denied the
+access, and 0 otherwise.`,
+		Example: `  fsoc status
+  fsoc status --timeout-per-service 5s -o json`,
+		Args: cobra.NoArgs,
+		Run:  runStatus,
+	}
+
+	cmd.Flags().Duration("timeout-per-service", defaultProbeTimeout, "maximum time to wait for each service to respond")
+	cmd.Flags().Duration("slow", defaultSlowThreshold, "response time above which a service is reported as degraded")
+
+	return cmd
+}
+
+func runStatus(cmd *cobra.Command, args []string) {
+	timeout, _ := cmd.Flags().GetDuration("timeout-per-service")
+	slow, _ := cmd.Flags().GetDuration("slow")
+
+	var results []serviceStatus
+	var lines [][]string
+	for _, p := range probes {
+		start := time.Now()
+		err := p.call(&api.Options{ReadOnly: true, Timeout: timeout})
+		latency := time.Since(start).Round(time.Millisecond)
+		status, details := classify(err, latency, slow)
+		log.WithFields(log.Fields{"service": p.service, "status": status, "latency": latency, "error": err}).Info("Probed platform service")
+
+		results = append(results, serviceStatus{Service: p.service, Status: status, Latency: latency.String(), Details: details})
+		lines = append(lines, []string{p.service, status, latency.String(), details})
+	}
+
+	output.PrintCmdOutputCustom(cmd, struct {
+		Items []serviceStatus `json:"items"`
+		Total int             `json:"total"`
+	}{Items: results, Total: len(results)}, &output.Table{
+		Headers: []string{"Service", "Status", "Latency", "Details"},
+		Lines:   lines,
+	})
+
+	if code, message := overallCode(results); code != exitcode.OK {
+		log.WithField(exitcode.Field, code).Fatal(message)
+	}
+}
+
+// classify determines a service's status from the outcome of its probe
+func classify(err error, latency time.Duration, slow time.Duration) (string, string) {
+	if err == nil {
+		if slow > 0 && latency > slow {
+			return statusDegraded, fmt.Sprintf("responding slowly (over %v)", slow)
+		}
+		return statusUp, "ok"
+	}
+
+	var problem api.Problem
+	if errors.As(err, &problem) && problem.Status == http.StatusTooManyRequests {
+		return statusDegraded, "throttling requests (429)"
+	}
+	var netErr net.Error
+	switch code := exitcode.Of(err); {
+	case code == exitcode.Timeout:
+		return statusDown, "timed out"
+	case code == exitcode.ServerError:
+		return statusDown, errorDetails(err)
+	case code == exitcode.Auth:
+		return statusDenied, "access denied; check the profile's credentials and roles"
+	case errors.As(err, &netErr):
+		return statusDown, "unreachable: " + err.Error()
+	}
+	return statusUp, "responding (the probe request was rejected: " + errorDetails(err) + ")"
+}
+
+// errorDetails returns a short description of a probe's error
+func errorDetails(err error) string {
+	var problem api.Problem
+	if !errors.As(err, &problem) {
+		return err.Error()
+	}
+	details := fmt.Sprintf("%d %s", problem.Status, problem.Title)
+	if problem.Detail != "" {
+		details += ": " + problem.Detail
+	}
+	return details
+}
+
+// overallCode returns the exit code for the services' statuses and a message explaining it
+func overallCode(results []serviceStatus) (int, string) {
+	code, message := exitcode.OK, ""
+	for _, r := range results {
+		switch {
+		case r.Status == statusDown:
+			return exitcode.ServerError, fmt.Sprintf("Platform service %s is down", r.Service)
+		case r.Status == statusDenied && code == exitcode.OK:
+			code, message = exitcode.Auth, fmt.Sprintf("Access to platform service %s was denied", r.Service)
+		}
+	}
+	return code, message
+}
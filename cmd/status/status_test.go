@@ -0,0 +1,60 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package status
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cisco-open/fsoc/exitcode"
+	"github.com/cisco-open/fsoc/platform/api"
+)
+
+func TestClassify(t *testing.T) {
+	slow := time.Second
+	tests := []struct {
+		name    string
+		err     error
+		latency time.Duration
+		status  string
+	}{
+		{"ok", nil, 100 * time.Millisecond, statusUp},
+		{"slow", nil, 2 * time.Second, statusDegraded},
+		{"throttled", api.Problem{Status: 429, Title: "Too Many Requests"}, 0, statusDegraded},
+		{"server error", &exitcode.Error{Code: exitcode.ServerError, Err: errors.New("bad gateway")}, 0, statusDown},
+		{"timeout", &exitcode.Error{Code: exitcode.Timeout, Err: errors.New("timed out")}, 0, statusDown},
+		{"denied", &exitcode.Error{Code: exitcode.Auth, Err: errors.New("forbidden")}, 0, statusDenied},
+		{"probe rejected", &exitcode.Error{Code: exitcode.NotFound, Err: errors.New("not found")}, 0, statusUp},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, _ := classify(tt.err, tt.latency, slow)
+			assert.Equal(t, tt.status, status)
+		})
+	}
+}
+
+func TestOverallCode(t *testing.T) {
+	code, _ := overallCode([]serviceStatus{{Status: statusUp}, {Status: statusDegraded}})
+	assert.Equal(t, exitcode.OK, code)
+	code, _ = overallCode([]serviceStatus{{Status: statusDenied}, {Status: statusUp}})
+	assert.Equal(t, exitcode.Auth, code)
+	code, message := overallCode([]serviceStatus{{Status: statusDenied}, {Service: "Ingestion", Status: statusDown}})
+	assert.Equal(t, exitcode.ServerError, code)
+	assert.Contains(t, message, "Ingestion")
+}
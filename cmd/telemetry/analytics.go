@@ -0,0 +1,201 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+
+	"github.com/cisco-open/fsoc/cmd/config"
+	"github.com/cisco-open/fsoc/cmd/plugin"
+	"github.com/cisco-open/fsoc/cmd/version"
+	"github.com/cisco-open/fsoc/cmdkit/offline"
+	"github.com/cisco-open/fsoc/cmdkit/picker"
+	"github.com/cisco-open/fsoc/exitcode"
+)
+
+// analyticsEndpoint is the URL receiving the anonymous usage analytics of fsoc, set at build time with
+// -ldflags "-X github.com/cisco-open/fsoc/cmd/telemetry.analyticsEndpoint=URL". Builds without one
+// neither ask for consent nor send analytics, unless the endpoint is set with FSOC_ANALYTICS_ENDPOINT.
+var analyticsEndpoint string
+
+const (
+	analyticsEndpointEnvVar = "FSOC_ANALYTICS_ENDPOINT"
+	doNotTrackEnvVar        = "DO_NOT_TRACK" // see https://consoledonottrack.com
+	analyticsTimeout        = 2 * time.Second
+)
+
+// usageEvent is everything the usage analytics send about a command. It has no room for the command's
+// arguments, flag values, data or error messages, nor for anything identifying the user, the host or
+// the tenant: only newUsageEvent creates events, from the command's definition and exit code.
+type usageEvent struct {
+	Command    string `json:"command"`    // e.g., "fsoc solution push"
+	DurationMs int64  `json:"durationMs"` // how long the command ran
+	ErrorClass string `json:"errorClass"` // the name of the exit code, e.g., "ok" or "not-found"
+	Version    string `json:"version"`    // the version of fsoc
+}
+
+// usage tracks the command whose usage is reported
+var usage struct {
+	sync.Mutex
+	endpoint string
+	cmd      *cobra.Command
+	started  time.Time
+}
+
+// usageEndpoint returns the URL receiving the usage analytics, or an empty string if this build of fsoc
+// has none or the user opted out of tracking with DO_NOT_TRACK
+func usageEndpoint() string {
+	if v := os.Getenv(doNotTrackEnvVar); v != "" && v != "0" && v != "false" {
+		return ""
+	}
+	if endpoint := os.Getenv(analyticsEndpointEnvVar); endpoint != "" {
+		return endpoint
+	}
+	return analyticsEndpoint
+}
+
+// AskConsent asks the user whether to send anonymous usage analytics, the first time fsoc runs
+// interactively; the answer is stored in the config file and can be changed with "fsoc telemetry on|off"
+func AskConsent(cmd *cobra.Command) {
+	if config.GetAnalyticsConsent() != "" || usageEndpoint() == "" || !asksConsent(cmd) || !picker.Interactive(cmd) {
+		return
+	}
+
+	out := cmd.ErrOrStderr()
+	fmt.Fprint(out, `Help improve fsoc by sending anonymous usage analytics to its maintainers: the name of each command
+(e.g., "fsoc solution push"), its duration and the class of error, if any. Arguments, flag values, data
+and anything identifying you or your tenant are never sent. You can change your mind at any time with
+"fsoc telemetry on" or "fsoc telemetry off".
+Send anonymous usage analytics? [y/N] `)
+	answer, _ := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	granted := answer == "y" || answer == "yes"
+	config.SetAnalyticsConsent(granted)
+	if granted {
+		fmt.Fprint(out, "Thank you! Usage analytics are on.\n\n")
+	} else {
+		fmt.Fprint(out, "Usage analytics are off.\n\n")
+	}
+}
+
+// asksConsent returns false for the commands that don't ask for consent to send usage analytics: the
+// telemetry commands, which decide it, and the help and completion commands
+func asksConsent(cmd *cobra.Command) bool {
+	for c := cmd; c != nil; c = c.Parent() {
+		switch c.Name() {
+		case "telemetry", "help", "completion", cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+			return false
+		}
+	}
+	return true
+}
+
+// startUsage begins timing a command for the usage analytics, if the user agreed to send them
+func startUsage(cmd *cobra.Command) {
+	endpoint := usageEndpoint()
+	if endpoint == "" || config.GetAnalyticsConsent() != config.AnalyticsGranted {
+		return
+	}
+
+	usage.Lock()
+	defer usage.Unlock()
+	usage.endpoint = endpoint
+	usage.cmd = cmd
+	usage.started = time.Now()
+}
+
+// cancelUsage stops timing the command, which is not reported, e.g., when the user turns the usage
+// analytics off
+func cancelUsage() {
+	usage.Lock()
+	defer usage.Unlock()
+	usage.cmd = nil
+}
+
+// finishUsage sends the usage analytics of the command started with startUsage
+func finishUsage(code int) {
+	usage.Lock()
+	defer usage.Unlock()
+
+	if usage.cmd == nil {
+		return
+	}
+	event := newUsageEvent(usage.cmd, time.Since(usage.started), code)
+	usage.cmd = nil
+	if offline.Enabled() {
+		return
+	}
+	if err := sendUsage(usage.endpoint, event); err != nil {
+		log.Infof("Failed to send the usage analytics: %v", err)
+	}
+}
+
+// newUsageEvent creates the usage event of a command
+func newUsageEvent(cmd *cobra.Command, duration time.Duration, code int) *usageEvent {
+	return &usageEvent{
+		Command:    commandName(cmd),
+		DurationMs: duration.Milliseconds(),
+		ErrorClass: exitcode.Name(code),
+		Version:    version.GetVersion().Version, // without the build host and git branch of dev builds
+	}
+}
+
+var commandNamePattern = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+
+// commandName returns the path of a command from the names in its definition, never from its command
+// line; plugins are reported as "plugin", since their names are chosen by the user
+func commandName(cmd *cobra.Command) string {
+	names := []string{}
+	for c := cmd; c != nil; c = c.Parent() {
+		name := c.Name()
+		if _, found := c.Annotations[plugin.AnnotationForPlugin]; found {
+			name = "plugin"
+		} else if !commandNamePattern.MatchString(name) {
+			name = "other"
+		}
+		names = append([]string{name}, names...)
+	}
+	return strings.Join(names, " ")
+}
+
+// sendUsage posts a usage event to the analytics endpoint
+func sendUsage(endpoint string, event *usageEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Timeout: analyticsTimeout}
+	resp, err := client.Post(endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("the analytics endpoint responded with %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cisco-open/fsoc/cmd/plugin"
+	"github.com/cisco-open/fsoc/exitcode"
+)
+
+func TestCommandName(t *testing.T) {
+	root := &cobra.Command{Use: "fsoc"}
+	solution := &cobra.Command{Use: "solution"}
+	push := &cobra.Command{Use: "push [NAME]"}
+	myPlugin := &cobra.Command{Use: "my-plugin", Annotations: map[string]string{plugin.AnnotationForPlugin: "/home/user/bin/my-plugin"}}
+	unexpected := &cobra.Command{Use: "Secret_Name"}
+	root.AddCommand(solution, myPlugin, unexpected)
+	solution.AddCommand(push)
+
+	assert.Equal(t, "fsoc solution push", commandName(push))
+	assert.Equal(t, "fsoc plugin", commandName(myPlugin))
+	assert.Equal(t, "fsoc other", commandName(unexpected))
+}
+
+func TestUsageEvent(t *testing.T) {
+	root := &cobra.Command{Use: "fsoc"}
+	get := &cobra.Command{Use: "get"}
+	root.AddCommand(get)
+
+	// only the command, duration, error class and version are sent
+	event := newUsageEvent(get, 1500*time.Millisecond, exitcode.NotFound)
+	data, err := json.Marshal(event)
+	require.NoError(t, err)
+	var fields map[string]any
+	require.NoError(t, json.Unmarshal(data, &fields))
+	assert.ElementsMatch(t, []string{"command", "durationMs", "errorClass", "version"}, keys(fields))
+	assert.Equal(t, "fsoc get", fields["command"])
+	assert.Equal(t, float64(1500), fields["durationMs"])
+	assert.Equal(t, "not-found", fields["errorClass"])
+}
+
+func TestSendUsage(t *testing.T) {
+	var received usageEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.NoError(t, json.Unmarshal(body, &received))
+	}))
+	defer server.Close()
+
+	event := &usageEvent{Command: "fsoc uql run", DurationMs: 20, ErrorClass: "ok", Version: "1.2.3"}
+	require.NoError(t, sendUsage(server.URL, event))
+	assert.Equal(t, *event, received)
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+	assert.Error(t, sendUsage(failing.URL, event))
+}
+
+func TestUsageEndpoint(t *testing.T) {
+	t.Setenv(analyticsEndpointEnvVar, "https://analytics.example.com/fsoc")
+	t.Setenv(doNotTrackEnvVar, "")
+	assert.Equal(t, "https://analytics.example.com/fsoc", usageEndpoint())
+
+	t.Setenv(doNotTrackEnvVar, "0")
+	assert.Equal(t, "https://analytics.example.com/fsoc", usageEndpoint())
+
+	t.Setenv(doNotTrackEnvVar, "1")
+	assert.Equal(t, "", usageEndpoint())
+}
+
+func TestAsksConsent(t *testing.T) {
+	root := &cobra.Command{Use: "fsoc"}
+	telemetry := &cobra.Command{Use: "telemetry"}
+	off := &cobra.Command{Use: "off"}
+	list := &cobra.Command{Use: "list"}
+	root.AddCommand(telemetry, list)
+	telemetry.AddCommand(off)
+
+	assert.True(t, asksConsent(list))
+	assert.False(t, asksConsent(off))
+}
+
+func keys(m map[string]any) []string {
+	result := []string{}
+	for k := range m {
+		result = append(result, k)
+	}
+	return result
+}
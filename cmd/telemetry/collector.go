@@ -0,0 +1,147 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+
+	"github.com/cisco-open/fsoc/cmd/config"
+	"github.com/cisco-open/fsoc/exitcode"
+	"github.com/cisco-open/fsoc/platform/api"
+)
+
+// apiCall is a platform API call made while running the command
+type apiCall struct {
+	method     string
+	path       string
+	started    time.Time
+	ended      time.Time
+	statusCode int
+	err        string
+}
+
+// current collects the telemetry of the command being executed
+var current struct {
+	sync.Mutex
+	settings config.TelemetrySettings
+	command  string
+	started  time.Time
+	calls    []apiCall
+	active   bool
+}
+
+// Start begins collecting the telemetry of a command, if the self-instrumentation or the usage analytics
+// are enabled; it should be called before the command runs
+func Start(cmd *cobra.Command) {
+	startUsage(cmd)
+
+	settings := config.GetTelemetrySettings()
+	if settings.Endpoint == "" {
+		return
+	}
+
+	current.Lock()
+	defer current.Unlock()
+	current.settings = settings
+	current.command = cmd.CommandPath()
+	current.started = time.Now()
+	current.calls = nil
+	current.active = true
+	api.SetCallObserver(observeCall)
+}
+
+// Finish sends the telemetry of the command started with Start, reporting the error, if any
+func Finish(err error) {
+	msg := ""
+	if err != nil {
+		msg = err.Error()
+	}
+	finish(msg, exitcode.Of(err))
+}
+
+// Handler returns a log handler that sends the telemetry when a command fails with a fatal error
+// (which exits without returning through Finish)
+func Handler() log.Handler {
+	return log.HandlerFunc(func(e *log.Entry) error {
+		if e.Level == log.FatalLevel {
+			finish(e.Message, exitcode.ForEntry(e))
+		}
+		return nil
+	})
+}
+
+func observeCall(method string, path string, started time.Time, statusCode int, err error) {
+	call := apiCall{method: method, path: urlPath(path), started: started, ended: time.Now(), statusCode: statusCode}
+	if err != nil {
+		call.err = err.Error()
+	}
+
+	current.Lock()
+	defer current.Unlock()
+	if current.active {
+		current.calls = append(current.calls, call)
+	}
+}
+
+func finish(errorMessage string, code int) {
+	finishUsage(code)
+
+	current.Lock()
+	defer current.Unlock()
+
+	if !current.active {
+		return
+	}
+	current.active = false
+	api.SetCallObserver(nil)
+
+	run := &commandRun{
+		command: current.command,
+		started: current.started,
+		ended:   time.Now(),
+		calls:   current.calls,
+		err:     errorMessage,
+		code:    code,
+	}
+	if err := newSender(current.settings).send(run); err != nil {
+		log.Infof("Failed to send the fsoc telemetry: %v", err)
+	}
+}
+
+// urlPath returns the path of an API call without the query, which may contain data
+func urlPath(path string) string {
+	if u, err := url.Parse(path); err == nil {
+		return u.Path
+	}
+	path, _, _ = strings.Cut(path, "?")
+	return path
+}
+
+// randomID returns a random trace or span ID of the given size
+func randomID(size int) []byte {
+	id := make([]byte, size)
+	if _, err := rand.Read(id); err != nil {
+		panic(fmt.Sprintf("failed to generate a random ID: %v", err)) // crypto/rand doesn't fail in practice
+	}
+	return id
+}
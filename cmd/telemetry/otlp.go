@@ -0,0 +1,248 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"strings"
+	"time"
+
+	collmetrics "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	collspans "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	common "go.opentelemetry.io/proto/otlp/common/v1"
+	metrics "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resource "go.opentelemetry.io/proto/otlp/resource/v1"
+	spans "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/cisco-open/fsoc/cmd/config"
+	"github.com/cisco-open/fsoc/cmd/version"
+	"github.com/cisco-open/fsoc/cmdkit/offline"
+)
+
+// sendTimeout limits the time spent sending the telemetry when a command completes
+const sendTimeout = 3 * time.Second
+
+const scopeName = "github.com/cisco-open/fsoc"
+
+// commandRun is the telemetry of a completed command
+type commandRun struct {
+	command string
+	started time.Time
+	ended   time.Time
+	calls   []apiCall
+	err     string // empty if the command succeeded
+	code    int    // exit code, if the command failed
+}
+
+// exitCode returns the exit code of the command, as reported in the telemetry
+func (r *commandRun) exitCode() int64 {
+	if r.err == "" {
+		return 0
+	}
+	if r.code == 0 {
+		return 1
+	}
+	return int64(r.code)
+}
+
+// sender sends telemetry to an OTLP/HTTP endpoint, in the protobuf encoding
+type sender struct {
+	endpoint string
+	headers  map[string]string
+	client   *http.Client
+}
+
+func newSender(settings config.TelemetrySettings) *sender {
+	return &sender{
+		endpoint: strings.TrimSuffix(settings.Endpoint, "/"),
+		headers:  settings.Headers,
+		client:   &http.Client{Timeout: sendTimeout},
+	}
+}
+
+// send sends the traces and metrics of a command run
+func (s *sender) send(run *commandRun) error {
+	if err := offline.Check("Sending telemetry"); err != nil {
+		return err
+	}
+	res := telemetryResource()
+	if err := s.post("/v1/traces", buildTraces(run, res)); err != nil {
+		return err
+	}
+	return s.post("/v1/metrics", buildMetrics(run, res))
+}
+
+func (s *sender) post(path string, m proto.Message) error {
+	body, err := proto.Marshal(m)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, s.endpoint+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	for name, value := range s.headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("POST %s failed, status %q", req.URL, resp.Status)
+	}
+	return nil
+}
+
+// telemetryResource describes fsoc as the source of the telemetry
+func telemetryResource() *resource.Resource {
+	return &resource.Resource{Attributes: []*common.KeyValue{
+		stringAttribute("service.name", "fsoc"),
+		stringAttribute("service.version", version.GetVersionShort()),
+		stringAttribute("os.type", runtime.GOOS),
+		stringAttribute("host.arch", runtime.GOARCH),
+	}}
+}
+
+// buildTraces returns a trace of the command run: a span for the command, with a child span for each API call
+func buildTraces(run *commandRun, res *resource.Resource) *collspans.ExportTraceServiceRequest {
+	traceID := randomID(16)
+	root := &spans.Span{
+		TraceId:           traceID,
+		SpanId:            randomID(8),
+		Name:              run.command,
+		Kind:              spans.Span_SPAN_KIND_INTERNAL,
+		StartTimeUnixNano: uint64(run.started.UnixNano()),
+		EndTimeUnixNano:   uint64(run.ended.UnixNano()),
+		Attributes: []*common.KeyValue{
+			stringAttribute("fsoc.command", run.command),
+			intAttribute("fsoc.exit_code", run.exitCode()),
+			intAttribute("fsoc.api_calls", int64(len(run.calls))),
+		},
+		Status: spanStatus(run.err),
+	}
+	list := []*spans.Span{root}
+	for _, call := range run.calls {
+		span := &spans.Span{
+			TraceId:           traceID,
+			SpanId:            randomID(8),
+			ParentSpanId:      root.SpanId,
+			Name:              call.method + " " + call.path,
+			Kind:              spans.Span_SPAN_KIND_CLIENT,
+			StartTimeUnixNano: uint64(call.started.UnixNano()),
+			EndTimeUnixNano:   uint64(call.ended.UnixNano()),
+			Attributes: []*common.KeyValue{
+				stringAttribute("http.method", call.method),
+				stringAttribute("url.path", call.path),
+			},
+			Status: spanStatus(call.err),
+		}
+		if call.statusCode != 0 {
+			span.Attributes = append(span.Attributes, intAttribute("http.status_code", int64(call.statusCode)))
+		}
+		list = append(list, span)
+	}
+	return &collspans.ExportTraceServiceRequest{ResourceSpans: []*spans.ResourceSpans{{
+		Resource:   res,
+		ScopeSpans: []*spans.ScopeSpans{{Scope: &common.InstrumentationScope{Name: scopeName}, Spans: list}},
+	}}}
+}
+
+// buildMetrics returns the metrics of the command run: its duration and the number of API calls, by
+// method and response status
+func buildMetrics(run *commandRun, res *resource.Resource) *collmetrics.ExportMetricsServiceRequest {
+	end := uint64(run.ended.UnixNano())
+	start := uint64(run.started.UnixNano())
+	duration := &metrics.Metric{
+		Name:        "fsoc.command.duration",
+		Description: "Duration of the fsoc command",
+		Unit:        "s",
+		Data: &metrics.Metric_Gauge{Gauge: &metrics.Gauge{DataPoints: []*metrics.NumberDataPoint{{
+			TimeUnixNano: end,
+			Value:        &metrics.NumberDataPoint_AsDouble{AsDouble: run.ended.Sub(run.started).Seconds()},
+			Attributes: []*common.KeyValue{
+				stringAttribute("fsoc.command", run.command),
+				intAttribute("fsoc.exit_code", run.exitCode()),
+			},
+		}}}},
+	}
+
+	type callKey struct {
+		method     string
+		statusCode int
+	}
+	counts := map[callKey]int64{}
+	var keys []callKey
+	for _, call := range run.calls {
+		key := callKey{call.method, call.statusCode}
+		if counts[key] == 0 {
+			keys = append(keys, key)
+		}
+		counts[key]++
+	}
+	var points []*metrics.NumberDataPoint
+	for _, key := range keys {
+		points = append(points, &metrics.NumberDataPoint{
+			StartTimeUnixNano: start,
+			TimeUnixNano:      end,
+			Value:             &metrics.NumberDataPoint_AsInt{AsInt: counts[key]},
+			Attributes: []*common.KeyValue{
+				stringAttribute("fsoc.command", run.command),
+				stringAttribute("http.method", key.method),
+				intAttribute("http.status_code", int64(key.statusCode)),
+			},
+		})
+	}
+	list := []*metrics.Metric{duration}
+	if len(points) > 0 {
+		list = append(list, &metrics.Metric{
+			Name:        "fsoc.api.calls",
+			Description: "Number of platform API calls made by the fsoc command",
+			Unit:        "{call}",
+			Data: &metrics.Metric_Sum{Sum: &metrics.Sum{
+				AggregationTemporality: metrics.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA,
+				IsMonotonic:            true,
+				DataPoints:             points,
+			}},
+		})
+	}
+	return &collmetrics.ExportMetricsServiceRequest{ResourceMetrics: []*metrics.ResourceMetrics{{
+		Resource:     res,
+		ScopeMetrics: []*metrics.ScopeMetrics{{Scope: &common.InstrumentationScope{Name: scopeName}, Metrics: list}},
+	}}}
+}
+
+func spanStatus(errorMessage string) *spans.Status {
+	if errorMessage == "" {
+		return &spans.Status{Code: spans.Status_STATUS_CODE_OK}
+	}
+	return &spans.Status{Code: spans.Status_STATUS_CODE_ERROR, Message: errorMessage}
+}
+
+func stringAttribute(key string, value string) *common.KeyValue {
+	return &common.KeyValue{Key: key, Value: &common.AnyValue{Value: &common.AnyValue_StringValue{StringValue: value}}}
+}
+
+func intAttribute(key string, value int64) *common.KeyValue {
+	return &common.KeyValue{Key: key, Value: &common.AnyValue{Value: &common.AnyValue_IntValue{IntValue: value}}}
+}
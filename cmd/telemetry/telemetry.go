@@ -0,0 +1,189 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package telemetry provides the opt-in self-instrumentation of fsoc, which sends fsoc's own traces and
+// metrics to an OTLP endpoint, the opt-in anonymous usage analytics sent to the maintainers of fsoc, and
+// the commands that turn them on or off
+package telemetry
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+
+	"github.com/cisco-open/fsoc/cmd/config"
+	"github.com/cisco-open/fsoc/output"
+)
+
+func NewSubCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "telemetry",
+		Short: "Turn the usage analytics or the self-instrumentation of fsoc on or off",
+		Long: `With the on, off and status subcommands, this command manages the anonymous usage analytics that fsoc
+sends to its maintainers, to help them prioritize improvements, if you agree to it. fsoc asks for your
+consent the first time it runs interactively. Each command then sends only its name (e.g., "fsoc solution
+push"), its duration, the class of its error, if any (e.g., "not-found") and the version of fsoc; never
+its arguments, flag values or data, nor anything identifying you, your host or your tenant. Setting the
+DO_NOT_TRACK environment variable turns the usage analytics off, regardless of the consent.
+
+With flags, this command manages the self-instrumentation. fsoc can send its own telemetry over OTLP/HTTP to an endpoint of your choice, e.g., an OpenTelemetry
+collector, so that platform teams can analyze how fsoc is used and diagnose slow commands. The
+self-instrumentation is off unless enabled with this command; nothing is sent anywhere otherwise.
+
+When enabled, each command sends:
+- a trace, with a span for the command and a child span for each platform API call (method, path
+  without query, response status and error)
+- the fsoc.command.duration and fsoc.api.calls metrics
+The telemetry contains the command path and exit code, but not the arguments, flag values or data.
+
+The endpoint is the base URL of an OTLP/HTTP receiver; the telemetry is sent to its /v1/traces and
+/v1/metrics paths. Use --header to add headers to the requests, e.g., for authentication. Without flags,
+this command displays whether the self-instrumentation is on.`,
+		Example: `  fsoc telemetry --enable --endpoint http://localhost:4318
+  fsoc telemetry --enable --endpoint https://otel.example.com --header "Authorization=Bearer TOKEN"
+  fsoc telemetry --disable
+  fsoc telemetry
+  fsoc telemetry on
+  fsoc telemetry off
+  fsoc telemetry status`,
+		Args:        cobra.NoArgs,
+		Annotations: map[string]string{config.AnnotationForConfigBypass: ""},
+		Run:         telemetryCommand,
+	}
+	cmd.Flags().Bool("enable", false, "Turn the self-instrumentation on")
+	cmd.Flags().Bool("disable", false, "Turn the self-instrumentation off")
+	cmd.Flags().String("endpoint", "", "Base URL of the OTLP/HTTP endpoint, e.g., http://localhost:4318 (required with --enable)")
+	cmd.Flags().StringArray("header", nil, "Header to send with the telemetry, as name=value (can be repeated)")
+	cmd.MarkFlagsMutuallyExclusive("enable", "disable")
+
+	cmd.AddCommand(newCmdAnalytics("on", "Send anonymous usage analytics to the maintainers of fsoc", true))
+	cmd.AddCommand(newCmdAnalytics("off", "Stop sending anonymous usage analytics", false))
+	cmd.AddCommand(newCmdStatus())
+
+	return cmd
+}
+
+func telemetryCommand(cmd *cobra.Command, args []string) {
+	enable, _ := cmd.Flags().GetBool("enable")
+	disable, _ := cmd.Flags().GetBool("disable")
+	endpoint, _ := cmd.Flags().GetString("endpoint")
+	headers, _ := cmd.Flags().GetStringArray("header")
+	if !enable && (endpoint != "" || len(headers) > 0) {
+		_ = cmd.Usage()
+		log.Fatalf("--endpoint and --header can be used only with --enable")
+	}
+
+	switch {
+	case enable:
+		settings, err := parseSettings(endpoint, headers)
+		if err != nil {
+			_ = cmd.Usage()
+			log.Fatalf("%v", err)
+		}
+		config.SetTelemetrySettings(settings)
+	case disable:
+		config.SetTelemetrySettings(nil)
+	}
+
+	if settings := config.GetTelemetrySettings(); settings.Endpoint != "" {
+		output.PrintCmdStatus(cmd, fmt.Sprintf("Self-instrumentation is on, sending telemetry to %s.\n", settings.Endpoint))
+	} else {
+		output.PrintCmdStatus(cmd, "Self-instrumentation is off.\n")
+	}
+}
+
+func newCmdAnalytics(use string, short string, granted bool) *cobra.Command {
+	return &cobra.Command{
+		Use:         use,
+		Short:       short,
+		Long:        short + ". See \"fsoc help telemetry\" for what the usage analytics contain.",
+		Args:        cobra.NoArgs,
+		Annotations: map[string]string{config.AnnotationForConfigBypass: ""},
+		Run: func(cmd *cobra.Command, args []string) {
+			config.SetAnalyticsConsent(granted)
+			if !granted {
+				cancelUsage()
+			}
+			if granted && analyticsEndpoint == "" && os.Getenv(analyticsEndpointEnvVar) == "" {
+				log.Warnf("This build of fsoc has no usage analytics endpoint; nothing will be sent")
+			}
+			output.PrintCmdStatus(cmd, analyticsStatus(granted))
+		},
+	}
+}
+
+func newCmdStatus() *cobra.Command {
+	return &cobra.Command{
+		Use:         "status",
+		Short:       "Display whether the usage analytics and the self-instrumentation are on",
+		Args:        cobra.NoArgs,
+		Annotations: map[string]string{config.AnnotationForConfigBypass: ""},
+		Run: func(cmd *cobra.Command, args []string) {
+			status := ""
+			switch config.GetAnalyticsConsent() {
+			case config.AnalyticsGranted:
+				status = analyticsStatus(true)
+			case config.AnalyticsDenied:
+				status = analyticsStatus(false)
+			default:
+				status = "Usage analytics are off; fsoc will ask whether to turn them on.\n"
+			}
+			if settings := config.GetTelemetrySettings(); settings.Endpoint != "" {
+				status += fmt.Sprintf("Self-instrumentation is on, sending telemetry to %s.\n", settings.Endpoint)
+			} else {
+				status += "Self-instrumentation is off.\n"
+			}
+			output.PrintCmdStatus(cmd, status)
+		},
+	}
+}
+
+// analyticsStatus describes whether usage analytics are sent, given the user's consent
+func analyticsStatus(granted bool) string {
+	switch {
+	case !granted:
+		return "Usage analytics are off.\n"
+	case usageEndpoint() == "":
+		return "Usage analytics are on, but not sent (DO_NOT_TRACK is set, or this build has no analytics endpoint).\n"
+	}
+	return "Usage analytics are on.\n"
+}
+
+// parseSettings validates the endpoint and the headers of the self-instrumentation
+func parseSettings(endpoint string, headers []string) (*config.TelemetrySettings, error) {
+	if endpoint == "" {
+		return nil, fmt.Errorf("the OTLP endpoint must be specified with --endpoint")
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid endpoint %q: expected an http or https URL, e.g., http://localhost:4318", endpoint)
+	}
+	settings := &config.TelemetrySettings{Endpoint: strings.TrimSuffix(endpoint, "/")}
+	for _, header := range headers {
+		name, value, found := strings.Cut(header, "=")
+		name = strings.TrimSpace(name)
+		if !found || name == "" {
+			return nil, fmt.Errorf("invalid header %q: expected name=value", header)
+		}
+		if settings.Headers == nil {
+			settings.Headers = map[string]string{}
+		}
+		settings.Headers[name] = value
+	}
+	return settings, nil
+}
@@ -0,0 +1,119 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	collmetrics "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	collspans "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	spans "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/cisco-open/fsoc/cmd/config"
+)
+
+func TestParseSettings(t *testing.T) {
+	settings, err := parseSettings("https://otel.example.com/", []string{"Authorization=Bearer a=b", "X-Team = cli"})
+	require.NoError(t, err)
+	assert.Equal(t, &config.TelemetrySettings{
+		Endpoint: "https://otel.example.com",
+		Headers:  map[string]string{"Authorization": "Bearer a=b", "X-Team": " cli"},
+	}, settings)
+
+	for _, tc := range []struct {
+		endpoint string
+		headers  []string
+	}{
+		{"", nil},
+		{"localhost:4318", nil},
+		{"ftp://localhost", nil},
+		{"http://localhost:4318", []string{"Authorization"}},
+		{"http://localhost:4318", []string{"=value"}},
+	} {
+		_, err := parseSettings(tc.endpoint, tc.headers)
+		assert.Error(t, err, tc)
+	}
+}
+
+func TestSend(t *testing.T) {
+	var traces collspans.ExportTraceServiceRequest
+	var metricsReq collmetrics.ExportMetricsServiceRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/x-protobuf", r.Header.Get("Content-Type"))
+		assert.Equal(t, "secret", r.Header.Get("X-Api-Key"))
+		body, _ := io.ReadAll(r.Body)
+		switch r.URL.Path {
+		case "/v1/traces":
+			require.NoError(t, proto.Unmarshal(body, &traces))
+		case "/v1/metrics":
+			require.NoError(t, proto.Unmarshal(body, &metricsReq))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	started := time.Now().Add(-time.Second)
+	run := &commandRun{
+		command: "fsoc solution push",
+		started: started,
+		ended:   time.Now(),
+		calls: []apiCall{
+			{method: "POST", path: "/solnmgmt/v1beta/solutions", started: started, ended: started.Add(time.Millisecond), statusCode: 200},
+			{method: "GET", path: "/solnmgmt/v1beta/solutions/x", started: started, ended: started.Add(time.Millisecond), statusCode: 404, err: "not found"},
+		},
+		err: "deployment failed",
+	}
+	s := newSender(config.TelemetrySettings{Endpoint: server.URL + "/", Headers: map[string]string{"x-api-key": "secret"}})
+	require.NoError(t, s.send(run))
+
+	require.Len(t, traces.ResourceSpans, 1)
+	list := traces.ResourceSpans[0].ScopeSpans[0].Spans
+	require.Len(t, list, 3)
+	assert.Equal(t, "fsoc solution push", list[0].Name)
+	assert.Equal(t, spans.Status_STATUS_CODE_ERROR, list[0].Status.Code)
+	assert.Equal(t, "GET /solnmgmt/v1beta/solutions/x", list[2].Name)
+	assert.Equal(t, list[0].SpanId, list[2].ParentSpanId)
+	assert.Equal(t, list[0].TraceId, list[2].TraceId)
+	assert.Equal(t, spans.Span_SPAN_KIND_CLIENT, list[2].Kind)
+
+	metricList := metricsReq.ResourceMetrics[0].ScopeMetrics[0].Metrics
+	require.Len(t, metricList, 2)
+	assert.Equal(t, "fsoc.command.duration", metricList[0].Name)
+	assert.InDelta(t, 1.0, metricList[0].GetGauge().DataPoints[0].GetAsDouble(), 0.5)
+	assert.Equal(t, "fsoc.api.calls", metricList[1].Name)
+	assert.Len(t, metricList[1].GetSum().DataPoints, 2)
+
+	s.endpoint = server.URL + "/other"
+	assert.Error(t, s.send(&commandRun{command: "fsoc version"}))
+}
+
+func TestObserveCall(t *testing.T) {
+	current.active = true
+	defer func() { current.active, current.calls = false, nil }()
+
+	observeCall("GET", "/objstore/v1beta/objects/t?filter=secret", time.Now(), 0, errors.New("connection refused"))
+	require.Len(t, current.calls, 1)
+	assert.Equal(t, "/objstore/v1beta/objects/t", current.calls[0].path)
+	assert.Equal(t, "connection refused", current.calls[0].err)
+}
@@ -0,0 +1,115 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tips
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// slowCommandDuration is the duration above which a command is considered slow
+const slowCommandDuration = 10 * time.Second
+
+// Event describes the outcome of a command, to which tips are matched
+type Event struct {
+	Command  string          // command path without the "fsoc" prefix, e.g., "uql query"
+	Flags    map[string]bool // names of the flags specified on the command line
+	Error    string          // error message if the command failed, empty otherwise
+	Duration time.Duration   // how long the command ran
+}
+
+// Rule is a tip with the condition under which it is shown
+type Rule struct {
+	ID    string
+	Tip   string
+	Match func(e *Event) bool
+}
+
+var (
+	authErrorRegexp    = regexp.MustCompile(`(?i)\b(401|403)\b|unauthorized|forbidden|token`)
+	networkErrorRegexp = regexp.MustCompile(`(?i)no such host|connection refused|x509|certificate|i/o timeout`)
+)
+
+// rules are evaluated in order; the first matching rule whose tip has not been shown yet wins
+var rules = []Rule{
+	{
+		ID:  "config-set",
+		Tip: `Create an access profile with "fsoc config set --auth=oauth --url=https://MYTENANT.observe.appdynamics.com".`,
+		Match: func(e *Event) bool {
+			return strings.Contains(e.Error, "not configured") || strings.Contains(e.Error, "missing profile")
+		},
+	},
+	{
+		ID:  "login",
+		Tip: `Your credentials may have expired; run "fsoc login" to log in again and "fsoc config get" to check the profile.`,
+		Match: func(e *Event) bool {
+			return authErrorRegexp.MatchString(e.Error)
+		},
+	},
+	{
+		ID:  "check-url",
+		Tip: `Check the URL of the access profile with "fsoc config get"; add -v to see the details of the API calls.`,
+		Match: func(e *Event) bool {
+			return networkErrorRegexp.MatchString(e.Error)
+		},
+	},
+	{
+		ID:  "validate-local",
+		Tip: `Check a solution for errors without uploading it with "fsoc solution validate --local".`,
+		Match: func(e *Event) bool {
+			return e.Error != "" && (e.Command == "solution push" || e.Command == "solution validate")
+		},
+	},
+	{
+		ID:  "uql-limits",
+		Tip: `Limit how long large queries run with --max-time or --max-pages.`,
+		Match: func(e *Event) bool {
+			return e.Command == "uql query" && e.Duration > slowCommandDuration && !e.Flags["max-time"] && !e.Flags["max-pages"]
+		},
+	},
+	{
+		ID:  "fields",
+		Tip: `Use --fields to trim the output to the data you need (e.g., --fields "id, name"), or --columns to choose table columns.`,
+		Match: func(e *Event) bool {
+			listing := strings.HasSuffix(e.Command, " list") || strings.HasSuffix(e.Command, " get")
+			return e.Error == "" && listing && e.Duration > slowCommandDuration && !e.Flags["fields"] && !e.Flags["fields-file"] && !e.Flags["columns"]
+		},
+	},
+	{
+		ID:  "uql-save",
+		Tip: `Save a query for reuse with "fsoc uql save NAME QUERY" and run it later with "fsoc uql run NAME".`,
+		Match: func(e *Event) bool {
+			return e.Error == "" && e.Command == "uql query"
+		},
+	},
+	{
+		ID:  "verbose",
+		Tip: `Re-run the command with -v to see more details; the full log is written to the file set with --log.`,
+		Match: func(e *Event) bool {
+			return e.Error != "" && !e.Flags["verbose"]
+		},
+	},
+}
+
+// findTip returns the first rule matching the event whose tip has not been shown yet, or nil if none
+func findTip(e *Event, shown map[string]bool) *Rule {
+	for i := range rules {
+		if !shown[rules[i].ID] && rules[i].Match(e) {
+			return &rules[i]
+		}
+	}
+	return nil
+}
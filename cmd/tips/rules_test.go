@@ -0,0 +1,46 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tips
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindTip(t *testing.T) {
+	tip := func(e *Event, shown map[string]bool) string {
+		if rule := findTip(e, shown); rule != nil {
+			return rule.ID
+		}
+		return ""
+	}
+
+	assert.Equal(t, "config-set", tip(&Event{Command: "solution list", Error: `fsoc is not configured, please use "fsoc config set"`}, nil))
+	assert.Equal(t, "login", tip(&Event{Command: "solution list", Error: "Platform API call failed: 401 Unauthorized"}, nil))
+	assert.Equal(t, "verbose", tip(&Event{Command: "solution list", Error: "Platform API call failed: 401 Unauthorized"}, map[string]bool{"login": true}))
+	assert.Equal(t, "validate-local", tip(&Event{Command: "solution push", Error: "Solution command failed"}, nil))
+
+	slowQuery := &Event{Command: "uql query", Flags: map[string]bool{}, Duration: time.Minute}
+	assert.Equal(t, "uql-limits", tip(slowQuery, nil))
+	assert.Equal(t, "uql-save", tip(slowQuery, map[string]bool{"uql-limits": true}))
+	slowQuery.Flags["max-time"] = true
+	assert.Equal(t, "uql-save", tip(slowQuery, nil))
+
+	assert.Equal(t, "fields", tip(&Event{Command: "solution list", Flags: map[string]bool{}, Duration: time.Minute}, nil))
+	assert.Equal(t, "", tip(&Event{Command: "solution list", Flags: map[string]bool{"fields": true}, Duration: time.Minute}, nil))
+	assert.Equal(t, "", tip(&Event{Command: "solution list", Flags: map[string]bool{}, Duration: time.Second}, nil))
+}
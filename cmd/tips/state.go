@@ -0,0 +1,76 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tips
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/apex/log"
+	"gopkg.in/yaml.v3"
+
+	"github.com/cisco-open/fsoc/cmd/config"
+)
+
+const stateFileName = "tips.yaml"
+
+// state records the tips that have already been shown, so that each tip is shown only once
+type state struct {
+	Shown []string `yaml:"shown"`
+}
+
+func stateFilePath() string {
+	return filepath.Join(config.GetConfigDir(), stateFileName)
+}
+
+// loadShown returns the set of tips that have already been shown
+func loadShown() map[string]bool {
+	shown := map[string]bool{}
+	data, err := os.ReadFile(stateFilePath())
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Infof("Failed to read the tips state: %v", err)
+		}
+		return shown
+	}
+	var s state
+	if err := yaml.Unmarshal(data, &s); err != nil {
+		log.Infof("Failed to parse the tips state: %v", err)
+		return shown
+	}
+	for _, id := range s.Shown {
+		shown[id] = true
+	}
+	return shown
+}
+
+// saveShown records the set of tips that have already been shown
+func saveShown(shown map[string]bool) error {
+	s := state{Shown: []string{}}
+	for _, rule := range rules { // keep the order stable
+		if shown[rule.ID] {
+			s.Shown = append(s.Shown, rule.ID)
+		}
+	}
+	data, err := yaml.Marshal(&s)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(config.GetConfigDir(), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(stateFilePath(), data, 0600)
+}
@@ -0,0 +1,94 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tips
+
+import (
+	"fmt"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+
+	"github.com/cisco-open/fsoc/cmd/config"
+	"github.com/cisco-open/fsoc/output"
+)
+
+type tipStatus struct {
+	ID    string `json:"id" yaml:"id"`
+	Tip   string `json:"tip" yaml:"tip"`
+	Shown bool   `json:"shown" yaml:"shown"`
+}
+
+func NewSubCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tips",
+		Short: "List contextual tips and turn them on or off",
+		Long: `fsoc shows a short, actionable tip after a command fails or runs slowly, for example,
+suggesting how to trim the output or how to reuse a query. Each tip is shown at most once and only
+when the output is displayed on a terminal. Tips are selected locally; nothing is sent anywhere.
+
+Without flags, this command lists all tips and whether they have been shown already. Tips can
+also be suppressed for a single invocation by setting the FSOC_NO_TIPS environment variable.`,
+		Example: `  fsoc tips
+  fsoc tips --disable
+  fsoc tips --enable --reset`,
+		Args:        cobra.NoArgs,
+		Annotations: map[string]string{config.AnnotationForConfigBypass: ""},
+		Run:         tipsCommand,
+	}
+	cmd.Flags().Bool("enable", false, "Turn tips on")
+	cmd.Flags().Bool("disable", false, "Turn tips off")
+	cmd.Flags().Bool("reset", false, "Forget which tips have been shown, so that they can be shown again")
+	cmd.MarkFlagsMutuallyExclusive("enable", "disable")
+
+	return cmd
+}
+
+func tipsCommand(cmd *cobra.Command, args []string) {
+	enable, _ := cmd.Flags().GetBool("enable")
+	disable, _ := cmd.Flags().GetBool("disable")
+	reset, _ := cmd.Flags().GetBool("reset")
+
+	if enable || disable {
+		config.SetTipsEnabled(enable)
+	}
+	if reset {
+		if err := saveShown(map[string]bool{}); err != nil {
+			log.Fatalf("Failed to reset the tips state: %v", err)
+		}
+	}
+	if enable || disable || reset {
+		state := map[bool]string{true: "on", false: "off"}[config.TipsEnabled()]
+		output.PrintCmdStatus(cmd, fmt.Sprintf("Tips are %s.\n", state))
+		return
+	}
+
+	shown := loadShown()
+	items := make([]tipStatus, len(rules))
+	lines := make([][]string, len(rules))
+	for i, rule := range rules {
+		items[i] = tipStatus{ID: rule.ID, Tip: rule.Tip, Shown: shown[rule.ID]}
+		lines[i] = []string{rule.ID, fmt.Sprint(items[i].Shown), rule.Tip}
+	}
+	if !config.TipsEnabled() {
+		log.Warn(`Tips are turned off; use "fsoc tips --enable" to turn them on`)
+	}
+	output.PrintCmdOutputCustom(cmd, struct {
+		Items []tipStatus `json:"items"`
+		Total int         `json:"total"`
+	}{Items: items, Total: len(items)}, &output.Table{
+		Headers: []string{"ID", "Shown", "Tip"},
+		Lines:   lines,
+	})
+}
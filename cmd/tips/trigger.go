@@ -0,0 +1,110 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tips
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/cisco-open/fsoc/cmd/config"
+)
+
+// current tracks the command being executed, for matching tips once it completes
+var current struct {
+	sync.Mutex
+	event   *Event
+	started time.Time
+	done    bool
+}
+
+// Start begins tracking the outcome of a command; it should be called before the command runs
+func Start(cmd *cobra.Command) {
+	current.Lock()
+	defer current.Unlock()
+
+	e := &Event{
+		Command: strings.TrimPrefix(strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()), " "),
+		Flags:   map[string]bool{},
+	}
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		e.Flags[f.Name] = true
+	})
+	current.event = e
+	current.started = time.Now()
+	current.done = false
+}
+
+// Finish completes tracking the command started with Start and shows a tip, if one applies
+func Finish(err error) {
+	msg := ""
+	if err != nil {
+		msg = err.Error()
+	}
+	finish(msg)
+}
+
+func finish(errorMessage string) {
+	current.Lock()
+	defer current.Unlock()
+
+	if current.event == nil || current.done {
+		return
+	}
+	current.done = true
+	current.event.Error = errorMessage
+	current.event.Duration = time.Since(current.started)
+
+	if !enabled() || current.event.Command == "tips" {
+		return
+	}
+	shown := loadShown()
+	rule := findTip(current.event, shown)
+	if rule == nil {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "\n%s %s\n%s\n", color.CyanString("Tip:"), rule.Tip, color.HiBlackString(`(run "fsoc tips --disable" to turn tips off)`))
+	shown[rule.ID] = true
+	if err := saveShown(shown); err != nil {
+		log.Infof("Failed to save the tips state: %v", err)
+	}
+}
+
+// enabled returns true if tips can be shown: they are not disabled and stderr is a terminal
+func enabled() bool {
+	if !config.TipsEnabled() || os.Getenv("FSOC_NO_TIPS") != "" {
+		return false
+	}
+	fi, err := os.Stderr.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// Handler returns a log handler that shows a tip when a command fails with a fatal error
+// (which exits without returning through Finish)
+func Handler() log.Handler {
+	return log.HandlerFunc(func(e *log.Entry) error {
+		if e.Level == log.FatalLevel {
+			finish(e.Message)
+		}
+		return nil
+	})
+}
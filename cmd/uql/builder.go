@@ -0,0 +1,89 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uql
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Helpers for commands that generate UQL queries from their flags
+
+var (
+	entityTypeRegexp = regexp.MustCompile(`^[\w.-]+:[\w.-]+$`)
+	entityIdRegexp   = regexp.MustCompile(`^[\w.-]+:[\w.-]+:[\w+/=.-]+$`)
+	nameRegexp       = regexp.MustCompile(`^[\w.-]+$`)
+	relativeRegexp   = regexp.MustCompile(`^-?\d+[smhdw]$`)
+)
+
+// IsEntityType returns true if the string is a fully qualified entity type, e.g., k8s:workload
+func IsEntityType(s string) bool {
+	return entityTypeRegexp.MatchString(s)
+}
+
+// IsEntityId returns true if the string has the form of an entity ID, e.g., k8s:workload:Ry4Aa2JfNcqrEXampleId
+func IsEntityId(s string) bool {
+	return entityIdRegexp.MatchString(s)
+}
+
+// IsAttributeName returns true if the string can be used as an attribute name, e.g., k8s.cluster.name
+func IsAttributeName(s string) bool {
+	return nameRegexp.MatchString(s)
+}
+
+// AttributeFilter returns the entity filter, e.g., [attributes(k8s.cluster.name) = 'prod'], that selects the
+// entities with all of the attribute values, specified as "attribute=value"; it returns an empty string if
+// there are no filters
+func AttributeFilter(filters []string) (string, error) {
+	var conditions []string
+	for _, filter := range filters {
+		name, value, found := strings.Cut(filter, "=")
+		name = strings.TrimSpace(name)
+		if !found || !IsAttributeName(name) {
+			return "", fmt.Errorf("invalid filter %q: expected attribute=value, e.g., k8s.cluster.name=prod", filter)
+		}
+		conditions = append(conditions, fmt.Sprintf("attributes(%s) = %s", name, StringLiteral(strings.TrimSpace(value))))
+	}
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return "[" + strings.Join(conditions, " && ") + "]", nil
+}
+
+// StringLiteral returns the value as a UQL string literal
+func StringLiteral(value string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
+
+// TimeExpression converts a time flag value to a UQL time: relative times (with or without the minus sign,
+// both meaning in the past), "now" or absolute times in RFC 3339 format
+func TimeExpression(s string) (string, error) {
+	switch {
+	case s == "now":
+		return s, nil
+	case relativeRegexp.MatchString(s):
+		if !strings.HasPrefix(s, "-") {
+			s = "-" + s
+		}
+		return s, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return "", fmt.Errorf("%q is not a relative time (e.g., -1h), \"now\" or an RFC 3339 time (e.g., 2023-06-01T10:00:00Z)", s)
+	}
+	return t.UTC().Format(time.RFC3339), nil
+}
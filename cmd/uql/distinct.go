@@ -0,0 +1,86 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uql
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/apex/log"
+
+	fsoc "github.com/cisco-open/fsoc/output"
+)
+
+// distinctRows removes duplicate rows from the data set, keeping the first occurrence of each.
+// Rows are compared by the values of the columns with the given aliases or as a whole if no columns are given.
+func distinctRows(dataSet *DataSet, columns []string) error {
+	if dataSet == nil {
+		return nil
+	}
+
+	var indices []int
+	for _, c := range columns {
+		idx := -1
+		if dataSet.DataModel != nil {
+			for i, f := range dataSet.DataModel.Fields {
+				if f.Alias == c {
+					idx = i
+					break
+				}
+			}
+		}
+		if idx < 0 {
+			return fmt.Errorf("unknown column %q for --distinct; available columns: %s", c, strings.Join(columnAliases(dataSet), ", "))
+		}
+		indices = append(indices, idx)
+	}
+
+	seen := map[string]bool{}
+	distinct := make([][]any, 0, len(dataSet.Data))
+	for _, row := range dataSet.Data {
+		var key string
+		if len(indices) == 0 {
+			key = rowKey(row)
+		} else {
+			values := make([]any, len(indices))
+			for i, idx := range indices {
+				if idx < len(row) {
+					values[i] = row[idx]
+				}
+			}
+			key = fsoc.DistinctKey(values...)
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		distinct = append(distinct, row)
+	}
+	if removed := len(dataSet.Data) - len(distinct); removed > 0 {
+		log.Infof("Removed %v duplicate rows from the result", removed)
+	}
+	dataSet.Data = distinct
+	return nil
+}
+
+func columnAliases(dataSet *DataSet) []string {
+	var aliases []string
+	if dataSet.DataModel != nil {
+		for _, f := range dataSet.DataModel.Fields {
+			aliases = append(aliases, f.Alias)
+		}
+	}
+	return aliases
+}
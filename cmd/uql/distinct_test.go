@@ -0,0 +1,41 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDistinctRows(t *testing.T) {
+	newDataSet := func() *DataSet {
+		return &DataSet{
+			DataModel: &Model{Fields: []ModelField{{Alias: "id"}, {Alias: "name"}}},
+			Data:      [][]any{{"1", "a"}, {"2", "a"}, {"1", "a"}, {"3", "b"}},
+		}
+	}
+
+	dataSet := newDataSet()
+	assert.Nil(t, distinctRows(dataSet, []string{}))
+	assert.Equal(t, [][]any{{"1", "a"}, {"2", "a"}, {"3", "b"}}, dataSet.Data)
+
+	dataSet = newDataSet()
+	assert.Nil(t, distinctRows(dataSet, []string{"name"}))
+	assert.Equal(t, [][]any{{"1", "a"}, {"3", "b"}}, dataSet.Data)
+
+	err := distinctRows(newDataSet(), []string{"missing"})
+	assert.ErrorContains(t, err, `unknown column "missing"`)
+}
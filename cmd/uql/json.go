@@ -132,6 +132,24 @@ func transformForJsonOutput(response *Response) (jsonResult, error) {
 	}, nil
 }
 
+// JSONData returns the rows of the main data set of the response as generic JSON values (objects keyed
+// by the column aliases), the same as the data displayed with -o json, e.g., for evaluating assertions
+func JSONData(response *Response) ([]any, error) {
+	result, err := transformForJsonOutput(response)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(result.Data)
+	if err != nil {
+		return nil, err
+	}
+	var rows []any
+	if err := json.Unmarshal(data, &rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
 // checkAliasCollisions checks for duplicate column aliases in a single table.
 // UQL allows having tables with columns sharing names. Such table is not serializable to a JSON where object fields
 // are named by these column names. Returns an error containing path to the colliding names.
@@ -406,3 +406,30 @@ func asYaml(value any) string {
 	}
 	return string(yamlBytes)
 }
+
+func TestJSONData(t *testing.T) {
+	// Given
+	// language=json
+	serverResponse := `[
+  {
+    "type": "model",
+    "model": { "name": "m:main", "fields": [
+      { "alias": "id", "type": "string", "hints": { "kind": "entity", "field": "id" } },
+      { "alias": "count", "type": "number", "hints": { "kind": "entity", "field": "count" } }
+    ] }
+  }, {
+    "type": "data",
+    "model": { "$jsonPath": "$..[?(@.type == 'model')]..[?(@.name == 'm:main')]", "$model": "m:main" },
+    "dataset": "d:main",
+    "data": [ [ "apm:service:oTHR/29IOh+/AiyhjzQhyQ", 3 ] ]
+  }
+]`
+	response, _ := executeUqlQuery(&Query{"ignored"}, ApiVersion1, mockExecuteResponse(serverResponse))
+
+	// When
+	rows, err := JSONData(response)
+
+	// Then
+	assert.Nil(t, err)
+	assert.Equal(t, []any{map[string]any{"id": "apm:service:oTHR/29IOh+/AiyhjzQhyQ", "count": float64(3)}}, rows)
+}
@@ -0,0 +1,276 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uql
+
+import (
+	"container/list"
+	"encoding/json"
+	"time"
+
+	"github.com/apex/log"
+)
+
+const (
+	nextLinkRel   = "next"
+	followLinkRel = "follow"
+)
+
+// pagingLimits restricts how many additional pages are fetched for a query
+type pagingLimits struct {
+	maxPages int       // maximum number of additional requests per data set (0 means no limit)
+	deadline time.Time // time after which no more pages are requested (zero means no limit)
+}
+
+// allows returns true if another page can be fetched, given the number of pages already fetched for a data set
+func (l pagingLimits) allows(pages int) bool {
+	if l.maxPages > 0 && pages >= l.maxPages {
+		return false
+	}
+	return l.deadline.IsZero() || time.Now().Before(l.deadline)
+}
+
+// fetchAllPages follows the "next" links of the main data set and of the data sets nested in its rows,
+// appending the continued data to the response in place, within the paging limits. If any data set
+// is left with more pages to fetch, the response is marked as truncated.
+func fetchAllPages(response *Response, limits pagingLimits, backend uqlService) error {
+	main := response.Main()
+	if main == nil {
+		return nil
+	}
+
+	// pages of the main data set
+	if err := fetchDataSetPages(response, main, -1, limits, backend); err != nil {
+		return err
+	}
+
+	// pages of the nested data sets
+	for _, row := range main.Data {
+		for colIdx, value := range row {
+			nested, ok := value.(*DataSet)
+			if !ok || nested == nil {
+				continue
+			}
+			if err := fetchDataSetPages(response, nested, colIdx, limits, backend); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// fetchDataSetPages follows the "next" links of a data set: the main data set if colIdx is negative
+// or a data set nested in column colIdx. The continuation responses have the same shape as the
+// original response, i.e., a continued nested data set is found in the same column of the
+// response's main data set.
+func fetchDataSetPages(response *Response, dataSet *DataSet, colIdx int, limits pagingLimits, backend uqlService) error {
+	pages := 0
+	for extractLink(dataSet, nextLinkRel) != nil {
+		if !limits.allows(pages) {
+			response.truncated = true
+			break
+		}
+		next, err := continueUqlQuery(dataSet, nextLinkRel, backend)
+		if err != nil {
+			return err
+		}
+		response.errors = append(response.errors, next.errors...)
+		pages++
+		var continued *DataSet
+		if colIdx < 0 {
+			continued = next.Main()
+		} else {
+			continued = findContinuedDataSet(next, colIdx)
+		}
+		if continued == nil {
+			break
+		}
+		dataSet.Data = append(dataSet.Data, continued.Data...)
+		dataSet.Links = continued.Links
+	}
+	if pages > 0 && colIdx < 0 {
+		log.WithFields(log.Fields{"pages": pages + 1, "rows": len(dataSet.Data)}).Info("Fetched additional pages of the main data set")
+	}
+	return nil
+}
+
+// findContinuedDataSet locates the data set continued by a follow-up request for a nested data set
+func findContinuedDataSet(response *Response, colIdx int) *DataSet {
+	main := response.Main()
+	if main == nil || len(main.Data) == 0 {
+		return nil
+	}
+	row := main.Data[0]
+	if colIdx < len(row) {
+		if ds, ok := row[colIdx].(*DataSet); ok && ds != nil {
+			return ds
+		}
+	}
+	return nil
+}
+
+// queryFollower retrieves data newer than what was already received for a query.
+// If the response provides a "follow" link (on the main data set or on a data set
+// nested in its first row), the link is used. Otherwise, the query is re-executed
+// and only the rows not seen before are returned.
+type queryFollower struct {
+	query   *Query
+	backend uqlService
+	target  *DataSet // data set with the follow link, nil if following is done by re-executing the query
+	column  int      // column of the nested data set with the follow link or -1 for the main data set
+	seen    *seenRows
+}
+
+// maxFollowSeenRows limits the number of rows remembered when following by re-executing the query.
+// The rows seen the longest time ago, which have left the query's time range, are forgotten first.
+const maxFollowSeenRows = 100000
+
+// seenRows is a set of row keys with a limited capacity, evicting the least recently seen keys
+type seenRows struct {
+	capacity int
+	order    *list.List // keys, most recently seen first
+	elements map[string]*list.Element
+}
+
+func newSeenRows(capacity int) *seenRows {
+	return &seenRows{capacity: capacity, order: list.New(), elements: map[string]*list.Element{}}
+}
+
+// add records that a row was seen, returning true if it was not seen before
+func (s *seenRows) add(key string) bool {
+	if e, found := s.elements[key]; found {
+		s.order.MoveToFront(e)
+		return false
+	}
+	s.elements[key] = s.order.PushFront(key)
+	if s.order.Len() > s.capacity {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.elements, oldest.Value.(string))
+	}
+	return true
+}
+
+func newQueryFollower(query *Query, response *Response, backend uqlService) *queryFollower {
+	f := &queryFollower{
+		query:   query,
+		backend: backend,
+		column:  -1,
+		seen:    newSeenRows(maxFollowSeenRows),
+	}
+	f.locateFollowTarget(response)
+	if f.target == nil && response.Main() != nil {
+		for _, row := range response.Main().Data {
+			f.seen.add(rowKey(row))
+		}
+	}
+	return f
+}
+
+func (f *queryFollower) locateFollowTarget(response *Response) {
+	main := response.Main()
+	if main == nil {
+		f.target = nil
+		return
+	}
+	if f.column < 0 && extractLink(main, followLinkRel) != nil {
+		f.target = main
+		return
+	}
+	if len(main.Data) > 0 {
+		for colIdx, value := range main.Data[0] {
+			if f.column >= 0 && colIdx != f.column {
+				continue
+			}
+			if ds, ok := value.(*DataSet); ok && extractLink(ds, followLinkRel) != nil {
+				f.target = ds
+				f.column = colIdx
+				return
+			}
+		}
+	}
+	f.target = nil
+}
+
+// next returns the newly available data or nil if there are none
+func (f *queryFollower) next() (*Response, error) {
+	if f.target != nil {
+		response, err := continueUqlQuery(f.target, followLinkRel, f.backend)
+		if err != nil {
+			return nil, err
+		}
+		previous := f.target
+		f.locateFollowTarget(response)
+		if f.target == nil {
+			// no further follow link provided, keep using the last one
+			f.target = previous
+		}
+		if f.isEmpty(response) {
+			return nil, nil
+		}
+		return response, nil
+	}
+
+	response, err := executeUqlQuery(f.query, ApiVersion1, f.backend)
+	if err != nil {
+		return nil, err
+	}
+	main := response.Main()
+	if main == nil {
+		return nil, nil
+	}
+	var fresh [][]any
+	for _, row := range main.Data {
+		if f.seen.add(rowKey(row)) {
+			fresh = append(fresh, row)
+		}
+	}
+	if len(fresh) == 0 {
+		return nil, nil
+	}
+	return &Response{
+		model: response.model,
+		mainDataSet: &DataSet{
+			Name:      main.Name,
+			DataModel: main.DataModel,
+			Metadata:  main.Metadata,
+			Data:      fresh,
+			Links:     main.Links,
+		},
+		errors: response.errors,
+		raw:    response.raw,
+	}, nil
+}
+
+func (f *queryFollower) isEmpty(response *Response) bool {
+	main := response.Main()
+	if main == nil || len(main.Data) == 0 {
+		return true
+	}
+	if f.column >= 0 {
+		ds := findContinuedDataSet(response, f.column)
+		return ds == nil || len(ds.Data) == 0
+	}
+	return false
+}
+
+func rowKey(row []any) string {
+	b, err := json.Marshal(row)
+	if err != nil {
+		log.Warnf("Failed to compare row data: %v", err)
+		return ""
+	}
+	return string(b)
+}
@@ -0,0 +1,163 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uql
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pagedResponse renders a single-column response with the given values and an optional "next" link
+func pagedResponse(values []int, next string) string {
+	links := ""
+	if next != "" {
+		links = fmt.Sprintf(`"_links": { "next": { "href": %q } },`, next)
+	}
+	rows := "["
+	for i, v := range values {
+		if i > 0 {
+			rows += ","
+		}
+		rows += fmt.Sprintf("[%d]", v)
+	}
+	rows += "]"
+	// language=json
+	return fmt.Sprintf(`[
+	  {
+		"type": "model",
+		"model": { "name": "m:main", "fields": [ { "alias": "count", "type": "number", "hints": { "kind": "entity", "type": "count" } } ] }
+	  },
+	  {
+		"type": "data",
+		"model": { "$jsonPath": "$..[?(@.type == 'model')]..[?(@.name == 'm:main')]", "$model": "m:main" },
+		"dataset": "d:main",
+		%s
+		"data": %s
+	  }
+	]`, links, rows)
+}
+
+func asParsedResponse(t *testing.T, response string) parsedResponse {
+	rawJson := json.RawMessage(response)
+	var chunks []parsedChunk
+	require.Nil(t, json.Unmarshal(rawJson, &chunks))
+	return parsedResponse{chunks: chunks, rawJson: &rawJson}
+}
+
+func pagingBackend(t *testing.T, pages map[string]string) uqlService {
+	return &mockUqlService{
+		executeBehavior: func(query *Query, version ApiVersion) (parsedResponse, error) {
+			return asParsedResponse(t, pages[""]), nil
+		},
+		continueBehavior: func(link *Link) (parsedResponse, error) {
+			page, ok := pages[link.Href]
+			if !ok {
+				t.Fatalf("unexpected link %q", link.Href)
+			}
+			return asParsedResponse(t, page), nil
+		},
+	}
+}
+
+func TestFetchAllPages(t *testing.T) {
+	backend := pagingBackend(t, map[string]string{
+		"":       pagedResponse([]int{1, 2}, "/page2"),
+		"/page2": pagedResponse([]int{3, 4}, "/page3"),
+		"/page3": pagedResponse([]int{5}, ""),
+	})
+
+	response, err := executeUqlQuery(&Query{"ignored"}, ApiVersion1, backend)
+	require.Nil(t, err)
+	require.Nil(t, fetchAllPages(response, pagingLimits{}, backend))
+
+	assert.Equal(t, [][]any{{1}, {2}, {3}, {4}, {5}}, response.Main().Values())
+	assert.Nil(t, extractLink(response.Main(), nextLinkRel))
+	assert.False(t, response.truncated)
+}
+
+func TestFetchAllPages_MaxPages(t *testing.T) {
+	backend := pagingBackend(t, map[string]string{
+		"":       pagedResponse([]int{1, 2}, "/page2"),
+		"/page2": pagedResponse([]int{3, 4}, "/page3"),
+	})
+
+	response, err := executeUqlQuery(&Query{"ignored"}, ApiVersion1, backend)
+	require.Nil(t, err)
+	require.Nil(t, fetchAllPages(response, pagingLimits{maxPages: 1}, backend))
+
+	assert.Equal(t, [][]any{{1}, {2}, {3}, {4}}, response.Main().Values())
+	assert.True(t, response.truncated)
+}
+
+func TestFetchAllPages_MaxTime(t *testing.T) {
+	backend := pagingBackend(t, map[string]string{
+		"": pagedResponse([]int{1, 2}, "/page2"),
+	})
+
+	response, err := executeUqlQuery(&Query{"ignored"}, ApiVersion1, backend)
+	require.Nil(t, err)
+	require.Nil(t, fetchAllPages(response, pagingLimits{deadline: time.Now().Add(-time.Second)}, backend))
+
+	assert.Equal(t, [][]any{{1}, {2}}, response.Main().Values())
+	assert.True(t, response.truncated)
+}
+
+func TestQueryFollower_ReExecute(t *testing.T) {
+	executions := []string{
+		pagedResponse([]int{1, 2, 3}, ""),
+		pagedResponse([]int{1, 2, 3}, ""),
+	}
+	backend := &mockUqlService{
+		executeBehavior: func(query *Query, version ApiVersion) (parsedResponse, error) {
+			response := executions[0]
+			executions = executions[1:]
+			return asParsedResponse(t, response), nil
+		},
+	}
+
+	initial, err := executeUqlQuery(&Query{"ignored"}, ApiVersion1, pagingBackend(t, map[string]string{
+		"": pagedResponse([]int{1, 2}, ""),
+	}))
+	require.Nil(t, err)
+
+	follower := newQueryFollower(&Query{"ignored"}, initial, backend)
+	assert.Nil(t, follower.target)
+
+	newData, err := follower.next()
+	require.Nil(t, err)
+	require.NotNil(t, newData)
+	assert.Equal(t, [][]any{{3}}, newData.Main().Values())
+
+	newData, err = follower.next()
+	require.Nil(t, err)
+	assert.Nil(t, newData)
+}
+
+func TestSeenRows(t *testing.T) {
+	s := newSeenRows(2)
+	assert.True(t, s.add("a"))
+	assert.True(t, s.add("b"))
+	assert.False(t, s.add("a")) // a is now the most recently seen
+	assert.True(t, s.add("c"))  // evicts b
+	assert.Len(t, s.elements, 2)
+	assert.False(t, s.add("a"))
+	assert.False(t, s.add("c"))
+	assert.True(t, s.add("b"))
+}
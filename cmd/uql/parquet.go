@@ -0,0 +1,88 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uql
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	fsoc "github.com/cisco-open/fsoc/output"
+)
+
+// writeParquet writes the main data set of the response as a Parquet table.
+// Each top-level column of the response becomes a Parquet column; scalar values are stored using the
+// closest Parquet type while nested (complex, reference) values are stored as JSON strings.
+func writeParquet(w io.Writer, response *Response) error {
+	// the JSON transformation is used for the nested values and also checks for alias collisions
+	jsonResp, err := transformForJsonOutput(response)
+	if err != nil {
+		return err
+	}
+
+	model := response.Model()
+	var rows [][]any
+	if response.Main() != nil {
+		rows = response.Main().Values()
+	}
+
+	columns := make([]fsoc.ParquetColumn, len(model.Fields))
+	for colIdx, field := range model.Fields {
+		column := fsoc.ParquetColumn{
+			Name:   field.Alias,
+			Type:   parquetTypeForField(field),
+			Values: make([]any, len(rows)),
+		}
+		for rowIdx, row := range rows {
+			if column.Type == fsoc.ParquetJSON && field.Model != nil {
+				// nested tables: take the already transformed value
+				value := reflect.ValueOf(jsonResp.Data[rowIdx]).Field(colIdx)
+				if value.Kind() == reflect.Slice && value.IsNil() {
+					continue // leave as null
+				}
+				column.Values[rowIdx] = value.Interface()
+			} else {
+				column.Values[rowIdx] = row[colIdx]
+			}
+		}
+		columns[colIdx] = column
+	}
+
+	if err := fsoc.WriteParquet(w, columns); err != nil {
+		return fmt.Errorf("failed to write parquet data: %w", err)
+	}
+	return nil
+}
+
+func parquetTypeForField(field ModelField) fsoc.ParquetType {
+	if field.Model != nil || field.IsReference() {
+		return fsoc.ParquetJSON
+	}
+	switch strings.ToLower(field.Type) {
+	case "long":
+		return fsoc.ParquetInt64
+	case "number", "double":
+		return fsoc.ParquetDouble
+	case "boolean":
+		return fsoc.ParquetBoolean
+	case "timestamp":
+		return fsoc.ParquetTimestamp
+	case "string", "csv", "duration":
+		return fsoc.ParquetString
+	default:
+		return fsoc.ParquetJSON
+	}
+}
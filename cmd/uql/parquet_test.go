@@ -0,0 +1,57 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uql
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportParquetToOutputFile(t *testing.T) {
+	// language=json
+	serverResponse := `[
+  {
+    "type": "model",
+    "model": { "name": "m:main", "fields": [
+      { "alias": "id", "type": "string", "hints": { "kind": "entity", "field": "id" } },
+      { "alias": "count", "type": "number", "hints": { "kind": "entity", "field": "count" } }
+    ] }
+  }, {
+    "type": "data",
+    "model": { "$jsonPath": "$..[?(@.type == 'model')]..[?(@.name == 'm:main')]", "$model": "m:main" },
+    "dataset": "d:main",
+    "data": [ [ "apm:service:oTHR/29IOh+/AiyhjzQhyQ", 3 ] ]
+  }
+]`
+	response, err := executeUqlQuery(&Query{"ignored"}, ApiVersion1, mockExecuteResponse(serverResponse))
+	require.NoError(t, err)
+
+	// the output is redirected to the (temporary) output file for --output-file
+	cmd := &cobra.Command{}
+	cmd.Flags().String("output-file", "", "")
+	require.NoError(t, cmd.Flags().Set("output-file", "results.parquet"))
+	var out, errOut bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&errOut)
+
+	require.NoError(t, exportParquet(cmd, response))
+	data := out.Bytes()
+	assert.True(t, bytes.HasPrefix(data, []byte("PAR1")) && bytes.HasSuffix(data, []byte("PAR1")), "the output file contains only the parquet data")
+	assert.Equal(t, "Exported 1 row(s) to results.parquet\n", errOut.String())
+}
@@ -31,6 +31,7 @@ type Response struct {
 	mainDataSet *DataSet
 	errors      []*Error
 	raw         *json.RawMessage
+	truncated   bool // true if more pages of data were available but were not fetched
 }
 
 // jsonObject is either a JSON object or an array received as in the UQL API response
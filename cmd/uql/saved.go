@@ -0,0 +1,314 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uql
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/cisco-open/fsoc/cmd/config"
+	"github.com/cisco-open/fsoc/output"
+)
+
+const (
+	savedQueriesDirName = "queries"
+	savedQueryExt       = ".yaml"
+)
+
+var savedQueryNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// savedQuery is a named query persisted as a YAML file, one file per query
+type savedQuery struct {
+	Name        string           `yaml:"name" json:"name"`
+	Description string           `yaml:"description,omitempty" json:"description,omitempty"`
+	Query       string           `yaml:"query" json:"query"`
+	Parameters  []queryParameter `yaml:"parameters,omitempty" json:"parameters,omitempty"`
+}
+
+// queryParameter is a parameter of a saved query, referenced in the query as {{.name}}
+type queryParameter struct {
+	Name     string `yaml:"name" json:"name"`
+	Default  string `yaml:"default,omitempty" json:"default,omitempty"`
+	Required bool   `yaml:"required,omitempty" json:"required,omitempty"`
+}
+
+var saveCmd = &cobra.Command{
+	Use:   "save NAME QUERY",
+	Short: "Save a UQL query under a name",
+	Long: `Save a UQL query under a name, so that it can be run later with "fsoc uql run NAME".
+
+Queries can be parameterized using {{.param}} placeholders; each parameter must be declared with
+the "param" flag, either with a default value (--param name=value) or as required (--param name).
+
+Saved queries are stored as YAML files, one per query, in the queries directory. By default, this is
+the "queries" subdirectory of the fsoc config home (` + config.ConfigHomeDescription + `); it can be changed
+with the "dir" flag or the FSOC_QUERIES_DIR environment variable, e.g., to point to a directory
+checked into git and shared by a team.`,
+	Example: `  fsoc uql save workloads "FETCH id, attributes(k8s.workload.name) FROM entities(k8s:workload)"
+  fsoc uql save cluster-workloads --description "Workloads in a cluster" --param cluster=prod \
+    "FETCH id FROM entities(k8s:workload)[attributes(k8s.cluster.name) = '{{.cluster}}']"`,
+	Args:         cobra.ExactArgs(2),
+	RunE:         saveQuery,
+	SilenceUsage: true,
+}
+
+var listCmd = &cobra.Command{
+	Use:          "list",
+	Short:        "List saved UQL queries",
+	Long:         `List the saved UQL queries available in the queries directory.`,
+	Example:      `  fsoc uql list`,
+	Args:         cobra.NoArgs,
+	RunE:         listQueries,
+	SilenceUsage: true,
+}
+
+var runCmd = &cobra.Command{
+	Use:   "run NAME",
+	Short: "Run a saved UQL query",
+	Long: `Run a saved UQL query, substituting its parameters with the values provided with the "param" flag
+or with their default values.
+The output flags are the same as for running a query directly with "fsoc uql".`,
+	Example: `  fsoc uql run workloads
+  fsoc uql run cluster-workloads --param cluster=staging -o json`,
+	Args:         cobra.ExactArgs(1),
+	RunE:         runSavedQuery,
+	SilenceUsage: true,
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{saveCmd, listCmd, runCmd} {
+		cmd.Flags().String("dir", "", "Directory containing the saved queries (default is $FSOC_QUERIES_DIR or the queries subdirectory of the fsoc config home)")
+	}
+	saveCmd.Flags().String("description", "", "Description of the query")
+	saveCmd.Flags().StringArray("param", nil, "Query parameter, as name=default or just name for a required parameter (can be repeated)")
+	saveCmd.Flags().Bool("force", false, "Overwrite the saved query if it already exists")
+
+	runCmd.Flags().StringArray("param", nil, "Parameter value as name=value (can be repeated)")
+	runCmd.Flags().StringVarP(&outputFlag, "output", "o", "table", fmt.Sprintf("output format (%s)", availableFormats))
+	runCmd.Flags().BoolVar(&rawFlag, "raw", false, "Display actual response from the backend. Cannot be used together with the output flag.")
+	runCmd.Flags().IntVar(&maxPagesFlag, "max-pages", 0, "Maximum number of additional result pages to fetch per data set (0 for no limit)")
+	runCmd.Flags().BoolVar(&followFlag, "follow", false, "Keep polling for new data until interrupted")
+	runCmd.Flags().DurationVar(&intervalFlag, "interval", 10*time.Second, "Polling interval for the follow mode")
+	runCmd.MarkFlagsMutuallyExclusive("output", "raw")
+
+	uqlCmd.AddCommand(saveCmd)
+	uqlCmd.AddCommand(listCmd)
+	uqlCmd.AddCommand(runCmd)
+}
+
+func saveQuery(cmd *cobra.Command, args []string) error {
+	dir := savedQueriesDir(cmd)
+	description, _ := cmd.Flags().GetString("description")
+	params, _ := cmd.Flags().GetStringArray("param")
+	force, _ := cmd.Flags().GetBool("force")
+
+	query := &savedQuery{Name: args[0], Description: description, Query: args[1]}
+	for _, p := range params {
+		name, value, hasDefault := strings.Cut(p, "=")
+		query.Parameters = append(query.Parameters, queryParameter{Name: name, Default: value, Required: !hasDefault})
+	}
+	if err := query.validate(); err != nil {
+		return err
+	}
+
+	fileName := savedQueryPath(dir, query.Name)
+	if _, err := os.Stat(fileName); err == nil && !force {
+		return fmt.Errorf("query %q already exists in %q; use --force to overwrite it", query.Name, dir)
+	}
+	if err := writeSavedQuery(fileName, query); err != nil {
+		return err
+	}
+	log.WithFields(log.Fields{"name": query.Name, "file": fileName}).Info("Saved UQL query")
+	output.PrintCmdStatus(cmd, fmt.Sprintf("Query %q saved to %q\n", query.Name, fileName))
+	return nil
+}
+
+func listQueries(cmd *cobra.Command, args []string) error {
+	queries, err := loadSavedQueries(savedQueriesDir(cmd))
+	if err != nil {
+		return err
+	}
+
+	lines := make([][]string, len(queries))
+	for i, q := range queries {
+		params := make([]string, len(q.Parameters))
+		for j, p := range q.Parameters {
+			params[j] = p.String()
+		}
+		lines[i] = []string{q.Name, q.Description, strings.Join(params, ", ")}
+	}
+	output.PrintCmdOutputCustom(cmd, struct {
+		Items []*savedQuery `json:"items"`
+		Total int           `json:"total"`
+	}{Items: queries, Total: len(queries)}, &output.Table{
+		Headers: []string{"Name", "Description", "Parameters"},
+		Lines:   lines,
+	})
+	return nil
+}
+
+func runSavedQuery(cmd *cobra.Command, args []string) error {
+	query, err := readSavedQuery(savedQueryPath(savedQueriesDir(cmd), args[0]))
+	if err != nil {
+		return err
+	}
+	params, _ := cmd.Flags().GetStringArray("param")
+	values := map[string]string{}
+	for _, p := range params {
+		name, value, found := strings.Cut(p, "=")
+		if !found {
+			return fmt.Errorf("invalid parameter %q, expected name=value", p)
+		}
+		values[name] = value
+	}
+
+	queryStr, err := query.render(values)
+	if err != nil {
+		return err
+	}
+	log.WithFields(log.Fields{"name": query.Name, "query": queryStr}).Info("Performing saved UQL query")
+	return performQuery(cmd, queryStr)
+}
+
+// validate checks the query name, parameters and template
+func (q *savedQuery) validate() error {
+	if !savedQueryNameRegexp.MatchString(q.Name) {
+		return fmt.Errorf("invalid query name %q: must start with a letter or digit and contain only letters, digits, '_', '.' and '-'", q.Name)
+	}
+	values := map[string]string{}
+	for _, p := range q.Parameters {
+		if p.Name == "" {
+			return fmt.Errorf("parameter names cannot be empty")
+		}
+		if _, found := values[p.Name]; found {
+			return fmt.Errorf("parameter %q is declared more than once", p.Name)
+		}
+		values[p.Name] = p.Default
+	}
+	// render with the declared parameters to catch template errors and undeclared parameters
+	_, err := q.render(values)
+	return err
+}
+
+// render substitutes the parameters in the query, using the default values for parameters not in values
+func (q *savedQuery) render(values map[string]string) (string, error) {
+	data := map[string]string{}
+	for _, p := range q.Parameters {
+		value, found := values[p.Name]
+		if !found {
+			if p.Required {
+				return "", fmt.Errorf("missing value for required parameter %q of query %q", p.Name, q.Name)
+			}
+			value = p.Default
+		}
+		data[p.Name] = value
+	}
+	for name := range values {
+		if _, found := data[name]; !found {
+			return "", fmt.Errorf("query %q has no parameter %q", q.Name, name)
+		}
+	}
+
+	tmpl, err := template.New(q.Name).Option("missingkey=error").Parse(q.Query)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse query %q: %w", q.Name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to substitute the parameters of query %q: %w", q.Name, err)
+	}
+	return buf.String(), nil
+}
+
+func (p queryParameter) String() string {
+	if p.Required {
+		return p.Name + " (required)"
+	}
+	return p.Name + "=" + p.Default
+}
+
+// savedQueriesDir returns the directory holding the saved queries, as selected by the command flags or environment
+func savedQueriesDir(cmd *cobra.Command) string {
+	if dir, _ := cmd.Flags().GetString("dir"); dir != "" {
+		return dir
+	}
+	if dir := os.Getenv("FSOC_QUERIES_DIR"); dir != "" {
+		return dir
+	}
+	return filepath.Join(config.ConfigHome(), savedQueriesDirName)
+}
+
+func savedQueryPath(dir string, name string) string {
+	return filepath.Join(dir, name+savedQueryExt)
+}
+
+func readSavedQuery(fileName string) (*savedQuery, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("saved query %q not found", strings.TrimSuffix(filepath.Base(fileName), savedQueryExt))
+		}
+		return nil, err
+	}
+	var query savedQuery
+	if err := yaml.Unmarshal(data, &query); err != nil {
+		return nil, fmt.Errorf("failed to parse saved query file %q: %w", fileName, err)
+	}
+	if query.Name == "" {
+		query.Name = strings.TrimSuffix(filepath.Base(fileName), savedQueryExt)
+	}
+	return &query, nil
+}
+
+func writeSavedQuery(fileName string, query *savedQuery) error {
+	if err := os.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
+		return fmt.Errorf("failed to create the queries directory: %w", err)
+	}
+	data, err := yaml.Marshal(query)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(fileName, data, 0644)
+}
+
+// loadSavedQueries reads all saved queries in the directory, sorted by name. A missing directory has no queries.
+func loadSavedQueries(dir string) ([]*savedQuery, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*"+savedQueryExt))
+	if err != nil {
+		return nil, err
+	}
+	queries := make([]*savedQuery, 0, len(files))
+	for _, f := range files {
+		query, err := readSavedQuery(f)
+		if err != nil {
+			return nil, err
+		}
+		queries = append(queries, query)
+	}
+	sort.Slice(queries, func(i, j int) bool { return queries[i].Name < queries[j].Name })
+	return queries, nil
+}
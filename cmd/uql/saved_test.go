@@ -0,0 +1,69 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSavedQuery_Render(t *testing.T) {
+	query := &savedQuery{
+		Name:  "cluster-workloads",
+		Query: "FETCH id FROM entities(k8s:workload)[attributes(k8s.cluster.name) = '{{.cluster}}' && attributes(k8s.namespace.name) = '{{.ns}}']",
+		Parameters: []queryParameter{
+			{Name: "cluster", Required: true},
+			{Name: "ns", Default: "default"},
+		},
+	}
+	require.Nil(t, query.validate())
+
+	rendered, err := query.render(map[string]string{"cluster": "prod"})
+	require.Nil(t, err)
+	assert.Equal(t, "FETCH id FROM entities(k8s:workload)[attributes(k8s.cluster.name) = 'prod' && attributes(k8s.namespace.name) = 'default']", rendered)
+
+	_, err = query.render(map[string]string{})
+	assert.ErrorContains(t, err, `missing value for required parameter "cluster"`)
+
+	_, err = query.render(map[string]string{"cluster": "prod", "region": "us"})
+	assert.ErrorContains(t, err, `has no parameter "region"`)
+}
+
+func TestSavedQuery_Validate(t *testing.T) {
+	assert.ErrorContains(t, (&savedQuery{Name: "../x", Query: "FETCH id"}).validate(), "invalid query name")
+	assert.ErrorContains(t, (&savedQuery{Name: "q", Query: "FETCH {{.undeclared}}"}).validate(), "undeclared")
+	assert.ErrorContains(t, (&savedQuery{Name: "q", Query: "FETCH {{.x}}", Parameters: []queryParameter{{Name: "x"}, {Name: "x"}}}).validate(), "more than once")
+}
+
+func TestSavedQueries_ReadWrite(t *testing.T) {
+	dir := t.TempDir()
+	queries, err := loadSavedQueries(dir)
+	require.Nil(t, err)
+	assert.Empty(t, queries)
+
+	second := &savedQuery{Name: "workloads", Query: "FETCH id FROM entities(k8s:workload)"}
+	first := &savedQuery{Name: "clusters", Description: "All clusters", Query: "FETCH id FROM entities(k8s:cluster)", Parameters: []queryParameter{{Name: "p", Default: "v"}}}
+	require.Nil(t, writeSavedQuery(savedQueryPath(dir, second.Name), second))
+	require.Nil(t, writeSavedQuery(savedQueryPath(dir, first.Name), first))
+
+	queries, err = loadSavedQueries(dir)
+	require.Nil(t, err)
+	assert.Equal(t, []*savedQuery{first, second}, queries)
+
+	_, err = readSavedQuery(savedQueryPath(dir, "missing"))
+	assert.ErrorContains(t, err, `saved query "missing" not found`)
+}
@@ -0,0 +1,309 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uql
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+
+	"github.com/cisco-open/fsoc/cmd/config"
+	"github.com/cisco-open/fsoc/output"
+)
+
+// scheduledExportType is the knowledge type of the scheduled export objects
+const scheduledExportType = "uql:scheduledExport"
+
+// scheduledExportFormats are the output formats a scheduled export can produce
+var scheduledExportFormats = []string{"json", "yaml", "parquet"}
+
+// scheduledExport is the knowledge object that makes the platform run a query on a schedule, exporting its results
+type scheduledExport struct {
+	Name        string `yaml:"name" json:"name"`
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+	Query       string `yaml:"query" json:"query"`
+	Schedule    string `yaml:"schedule" json:"schedule"`
+	Format      string `yaml:"format" json:"format"`
+}
+
+var scheduleCmd = &cobra.Command{
+	Use:   "schedule NAME",
+	Short: "Generate a scheduled export of a saved UQL query",
+	Long: `Generate the definition of a recurring export of a saved UQL query, run on a cron schedule.
+
+By default, a ` + scheduledExportType + ` knowledge object is generated, with the query's parameters
+substituted; create it with "fsoc knowledge create" to have the platform run the export. With --script,
+a shell script is generated instead, which runs the saved query with fsoc and writes the results to a
+timestamped file in --export-dir; install it with the crontab entry shown in its header.
+
+The schedule is a standard five-field cron specification (minute, hour, day of month, month and day of
+week) or one of the @hourly, @daily, @midnight, @weekly, @monthly, @yearly and @annually shortcuts.
+The generated definition is displayed, or written to the file specified with --out.`,
+	Example: `  fsoc uql schedule workloads --cron "0 6 * * *" --out workloads-export.yaml
+  fsoc knowledge create --type uql:scheduledExport --layer-type TENANT -f workloads-export.yaml
+  fsoc uql schedule cluster-workloads --param cluster=prod --cron @hourly --script --export-dir /var/exports --out export.sh`,
+	Args:         cobra.ExactArgs(1),
+	RunE:         scheduleQuery,
+	SilenceUsage: true,
+}
+
+func init() {
+	scheduleCmd.Flags().String("dir", "", "Directory containing the saved queries (default is $FSOC_QUERIES_DIR or the queries subdirectory of the fsoc config directory)")
+	scheduleCmd.Flags().StringArray("param", nil, "Parameter value as name=value (can be repeated)")
+	scheduleCmd.Flags().String("cron", "", "Cron schedule of the export, e.g., \"0 6 * * *\" or @daily")
+	_ = scheduleCmd.MarkFlagRequired("cron")
+	scheduleCmd.Flags().String("format", "json", fmt.Sprintf("Format of the exported results (%s)", strings.Join(scheduledExportFormats, ", ")))
+	scheduleCmd.Flags().Bool("script", false, "Generate a shell script for cron instead of a knowledge object")
+	scheduleCmd.Flags().String("export-dir", ".", "Directory the script writes the results to")
+	scheduleCmd.Flags().String("out", "", "File to write the generated definition to, instead of displaying it")
+	uqlCmd.AddCommand(scheduleCmd)
+}
+
+func scheduleQuery(cmd *cobra.Command, args []string) error {
+	dir := savedQueriesDir(cmd)
+	query, err := readSavedQuery(savedQueryPath(dir, args[0]))
+	if err != nil {
+		return err
+	}
+	spec, _ := cmd.Flags().GetString("cron")
+	if err := validateCronSpec(spec); err != nil {
+		return err
+	}
+	format, _ := cmd.Flags().GetString("format")
+	if !contains(scheduledExportFormats, format) {
+		return fmt.Errorf("invalid export format %q, expected one of: %s", format, strings.Join(scheduledExportFormats, ", "))
+	}
+	params, _ := cmd.Flags().GetStringArray("param")
+	values := map[string]string{}
+	for _, p := range params {
+		name, value, found := strings.Cut(p, "=")
+		if !found {
+			return fmt.Errorf("invalid parameter %q, expected name=value", p)
+		}
+		values[name] = value
+	}
+	queryStr, err := query.render(values) // also checks the parameters for the script
+	if err != nil {
+		return err
+	}
+
+	var definition []byte
+	if script, _ := cmd.Flags().GetBool("script"); script {
+		exportDir, _ := cmd.Flags().GetString("export-dir")
+		out, _ := cmd.Flags().GetString("out")
+		definition, err = exportScript(query.Name, dir, params, spec, format, exportDir, out)
+	} else {
+		definition, err = exportObject(cmd, &scheduledExport{
+			Name:        query.Name,
+			Description: query.Description,
+			Query:       queryStr,
+			Schedule:    spec,
+			Format:      format,
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	out, _ := cmd.Flags().GetString("out")
+	if out == "" {
+		output.PrintCmdStatus(cmd, string(definition))
+		return nil
+	}
+	mode := os.FileMode(0644)
+	if bytes.HasPrefix(definition, []byte("#!")) {
+		mode = 0755
+	}
+	if err := os.WriteFile(out, definition, mode); err != nil {
+		return fmt.Errorf("failed to write %q: %w", out, err)
+	}
+	log.WithFields(log.Fields{"name": query.Name, "file": out}).Info("Generated scheduled export")
+	output.PrintCmdStatus(cmd, fmt.Sprintf("Scheduled export of query %q written to %q\n", query.Name, out))
+	return nil
+}
+
+// exportObject returns the knowledge object in YAML or, with -o json, in JSON
+func exportObject(cmd *cobra.Command, export *scheduledExport) ([]byte, error) {
+	if format, _ := cmd.Flags().GetString("output"); format == "json" {
+		data, err := json.MarshalIndent(export, "", "   ")
+		return append(data, '\n'), err
+	}
+	return yaml.Marshal(export)
+}
+
+// exportScript returns a shell script that runs the saved query with the current fsoc executable,
+// config file and profile, so that it works in cron's minimal environment; scriptFile is where the
+// script is written, for the crontab entry in its header (a placeholder is shown if empty)
+func exportScript(name string, queriesDir string, params []string, spec string, format string, exportDir string, scriptFile string) ([]byte, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate the fsoc executable: %w", err)
+	}
+	absQueriesDir, err := filepath.Abs(queriesDir)
+	if err != nil {
+		return nil, err
+	}
+	absExportDir, err := filepath.Abs(exportDir)
+	if err != nil {
+		return nil, err
+	}
+	configFile := viper.ConfigFileUsed()
+	if configFile == "" {
+		configFile = config.DefaultConfigFilePath()
+	}
+	if configFile, err = filepath.Abs(configFile); err != nil {
+		return nil, err
+	}
+	scriptPath := "/path/to/this/script"
+	if scriptFile != "" {
+		if scriptPath, err = filepath.Abs(scriptFile); err != nil {
+			return nil, err
+		}
+	}
+
+	runArgs := []string{executable, "uql", "run", name,
+		"--dir", absQueriesDir,
+		"--config", configFile,
+		"--profile", config.GetCurrentProfileName(),
+	}
+	for _, p := range params {
+		runArgs = append(runArgs, "--param", p)
+	}
+	runArgs = append(runArgs, "-o", format)
+	quoted := make([]string, len(runArgs))
+	for i, a := range runArgs {
+		quoted[i] = shellQuote(a)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("#!/bin/sh\n")
+	fmt.Fprintf(&sb, "# Scheduled export of the saved UQL query %q, generated by \"fsoc uql schedule\"\n", name)
+	sb.WriteString("# Install it with the crontab entry:\n")
+	fmt.Fprintf(&sb, "#   %s %s\n", spec, scriptPath)
+	sb.WriteString("set -e\n")
+	fmt.Fprintf(&sb, "mkdir -p %s\n", shellQuote(absExportDir))
+	fmt.Fprintf(&sb, "file=%s\"/%s-$(date -u +%%Y%%m%%dT%%H%%M%%SZ).%s\"\n", shellQuote(absExportDir), name, format)
+	if format == "parquet" {
+		fmt.Fprintf(&sb, "exec %s --output-file \"$file\"\n", strings.Join(quoted, " "))
+	} else {
+		fmt.Fprintf(&sb, "exec %s >\"$file\"\n", strings.Join(quoted, " "))
+	}
+	return []byte(sb.String()), nil
+}
+
+// cronMacros are the shortcuts accepted in place of the five fields of a cron specification
+var cronMacros = []string{"@hourly", "@daily", "@midnight", "@weekly", "@monthly", "@yearly", "@annually"}
+
+// cronFields are the ranges and names of the fields of a cron specification
+var cronFields = []struct {
+	name     string
+	min, max int
+	names    []string // names of the values, starting at min
+}{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}},
+	{name: "day of week", min: 0, max: 7, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}},
+}
+
+// validateCronSpec checks that the cron specification has five valid fields or is a shortcut
+func validateCronSpec(spec string) error {
+	if strings.HasPrefix(spec, "@") {
+		if contains(cronMacros, spec) {
+			return nil
+		}
+		return fmt.Errorf("invalid cron schedule %q: unknown shortcut, expected one of: %s", spec, strings.Join(cronMacros, ", "))
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != len(cronFields) {
+		return fmt.Errorf("invalid cron schedule %q: expected 5 fields (minute, hour, day of month, month, day of week), found %d", spec, len(fields))
+	}
+	for i, field := range fields {
+		f := cronFields[i]
+		for _, item := range strings.Split(field, ",") {
+			if err := validateCronItem(item, f.min, f.max, f.names); err != nil {
+				return fmt.Errorf("invalid cron schedule %q: %s field %q: %w", spec, f.name, field, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validateCronItem checks one item of a cron field's list: *, a value or a range, optionally with a /step
+func validateCronItem(item string, min int, max int, names []string) error {
+	rangePart, step, hasStep := strings.Cut(item, "/")
+	if hasStep {
+		if n, err := strconv.Atoi(step); err != nil || n < 1 {
+			return fmt.Errorf("invalid step %q", step)
+		}
+	}
+	if rangePart == "*" {
+		return nil
+	}
+	first, last, isRange := strings.Cut(rangePart, "-")
+	from, err := cronValue(first, min, max, names)
+	if err != nil {
+		return err
+	}
+	if !isRange {
+		return nil
+	}
+	to, err := cronValue(last, min, max, names)
+	if err != nil {
+		return err
+	}
+	if to < from {
+		return fmt.Errorf("range %q ends before it starts", rangePart)
+	}
+	return nil
+}
+
+// cronValue parses a cron field value, a number in the range or one of the names (case-insensitive)
+func cronValue(s string, min int, max int, names []string) (int, error) {
+	for i, name := range names {
+		if strings.EqualFold(s, name) {
+			return min + i, nil
+		}
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < min || n > max {
+		return 0, fmt.Errorf("value %q is not between %d and %d", s, min, max)
+	}
+	return n, nil
+}
+
+// shellQuote quotes a string for the POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateCronSpec(t *testing.T) {
+	for _, spec := range []string{"0 6 * * *", "*/15 * * * *", "0 8-18/2 * * mon-fri", "30 2 1,15 jan,jul 0", "@daily", "0 0 * * 7"} {
+		assert.NoError(t, validateCronSpec(spec), spec)
+	}
+	assert.ErrorContains(t, validateCronSpec("0 6 * *"), "expected 5 fields")
+	assert.ErrorContains(t, validateCronSpec("60 * * * *"), "minute field")
+	assert.ErrorContains(t, validateCronSpec("0 18-8 * * *"), "ends before it starts")
+	assert.ErrorContains(t, validateCronSpec("*/0 * * * *"), "invalid step")
+	assert.ErrorContains(t, validateCronSpec("0 0 * foo *"), "month field")
+	assert.ErrorContains(t, validateCronSpec("@reboot"), "unknown shortcut")
+}
+
+func TestExportScript(t *testing.T) {
+	script, err := exportScript("cluster-workloads", "/q", []string{"cluster=it's"}, "@hourly", "json", "/var/exports", "/opt/export.sh")
+	require.NoError(t, err)
+	s := string(script)
+	assert.Contains(t, s, "#!/bin/sh\n")
+	assert.Contains(t, s, "#   @hourly /opt/export.sh\n")
+	assert.Contains(t, s, `'uql' 'run' 'cluster-workloads' '--dir' '/q'`)
+	assert.Contains(t, s, `file='/var/exports'"/cluster-workloads-$(date -u +%Y%m%dT%H%M%SZ).json"`)
+	assert.Contains(t, s, `'--param' 'cluster=it'\''s' '-o' 'json' >"$file"`)
+
+	script, err = exportScript("workloads", "/q", nil, "0 6 * * *", "parquet", "/var/exports", "")
+	require.NoError(t, err)
+	assert.Contains(t, string(script), "#   0 6 * * * /path/to/this/script\n")
+	assert.Contains(t, string(script), `'-o' 'parquet' --output-file "$file"`)
+}
@@ -17,7 +17,10 @@ package uql
 import (
 	"fmt"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/apex/log"
 	"github.com/charmbracelet/lipgloss"
@@ -25,14 +28,19 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 
+	"github.com/cisco-open/fsoc/cmdkit/interrupt"
+	"github.com/cisco-open/fsoc/cmdkit/progress"
 	fsoc "github.com/cisco-open/fsoc/output"
 )
 
 var outputFlag string
 var rawFlag bool
+var maxPagesFlag int
+var followFlag bool
+var intervalFlag time.Duration
 
 const (
-	availableFormats string = "auto, table, json, yaml"
+	availableFormats string = "auto, table, json, yaml, parquet"
 )
 
 // uqlCmd represents the uql command
@@ -42,9 +50,22 @@ var uqlCmd = &cobra.Command{
 	Long: `Perform UQL query of MELT data for a tenant.
 Parsed response data are displayed in a table by default.
 Available output formats: ` + availableFormats + `.
-If the "raw" flag is provided, the actual response from the backend API is displayed instead.`,
+If the "raw" flag is provided, the actual response from the backend API is displayed instead.
+The parquet format writes the result data into a file specified with the "output-file" flag,
+ready to be loaded into data analysis tools like pandas or Spark.
+
+Results returned in multiple pages are fetched and concatenated automatically; use the
+"max-pages" flag to limit the number of additional pages requested or the "max-time" flag
+to limit the time spent fetching them. Output that does not include all pages is reported as truncated.
+With the "follow" flag, the query keeps polling for new data at the specified interval until interrupted.`,
 	Example: `# Get parsed results
-  fsoc uql "FETCH id, type, attributes FROM entities(k8s:workload)"`,
+  fsoc uql "FETCH id, type, attributes FROM entities(k8s:workload)"
+
+# Export results to a Parquet file
+  fsoc uql "FETCH id, attributes(k8s.cluster.name) FROM entities(k8s:workload)" -o parquet --output-file results.parquet
+
+# Tail events as they arrive
+  fsoc uql "FETCH events(logs:generic_record){timestamp, raw} SINCE -5m" --follow --interval 5s`,
 	Args:             cobra.ExactArgs(1),
 	RunE:             uqlQuery,
 	TraverseChildren: true,
@@ -58,19 +79,28 @@ const (
 	rawFormat
 	jsonFormat
 	yamlFormat
+	parquetFormat
 )
 
 func init() {
 	uqlCmd.Flags().StringVarP(&outputFlag, "output", "o", "table", "overridden")
 	uqlCmd.Flags().BoolVar(&rawFlag, "raw", false, "Display actual response from the backend. Cannot be used together with the output flag.")
+	uqlCmd.Flags().IntVar(&maxPagesFlag, "max-pages", 0, "Maximum number of additional result pages to fetch per data set (0 for no limit)")
+	uqlCmd.Flags().BoolVar(&followFlag, "follow", false, "Keep polling for new data until interrupted")
+	uqlCmd.Flags().DurationVar(&intervalFlag, "interval", 10*time.Second, "Polling interval for the follow mode")
 	uqlCmd.MarkFlagsMutuallyExclusive("output", "raw")
+	// nb: the help and usage functions are inherited by the subcommands, hence the explicit reference to uqlCmd
 	uqlCmd.SetHelpFunc(func(cmd *cobra.Command, args []string) {
-		changeFlagUsage(cmd.Parent())
-		cmd.Parent().HelpFunc()(cmd, args)
+		if cmd == uqlCmd {
+			changeFlagUsage(cmd.Parent())
+		}
+		uqlCmd.Parent().HelpFunc()(cmd, args)
 	})
 	uqlCmd.SetUsageFunc(func(cmd *cobra.Command) error {
-		changeFlagUsage(cmd.Parent())
-		return cmd.Parent().UsageFunc()(cmd)
+		if cmd == uqlCmd {
+			changeFlagUsage(cmd.Parent())
+		}
+		return uqlCmd.Parent().UsageFunc()(cmd)
 	})
 }
 
@@ -81,12 +111,105 @@ func NewSubCmd() *cobra.Command {
 func uqlQuery(cmd *cobra.Command, args []string) error {
 	log.WithFields(log.Fields{"command": cmd.Name(), "args": args[0]}).Info("Performing UQL query")
 
+	return performQuery(cmd, args[0])
+}
+
+// performQuery executes the query and displays its results according to the output flags
+func performQuery(cmd *cobra.Command, queryStr string) error {
 	output, err := outputFormat(outputFlag, rawFlag)
 	if err != nil {
 		return err
 	}
-	queryStr := args[0]
-	response, err := runQuery(queryStr)
+	if file, _ := cmd.Flags().GetString("output-file"); output == parquetFormat && file == "" {
+		return fmt.Errorf("the parquet output format requires an output file, please specify it with --output-file")
+	}
+	if followFlag && output == parquetFormat {
+		return fmt.Errorf("the follow mode cannot be used with the parquet output format")
+	}
+	if intervalFlag <= 0 {
+		return fmt.Errorf("the polling interval must be positive")
+	}
+	limits := pagingLimits{maxPages: maxPagesFlag}
+	if maxTime, _ := cmd.Flags().GetDuration("max-time"); maxTime > 0 {
+		limits.deadline = time.Now().Add(maxTime)
+	}
+	response := fetchResponse(cmd, queryStr, limits)
+	if spec, _ := cmd.Flags().GetString("distinct"); spec != "" {
+		if err := distinctRows(response.Main(), fsoc.ParseDistinctFields(spec)); err != nil {
+			return err
+		}
+	}
+	err = printResponse(cmd, response, output)
+	if err != nil {
+		return err
+	}
+	if response.truncated {
+		log.Warn("Output is truncated: more result pages are available but were not fetched due to --max-pages or --max-time")
+	}
+	if followFlag {
+		return followQuery(cmd, &Query{Str: queryStr}, response, output, intervalFlag)
+	}
+	return nil
+}
+
+// QueryAndDisplay executes a query generated by another command and displays its results in the
+// format selected with the --output flag (auto, table, json or yaml), like the uql command does
+func QueryAndDisplay(cmd *cobra.Command, queryStr string) error {
+	output, err := generatedQueryFormat(cmd)
+	if err != nil {
+		return err
+	}
+	return printResponse(cmd, FetchQuery(cmd, queryStr), output)
+}
+
+// QueryAndFollow executes a query generated by another command, displays its results and keeps polling
+// for new data at the interval until interrupted, like the uql command's follow mode
+func QueryAndFollow(cmd *cobra.Command, queryStr string, interval time.Duration) error {
+	output, err := generatedQueryFormat(cmd)
+	if err != nil {
+		return err
+	}
+	if interval <= 0 {
+		return fmt.Errorf("the polling interval must be positive")
+	}
+	response := FetchQuery(cmd, queryStr)
+	if err := printResponse(cmd, response, output); err != nil {
+		return err
+	}
+	return followQuery(cmd, &Query{Str: queryStr}, response, output, interval)
+}
+
+// generatedQueryFormat returns the output format selected for a command that generates queries
+func generatedQueryFormat(cmd *cobra.Command) (format, error) {
+	name, _ := cmd.Flags().GetString("output")
+	output, err := outputFormat(name, false)
+	if err != nil {
+		return -1, err
+	}
+	if output == parquetFormat {
+		return -1, fmt.Errorf("the parquet output format is supported only by the uql command")
+	}
+	return output, nil
+}
+
+// FetchQuery executes a query generated by another command, fetching all result pages within the
+// --max-time limit. Query problems are displayed and the command fails.
+func FetchQuery(cmd *cobra.Command, queryStr string) *Response {
+	limits := pagingLimits{}
+	if maxTime, _ := cmd.Flags().GetDuration("max-time"); maxTime > 0 {
+		limits.deadline = time.Now().Add(maxTime)
+	}
+	response := fetchResponse(cmd, queryStr, limits)
+	if response.truncated {
+		log.Warn("Output is truncated: more result pages are available but were not fetched due to --max-time")
+	}
+	return response
+}
+
+// fetchResponse executes the query, fetching the result pages within the limits, and reports errors.
+// Query problems are displayed with the location of the error in the query.
+func fetchResponse(cmd *cobra.Command, queryStr string, limits pagingLimits) *Response {
+	response, err := runQuery(queryStr, limits)
 	if err != nil {
 		if problem, ok := err.(uqlProblem); ok {
 			printProblemDescription(cmd, problem, queryStr)
@@ -101,11 +224,7 @@ func uqlQuery(cmd *cobra.Command, args []string) error {
 			log.Errorf("%s: %s", e.Title, e.Detail)
 		}
 	}
-	err = printResponse(cmd, response, output)
-	if err != nil {
-		return err
-	}
-	return nil
+	return response
 }
 
 func outputFormat(output string, useRaw bool) (format, error) {
@@ -121,6 +240,8 @@ func outputFormat(output string, useRaw bool) (format, error) {
 		return jsonFormat, nil
 	case "yaml":
 		return yamlFormat, nil
+	case "parquet":
+		return parquetFormat, nil
 
 	default:
 		return -1, fmt.Errorf(
@@ -131,10 +252,19 @@ func outputFormat(output string, useRaw bool) (format, error) {
 	}
 }
 
-func runQuery(query string) (*Response, error) {
+func runQuery(query string, limits pagingLimits) (*Response, error) {
 	log.Info("fetch data")
 
-	resp, err := ExecuteQuery(&Query{Str: query}, ApiVersion1)
+	spinner := progress.Start("UQL query")
+	tracked := &progressBackend{uqlService: backend, spinner: spinner}
+	resp, err := executeUqlQuery(&Query{Str: query}, ApiVersion1, tracked)
+	if err == nil {
+		err = fetchAllPages(resp, limits, tracked)
+	}
+	if tracked.pages > 0 {
+		spinner.Update(fmt.Sprintf("%d pages", tracked.pages+1))
+	}
+	spinner.Stop(err == nil)
 	if err != nil {
 		return nil, err
 	}
@@ -142,6 +272,52 @@ func runQuery(query string) (*Response, error) {
 	return resp, nil
 }
 
+// progressBackend displays the number of the result page being fetched on the query's spinner
+type progressBackend struct {
+	uqlService
+	spinner *progress.Spinner
+	pages   int
+}
+
+func (b *progressBackend) Continue(link *Link) (parsedResponse, error) {
+	b.pages++
+	b.spinner.Update(fmt.Sprintf("fetching page %d", b.pages+1))
+	return b.uqlService.Continue(link)
+}
+
+func followQuery(cmd *cobra.Command, query *Query, response *Response, output format, interval time.Duration) error {
+	interrupt.Disable() // following until interrupted is not a failure
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	follower := newQueryFollower(query, response, backend)
+	log.WithFields(log.Fields{"interval": interval, "followLink": follower.target != nil}).Info("Following query results")
+	for {
+		select {
+		case <-signals:
+			return nil
+		case <-time.After(interval):
+		}
+
+		newData, err := follower.next()
+		if err != nil {
+			return err
+		}
+		if newData == nil {
+			continue
+		}
+		if newData.HasErrors() {
+			for _, e := range newData.Errors() {
+				log.Errorf("%s: %s", e.Title, e.Detail)
+			}
+		}
+		if err := printResponse(cmd, newData, output); err != nil {
+			return err
+		}
+	}
+}
+
 func printResponse(cmd *cobra.Command, response *Response, output format) error {
 	switch output {
 	case tableFormat, autoFormat:
@@ -161,7 +337,32 @@ func printResponse(cmd *cobra.Command, response *Response, output format) error
 		return fsoc.PrintYaml(cmd, json)
 	case rawFormat:
 		fsoc.PrintCmdOutput(cmd, string(*response.raw))
+	case parquetFormat:
+		return exportParquet(cmd, response)
+	}
+	return nil
+}
+
+// exportParquet writes the results in the parquet format to the output file (--output-file), which
+// is replaced only once the command succeeds. The data is synced to the disk before the export is
+// reported, so that write errors are not reported after it.
+func exportParquet(cmd *cobra.Command, response *Response) error {
+	fileName, _ := cmd.Flags().GetString("output-file")
+	w := fsoc.GetOutWriter(cmd)
+	if err := writeParquet(w, response); err != nil {
+		return err
+	}
+	if file, ok := w.(*os.File); ok {
+		if err := file.Sync(); err != nil {
+			return fmt.Errorf("failed to write the parquet file %q: %w", fileName, err)
+		}
+	}
+	rows := 0
+	if response.Main() != nil {
+		rows = len(response.Main().Values())
 	}
+	log.WithFields(log.Fields{"file": fileName, "rows": rows}).Info("Exported UQL results in parquet format")
+	fsoc.PrintCmdStatus(cmd, fmt.Sprintf("Exported %d row(s) to %s\n", rows, fileName))
 	return nil
 }
 
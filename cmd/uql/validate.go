@@ -0,0 +1,344 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uql
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+
+	"github.com/cisco-open/fsoc/cmd/config"
+	"github.com/cisco-open/fsoc/platform/api"
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate QUERY",
+	Short: "Validate a UQL query without executing it",
+	Long: `Validate a UQL query without executing it.
+
+The query is checked client-side for syntax problems (unbalanced parentheses and quotes, missing FETCH clause).
+Unless --syntax-only is specified, the entity types and attributes referenced in the query are also
+checked against the entity types known to the tenant.`,
+	Example: `  fsoc uql validate "FETCH id, attributes(k8s.cluster.name) FROM entities(k8s:workload)"
+  fsoc uql validate --syntax-only "FETCH id FROM entities(k8s:workload"`,
+	Args:         cobra.ExactArgs(1),
+	RunE:         validateQuery,
+	SilenceUsage: true,
+}
+
+func init() {
+	validateCmd.Flags().Bool("syntax-only", false, "Check only the query syntax, without looking up entity types and attributes")
+	uqlCmd.AddCommand(validateCmd)
+}
+
+type issueSeverity string
+
+const (
+	severityError   issueSeverity = "error"
+	severityWarning issueSeverity = "warning"
+)
+
+// queryIssue is a problem found in a query during validation
+type queryIssue struct {
+	severity issueSeverity
+	detail   errorDetail
+}
+
+// entityTypeInfo is the subset of an FMM entity type definition needed for validation
+type entityTypeInfo struct {
+	Namespace struct {
+		Name string `json:"name"`
+	} `json:"namespace"`
+	Name                 string `json:"name"`
+	AttributeDefinitions *struct {
+		Attributes map[string]any `json:"attributes"`
+	} `json:"attributeDefinitions"`
+}
+
+// entityTypeLoader provides the known entity types, indexed by fully qualified name; replaceable for testing
+var entityTypeLoader = fetchEntityTypes
+
+var (
+	entitiesRegexp   = regexp.MustCompile(`(?i)\bentities\s*\(([^()]*)\)`)
+	attributesRegexp = regexp.MustCompile(`(?i)\battributes\s*\(([^()]*)\)`)
+	fetchRegexp      = regexp.MustCompile(`(?i)^\s*fetch\b`)
+)
+
+func validateQuery(cmd *cobra.Command, args []string) error {
+	query := args[0]
+	syntaxOnly, _ := cmd.Flags().GetBool("syntax-only")
+	log.WithFields(log.Fields{"query": query, "syntaxOnly": syntaxOnly}).Info("Validating UQL query")
+
+	issues := lintQuery(query)
+	if !syntaxOnly && !hasErrors(issues) {
+		entityTypes, err := entityTypeLoader()
+		if err != nil {
+			return fmt.Errorf("failed to retrieve the known entity types: %w", err)
+		}
+		issues = append(issues, checkReferences(query, entityTypes)...)
+	}
+
+	if len(issues) == 0 {
+		cmd.Println("Query is valid")
+		return nil
+	}
+	for _, issue := range issues {
+		cmd.Printf("%s: ", issue.severity)
+		printErrorDetail(cmd, issue.detail)
+		if issue.detail.errorFrom != (position{}) {
+			cmd.Printf("%s\n\n", highlightError(query, issue.detail))
+		}
+	}
+	if hasErrors(issues) {
+		return fmt.Errorf("query validation failed")
+	}
+	return nil
+}
+
+func hasErrors(issues []queryIssue) bool {
+	for _, issue := range issues {
+		if issue.severity == severityError {
+			return true
+		}
+	}
+	return false
+}
+
+// lintQuery performs client-side syntax checks of the query
+func lintQuery(query string) []queryIssue {
+	var issues []queryIssue
+
+	if !fetchRegexp.MatchString(query) {
+		issues = append(issues, queryIssue{
+			severity: severityError,
+			detail: errorDetail{
+				message:       "Query must start with the FETCH clause",
+				fixSuggestion: "Start the query with FETCH followed by the fields to retrieve",
+				errorType:     "SYNTAX",
+			},
+		})
+	}
+
+	type opening struct {
+		char rune
+		pos  position
+	}
+	closers := map[rune]rune{')': '(', ']': '[', '}': '{'}
+	var stack []opening
+	var quote *opening
+
+	line, column := 1, 0
+	for _, r := range query {
+		pos := position{line: line, column: column}
+		switch {
+		case quote != nil:
+			if r == quote.char {
+				quote = nil
+			}
+		case r == '\'' || r == '"' || r == '`':
+			quote = &opening{char: r, pos: pos}
+		case r == '(' || r == '[' || r == '{':
+			stack = append(stack, opening{char: r, pos: pos})
+		case closers[r] != 0:
+			if len(stack) == 0 || stack[len(stack)-1].char != closers[r] {
+				issues = append(issues, syntaxIssue(fmt.Sprintf("Unexpected %q", r), pos, position{line: line, column: column + 1}))
+			} else {
+				stack = stack[:len(stack)-1]
+			}
+		}
+		if r == '\n' {
+			line++
+			column = 0
+		} else {
+			column++
+		}
+	}
+
+	if quote != nil {
+		issues = append(issues, syntaxIssue(fmt.Sprintf("Unterminated string starting with %q", quote.char), quote.pos, position{line: line, column: column}))
+	}
+	for i := len(stack) - 1; i >= 0; i-- {
+		o := stack[i]
+		issues = append(issues, syntaxIssue(fmt.Sprintf("Unclosed %q", o.char), o.pos, position{line: o.pos.line, column: o.pos.column + 1}))
+	}
+
+	return issues
+}
+
+func syntaxIssue(message string, from position, to position) queryIssue {
+	return queryIssue{
+		severity: severityError,
+		detail: errorDetail{
+			message:   message,
+			errorType: "SYNTAX",
+			errorFrom: from,
+			errorTo:   to,
+		},
+	}
+}
+
+// checkReferences checks the entity types and attributes referenced in the query against the known entity types
+func checkReferences(query string, entityTypes map[string]entityTypeInfo) []queryIssue {
+	var issues []queryIssue
+
+	knownAttributes := map[string]bool{}
+	var referencedTypes []string
+	for _, match := range entitiesRegexp.FindAllStringSubmatchIndex(query, -1) {
+		for _, ref := range splitReferences(query, match[2], match[3]) {
+			entityType, found := entityTypes[ref.name]
+			if !found {
+				issues = append(issues, queryIssue{
+					severity: severityError,
+					detail: errorDetail{
+						message:          fmt.Sprintf("Unknown entity type %q", ref.name),
+						fixPossibilities: similarNames(ref.name, keys(entityTypes)),
+						errorType:        "SEMANTIC",
+						errorFrom:        offsetToPosition(query, ref.from),
+						errorTo:          offsetToPosition(query, ref.to-1),
+					},
+				})
+				continue
+			}
+			referencedTypes = append(referencedTypes, ref.name)
+			if entityType.AttributeDefinitions != nil {
+				for name := range entityType.AttributeDefinitions.Attributes {
+					knownAttributes[name] = true
+				}
+			}
+		}
+	}
+
+	// attributes can be checked only against explicitly referenced entity types
+	if len(referencedTypes) == 0 {
+		return issues
+	}
+	for _, match := range attributesRegexp.FindAllStringSubmatchIndex(query, -1) {
+		for _, ref := range splitReferences(query, match[2], match[3]) {
+			if knownAttributes[ref.name] {
+				continue
+			}
+			issues = append(issues, queryIssue{
+				severity: severityWarning,
+				detail: errorDetail{
+					message:          fmt.Sprintf("Attribute %q is not defined by entity type(s) %s", ref.name, strings.Join(referencedTypes, ", ")),
+					fixPossibilities: similarNames(ref.name, keys(knownAttributes)),
+					errorType:        "SEMANTIC",
+					errorFrom:        offsetToPosition(query, ref.from),
+					errorTo:          offsetToPosition(query, ref.to-1),
+				},
+			})
+		}
+	}
+	return issues
+}
+
+type nameRef struct {
+	name     string
+	from, to int // byte offsets in the query
+}
+
+// splitReferences splits a comma-separated list of names in query[start:end], removing quotes
+func splitReferences(query string, start int, end int) []nameRef {
+	var refs []nameRef
+	offset := start
+	for _, part := range strings.Split(query[start:end], ",") {
+		trimmed := strings.TrimSpace(part)
+		from := offset + strings.Index(part, trimmed)
+		offset += len(part) + 1
+		name := strings.Trim(trimmed, "'\"`")
+		if name == "" {
+			continue
+		}
+		refs = append(refs, nameRef{name: name, from: from, to: from + len(trimmed)})
+	}
+	return refs
+}
+
+// offsetToPosition converts a byte offset in the query to a line/column position
+func offsetToPosition(query string, offset int) position {
+	pos := position{line: 1}
+	for _, r := range query[:offset] {
+		if r == '\n' {
+			pos.line++
+			pos.column = 0
+		} else {
+			pos.column++
+		}
+	}
+	return pos
+}
+
+// similarNames returns up to 3 candidates that look similar to the name (sharing its prefix or suffix)
+func similarNames(name string, candidates []string) []string {
+	var similar []string
+	sort.Strings(candidates)
+	prefix, suffix := name, name
+	if i := strings.LastIndexAny(name, ":."); i >= 0 {
+		prefix, suffix = name[:i+1], name[i+1:]
+	}
+	for _, c := range candidates {
+		if len(similar) >= 3 {
+			break
+		}
+		if strings.HasPrefix(c, prefix) || strings.HasSuffix(c, suffix) {
+			similar = append(similar, c)
+		}
+	}
+	return similar
+}
+
+func keys[V any](m map[string]V) []string {
+	result := make([]string, 0, len(m))
+	for k := range m {
+		result = append(result, k)
+	}
+	return result
+}
+
+func fetchEntityTypes() (map[string]entityTypeInfo, error) {
+	headers := map[string]string{
+		"layer-type": "TENANT",
+		"layer-id":   config.GetCurrentContext().Tenant,
+	}
+	var res any
+	if err := api.JSONGetCollection("objstore/v1beta/objects/fmm:entity", &res, &api.Options{Headers: headers}); err != nil {
+		return nil, err
+	}
+
+	// re-parse the generic collection items into entity type definitions
+	b, err := json.Marshal(res)
+	if err != nil {
+		return nil, err
+	}
+	var collection struct {
+		Items []struct {
+			Data entityTypeInfo `json:"data"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(b, &collection); err != nil {
+		return nil, err
+	}
+
+	entityTypes := make(map[string]entityTypeInfo, len(collection.Items))
+	for _, item := range collection.Items {
+		entityTypes[item.Data.Namespace.Name+":"+item.Data.Name] = item.Data
+	}
+	return entityTypes, nil
+}
@@ -0,0 +1,76 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uql
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLintQuery_Valid(t *testing.T) {
+	issues := lintQuery("FETCH id, attributes('k8s.cluster.name') FROM entities(k8s:workload)[attributes(\"x\") = 'a)b'] SINCE -1h")
+	assert.Empty(t, issues)
+}
+
+func TestLintQuery_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		message string
+		from    position
+	}{
+		{name: "missing fetch", query: "id FROM entities(k8s:workload)", message: "Query must start with the FETCH clause"},
+		{name: "unclosed parenthesis", query: "FETCH id FROM entities(k8s:workload", message: "Unclosed '('", from: position{line: 1, column: 22}},
+		{name: "unexpected bracket", query: "FETCH id\nFROM entities(k8s:workload]", message: "Unexpected ']'", from: position{line: 2, column: 26}},
+		{name: "unterminated string", query: "FETCH id FROM entities(k8s:workload)[attributes(x) = 'abc]", message: "Unterminated string starting with '\\''", from: position{line: 1, column: 53}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := lintQuery(tt.query)
+			require.NotEmpty(t, issues)
+			assert.Equal(t, severityError, issues[0].severity)
+			assert.Equal(t, tt.message, issues[0].detail.message)
+			assert.Equal(t, tt.from, issues[0].detail.errorFrom)
+		})
+	}
+}
+
+func TestCheckReferences(t *testing.T) {
+	// language=json
+	entityTypesJson := `{
+		"k8s:workload": { "namespace": { "name": "k8s" }, "name": "workload", "attributeDefinitions": { "attributes": { "k8s.cluster.name": {}, "k8s.workload.name": {} } } },
+		"k8s:cluster": { "namespace": { "name": "k8s" }, "name": "cluster" }
+	}`
+	var entityTypes map[string]entityTypeInfo
+	require.Nil(t, json.Unmarshal([]byte(entityTypesJson), &entityTypes))
+
+	issues := checkReferences("FETCH id, attributes(k8s.cluster.name) FROM entities(k8s:workload)", entityTypes)
+	assert.Empty(t, issues)
+
+	issues = checkReferences("FETCH id FROM entities(k8s:workloads, k8s:cluster)", entityTypes)
+	require.Len(t, issues, 1)
+	assert.Equal(t, severityError, issues[0].severity)
+	assert.Equal(t, `Unknown entity type "k8s:workloads"`, issues[0].detail.message)
+	assert.Equal(t, []string{"k8s:cluster", "k8s:workload"}, issues[0].detail.fixPossibilities)
+	assert.Equal(t, position{line: 1, column: 23}, issues[0].detail.errorFrom)
+
+	issues = checkReferences("FETCH attributes(k8s.cluster.nam) FROM entities(k8s:workload)", entityTypes)
+	require.Len(t, issues, 1)
+	assert.Equal(t, severityWarning, issues[0].severity)
+	assert.Equal(t, []string{"k8s.cluster.name"}, issues[0].detail.fixPossibilities)
+}
@@ -0,0 +1,216 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package version
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cisco-open/fsoc/cmdkit/offline"
+)
+
+// releasesURL is the GitHub API endpoint that lists the fsoc releases
+const releasesURL = "https://api.github.com/repos/cisco-open/fsoc/releases"
+
+// Release channels
+const (
+	ChannelStable  = "stable"
+	ChannelPreview = "preview"
+)
+
+var releaseVersionRegexp = regexp.MustCompile(`^v?(\d+)\.(\d+)\.(\d+)(?:-([0-9A-Za-z.-]+))?`)
+
+// Release describes a GitHub release of fsoc
+type Release struct {
+	TagName    string         `json:"tag_name"`
+	Prerelease bool           `json:"prerelease"`
+	Draft      bool           `json:"draft"`
+	HTMLURL    string         `json:"html_url"`
+	Assets     []ReleaseAsset `json:"assets"`
+}
+
+// ReleaseAsset describes a file published with a release
+type ReleaseAsset struct {
+	Name        string `json:"name"`
+	DownloadURL string `json:"browser_download_url"`
+	Size        int64  `json:"size"`
+}
+
+// Version returns the release version, without the "v" prefix, e.g., 0.42.0
+func (r *Release) Version() string {
+	return strings.TrimPrefix(r.TagName, "v")
+}
+
+// Asset returns the release asset with the given name, or nil if there is none
+func (r *Release) Asset(name string) *ReleaseAsset {
+	for i := range r.Assets {
+		if r.Assets[i].Name == name {
+			return &r.Assets[i]
+		}
+	}
+	return nil
+}
+
+// GetLatestRelease returns the version of the latest fsoc release, e.g., 0.42.0
+func GetLatestRelease(timeout time.Duration) (string, error) {
+	release, err := getRelease(releasesURL, ChannelStable, timeout)
+	if err != nil {
+		return "", err
+	}
+	return release.Version(), nil
+}
+
+// GetChannelRelease returns the latest fsoc release in a channel: "stable" releases only or "preview",
+// which includes pre-releases
+func GetChannelRelease(channel string, timeout time.Duration) (*Release, error) {
+	return getRelease(releasesURL, channel, timeout)
+}
+
+func getRelease(url string, channel string, timeout time.Duration) (*Release, error) {
+	switch channel {
+	case ChannelStable:
+		// GitHub's latest release excludes drafts and pre-releases
+		var release Release
+		if err := getGitHubJSON(url+"/latest", timeout, &release); err != nil {
+			return nil, err
+		}
+		if !releaseVersionRegexp.MatchString(release.TagName) {
+			return nil, fmt.Errorf("unexpected release tag %q", release.TagName)
+		}
+		return &release, nil
+	case ChannelPreview:
+		var releases []Release
+		if err := getGitHubJSON(url+"?per_page=30", timeout, &releases); err != nil {
+			return nil, err
+		}
+		var latest *Release
+		for i := range releases {
+			r := &releases[i]
+			if r.Draft || !releaseVersionRegexp.MatchString(r.TagName) {
+				continue
+			}
+			if latest == nil || compareReleaseVersions(r.TagName, latest.TagName) > 0 {
+				latest = r
+			}
+		}
+		if latest == nil {
+			return nil, fmt.Errorf("no releases found")
+		}
+		return latest, nil
+	default:
+		return nil, fmt.Errorf("unknown release channel %q, must be %q or %q", channel, ChannelStable, ChannelPreview)
+	}
+}
+
+func getGitHubJSON(url string, timeout time.Duration, v any) error {
+	if err := offline.Check("Checking the fsoc releases"); err != nil {
+		return err
+	}
+	client := &http.Client{Timeout: timeout}
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to get the latest release: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to get the latest release: status %q", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to parse the latest release: %w", err)
+	}
+	return nil
+}
+
+// IsOlderThan returns true if this fsoc version is older than the given release version, comparing
+// the major, minor and patch numbers and the pre-release, if any
+func IsOlderThan(release string) bool {
+	return compareReleaseVersions(version.Version, release) < 0
+}
+
+// compareReleaseVersions compares two release versions, returning -1, 0 or 1. A pre-release is older
+// than the release with the same number, e.g., 0.42.0-rc1 < 0.42.0; versions that can't be parsed
+// compare as equal.
+func compareReleaseVersions(a, b string) int {
+	ma := releaseVersionRegexp.FindStringSubmatch(a)
+	mb := releaseVersionRegexp.FindStringSubmatch(b)
+	if ma == nil || mb == nil {
+		return 0
+	}
+	for i := 1; i <= 3; i++ {
+		na, _ := strconv.ParseUint(ma[i], 10, 32)
+		nb, _ := strconv.ParseUint(mb[i], 10, 32)
+		switch {
+		case na < nb:
+			return -1
+		case na > nb:
+			return 1
+		}
+	}
+	preA, preB := ma[4], mb[4]
+	switch {
+	case preA == preB:
+		return 0
+	case preA == "":
+		return 1
+	case preB == "":
+		return -1
+	}
+	return comparePrerelease(preA, preB)
+}
+
+// comparePrerelease compares pre-release identifiers as semver does: dot-separated fields are compared
+// numerically if both are numbers and lexically otherwise
+func comparePrerelease(a, b string) int {
+	fa, fb := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(fa) && i < len(fb); i++ {
+		na, errA := strconv.ParseUint(fa[i], 10, 64)
+		nb, errB := strconv.ParseUint(fb[i], 10, 64)
+		var c int
+		switch {
+		case errA == nil && errB == nil:
+			c = compareUints(na, nb)
+		case errA == nil:
+			c = -1 // numeric identifiers are lower than alphanumeric ones
+		case errB == nil:
+			c = 1
+		default:
+			c = strings.Compare(fa[i], fb[i])
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return compareUints(uint64(len(fa)), uint64(len(fb)))
+}
+
+func compareUints(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,67 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package version
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetRelease(t *testing.T) {
+	tag := "v0.42.1"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/latest":
+			_, _ = w.Write([]byte(`{"tag_name":"` + tag + `","name":"fsoc"}`))
+		case "/":
+			_, _ = w.Write([]byte(`[{"tag_name":"v0.43.0-rc.1","draft":true},{"tag_name":"v0.42.1"},{"tag_name":"v0.43.0-rc.0","prerelease":true}]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	release, err := getRelease(server.URL, ChannelStable, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "0.42.1", release.Version())
+
+	release, err = getRelease(server.URL, ChannelPreview, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "0.43.0-rc.0", release.Version())
+
+	tag = "nightly"
+	_, err = getRelease(server.URL, ChannelStable, time.Second)
+	assert.Error(t, err)
+	_, err = getRelease(server.URL, "beta", time.Second)
+	assert.Error(t, err)
+}
+
+func TestCompareReleaseVersions(t *testing.T) {
+	assert.Equal(t, -1, compareReleaseVersions("0.41.9", "0.42.0"))
+	assert.Equal(t, -1, compareReleaseVersions("0.42.0", "v0.42.1"))
+	assert.Equal(t, 0, compareReleaseVersions("0.42.1", "0.42.1"))
+	assert.Equal(t, 1, compareReleaseVersions("1.0.0", "0.42.1"))
+	assert.Equal(t, 0, compareReleaseVersions("1.0.0", "latest"))
+	assert.Equal(t, -1, compareReleaseVersions("0.42.0-rc.1", "0.42.0"))
+	assert.Equal(t, 1, compareReleaseVersions("0.42.0", "0.42.0-rc.1"))
+	assert.Equal(t, -1, compareReleaseVersions("0.42.0-rc.2", "0.42.0-rc.10"))
+	assert.Equal(t, -1, compareReleaseVersions("0.42.0-rc", "0.42.0-rc.1"))
+	assert.Equal(t, 0, compareReleaseVersions("0.0.0-0+local", "0.0.0-0"))
+}
@@ -0,0 +1,295 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package version
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+
+	"github.com/cisco-open/fsoc/cmd/config"
+	"github.com/cisco-open/fsoc/cmdkit/offline"
+	"github.com/cisco-open/fsoc/output"
+)
+
+const (
+	releaseCheckTimeout = 10 * time.Second
+	downloadTimeout     = 5 * time.Minute
+	maxChecksumsSize    = 1 << 20
+)
+
+// releaseSigningKey is the PEM-encoded public key of the cosign key pair that signs the checksums file
+// of the releases (see .goreleaser.yml). The maintainers generate the key pair and set the public key
+// here (see CONTRIBUTING.md); until then, self-update refuses to install releases.
+var releaseSigningKey = []byte(``)
+
+var selfUpdateCmd = &cobra.Command{
+	Use:   "self-update",
+	Short: "Update fsoc to the latest release",
+	Long: `Update fsoc to the latest release published on GitHub, replacing the running executable in place.
+
+The release binary for this platform is downloaded and its SHA-256 checksum is verified against the
+checksums file published with the release before the executable is replaced. The checksums file is
+trusted only if its signature verifies with the release signing key built into fsoc; the update is
+refused if fsoc has no signing key, or if the signature or the checksum is missing or doesn't match.
+
+The "stable" channel (default) considers only full releases, while "preview" includes pre-releases.
+Development builds are not updated unless --force is specified, which also reinstalls the latest release
+if fsoc is already up to date. Use "fsoc version --check" to check for an update without installing it.`,
+	Example: `  fsoc self-update
+  fsoc self-update --channel preview`,
+	Args:        cobra.NoArgs,
+	Run:         selfUpdate,
+	Annotations: map[string]string{config.AnnotationForConfigBypass: ""},
+}
+
+func init() {
+	addSelfUpdateFlags(selfUpdateCmd)
+}
+
+// NewSelfUpdateCmd returns the self-update command
+func NewSelfUpdateCmd() *cobra.Command {
+	return selfUpdateCmd
+}
+
+func addSelfUpdateFlags(cmd *cobra.Command) {
+	cmd.Flags().String("channel", ChannelStable, "Release channel to update from (stable, preview)")
+	cmd.Flags().Bool("force", false, "Update even if fsoc is up to date or is a development build")
+}
+
+func selfUpdate(cmd *cobra.Command, args []string) {
+	channel, _ := cmd.Flags().GetString("channel")
+	force, _ := cmd.Flags().GetBool("force")
+
+	if IsDev() && !force {
+		log.Fatalf("This is a development build of fsoc (%v); use --force to replace it with a release", GetVersionShort())
+	}
+
+	release, err := GetChannelRelease(channel, releaseCheckTimeout)
+	if err != nil {
+		log.Fatalf("Failed to check for updates: %v", err)
+	}
+	if !IsOlderThan(release.Version()) && !force {
+		output.PrintCmdStatus(cmd, fmt.Sprintf("fsoc is up to date (version %v, %v channel)\n", GetVersionShort(), channel))
+		return
+	}
+
+	exe, err := os.Executable()
+	if err == nil {
+		exe, err = filepath.EvalSymlinks(exe)
+	}
+	if err != nil {
+		log.Fatalf("Failed to locate the fsoc executable: %v", err)
+	}
+
+	output.PrintCmdStatus(cmd, fmt.Sprintf("Updating fsoc %v to %v\n", GetVersionShort(), release.Version()))
+	if err := installRelease(release, runtime.GOOS, runtime.GOARCH, exe); err != nil {
+		log.Fatalf("Failed to update fsoc: %v", err)
+	}
+	output.PrintCmdStatus(cmd, fmt.Sprintf("fsoc updated to version %v\n", release.Version()))
+}
+
+// releaseAssetName returns the name of the release binary for a platform, as published by goreleaser
+func releaseAssetName(goos, goarch string) string {
+	name := "fsoc-" + goos + "-" + goarch
+	if goos == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// installRelease downloads the release binary for a platform, verifies its checksum, using the checksums
+// file after verifying its signature, and replaces the executable at exe with it
+func installRelease(release *Release, goos, goarch, exe string) error {
+	if len(releaseSigningKey) == 0 {
+		return fmt.Errorf("this build of fsoc has no release signing key to verify the releases; download %v from %v instead", release.TagName, release.HTMLURL)
+	}
+	name := releaseAssetName(goos, goarch)
+	asset := release.Asset(name)
+	if asset == nil {
+		return fmt.Errorf("release %v has no binary for %v/%v", release.TagName, goos, goarch)
+	}
+	var checksumsAsset *ReleaseAsset
+	for i := range release.Assets {
+		if strings.HasSuffix(release.Assets[i].Name, "checksums.txt") {
+			checksumsAsset = &release.Assets[i]
+			break
+		}
+	}
+	if checksumsAsset == nil {
+		return fmt.Errorf("release %v has no checksums file, refusing to install an unverified binary", release.TagName)
+	}
+	signatureAsset := release.Asset(checksumsAsset.Name + ".sig")
+	if signatureAsset == nil {
+		return fmt.Errorf("release %v has no signature for its checksums file, refusing to install an unverified binary", release.TagName)
+	}
+
+	// get the expected checksum from the signed checksums file
+	client := &http.Client{Timeout: downloadTimeout}
+	checksums, err := downloadSmall(client, checksumsAsset.DownloadURL)
+	if err != nil {
+		return err
+	}
+	signature, err := downloadSmall(client, signatureAsset.DownloadURL)
+	if err != nil {
+		return err
+	}
+	if err := verifySignature(releaseSigningKey, checksums, signature); err != nil {
+		return fmt.Errorf("the checksums file of release %v is not signed by the fsoc release key: %w", release.TagName, err)
+	}
+	expected, err := findChecksum(bytes.NewReader(checksums), name)
+	if err != nil {
+		return err
+	}
+
+	// download into a temporary file next to the executable, so that it can be renamed over it
+	body, err := download(client, asset.DownloadURL)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	tmp, err := os.CreateTemp(filepath.Dir(exe), ".fsoc-update-*")
+	if err != nil {
+		return fmt.Errorf("failed to create a file next to %q (check permissions): %w", exe, err)
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+	hash := sha256.New()
+	_, err = io.Copy(io.MultiWriter(tmp, hash), body)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to download %q: %w", asset.DownloadURL, err)
+	}
+	if actual := hex.EncodeToString(hash.Sum(nil)); actual != expected {
+		return fmt.Errorf("checksum mismatch for %v: expected %v, got %v", name, expected, actual)
+	}
+	if err := os.Chmod(tmp.Name(), 0755); err != nil {
+		return err
+	}
+
+	return replaceExecutable(exe, tmp.Name(), goos)
+}
+
+func download(client *http.Client, url string) (io.ReadCloser, error) {
+	if err := offline.Check("Downloading fsoc"); err != nil {
+		return nil, err
+	}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %q: %w", url, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to download %q: status %q", url, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// downloadSmall downloads a small file, such as the checksums file or its signature, into memory
+func downloadSmall(client *http.Client, url string) ([]byte, error) {
+	body, err := download(client, url)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	data, err := io.ReadAll(io.LimitReader(body, maxChecksumsSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %q: %w", url, err)
+	}
+	if len(data) > maxChecksumsSize {
+		return nil, fmt.Errorf("failed to download %q: the file is larger than %d bytes", url, maxChecksumsSize)
+	}
+	return data, nil
+}
+
+// verifySignature verifies a signature created by "cosign sign-blob", which is a base64-encoded ECDSA
+// signature of the SHA-256 digest of the data, with a PEM-encoded public key
+func verifySignature(keyPEM []byte, data []byte, signature []byte) error {
+	block, _ := pem.Decode(keyPEM)
+	if block == nil || block.Type != "PUBLIC KEY" {
+		return fmt.Errorf("invalid public key")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("invalid public key: %w", err)
+	}
+	ecKey, ok := key.(*ecdsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("unsupported public key type %T", key)
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil {
+		return fmt.Errorf("invalid signature encoding: %w", err)
+	}
+	digest := sha256.Sum256(data)
+	if !ecdsa.VerifyASN1(ecKey, digest[:], sig) {
+		return fmt.Errorf("invalid signature")
+	}
+	return nil
+}
+
+// findChecksum returns the SHA-256 checksum of a file from a checksums file in the sha256sum format
+func findChecksum(r io.Reader, name string) (string, error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("failed to read the checksums file: %w", err)
+	}
+	return "", fmt.Errorf("no checksum found for %v", name)
+}
+
+// replaceExecutable replaces the executable with a new file. A running executable can't be
+// overwritten on Windows but can be renamed, so it is moved aside first; the old file is removed
+// on the next update.
+func replaceExecutable(exe, newFile, goos string) error {
+	if goos == "windows" {
+		old := exe + ".old"
+		_ = os.Remove(old)
+		if err := os.Rename(exe, old); err != nil {
+			return fmt.Errorf("failed to move %q aside: %w", exe, err)
+		}
+		if err := os.Rename(newFile, exe); err != nil {
+			_ = os.Rename(old, exe)
+			return fmt.Errorf("failed to replace %q: %w", exe, err)
+		}
+		return nil
+	}
+	if err := os.Rename(newFile, exe); err != nil {
+		return fmt.Errorf("failed to replace %q: %w", exe, err)
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package version
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindChecksum(t *testing.T) {
+	checksums := "abc123  fsoc-linux-amd64\nDEF456 *fsoc-windows-amd64.exe\n"
+	sum, err := findChecksum(strings.NewReader(checksums), "fsoc-windows-amd64.exe")
+	require.NoError(t, err)
+	assert.Equal(t, "def456", sum)
+	_, err = findChecksum(strings.NewReader(checksums), "fsoc-darwin-arm64")
+	assert.Error(t, err)
+}
+
+// newSigningKey returns a key pair like the ones created by "cosign generate-key-pair", with the
+// public key in PEM
+func newSigningKey(t *testing.T) (*ecdsa.PrivateKey, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	return key, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+// sign signs data like "cosign sign-blob"
+func sign(t *testing.T, key *ecdsa.PrivateKey, data string) string {
+	digest := sha256.Sum256([]byte(data))
+	sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	require.NoError(t, err)
+	return base64.StdEncoding.EncodeToString(sig)
+}
+
+func TestVerifySignature(t *testing.T) {
+	key, pub := newSigningKey(t)
+	_, otherPub := newSigningKey(t)
+	sig := []byte(sign(t, key, "data") + "\n")
+	assert.NoError(t, verifySignature(pub, []byte("data"), sig))
+	assert.Error(t, verifySignature(pub, []byte("other data"), sig))
+	assert.Error(t, verifySignature(otherPub, []byte("data"), sig))
+	assert.Error(t, verifySignature(pub, []byte("data"), []byte("not base64!")))
+	assert.Error(t, verifySignature([]byte("not a key"), []byte("data"), sig))
+}
+
+func TestInstallRelease(t *testing.T) {
+	key, pub := newSigningKey(t)
+	defer func(k []byte) { releaseSigningKey = k }(releaseSigningKey)
+	releaseSigningKey = pub
+
+	binary := []byte("#!/bin/sh\necho new fsoc\n")
+	hash := sha256.Sum256(binary)
+	checksums := hex.EncodeToString(hash[:]) + "  fsoc-linux-amd64\n"
+	signature := sign(t, key, checksums)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/fsoc-linux-amd64":
+			_, _ = w.Write(binary)
+		case "/checksums.txt":
+			_, _ = w.Write([]byte(checksums))
+		case "/checksums.txt.sig":
+			_, _ = w.Write([]byte(signature))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	release := &Release{TagName: "v0.42.1", Assets: []ReleaseAsset{
+		{Name: "fsoc-linux-amd64", DownloadURL: server.URL + "/fsoc-linux-amd64"},
+		{Name: "checksums.txt", DownloadURL: server.URL + "/checksums.txt"},
+		{Name: "checksums.txt.sig", DownloadURL: server.URL + "/checksums.txt.sig"},
+	}}
+
+	dir := t.TempDir()
+	exe := filepath.Join(dir, "fsoc")
+	require.NoError(t, os.WriteFile(exe, []byte("old"), 0755))
+
+	// no release signing key
+	releaseSigningKey = nil
+	assert.ErrorContains(t, installRelease(release, "linux", "amd64", exe), "no release signing key")
+	releaseSigningKey = pub
+
+	// missing binary for the platform
+	assert.ErrorContains(t, installRelease(release, "darwin", "arm64", exe), "no binary")
+
+	// checksum mismatch leaves the executable unchanged
+	checksums = fmt.Sprintf("%064d  fsoc-linux-amd64\n", 0)
+	signature = sign(t, key, checksums)
+	assert.ErrorContains(t, installRelease(release, "linux", "amd64", exe), "checksum mismatch")
+	content, _ := os.ReadFile(exe)
+	assert.Equal(t, "old", string(content))
+
+	// checksums that don't match the signature are not trusted
+	checksums = hex.EncodeToString(hash[:]) + "  fsoc-linux-amd64\n"
+	assert.ErrorContains(t, installRelease(release, "linux", "amd64", exe), "not signed")
+	content, _ = os.ReadFile(exe)
+	assert.Equal(t, "old", string(content))
+
+	signature = sign(t, key, checksums)
+	require.NoError(t, installRelease(release, "linux", "amd64", exe))
+	content, _ = os.ReadFile(exe)
+	assert.Equal(t, binary, content)
+	entries, _ := os.ReadDir(dir)
+	assert.Len(t, entries, 1, "temporary files should be removed")
+
+	// unverifiable releases
+	release.Assets = release.Assets[:2]
+	assert.ErrorContains(t, installRelease(release, "linux", "amd64", exe), "no signature")
+	release.Assets = release.Assets[:1]
+	assert.ErrorContains(t, installRelease(release, "linux", "amd64", exe), "no checksums file")
+}
+
+func TestReleaseAssetName(t *testing.T) {
+	assert.Equal(t, "fsoc-darwin-arm64", releaseAssetName("darwin", "arm64"))
+	assert.Equal(t, "fsoc-windows-amd64.exe", releaseAssetName("windows", "amd64"))
+}
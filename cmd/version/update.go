@@ -15,9 +15,6 @@
 package version
 
 import (
-	//"errors"
-
-	"github.com/apex/log"
 	"github.com/spf13/cobra"
 
 	"github.com/cisco-open/fsoc/cmd/config"
@@ -26,47 +23,13 @@ import (
 var updateCmd = &cobra.Command{
 	Use:         "update",
 	Short:       "Update fsoc",
-	Long:        `Update fsoc if a new version is available.`,
-	Run:         update,
+	Long:        `Update fsoc if a new version is available. This is the same as "fsoc self-update".`,
+	Args:        cobra.NoArgs,
+	Run:         selfUpdate,
 	Annotations: map[string]string{config.AnnotationForConfigBypass: ""},
 }
 
 func init() {
+	addSelfUpdateFlags(updateCmd)
 	versionCmd.AddCommand(updateCmd)
 }
-
-func update(cmd *cobra.Command, args []string) {
-	log.Fatalf("Update command is not implemented yet. Please check version manually and download an update if available.")
-}
-
-// func update(core *Core, pkg dependency.Installable) {
-// 	core.cfg.Log.Info("")
-// 	core.cfg.Log.ProgressReporter().SetProgress("checking updates")
-
-// 	err := pkg.Check()
-// 	if err == nil {
-// 		core.cfg.Log.ProgressReporter().Stop()
-// 		core.cfg.Log.Infof("cli is already up to date: %s", pkg.Version())
-
-// 		return
-// 	}
-
-// 	if !errors.As(err, &dependency.OldVersionError{}) {
-// 		core.cfg.Log.ProgressReporter().Stop()
-// 		core.cfg.Log.Errorf("error occurred during check: %s", err.Error())
-
-// 		return
-// 	}
-
-// 	core.cfg.Log.ProgressReporter().SetProgress("installing update")
-
-// 	if err = pkg.Install(core.fs); err != nil {
-// 		core.cfg.Log.ProgressReporter().Stop()
-// 		core.cfg.Log.Errorf("error occurred during update: %s", err.Error())
-
-// 		return
-// 	}
-
-// 	core.cfg.Log.ProgressReporter().Stop()
-// 	core.cfg.Log.Infof("cli updated successfully to version: %s\n", pkg.Version())
-// }
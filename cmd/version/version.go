@@ -17,6 +17,7 @@ package version
 import (
 	"fmt"
 
+	"github.com/apex/log"
 	"github.com/spf13/cobra"
 
 	"github.com/cisco-open/fsoc/cmd/config"
@@ -26,9 +27,15 @@ import (
 var versionCmd = &cobra.Command{
 	Use:   "version",
 	Short: "Print fsoc version",
-	Long:  `Print fsoc version`,
+	Long: `Print fsoc version.
+
+With --check, also check whether a newer release is available on GitHub in the selected channel
+("stable" or "preview", which includes pre-releases). Use "fsoc self-update" to install it.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		displayVersion(cmd)
+		if check, _ := cmd.Flags().GetBool("check"); check {
+			checkForUpdate(cmd)
+		}
 	},
 	Annotations: map[string]string{config.AnnotationForConfigBypass: ""},
 }
@@ -36,6 +43,8 @@ var versionCmd = &cobra.Command{
 func init() {
 	versionCmd.PersistentFlags().StringP("output", "o", "human", "Output format (human*, json, yaml)")
 	versionCmd.PersistentFlags().BoolP("detail", "d", false, "Show full version detail (incl. git info)")
+	versionCmd.Flags().Bool("check", false, "Check whether a newer release is available")
+	versionCmd.Flags().String("channel", ChannelStable, "Release channel to check with --check (stable, preview)")
 }
 
 func NewSubCmd() *cobra.Command {
@@ -64,3 +73,16 @@ func displayVersion(cmd *cobra.Command) {
 		Detail:  true,
 	})
 }
+
+func checkForUpdate(cmd *cobra.Command) {
+	channel, _ := cmd.Flags().GetString("channel")
+	release, err := GetChannelRelease(channel, releaseCheckTimeout)
+	if err != nil {
+		log.Fatalf("Failed to check for updates: %v", err)
+	}
+	if IsOlderThan(release.Version()) {
+		output.PrintCmdStatus(cmd, fmt.Sprintf("A newer fsoc version %v is available (%v channel); run \"fsoc self-update\" to install it\n", release.Version(), channel))
+		return
+	}
+	output.PrintCmdStatus(cmd, fmt.Sprintf("fsoc is up to date (latest %v release is %v)\n", channel, release.Version()))
+}
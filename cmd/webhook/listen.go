@@ -0,0 +1,308 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+
+	"github.com/cisco-open/fsoc/cmd/config"
+	"github.com/cisco-open/fsoc/cmdkit/interrupt"
+	"github.com/cisco-open/fsoc/output"
+)
+
+// maxBodySize limits the size of the request bodies that are read and displayed
+const maxBodySize = 10 << 20
+
+// receivedRequest is a request received by the listener
+type receivedRequest struct {
+	Time       string            `json:"time" yaml:"time"`
+	Method     string            `json:"method" yaml:"method"`
+	Path       string            `json:"path" yaml:"path"`
+	Query      string            `json:"query,omitempty" yaml:"query,omitempty"`
+	RemoteAddr string            `json:"remoteAddr" yaml:"remoteAddr"`
+	Headers    map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Body       any               `json:"body,omitempty" yaml:"body,omitempty"` // parsed if JSON, string otherwise
+	Truncated  bool              `json:"truncated,omitempty" yaml:"truncated,omitempty"`
+}
+
+func newCmdListen() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "listen",
+		Short: "Receive webhooks and display them",
+		Long: `Start an HTTP listener that receives webhooks, e.g., platform notifications, and displays each request
+(method, path, headers and body, with JSON bodies formatted) until interrupted with Ctrl+C or until --count
+requests are received. The listener responds to each request with the --status code.
+
+By default, the listener accepts connections on all network interfaces. For the platform to reach it, the
+listener must be reachable from the internet, e.g., through a tunnel such as "ngrok http 8080" or
+"ssh -R 80:localhost:8080 <relay-host>"; specify the tunnel's URL with --public-url to have it displayed as
+the URL to configure.
+
+With -o json or -o yaml, each request is printed as a JSON or YAML document, e.g., for processing with jq.`,
+		Example: `  # Listen on port 8080 and display all requests
+  fsoc webhook listen
+
+  # Listen only on the local interface, for requests to /hooks/alerts, and stop after the first one
+  fsoc webhook listen --address 127.0.0.1 --port 9000 --path /hooks/alerts --count 1
+
+  # Simulate a failing receiver, to test the retries of the sender
+  fsoc webhook listen --status 503
+
+  # Show the URL to configure when the listener is exposed through a tunnel
+  fsoc webhook listen --public-url https://1234abcd.ngrok.io`,
+		Args:        cobra.NoArgs,
+		Annotations: map[string]string{config.AnnotationForConfigBypass: ""},
+		Run:         listen,
+	}
+
+	cmd.Flags().String("address", "0.0.0.0", "The network address to listen on")
+	cmd.Flags().Int("port", 8080, "The port to listen on (0 selects a free port)")
+	cmd.Flags().String("path", "/", "Accept only requests to this path or below it; other requests get 404 Not Found")
+	cmd.Flags().Int("status", http.StatusOK, "The HTTP status code to respond with")
+	cmd.Flags().Int("count", 0, "Stop after receiving this many requests (0 for no limit)")
+	cmd.Flags().Bool("hide-headers", false, "Do not display the request headers")
+	cmd.Flags().String("public-url", "", "The public URL of the listener, e.g., of a tunnel, displayed as the URL to configure")
+
+	return cmd
+}
+
+func listen(cmd *cobra.Command, args []string) {
+	address, _ := cmd.Flags().GetString("address")
+	port, _ := cmd.Flags().GetInt("port")
+	path, _ := cmd.Flags().GetString("path")
+	status, _ := cmd.Flags().GetInt("status")
+	count, _ := cmd.Flags().GetInt("count")
+	hideHeaders, _ := cmd.Flags().GetBool("hide-headers")
+	publicURL, _ := cmd.Flags().GetString("public-url")
+	if status < 100 || status > 999 {
+		log.Fatalf("Invalid --status value %d", status)
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(address, fmt.Sprint(port)))
+	if err != nil {
+		log.Fatalf("Failed to listen on %s:%d: %v", address, port, err)
+	}
+	interrupt.Disable() // listening until interrupted is not a failure
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	r := &receiver{
+		path:        path,
+		status:      status,
+		count:       count,
+		hideHeaders: hideHeaders,
+		print:       func(req *receivedRequest) { printRequest(cmd, req) },
+		done:        stop,
+	}
+	server := &http.Server{Handler: r, ReadHeaderTimeout: 10 * time.Second}
+
+	// status messages go to stderr, to keep the output parseable in the machine-readable formats
+	listenPort := listener.Addr().(*net.TCPAddr).Port
+	fmt.Fprintf(os.Stderr, "Listening for webhooks on %s\n", strings.Join(listenURLs(address, listenPort, path), ", "))
+	if publicURL != "" {
+		fmt.Fprintf(os.Stderr, "Configure the webhook with the URL %s\n", strings.TrimSuffix(publicURL, "/")+strings.TrimSuffix(path, "/"))
+	}
+	fmt.Fprintln(os.Stderr, "Press Ctrl+C to stop")
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatalf("Listener failed: %v", err)
+	}
+	fmt.Fprintf(os.Stderr, "Received %d request(s)\n", r.received)
+}
+
+// receiver is the handler of the listener, recording and printing the requests
+type receiver struct {
+	path        string
+	status      int
+	count       int
+	hideHeaders bool
+	print       func(req *receivedRequest)
+	done        func() // called after count requests are received
+
+	mu       sync.Mutex
+	received int
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if !matchPath(r.path, req.URL.Path) {
+		http.NotFound(w, req)
+		return
+	}
+	received := readRequest(req, !r.hideHeaders)
+
+	// serialize printing, so that concurrent requests are not interleaved
+	r.mu.Lock()
+	if r.count > 0 && r.received >= r.count {
+		r.mu.Unlock()
+		http.Error(w, "listener is shutting down", http.StatusServiceUnavailable)
+		return
+	}
+	r.received++
+	r.print(received)
+	last := r.count > 0 && r.received >= r.count
+	r.mu.Unlock()
+
+	w.WriteHeader(r.status)
+	if last && r.done != nil {
+		r.done()
+	}
+}
+
+// matchPath returns true if the request path is the accepted path or below it
+func matchPath(accepted string, path string) bool {
+	accepted = strings.TrimSuffix(accepted, "/")
+	return accepted == "" || path == accepted || strings.HasPrefix(path, accepted+"/")
+}
+
+// readRequest reads a request, parsing its body if it is JSON
+func readRequest(req *http.Request, withHeaders bool) *receivedRequest {
+	received := &receivedRequest{
+		Time:       time.Now().UTC().Format(time.RFC3339Nano),
+		Method:     req.Method,
+		Path:       req.URL.Path,
+		Query:      req.URL.RawQuery,
+		RemoteAddr: req.RemoteAddr,
+	}
+	if withHeaders {
+		received.Headers = map[string]string{}
+		for name, values := range req.Header {
+			received.Headers[name] = strings.Join(values, ", ")
+		}
+	}
+
+	body, err := io.ReadAll(io.LimitReader(req.Body, maxBodySize+1))
+	if err != nil {
+		received.Body = fmt.Sprintf("<failed to read the body: %v>", err)
+		return received
+	}
+	if len(body) > maxBodySize {
+		body = body[:maxBodySize]
+		received.Truncated = true
+	}
+	if len(body) == 0 {
+		return received
+	}
+	var parsed any
+	if !received.Truncated && json.Unmarshal(body, &parsed) == nil {
+		received.Body = parsed
+	} else {
+		received.Body = string(body)
+	}
+	return received
+}
+
+// printRequest displays a request in the selected output format
+func printRequest(cmd *cobra.Command, req *receivedRequest) {
+	format, _ := cmd.Flags().GetString("output")
+	switch format {
+	case "json":
+		b, err := json.Marshal(req)
+		if err != nil {
+			log.Errorf("Failed to format the request: %v", err)
+			return
+		}
+		output.PrintCmdData(cmd, string(b)+"\n") // one request per line
+	case "yaml":
+		output.PrintCmdData(cmd, "---\n")
+		_ = output.PrintYaml(cmd, req)
+	default:
+		output.PrintCmdData(cmd, formatRequest(req))
+	}
+}
+
+// formatRequest returns the human-readable display of a request
+func formatRequest(req *receivedRequest) string {
+	var sb strings.Builder
+	target := req.Path
+	if req.Query != "" {
+		target += "?" + req.Query
+	}
+	fmt.Fprintf(&sb, "=== %s %s %s (from %s)\n", req.Time, req.Method, target, req.RemoteAddr)
+
+	names := make([]string, 0, len(req.Headers))
+	for name := range req.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(&sb, "%s: %s\n", name, req.Headers[name])
+	}
+
+	switch body := req.Body.(type) {
+	case nil:
+		sb.WriteString("\n(no body)\n")
+	case string:
+		sb.WriteString("\n" + body)
+		if !strings.HasSuffix(body, "\n") {
+			sb.WriteString("\n")
+		}
+	default:
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(body)
+		sb.WriteString("\n" + buf.String())
+	}
+	if req.Truncated {
+		fmt.Fprintf(&sb, "(body truncated to %d bytes)\n", maxBodySize)
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// listenURLs returns the URLs the listener can be reached at from this host
+func listenURLs(address string, port int, path string) []string {
+	hosts := []string{address}
+	if ip := net.ParseIP(address); address == "" || (ip != nil && ip.IsUnspecified()) {
+		hosts = []string{"localhost"}
+		if addrs, err := net.InterfaceAddrs(); err == nil {
+			for _, a := range addrs {
+				if ipNet, ok := a.(*net.IPNet); ok && ipNet.IP.To4() != nil && !ipNet.IP.IsLoopback() {
+					hosts = append(hosts, ipNet.IP.String())
+				}
+			}
+		}
+	}
+	urls := make([]string, len(hosts))
+	for i, host := range hosts {
+		urls[i] = fmt.Sprintf("http://%s%s", net.JoinHostPort(host, fmt.Sprint(port)), path)
+	}
+	return urls
+}
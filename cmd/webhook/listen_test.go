@@ -0,0 +1,90 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchPath(t *testing.T) {
+	assert.True(t, matchPath("/", "/anything"))
+	assert.True(t, matchPath("/hooks", "/hooks"))
+	assert.True(t, matchPath("/hooks/", "/hooks/alerts"))
+	assert.False(t, matchPath("/hooks", "/hooksx"))
+	assert.False(t, matchPath("/hooks", "/"))
+}
+
+func TestReceiver(t *testing.T) {
+	var printed []*receivedRequest
+	done := 0
+	r := &receiver{
+		path:   "/hooks",
+		status: http.StatusAccepted,
+		count:  2,
+		print:  func(req *receivedRequest) { printed = append(printed, req) },
+		done:   func() { done++ },
+	}
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/hooks/alerts?x=1", "application/json", strings.NewReader(`{"severity":"CRITICAL"}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	resp, err = http.Post(server.URL+"/other", "text/plain", strings.NewReader("ignored"))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, err = http.Post(server.URL+"/hooks", "text/plain", strings.NewReader("plain text"))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, 1, done, "done after count requests")
+
+	resp, err = http.Post(server.URL+"/hooks", "text/plain", strings.NewReader("too late"))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+
+	require.Len(t, printed, 2)
+	assert.Equal(t, "/hooks/alerts", printed[0].Path)
+	assert.Equal(t, "x=1", printed[0].Query)
+	assert.Equal(t, map[string]any{"severity": "CRITICAL"}, printed[0].Body)
+	assert.Equal(t, "application/json", printed[0].Headers["Content-Type"])
+	assert.Equal(t, "plain text", printed[1].Body)
+}
+
+func TestFormatRequest(t *testing.T) {
+	s := formatRequest(&receivedRequest{
+		Time:       "2023-05-01T10:00:00Z",
+		Method:     "POST",
+		Path:       "/hooks",
+		Query:      "a=b",
+		RemoteAddr: "10.0.0.1:5000",
+		Headers:    map[string]string{"X-B": "2", "Content-Type": "application/json"},
+		Body:       map[string]any{"id": "n1"},
+	})
+	assert.Equal(t, "=== 2023-05-01T10:00:00Z POST /hooks?a=b (from 10.0.0.1:5000)\nContent-Type: application/json\nX-B: 2\n\n{\n  \"id\": \"n1\"\n}\n\n", s)
+
+	s = formatRequest(&receivedRequest{Time: "t", Method: "GET", Path: "/", RemoteAddr: "r"})
+	assert.Contains(t, s, "(no body)")
+}
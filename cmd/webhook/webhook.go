@@ -0,0 +1,36 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package webhook provides commands that help with developing and debugging the webhooks and
+// notifications sent by the platform
+package webhook
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func NewSubCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "webhook",
+		Short: "Receive and inspect webhooks",
+		Long: `Receive the webhooks and notifications sent by the platform and display them, so that notification
+configurations can be debugged without deploying a service.`,
+		Example:          `  fsoc webhook listen --port 8080`,
+		TraverseChildren: true,
+	}
+
+	cmd.AddCommand(newCmdListen())
+
+	return cmd
+}
@@ -0,0 +1,93 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cmdline inspects the command line arguments ahead of their parsing by cobra, e.g., to find
+// the command to run before aliases are expanded and plugins are registered
+package cmdline
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// CommandIndex returns the index of the first argument that is not a flag of the root command or a
+// flag's value, which is the name of the command to run, or -1 if there is none. As for cobra, the
+// arguments after "--" are never a command.
+func CommandIndex(root *cobra.Command, args []string) int {
+	index := -1
+	scan(root, args, func(i int, flag *pflag.Flag, value string) bool {
+		if flag == nil {
+			index = i
+			return false
+		}
+		return true
+	})
+	return index
+}
+
+// FlagValue returns the value of a flag of the root command in the command line arguments, or "" if
+// it is not specified. Like the persistent flags, the flag may follow the command's name.
+func FlagValue(root *cobra.Command, args []string, name string) string {
+	result := ""
+	scan(root, args, func(i int, flag *pflag.Flag, value string) bool {
+		if flag != nil && flag.Name == name {
+			result = value // the last value wins, as when the flags are parsed
+		}
+		return true
+	})
+	return result
+}
+
+// scan calls fn for each flag of the root command in the arguments, with its value, and for each
+// other argument, with a nil flag, until fn returns false or "--" is reached. Unknown flags are
+// assumed not to take a value.
+func scan(root *cobra.Command, args []string, fn func(i int, flag *pflag.Flag, value string) bool) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			if !fn(i, nil, arg) {
+				return
+			}
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		var flag *pflag.Flag
+		switch {
+		case strings.HasPrefix(arg, "--"):
+			if flag = root.PersistentFlags().Lookup(name); flag == nil {
+				flag = root.Flags().Lookup(name)
+			}
+		case len(name) == 1:
+			if flag = root.PersistentFlags().ShorthandLookup(name); flag == nil {
+				flag = root.Flags().ShorthandLookup(name)
+			}
+		}
+		if flag == nil {
+			continue // unknown flag or shorthand with its value attached, e.g., -ojson
+		}
+		if !hasValue && flag.NoOptDefVal == "" && i+1 < len(args) {
+			i++
+			value = args[i]
+		}
+		if !fn(i, flag, value) {
+			return
+		}
+	}
+}
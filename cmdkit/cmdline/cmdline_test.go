@@ -0,0 +1,74 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmdline
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func testRoot() *cobra.Command {
+	root := &cobra.Command{Use: "fsoc"}
+	root.PersistentFlags().StringP("output", "o", "", "")
+	root.PersistentFlags().String("config", "", "")
+	root.PersistentFlags().Bool("quiet", false, "")
+	root.Flags().Bool("version", false, "")
+	return root
+}
+
+func TestCommandIndex(t *testing.T) {
+	root := testRoot()
+	for _, tc := range []struct {
+		args  []string
+		index int
+	}{
+		{[]string{"hello", "world"}, 0},
+		{[]string{"-o", "json", "hello"}, 2},
+		{[]string{"-o=json", "hello"}, 1},
+		{[]string{"-ojson", "hello"}, 1},
+		{[]string{"--output=json", "--quiet", "hello"}, 2},
+		{[]string{"--quiet=false", "hello"}, 1},
+		{[]string{"--version", "hello"}, 1},
+		{[]string{"--unknown", "hello"}, 1},
+		{[]string{"-", "hello"}, 0},
+		{[]string{"--output", "json"}, -1},
+		{[]string{"--output"}, -1},
+		{[]string{"--", "hello"}, -1},
+		{[]string{"--quiet", "--", "hello"}, -1},
+		{[]string{}, -1},
+	} {
+		assert.Equal(t, tc.index, CommandIndex(root, tc.args), tc.args)
+	}
+}
+
+func TestFlagValue(t *testing.T) {
+	root := testRoot()
+	for _, tc := range []struct {
+		args  []string
+		value string
+	}{
+		{[]string{"--config", "a.yaml", "hello"}, "a.yaml"},
+		{[]string{"--config=a.yaml", "hello"}, "a.yaml"},
+		{[]string{"hello", "--config", "a.yaml"}, "a.yaml"},
+		{[]string{"--config", "a.yaml", "--config", "b.yaml"}, "b.yaml"},
+		{[]string{"-o", "--config", "hello"}, ""},
+		{[]string{"hello", "--", "--config", "a.yaml"}, ""},
+		{[]string{"hello"}, ""},
+	} {
+		assert.Equal(t, tc.value, FlagValue(root, tc.args, "config"), tc.args)
+	}
+}
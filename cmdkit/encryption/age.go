@@ -0,0 +1,310 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package encryption encrypts and decrypts files in the age format (https://age-encryption.org/v1),
+// either to X25519 recipients ("age1...") or with a passphrase, so that files written by fsoc can
+// be decrypted with the age tools and vice versa. The interoperability is tested with the age test
+// vectors (testdata/testkit) and with files created by the age command-line tool (testdata/agecli).
+package encryption
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	ageIntro       = "age-encryption.org/v1\n"
+	fileKeySize    = 16
+	streamNonceLen = 16
+	chunkSize      = 64 * 1024
+	stanzaColumns  = 64
+)
+
+// ErrNoIdentity is returned when none of the identities can decrypt a file
+var ErrNoIdentity = errors.New("no identity matched the recipients of the encrypted file")
+
+// Recipient is a public key or a passphrase that a file is encrypted to
+type Recipient interface {
+	wrap(fileKey []byte) (*stanza, error)
+}
+
+// Identity is a private key or a passphrase that decrypts a file encrypted to the matching recipient
+type Identity interface {
+	// unwrap returns the file key from the stanzas, or errIncorrectIdentity if the stanzas are not for it
+	unwrap(stanzas []*stanza) ([]byte, error)
+}
+
+var errIncorrectIdentity = errors.New("incorrect identity for recipient block")
+
+// stanza is a recipient block in the header of an encrypted file
+type stanza struct {
+	Type string
+	Args []string
+	Body []byte
+}
+
+// IsEncrypted returns true if the data is a file encrypted in the age format
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(ageIntro))
+}
+
+// Encrypt encrypts the plaintext to one or more recipients
+func Encrypt(plaintext []byte, recipients ...Recipient) ([]byte, error) {
+	if len(recipients) == 0 {
+		return nil, errors.New("no recipients specified")
+	}
+	fileKey := make([]byte, fileKeySize)
+	if _, err := rand.Read(fileKey); err != nil {
+		return nil, err
+	}
+
+	var stanzas []*stanza
+	for _, r := range recipients {
+		s, err := r.wrap(fileKey)
+		if err != nil {
+			return nil, err
+		}
+		if s.Type == scryptStanzaType && len(recipients) > 1 {
+			return nil, errors.New("a passphrase cannot be combined with other recipients")
+		}
+		stanzas = append(stanzas, s)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(ageIntro)
+	for _, s := range stanzas {
+		writeStanza(&buf, s)
+	}
+	buf.WriteString("---")
+	buf.WriteString(" " + b64.EncodeToString(headerMAC(fileKey, buf.Bytes())) + "\n")
+
+	nonce := make([]byte, streamNonceLen)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	buf.Write(nonce)
+	payload, err := sealPayload(streamKey(fileKey, nonce), plaintext)
+	if err != nil {
+		return nil, err
+	}
+	buf.Write(payload)
+	return buf.Bytes(), nil
+}
+
+// Decrypt decrypts a file encrypted to one of the identities
+func Decrypt(ciphertext []byte, identities ...Identity) ([]byte, error) {
+	stanzas, headerLen, macLen, mac, err := parseHeader(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+
+	var fileKey []byte
+	for _, id := range identities {
+		fileKey, err = id.unwrap(stanzas)
+		if errors.Is(err, errIncorrectIdentity) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		break
+	}
+	if fileKey == nil {
+		return nil, ErrNoIdentity
+	}
+
+	if !hmac.Equal(headerMAC(fileKey, ciphertext[:macLen]), mac) {
+		return nil, errors.New("bad header MAC")
+	}
+	rest := ciphertext[headerLen:]
+	if len(rest) < streamNonceLen {
+		return nil, errors.New("missing payload nonce")
+	}
+	return openPayload(streamKey(fileKey, rest[:streamNonceLen]), rest[streamNonceLen:])
+}
+
+// b64 is the encoding of the header fields, which must be canonical: without padding or unused bits
+var b64 = base64.RawStdEncoding.Strict()
+
+func writeStanza(buf *bytes.Buffer, s *stanza) {
+	buf.WriteString("-> " + s.Type)
+	for _, a := range s.Args {
+		buf.WriteString(" " + a)
+	}
+	buf.WriteByte('\n')
+	body := b64.EncodeToString(s.Body)
+	for len(body) >= stanzaColumns {
+		buf.WriteString(body[:stanzaColumns] + "\n")
+		body = body[stanzaColumns:]
+	}
+	buf.WriteString(body + "\n") // the last line is always shorter than a full line, possibly empty
+}
+
+// parseHeader parses the header of an encrypted file, returning its stanzas, the length of the header,
+// the length of the header part covered by the MAC and the MAC
+func parseHeader(data []byte) (stanzas []*stanza, headerLen int, macLen int, mac []byte, err error) {
+	if !IsEncrypted(data) {
+		return nil, 0, 0, nil, errors.New("not an age encrypted file")
+	}
+	pos := len(ageIntro)
+	nextLine := func() (string, error) {
+		i := bytes.IndexByte(data[pos:], '\n')
+		if i < 0 {
+			return "", errors.New("truncated header")
+		}
+		line := string(data[pos : pos+i])
+		pos += i + 1
+		return line, nil
+	}
+
+	for {
+		lineStart := pos
+		line, err := nextLine()
+		if err != nil {
+			return nil, 0, 0, nil, err
+		}
+		if strings.HasPrefix(line, "--- ") {
+			mac, err = b64.DecodeString(line[4:])
+			if err != nil || len(mac) != sha256.Size {
+				return nil, 0, 0, nil, errors.New("malformed header MAC")
+			}
+			return stanzas, pos, lineStart + 3, mac, nil
+		}
+		if !strings.HasPrefix(line, "-> ") {
+			return nil, 0, 0, nil, fmt.Errorf("malformed header line %q", line)
+		}
+		fields := strings.Split(line[3:], " ")
+		for _, f := range fields {
+			if !isHeaderArgument(f) {
+				return nil, 0, 0, nil, errors.New("malformed recipient block")
+			}
+		}
+		s := &stanza{Type: fields[0], Args: fields[1:]}
+		for {
+			bodyLine, err := nextLine()
+			if err != nil {
+				return nil, 0, 0, nil, err
+			}
+			b, err := b64.DecodeString(bodyLine)
+			if err != nil || len(bodyLine) > stanzaColumns {
+				return nil, 0, 0, nil, errors.New("malformed recipient block body")
+			}
+			s.Body = append(s.Body, b...)
+			if len(bodyLine) < stanzaColumns {
+				break
+			}
+		}
+		stanzas = append(stanzas, s)
+	}
+}
+
+// isHeaderArgument returns true if s is a valid stanza type or argument: a non-empty string of
+// visible ASCII characters
+func isHeaderArgument(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x21 || s[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func headerMAC(fileKey []byte, header []byte) []byte {
+	h := hmac.New(sha256.New, hkdfKey(fileKey, nil, "header"))
+	h.Write(header)
+	return h.Sum(nil)
+}
+
+func streamKey(fileKey []byte, nonce []byte) []byte {
+	return hkdfKey(fileKey, nonce, "payload")
+}
+
+func hkdfKey(secret []byte, salt []byte, info string) []byte {
+	key := make([]byte, chacha20poly1305.KeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, secret, salt, []byte(info)), key); err != nil {
+		panic("hkdf: " + err.Error()) // cannot happen for a 32 byte key
+	}
+	return key
+}
+
+// chunkNonce returns the nonce of a payload chunk: an 11 byte big-endian counter and a last chunk flag
+func chunkNonce(counter uint64, last bool) []byte {
+	nonce := make([]byte, chacha20poly1305.NonceSize)
+	binary.BigEndian.PutUint64(nonce[3:11], counter)
+	if last {
+		nonce[11] = 1
+	}
+	return nonce
+}
+
+func sealPayload(key []byte, plaintext []byte) ([]byte, error) {
+	aead, err := chacha20poly1305.New(key)
+	if err != nil {
+		return nil, err
+	}
+	var out []byte
+	for counter := uint64(0); ; counter++ {
+		n := len(plaintext)
+		if n > chunkSize {
+			n = chunkSize
+		}
+		last := n == len(plaintext)
+		out = aead.Seal(out, chunkNonce(counter, last), plaintext[:n], nil)
+		plaintext = plaintext[n:]
+		if last {
+			return out, nil
+		}
+	}
+}
+
+func openPayload(key []byte, payload []byte) ([]byte, error) {
+	aead, err := chacha20poly1305.New(key)
+	if err != nil {
+		return nil, err
+	}
+	encChunkSize := chunkSize + aead.Overhead()
+	out := make([]byte, 0, len(payload))
+	for counter := uint64(0); ; counter++ {
+		n := len(payload)
+		if n > encChunkSize {
+			n = encChunkSize
+		}
+		last := n == len(payload)
+		out, err = aead.Open(out, chunkNonce(counter, last), payload[:n], nil)
+		if err != nil {
+			return nil, errors.New("failed to decrypt the payload: the file is corrupted or truncated")
+		}
+		if last {
+			if n == aead.Overhead() && counter > 0 {
+				return nil, errors.New("last payload chunk is empty")
+			}
+			return out, nil
+		}
+		payload = payload[n:]
+	}
+}
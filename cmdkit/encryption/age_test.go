@@ -0,0 +1,158 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encryption
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// secret key of 32 0x42 bytes
+const testSecretKey = "AGE-SECRET-KEY-1GFPYYSJZGFPYYSJZGFPYYSJZGFPYYSJZGFPYYSJZGFPYYSJZGFPQ4EGAEX"
+
+func TestBech32(t *testing.T) {
+	// valid strings from BIP 173
+	for _, s := range []string{"A12UEL5L", "abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxw", "split1checkupstagehandshakeupstreamerranterredcaperred2y9e3w"} {
+		hrp, data, err := bech32Decode(s)
+		require.NoError(t, err, s)
+		encoded, err := bech32Encode(hrp, data)
+		require.NoError(t, err, s)
+		assert.Equal(t, strings.ToLower(s), encoded)
+	}
+	// invalid strings from BIP 173
+	for _, s := range []string{"pzry9x0s0muk", "1pzry9x0s0muk", "x1b4n0q5v", "li1dgmt3", "A1G7SGD8", "10a06t8", "1qzzfhee", "a12UEL5L"} {
+		_, _, err := bech32Decode(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	id, err := ParseIdentity(testSecretKey)
+	require.NoError(t, err)
+	assert.Equal(t, testSecretKey, id.String())
+	testPublicKey := id.Recipient().String()
+	assert.True(t, strings.HasPrefix(testPublicKey, "age1"))
+
+	r, err := ParseRecipient(testPublicKey)
+	require.NoError(t, err)
+	assert.Equal(t, testPublicKey, r.String())
+
+	_, err = ParseRecipient(testPublicKey[:len(testPublicKey)-1] + "q")
+	assert.Error(t, err, "bad checksum")
+	_, err = ParseRecipient(testSecretKey)
+	assert.Error(t, err, "secret key is not a recipient")
+	_, err = ParseIdentity(testPublicKey)
+	assert.Error(t, err, "public key is not an identity")
+}
+
+func TestParseIdentities(t *testing.T) {
+	ids, err := ParseIdentities(strings.NewReader("# created: 2023-05-01\n\n" + testSecretKey + "\n"))
+	require.NoError(t, err)
+	assert.Len(t, ids, 1)
+
+	_, err = ParseIdentities(strings.NewReader("# nothing here\n"))
+	assert.Error(t, err)
+	_, err = ParseIdentities(strings.NewReader("not-a-key\n"))
+	assert.ErrorContains(t, err, "line 1")
+}
+
+func TestEncryptDecryptX25519(t *testing.T) {
+	alice, err := GenerateIdentity()
+	require.NoError(t, err)
+	bob, err := ParseIdentity(testSecretKey)
+	require.NoError(t, err)
+	eve, err := GenerateIdentity()
+	require.NoError(t, err)
+
+	for _, size := range []int{0, 1, chunkSize - 1, chunkSize, chunkSize + 1, 3*chunkSize + 17} {
+		plaintext := bytes.Repeat([]byte("fsoc"), size/4+1)[:size]
+		ciphertext, err := Encrypt(plaintext, alice.Recipient(), bob.Recipient())
+		require.NoError(t, err)
+		assert.True(t, IsEncrypted(ciphertext))
+
+		for _, id := range []Identity{alice, bob} {
+			decrypted, err := Decrypt(ciphertext, eve, id)
+			require.NoError(t, err, "size %d", size)
+			assert.Equal(t, plaintext, decrypted, "size %d", size)
+		}
+		_, err = Decrypt(ciphertext, eve)
+		assert.ErrorIs(t, err, ErrNoIdentity)
+	}
+}
+
+func TestEncryptDecryptPassphrase(t *testing.T) {
+	r, err := NewScryptRecipient("correct horse")
+	require.NoError(t, err)
+	r.workFactor = 10 // fast enough for tests
+
+	ciphertext, err := Encrypt([]byte(`{"name":"dark"}`), r)
+	require.NoError(t, err)
+	assert.Contains(t, string(ciphertext), "\n-> scrypt ")
+
+	decrypted, err := Decrypt(ciphertext, NewScryptIdentity("correct horse"))
+	require.NoError(t, err)
+	assert.Equal(t, `{"name":"dark"}`, string(decrypted))
+
+	_, err = Decrypt(ciphertext, NewScryptIdentity("wrong horse"))
+	assert.ErrorContains(t, err, "incorrect passphrase")
+
+	id, err := GenerateIdentity()
+	require.NoError(t, err)
+	_, err = Encrypt([]byte("x"), r, id.Recipient())
+	assert.Error(t, err, "passphrase cannot be combined with other recipients")
+	_, err = NewScryptRecipient("")
+	assert.Error(t, err)
+}
+
+func TestDecryptTampered(t *testing.T) {
+	id, err := GenerateIdentity()
+	require.NoError(t, err)
+	ciphertext, err := Encrypt([]byte("secret tenant configuration"), id.Recipient())
+	require.NoError(t, err)
+
+	payload := append([]byte{}, ciphertext...)
+	payload[len(payload)-1] ^= 1
+	_, err = Decrypt(payload, id)
+	assert.Error(t, err, "modified payload")
+
+	truncated := ciphertext[:len(ciphertext)-5]
+	_, err = Decrypt(truncated, id)
+	assert.Error(t, err, "truncated payload")
+
+	header := bytes.Replace(ciphertext, []byte("-> X25519 "), []byte("-> X25519 extra "), 1)
+	_, err = Decrypt(header, id)
+	assert.Error(t, err, "modified header")
+
+	_, err = Decrypt([]byte(`{"name":"plain"}`), id)
+	assert.Error(t, err, "not encrypted")
+}
+
+func TestStanzaBodyLines(t *testing.T) {
+	// bodies are wrapped at 64 columns, with a final short (possibly empty) line
+	for _, size := range []int{0, 32, 48, 96, 100} {
+		var buf bytes.Buffer
+		buf.WriteString(ageIntro)
+		writeStanza(&buf, &stanza{Type: "test", Args: []string{"a"}, Body: bytes.Repeat([]byte{7}, size)})
+		buf.WriteString("--- " + b64.EncodeToString(make([]byte, 32)) + "\n")
+		stanzas, _, _, _, err := parseHeader(buf.Bytes())
+		require.NoError(t, err, "size %d", size)
+		require.Len(t, stanzas, 1)
+		assert.Len(t, stanzas[0].Body, size)
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encryption
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// PassphraseEnvVar is the environment variable with the passphrase for non-interactive use
+const PassphraseEnvVar = "FSOC_PASSPHRASE"
+
+// AddEncryptFlags adds the flags that select the recipients to encrypt to
+func AddEncryptFlags(cmd *cobra.Command) {
+	cmd.Flags().StringArray("recipient", nil, "Encrypt to an age public key (age1...). Can be repeated")
+	cmd.Flags().String("recipients-file", "", "Encrypt to the age public keys listed in a file, one per line")
+	cmd.Flags().Bool("passphrase", false, fmt.Sprintf("Encrypt with a passphrase, prompted for or taken from the %s environment variable", PassphraseEnvVar))
+	cmd.MarkFlagsMutuallyExclusive("passphrase", "recipient")
+	cmd.MarkFlagsMutuallyExclusive("passphrase", "recipients-file")
+}
+
+// AddDecryptFlags adds the flags that select the identities to decrypt with
+func AddDecryptFlags(cmd *cobra.Command) {
+	cmd.Flags().StringArray("identity", nil, fmt.Sprintf("An age identity file with the secret keys to decrypt with. Can be repeated. Without it, a passphrase is prompted for or taken from the %s environment variable", PassphraseEnvVar))
+}
+
+// Recipients returns the recipients selected by the flags added with AddEncryptFlags, or nil if
+// encryption was not requested
+func Recipients(cmd *cobra.Command) ([]Recipient, error) {
+	var recipients []Recipient
+	if usePassphrase, _ := cmd.Flags().GetBool("passphrase"); usePassphrase {
+		passphrase, err := readPassphrase(true)
+		if err != nil {
+			return nil, err
+		}
+		r, err := NewScryptRecipient(passphrase)
+		if err != nil {
+			return nil, err
+		}
+		return []Recipient{r}, nil
+	}
+	keys, _ := cmd.Flags().GetStringArray("recipient")
+	for _, key := range keys {
+		r, err := ParseRecipient(key)
+		if err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, r)
+	}
+	if path, _ := cmd.Flags().GetString("recipients-file"); path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open the recipients file: %w", err)
+		}
+		defer f.Close()
+		fileRecipients, err := ParseRecipients(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the recipients file %q: %v", path, err)
+		}
+		recipients = append(recipients, fileRecipients...)
+	}
+	return recipients, nil
+}
+
+// Identities returns the identities selected by the flags added with AddDecryptFlags; without
+// identity files, the passphrase is used
+func Identities(cmd *cobra.Command) ([]Identity, error) {
+	paths, _ := cmd.Flags().GetStringArray("identity")
+	if len(paths) == 0 {
+		passphrase, err := readPassphrase(false)
+		if err != nil {
+			return nil, err
+		}
+		return []Identity{NewScryptIdentity(passphrase)}, nil
+	}
+	var ids []Identity
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open the identity file: %w", err)
+		}
+		fileIds, err := ParseIdentities(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the identity file %q: %v", path, err)
+		}
+		ids = append(ids, fileIds...)
+	}
+	return ids, nil
+}
+
+// readPassphrase returns the passphrase from the environment or, in interactive runs, from the user;
+// a new passphrase is asked for twice
+func readPassphrase(confirm bool) (string, error) {
+	if passphrase, ok := os.LookupEnv(PassphraseEnvVar); ok {
+		return passphrase, nil
+	}
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("a passphrase is required: set the %s environment variable or run in a terminal", PassphraseEnvVar)
+	}
+	fmt.Fprint(os.Stderr, "Passphrase: ")
+	passphrase, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	if confirm {
+		fmt.Fprint(os.Stderr, "Confirm passphrase: ")
+		again, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", err
+		}
+		if string(again) != string(passphrase) {
+			return "", errors.New("the passphrases do not match")
+		}
+	}
+	return string(passphrase), nil
+}
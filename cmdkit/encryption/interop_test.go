@@ -0,0 +1,164 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encryption
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The vectors in testdata/testkit are the age test vectors of the C2SP project
+// (https://github.com/C2SP/CCTV/tree/main/age), without the ASCII armor and post-quantum
+// hybrid ones, which are not supported. The files in testdata/agecli were created with the
+// age v1.1.1 command-line tool; the passphrase of passphrase.age is agecliPassphrase.
+
+const agecliPassphrase = "correct horse battery staple"
+
+// testkitVector is a test vector: the textual header and the encrypted file
+type testkitVector struct {
+	expect      string
+	payload     string
+	identities  []Identity
+	unsupported bool // the vector uses a feature that is not implemented
+	file        []byte
+}
+
+func readTestkitVector(t *testing.T, path string) *testkitVector {
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	header, file, found := bytes.Cut(data, []byte("\n\n"))
+	require.True(t, found, "missing the end of the header")
+
+	v := &testkitVector{file: file}
+	compressed := false
+	scanner := bufio.NewScanner(bytes.NewReader(header))
+	for scanner.Scan() {
+		key, value, _ := strings.Cut(scanner.Text(), ": ")
+		switch key {
+		case "expect":
+			v.expect = value
+		case "payload":
+			v.payload = value
+		case "identity":
+			id, err := ParseIdentity(value)
+			if err != nil {
+				v.unsupported = true
+				continue
+			}
+			v.identities = append(v.identities, id)
+		case "passphrase":
+			v.identities = append(v.identities, NewScryptIdentity(value))
+		case "armored":
+			v.unsupported = true
+		case "compressed":
+			require.Equal(t, "zlib", value)
+			compressed = true
+		}
+	}
+	if compressed {
+		r, err := zlib.NewReader(bytes.NewReader(file))
+		require.NoError(t, err)
+		v.file, err = io.ReadAll(r)
+		require.NoError(t, err)
+	}
+	return v
+}
+
+func TestTestkitVectors(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("testdata", "testkit", "*"))
+	require.NoError(t, err)
+	require.NotEmpty(t, paths)
+
+	for _, path := range paths {
+		path := path
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			v := readTestkitVector(t, path)
+			if v.unsupported {
+				t.Skip("unsupported feature")
+			}
+			plaintext, err := Decrypt(v.file, v.identities...)
+			switch v.expect {
+			case "success":
+				require.NoError(t, err)
+				sum := sha256.Sum256(plaintext)
+				assert.Equal(t, v.payload, hex.EncodeToString(sum[:]))
+			case "no match":
+				assert.ErrorIs(t, err, ErrNoIdentity)
+			default:
+				// header, HMAC and payload failures; no partial plaintext is returned
+				assert.Error(t, err, v.expect)
+				assert.Nil(t, plaintext)
+			}
+		})
+	}
+}
+
+func TestDecryptAgeCLIFiles(t *testing.T) {
+	plaintext, err := os.ReadFile(filepath.Join("testdata", "agecli", "plaintext.txt"))
+	require.NoError(t, err)
+	f, err := os.Open(filepath.Join("testdata", "agecli", "identity.txt"))
+	require.NoError(t, err)
+	defer f.Close()
+	identities, err := ParseIdentities(f)
+	require.NoError(t, err)
+
+	for name, ids := range map[string][]Identity{
+		"x25519.age":     identities,
+		"multiple.age":   identities,
+		"passphrase.age": {NewScryptIdentity(agecliPassphrase)},
+	} {
+		data, err := os.ReadFile(filepath.Join("testdata", "agecli", name))
+		require.NoError(t, err)
+		require.True(t, IsEncrypted(data), name)
+		decrypted, err := Decrypt(data, ids...)
+		require.NoError(t, err, name)
+		assert.Equal(t, plaintext, decrypted, name)
+	}
+}
+
+// TestAgeCLIDecrypt checks that the age command-line tool decrypts the files encrypted by this
+// package; it is skipped if the tool is not installed
+func TestAgeCLIDecrypt(t *testing.T) {
+	agePath, err := exec.LookPath("age")
+	if err != nil {
+		t.Skip("the age command-line tool is not installed")
+	}
+	id, err := GenerateIdentity()
+	require.NoError(t, err)
+	dir := t.TempDir()
+	identityPath := filepath.Join(dir, "identity.txt")
+	require.NoError(t, os.WriteFile(identityPath, []byte(id.String()+"\n"), 0600))
+
+	plaintext := bytes.Repeat([]byte("fsoc"), chunkSize/2+3)
+	ciphertext, err := Encrypt(plaintext, id.Recipient())
+	require.NoError(t, err)
+	cmd := exec.Command(agePath, "--decrypt", "--identity", identityPath)
+	cmd.Stdin = bytes.NewReader(ciphertext)
+	decrypted, err := cmd.Output()
+	require.NoError(t, err)
+	assert.Equal(t, plaintext, decrypted)
+}
@@ -0,0 +1,300 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encryption
+
+import (
+	"bufio"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	x25519StanzaType = "X25519"
+	x25519Label      = "age-encryption.org/v1/X25519"
+	recipientHRP     = "age"
+	identityHRP      = "AGE-SECRET-KEY-"
+
+	scryptStanzaType    = "scrypt"
+	scryptLabel         = "age-encryption.org/v1/scrypt"
+	scryptSaltSize      = 16
+	scryptWorkFactor    = 18 // log2 of the scrypt N parameter, as used by age
+	scryptMaxWorkFactor = 22
+)
+
+// X25519Recipient is an age public key
+type X25519Recipient struct {
+	publicKey []byte
+}
+
+// X25519Identity is an age private key
+type X25519Identity struct {
+	secretKey, publicKey []byte
+}
+
+// ParseRecipient parses an age public key ("age1...")
+func ParseRecipient(s string) (*X25519Recipient, error) {
+	hrp, key, err := bech32Decode(s)
+	if err != nil {
+		return nil, fmt.Errorf("malformed recipient %q: %v", s, err)
+	}
+	if hrp != recipientHRP || len(key) != curve25519.PointSize {
+		return nil, fmt.Errorf("malformed recipient %q: not an age public key", s)
+	}
+	return &X25519Recipient{publicKey: key}, nil
+}
+
+// String returns the age public key ("age1...")
+func (r *X25519Recipient) String() string {
+	s, _ := bech32Encode(recipientHRP, r.publicKey)
+	return s
+}
+
+func (r *X25519Recipient) wrap(fileKey []byte) (*stanza, error) {
+	ephemeral := make([]byte, curve25519.ScalarSize)
+	if _, err := rand.Read(ephemeral); err != nil {
+		return nil, err
+	}
+	share, err := curve25519.X25519(ephemeral, curve25519.Basepoint)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := curve25519.X25519(ephemeral, r.publicKey)
+	if err != nil {
+		return nil, err
+	}
+	salt := append(append([]byte{}, share...), r.publicKey...)
+	body, err := aeadSeal(hkdfKey(shared, salt, x25519Label), fileKey)
+	if err != nil {
+		return nil, err
+	}
+	return &stanza{Type: x25519StanzaType, Args: []string{b64.EncodeToString(share)}, Body: body}, nil
+}
+
+// ParseIdentity parses an age private key ("AGE-SECRET-KEY-1...")
+func ParseIdentity(s string) (*X25519Identity, error) {
+	hrp, key, err := bech32Decode(s)
+	if err != nil {
+		return nil, fmt.Errorf("malformed secret key: %v", err)
+	}
+	if hrp != strings.ToLower(identityHRP) || len(key) != curve25519.ScalarSize {
+		return nil, errors.New("malformed secret key: not an age secret key")
+	}
+	return newX25519Identity(key)
+}
+
+// ParseIdentities parses an age identity file: one private key per line, with empty lines and
+// comments starting with # ignored
+func ParseIdentities(r io.Reader) ([]Identity, error) {
+	var ids []Identity
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		id, err := ParseIdentity(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", n, err)
+		}
+		ids = append(ids, id)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, errors.New("no secret keys found")
+	}
+	return ids, nil
+}
+
+// ParseRecipients parses a recipients file: one public key per line, with empty lines and comments
+// starting with # ignored
+func ParseRecipients(r io.Reader) ([]Recipient, error) {
+	var recipients []Recipient
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		recipient, err := ParseRecipient(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", n, err)
+		}
+		recipients = append(recipients, recipient)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(recipients) == 0 {
+		return nil, errors.New("no recipients found")
+	}
+	return recipients, nil
+}
+
+// GenerateIdentity creates a new random age private key
+func GenerateIdentity() (*X25519Identity, error) {
+	secretKey := make([]byte, curve25519.ScalarSize)
+	if _, err := rand.Read(secretKey); err != nil {
+		return nil, err
+	}
+	return newX25519Identity(secretKey)
+}
+
+func newX25519Identity(secretKey []byte) (*X25519Identity, error) {
+	publicKey, err := curve25519.X25519(secretKey, curve25519.Basepoint)
+	if err != nil {
+		return nil, err
+	}
+	return &X25519Identity{secretKey: secretKey, publicKey: publicKey}, nil
+}
+
+// Recipient returns the public key matching the private key
+func (i *X25519Identity) Recipient() *X25519Recipient {
+	return &X25519Recipient{publicKey: i.publicKey}
+}
+
+// String returns the age private key ("AGE-SECRET-KEY-1...")
+func (i *X25519Identity) String() string {
+	s, _ := bech32Encode(identityHRP, i.secretKey)
+	return s
+}
+
+func (i *X25519Identity) unwrap(stanzas []*stanza) ([]byte, error) {
+	for _, s := range stanzas {
+		if s.Type != x25519StanzaType {
+			continue
+		}
+		if len(s.Args) != 1 {
+			return nil, errors.New("invalid X25519 recipient block")
+		}
+		share, err := b64.DecodeString(s.Args[0])
+		if err != nil || len(share) != curve25519.PointSize {
+			return nil, errors.New("invalid X25519 recipient block")
+		}
+		shared, err := curve25519.X25519(i.secretKey, share)
+		if err != nil {
+			return nil, fmt.Errorf("invalid X25519 recipient block: %v", err)
+		}
+		salt := append(append([]byte{}, share...), i.publicKey...)
+		if fileKey, err := aeadOpen(hkdfKey(shared, salt, x25519Label), s.Body); err == nil {
+			return fileKey, nil
+		}
+	}
+	return nil, errIncorrectIdentity
+}
+
+// ScryptRecipient encrypts a file with a passphrase
+type ScryptRecipient struct {
+	passphrase []byte
+	workFactor int
+}
+
+// NewScryptRecipient returns a recipient that encrypts with the passphrase
+func NewScryptRecipient(passphrase string) (*ScryptRecipient, error) {
+	if passphrase == "" {
+		return nil, errors.New("the passphrase cannot be empty")
+	}
+	return &ScryptRecipient{passphrase: []byte(passphrase), workFactor: scryptWorkFactor}, nil
+}
+
+func (r *ScryptRecipient) wrap(fileKey []byte) (*stanza, error) {
+	salt := make([]byte, scryptSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	key, err := scrypt.Key(r.passphrase, append([]byte(scryptLabel), salt...), 1<<r.workFactor, 8, 1, chacha20poly1305.KeySize)
+	if err != nil {
+		return nil, err
+	}
+	body, err := aeadSeal(key, fileKey)
+	if err != nil {
+		return nil, err
+	}
+	return &stanza{Type: scryptStanzaType, Args: []string{b64.EncodeToString(salt), strconv.Itoa(r.workFactor)}, Body: body}, nil
+}
+
+// ScryptIdentity decrypts a file encrypted with a passphrase
+type ScryptIdentity struct {
+	passphrase []byte
+}
+
+// NewScryptIdentity returns an identity that decrypts with the passphrase
+func NewScryptIdentity(passphrase string) *ScryptIdentity {
+	return &ScryptIdentity{passphrase: []byte(passphrase)}
+}
+
+func (i *ScryptIdentity) unwrap(stanzas []*stanza) ([]byte, error) {
+	for _, s := range stanzas {
+		if s.Type != scryptStanzaType {
+			continue
+		}
+		if len(stanzas) != 1 {
+			return nil, errors.New("a passphrase recipient block must be the only one")
+		}
+		if len(s.Args) != 2 {
+			return nil, errors.New("invalid scrypt recipient block")
+		}
+		salt, err := b64.DecodeString(s.Args[0])
+		if err != nil || len(salt) != scryptSaltSize {
+			return nil, errors.New("invalid scrypt recipient block")
+		}
+		workFactor, err := strconv.Atoi(s.Args[1])
+		if err != nil || workFactor <= 0 || strconv.Itoa(workFactor) != s.Args[1] {
+			return nil, errors.New("invalid scrypt work factor")
+		}
+		if workFactor > scryptMaxWorkFactor {
+			return nil, fmt.Errorf("scrypt work factor too large: %d", workFactor)
+		}
+		key, err := scrypt.Key(i.passphrase, append([]byte(scryptLabel), salt...), 1<<workFactor, 8, 1, chacha20poly1305.KeySize)
+		if err != nil {
+			return nil, err
+		}
+		fileKey, err := aeadOpen(key, s.Body)
+		if err != nil {
+			return nil, fmt.Errorf("incorrect passphrase: %w", ErrNoIdentity)
+		}
+		return fileKey, nil
+	}
+	return nil, errIncorrectIdentity
+}
+
+// aeadSeal encrypts a file key with a zero nonce, which is safe because each key is used only once
+func aeadSeal(key []byte, fileKey []byte) ([]byte, error) {
+	aead, err := chacha20poly1305.New(key)
+	if err != nil {
+		return nil, err
+	}
+	return aead.Seal(nil, make([]byte, chacha20poly1305.NonceSize), fileKey, nil), nil
+}
+
+func aeadOpen(key []byte, body []byte) ([]byte, error) {
+	aead, err := chacha20poly1305.New(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(body) != fileKeySize+aead.Overhead() {
+		return nil, errors.New("invalid recipient block body size")
+	}
+	return aead.Open(nil, make([]byte, chacha20poly1305.NonceSize), body, nil)
+}
@@ -0,0 +1,183 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package examples extracts the usage examples of the commands from their help, i.e., cobra's Example
+// field, so that they can be listed and searched across the command tree (see "fsoc examples") and
+// verified in tests. Commands can register examples with Add, which appends them to their help, or
+// write them into the Example field directly: command lines start with "fsoc", possibly after
+// environment variable assignments, and are described by the "#" comment lines preceding them.
+package examples
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cisco-open/fsoc/cmd/batch"
+)
+
+// programName is the first word of the example command lines
+const programName = "fsoc"
+
+// Example is a usage example of a command
+type Example struct {
+	Command     string `json:"command" yaml:"command"` // path of the command with the example, e.g., "fsoc knowledge list"
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	Line        string `json:"line" yaml:"line"` // the command line
+}
+
+// envAssignmentRegexp matches environment variable assignments preceding a command line
+var envAssignmentRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*=`)
+
+// Add registers a usage example of the command, appending it to the command's help
+func Add(cmd *cobra.Command, description string, line string) {
+	var sb strings.Builder
+	if cmd.Example != "" {
+		sb.WriteString(strings.TrimRight(cmd.Example, " \n"))
+		sb.WriteString("\n\n")
+	}
+	if description != "" {
+		sb.WriteString("  # " + description + "\n")
+	}
+	sb.WriteString("  " + line)
+	cmd.Example = sb.String()
+}
+
+// Parse extracts the examples from the text of a command's Example field. The consecutive "#" comment
+// lines preceding one or more command lines are their description, until the next comment or blank line;
+// command lines continued with a trailing backslash are joined. Other lines (e.g., output shown in the
+// help) are ignored.
+func Parse(text string) []Example {
+	var examples []Example
+	var description []string
+	var continued string
+	described := false // the description has been used by a command line
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if continued != "" {
+			line = continued + " " + line
+			continued = ""
+		}
+		switch {
+		case line == "":
+			description, described = nil, false
+		case strings.HasPrefix(line, "#"):
+			if described {
+				description, described = nil, false
+			}
+			description = append(description, strings.TrimSpace(strings.TrimLeft(line, "#")))
+		case strings.HasSuffix(line, `\`):
+			continued = strings.TrimSpace(strings.TrimSuffix(line, `\`))
+		case isCommandLine(line):
+			examples = append(examples, Example{Description: strings.Join(description, " "), Line: line})
+			described = true
+		}
+	}
+	return examples
+}
+
+// isCommandLine returns true if the line runs fsoc, possibly after environment variable assignments
+func isCommandLine(line string) bool {
+	for _, word := range strings.Fields(line) {
+		if word == programName {
+			return true
+		}
+		if !envAssignmentRegexp.MatchString(word) {
+			return false
+		}
+	}
+	return false
+}
+
+// Collect returns the examples of the command and of all of its subcommands, depth first
+func Collect(cmd *cobra.Command) []Example {
+	var examples []Example
+	for _, e := range Parse(cmd.Example) {
+		e.Command = cmd.CommandPath()
+		examples = append(examples, e)
+	}
+	for _, c := range cmd.Commands() {
+		examples = append(examples, Collect(c)...)
+	}
+	return examples
+}
+
+// Search returns the examples matching all of the terms, case-insensitively, in their command path,
+// description or command line
+func Search(examples []Example, terms []string) []Example {
+	var matched []Example
+	for _, e := range examples {
+		text := strings.ToLower(e.Command + "\n" + e.Description + "\n" + e.Line)
+		found := true
+		for _, term := range terms {
+			if !strings.Contains(text, strings.ToLower(term)) {
+				found = false
+				break
+			}
+		}
+		if found {
+			matched = append(matched, e)
+		}
+	}
+	return matched
+}
+
+// Args returns the arguments of the example's command line, without the environment variable assignments
+// and the program name. Shell pipelines, redirections and command lists are cut at the first operator.
+func (e Example) Args() ([]string, error) {
+	words, err := batch.SplitCommand(e.Line)
+	if err != nil {
+		return nil, err
+	}
+	for len(words) > 0 && words[0] != programName {
+		words = words[1:] // environment variable assignments
+	}
+	if len(words) == 0 {
+		return nil, fmt.Errorf("%q doesn't run %s", e.Line, programName)
+	}
+	args := []string{}
+	for _, word := range words[1:] {
+		if word == "|" || word == "||" || word == "&&" || word == ";" || strings.HasPrefix(word, ">") || strings.HasPrefix(word, "<") {
+			break
+		}
+		args = append(args, word)
+	}
+	return args, nil
+}
+
+// Verify checks that the example's command line is valid for the root command: it names an existing
+// command, with flags that the command defines and values that they accept. Note that the flags of
+// the command are set as a side effect; this is meant for tests and other processes that don't
+// execute the commands.
+func Verify(root *cobra.Command, e Example) error {
+	args, err := e.Args()
+	if err != nil {
+		return err
+	}
+	cmd, flags, err := root.Find(args)
+	if err != nil {
+		return err
+	}
+	if err := cmd.ParseFlags(flags); err != nil {
+		return fmt.Errorf("%s: %w", cmd.CommandPath(), err)
+	}
+	if cmd.Args != nil {
+		if err := cmd.ValidateArgs(cmd.Flags().Args()); err != nil {
+			return fmt.Errorf("%s: %w", cmd.CommandPath(), err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package examples
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	text := `# List themes
+  fsoc knowledge list --type preferences:theme \
+    --layer-type TENANT
+
+  # Export with a passphrase
+  # from the environment
+  FSOC_PASSPHRASE=... fsoc knowledge export --dir ./backup --passphrase
+  fsoc knowledge import --dir ./backup
+  # Other tools
+  curl -H "$(fsoc auth print-token --header)" https://example.com
+
+  fsoc solution list`
+	assert.Equal(t, []Example{
+		{Description: "List themes", Line: "fsoc knowledge list --type preferences:theme --layer-type TENANT"},
+		{Description: "Export with a passphrase from the environment", Line: "FSOC_PASSPHRASE=... fsoc knowledge export --dir ./backup --passphrase"},
+		{Description: "Export with a passphrase from the environment", Line: "fsoc knowledge import --dir ./backup"},
+		{Line: "fsoc solution list"},
+	}, Parse(text))
+}
+
+func TestAdd(t *testing.T) {
+	cmd := &cobra.Command{Use: "list", Example: "  fsoc list\n"}
+	Add(cmd, "List as JSON", "fsoc list -o json")
+	assert.Equal(t, "  fsoc list\n\n  # List as JSON\n  fsoc list -o json", cmd.Example)
+	assert.Equal(t, []Example{{Line: "fsoc list"}, {Description: "List as JSON", Line: "fsoc list -o json"}}, Parse(cmd.Example))
+}
+
+func TestArgs(t *testing.T) {
+	args, err := Example{Line: `A=1 fsoc uql "fetch id" -o json | jq .`}.Args()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"uql", "fetch id", "-o", "json"}, args)
+
+	_, err = Example{Line: `fsoc uql "fetch`}.Args()
+	assert.Error(t, err)
+}
+
+func testRoot() *cobra.Command {
+	root := &cobra.Command{Use: "fsoc"}
+	sub := &cobra.Command{Use: "solution", Example: "  fsoc solution list"}
+	list := &cobra.Command{Use: "list", Args: cobra.NoArgs, Run: func(*cobra.Command, []string) {}, Example: "  # List solutions\n  fsoc solution list --tag dev"}
+	list.Flags().String("tag", "", "")
+	sub.AddCommand(list)
+	root.AddCommand(sub)
+	return root
+}
+
+func TestCollectAndSearch(t *testing.T) {
+	root := testRoot()
+	list := Collect(root)
+	assert.Equal(t, []Example{
+		{Command: "fsoc solution", Line: "fsoc solution list"},
+		{Command: "fsoc solution list", Description: "List solutions", Line: "fsoc solution list --tag dev"},
+	}, list)
+	assert.Len(t, Search(list, []string{"solutions"}), 1)
+	assert.Len(t, Search(list, []string{"SOLUTION", "list"}), 2)
+	assert.Empty(t, Search(list, []string{"list", "uql"}))
+}
+
+func TestVerify(t *testing.T) {
+	root := testRoot()
+	assert.NoError(t, Verify(root, Example{Line: "fsoc solution list --tag dev"}))
+	assert.Error(t, Verify(root, Example{Line: "fsoc solution list --unknown"}))
+	assert.Error(t, Verify(root, Example{Line: "fsoc solution list extra"}))
+	assert.Error(t, Verify(root, Example{Line: "fsoc solutions"}))
+}
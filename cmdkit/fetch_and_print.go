@@ -39,6 +39,7 @@ type FetchAndPrintOptions struct {
 // If a cmd is not provided or it has no `output` flag, human output is assumed (table)
 // If a human format is requested/assumed but no table is provided, it displays YAML
 // If the object cannot be converted to the desired format, shows the object in Go's %+v format
+// Collections are displayed page by page as they arrive, for the output formats that allow it (see output.ItemStream).
 // In addition, if the fetch API command fails, this function prints the error and exits with failure.
 func FetchAndPrint(cmd *cobra.Command, path string, options *FetchAndPrintOptions) {
 	// finalize override fields
@@ -65,6 +66,17 @@ func FetchAndPrint(cmd *cobra.Command, path string, options *FetchAndPrintOption
 		if method != "GET" {
 			log.Fatalf("bug: cannot request %q for a collection at %q, only GET is supported for collections", method, path)
 		}
+
+		// display the items as each page arrives, if the output format allows it
+		if stream := output.NewItemStream(cmd); stream != nil {
+			_, err = api.VisitCollection(path, httpOptions, func(items []any, _ int) error {
+				return stream.Write(items)
+			})
+			if err != nil {
+				log.Fatalf("Platform API call failed: %v", err)
+			}
+			return
+		}
 		err = api.JSONGetCollection(path, &res, httpOptions)
 	} else {
 		err = api.JSONRequest(method, path, body, &res, httpOptions)
@@ -0,0 +1,140 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package interrupt makes fsoc stop gracefully when interrupted with Ctrl+C (SIGINT) or SIGTERM: the
+// command's context is canceled, which aborts the platform API calls in progress, so that commands can
+// stop at a safe point, e.g., saving the progress of an import to resume it later. Temporary files and
+// other state registered with OnCleanup are removed however the command ends. A second signal exits
+// immediately, after the cleanup.
+package interrupt
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/apex/log"
+
+	"github.com/cisco-open/fsoc/exitcode"
+)
+
+var (
+	interrupted atomic.Bool
+	watched     atomic.Value // the chan os.Signal notified of the signals, see Disable
+
+	cleanupMutex sync.Mutex
+	cleanups     = map[int]func(){}
+	nextCleanup  int
+)
+
+// exit is replaced in tests
+var exit = os.Exit
+
+// NotifyContext returns a context that is canceled when fsoc receives SIGINT or SIGTERM, and the function
+// that stops watching for the signals. After the first signal, a second one runs the cleanup functions and
+// exits with the Interrupted exit code, for commands that don't stop in time.
+func NotifyContext(parent context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(parent)
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	watched.Store(signals)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case sig := <-signals:
+			interrupted.Store(true)
+			log.WithField("signal", sig.String()).Warn("Interrupted, stopping; interrupt again to exit immediately")
+			cancel()
+		case <-done:
+			return
+		}
+		select {
+		case <-signals:
+			RunCleanups()
+			exit(exitcode.Interrupted)
+		case <-done:
+		}
+	}()
+
+	var once sync.Once
+	return ctx, func() {
+		once.Do(func() {
+			signal.Stop(signals)
+			close(done)
+			cancel()
+		})
+	}
+}
+
+// Disable stops the graceful handling of the signals, for commands that handle them themselves, e.g.,
+// commands that run until interrupted (following new data) or that pass them to another process (plugins)
+func Disable() {
+	if signals, ok := watched.Load().(chan os.Signal); ok {
+		signal.Stop(signals)
+	}
+}
+
+// Interrupted returns true if fsoc received SIGINT or SIGTERM
+func Interrupted() bool {
+	return interrupted.Load()
+}
+
+// OnCleanup registers a function that cleans up after an operation in progress (e.g., removes a
+// temporary file) if fsoc exits before the operation completes, and returns the function that the
+// operation calls when it completes to run the cleanup function (once) and unregister it
+func OnCleanup(cleanup func()) func() {
+	cleanupMutex.Lock()
+	defer cleanupMutex.Unlock()
+	id := nextCleanup
+	nextCleanup++
+	cleanups[id] = cleanup
+	return func() {
+		cleanupMutex.Lock()
+		_, registered := cleanups[id]
+		delete(cleanups, id)
+		cleanupMutex.Unlock()
+		if registered {
+			cleanup()
+		}
+	}
+}
+
+// RunCleanups runs the registered cleanup functions, e.g., before fsoc exits
+func RunCleanups() {
+	cleanupMutex.Lock()
+	pending := cleanups
+	cleanups = map[int]func(){}
+	cleanupMutex.Unlock()
+	for _, cleanup := range pending {
+		cleanup()
+	}
+}
+
+// Handler returns a log handler that runs the cleanup functions on fatal log messages, which exit
+// without returning to the callers, and exits with the Interrupted exit code if fsoc was interrupted.
+// It must precede the exitcode handler.
+func Handler() log.Handler {
+	return log.HandlerFunc(func(e *log.Entry) error {
+		if e.Level == log.FatalLevel {
+			RunCleanups()
+			if Interrupted() {
+				exit(exitcode.Interrupted)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,63 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interrupt
+
+import (
+	"os"
+	"testing"
+
+	"github.com/apex/log"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cisco-open/fsoc/exitcode"
+)
+
+func TestCleanups(t *testing.T) {
+	calls := map[string]int{}
+	doneA := OnCleanup(func() { calls["a"]++ })
+	OnCleanup(func() { calls["b"]++ })
+
+	// completing an operation runs its cleanup once
+	doneA()
+	doneA()
+	assert.Equal(t, map[string]int{"a": 1}, calls)
+
+	// the remaining cleanups run once, on exit
+	RunCleanups()
+	RunCleanups()
+	assert.Equal(t, map[string]int{"a": 1, "b": 1}, calls)
+}
+
+func TestHandler(t *testing.T) {
+	code := -1
+	exit = func(c int) { code = c }
+	defer func() {
+		exit = os.Exit
+		interrupted.Store(false)
+	}()
+
+	cleaned := false
+	OnCleanup(func() { cleaned = true })
+	assert.NoError(t, Handler().HandleLog(&log.Entry{Level: log.ErrorLevel, Message: "not fatal"}))
+	assert.False(t, cleaned)
+
+	assert.NoError(t, Handler().HandleLog(&log.Entry{Level: log.FatalLevel, Message: "failed"}))
+	assert.True(t, cleaned)
+	assert.Equal(t, -1, code)
+
+	interrupted.Store(true)
+	assert.NoError(t, Handler().HandleLog(&log.Entry{Level: log.FatalLevel, Message: "import interrupted"}))
+	assert.Equal(t, exitcode.Interrupted, code)
+}
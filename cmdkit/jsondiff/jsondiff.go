@@ -0,0 +1,193 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package jsondiff compares JSON values semantically, reporting the individual values that differ
+// rather than the lines of their text representation. Object members are compared by name,
+// regardless of their order, and array elements are matched by their "id" or "name" field, so
+// that reordering them is not reported as a change.
+package jsondiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+)
+
+// Kinds of change
+const (
+	Added   = "added"
+	Removed = "removed"
+	Changed = "changed"
+)
+
+// Change is a difference between two JSON values at a path, specified as a jq path expression
+// (e.g., ".spec.items[id=abc].name")
+type Change struct {
+	Path   string `json:"path" yaml:"path"`
+	Change string `json:"change" yaml:"change"`
+	Old    any    `json:"old,omitempty" yaml:"old,omitempty"`
+	New    any    `json:"new,omitempty" yaml:"new,omitempty"`
+}
+
+// Diff returns the differences between two JSON values, as produced by json.Unmarshal into an any,
+// or an empty list if they are semantically equal
+func Diff(oldValue any, newValue any) []Change {
+	changes := diff("", oldValue, newValue)
+	if changes == nil {
+		changes = []Change{}
+	}
+	return changes
+}
+
+// diff returns the differences between two JSON values; object members are compared by
+// name and array elements by their "id" or "name" field, if all elements have one, or by index
+func diff(path string, oldValue any, newValue any) []Change {
+	switch oldValue := oldValue.(type) {
+	case map[string]any:
+		newValue, ok := newValue.(map[string]any)
+		if !ok {
+			break
+		}
+		changes := []Change{}
+		for _, key := range sortedUnion(oldValue, newValue) {
+			changes = append(changes, diffMember(path+jqField(key), oldValue, newValue, key)...)
+		}
+		return changes
+	case []any:
+		newValue, ok := newValue.([]any)
+		if !ok {
+			break
+		}
+		if key := elementKey(oldValue, newValue); key != "" {
+			oldItems, newItems := indexElements(oldValue, key), indexElements(newValue, key)
+			changes := []Change{}
+			for _, id := range sortedUnion(oldItems, newItems) {
+				changes = append(changes, diffMember(fmt.Sprintf("%s[%s=%s]", path, key, id), oldItems, newItems, id)...)
+			}
+			return changes
+		}
+		changes := []Change{}
+		for i := 0; i < len(oldValue) || i < len(newValue); i++ {
+			elementPath := fmt.Sprintf("%s[%d]", path, i)
+			switch {
+			case i >= len(newValue):
+				changes = append(changes, Change{Path: elementPath, Change: Removed, Old: oldValue[i]})
+			case i >= len(oldValue):
+				changes = append(changes, Change{Path: elementPath, Change: Added, New: newValue[i]})
+			default:
+				changes = append(changes, diff(elementPath, oldValue[i], newValue[i])...)
+			}
+		}
+		return changes
+	}
+	if reflect.DeepEqual(oldValue, newValue) {
+		return nil
+	}
+	if path == "" {
+		path = "."
+	}
+	return []Change{{Path: path, Change: Changed, Old: oldValue, New: newValue}}
+}
+
+// diffMember compares the member of two maps, either of which may not have it
+func diffMember[V any](path string, oldMap map[string]V, newMap map[string]V, key string) []Change {
+	oldValue, inOld := oldMap[key]
+	newValue, inNew := newMap[key]
+	switch {
+	case !inNew:
+		return []Change{{Path: path, Change: Removed, Old: oldValue}}
+	case !inOld:
+		return []Change{{Path: path, Change: Added, New: newValue}}
+	default:
+		return diff(path, oldValue, newValue)
+	}
+}
+
+// elementKey returns the field by which the elements of both arrays can be matched ("id" or "name"),
+// or an empty string if there is no field that all elements have with a unique string value
+func elementKey(lists ...[]any) string {
+	for _, key := range []string{"id", "name"} {
+		ok := true
+		for _, list := range lists {
+			seen := map[string]bool{}
+			for _, item := range list {
+				m, isMap := item.(map[string]any)
+				id, isString := m[key].(string)
+				if !isMap || !isString || seen[id] {
+					ok = false
+					break
+				}
+				seen[id] = true
+			}
+		}
+		if ok {
+			return key
+		}
+	}
+	return ""
+}
+
+func indexElements(list []any, key string) map[string]any {
+	index := make(map[string]any, len(list))
+	for _, item := range list {
+		index[item.(map[string]any)[key].(string)] = item
+	}
+	return index
+}
+
+func sortedUnion[V any](a map[string]V, b map[string]V) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, found := a[k]; !found {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+var jqIdentifierRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// jqField returns the jq path expression for an object member
+func jqField(key string) string {
+	if jqIdentifierRegexp.MatchString(key) {
+		return "." + key
+	}
+	b, _ := json.Marshal(key)
+	return "[" + string(b) + "]"
+}
+
+// DisplayValue returns a short representation of a JSON value for display in a table, truncated
+// to maxLength characters
+func DisplayValue(v any, maxLength int) string {
+	if v == nil {
+		return ""
+	}
+	var s string
+	if str, ok := v.(string); ok {
+		s = str
+	} else {
+		b, _ := json.Marshal(v)
+		s = string(b)
+	}
+	if len(s) > maxLength {
+		s = s[:maxLength-3] + "..."
+	}
+	return s
+}
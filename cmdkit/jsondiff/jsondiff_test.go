@@ -0,0 +1,57 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jsondiff
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parse(t *testing.T, s string) any {
+	var v any
+	require.Nil(t, json.Unmarshal([]byte(s), &v))
+	return v
+}
+
+func TestDiff(t *testing.T) {
+	oldValue := parse(t, `{"name": "a", "spec": {"color": "red", "size": 1}, "items": [{"id": "x", "v": 1}, {"id": "y", "v": 2}], "tags": ["p", "q"], "odd key": 1}`)
+	newValue := parse(t, `{"name": "a", "spec": {"color": "blue"}, "items": [{"id": "y", "v": 3}, {"id": "z", "v": 4}], "tags": ["p"], "odd key": 2}`)
+
+	assert.Equal(t, []Change{
+		{Path: `.items[id=x]`, Change: Removed, Old: map[string]any{"id": "x", "v": 1.0}},
+		{Path: `.items[id=y].v`, Change: Changed, Old: 2.0, New: 3.0},
+		{Path: `.items[id=z]`, Change: Added, New: map[string]any{"id": "z", "v": 4.0}},
+		{Path: `["odd key"]`, Change: Changed, Old: 1.0, New: 2.0},
+		{Path: `.spec.color`, Change: Changed, Old: "red", New: "blue"},
+		{Path: `.spec.size`, Change: Removed, Old: 1.0},
+		{Path: `.tags[1]`, Change: Removed, Old: "q"},
+	}, Diff(oldValue, newValue))
+
+	// equal values, differing only in member order
+	assert.Empty(t, Diff(parse(t, `{"a": 1, "b": [1, 2]}`), parse(t, `{"b": [1, 2], "a": 1}`)))
+
+	// values of different types
+	assert.Equal(t, []Change{{Path: ".", Change: Changed, Old: "x", New: 1.0}}, Diff("x", 1.0))
+}
+
+func TestDisplayValue(t *testing.T) {
+	assert.Equal(t, "", DisplayValue(nil, 10))
+	assert.Equal(t, "short", DisplayValue("short", 10))
+	assert.Equal(t, "abcdefg...", DisplayValue("abcdefghijklmnop", 10))
+	assert.Equal(t, `{"a":1}`, DisplayValue(map[string]any{"a": 1}, 10))
+}
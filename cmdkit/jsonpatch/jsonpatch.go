@@ -0,0 +1,309 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package jsonpatch applies patches to JSON values, as produced by json.Unmarshal into an any: JSON
+// Patch documents (RFC 6902), which are lists of operations on the values at JSON Pointers (RFC 6901),
+// and JSON Merge Patch documents (RFC 7386), which are partial values merged into the target.
+package jsonpatch
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Operation is a JSON Patch operation: "add", "remove", "replace", "move", "copy" or "test"
+type Operation struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	From  string `json:"from,omitempty"`  // for move and copy
+	Value any    `json:"value,omitempty"` // for add, replace and test
+}
+
+// Apply applies the JSON Patch operations to a copy of the document, returning the patched document.
+// The operations are applied in order; if one fails (e.g., a test operation), an error identifying it
+// is returned and the document is left unchanged.
+func Apply(doc any, ops []Operation) (any, error) {
+	doc = deepCopy(doc)
+	for i, op := range ops {
+		var err error
+		doc, err = applyOperation(doc, op)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %w", i+1, op.Op, op.Path, err)
+		}
+	}
+	return doc, nil
+}
+
+// MergePatch applies a JSON Merge Patch to a copy of the target, returning the result: the members of
+// patch objects are merged recursively, null members are removed and other values replace the target
+func MergePatch(target any, patch any) any {
+	patchObject, ok := patch.(map[string]any)
+	if !ok {
+		return deepCopy(patch)
+	}
+	targetObject, ok := target.(map[string]any)
+	if !ok {
+		targetObject = map[string]any{}
+	}
+	result := map[string]any{}
+	for name, value := range targetObject {
+		result[name] = deepCopy(value)
+	}
+	for name, value := range patchObject {
+		if value == nil {
+			delete(result, name)
+			continue
+		}
+		result[name] = MergePatch(result[name], value)
+	}
+	return result
+}
+
+func applyOperation(doc any, op Operation) (any, error) {
+	switch op.Op {
+	case "add":
+		return add(doc, op.Path, deepCopy(op.Value))
+	case "remove":
+		doc, _, err := remove(doc, op.Path)
+		return doc, err
+	case "replace":
+		if op.Path == "" {
+			return deepCopy(op.Value), nil
+		}
+		doc, _, err := remove(doc, op.Path)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, op.Path, deepCopy(op.Value))
+	case "move":
+		if op.Path != op.From && strings.HasPrefix(op.Path, op.From+"/") {
+			return nil, fmt.Errorf("cannot move %q into one of its children", op.From)
+		}
+		doc, value, err := remove(doc, op.From)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, op.Path, value)
+	case "copy":
+		value, err := Get(doc, op.From)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, op.Path, deepCopy(value))
+	case "test":
+		value, err := Get(doc, op.Path)
+		if err != nil {
+			return nil, err
+		}
+		if !equal(value, op.Value) {
+			return nil, fmt.Errorf("test failed: the value is %s, not %s", display(value), display(op.Value))
+		}
+		return doc, nil
+	}
+	return nil, fmt.Errorf("unknown operation %q", op.Op)
+}
+
+// Get returns the value at the JSON Pointer in the document
+func Get(doc any, pointer string) (any, error) {
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+	value := doc
+	for i, token := range tokens {
+		switch container := value.(type) {
+		case map[string]any:
+			member, found := container[token]
+			if !found {
+				return nil, fmt.Errorf("%q does not exist", joinPointer(tokens[:i+1]))
+			}
+			value = member
+		case []any:
+			index, err := arrayIndex(token, len(container)-1)
+			if err != nil {
+				return nil, fmt.Errorf("%q: %w", joinPointer(tokens[:i+1]), err)
+			}
+			value = container[index]
+		default:
+			return nil, fmt.Errorf("%q is not an object or an array", joinPointer(tokens[:i]))
+		}
+	}
+	return value, nil
+}
+
+// add adds the value at the pointer, replacing an existing object member or inserting an array element
+func add(doc any, pointer string, value any) (any, error) {
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	parentPointer, last := joinPointer(tokens[:len(tokens)-1]), tokens[len(tokens)-1]
+	parent, err := Get(doc, parentPointer)
+	if err != nil {
+		return nil, err
+	}
+	switch container := parent.(type) {
+	case map[string]any:
+		container[last] = value
+		return doc, nil
+	case []any:
+		index := len(container)
+		if last != "-" {
+			if index, err = arrayIndex(last, len(container)); err != nil {
+				return nil, fmt.Errorf("%q: %w", pointer, err)
+			}
+		}
+		container = append(container, nil)
+		copy(container[index+1:], container[index:])
+		container[index] = value
+		return replaceParent(doc, parentPointer, container)
+	}
+	return nil, fmt.Errorf("%q is not an object or an array", parentPointer)
+}
+
+// remove removes the value at the pointer, returning the document and the removed value
+func remove(doc any, pointer string) (any, any, error) {
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, nil, fmt.Errorf("cannot remove the whole document")
+	}
+	value, err := Get(doc, pointer)
+	if err != nil {
+		return nil, nil, err
+	}
+	parentPointer, last := joinPointer(tokens[:len(tokens)-1]), tokens[len(tokens)-1]
+	parent, _ := Get(doc, parentPointer) // exists, since the value does
+	switch container := parent.(type) {
+	case map[string]any:
+		delete(container, last)
+		return doc, value, nil
+	case []any:
+		index, _ := arrayIndex(last, len(container)-1)
+		container = append(container[:index], container[index+1:]...)
+		doc, err = replaceParent(doc, parentPointer, container)
+		return doc, value, err
+	}
+	return nil, nil, fmt.Errorf("%q is not an object or an array", parentPointer)
+}
+
+// replaceParent sets the array at the pointer, whose length changed, in its own parent
+func replaceParent(doc any, pointer string, array []any) (any, error) {
+	tokens, _ := parsePointer(pointer) // already parsed
+	if len(tokens) == 0 {
+		return array, nil
+	}
+	grandparent, err := Get(doc, joinPointer(tokens[:len(tokens)-1]))
+	if err != nil {
+		return nil, err
+	}
+	last := tokens[len(tokens)-1]
+	switch container := grandparent.(type) {
+	case map[string]any:
+		container[last] = array
+	case []any:
+		index, _ := arrayIndex(last, len(container)-1)
+		container[index] = array
+	}
+	return doc, nil
+}
+
+// parsePointer splits a JSON Pointer into its unescaped reference tokens
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q: it must start with /", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func joinPointer(tokens []string) string {
+	var b strings.Builder
+	for _, token := range tokens {
+		b.WriteString("/")
+		b.WriteString(strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1"))
+	}
+	return b.String()
+}
+
+// arrayIndex parses an array index, which must be between 0 and max
+func arrayIndex(token string, max int) (int, error) {
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	if index > max {
+		return 0, fmt.Errorf("array index %d is out of range", index)
+	}
+	return index, nil
+}
+
+// equal compares JSON values, regardless of the numeric types they were decoded into
+func equal(a any, b any) bool {
+	return reflect.DeepEqual(normalize(a), normalize(b))
+}
+
+// deepCopy copies a JSON value, so that patching it doesn't change the original
+func deepCopy(value any) any {
+	switch value := value.(type) {
+	case map[string]any:
+		result := make(map[string]any, len(value))
+		for name, member := range value {
+			result[name] = deepCopy(member)
+		}
+		return result
+	case []any:
+		result := make([]any, len(value))
+		for i, element := range value {
+			result[i] = deepCopy(element)
+		}
+		return result
+	}
+	return value
+}
+
+// normalize converts a value into the types produced by json.Unmarshal
+func normalize(value any) any {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var result any
+	if err := json.Unmarshal(data, &result); err != nil {
+		return value
+	}
+	return result
+}
+
+func display(value any) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
@@ -0,0 +1,104 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jsonpatch
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parse(t *testing.T, s string) any {
+	var v any
+	require.NoError(t, json.Unmarshal([]byte(s), &v))
+	return v
+}
+
+func parseOps(t *testing.T, s string) []Operation {
+	var ops []Operation
+	require.NoError(t, json.Unmarshal([]byte(s), &ops))
+	return ops
+}
+
+// examples from RFC 6902, appendix A
+func TestApply(t *testing.T) {
+	for _, tc := range []struct {
+		name, doc, ops, expected string
+	}{
+		{"add member", `{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"baz":"qux","foo":"bar"}`},
+		{"add element", `{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`},
+		{"append element", `{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":["abc","def"]}]`, `{"foo":["bar",["abc","def"]]}`},
+		{"remove member", `{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`},
+		{"remove element", `{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`},
+		{"replace", `{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`},
+		{"move member", `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`, `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+			`{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{"move element", `{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`},
+		{"copy", `{"a":{"b":1}}`, `[{"op":"copy","from":"/a","path":"/c"}]`, `{"a":{"b":1},"c":{"b":1}}`},
+		{"test", `{"baz":"qux","foo":["a",2,"c"]}`, `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`, `{"baz":"qux","foo":["a",2,"c"]}`},
+		{"escaped", `{"/":9,"~1":10}`, `[{"op":"test","path":"/~01","value":10},{"op":"remove","path":"/~1"}]`, `{"~1":10}`},
+		{"nested array", `{"a":[[1,2]]}`, `[{"op":"add","path":"/a/0/0","value":0}]`, `{"a":[[0,1,2]]}`},
+		{"root", `{"a":1}`, `[{"op":"replace","path":"","value":[1]}]`, `[1]`},
+	} {
+		doc := parse(t, tc.doc)
+		patched, err := Apply(doc, parseOps(t, tc.ops))
+		require.NoError(t, err, tc.name)
+		assert.Equal(t, parse(t, tc.expected), patched, tc.name)
+		assert.Equal(t, parse(t, tc.doc), doc, "%s: the original document is unchanged", tc.name)
+	}
+}
+
+func TestApplyErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name, doc, ops, message string
+	}{
+		{"test failure", `{"baz":"qux"}`, `[{"op":"test","path":"/baz","value":"bar"}]`, `operation 1 (test /baz): test failed: the value is "qux", not "bar"`},
+		{"missing member", `{"foo":"bar"}`, `[{"op":"add","path":"/baz/bat","value":"qux"}]`, `operation 1 (add /baz/bat): "/baz" does not exist`},
+		{"remove missing", `{"foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `operation 1 (remove /baz): "/baz" does not exist`},
+		{"index out of range", `{"foo":[1]}`, `[{"op":"add","path":"/foo/2","value":3}]`, `operation 1 (add /foo/2): "/foo/2": array index 2 is out of range`},
+		{"invalid index", `{"foo":[1]}`, `[{"op":"replace","path":"/foo/01","value":3}]`, `operation 1 (replace /foo/01): "/foo/01": invalid array index "01"`},
+		{"move into child", `{"a":{"b":1}}`, `[{"op":"move","from":"/a","path":"/a/c"}]`, `operation 1 (move /a/c): cannot move "/a" into one of its children`},
+		{"unknown op", `{}`, `[{"op":"merge","path":"/a"}]`, `operation 1 (merge /a): unknown operation "merge"`},
+		{"invalid pointer", `{}`, `[{"op":"add","path":"a","value":1}]`, `operation 1 (add a): invalid JSON pointer "a": it must start with /`},
+	} {
+		_, err := Apply(parse(t, tc.doc), parseOps(t, tc.ops))
+		assert.EqualError(t, err, tc.message, tc.name)
+	}
+}
+
+// examples from RFC 7386, appendix A
+func TestMergePatch(t *testing.T) {
+	for _, tc := range [][3]string{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	} {
+		target := parse(t, tc[0])
+		assert.Equal(t, parse(t, tc[2]), MergePatch(target, parse(t, tc[1])), tc)
+		assert.Equal(t, parse(t, tc[0]), target, "%v: the target is unchanged", tc)
+	}
+}
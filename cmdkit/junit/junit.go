@@ -0,0 +1,139 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package junit writes test results in the JUnit XML format, which CI systems (e.g., Jenkins,
+// GitLab and GitHub Actions reporters) display as test reports
+package junit
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Suite is a named group of test cases
+type Suite struct {
+	Name  string
+	Cases []Case
+}
+
+// Case is the result of a test case; a case with neither Failure nor Skipped set passed
+type Case struct {
+	Name     string
+	Class    string // e.g., the name of the tested component
+	Duration time.Duration
+	Failure  string // message of the failure, if the case failed
+	Output   string // details of the failure or the case's output, if any
+	Skipped  string // reason for skipping the case, if it was skipped
+}
+
+// Failed returns the number of failed cases of the suite
+func (s *Suite) Failed() int {
+	n := 0
+	for _, c := range s.Cases {
+		if c.Failure != "" {
+			n++
+		}
+	}
+	return n
+}
+
+type xmlSuites struct {
+	XMLName  xml.Name   `xml:"testsuites"`
+	Tests    int        `xml:"tests,attr"`
+	Failures int        `xml:"failures,attr"`
+	Skipped  int        `xml:"skipped,attr"`
+	Time     string     `xml:"time,attr"`
+	Suites   []xmlSuite `xml:"testsuite"`
+}
+
+type xmlSuite struct {
+	Name      string    `xml:"name,attr"`
+	Tests     int       `xml:"tests,attr"`
+	Failures  int       `xml:"failures,attr"`
+	Skipped   int       `xml:"skipped,attr"`
+	Time      string    `xml:"time,attr"`
+	Timestamp string    `xml:"timestamp,attr,omitempty"`
+	Cases     []xmlCase `xml:"testcase"`
+}
+
+type xmlCase struct {
+	Name      string      `xml:"name,attr"`
+	ClassName string      `xml:"classname,attr,omitempty"`
+	Time      string      `xml:"time,attr"`
+	Failure   *xmlFailure `xml:"failure,omitempty"`
+	Skipped   *xmlSkipped `xml:"skipped,omitempty"`
+	SystemOut string      `xml:"system-out,omitempty"`
+}
+
+type xmlFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type xmlSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+// Write writes the suites as a JUnit XML report, timestamped with the time the tests started
+func Write(w io.Writer, started time.Time, suites ...Suite) error {
+	report := xmlSuites{}
+	var total time.Duration
+	for _, s := range suites {
+		xs := xmlSuite{Name: s.Name, Tests: len(s.Cases)}
+		if !started.IsZero() {
+			xs.Timestamp = started.UTC().Format("2006-01-02T15:04:05")
+		}
+		var suiteTime time.Duration
+		for _, c := range s.Cases {
+			xc := xmlCase{Name: c.Name, ClassName: c.Class, Time: seconds(c.Duration)}
+			switch {
+			case c.Failure != "":
+				xc.Failure = &xmlFailure{Message: c.Failure, Text: c.Output}
+				xs.Failures++
+			case c.Skipped != "":
+				xc.Skipped = &xmlSkipped{Message: c.Skipped}
+				xs.Skipped++
+			default:
+				xc.SystemOut = c.Output
+			}
+			xs.Cases = append(xs.Cases, xc)
+			suiteTime += c.Duration
+		}
+		xs.Time = seconds(suiteTime)
+		report.Suites = append(report.Suites, xs)
+		report.Tests += xs.Tests
+		report.Failures += xs.Failures
+		report.Skipped += xs.Skipped
+		total += suiteTime
+	}
+	report.Time = seconds(total)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// seconds formats a duration in seconds, as used by the JUnit format
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
@@ -0,0 +1,41 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package junit
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrite(t *testing.T) {
+	suite := Suite{Name: "spacefleet", Cases: []Case{
+		{Name: "ships are created", Class: "spacefleet", Duration: 1500 * time.Millisecond},
+		{Name: "fuel is reported", Class: "spacefleet", Duration: 2 * time.Second, Failure: "expected 1 row, found 0", Output: "query: FETCH id"},
+		{Name: "docked ships", Class: "spacefleet", Skipped: "setup failed"},
+	}}
+	assert.Equal(t, 1, suite.Failed())
+
+	var buf bytes.Buffer
+	err := Write(&buf, time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC), suite)
+	assert.Nil(t, err)
+	report := buf.String()
+	assert.Contains(t, report, `<testsuites tests="3" failures="1" skipped="1" time="3.500">`)
+	assert.Contains(t, report, `<testsuite name="spacefleet" tests="3" failures="1" skipped="1" time="3.500" timestamp="2023-05-01T10:00:00">`)
+	assert.Contains(t, report, `<failure message="expected 1 row, found 0">query: FETCH id</failure>`)
+	assert.Contains(t, report, `<skipped message="setup failed"></skipped>`)
+}
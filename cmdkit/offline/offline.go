@@ -0,0 +1,61 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package offline implements fsoc's offline mode (--offline), which guarantees that a command
+// makes no network calls, e.g., for validation in air-gapped environments. Commands that can work
+// from local files or caches proceed; anything that needs the network fails immediately with an
+// error that has the Offline exit code, instead of attempting a connection.
+package offline
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/cisco-open/fsoc/exitcode"
+)
+
+// FlagName is the name of the global flag that enables the offline mode
+const FlagName = "offline"
+
+// EnvVar is the environment variable that enables the offline mode when set to a non-empty value,
+// e.g., for all commands run in a CI pipeline
+const EnvVar = "FSOC_OFFLINE"
+
+// ErrOffline is the error in the chain of errors returned for operations needing the network
+var ErrOffline = errors.New("network access is disabled in offline mode (--offline)")
+
+var enabled atomic.Bool
+
+// Set enables or disables the offline mode
+func Set(offline bool) {
+	enabled.Store(offline)
+}
+
+// Enabled returns true if the offline mode is enabled
+func Enabled() bool {
+	return enabled.Load()
+}
+
+// Check returns an error if the offline mode is enabled, naming the operation that needs network
+// access, e.g., offline.Check("Checking for updates"); it returns nil otherwise. The error's exit
+// code is recorded, so that the command exits with it also when failing with the error's text only.
+func Check(operation string) error {
+	if !Enabled() {
+		return nil
+	}
+	err := fmt.Errorf("%s requires network access: %w", operation, ErrOffline)
+	exitcode.Record(err, exitcode.Offline)
+	return &exitcode.Error{Code: exitcode.Offline, Err: err}
+}
@@ -0,0 +1,39 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package offline
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cisco-open/fsoc/exitcode"
+)
+
+func TestCheck(t *testing.T) {
+	defer Set(false)
+
+	assert.NoError(t, Check("Calling the platform"))
+
+	Set(true)
+	err := Check("Calling the platform")
+	assert.ErrorIs(t, err, ErrOffline)
+	assert.Equal(t, exitcode.Offline, exitcode.Of(err))
+	assert.Equal(t, "Calling the platform requires network access: "+ErrOffline.Error(), err.Error())
+
+	wrapped := fmt.Errorf("failed to validate: %w", err)
+	assert.Equal(t, exitcode.Offline, exitcode.Of(wrapped))
+}
@@ -0,0 +1,198 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package onerror decides what a bulk operation does when processing one of its items fails: in
+// interactive runs, the user is asked whether to retry the item, skip it or abort the operation; in
+// non-interactive runs (or when specified), the --on-error flag selects the behavior.
+package onerror
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+
+	"github.com/cisco-open/fsoc/cmdkit/offline"
+	"github.com/cisco-open/fsoc/output"
+	"github.com/cisco-open/fsoc/platform/api"
+)
+
+// FlagName is the name of the flag added by AddFlag
+const FlagName = "on-error"
+
+// Modes of handling errors, selected with the --on-error flag
+const (
+	Prompt = "prompt" // ask the user (requires a terminal)
+	Retry  = "retry"  // retry transient errors a few times, then abort
+	Skip   = "skip"   // skip the item and continue with the rest
+	Abort  = "abort"  // stop the operation
+)
+
+// retry policy for the Retry mode
+const maxRetries = 3
+
+var retryDelay = time.Second // doubled after each retry
+
+// ErrSkipped is returned for items that were skipped after an error
+var ErrSkipped = errors.New("skipped after error")
+
+// AbortError is returned when the operation is to be aborted after an error processing an item;
+// its message is the message of the error
+type AbortError struct {
+	Item string
+	Err  error
+}
+
+func (e *AbortError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *AbortError) Unwrap() error {
+	return e.Err
+}
+
+// AddFlag adds the --on-error flag to a command; the default mode applies to non-interactive runs
+func AddFlag(cmd *cobra.Command, defaultMode string) {
+	cmd.Flags().String(FlagName, defaultMode, fmt.Sprintf("What to do when processing an item fails: %s, %s, %s or %s (in interactive runs, the default is to prompt)", Prompt, Retry, Skip, Abort))
+}
+
+// Handler handles the errors of the items of a bulk operation. It is safe for concurrent use; prompts
+// are serialized.
+type Handler struct {
+	mode string
+	cmd  *cobra.Command
+	in   *bufio.Reader
+
+	mu      sync.Mutex
+	skipAll bool // the user chose to skip all further errors
+}
+
+// New returns the error handler for the command: the mode specified with --on-error or, if the flag
+// was not specified and the input is a terminal, prompting the user
+func New(cmd *cobra.Command) (*Handler, error) {
+	mode, _ := cmd.Flags().GetString(FlagName)
+	if !cmd.Flags().Changed(FlagName) && isTerminal() {
+		mode = Prompt
+	}
+	switch mode {
+	case Prompt:
+		if !isTerminal() {
+			return nil, fmt.Errorf("--%s=%s requires the input to be a terminal", FlagName, Prompt)
+		}
+	case Retry, Skip, Abort:
+	default:
+		return nil, fmt.Errorf("invalid --%s value %q: must be one of %s, %s, %s or %s", FlagName, mode, Prompt, Retry, Skip, Abort)
+	}
+	return newHandler(cmd, mode), nil
+}
+
+func newHandler(cmd *cobra.Command, mode string) *Handler {
+	return &Handler{mode: mode, cmd: cmd, in: bufio.NewReader(cmd.InOrStdin())}
+}
+
+// Do performs the operation on the item, handling its errors per the mode. It returns nil if the
+// operation succeeded (possibly after retries), an error wrapping ErrSkipped if the item was skipped
+// and an *AbortError if the bulk operation is to be aborted. An operation canceled by an interrupt
+// is never retried or skipped: it aborts the bulk operation.
+func (h *Handler) Do(item string, op func() error) error {
+	delay := retryDelay
+	for attempt := 1; ; attempt++ {
+		err := op()
+		if err == nil {
+			return nil
+		}
+		if errors.Is(err, context.Canceled) {
+			return &AbortError{Item: item, Err: err}
+		}
+
+		action := h.mode
+		if action == Prompt {
+			action = h.prompt(item, err)
+		}
+		switch action {
+		case Retry:
+			if h.mode == Retry {
+				if !IsTransient(err) || attempt > maxRetries {
+					return &AbortError{Item: item, Err: err}
+				}
+				log.Warnf("Failed to process %s (attempt %d): %v; retrying in %v", item, attempt, err, delay)
+				time.Sleep(delay)
+				delay *= 2
+			}
+		case Skip:
+			log.Warnf("Skipping %s: %v", item, err)
+			return fmt.Errorf("%w: %v", ErrSkipped, err)
+		default:
+			return &AbortError{Item: item, Err: err}
+		}
+	}
+}
+
+// prompt asks the user what to do after the error, returning the mode to apply
+func (h *Handler) prompt(item string, err error) string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.skipAll {
+		return Skip
+	}
+	output.PrintCmdStatus(h.cmd, fmt.Sprintf("Failed to process %s: %v\n", item, err))
+	for {
+		output.PrintCmdStatus(h.cmd, "[r]etry, [s]kip, skip a[l]l, or [a]bort? ")
+		line, err := h.in.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			return Abort
+		}
+		switch strings.ToLower(strings.TrimSpace(line)) {
+		case "r", "retry":
+			return Retry
+		case "s", "skip":
+			return Skip
+		case "l", "skip all":
+			h.skipAll = true
+			return Skip
+		case "a", "abort":
+			return Abort
+		}
+	}
+}
+
+// IsTransient returns true if the error is likely to go away if the operation is retried: network
+// errors, timeouts and the API responses for throttling and server-side failures
+func IsTransient(err error) bool {
+	if errors.Is(err, offline.ErrOffline) {
+		return false
+	}
+	var problem api.Problem
+	if errors.As(err, &problem) {
+		return problem.Status == http.StatusTooManyRequests || problem.Status >= 500
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+func isTerminal() bool {
+	fi, err := os.Stdin.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,119 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package onerror
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cisco-open/fsoc/platform/api"
+)
+
+func testCommand(input string) *cobra.Command {
+	cmd := &cobra.Command{Use: "test"}
+	cmd.Flags().String("output", "auto", "")
+	cmd.SetIn(strings.NewReader(input))
+	cmd.SetOut(&bytes.Buffer{})
+	return cmd
+}
+
+// failing returns an operation that fails the first n times with the error
+func failing(n int, err error) (func() error, *int) {
+	calls := 0
+	return func() error {
+		calls++
+		if calls <= n {
+			return err
+		}
+		return nil
+	}, &calls
+}
+
+func TestModes(t *testing.T) {
+	retryDelay = time.Millisecond
+	transient := api.Problem{Title: "Unavailable", Status: 503}
+	permanent := api.Problem{Title: "Bad Request", Status: 400}
+
+	op, calls := failing(2, transient)
+	assert.Nil(t, newHandler(testCommand(""), Retry).Do("a", op))
+	assert.Equal(t, 3, *calls)
+
+	op, _ = failing(10, transient)
+	var abortErr *AbortError
+	assert.True(t, errors.As(newHandler(testCommand(""), Retry).Do("a", op), &abortErr))
+
+	// permanent errors are not retried
+	op, calls = failing(1, permanent)
+	assert.True(t, errors.As(newHandler(testCommand(""), Retry).Do("a", op), &abortErr))
+	assert.Equal(t, 1, *calls)
+
+	op, _ = failing(1, permanent)
+	err := newHandler(testCommand(""), Skip).Do("a", op)
+	assert.True(t, errors.Is(err, ErrSkipped))
+	assert.Equal(t, "skipped after error: Bad Request: ", err.Error())
+
+	op, _ = failing(1, permanent)
+	err = newHandler(testCommand(""), Abort).Do("item a", op)
+	assert.True(t, errors.As(err, &abortErr))
+	assert.Equal(t, "item a", abortErr.Item)
+	var problem api.Problem
+	assert.True(t, errors.As(err, &problem))
+	assert.Equal(t, 400, problem.Status)
+
+	// interrupted operations abort without retrying, skipping or prompting
+	for _, mode := range []string{Retry, Skip, Prompt} {
+		op, calls = failing(1, fmt.Errorf("request interrupted: %w", context.Canceled))
+		assert.True(t, errors.As(newHandler(testCommand(""), mode).Do("a", op), &abortErr), mode)
+		assert.Equal(t, 1, *calls, mode)
+	}
+}
+
+func TestPrompt(t *testing.T) {
+	// invalid answers are asked again; retry, then succeed
+	cmd := testCommand("x\nr\n")
+	op, calls := failing(1, fmt.Errorf("boom"))
+	assert.Nil(t, newHandler(cmd, Prompt).Do("a", op))
+	assert.Equal(t, 2, *calls)
+	assert.Contains(t, cmd.OutOrStdout().(*bytes.Buffer).String(), "Failed to process a: boom")
+
+	// skip all applies to later errors without asking
+	h := newHandler(testCommand("l\n"), Prompt)
+	op, _ = failing(1, fmt.Errorf("boom"))
+	assert.True(t, errors.Is(h.Do("a", op), ErrSkipped))
+	op, _ = failing(1, fmt.Errorf("boom"))
+	assert.True(t, errors.Is(h.Do("b", op), ErrSkipped))
+
+	// abort, also when the input ends
+	var abortErr *AbortError
+	op, _ = failing(1, fmt.Errorf("boom"))
+	assert.True(t, errors.As(newHandler(testCommand("a\n"), Prompt).Do("a", op), &abortErr))
+	op, _ = failing(1, fmt.Errorf("boom"))
+	assert.True(t, errors.As(newHandler(testCommand(""), Prompt).Do("a", op), &abortErr))
+}
+
+func TestIsTransient(t *testing.T) {
+	assert.True(t, IsTransient(api.Problem{Status: 429}))
+	assert.True(t, IsTransient(fmt.Errorf("wrapped: %w", api.Problem{Status: 502})))
+	assert.False(t, IsTransient(api.Problem{Status: 404}))
+	assert.False(t, IsTransient(fmt.Errorf("error response: bad")))
+}
@@ -0,0 +1,95 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package picker
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// scoring of fuzzy matches: every matched character scores, more so when it follows the previous
+// matched character or starts a word, so that "sf" ranks "spacefleet" below "space-fleet"
+const (
+	scoreMatch       = 1
+	bonusConsecutive = 5
+	bonusWordStart   = 3
+	penaltyGap       = 1
+)
+
+// Match reports whether the pattern matches the text as a case-insensitive subsequence (the pattern's
+// characters appear in the text in order, not necessarily adjacent) and, if so, the match's score
+// (higher is better). An empty pattern matches everything with a score of 0. Spaces in the pattern
+// separate terms that must all match.
+func Match(pattern string, text string) (int, bool) {
+	total := 0
+	for _, term := range strings.Fields(pattern) {
+		score, ok := matchTerm([]rune(strings.ToLower(term)), []rune(text))
+		if !ok {
+			return 0, false
+		}
+		total += score
+	}
+	return total, true
+}
+
+func matchTerm(pattern []rune, text []rune) (int, bool) {
+	score := 0
+	p := 0
+	last := -2
+	for i, r := range text {
+		if p == len(pattern) {
+			break
+		}
+		if unicode.ToLower(r) != pattern[p] {
+			continue
+		}
+		score += scoreMatch
+		switch {
+		case i == last+1:
+			score += bonusConsecutive
+		case last >= 0:
+			score -= penaltyGap
+		}
+		if i == 0 || !unicode.IsLetter(text[i-1]) && !unicode.IsDigit(text[i-1]) || unicode.IsUpper(r) && unicode.IsLower(text[i-1]) {
+			score += bonusWordStart
+		}
+		last = i
+		p++
+	}
+	return score, p == len(pattern)
+}
+
+// Filter returns the indexes of the items that match the pattern, best matches first; items with
+// the same score keep their original order
+func Filter(pattern string, items []string) []int {
+	type match struct {
+		index int
+		score int
+	}
+	matches := []match{}
+	for i, item := range items {
+		if score, ok := Match(pattern, item); ok {
+			matches = append(matches, match{index: i, score: score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+
+	indexes := make([]int, len(matches))
+	for i, m := range matches {
+		indexes[i] = m.index
+	}
+	return indexes
+}
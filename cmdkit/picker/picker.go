@@ -0,0 +1,330 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package picker implements an interactive, fuzzy-searchable selector for choosing items from a list
+// on the terminal, used by commands with the --pick flag to continue with only the chosen items, and
+// by commands that prompt for a missing solution, profile or object when running interactively.
+package picker
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// FlagName is the name of the flag added by AddFlag
+const FlagName = "pick"
+
+// InteractiveFlagName is the name of the global flag that controls prompting for missing values
+const InteractiveFlagName = "interactive"
+
+// ErrCanceled is returned when the user cancels the selection
+var ErrCanceled = errors.New("selection canceled")
+
+// default number of items displayed at a time
+const defaultHeight = 10
+
+// Options control the picker
+type Options struct {
+	Prompt string // displayed before the search text
+	Single bool   // select exactly one item (default is one or more)
+	Height int    // number of items displayed at a time (default is 10)
+}
+
+// AddFlag adds the --pick flag to a command
+func AddFlag(cmd *cobra.Command) {
+	cmd.Flags().Bool(FlagName, false, "Choose the items interactively from a searchable list")
+}
+
+// Requested returns true if the --pick flag was specified for the command
+func Requested(cmd *cobra.Command) bool {
+	pick, _ := cmd.Flags().GetBool(FlagName)
+	return pick
+}
+
+// isTerminal reports whether the file is a terminal; replaced in tests
+var isTerminal = func(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
+
+// Interactive returns true if the command may use the picker to prompt for a value missing from its
+// command line, instead of failing: when --interactive is specified or, unless --interactive=false or
+// --quiet is specified, when both the standard input and the standard error are terminals
+func Interactive(cmd *cobra.Command) bool {
+	if f := cmd.Flags().Lookup(InteractiveFlagName); f != nil && f.Changed {
+		interactive, _ := cmd.Flags().GetBool(InteractiveFlagName)
+		return interactive
+	}
+	if quiet, _ := cmd.Flags().GetBool("quiet"); quiet {
+		return false
+	}
+	return isTerminal(os.Stdin) && isTerminal(os.Stderr)
+}
+
+// Pick displays the items on the terminal and lets the user narrow them down by typing a fuzzy search
+// and choose one or more of them. It returns the indexes of the chosen items, in their original order.
+// The picker reads from the terminal even if the standard input is redirected; it fails if there is no
+// terminal.
+func Pick(items []string, options *Options) ([]int, error) {
+	if len(items) == 0 {
+		return nil, errors.New("there are no items to choose from")
+	}
+	if options == nil {
+		options = &Options{}
+	}
+
+	in, closeIn, err := openTerminal()
+	if err != nil {
+		return nil, fmt.Errorf("choosing interactively requires a terminal: %w", err)
+	}
+	defer closeIn()
+	state, err := term.MakeRaw(int(in.Fd()))
+	if err != nil {
+		return nil, fmt.Errorf("choosing interactively requires a terminal: %w", err)
+	}
+	defer func() { _ = term.Restore(int(in.Fd()), state) }()
+
+	p := newPicker(items, options)
+	return p.run(in, os.Stderr)
+}
+
+// openTerminal returns the terminal input: the standard input, if it is a terminal, or the
+// process's controlling terminal otherwise
+func openTerminal() (*os.File, func(), error) {
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		return os.Stdin, func() {}, nil
+	}
+	name := "/dev/tty"
+	if runtime.GOOS == "windows" {
+		name = "CONIN$"
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !term.IsTerminal(int(f.Fd())) {
+		f.Close()
+		return nil, nil, errors.New("no terminal found")
+	}
+	return f, func() { f.Close() }, nil
+}
+
+// keys recognized by the picker
+const (
+	keyCtrlC     = 3
+	keyTab       = 9
+	keyCtrlN     = 14
+	keyEnter     = 13
+	keyCtrlP     = 16
+	keyCtrlU     = 21
+	keyEscape    = 27
+	keyBackspace = 127
+	keyCtrlH     = 8
+	keyUp        = -1 // escape sequences are mapped to negative values
+	keyDown      = -2
+)
+
+// picker is the state of the selection
+type picker struct {
+	items    []string
+	options  Options
+	query    string
+	matches  []int        // indexes of the items matching the query, best first
+	cursor   int          // position in matches
+	offset   int          // first match displayed
+	selected map[int]bool // chosen items, by index
+	drawn    int          // number of lines drawn, to be erased on redraw
+}
+
+func newPicker(items []string, options *Options) *picker {
+	p := &picker{items: items, options: *options, selected: map[int]bool{}}
+	if p.options.Height <= 0 {
+		p.options.Height = defaultHeight
+	}
+	if p.options.Prompt == "" {
+		p.options.Prompt = "Search"
+	}
+	p.filter()
+	return p
+}
+
+// run processes the keys read from the input until the selection is accepted or canceled
+func (p *picker) run(in io.Reader, out io.Writer) ([]int, error) {
+	buf := make([]byte, 64)
+	for {
+		p.render(out)
+		n, err := in.Read(buf)
+		if err != nil {
+			p.clear(out)
+			return nil, err
+		}
+		for _, key := range parseKeys(buf[:n]) {
+			done, err := p.handleKey(key)
+			if done || err != nil {
+				p.clear(out)
+				return p.result(), err
+			}
+		}
+	}
+}
+
+// parseKeys converts the bytes read from the terminal into keys, decoding the arrow key sequences
+func parseKeys(b []byte) []rune {
+	keys := []rune{}
+	s := string(b)
+	for len(s) > 0 {
+		switch {
+		case strings.HasPrefix(s, "\x1b[A") || strings.HasPrefix(s, "\x1bOA"):
+			keys = append(keys, keyUp)
+			s = s[3:]
+		case strings.HasPrefix(s, "\x1b[B") || strings.HasPrefix(s, "\x1bOB"):
+			keys = append(keys, keyDown)
+			s = s[3:]
+		case strings.HasPrefix(s, "\x1b[") && len(s) >= 3:
+			s = s[3:] // other sequences are ignored
+		default:
+			r := []rune(s)[0]
+			keys = append(keys, r)
+			s = s[len(string(r)):]
+		}
+	}
+	return keys
+}
+
+// handleKey updates the state for a key; it returns true when the selection is complete
+func (p *picker) handleKey(key rune) (bool, error) {
+	switch key {
+	case keyCtrlC, keyEscape:
+		return true, ErrCanceled
+	case keyEnter:
+		if len(p.selected) == 0 {
+			if len(p.matches) == 0 {
+				return false, nil
+			}
+			p.selected[p.matches[p.cursor]] = true
+		}
+		return true, nil
+	case keyUp, keyCtrlP:
+		p.move(-1)
+	case keyDown, keyCtrlN:
+		p.move(1)
+	case keyTab:
+		if len(p.matches) > 0 && !p.options.Single {
+			index := p.matches[p.cursor]
+			if p.selected[index] {
+				delete(p.selected, index)
+			} else {
+				p.selected[index] = true
+			}
+			p.move(1)
+		}
+	case keyBackspace, keyCtrlH:
+		if r := []rune(p.query); len(r) > 0 {
+			p.query = string(r[:len(r)-1])
+			p.filter()
+		}
+	case keyCtrlU:
+		p.query = ""
+		p.filter()
+	default:
+		if key >= ' ' {
+			p.query += string(key)
+			p.filter()
+		}
+	}
+	return false, nil
+}
+
+func (p *picker) filter() {
+	p.matches = Filter(p.query, p.items)
+	p.cursor = 0
+	p.offset = 0
+}
+
+func (p *picker) move(delta int) {
+	if len(p.matches) == 0 {
+		return
+	}
+	p.cursor = (p.cursor + delta + len(p.matches)) % len(p.matches)
+	if p.cursor < p.offset {
+		p.offset = p.cursor
+	} else if p.cursor >= p.offset+p.options.Height {
+		p.offset = p.cursor - p.options.Height + 1
+	}
+}
+
+// result returns the indexes of the chosen items, in their original order
+func (p *picker) result() []int {
+	indexes := []int{}
+	for i := range p.items {
+		if p.selected[i] {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+// lines returns the lines displaying the current state
+func (p *picker) lines() []string {
+	lines := []string{fmt.Sprintf("%s: %s", p.options.Prompt, p.query)}
+	end := p.offset + p.options.Height
+	if end > len(p.matches) {
+		end = len(p.matches)
+	}
+	for i := p.offset; i < end; i++ {
+		index := p.matches[i]
+		pointer, mark := "  ", "[ ]"
+		if i == p.cursor {
+			pointer = "> "
+		}
+		if p.selected[index] {
+			mark = "[x]"
+		}
+		if p.options.Single {
+			mark = ""
+		} else {
+			mark += " "
+		}
+		lines = append(lines, pointer+mark+p.items[index])
+	}
+	help := "up/down to move, enter to accept, esc to cancel"
+	if !p.options.Single {
+		help = "up/down to move, tab to (un)select, enter to accept, esc to cancel"
+	}
+	lines = append(lines, fmt.Sprintf("  %d/%d items, %d selected; %s", len(p.matches), len(p.items), len(p.selected), help))
+	return lines
+}
+
+// render redraws the picker, replacing the previously drawn lines; the cursor is left on the search line
+func (p *picker) render(out io.Writer) {
+	p.clear(out)
+	lines := p.lines()
+	fmt.Fprint(out, strings.Join(lines, "\r\n"))
+	p.drawn = len(lines)
+	fmt.Fprintf(out, "\x1b[%dA\r\x1b[%dC", p.drawn-1, len([]rune(lines[0])))
+}
+
+// clear erases the lines drawn by render
+func (p *picker) clear(out io.Writer) {
+	if p.drawn > 0 {
+		fmt.Fprint(out, "\r\x1b[J")
+		p.drawn = 0
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package picker
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatch(t *testing.T) {
+	_, ok := Match("spflt", "spacefleet")
+	assert.True(t, ok)
+	_, ok = Match("SPACE", "spacefleet")
+	assert.True(t, ok)
+	_, ok = Match("fleets", "spacefleet")
+	assert.False(t, ok)
+	_, ok = Match("", "anything")
+	assert.True(t, ok)
+
+	// all space-separated terms must match
+	_, ok = Match("space zod", "spacefleet")
+	assert.False(t, ok)
+	_, ok = Match("fleet space", "spacefleet")
+	assert.True(t, ok)
+
+	// consecutive and word-start matches rank higher
+	consecutive, _ := Match("fle", "spacefleet")
+	scattered, _ := Match("fle", "fxlxe")
+	assert.Greater(t, consecutive, scattered)
+	wordStart, _ := Match("sf", "space-fleet")
+	inWord, _ := Match("sf", "spacefleet")
+	assert.Greater(t, wordStart, inWord)
+}
+
+func TestFilter(t *testing.T) {
+	items := []string{"zodiac", "spacefleet", "space-fleet", "fleet"}
+	assert.Equal(t, []int{2, 3, 1}, Filter("fleet", items))
+	assert.Equal(t, []int{0, 1, 2, 3}, Filter("", items))
+	assert.Empty(t, Filter("xyz", items))
+}
+
+func TestParseKeys(t *testing.T) {
+	assert.Equal(t, []rune{'a', keyUp, keyDown, keyEnter}, parseKeys([]byte("a\x1b[A\x1b[B\r")))
+	assert.Equal(t, []rune{keyEscape}, parseKeys([]byte("\x1b")))
+	assert.Equal(t, []rune{'é'}, parseKeys([]byte("é")))
+}
+
+func TestPicker(t *testing.T) {
+	items := []string{"zodiac", "spacefleet", "fleetops", "apm"}
+
+	// type a search, select two items and accept
+	p := newPicker(items, &Options{})
+	out := &bytes.Buffer{}
+	chosen, err := p.run(strings.NewReader("fleet\t\t\r"), out)
+	require.Nil(t, err)
+	assert.Equal(t, []int{1, 2}, chosen)
+	assert.Equal(t, "Search: fleet", p.lines()[0])
+	assert.True(t, strings.HasSuffix(out.String(), "\r\x1b[J"), "display is erased")
+
+	// accept without selecting chooses the item under the cursor
+	p = newPicker(items, &Options{Single: true})
+	chosen, err = p.run(strings.NewReader("\x1b[B\x1b[B\r"), &bytes.Buffer{})
+	require.Nil(t, err)
+	assert.Equal(t, []int{2}, chosen)
+
+	// backspace widens the search again; escape cancels
+	p = newPicker(items, &Options{})
+	_, err = p.run(strings.NewReader("zx\x7f\x1b"), &bytes.Buffer{})
+	assert.Equal(t, ErrCanceled, err)
+	assert.Equal(t, "z", p.query)
+	assert.Equal(t, []int{0}, p.matches)
+
+	// the display scrolls to keep the cursor visible
+	p = newPicker(items, &Options{Height: 2})
+	for i := 0; i < 3; i++ {
+		_, _ = p.handleKey(keyDown)
+	}
+	lines := p.lines()
+	require.Len(t, lines, 4)
+	assert.Equal(t, "> [ ] apm", lines[2])
+}
+
+func TestInteractive(t *testing.T) {
+	terminal := true
+	saved := isTerminal
+	isTerminal = func(*os.File) bool { return terminal }
+	defer func() { isTerminal = saved }()
+
+	newCmd := func(args ...string) *cobra.Command {
+		cmd := &cobra.Command{Use: "test"}
+		cmd.Flags().Bool(InteractiveFlagName, false, "")
+		cmd.Flags().Bool("quiet", false, "")
+		require.NoError(t, cmd.Flags().Parse(args))
+		return cmd
+	}
+
+	// by default, prompting depends on running on a terminal
+	assert.True(t, Interactive(newCmd()))
+	assert.False(t, Interactive(newCmd("--quiet")))
+	assert.False(t, Interactive(newCmd("--interactive=false")))
+	terminal = false
+	assert.False(t, Interactive(newCmd()))
+
+	// the flag forces prompting, e.g., when the output is piped through a pager
+	assert.True(t, Interactive(newCmd("--interactive")))
+	assert.True(t, Interactive(newCmd("--interactive", "--quiet")))
+}
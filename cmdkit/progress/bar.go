@@ -0,0 +1,98 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package progress
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	progressBarWidth    = 30
+	progressRedrawDelay = 100 * time.Millisecond
+)
+
+// Bar displays the progress of a transfer (e.g., an upload) on stderr. When stderr is not a terminal,
+// only the start and the completion are reported, on separate lines; nothing is displayed if progress
+// is disabled (see SetQuiet).
+type Bar struct {
+	label       string
+	silent      bool
+	interactive bool
+	started     bool
+	done        bool
+	lastDraw    time.Time
+}
+
+// NewBar returns a progress bar for the transfer described by the label, e.g., "Uploading solution.zip"
+func NewBar(label string) *Bar {
+	mu.Lock()
+	defer mu.Unlock()
+	return &Bar{label: label, silent: quiet, interactive: isTerminal()}
+}
+
+// Update redraws the bar with the number of bytes transferred so far; it can be used as an api.ProgressFunc
+func (p *Bar) Update(sent int64, total int64) {
+	if sent == 0 { // (re)started, e.g., when retrying after login
+		p.started, p.done = false, false
+	}
+	if p.done || p.silent {
+		return
+	}
+	complete := sent >= total
+	if !p.interactive {
+		if !p.started {
+			fmt.Fprintf(os.Stderr, "%s (%s)...\n", p.label, formatByteSize(total))
+		}
+		if complete {
+			fmt.Fprintf(os.Stderr, "%s: done, waiting for the server to respond\n", p.label)
+		}
+	} else if complete || time.Since(p.lastDraw) >= progressRedrawDelay {
+		fraction := 1.0
+		if total > 0 {
+			fraction = float64(sent) / float64(total)
+		}
+		filled := int(fraction * progressBarWidth)
+		fmt.Fprintf(os.Stderr, "\r%s [%s%s] %3.0f%% %s/%s",
+			p.label,
+			strings.Repeat("=", filled),
+			strings.Repeat(" ", progressBarWidth-filled),
+			fraction*100,
+			formatByteSize(sent),
+			formatByteSize(total))
+		if complete {
+			fmt.Fprintln(os.Stderr)
+		}
+		p.lastDraw = time.Now()
+	}
+	p.started = true
+	p.done = complete
+}
+
+// formatByteSize returns a human-readable size, e.g., "12.3 MB"
+func formatByteSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,177 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package progress displays indicators of the progress of long-running operations on stderr, so that
+// users can tell a working command from a hung one: spinners for operations of unknown length and bars
+// for transfers of known size. Spinners are displayed only when stderr is a terminal and progress has
+// not been disabled (e.g., with --quiet), so that they never end up in the output captured by scripts.
+// Only one spinner is displayed at a time: while an operation's spinner is active, the spinners of the
+// steps it performs (e.g., the individual API calls) are silent.
+package progress
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/briandowns/spinner"
+	"github.com/fatih/color"
+	"golang.org/x/term"
+)
+
+var (
+	mu     sync.Mutex
+	quiet  bool
+	active *Spinner // the spinner being displayed, if any
+)
+
+// isTerminal returns true if stderr is a terminal, on which progress can be displayed
+var isTerminal = func() bool {
+	return term.IsTerminal(int(os.Stderr.Fd()))
+}
+
+var statusChar = map[bool]string{
+	false: color.RedString("×"),   // cross mark
+	true:  color.GreenString("✓"), // checkmark
+}
+
+// SetQuiet disables the progress indicators, e.g., for --quiet or when fsoc is used as a library
+func SetQuiet(q bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	quiet = q
+}
+
+// Enabled returns true if progress indicators are displayed: stderr is a terminal and they are not disabled
+func Enabled() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return !quiet && isTerminal()
+}
+
+// Handler wraps the log handler that writes to stderr, so that log messages at or above the level (i.e.,
+// those that are displayed) are not mixed with the spinner: the spinner is erased while a message is
+// written and redrawn after it, unless the message is fatal.
+func Handler(h log.Handler, level log.Level) log.Handler {
+	return log.HandlerFunc(func(e *log.Entry) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if e.Level < level || active == nil {
+			return h.HandleLog(e)
+		}
+		active.spinner.FinalMSG = ""
+		active.spinner.Stop()
+		if e.Level != log.FatalLevel {
+			defer active.spinner.Start()
+		}
+		return h.HandleLog(e)
+	})
+}
+
+// Spinner indicates that an operation of unknown length is in progress. Its methods can be called on a
+// nil Spinner, which is silent.
+type Spinner struct {
+	message string
+	detail  string
+	spinner *spinner.Spinner // nil if silent or stopped
+}
+
+// Start displays a spinner with the message, e.g., "UQL query", as "<message> in progress" until it is
+// stopped. The spinner is silent if progress is not displayed or another spinner is already active.
+func Start(message string) *Spinner {
+	mu.Lock()
+	defer mu.Unlock()
+	s := &Spinner{message: message}
+	if quiet || active != nil || !isTerminal() {
+		return s
+	}
+	s.spinner = spinner.New(spinner.CharSets[21], 50*time.Millisecond, spinner.WithWriterFile(os.Stderr))
+	_ = s.spinner.Color("cyan")
+	s.spinner.Suffix = s.suffix()
+	active = s
+	s.spinner.Start()
+	return s
+}
+
+// Update sets the details displayed after the message, e.g., the number of items processed so far
+func (s *Spinner) Update(detail string) {
+	if s == nil {
+		return
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	s.detail = detail
+	if s.spinner != nil {
+		s.spinner.Lock()
+		s.spinner.Suffix = s.suffix()
+		s.spinner.Unlock()
+	}
+}
+
+// Stop stops the spinner, replacing it with the message and a mark of the operation's success or failure
+func (s *Spinner) Stop(ok bool) {
+	s.stop(statusChar[ok])
+}
+
+// Hide stops the spinner, removing it from the display
+func (s *Spinner) Hide() {
+	s.stop("")
+}
+
+// stop stops the spinner, replacing it with the mark and the message, or with nothing if mark is empty
+func (s *Spinner) stop(mark string) {
+	if s == nil {
+		return
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if s.spinner == nil {
+		return
+	}
+	s.spinner.FinalMSG = ""
+	if mark != "" {
+		s.spinner.FinalMSG = mark + " " + s.text() + "\n"
+	}
+	s.spinner.Stop()
+	s.spinner = nil
+	if active == s {
+		active = nil
+	}
+}
+
+// Active returns true if the spinner is being displayed
+func (s *Spinner) Active() bool {
+	if s == nil {
+		return false
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	return s.spinner != nil
+}
+
+func (s *Spinner) text() string {
+	if s.detail != "" {
+		return s.message + ": " + s.detail
+	}
+	return s.message
+}
+
+func (s *Spinner) suffix() string {
+	suffix := " " + s.message + " in progress"
+	if s.detail != "" {
+		suffix += ": " + s.detail
+	}
+	return suffix
+}
@@ -0,0 +1,90 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package progress
+
+import (
+	"testing"
+
+	"github.com/apex/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func withTerminal(t *testing.T, terminal bool) {
+	saved := isTerminal
+	isTerminal = func() bool { return terminal }
+	t.Cleanup(func() {
+		isTerminal = saved
+		SetQuiet(false)
+	})
+}
+
+func TestSilentWhenNotTerminal(t *testing.T) {
+	withTerminal(t, false)
+	assert.False(t, Enabled())
+	s := Start("test")
+	assert.False(t, s.Active())
+	s.Update("1 of 2")
+	s.Stop(true)
+	assert.Equal(t, "test: 1 of 2", s.text())
+}
+
+func TestSilentWhenQuiet(t *testing.T) {
+	withTerminal(t, true)
+	SetQuiet(true)
+	assert.False(t, Enabled())
+	assert.False(t, Start("test").Active())
+	assert.True(t, NewBar("upload").silent)
+}
+
+func TestOneSpinnerAtATime(t *testing.T) {
+	withTerminal(t, true)
+	outer := Start("operation")
+	assert.True(t, outer.Active())
+	inner := Start("step")
+	assert.False(t, inner.Active())
+	inner.Stop(true)
+	assert.True(t, outer.Active())
+
+	outer.Hide()
+	assert.False(t, outer.Active())
+	outer.Stop(false) // already stopped
+	next := Start("next")
+	assert.True(t, next.Active())
+	next.Hide()
+}
+
+func TestNilSpinner(t *testing.T) {
+	var s *Spinner
+	s.Update("detail")
+	s.Stop(true)
+	s.Hide()
+	assert.False(t, s.Active())
+}
+
+func TestHandlerPassesEntries(t *testing.T) {
+	withTerminal(t, true)
+	var messages []string
+	h := Handler(log.HandlerFunc(func(e *log.Entry) error {
+		messages = append(messages, e.Message)
+		return nil
+	}), log.WarnLevel)
+
+	s := Start("operation")
+	defer s.Hide()
+	assert.NoError(t, h.HandleLog(&log.Entry{Level: log.WarnLevel, Message: "warning"}))
+	assert.NoError(t, h.HandleLog(&log.Entry{Level: log.InfoLevel, Message: "info"}))
+	assert.Equal(t, []string{"warning", "info"}, messages)
+	assert.True(t, s.Active())
+}
@@ -0,0 +1,246 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package selector implements Kubernetes-style label selectors, used by list commands
+// to select items with the -l/--selector flag, e.g., -l "env=prod,tier in (web,api),!deprecated".
+// Selectors are translated into SCIM filters for the APIs that support them and can also be
+// evaluated on the client side.
+package selector
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Operators of selector requirements
+const (
+	Equals       = "="
+	NotEquals    = "!="
+	In           = "in"
+	NotIn        = "notin"
+	Exists       = "exists"
+	DoesNotExist = "!"
+)
+
+// FlagName is the name of the selector flag added by AddFlag
+const FlagName = "selector"
+
+// Requirement is a single condition of a selector, e.g., "env=prod"
+type Requirement struct {
+	Key      string
+	Operator string
+	Values   []string // one value for Equals and NotEquals, none for Exists and DoesNotExist
+}
+
+// Selector is a list of requirements, all of which must be met
+type Selector []Requirement
+
+var (
+	keyRegexp    = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.\-/]*$`)
+	setRegexp    = regexp.MustCompile(`^(\S+)\s+(in|notin)\s*\((.*)\)$`)
+	equalsRegexp = regexp.MustCompile(`^([^=!\s]+)\s*(==|=|!=)\s*(.*)$`)
+)
+
+// Parse parses a selector: a comma-separated list of requirements in one of the forms
+// key=value (or key==value), key!=value, key in (v1,v2), key notin (v1,v2), key (exists) and !key
+// (does not exist). An empty string produces an empty selector, which selects everything.
+func Parse(s string) (Selector, error) {
+	var sel Selector
+	for _, term := range splitTerms(s) {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+		r, err := parseRequirement(term)
+		if err != nil {
+			return nil, err
+		}
+		sel = append(sel, r)
+	}
+	return sel, nil
+}
+
+// splitTerms splits a selector on the commas that are not within parentheses
+func splitTerms(s string) []string {
+	var terms []string
+	depth, start := 0, 0
+	for i, c := range s {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				terms = append(terms, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(terms, s[start:])
+}
+
+func parseRequirement(term string) (Requirement, error) {
+	var r Requirement
+	switch {
+	case strings.HasPrefix(term, "!") && !strings.ContainsAny(term, "=("):
+		r = Requirement{Key: strings.TrimSpace(term[1:]), Operator: DoesNotExist}
+	case setRegexp.MatchString(term):
+		m := setRegexp.FindStringSubmatch(term)
+		r = Requirement{Key: m[1], Operator: m[2]}
+		for _, v := range strings.Split(m[3], ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				r.Values = append(r.Values, v)
+			}
+		}
+		if len(r.Values) == 0 {
+			return r, fmt.Errorf("invalid selector %q: the set of values is empty", term)
+		}
+	case equalsRegexp.MatchString(term):
+		m := equalsRegexp.FindStringSubmatch(term)
+		op := Equals
+		if m[2] == "!=" {
+			op = NotEquals
+		}
+		r = Requirement{Key: m[1], Operator: op, Values: []string{strings.TrimSpace(m[3])}}
+	default:
+		r = Requirement{Key: term, Operator: Exists}
+	}
+	if !keyRegexp.MatchString(r.Key) {
+		return r, fmt.Errorf("invalid selector %q: invalid key %q", term, r.Key)
+	}
+	return r, nil
+}
+
+func (r Requirement) String() string {
+	switch r.Operator {
+	case Exists:
+		return r.Key
+	case DoesNotExist:
+		return "!" + r.Key
+	case In, NotIn:
+		return fmt.Sprintf("%s %s (%s)", r.Key, r.Operator, strings.Join(r.Values, ","))
+	default:
+		return r.Key + r.Operator + r.Values[0]
+	}
+}
+
+func (s Selector) String() string {
+	terms := make([]string, len(s))
+	for i, r := range s {
+		terms[i] = r.String()
+	}
+	return strings.Join(terms, ",")
+}
+
+// SCIMFilter returns the SCIM filter expression equivalent to the selector, with the prefix (e.g., "data.")
+// prepended to the keys. Values that are numbers or booleans are compared as such, other values as strings.
+// An empty selector produces an empty filter.
+func (s Selector) SCIMFilter(prefix string) string {
+	terms := make([]string, len(s))
+	for i, r := range s {
+		attr := prefix + r.Key
+		switch r.Operator {
+		case Exists:
+			terms[i] = attr + " pr"
+		case DoesNotExist:
+			terms[i] = fmt.Sprintf("not(%s pr)", attr)
+		case Equals:
+			terms[i] = fmt.Sprintf("%s eq %s", attr, scimValue(r.Values[0]))
+		case NotEquals:
+			terms[i] = fmt.Sprintf("%s ne %s", attr, scimValue(r.Values[0]))
+		case In, NotIn:
+			alternatives := make([]string, len(r.Values))
+			for j, v := range r.Values {
+				alternatives[j] = fmt.Sprintf("%s eq %s", attr, scimValue(v))
+			}
+			terms[i] = "(" + strings.Join(alternatives, " or ") + ")"
+			if r.Operator == NotIn {
+				terms[i] = "not" + terms[i]
+			}
+		}
+	}
+	return strings.Join(terms, " and ")
+}
+
+func scimValue(v string) string {
+	if v == "true" || v == "false" {
+		return v
+	}
+	if _, err := strconv.ParseFloat(v, 64); err == nil {
+		return v
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+// Matches evaluates the selector on the client side, using the lookup function to get the value of a key
+func (s Selector) Matches(lookup func(key string) (value string, found bool)) bool {
+	for _, r := range s {
+		value, found := lookup(r.Key)
+		var ok bool
+		switch r.Operator {
+		case Exists:
+			ok = found
+		case DoesNotExist:
+			ok = !found
+		case Equals, In:
+			ok = found && contains(r.Values, value)
+		case NotEquals, NotIn:
+			ok = !found || !contains(r.Values, value)
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+// AddFlag adds the -l/--selector flag to a list command
+func AddFlag(cmd *cobra.Command) {
+	cmd.Flags().StringP(FlagName, "l", "", `Select items by their fields, e.g., "env=prod,tier in (web,api),!deprecated"`)
+}
+
+// GetFilter returns the SCIM filter for the selector specified with the flag (see AddFlag) combined,
+// if not empty, with the filter (e.g., from a --filter flag). The prefix is prepended to the selector keys.
+func GetFilter(cmd *cobra.Command, prefix string, filter string) (string, error) {
+	spec, _ := cmd.Flags().GetString(FlagName)
+	sel, err := Parse(spec)
+	if err != nil {
+		return "", err
+	}
+	selFilter := sel.SCIMFilter(prefix)
+	switch {
+	case selFilter == "":
+		return filter, nil
+	case filter == "":
+		return selFilter, nil
+	default:
+		return fmt.Sprintf("(%s) and %s", filter, selFilter), nil
+	}
+}
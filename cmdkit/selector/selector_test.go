@@ -0,0 +1,79 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package selector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	sel, err := Parse("env=prod, tier in (web, api),owner!=me,version==2,!deprecated,region notin (us),label")
+	require.Nil(t, err)
+	assert.Equal(t, Selector{
+		{Key: "env", Operator: Equals, Values: []string{"prod"}},
+		{Key: "tier", Operator: In, Values: []string{"web", "api"}},
+		{Key: "owner", Operator: NotEquals, Values: []string{"me"}},
+		{Key: "version", Operator: Equals, Values: []string{"2"}},
+		{Key: "deprecated", Operator: DoesNotExist},
+		{Key: "region", Operator: NotIn, Values: []string{"us"}},
+		{Key: "label", Operator: Exists},
+	}, sel)
+	assert.Equal(t, "env=prod,tier in (web,api),owner!=me,version=2,!deprecated,region notin (us),label", sel.String())
+
+	sel, err = Parse("")
+	require.Nil(t, err)
+	assert.Empty(t, sel)
+
+	for _, s := range []string{"a b=c", "tier in ()", "=x", "!a b"} {
+		_, err := Parse(s)
+		assert.NotNil(t, err, s)
+	}
+}
+
+func TestSCIMFilter(t *testing.T) {
+	sel, err := Parse(`env=prod,tier in (web,api),isSystem=true,count!=3,!deprecated,region notin (us),name`)
+	require.Nil(t, err)
+	assert.Equal(t,
+		`data.env eq "prod" and (data.tier eq "web" or data.tier eq "api") and data.isSystem eq true and data.count ne 3 and not(data.deprecated pr) and not(data.region eq "us") and data.name pr`,
+		sel.SCIMFilter("data."))
+	assert.Equal(t, "", Selector{}.SCIMFilter("data."))
+}
+
+func TestMatches(t *testing.T) {
+	fields := map[string]string{"env": "prod", "tier": "web"}
+	lookup := func(key string) (string, bool) {
+		v, found := fields[key]
+		return v, found
+	}
+	for spec, expected := range map[string]bool{
+		"":                       true,
+		"env=prod":               true,
+		"env!=prod":              false,
+		"tier in (web,api)":      true,
+		"tier notin (web,api)":   false,
+		"region notin (us)":      true,
+		"env,!deprecated":        true,
+		"deprecated":             false,
+		"env=prod,tier=api":      false,
+		"env=prod,region!=eu,!x": true,
+	} {
+		sel, err := Parse(spec)
+		require.Nil(t, err, spec)
+		assert.Equal(t, expected, sel.Matches(lookup), spec)
+	}
+}
@@ -0,0 +1,220 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package exitcode defines the exit codes of fsoc, which allow scripts to react to the different
+// kinds of failures, and classifies errors into them.
+package exitcode
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/apex/log"
+)
+
+// Exit codes
+const (
+	OK            = 0   // success
+	General       = 1   // failure without a more specific code, or a negative result (e.g., iam can-i)
+	Failed        = 2   // an operation the command waited for failed, e.g., a solution deployment
+	Timeout       = 3   // a timeout expired, waiting for an operation or for a platform response
+	Usage         = 4   // invalid command line: unknown command or flag, missing or invalid arguments
+	ConfigMissing = 5   // fsoc is not configured or the profile doesn't exist
+	Auth          = 6   // authentication failed or the principal lacks the permissions
+	NotFound      = 7   // the requested object or resource doesn't exist
+	Validation    = 8   // the platform rejected the request as invalid, e.g., a solution failing validation
+	ServerError   = 9   // the platform failed to process the request or is unavailable
+	Offline       = 10  // the command needs network access, which is disabled in offline mode
+	Interrupted   = 130 // the command was interrupted with Ctrl+C (SIGINT) or SIGTERM, as shells report it
+)
+
+// Code describes an exit code
+type Code struct {
+	Code        int
+	Name        string
+	Description string
+}
+
+// Codes lists the exit codes with their descriptions, for the help
+var Codes = []Code{
+	{OK, "ok", "The command succeeded"},
+	{General, "error", "The command failed without a more specific code, or reported a negative result (e.g., \"iam can-i\", \"solution diff --exit-code\")"},
+	{Failed, "failed", "An operation the command waited for failed, e.g., a solution deployment with \"solution push --wait\""},
+	{Timeout, "timeout", "A timeout expired, waiting for an operation or for a platform response"},
+	{Usage, "usage", "Invalid command line: unknown command or flag, missing or invalid arguments"},
+	{ConfigMissing, "config-missing", "fsoc is not configured or the selected profile doesn't exist"},
+	{Auth, "auth", "Authentication failed or the principal lacks the required permissions (HTTP 401, 403)"},
+	{NotFound, "not-found", "The requested object or resource doesn't exist (HTTP 404, 410)"},
+	{Validation, "validation", "The platform rejected the request as invalid (HTTP 400, 409, 412, 422)"},
+	{ServerError, "server-error", "The platform failed to process the request or is unavailable (HTTP 5xx)"},
+	{Offline, "offline", "The command needs network access, which is disabled in offline mode (--offline)"},
+	{Interrupted, "interrupted", "The command was interrupted with Ctrl+C (SIGINT) or SIGTERM"},
+}
+
+// Name returns the name of an exit code, e.g., "not-found"; codes not listed in Codes are named
+// as the General code
+func Name(code int) string {
+	for _, c := range Codes {
+		if c.Code == code {
+			return c.Name
+		}
+	}
+	return Codes[General].Name
+}
+
+// Field is the log field that sets the exit code of a fatal log message, e.g.,
+// log.WithField(exitcode.Field, exitcode.ConfigMissing).Fatal("fsoc is not configured")
+const Field = "exit_code"
+
+// Error is an error with an exit code
+type Error struct {
+	Code int
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Wrap returns the error with its exit code, as classified by Of, or nil if err is nil
+func Wrap(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Code: Of(err), Err: err}
+}
+
+// recorded remembers the exit codes of errors that may be reported by a fatal log message, which
+// includes only their text
+var recorded struct {
+	sync.Mutex
+	errors []recordedError
+}
+
+type recordedError struct {
+	message string
+	code    int
+}
+
+const maxRecorded = 16
+
+// Record remembers the exit code of an error, e.g., of a failed platform API call, for when it
+// causes the command to fail
+func Record(err error, code int) {
+	if err == nil || err.Error() == "" || code == General {
+		return
+	}
+	recorded.Lock()
+	defer recorded.Unlock()
+	recorded.errors = append(recorded.errors, recordedError{message: err.Error(), code: code})
+	if len(recorded.errors) > maxRecorded {
+		recorded.errors = recorded.errors[1:]
+	}
+}
+
+// Of returns the exit code of an error: the code of an Error in its chain, the recorded code of the
+// error, Timeout for timeouts or Interrupted for canceled operations; General otherwise
+func Of(err error) int {
+	if err == nil {
+		return OK
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	if code := recordedCode(err.Error()); code != General {
+		return code
+	}
+	if IsTimeout(err) {
+		return Timeout
+	}
+	if errors.Is(err, context.Canceled) {
+		return Interrupted
+	}
+	return General
+}
+
+// IsTimeout returns true if the error is caused by a timeout
+func IsTimeout(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) || errors.Is(err, os.ErrDeadlineExceeded) ||
+		(errors.As(err, &netErr) && netErr.Timeout())
+}
+
+// ForStatus returns the exit code for a failed HTTP response status
+func ForStatus(status int) int {
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return Auth
+	case status == http.StatusNotFound || status == http.StatusGone:
+		return NotFound
+	case status == http.StatusBadRequest || status == http.StatusConflict ||
+		status == http.StatusPreconditionFailed || status == http.StatusUnprocessableEntity:
+		return Validation
+	case status == http.StatusRequestTimeout || status == http.StatusGatewayTimeout:
+		return Timeout
+	case status >= 500 && status <= 599:
+		return ServerError
+	}
+	return General
+}
+
+// recordedCode returns the code of the most recent recorded error whose text is in a message
+func recordedCode(message string) int {
+	recorded.Lock()
+	defer recorded.Unlock()
+	for i := len(recorded.errors) - 1; i >= 0; i-- {
+		if strings.Contains(message, recorded.errors[i].message) {
+			return recorded.errors[i].code
+		}
+	}
+	return General
+}
+
+// ForEntry returns the exit code of a fatal log message: the code in its Field, if any, or the code
+// of a recorded error included in the message
+func ForEntry(e *log.Entry) int {
+	if code, ok := e.Fields[Field].(int); ok {
+		return code
+	}
+	if err, ok := e.Fields["error"].(error); ok {
+		if code := Of(err); code != General {
+			return code
+		}
+	}
+	return recordedCode(e.Message)
+}
+
+// exit is replaced in tests
+var exit = os.Exit
+
+// Handler returns a log handler that exits with the classified exit code on fatal log messages;
+// it must be the last of the handlers, as it exits instead of returning
+func Handler() log.Handler {
+	return log.HandlerFunc(func(e *log.Entry) error {
+		if e.Level == log.FatalLevel {
+			exit(ForEntry(e)) // log.Fatal would exit with General otherwise
+		}
+		return nil
+	})
+}
@@ -0,0 +1,83 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exitcode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/apex/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOf(t *testing.T) {
+	assert.Equal(t, OK, Of(nil))
+	assert.Equal(t, General, Of(errors.New("failed")))
+	assert.Equal(t, NotFound, Of(fmt.Errorf("get: %w", &Error{Code: NotFound, Err: errors.New("no such object")})))
+	assert.Equal(t, Timeout, Of(fmt.Errorf("request failed: %w", context.DeadlineExceeded)))
+	assert.Equal(t, Interrupted, Of(fmt.Errorf("request interrupted: %w", context.Canceled)))
+
+	err := errors.New("error response: invalid solution manifest")
+	Record(err, ForStatus(http.StatusUnprocessableEntity))
+	assert.Equal(t, Validation, Of(err))
+	assert.Equal(t, Validation, Of(fmt.Errorf("push failed: %v", err)))
+
+	wrapped := Wrap(err)
+	assert.Equal(t, err.Error(), wrapped.Error())
+	assert.ErrorIs(t, wrapped, err)
+	assert.Nil(t, Wrap(nil))
+}
+
+func TestName(t *testing.T) {
+	assert.Equal(t, "ok", Name(OK))
+	assert.Equal(t, "not-found", Name(NotFound))
+	assert.Equal(t, "interrupted", Name(Interrupted))
+	assert.Equal(t, "error", Name(42))
+}
+
+func TestForStatus(t *testing.T) {
+	for status, code := range map[int]int{
+		401: Auth, 403: Auth, 404: NotFound, 410: NotFound, 400: Validation, 409: Validation,
+		422: Validation, 504: Timeout, 500: ServerError, 503: ServerError, 429: General,
+	} {
+		assert.Equal(t, code, ForStatus(status), status)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	code := -1
+	exit = func(c int) { code = c }
+	defer func() { exit = os.Exit }()
+
+	assert.NoError(t, Handler().HandleLog(&log.Entry{Level: log.ErrorLevel, Message: "not fatal"}))
+	assert.Equal(t, -1, code)
+
+	e := &log.Entry{Level: log.FatalLevel, Message: "fsoc is not configured", Fields: log.Fields{Field: ConfigMissing}}
+	assert.NoError(t, Handler().HandleLog(e))
+	assert.Equal(t, ConfigMissing, code)
+
+	Record(errors.New("token expired"), Auth)
+	e = &log.Entry{Level: log.FatalLevel, Message: "Failed to get the solutions: token expired"}
+	assert.NoError(t, Handler().HandleLog(e))
+	assert.Equal(t, Auth, code)
+
+	e.Message = "something else failed"
+	assert.NoError(t, Handler().HandleLog(e))
+	assert.Equal(t, General, code)
+}
@@ -0,0 +1,141 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package logfile provides the fsoc log file, which is rotated on each run and when it reaches its
+// maximum size, keeping the logs of a number of previous runs
+package logfile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// AnnotationForNoLogFile marks a command that doesn't write (or rotate) the log file, e.g., to display
+// the log of the previous command
+const AnnotationForNoLogFile = "log/no-file"
+
+// Defaults for the log retention
+const (
+	DefaultKeep    = 5
+	DefaultMaxSize = 10 << 20
+)
+
+// File is a log file that is rotated when it reaches its maximum size. The rotated files have the
+// same path with a numeric suffix, from .1 (most recent) up to the number of files to keep.
+type File struct {
+	path    string
+	keep    int
+	maxSize int64
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// Open rotates the existing log file, if any, so that the log of the previous run is kept, and opens a
+// new log file. The keep argument is the total number of log files to keep, including the new one;
+// a maxSize of 0 means no size limit.
+func Open(path string, keep int, maxSize int64) (*File, error) {
+	if keep < 1 {
+		return nil, fmt.Errorf("the number of log files to keep must be at least 1, found %d", keep)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	f := &File{path: path, keep: keep, maxSize: maxSize}
+	if info, err := os.Stat(path); err == nil && info.Size() > 0 {
+		if err := rotate(path, keep); err != nil {
+			// the log file can't be renamed while another process has it open on Windows (e.g., a concurrent
+			// fsoc command); append to it instead, rather than failing or truncating the other process's log
+			if err := f.open(os.O_APPEND); err != nil {
+				return nil, err
+			}
+			f.size = info.Size()
+			return f, nil
+		}
+	}
+	if err := f.create(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Path returns the path of a log file: the current one for n == 0, or the nth most recent rotated one
+func Path(path string, n int) string {
+	if n == 0 {
+		return path
+	}
+	return fmt.Sprintf("%s.%d", path, n)
+}
+
+// Write writes to the log file, rotating it first if the data would exceed the maximum size
+func (f *File) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.maxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		_ = f.file.Close()
+		if err := rotate(f.path, f.keep); err != nil {
+			return 0, err
+		}
+		if err := f.create(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Close closes the log file
+func (f *File) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.file.Close()
+}
+
+func (f *File) create() error {
+	return f.open(os.O_TRUNC)
+}
+
+// open opens the log file for writing, creating it if needed, with the additional flag (e.g., os.O_APPEND)
+func (f *File) open(flag int) error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|flag, 0600)
+	if err != nil {
+		return err
+	}
+	f.file = file
+	f.size = 0
+	return nil
+}
+
+// rotate shifts the log files by one, dropping the oldest one, so that the path becomes free
+func rotate(path string, keep int) error {
+	if keep == 1 {
+		return nil // the log file is truncated when opened
+	}
+	if err := os.Remove(Path(path, keep-1)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	for n := keep - 2; n >= 0; n-- {
+		err := os.Rename(Path(path, n), Path(path, n+1))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readLog(t *testing.T, path string) string {
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return string(b)
+}
+
+func TestRotationOnOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fsoc.log")
+	for _, run := range []string{"run1\n", "run2\n", "run3\n", "run4\n"} {
+		f, err := Open(path, 3, 0)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(run))
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+	}
+	assert.Equal(t, "run4\n", readLog(t, Path(path, 0)))
+	assert.Equal(t, "run3\n", readLog(t, Path(path, 1)))
+	assert.Equal(t, "run2\n", readLog(t, Path(path, 2)))
+	assert.NoFileExists(t, Path(path, 3))
+
+	// empty logs are not kept
+	f, err := Open(path, 3, 0)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	f, err = Open(path, 3, 0)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	assert.Equal(t, "run4\n", readLog(t, Path(path, 1)))
+
+	// keeping one file truncates it
+	f, err = Open(path, 1, 0)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	assert.Equal(t, "", readLog(t, path))
+
+	_, err = Open(path, 0, 0)
+	assert.Error(t, err)
+}
+
+func TestRotationOnSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fsoc.log")
+	f, err := Open(path, 2, 10)
+	require.NoError(t, err)
+	defer f.Close()
+
+	for _, entry := range []string{"12345\n", "6789\n", "abcdef\n", "a very long entry\n"} {
+		_, err := f.Write([]byte(entry))
+		require.NoError(t, err)
+	}
+	assert.Equal(t, "a very long entry\n", readLog(t, path))
+	assert.Equal(t, "abcdef\n", readLog(t, Path(path, 1)))
+	assert.NoFileExists(t, Path(path, 2))
+}
+
+func TestAppendWhenRotationFails(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fsoc.log")
+	require.NoError(t, os.WriteFile(path, []byte("other\n"), 0600))
+
+	// a non-empty directory in place of the rotated log makes renaming fail, as an open file does on Windows
+	require.NoError(t, os.MkdirAll(filepath.Join(Path(path, 1), "busy"), 0700))
+
+	f, err := Open(path, 2, 0)
+	require.NoError(t, err)
+	_, err = f.Write([]byte("run\n"))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	assert.Equal(t, "other\nrun\n", readLog(t, path))
+}
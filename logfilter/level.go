@@ -0,0 +1,27 @@
+package logfilter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/apex/log"
+)
+
+// LevelNames lists the log levels accepted by ParseLevel, from the most to the least detailed
+var LevelNames = []string{"trace", "debug", "info", "warn", "error"}
+
+// ParseLevel parses a log level name. Trace is accepted for familiarity and is the same as debug,
+// the most detailed level of the logger.
+func ParseLevel(name string) (log.Level, error) {
+	switch strings.ToLower(name) {
+	case "trace", "debug":
+		return log.DebugLevel, nil
+	case "info":
+		return log.InfoLevel, nil
+	case "warn", "warning":
+		return log.WarnLevel, nil
+	case "error":
+		return log.ErrorLevel, nil
+	}
+	return log.InvalidLevel, fmt.Errorf("invalid log level %q; must be one of %s", name, strings.Join(LevelNames, ", "))
+}
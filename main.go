@@ -35,7 +35,7 @@ func realMain() int {
 
 	if err := cmd.Execute(ctx); err != nil {
 		log.WithFields(log.Fields{"error": err}).Error("command failed")
-		return 1
+		return cmd.ExitCode(err)
 	}
 	return 0
 }
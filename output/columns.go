@@ -0,0 +1,97 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/itchyny/gojq"
+)
+
+// Column is a user-defined table column, with its value computed by a JQ expression evaluated on each row
+type Column struct {
+	Name  string
+	Query *gojq.Code
+}
+
+// ParseColumns parses column specifications in the form name=jq_expr, e.g., "rate=.errors / .requests"
+func ParseColumns(specs []string) ([]Column, error) {
+	columns := make([]Column, 0, len(specs))
+	for _, spec := range specs {
+		name, expr, found := strings.Cut(spec, "=")
+		name = strings.TrimSpace(name)
+		if !found || name == "" || strings.TrimSpace(expr) == "" {
+			return nil, fmt.Errorf("invalid column specification %q, expected name=jq_expression", spec)
+		}
+		query, err := compileJQ(expr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the jq expression for column %q: %w", name, err)
+		}
+		columns = append(columns, Column{Name: name, Query: query})
+	}
+	return columns, nil
+}
+
+// createColumnsTable creates a table with the user-defined columns, evaluating each column's
+// expression on every entry of the data's .items array
+func createColumnsTable(v any, columns []Column) *Table {
+	table := Table{Headers: make([]string, len(columns)), Lines: [][]string{}}
+	for i, c := range columns {
+		table.Headers[i] = c.Name
+	}
+
+	data := canonicalizeData(v)
+	m, ok := data.(map[string]any)
+	if !ok {
+		return &table
+	}
+	items, _ := m["items"].([]any)
+	for index, item := range items {
+		line := make([]string, len(columns))
+		for i, c := range columns {
+			line[i] = evalColumn(c, item, index)
+		}
+		table.Lines = append(table.Lines, line)
+	}
+	return &table
+}
+
+// evalColumn evaluates the column expression on a single row, returning the first value produced,
+// converted to a string. Evaluation errors produce an empty value.
+func evalColumn(c Column, row any, index int) string {
+	value, ok := c.Query.Run(row).Next()
+	if !ok {
+		return ""
+	}
+	if err, ok := value.(error); ok {
+		log.Warnf("Failed to compute column %q for row %v: %v", c.Name, index, err)
+		return ""
+	}
+	switch value := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	default:
+		b, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Sprint(value)
+		}
+		return string(b)
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package output
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseColumns(t *testing.T) {
+	columns, err := ParseColumns([]string{"name=.name", "rate = .errors == 0"})
+	require.Nil(t, err)
+	require.Len(t, columns, 2)
+	assert.Equal(t, "name", columns[0].Name)
+	assert.Equal(t, "rate", columns[1].Name)
+
+	for _, spec := range []string{"name", "=.name", "name=", "name=.["} {
+		_, err := ParseColumns([]string{spec})
+		assert.NotNil(t, err, spec)
+	}
+}
+
+func TestCreateColumnsTable(t *testing.T) {
+	columns, err := ParseColumns([]string{
+		"Service=.name",
+		"Error Rate=.errors / .requests",
+		"Tags=.tags",
+		"Missing=.nothing",
+		"Broken=.name + 1",
+	})
+	require.Nil(t, err)
+
+	data := map[string]any{
+		"items": []any{
+			map[string]any{"name": "a", "errors": 1, "requests": 4, "tags": []any{"x", "y"}},
+			map[string]any{"name": "b", "errors": 0, "requests": 10},
+		},
+		"total": 2,
+	}
+	table := createColumnsTable(data, columns)
+	assert.Equal(t, []string{"Service", "Error Rate", "Tags", "Missing", "Broken"}, table.Headers)
+	assert.Equal(t, [][]string{
+		{"a", "0.25", `["x","y"]`, "", ""},
+		{"b", "0", "", "", ""},
+	}, table.Lines)
+
+	// single objects are treated as a one-row list
+	table = createColumnsTable(struct {
+		Name string `json:"name"`
+	}{Name: "solo"}, columns[:1])
+	assert.Equal(t, [][]string{{"solo"}}, table.Lines)
+}
@@ -0,0 +1,113 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/apex/log"
+)
+
+// ParseDistinctFields parses a comma-separated list of fields that identify duplicate entries.
+// Nested fields can be specified with a dotted path (e.g., "metadata.id"). "*" means that
+// entries are compared as a whole, which is represented by an empty (non-nil) list.
+func ParseDistinctFields(spec string) []string {
+	fields := []string{}
+	for _, f := range strings.Split(spec, ",") {
+		f = strings.TrimSpace(f)
+		if f != "" && f != "*" {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// DistinctKey returns a key identifying an entry by the values of the fields (or by the entire entry if no fields)
+func DistinctKey(values ...any) string {
+	b, err := json.Marshal(values)
+	if err != nil {
+		return fmt.Sprintf("%#v", values)
+	}
+	return string(b)
+}
+
+// distinctItems removes duplicate entries from the data's .items array (or from the data itself,
+// if it is a list), keeping the first occurrence of each. It returns the resulting data and, for each
+// original entry, whether it was kept; the latter is nil if nothing was removed (in which case the
+// data is returned unchanged).
+func distinctItems(v any, fields []string) (any, []bool) {
+	var data map[string]any
+	items, isList := v.([]any)
+	if !isList {
+		var ok bool
+		if data, ok = canonicalizeData(v).(map[string]any); !ok {
+			return v, nil
+		}
+		if items, ok = data["items"].([]any); !ok {
+			return v, nil
+		}
+	}
+
+	seen := map[string]bool{}
+	kept := make([]bool, len(items))
+	distinct := make([]any, 0, len(items))
+	for i, item := range items {
+		var key string
+		if len(fields) == 0 {
+			key = DistinctKey(item)
+		} else {
+			values := make([]any, len(fields))
+			for j, f := range fields {
+				values[j] = lookupField(item, f)
+			}
+			key = DistinctKey(values...)
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		kept[i] = true
+		distinct = append(distinct, item)
+	}
+	if len(distinct) == len(items) {
+		return v, nil
+	}
+
+	log.Infof("Removed %v duplicate entries from the output", len(items)-len(distinct))
+	if isList {
+		return distinct, kept
+	}
+	result := make(map[string]any, len(data))
+	for k, v := range data {
+		result[k] = v
+	}
+	result["items"] = distinct
+	result["total"] = len(distinct)
+	return result, kept
+}
+
+// lookupField returns the value of a field specified with a dotted path or nil if not found
+func lookupField(item any, path string) any {
+	for _, name := range strings.Split(path, ".") {
+		m, ok := item.(map[string]any)
+		if !ok {
+			return nil
+		}
+		item = m[name]
+	}
+	return item
+}
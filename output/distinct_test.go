@@ -0,0 +1,54 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package output
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDistinctFields(t *testing.T) {
+	assert.Equal(t, []string{"id", "metadata.name"}, ParseDistinctFields(" id, metadata.name ,"))
+	assert.Equal(t, []string{}, ParseDistinctFields("*"))
+}
+
+func TestDistinctItems(t *testing.T) {
+	data := map[string]any{
+		"items": []any{
+			map[string]any{"id": "1", "metadata": map[string]any{"name": "a"}},
+			map[string]any{"id": "2", "metadata": map[string]any{"name": "a"}},
+			map[string]any{"id": "1", "metadata": map[string]any{"name": "a"}},
+		},
+		"total": 3,
+	}
+
+	result, kept := distinctItems(data, []string{})
+	assert.Equal(t, []bool{true, true, false}, kept)
+	assert.Equal(t, 2, result.(map[string]any)["total"])
+
+	result, kept = distinctItems(data, []string{"metadata.name"})
+	assert.Equal(t, []bool{true, false, false}, kept)
+	assert.Len(t, result.(map[string]any)["items"], 1)
+
+	// nothing removed: data is returned unchanged
+	unique := []any{"x", "y"}
+	result, kept = distinctItems(unique, []string{})
+	assert.Nil(t, kept)
+	assert.Equal(t, unique, result)
+
+	result, _ = distinctItems([]any{"x", "y", "x"}, []string{})
+	assert.Equal(t, []any{"x", "y"}, result)
+}
@@ -0,0 +1,107 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/itchyny/gojq"
+)
+
+// jqPrelude defines the jq 1.7 builtins that gojq does not provide, with the same definitions as jq's
+// own builtins, so that programs written for jq work unchanged
+const jqPrelude = `
+def pick(pathexps): . as $top | reduce path(pathexps) as $p (null; setpath($p; $top | getpath($p)));
+def debug(msg): (msg | debug | empty), .;
+def abs: if type == "number" and . < 0 then - . else . end;
+def toarray: if type == "array" then . else [.] end;
+def ltrim: if type == "string" then sub("^\\s+"; "") else error("trim input must be a string") end;
+def rtrim: if type == "string" then sub("\\s+$"; "") else error("trim input must be a string") end;
+def trim: ltrim | rtrim;
+def add(f): reduce f as $x (null; . + $x);
+def have_decnum: false;
+def have_literal_numbers: true;
+def dateadd(u; n): . + n;
+def datesub(u; n): . - n;
+def date: todate;
+def input_filename: null;
+`
+
+// jqOptions adds the jq builtins that write to stderr, which can't be defined in jq
+var jqOptions = []gojq.CompilerOption{
+	gojq.WithFunction("debug", 0, 0, func(v any, _ []any) any {
+		writeJQStderr([]any{"DEBUG:", v}, "\n")
+		return v
+	}),
+	gojq.WithFunction("stderr", 0, 0, func(v any, _ []any) any {
+		writeJQStderr(v, "")
+		return v
+	}),
+}
+
+func writeJQStderr(v any, suffix string) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		b = []byte(fmt.Sprint(v))
+	}
+	fmt.Fprint(os.Stderr, string(b)+suffix)
+}
+
+// compileJQ parses and compiles a jq expression or program, with the builtins of jq 1.7
+func compileJQ(expr string) (*gojq.Code, error) {
+	query, err := gojq.Parse(jqPrelude + expr)
+	if err != nil {
+		return nil, err
+	}
+	return gojq.Compile(query, jqOptions...)
+}
+
+// isFieldList returns true if the fields specification is a list of fields, e.g., "id, name:.data.name"
+// (the body of a jq object construction), rather than a jq program
+func isFieldList(fields string) bool {
+	_, err := gojq.Parse("{" + fields + "\n}") // newline ends a trailing comment, if any
+	return err == nil
+}
+
+// runJQProgram runs a jq program on the data, returning its output or, if the program produces
+// several outputs, a list of them
+func runJQProgram(v any, program string) (any, error) {
+	code, err := compileJQ(program)
+	if err != nil {
+		return nil, err
+	}
+	results := []any{}
+	iter := code.Run(v)
+	for {
+		result, ok := iter.Next()
+		if !ok {
+			break
+		}
+		if err, ok := result.(error); ok {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	switch len(results) {
+	case 0:
+		return nil, nil
+	case 1:
+		return results[0], nil
+	default:
+		return results, nil
+	}
+}
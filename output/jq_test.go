@@ -0,0 +1,105 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package output
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cisco-open/fsoc/test"
+)
+
+func TestJQCompatibility(t *testing.T) {
+	input := map[string]any{
+		"items": []any{
+			map[string]any{"id": "a", "count": 3, "tags": map[string]any{"env": "prod", "team": "x"}},
+			map[string]any{"id": "b", "count": -4, "tags": map[string]any{"env": "dev"}},
+		},
+		"total": 2,
+	}
+	tests := []struct {
+		program  string
+		expected any
+	}{
+		{`reduce .items[] as $i (0; . + $i.count)`, -1},
+		{`def double(f): f * 2; [.items[] | double(.count)]`, []any{6, -8}},
+		{`try error("boom") catch ("caught " + .)`, "caught boom"},
+		{`.items | map([.id, .count]) | .[] | @csv`, []any{`"a",3`, `"b",-4`}},
+		{`.items[0] | [.id, .tags.env] | @tsv`, "a\tprod"},
+		{`.items[0] | pick(.id, .tags.env)`, map[string]any{"id": "a", "tags": map[string]any{"env": "prod"}}},
+		{`[.items[].count | abs]`, []any{3, 4}},
+		{`[.total | toarray, ([1] | toarray)]`, []any{[]any{2}, []any{1}}},
+		{`"  padded " | [trim, ltrim, rtrim]`, []any{"padded", "padded ", "  padded"}},
+		{`add(.items[].count)`, -1},
+		{`[have_decnum, have_literal_numbers]`, []any{false, true}},
+		{`.total | debug("checking total") | . + 1`, 3},
+		{"# comments are allowed\n.total # trailing comment", 2},
+		{`empty`, nil},
+	}
+	for _, tt := range tests {
+		result, err := runJQProgram(canonicalizeData(input), tt.program)
+		require.Nil(t, err, tt.program)
+		assert.Equal(t, tt.expected, result, tt.program)
+	}
+
+	_, err := runJQProgram(input, `error("failed")`)
+	assert.ErrorContains(t, err, "failed")
+	_, err = runJQProgram(input, `.items[`)
+	assert.Error(t, err)
+}
+
+func TestIsFieldList(t *testing.T) {
+	assert.True(t, isFieldList("id"))
+	assert.True(t, isFieldList("id, name:.data.name, isSystem:.data.isSystem"))
+	assert.True(t, isFieldList("id,\n# the display name\nname: .data.name # trailing comment"))
+	assert.False(t, isFieldList(".items[] | .id"))
+	assert.False(t, isFieldList("def f: .; f"))
+	assert.False(t, isFieldList(`.items | map(.id) | @csv`))
+}
+
+func TestTransformFieldsProgram(t *testing.T) {
+	input := map[string]any{
+		"items": []any{map[string]any{"id": "a", "name": "x"}, map[string]any{"id": "b", "name": "y"}},
+		"total": 2,
+	}
+
+	// field lists keep the items structure
+	v := transformFields(input, "name, id")
+	assert.Equal(t, map[string]any{
+		"items": []any{map[string]any{"id": "a", "name": "x"}, map[string]any{"id": "b", "name": "y"}},
+		"total": 2,
+	}, v)
+
+	// programs see the whole data and produce any output
+	assert.Equal(t, []any{"a", "b"}, transformFields(input, ".items[].id"))
+
+	// text output is displayed as is
+	pr := printRequest{format: "auto", fields: `.items[] | [.id, .name] | @csv`}
+	out := test.CaptureConsoleOutput(func() {
+		printCmdOutputCustom(pr, input, &Table{Headers: []string{"ID"}, Lines: [][]string{{"a"}, {"b"}}})
+	}, t)
+	assert.Equal(t, "\"a\",\"x\"\n\"b\",\"y\"\n", out)
+
+	// output with items is displayed as a table, other output as YAML
+	pr.fields = `{items: [.items[] | {upper: (.id | ascii_upcase)}], total}`
+	out = test.CaptureConsoleOutput(func() { printCmdOutputCustom(pr, input, nil) }, t)
+	assert.Contains(t, out, "UPPER")
+	assert.Contains(t, out, "B")
+	pr.fields = `{count: .total}`
+	out = test.CaptureConsoleOutput(func() { printCmdOutputCustom(pr, input, nil) }, t)
+	assert.Equal(t, "count: 2\n", out)
+}
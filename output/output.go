@@ -26,9 +26,10 @@ import (
 
 	"github.com/apex/log"
 	"github.com/itchyny/gojq"
-	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
+
+	"github.com/cisco-open/fsoc/exitcode"
 )
 
 const (
@@ -43,6 +44,10 @@ type printRequest struct {
 	cmd         *cobra.Command
 	format      string
 	fields      string
+	columns     []Column
+	distinct    []string // nil if no deduplication is requested
+	flatten     bool     // display the items of lists as separate YAML documents
+	template    string   // Go template, for the go-template format
 	annotations map[string]string
 }
 
@@ -100,13 +105,38 @@ func PrintYaml(cmd *cobra.Command, v any) error {
 	return nil
 }
 
+// printYamlDocuments displays each item as a separate YAML document, e.g., for kubectl-style tools
+func printYamlDocuments(cmd *cobra.Command, items []any) error {
+	for _, item := range items {
+		data, err := yaml.Marshal(item)
+		if err != nil {
+			return err
+		}
+		print(cmd, "---\n"+string(data))
+	}
+	return nil
+}
+
 // PrintCmdStatus displays a single string message to the command output
 // Use this only for commands that don't display parseable data (e.g., "config set"),
-// for example, to confirm that the operation was completed
+// for example, to confirm that the operation was completed. When the output is written
+// to a file (--output-file), the message is displayed on stderr instead.
 func PrintCmdStatus(cmd *cobra.Command, s string) {
+	if cmd != nil {
+		if file, _ := cmd.Flags().GetString("output-file"); file != "" {
+			fmt.Fprint(cmd.ErrOrStderr(), s)
+			return
+		}
+	}
 	print(cmd, s)
 }
 
+// PrintCmdData displays unstructured data (e.g., a token or a graph) to the command output,
+// including when the output is written to a file (--output-file)
+func PrintCmdData(cmd *cobra.Command, s string) {
+	fmt.Fprint(GetOutWriter(cmd), s)
+}
+
 type Table struct {
 	// table output
 	Headers []string
@@ -133,6 +163,11 @@ func PrintCmdOutput(cmd *cobra.Command, v any) {
 // If human format is requested/assumed but no table is provided, displays YAML
 // If the object cannot be converted to the desired format, shows the object in Go's %+v format
 func PrintCmdOutputCustom(cmd *cobra.Command, v any, table *Table) {
+	printCmdOutputCustom(newPrintRequest(cmd), v, table)
+}
+
+// newPrintRequest collects the output options of the command from its flags and annotations
+func newPrintRequest(cmd *cobra.Command) printRequest {
 	// extract format, assume default if no command or no -o flag
 	format := ""
 	if cmd != nil {
@@ -145,46 +180,119 @@ func PrintCmdOutputCustom(cmd *cobra.Command, v any, table *Table) {
 	//        - for human outputs only, get the fields spec from the command annotations (if set)
 	//        - for machine formats, don't filter by fields
 	fields, _ := cmd.Flags().GetString("fields") // since --fields doesn't have default, non-empty means explicitly set
+	if file, _ := cmd.Flags().GetString("fields-file"); file != "" {
+		if fields != "" {
+			log.Fatalf("--fields and --fields-file cannot be used together")
+		}
+		program, err := os.ReadFile(file)
+		if err != nil {
+			log.Fatalf("Failed to read the fields file: %v", err)
+		}
+		fields = string(program)
+	}
 	pr := printRequest{cmd: cmd, format: format, fields: fields, annotations: cmd.Annotations}
-	printCmdOutputCustom(pr, v, table)
-}
+	pr.flatten, _ = cmd.Flags().GetBool("flatten")
 
-func printCmdOutputCustom(pr printRequest, v any, table *Table) {
-	// if no field spec is given on the command line and built-in specs are available, use them
-	if pr.fields == "" && pr.annotations != nil {
-		// choose which annotations to use and in what priority order
-		annotations := []string{} // names of annotations to use for fields, in priority order
-		switch pr.format {
-		case "", "auto", "table":
-			annotations = []string{TableFieldsAnnotation, DetailFieldsAnnotation}
-		case "detail":
-			annotations = []string{DetailFieldsAnnotation, TableFieldsAnnotation}
-			// all others, keep empty list
+	// Go template, given with the format, e.g., -o go-template=TEMPLATE
+	template, err := parseTemplateFormat(format)
+	if err != nil {
+		log.WithField(exitcode.Field, exitcode.Usage).Fatalf("%v", err)
+	}
+	if template != "" {
+		pr.format, pr.template = goTemplateFormat, template
+	}
+
+	// fields identifying duplicate entries to remove, if requested
+	if spec, _ := cmd.Flags().GetString("distinct"); spec != "" {
+		pr.distinct = ParseDistinctFields(spec)
+	}
+
+	// user-defined table columns, if any (apply to human formats only)
+	if specs, _ := cmd.Flags().GetStringArray("columns"); len(specs) > 0 {
+		columns, err := ParseColumns(specs)
+		if err != nil {
+			log.Fatalf("%v", err)
 		}
+		pr.columns = columns
+	}
+	return pr
+}
 
-		// get the first available fields specification
-		for _, name := range annotations {
-			if spec := pr.annotations[name]; spec != "" {
-				pr.fields = spec
-				break
-			}
+// annotationFields returns the built-in fields specification of the command for the output format, if any
+func annotationFields(pr printRequest) string {
+	// choose which annotations to use and in what priority order
+	annotations := []string{} // names of annotations to use for fields, in priority order
+	switch pr.format {
+	case "", "auto", "table", "csv":
+		annotations = []string{TableFieldsAnnotation, DetailFieldsAnnotation}
+	case "detail":
+		annotations = []string{DetailFieldsAnnotation, TableFieldsAnnotation}
+		// all others, keep empty list
+	}
+
+	// get the first available fields specification
+	for _, name := range annotations {
+		if spec := pr.annotations[name]; spec != "" {
+			return spec
 		}
 	}
+	return ""
+}
+
+func printCmdOutputCustom(pr printRequest, v any, table *Table) {
+	// if no field spec is given on the command line and built-in specs are available, use them
+	// (unless the user has defined their own columns, which are computed from the full data)
+	if pr.fields == "" && pr.columns == nil && pr.annotations != nil {
+		pr.fields = annotationFields(pr)
+	}
 
 	// adjust format to yaml if not enough info to produce human output (nb: the criteria may change
 	// in the future as the auto format capabilities improve)
 	if (pr.format == "" || pr.format == "auto") && // format is not explicitly specified
 		pr.fields == "" && // no field specification is provided (on the command line or from the command descriptor)
+		pr.columns == nil && // no user-defined columns are provided
 		(table == nil || table.Headers == nil || len(table.Headers) == 0) { // no explicit table form is provided
 		// go for YAML output, which is mostly human readable (or, at least, more human-readable than json or go %+v)
 		pr.format = "yaml"
 	}
 
+	// remove duplicate entries (before transforming, so that any fields can be used for comparison)
+	if pr.distinct != nil {
+		var kept []bool
+		v, kept = distinctItems(v, pr.distinct)
+		if kept != nil && table != nil && len(table.Lines) == len(kept) {
+			lines := [][]string{}
+			for i, line := range table.Lines {
+				if kept[i] {
+					lines = append(lines, line)
+				}
+			}
+			table = &Table{Headers: table.Headers, Lines: lines, LineBuilder: table.LineBuilder, Detail: table.Detail}
+		}
+	}
+
 	// transform data according to the fields query (if provided and should be used)
 	if pr.fields != "" {
 		v = transformFields(v, pr.fields)
 	}
 
+	// a jq program can produce any output; display text as is and other values as YAML,
+	// unless they have the items structure that can be displayed as a table
+	if pr.fields != "" && !isFieldList(pr.fields) && pr.columns == nil && (pr.format == "" || pr.format == "auto" || pr.format == "table" || pr.format == "csv") {
+		if text, ok := programText(v); ok {
+			printSimple(pr.cmd, text)
+			return
+		}
+		if m, ok := v.(map[string]any); !ok || m["items"] == nil {
+			if pr.format == "csv" {
+				log.Fatalf("The output of the fields jq program cannot be displayed as CSV: expected {items: [...]} or text")
+			}
+			pr.format = "yaml"
+		}
+		pr.fields = "" // the program defines the fields, their order is that of the output
+		table = nil
+	}
+
 	// print according to format and presence of table
 	switch pr.format {
 	case "json":
@@ -193,10 +301,28 @@ func printCmdOutputCustom(pr printRequest, v any, table *Table) {
 		}
 		return
 	case "yaml":
+		if pr.flatten {
+			if err := printYamlDocuments(pr.cmd, listItems(v)); err != nil {
+				log.Fatalf("Failed to convert output to YAML: %v (%+v)", err, v)
+			}
+			return
+		}
 		if err := PrintYaml(pr.cmd, v); err != nil {
 			log.Fatalf("Failed to convert output to YAML: %v (%+v)", err, v)
 		}
 		return
+	case "jsonl":
+		if err := printJsonLines(pr.cmd, listItems(v)); err != nil {
+			log.Fatalf("Failed to convert output to JSON lines: %v (%+v)", err, v)
+		}
+		return
+	case goTemplateFormat:
+		out, err := executeTemplate(v, pr.template)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		print(pr.cmd, out)
+		return
 	}
 
 	// display simple values
@@ -213,8 +339,11 @@ func printCmdOutputCustom(pr printRequest, v any, table *Table) {
 		}
 	}
 
-	// format table if a transform is provided or there is no custom table
-	if pr.fields != "" || table == nil || len(table.Headers) == 0 {
+	// format table from the user-defined columns, if provided; otherwise,
+	// if a transform is provided or there is no custom table
+	if pr.columns != nil {
+		table = createColumnsTable(v, pr.columns)
+	} else if pr.fields != "" || table == nil || len(table.Headers) == 0 {
 		var err error
 		table, err = createTable(v, pr.fields) // replaces the table
 		if err != nil {
@@ -227,7 +356,11 @@ func printCmdOutputCustom(pr printRequest, v any, table *Table) {
 	}
 
 	// display table
-	if table.Detail || pr.format == "detail" {
+	if pr.format == "csv" {
+		if err := printCsv(pr.cmd, table, true); err != nil {
+			log.Fatalf("Failed to write CSV output: %v", err)
+		}
+	} else if table.Detail || pr.format == "detail" {
 		printDetail(pr.cmd, table)
 	} else {
 		printTable(pr.cmd, table)
@@ -267,14 +400,10 @@ func printTable(cmd *cobra.Command, t *Table) {
 		printSimple(cmd, "Nothing to display")
 		return
 	}
-	tw := tablewriter.NewWriter(GetOutWriter(cmd))
-	tw.SetBorder(false)
-	tw.SetCenterSeparator("")
-	tw.SetColumnSeparator("")
-	tw.SetRowSeparator("")
-	tw.SetHeader(t.Headers)
-	tw.AppendBulk(t.Lines)
-	tw.Render()
+	r := newTableRenderer(cmd)
+	if err := r.render(GetOutWriter(cmd), t.Headers, t.Lines, r.layout(t.Headers, t.Lines, nil), true); err != nil {
+		log.Fatalf("Failed to display the table: %v", err)
+	}
 }
 
 // printDetail prints a form-like detail output, with "label: value" pairs on each row
@@ -402,19 +531,51 @@ func transformFields(v any, fieldsCommaList string) any {
 
 	//default value of fields is "*". We don't mess with anything if the fields
 	//requested are "*""
-	if strings.TrimSpace(fieldsCommaList) != "*" {
-		qStr := fmt.Sprintf(". as $root|.items|{items: map({%s}),total:$root.total}", fieldsCommaList)
-		query, err := gojq.Parse(qStr)
+	if strings.TrimSpace(fieldsCommaList) == "*" {
+		return v
+	}
+
+	// anything other than a field list is a jq program, run on the entire data
+	if !isFieldList(fieldsCommaList) {
+		result, err := runJQProgram(v, fieldsCommaList)
 		if err != nil {
-			log.Fatalf("Failed to parse field list %q as a jq expression %q: %v", fieldsCommaList, qStr, err)
+			log.Fatalf("Failed to run the fields jq program: %v", err)
 		}
-		iter := query.Run(v)
+		return result
+	}
 
-		v, _ = iter.Next()
+	qStr := fmt.Sprintf(". as $root|.items|{items: map({%s\n}),total:$root.total}", fieldsCommaList)
+	query, err := compileJQ(qStr)
+	if err != nil {
+		log.Fatalf("Failed to parse field list %q as a jq expression %q: %v", fieldsCommaList, qStr, err)
+	}
+	v, _ = query.Run(v).Next()
+	if err, ok := v.(error); ok {
+		log.Fatalf("Failed to apply the field list %q: %v", fieldsCommaList, err)
 	}
 	return v
 }
 
+// programText returns the output of a jq program as text if it is a string or a list of strings,
+// e.g., the lines produced by @csv
+func programText(v any) (string, bool) {
+	switch val := v.(type) {
+	case string:
+		return val, true
+	case []any:
+		lines := make([]string, len(val))
+		for i, item := range val {
+			s, ok := item.(string)
+			if !ok {
+				return "", false
+			}
+			lines[i] = s
+		}
+		return strings.Join(lines, "\n"), len(lines) > 0
+	}
+	return "", false
+}
+
 // canonicalizeData ensures that the data is in a uniform, expected format, converting any possible input
 // into the expected .items[] and .total structure, rendered as a map[string]any, as JSON parse would
 // produce it given no specific schema/structure to parse into
@@ -15,10 +15,12 @@
 package output
 
 import (
+	"bytes"
 	"fmt"
 	"strconv"
 	"testing"
 
+	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/require"
 
 	"github.com/cisco-open/fsoc/test"
@@ -70,6 +72,24 @@ func TestPrintCmdStatus(t *testing.T) {
 	outExpected := "test string"
 	outActual := test.CaptureConsoleOutput(func() { PrintCmdStatus(nil, "test string") }, t)
 	require.Equal(t, outExpected, outActual)
+
+	// with --output-file, status messages don't go into the output
+	cmd := &cobra.Command{}
+	cmd.Flags().String("output-file", "", "")
+	require.NoError(t, cmd.Flags().Set("output-file", "out.json"))
+	var out, errOut bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&errOut)
+	PrintCmdStatus(cmd, "test string")
+	require.Empty(t, out.String())
+	require.Equal(t, outExpected, errOut.String())
+
+	// data, on the other hand, goes into the output
+	out.Reset()
+	errOut.Reset()
+	PrintCmdData(cmd, "test data")
+	require.Equal(t, "test data", out.String())
+	require.Empty(t, errOut.String())
 }
 
 func TestPrintTable(t *testing.T) {
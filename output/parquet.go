@@ -0,0 +1,433 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package output
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// ParquetType is the logical type of a Parquet column
+type ParquetType int
+
+const (
+	ParquetString    ParquetType = iota // UTF-8 string
+	ParquetInt64                        // signed 64-bit integer
+	ParquetDouble                       // 64-bit floating point
+	ParquetBoolean                      // boolean
+	ParquetTimestamp                    // timestamp, stored as milliseconds since the Unix epoch
+	ParquetJSON                         // any value, stored as a JSON-encoded string
+)
+
+// ParquetColumn is a single column of a table to be written in Parquet format.
+// All columns are optional (nullable); a nil value is written as null.
+type ParquetColumn struct {
+	Name   string
+	Type   ParquetType
+	Values []any
+}
+
+// The parquet writer below produces a single row group with one uncompressed, PLAIN-encoded
+// data page per column. This keeps the implementation small and dependency-free while remaining
+// readable by all common Parquet readers (pandas/pyarrow, Spark, DuckDB, etc.).
+
+const parquetMagic = "PAR1"
+
+// physical types, see parquet.thrift
+const (
+	parquetTypeBoolean   = 0
+	parquetTypeInt64     = 2
+	parquetTypeDouble    = 5
+	parquetTypeByteArray = 6
+)
+
+// converted types, see parquet.thrift
+const (
+	parquetConvertedUTF8            = 0
+	parquetConvertedTimestampMillis = 9
+	parquetConvertedJSON            = 19
+)
+
+// encodings, see parquet.thrift
+const (
+	parquetEncodingPlain = 0
+	parquetEncodingRLE   = 3
+)
+
+// WriteParquet writes the provided columns as a Parquet file. All columns must have the same number of values.
+func WriteParquet(w io.Writer, columns []ParquetColumn) error {
+	if len(columns) == 0 {
+		return fmt.Errorf("cannot write a parquet file with no columns")
+	}
+	numRows := len(columns[0].Values)
+	for _, col := range columns {
+		if len(col.Values) != numRows {
+			return fmt.Errorf("column %q has %v values, expected %v", col.Name, len(col.Values), numRows)
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(parquetMagic)
+
+	chunks := make([]parquetChunkMeta, len(columns))
+	for i, col := range columns {
+		offset := int64(buf.Len())
+		page, err := encodeParquetPage(col)
+		if err != nil {
+			return err
+		}
+		buf.Write(page)
+		chunks[i] = parquetChunkMeta{
+			column: col,
+			offset: offset,
+			size:   int64(len(page)),
+		}
+	}
+
+	footer := encodeParquetFooter(columns, chunks, int64(numRows))
+	buf.Write(footer)
+	if err := binary.Write(&buf, binary.LittleEndian, uint32(len(footer))); err != nil {
+		return err
+	}
+	buf.WriteString(parquetMagic)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+type parquetChunkMeta struct {
+	column ParquetColumn
+	offset int64
+	size   int64
+}
+
+func (t ParquetType) physical() int32 {
+	switch t {
+	case ParquetInt64, ParquetTimestamp:
+		return parquetTypeInt64
+	case ParquetDouble:
+		return parquetTypeDouble
+	case ParquetBoolean:
+		return parquetTypeBoolean
+	default:
+		return parquetTypeByteArray
+	}
+}
+
+// converted returns the converted (logical) type annotation for the column or -1 if none is needed
+func (t ParquetType) converted() int32 {
+	switch t {
+	case ParquetString:
+		return parquetConvertedUTF8
+	case ParquetTimestamp:
+		return parquetConvertedTimestampMillis
+	case ParquetJSON:
+		return parquetConvertedJSON
+	default:
+		return -1
+	}
+}
+
+// encodeParquetPage encodes a column's values as a complete data page, including its header
+func encodeParquetPage(col ParquetColumn) ([]byte, error) {
+	defLevels := make([]bool, len(col.Values))
+	var values bytes.Buffer
+	var bits []bool
+	for i, v := range col.Values {
+		if v == nil {
+			continue
+		}
+		defLevels[i] = true
+		switch col.Type {
+		case ParquetString:
+			s, ok := v.(string)
+			if !ok {
+				s = fmt.Sprint(v)
+			}
+			writeParquetByteArray(&values, []byte(s))
+		case ParquetJSON:
+			b, err := json.Marshal(v)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode value of column %q as JSON: %w", col.Name, err)
+			}
+			writeParquetByteArray(&values, b)
+		case ParquetInt64:
+			n, err := toInt64(v)
+			if err != nil {
+				return nil, fmt.Errorf("column %q: %w", col.Name, err)
+			}
+			_ = binary.Write(&values, binary.LittleEndian, n)
+		case ParquetTimestamp:
+			t, ok := v.(time.Time)
+			if !ok {
+				return nil, fmt.Errorf("column %q: expected a timestamp, found %T", col.Name, v)
+			}
+			_ = binary.Write(&values, binary.LittleEndian, t.UnixMilli())
+		case ParquetDouble:
+			f, err := toFloat64(v)
+			if err != nil {
+				return nil, fmt.Errorf("column %q: %w", col.Name, err)
+			}
+			_ = binary.Write(&values, binary.LittleEndian, math.Float64bits(f))
+		case ParquetBoolean:
+			b, ok := v.(bool)
+			if !ok {
+				return nil, fmt.Errorf("column %q: expected a boolean, found %T", col.Name, v)
+			}
+			bits = append(bits, b)
+		}
+	}
+	if col.Type == ParquetBoolean {
+		values.Write(packBits(bits))
+	}
+
+	// data page v1: definition levels (length-prefixed RLE), followed by the values
+	levels := encodeRLEBits(defLevels)
+	var data bytes.Buffer
+	_ = binary.Write(&data, binary.LittleEndian, uint32(len(levels)))
+	data.Write(levels)
+	data.Write(values.Bytes())
+
+	var header thriftWriter
+	header.fieldI32(1, 0) // type: DATA_PAGE
+	header.fieldI32(2, int32(data.Len()))
+	header.fieldI32(3, int32(data.Len()))
+	header.fieldStructBegin(5) // data_page_header
+	header.fieldI32(1, int32(len(col.Values)))
+	header.fieldI32(2, parquetEncodingPlain)
+	header.fieldI32(3, parquetEncodingRLE)
+	header.fieldI32(4, parquetEncodingRLE)
+	header.structEnd()
+	header.structEnd()
+
+	return append(header.buf.Bytes(), data.Bytes()...), nil
+}
+
+// encodeParquetFooter encodes the file metadata
+func encodeParquetFooter(columns []ParquetColumn, chunks []parquetChunkMeta, numRows int64) []byte {
+	var t thriftWriter
+	t.fieldI32(1, 1) // version
+
+	// schema: root element followed by one element per column
+	t.fieldListBegin(2, thriftStruct, len(columns)+1)
+	t.structBegin()
+	t.fieldBinary(4, []byte("schema"))
+	t.fieldI32(5, int32(len(columns)))
+	t.structEnd()
+	for _, col := range columns {
+		t.structBegin()
+		t.fieldI32(1, col.Type.physical())
+		t.fieldI32(3, 1) // repetition: OPTIONAL
+		t.fieldBinary(4, []byte(col.Name))
+		if ct := col.Type.converted(); ct >= 0 {
+			t.fieldI32(6, ct)
+		}
+		t.structEnd()
+	}
+
+	t.fieldI64(3, numRows)
+
+	// a single row group
+	var totalSize int64
+	for _, c := range chunks {
+		totalSize += c.size
+	}
+	t.fieldListBegin(4, thriftStruct, 1)
+	t.structBegin()
+	t.fieldListBegin(1, thriftStruct, len(chunks))
+	for _, c := range chunks {
+		t.structBegin()
+		t.fieldI64(2, c.offset)
+		t.fieldStructBegin(3) // column metadata
+		t.fieldI32(1, c.column.Type.physical())
+		t.fieldListBegin(2, thriftI32, 2)
+		t.writeVarint(zigzag(parquetEncodingPlain))
+		t.writeVarint(zigzag(parquetEncodingRLE))
+		t.fieldListBegin(3, thriftBinary, 1)
+		t.writeBinary([]byte(c.column.Name))
+		t.fieldI32(4, 0) // codec: UNCOMPRESSED
+		t.fieldI64(5, int64(len(c.column.Values)))
+		t.fieldI64(6, c.size)
+		t.fieldI64(7, c.size)
+		t.fieldI64(9, c.offset)
+		t.structEnd()
+		t.structEnd()
+	}
+	t.fieldI64(2, totalSize)
+	t.fieldI64(3, numRows)
+	t.structEnd()
+
+	t.fieldBinary(6, []byte("fsoc"))
+	t.structEnd()
+
+	return t.buf.Bytes()
+}
+
+func writeParquetByteArray(buf *bytes.Buffer, b []byte) {
+	_ = binary.Write(buf, binary.LittleEndian, uint32(len(b)))
+	buf.Write(b)
+}
+
+// packBits packs booleans LSB-first, as used by the PLAIN encoding for booleans
+func packBits(bits []bool) []byte {
+	packed := make([]byte, (len(bits)+7)/8)
+	for i, b := range bits {
+		if b {
+			packed[i/8] |= 1 << (i % 8)
+		}
+	}
+	return packed
+}
+
+// encodeRLEBits encodes 1-bit levels using the RLE/bit-packing hybrid encoding (RLE runs only)
+func encodeRLEBits(levels []bool) []byte {
+	var buf bytes.Buffer
+	for i := 0; i < len(levels); {
+		j := i
+		for j < len(levels) && levels[j] == levels[i] {
+			j++
+		}
+		writeUvarint(&buf, uint64(j-i)<<1)
+		if levels[i] {
+			buf.WriteByte(1)
+		} else {
+			buf.WriteByte(0)
+		}
+		i = j
+	}
+	return buf.Bytes()
+}
+
+func toInt64(v any) (int64, error) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), nil
+	case int32:
+		return int64(n), nil
+	case int64:
+		return n, nil
+	case float64:
+		return int64(n), nil
+	}
+	return 0, fmt.Errorf("expected an integer, found %T", v)
+}
+
+func toFloat64(v any) (float64, error) {
+	switch n := v.(type) {
+	case float64:
+		return n, nil
+	case float32:
+		return float64(n), nil
+	case int:
+		return float64(n), nil
+	case int64:
+		return float64(n), nil
+	}
+	return 0, fmt.Errorf("expected a number, found %T", v)
+}
+
+// thrift compact protocol field types
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter is a minimal encoder for the thrift compact protocol, sufficient for the parquet metadata
+type thriftWriter struct {
+	buf     bytes.Buffer
+	lastIDs []int16
+	lastID  int16
+}
+
+func (t *thriftWriter) fieldHeader(id int16, typ byte) {
+	delta := id - t.lastID
+	if delta > 0 && delta <= 15 {
+		t.buf.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		t.buf.WriteByte(typ)
+		t.writeVarint(zigzag(int64(id)))
+	}
+	t.lastID = id
+}
+
+func (t *thriftWriter) fieldI32(id int16, v int32) {
+	t.fieldHeader(id, thriftI32)
+	t.writeVarint(zigzag(int64(v)))
+}
+
+func (t *thriftWriter) fieldI64(id int16, v int64) {
+	t.fieldHeader(id, thriftI64)
+	t.writeVarint(zigzag(v))
+}
+
+func (t *thriftWriter) fieldBinary(id int16, b []byte) {
+	t.fieldHeader(id, thriftBinary)
+	t.writeBinary(b)
+}
+
+func (t *thriftWriter) fieldListBegin(id int16, elemType byte, size int) {
+	t.fieldHeader(id, thriftList)
+	if size < 15 {
+		t.buf.WriteByte(byte(size)<<4 | elemType)
+	} else {
+		t.buf.WriteByte(0xf0 | elemType)
+		writeUvarint(&t.buf, uint64(size))
+	}
+}
+
+func (t *thriftWriter) fieldStructBegin(id int16) {
+	t.fieldHeader(id, thriftStruct)
+	t.structBegin()
+}
+
+func (t *thriftWriter) structBegin() {
+	t.lastIDs = append(t.lastIDs, t.lastID)
+	t.lastID = 0
+}
+
+func (t *thriftWriter) structEnd() {
+	t.buf.WriteByte(0) // stop field
+	if n := len(t.lastIDs); n > 0 {
+		t.lastID = t.lastIDs[n-1]
+		t.lastIDs = t.lastIDs[:n-1]
+	}
+}
+
+func (t *thriftWriter) writeBinary(b []byte) {
+	writeUvarint(&t.buf, uint64(len(b)))
+	t.buf.Write(b)
+}
+
+func (t *thriftWriter) writeVarint(v uint64) {
+	writeUvarint(&t.buf, v)
+}
+
+func zigzag(v int64) uint64 {
+	return uint64((v << 1) ^ (v >> 63))
+}
+
+func writeUvarint(buf *bytes.Buffer, v uint64) {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], v)
+	buf.Write(tmp[:n])
+}
@@ -0,0 +1,284 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package output
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteParquet(t *testing.T) {
+	columns := []ParquetColumn{
+		{Name: "name", Type: ParquetString, Values: []any{"a", nil, "ccc"}},
+		{Name: "count", Type: ParquetInt64, Values: []any{1, 2, nil}},
+		{Name: "ratio", Type: ParquetDouble, Values: []any{1.5, nil, 3}},
+		{Name: "active", Type: ParquetBoolean, Values: []any{true, false, true}},
+		{Name: "timestamp", Type: ParquetTimestamp, Values: []any{time.UnixMilli(1000), nil, nil}},
+		{Name: "attributes", Type: ParquetJSON, Values: []any{map[string]any{"x": 1}, nil, []int{1, 2}}},
+	}
+
+	var buf bytes.Buffer
+	require.Nil(t, WriteParquet(&buf, columns))
+
+	data := buf.Bytes()
+	require.Greater(t, len(data), 12)
+	assert.Equal(t, parquetMagic, string(data[:4]))
+	assert.Equal(t, parquetMagic, string(data[len(data)-4:]))
+
+	footerLen := int(binary.LittleEndian.Uint32(data[len(data)-8 : len(data)-4]))
+	require.Less(t, footerLen, len(data)-12)
+	footer := data[len(data)-8-footerLen : len(data)-8]
+	for _, col := range columns {
+		assert.Contains(t, string(footer), col.Name)
+	}
+	assert.Contains(t, string(data), `{"x":1}`)
+}
+
+func TestWriteParquetErrors(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NotNil(t, WriteParquet(&buf, nil))
+	assert.NotNil(t, WriteParquet(&buf, []ParquetColumn{
+		{Name: "a", Type: ParquetString, Values: []any{"x"}},
+		{Name: "b", Type: ParquetString, Values: []any{}},
+	}))
+	assert.NotNil(t, WriteParquet(&buf, []ParquetColumn{
+		{Name: "a", Type: ParquetInt64, Values: []any{"not a number"}},
+	}))
+}
+
+func TestParquetEncodings(t *testing.T) {
+	assert.Equal(t, []byte{0x04, 1, 0x02, 0, 0x06, 1}, encodeRLEBits([]bool{true, true, false, true, true, true}))
+	assert.Equal(t, []byte{0x05, 0x01}, packBits([]bool{true, false, true, false, false, false, false, false, true}))
+	assert.Equal(t, uint64(1), zigzag(-1))
+	assert.Equal(t, uint64(4), zigzag(2))
+}
+
+// TestWriteParquetRoundTrip decodes the file metadata and the column chunks with the minimal reader below
+// and compares the values with the columns written
+func TestWriteParquetRoundTrip(t *testing.T) {
+	columns := []ParquetColumn{
+		{Name: "name", Type: ParquetString, Values: []any{"a", nil, "ccc"}},
+		{Name: "count", Type: ParquetInt64, Values: []any{1, int64(-2), nil}},
+		{Name: "ratio", Type: ParquetDouble, Values: []any{1.5, nil, 3}},
+		{Name: "active", Type: ParquetBoolean, Values: []any{true, nil, false}},
+		{Name: "timestamp", Type: ParquetTimestamp, Values: []any{time.UnixMilli(1000), nil, nil}},
+		{Name: "attributes", Type: ParquetJSON, Values: []any{map[string]any{"x": 1}, nil, []int{1, 2}}},
+	}
+	expected := [][]any{
+		{"a", nil, "ccc"},
+		{int64(1), int64(-2), nil},
+		{1.5, nil, 3.0},
+		{true, nil, false},
+		{int64(1000), nil, nil},
+		{`{"x":1}`, nil, `[1,2]`},
+	}
+	expectedTypes := []struct{ physical, converted int64 }{
+		{parquetTypeByteArray, parquetConvertedUTF8},
+		{parquetTypeInt64, -1},
+		{parquetTypeDouble, -1},
+		{parquetTypeBoolean, -1},
+		{parquetTypeInt64, parquetConvertedTimestampMillis},
+		{parquetTypeByteArray, parquetConvertedJSON},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteParquet(&buf, columns))
+	data := buf.Bytes()
+	require.Equal(t, parquetMagic, string(data[:4]))
+	require.Equal(t, parquetMagic, string(data[len(data)-4:]))
+	footerLen := int(binary.LittleEndian.Uint32(data[len(data)-8 : len(data)-4]))
+	footerStart := len(data) - 8 - footerLen
+	require.Greater(t, footerStart, 4)
+
+	// FileMetaData: 2 schema, 3 num_rows, 4 row_groups
+	r := &thriftReader{data: data[footerStart : len(data)-8]}
+	meta := r.readStruct(t)
+	assert.Equal(t, footerLen, r.pos, "the footer is decoded entirely")
+	assert.Equal(t, int64(3), meta[3])
+
+	// SchemaElement: 1 type, 3 repetition_type, 4 name, 5 num_children, 6 converted_type
+	schema := meta[2].([]any)
+	require.Len(t, schema, len(columns)+1)
+	assert.Equal(t, int64(len(columns)), schema[0].(map[int16]any)[5])
+	for i, col := range columns {
+		element := schema[i+1].(map[int16]any)
+		assert.Equal(t, col.Name, string(element[4].([]byte)))
+		assert.Equal(t, int64(1), element[3], "%s is optional", col.Name)
+		assert.Equal(t, expectedTypes[i].physical, element[1], col.Name)
+		if expectedTypes[i].converted >= 0 {
+			assert.Equal(t, expectedTypes[i].converted, element[6], col.Name)
+		} else {
+			assert.NotContains(t, element, int16(6), col.Name)
+		}
+	}
+
+	// RowGroup: 1 columns, 3 num_rows; ColumnChunk: 3 meta_data; ColumnMetaData: 1 type, 3 path_in_schema,
+	// 4 codec, 5 num_values, 7 total_compressed_size, 9 data_page_offset
+	rowGroups := meta[4].([]any)
+	require.Len(t, rowGroups, 1)
+	rowGroup := rowGroups[0].(map[int16]any)
+	assert.Equal(t, int64(3), rowGroup[3])
+	chunks := rowGroup[1].([]any)
+	require.Len(t, chunks, len(columns))
+	for i, col := range columns {
+		chunkMeta := chunks[i].(map[int16]any)[3].(map[int16]any)
+		assert.Equal(t, expectedTypes[i].physical, chunkMeta[1], col.Name)
+		assert.Equal(t, []any{[]byte(col.Name)}, chunkMeta[3], col.Name)
+		assert.Equal(t, int64(0), chunkMeta[4], col.Name)
+		assert.Equal(t, int64(len(col.Values)), chunkMeta[5], col.Name)
+
+		offset, size := chunkMeta[9].(int64), chunkMeta[7].(int64)
+		require.True(t, offset >= 4 && offset+size <= int64(footerStart), col.Name)
+		values := decodeParquetPage(t, data[offset:offset+size], chunkMeta[1].(int64), len(col.Values))
+		assert.Equal(t, expected[i], values, col.Name)
+	}
+}
+
+// decodeParquetPage decodes a data page with its header, as written by encodeParquetPage: RLE-encoded
+// definition levels followed by the PLAIN-encoded non-null values
+func decodeParquetPage(t *testing.T, page []byte, physical int64, numValues int) []any {
+	// PageHeader: 1 type, 2 uncompressed_page_size, 3 compressed_page_size, 5 data_page_header
+	r := &thriftReader{data: page}
+	header := r.readStruct(t)
+	assert.Equal(t, int64(0), header[1], "data page")
+	assert.Equal(t, int64(len(page)-r.pos), header[3])
+	dataHeader := header[5].(map[int16]any)
+	assert.Equal(t, int64(numValues), dataHeader[1])
+	assert.Equal(t, int64(parquetEncodingPlain), dataHeader[2])
+
+	data := page[r.pos:]
+	levelsLen := int(binary.LittleEndian.Uint32(data))
+	levels := bytes.NewReader(data[4 : 4+levelsLen])
+	var defined []bool
+	for levels.Len() > 0 {
+		runHeader, err := binary.ReadUvarint(levels)
+		require.NoError(t, err)
+		require.Zero(t, runHeader&1, "only RLE runs are written")
+		value, err := levels.ReadByte()
+		require.NoError(t, err)
+		for n := runHeader >> 1; n > 0; n-- {
+			defined = append(defined, value == 1)
+		}
+	}
+	require.Len(t, defined, numValues)
+
+	values := data[4+levelsLen:]
+	result := make([]any, numValues)
+	bit := 0
+	for i := range result {
+		if !defined[i] {
+			continue
+		}
+		switch physical {
+		case parquetTypeByteArray:
+			n := binary.LittleEndian.Uint32(values)
+			result[i] = string(values[4 : 4+n])
+			values = values[4+n:]
+		case parquetTypeInt64:
+			result[i] = int64(binary.LittleEndian.Uint64(values))
+			values = values[8:]
+		case parquetTypeDouble:
+			result[i] = math.Float64frombits(binary.LittleEndian.Uint64(values))
+			values = values[8:]
+		case parquetTypeBoolean:
+			result[i] = values[bit/8]&(1<<(bit%8)) != 0
+			bit++
+		}
+	}
+	if physical == parquetTypeBoolean {
+		values = values[(bit+7)/8:]
+	}
+	assert.Empty(t, values, "all values are decoded")
+	return result
+}
+
+// thriftReader decodes thrift compact protocol structs into maps keyed by field ID, with integers
+// as int64, binaries as []byte, lists as []any and structs as map[int16]any
+type thriftReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *thriftReader) readStruct(t *testing.T) map[int16]any {
+	fields := map[int16]any{}
+	var lastID int16
+	for {
+		b := r.readByte(t)
+		if b == 0 {
+			return fields
+		}
+		id := lastID + int16(b>>4)
+		if b>>4 == 0 {
+			id = int16(r.readZigzag(t))
+		}
+		fields[id] = r.readValue(t, b&0x0f)
+		lastID = id
+	}
+}
+
+func (r *thriftReader) readValue(t *testing.T, typ byte) any {
+	switch typ {
+	case 1, 2: // boolean field: true, false
+		return typ == 1
+	case thriftI32, thriftI64:
+		return r.readZigzag(t)
+	case thriftBinary:
+		n := int(r.readVarint(t))
+		require.LessOrEqual(t, r.pos+n, len(r.data))
+		b := r.data[r.pos : r.pos+n]
+		r.pos += n
+		return b
+	case thriftList:
+		header := r.readByte(t)
+		size := int(header >> 4)
+		if size == 15 {
+			size = int(r.readVarint(t))
+		}
+		list := make([]any, size)
+		for i := range list {
+			list[i] = r.readValue(t, header&0x0f)
+		}
+		return list
+	case thriftStruct:
+		return r.readStruct(t)
+	}
+	require.Failf(t, "unexpected thrift type", "type %d at offset %d", typ, r.pos)
+	return nil
+}
+
+func (r *thriftReader) readByte(t *testing.T) byte {
+	require.Less(t, r.pos, len(r.data), "unexpected end of thrift data")
+	b := r.data[r.pos]
+	r.pos++
+	return b
+}
+
+func (r *thriftReader) readVarint(t *testing.T) uint64 {
+	v, n := binary.Uvarint(r.data[r.pos:])
+	require.Greater(t, n, 0, "invalid varint at offset %d", r.pos)
+	r.pos += n
+	return v
+}
+
+func (r *thriftReader) readZigzag(t *testing.T) int64 {
+	v := r.readVarint(t)
+	return int64(v>>1) ^ -int64(v&1)
+}
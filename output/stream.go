@@ -0,0 +1,143 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+
+	"github.com/spf13/cobra"
+)
+
+// ItemStream displays the items of a list as they are received, e.g., page by page, instead of
+// accumulating the entire list before displaying it. This reduces the time to the first output and
+// the memory needed for large lists. Streaming is supported for the output formats that can be
+// rendered incrementally: JSON lines, flattened YAML documents, CSV and tables of fields or columns
+// (the column widths are determined by the first items received and grow as needed).
+type ItemStream struct {
+	pr      printRequest
+	headers []string     // nil until the first non-empty items are displayed
+	layout  *tableLayout // table column layout so far
+}
+
+// NewItemStream returns a stream that displays a list in the command's output format, or nil if the
+// output format requires the entire list, e.g., JSON and YAML documents (unless flattened), jq programs,
+// detail forms and --distinct. In the latter case, accumulate the list and display it with PrintCmdOutput.
+func NewItemStream(cmd *cobra.Command) *ItemStream {
+	pr := newPrintRequest(cmd)
+	if pr.distinct != nil {
+		return nil
+	}
+	if pr.fields == "" && pr.columns == nil && pr.annotations != nil {
+		pr.fields = annotationFields(pr)
+	}
+	if pr.fields != "" && !isFieldList(pr.fields) {
+		return nil // jq programs see the entire output
+	}
+	switch pr.format {
+	case "jsonl", "csv", "table":
+	case "yaml":
+		if !pr.flatten {
+			return nil // a single document with all items
+		}
+	case "", "auto":
+		if pr.fields == "" && pr.columns == nil {
+			return nil // displayed as YAML
+		}
+	default:
+		return nil
+	}
+	return &ItemStream{pr: pr}
+}
+
+// Write displays the next items of the list
+func (s *ItemStream) Write(items []any) error {
+	if len(items) == 0 {
+		return nil
+	}
+	var v any = map[string]any{"items": items, "total": len(items)}
+	if s.pr.fields != "" {
+		v = transformFields(v, s.pr.fields)
+	}
+	switch s.pr.format {
+	case "jsonl":
+		return printJsonLines(s.pr.cmd, listItems(v))
+	case "yaml":
+		return printYamlDocuments(s.pr.cmd, listItems(v))
+	}
+
+	var table *Table
+	var err error
+	if s.pr.columns != nil {
+		table = createColumnsTable(v, s.pr.columns)
+	} else if table, err = createTable(v, s.pr.fields); err != nil {
+		return err
+	}
+	first := s.headers == nil
+	if first {
+		s.headers = table.Headers
+	}
+	if s.pr.format == "csv" {
+		return printCsv(s.pr.cmd, table, first)
+	}
+	return s.printTableRows(table, first)
+}
+
+// printTableRows displays table rows, with the header for the first rows, keeping the columns
+// at least as wide as in the previous rows
+func (s *ItemStream) printTableRows(t *Table, withHeader bool) error {
+	r := newTableRenderer(s.pr.cmd)
+	s.layout = r.layout(s.headers, t.Lines, s.layout)
+	return r.render(GetOutWriter(s.pr.cmd), s.headers, t.Lines, s.layout, withHeader)
+}
+
+// listItems returns the items of a list or, for other values, a list with the value
+func listItems(v any) []any {
+	if list, ok := v.([]any); ok {
+		return list
+	}
+	if m, ok := canonicalizeData(v).(map[string]any); ok {
+		if items, ok := m["items"].([]any); ok {
+			return items
+		}
+	}
+	return []any{v}
+}
+
+// printJsonLines displays each item as a line of compact JSON (JSON lines)
+func printJsonLines(cmd *cobra.Command, items []any) error {
+	for _, item := range items {
+		data, err := json.Marshal(item)
+		if err != nil {
+			return err
+		}
+		println(cmd, string(data))
+	}
+	return nil
+}
+
+// printCsv displays the table rows as CSV, preceded by the header row if requested
+func printCsv(cmd *cobra.Command, t *Table, withHeader bool) error {
+	w := csv.NewWriter(GetOutWriter(cmd))
+	if withHeader {
+		if err := w.Write(t.Headers); err != nil {
+			return err
+		}
+	}
+	if err := w.WriteAll(t.Lines); err != nil { // nb: WriteAll flushes
+		return err
+	}
+	return w.Error()
+}
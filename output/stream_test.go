@@ -0,0 +1,85 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package output
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cisco-open/fsoc/test"
+)
+
+func TestItemStream(t *testing.T) {
+	pages := [][]any{
+		{map[string]any{"id": "a", "data": map[string]any{"name": "first"}}},
+		{},
+		{map[string]any{"id": "bb", "data": map[string]any{"name": "the second"}}, map[string]any{"id": "c", "data": map[string]any{"name": "x,y"}}},
+	}
+	write := func(s *ItemStream) string {
+		return test.CaptureConsoleOutput(func() {
+			for _, page := range pages {
+				assert.Nil(t, s.Write(page))
+			}
+		}, t)
+	}
+
+	s := &ItemStream{pr: printRequest{format: "csv", fields: "name:.data.name, id"}}
+	assert.Equal(t, "name,id\nfirst,a\nthe second,bb\n\"x,y\",c\n", write(s))
+
+	s = &ItemStream{pr: printRequest{format: "jsonl", fields: "id"}}
+	assert.Equal(t, "{\"id\":\"a\"}\n{\"id\":\"bb\"}\n{\"id\":\"c\"}\n", write(s))
+
+	s = &ItemStream{pr: printRequest{format: "yaml", fields: "id", flatten: true}}
+	assert.Equal(t, "---\nid: a\n---\nid: bb\n---\nid: c\n", write(s))
+
+	// the header is displayed once and columns don't shrink
+	s = &ItemStream{pr: printRequest{format: "table", fields: "id, name:.data.name"}}
+	lines := strings.Split(strings.TrimRight(write(s), "\n"), "\n")
+	assert.Len(t, lines, 5) // header, separator, 3 rows
+	assert.Equal(t, 1, strings.Count(strings.Join(lines, "\n"), "NAME"))
+	assert.Equal(t, strings.Index(lines[0], "NAME"), strings.Index(lines[2], "first"))
+	assert.Equal(t, strings.Index(lines[3], "the second"), strings.Index(lines[4], "x,y"))
+}
+
+func TestPrintJsonLinesAndCsv(t *testing.T) {
+	v := map[string]any{"items": []any{map[string]any{"id": "a"}, map[string]any{"id": "b"}}, "total": 2}
+	out := test.CaptureConsoleOutput(func() { printCmdOutputCustom(printRequest{format: "jsonl"}, v, nil) }, t)
+	assert.Equal(t, "{\"id\":\"a\"}\n{\"id\":\"b\"}\n", out)
+
+	out = test.CaptureConsoleOutput(func() { printCmdOutputCustom(printRequest{format: "jsonl"}, testStruct{Field1: "x"}, nil) }, t)
+	assert.Equal(t, "{\"Field1\":\"x\",\"Field2\":0,\"Field3\":false}\n", out)
+
+	table := &Table{Headers: []string{"ID", "Name"}, Lines: [][]string{{"1", "one"}, {"2", "two"}}}
+	out = test.CaptureConsoleOutput(func() { printCmdOutputCustom(printRequest{format: "csv"}, v, table) }, t)
+	assert.Equal(t, "ID,Name\n1,one\n2,two\n", out)
+}
+
+func TestPrintYamlDocuments(t *testing.T) {
+	v := map[string]any{"items": []any{map[string]any{"id": "a", "n": 1}, map[string]any{"id": "b", "n": 2}}, "total": 2}
+	out := test.CaptureConsoleOutput(func() { printCmdOutputCustom(printRequest{format: "yaml", flatten: true}, v, nil) }, t)
+	assert.Equal(t, "---\nid: a\n\"n\": 1\n---\nid: b\n\"n\": 2\n", out)
+
+	// without --flatten, the list is a single document
+	out = test.CaptureConsoleOutput(func() { printCmdOutputCustom(printRequest{format: "yaml"}, v, nil) }, t)
+	assert.True(t, strings.HasPrefix(out, "items:\n"))
+
+	// other values are a single document, with the same fields as in JSON
+	out = test.CaptureConsoleOutput(func() {
+		printCmdOutputCustom(printRequest{format: "yaml", flatten: true}, testStruct{Field1: "x"}, nil)
+	}, t)
+	assert.Equal(t, "---\nField1: x\nField2: 0\nField3: false\n", out)
+}
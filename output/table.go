@@ -0,0 +1,276 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package output
+
+import (
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/mattn/go-runewidth"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+const (
+	cellPadding    = " " // on both sides of each cell, as well as at the start and the end of each line
+	minColumnWidth = 8   // columns are not shrunk below this width (or their header's) to fit the terminal
+	ellipsis       = "…"
+)
+
+// statusHeaderRegexp matches the headers of the columns whose values are colorized by status
+var statusHeaderRegexp = regexp.MustCompile(`(?i)(^|[ _])(status|state|result|health|phase|outcome)$`)
+
+// statusColors maps the first word of a status value, in lower case, to its color
+var statusColors = map[string]*color.Color{}
+
+func init() {
+	green, red, yellow := color.New(color.FgGreen), color.New(color.FgRed), color.New(color.FgYellow)
+	for _, s := range []string{"ok", "success", "succeeded", "successful", "healthy", "pass", "passed", "completed", "complete", "done", "active", "ready", "running", "up", "enabled", "valid", "installed"} {
+		statusColors[s] = green
+	}
+	for _, s := range []string{"failed", "failure", "fail", "error", "errored", "unhealthy", "invalid", "rejected", "down", "crashed", "timeout"} {
+		statusColors[s] = red
+	}
+	for _, s := range []string{"pending", "in progress", "warning", "degraded", "unknown", "queued", "waiting", "deploying"} {
+		statusColors[s] = yellow
+	}
+}
+
+// tableRenderer displays tables as aligned columns, with centered headers followed by a blank line.
+// Tables wider than the terminal are fitted to it by shrinking the widest columns, truncating or
+// wrapping their cells.
+type tableRenderer struct {
+	maxWidth int  // maximum width of a line, 0 for unlimited (e.g., when the output is not a terminal)
+	wrap     bool // wrap long cells over multiple lines, instead of truncating them
+	color    bool // colorize the header and the status columns
+}
+
+// newTableRenderer returns a renderer for the command's output, honoring the --wrap flag, the terminal
+// width (or $COLUMNS) and whether color is enabled (see --no-color and $NO_COLOR)
+func newTableRenderer(cmd *cobra.Command) *tableRenderer {
+	r := &tableRenderer{color: !color.NoColor}
+	if cmd != nil {
+		r.wrap, _ = cmd.Flags().GetBool("wrap")
+	}
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		r.maxWidth = columns
+	} else if f, ok := GetOutWriter(cmd).(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		if width, _, err := term.GetSize(int(f.Fd())); err == nil {
+			r.maxWidth = width
+		}
+	}
+	if f, ok := GetOutWriter(cmd).(*os.File); !ok || !term.IsTerminal(int(f.Fd())) {
+		r.color = false // e.g., output redirected to a file
+	}
+	return r
+}
+
+// tableLayout is the layout of a table's columns
+type tableLayout struct {
+	widths  []int
+	numeric []bool // columns aligned to the right
+}
+
+// layout returns the layout of the columns: each is as wide as its widest cell, but at least as wide as
+// in the previous layout (e.g., of the rows displayed so far), and shrunk to fit the maximum width if
+// needed. Columns of numbers are aligned to the right, as in the previous layout, if any.
+func (r *tableRenderer) layout(headers []string, lines [][]string, previous *tableLayout) *tableLayout {
+	var minWidths []int
+	numeric := numericColumns(len(headers), lines)
+	if previous != nil {
+		minWidths, numeric = previous.widths, previous.numeric
+	}
+	widths := make([]int, len(headers))
+	floors := make([]int, len(headers))
+	for i, h := range headers {
+		widths[i] = runewidth.StringWidth(headerText(h))
+		floors[i] = max(widths[i], minColumnWidth)
+		if i < len(minWidths) {
+			widths[i] = max(widths[i], minWidths[i])
+			floors[i] = max(floors[i], minWidths[i])
+		}
+	}
+	for _, line := range lines {
+		for i, cell := range line {
+			if i < len(widths) {
+				for _, l := range strings.Split(cell, "\n") {
+					widths[i] = max(widths[i], runewidth.StringWidth(l))
+				}
+			}
+		}
+	}
+	if r.maxWidth <= 0 {
+		return &tableLayout{widths: widths, numeric: numeric}
+	}
+
+	// shrink the widest column, one at a time, until the table fits
+	excess := len(cellPadding)*(2*len(widths)+2) - r.maxWidth
+	for _, w := range widths {
+		excess += w
+	}
+	for excess > 0 {
+		widest := -1
+		for i, w := range widths {
+			if w > floors[i] && (widest < 0 || w > widths[widest]) {
+				widest = i
+			}
+		}
+		if widest < 0 {
+			break // can't fit, the terminal will wrap the lines
+		}
+		widths[widest]--
+		excess--
+	}
+	return &tableLayout{widths: widths, numeric: numeric}
+}
+
+// render displays the rows of a table, preceded by the header if requested, with the given layout
+func (r *tableRenderer) render(w io.Writer, headers []string, lines [][]string, l *tableLayout, withHeader bool) error {
+	var b strings.Builder
+	if withHeader {
+		cells := make([]string, len(headers))
+		for i, h := range headers {
+			cells[i] = headerText(h)
+		}
+		r.writeRow(&b, cells, l, true, func(int, string) *color.Color { return color.New(color.Bold) })
+		b.WriteString("\n")
+	}
+	for _, line := range lines {
+		r.writeRow(&b, line, l, false, func(i int, cell string) *color.Color {
+			if i < len(headers) && statusHeaderRegexp.MatchString(headers[i]) {
+				return statusColor(cell)
+			}
+			return nil
+		})
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeRow writes a row of cells, over multiple lines if cells contain newlines or are wrapped; the
+// cells of the header are centered
+func (r *tableRenderer) writeRow(b *strings.Builder, cells []string, l *tableLayout, header bool, colorOf func(int, string) *color.Color) {
+	widths := l.widths
+	// split the cells into the lines to display
+	cells = append(cells, make([]string, max(len(widths)-len(cells), 0))...)
+	cellLines := make([][]string, len(widths))
+	height := 1
+	for i := range widths {
+		for _, l := range strings.Split(cells[i], "\n") {
+			if r.wrap {
+				cellLines[i] = append(cellLines[i], wrapText(l, widths[i])...)
+			} else {
+				cellLines[i] = append(cellLines[i], truncateText(l, widths[i]))
+			}
+		}
+		height = max(height, len(cellLines[i]))
+	}
+
+	for row := 0; row < height; row++ {
+		b.WriteString(cellPadding)
+		for i, width := range widths {
+			text := ""
+			if row < len(cellLines[i]) {
+				text = cellLines[i][row]
+			}
+			gap := max(width-runewidth.StringWidth(text), 0)
+			if c := colorOf(i, cells[i]); r.color && c != nil && text != "" {
+				text = c.Sprint(text)
+			}
+			left := 0
+			switch {
+			case header:
+				left = gap / 2
+			case l.numeric[i]:
+				left = gap
+			}
+			b.WriteString(cellPadding + strings.Repeat(" ", left) + text + strings.Repeat(" ", gap-left) + cellPadding)
+		}
+		b.WriteString(cellPadding + "\n")
+	}
+}
+
+// headerText returns the displayed text of a header, e.g., "AUTH METHOD" for "auth_method"
+func headerText(header string) string {
+	return strings.ToUpper(strings.ReplaceAll(header, "_", " "))
+}
+
+// numericColumns returns, for each column, whether all its non-empty cells are numbers, so that they
+// can be aligned to the right
+func numericColumns(n int, lines [][]string) []bool {
+	numeric := make([]bool, n)
+	for i := range numeric {
+		numeric[i] = len(lines) > 0
+	}
+	for _, line := range lines {
+		for i := 0; i < n && i < len(line); i++ {
+			if _, err := strconv.ParseFloat(strings.TrimSpace(line[i]), 64); err != nil && line[i] != "" {
+				numeric[i] = false
+			}
+		}
+	}
+	return numeric
+}
+
+// statusColor returns the color of a status value, based on its words (e.g., "in progress") or its
+// first word (e.g., "failed (3 errors)"), or nil if it has none
+func statusColor(value string) *color.Color {
+	words := strings.FieldsFunc(strings.ToLower(value), func(r rune) bool {
+		return !('a' <= r && r <= 'z')
+	})
+	if len(words) == 0 {
+		return nil
+	}
+	if c, found := statusColors[strings.Join(words, " ")]; found {
+		return c
+	}
+	return statusColors[words[0]]
+}
+
+// truncateText shortens a text to the width, ending it with an ellipsis if it is truncated
+func truncateText(s string, width int) string {
+	if runewidth.StringWidth(s) <= width {
+		return s
+	}
+	return runewidth.Truncate(s, width, ellipsis)
+}
+
+// wrapText breaks a text into lines of the width, at spaces where possible
+func wrapText(s string, width int) []string {
+	if width <= 0 || runewidth.StringWidth(s) <= width {
+		return []string{s}
+	}
+	var lines []string
+	for runewidth.StringWidth(s) > width {
+		head := runewidth.Truncate(s, width, "")
+		if i := strings.LastIndex(head, " "); i > 0 {
+			head = head[:i]
+		}
+		lines = append(lines, strings.TrimRight(head, " "))
+		s = strings.TrimLeft(s[len(head):], " ")
+	}
+	return append(lines, s)
+}
+
+func max(a int, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
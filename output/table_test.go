@@ -0,0 +1,111 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package output
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tableLines returns the expected output of a table, with a line per argument
+func tableLines(lines ...string) string {
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func renderTable(t *testing.T, r *tableRenderer, headers []string, lines [][]string) string {
+	var b strings.Builder
+	require.NoError(t, r.render(&b, headers, lines, r.layout(headers, lines, nil), true))
+	return b.String()
+}
+
+func TestTableFitsWidth(t *testing.T) {
+	headers := []string{"id", "description", "count"}
+	lines := [][]string{
+		{"a", "a long description that does not fit in the terminal", "3"},
+		{"b", "short", "12"},
+	}
+
+	// unlimited width
+	r := &tableRenderer{}
+	assert.Equal(t, tableLines(
+		"  ID                      DESCRIPTION                       COUNT  ",
+		"",
+		"  a   a long description that does not fit in the terminal      3  ",
+		"  b   short                                                    12  ",
+	), renderTable(t, r, headers, lines))
+
+	// truncated
+	r.maxWidth = 30
+	out := renderTable(t, r, headers, lines)
+	assert.Equal(t, tableLines(
+		"  ID    DESCRIPTION    COUNT  ",
+		"",
+		"  a   a long descrip…      3  ",
+		"  b   short               12  ",
+	), out)
+
+	// wrapped
+	r.wrap = true
+	out = renderTable(t, r, headers, lines)
+	assert.Equal(t, tableLines(
+		"  ID    DESCRIPTION    COUNT  ",
+		"",
+		"  a   a long               3  ",
+		"      description             ",
+		"      that does not           ",
+		"      fit in the              ",
+		"      terminal                ",
+		"  b   short               12  ",
+	), out)
+	for _, line := range strings.Split(out, "\n") {
+		assert.LessOrEqual(t, len(line), 30)
+	}
+
+	// columns are not shrunk below the minimum width, even if the table does not fit
+	r = &tableRenderer{maxWidth: 5}
+	l := r.layout(headers, lines, nil)
+	assert.Equal(t, []int{2, 11, 5}, l.widths)
+}
+
+func TestTableLayoutKeepsPreviousWidths(t *testing.T) {
+	r := &tableRenderer{}
+	l := r.layout([]string{"name"}, [][]string{{"a long name"}}, nil)
+	l = r.layout([]string{"name"}, [][]string{{"x"}}, l)
+	assert.Equal(t, []int{11}, l.widths)
+	assert.Equal(t, []bool{false}, l.numeric)
+}
+
+func TestTableStatusColor(t *testing.T) {
+	noColor := color.NoColor
+	color.NoColor = false
+	defer func() { color.NoColor = noColor }()
+
+	r := &tableRenderer{color: true}
+	out := renderTable(t, r, []string{"name", "status"}, [][]string{{"failed", "FAILED (3 errors)"}, {"b", "ok"}, {"c", "in progress"}, {"d", "other"}})
+	assert.Contains(t, out, color.New(color.FgRed).Sprint("FAILED (3 errors)"))
+	assert.Contains(t, out, color.New(color.FgGreen).Sprint("ok"))
+	assert.Contains(t, out, color.New(color.FgYellow).Sprint("in progress"))
+	assert.Contains(t, out, "failed  ", "only status columns are colorized")
+	assert.Contains(t, out, "other  ")
+
+	assert.Nil(t, statusColor("42"))
+	assert.True(t, statusHeaderRegexp.MatchString("Health"))
+	assert.True(t, statusHeaderRegexp.MatchString("deployment_status"))
+	assert.False(t, statusHeaderRegexp.MatchString("statuses"))
+}
@@ -0,0 +1,105 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Go template output formats, as in kubectl: -o go-template=TEMPLATE or -o go-template-file=FILE
+const (
+	goTemplatePrefix     = "go-template="
+	goTemplateFilePrefix = "go-template-file="
+	goTemplateFormat     = "go-template"
+)
+
+// templateFuncs are the functions available to output templates, in addition to the text/template builtins
+var templateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"yaml": func(v any) (string, error) {
+		b, err := yaml.Marshal(v)
+		return string(b), err
+	},
+	"join": func(sep string, v []any) string {
+		s := make([]string, len(v))
+		for i, e := range v {
+			s[i] = fmt.Sprint(e)
+		}
+		return strings.Join(s, sep)
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// parseTemplateFormat returns the template of a go-template or go-template-file output format, or
+// an empty string for other formats; the template is checked for syntax errors
+func parseTemplateFormat(format string) (string, error) {
+	var text string
+	switch {
+	case strings.HasPrefix(format, goTemplatePrefix):
+		text = strings.TrimPrefix(format, goTemplatePrefix)
+	case strings.HasPrefix(format, goTemplateFilePrefix):
+		b, err := os.ReadFile(strings.TrimPrefix(format, goTemplateFilePrefix))
+		if err != nil {
+			return "", fmt.Errorf("failed to read the template file: %w", err)
+		}
+		text = string(b)
+	case format == goTemplateFormat || format == "go-template-file":
+		return "", fmt.Errorf("the %q output format requires a template, e.g., -o %s'{{range .items}}{{.id}}{{\"\\n\"}}{{end}}'", format, goTemplatePrefix)
+	default:
+		return "", nil
+	}
+	if text == "" {
+		return "", fmt.Errorf("the output template is empty")
+	}
+	if _, err := template.New("output").Funcs(templateFuncs).Parse(text); err != nil {
+		return "", fmt.Errorf("invalid output template: %w", err)
+	}
+	return text, nil
+}
+
+// executeTemplate renders the data with a Go template. The template sees the data as in the JSON
+// output, e.g., {{.items}} for lists, with numbers displayed as in JSON.
+func executeTemplate(v any, text string) (string, error) {
+	tmpl, err := template.New("output").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid output template: %w", err)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	var generic any
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&generic); err != nil {
+		return "", err
+	}
+	var out strings.Builder
+	if err := tmpl.Execute(&out, generic); err != nil {
+		return "", fmt.Errorf("failed to render the output template: %w", err)
+	}
+	return out.String(), nil
+}
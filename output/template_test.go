@@ -0,0 +1,67 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cisco-open/fsoc/test"
+)
+
+func TestParseTemplateFormat(t *testing.T) {
+	text, err := parseTemplateFormat("json")
+	assert.Nil(t, err)
+	assert.Equal(t, "", text)
+
+	text, err = parseTemplateFormat(`go-template={{.id}}`)
+	assert.Nil(t, err)
+	assert.Equal(t, "{{.id}}", text)
+
+	file := filepath.Join(t.TempDir(), "tmpl.txt")
+	require.NoError(t, os.WriteFile(file, []byte("{{range .items}}{{.id}}\n{{end}}"), 0600))
+	text, err = parseTemplateFormat("go-template-file=" + file)
+	assert.Nil(t, err)
+	assert.Equal(t, "{{range .items}}{{.id}}\n{{end}}", text)
+
+	for _, format := range []string{"go-template", "go-template=", "go-template={{.id", "go-template-file=/nonexistent"} {
+		_, err := parseTemplateFormat(format)
+		assert.Error(t, err, format)
+	}
+}
+
+func TestPrintGoTemplate(t *testing.T) {
+	type item struct {
+		ID    string   `json:"id"`
+		Count int64    `json:"count"`
+		Tags  []string `json:"tags"`
+	}
+	v := struct {
+		Items []item `json:"items"`
+		Total int    `json:"total"`
+	}{Items: []item{{ID: "a", Count: 12345678, Tags: []string{"x", "y"}}, {ID: "b", Tags: []string{}}}, Total: 2}
+
+	pr := printRequest{format: goTemplateFormat, template: `{{range .items}}{{.id}} {{.count}} {{join "," .tags | upper}}{{"\n"}}{{end}}total: {{.total}}`}
+	out := test.CaptureConsoleOutput(func() { printCmdOutputCustom(pr, v, nil) }, t)
+	assert.Equal(t, "a 12345678 X,Y\nb 0 \ntotal: 2", out)
+
+	pr.template = `{{(index .items 0) | json}}`
+	out = test.CaptureConsoleOutput(func() { printCmdOutputCustom(pr, v, nil) }, t)
+	assert.Equal(t, `{"count":12345678,"id":"a","tags":["x","y"]}`, out)
+}
@@ -0,0 +1,189 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fsocsdk is the public Go API for reusing fsoc's profile resolution, authentication and
+// platform API calls in other tools, instead of running the fsoc executable.
+//
+// A client is created for a profile in an fsoc config file, as managed by "fsoc config", and makes
+// API calls with the profile's authentication, logging in or refreshing the token as needed:
+//
+//	client, err := fsocsdk.New(fsocsdk.Options{Profile: "prod"})
+//	if err != nil {
+//		return err
+//	}
+//	var solutions any
+//	err = client.Get("/solnmgmt/v1beta/solutions", &solutions)
+//
+// fsoc's configuration is process-wide: a process works with a single config file and profile, selected
+// by the first call to New. The types and functions in this package are stable; the internal fsoc
+// packages they are built on may change between releases.
+package fsocsdk
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/spf13/viper"
+
+	"github.com/cisco-open/fsoc/cmd/config"
+	"github.com/cisco-open/fsoc/platform/api"
+)
+
+// Options select the config file and profile of a client
+type Options struct {
+	// ConfigFile is the fsoc config file; the default is config.yaml in the fsoc config home, see config.ConfigHome
+	ConfigFile string
+
+	// Profile is the name of the profile; the default is the config file's current profile
+	Profile string
+
+	// ShowProgress displays fsoc's progress indicators on stderr during API calls
+	ShowProgress bool
+}
+
+// Profile describes the profile used by a client
+type Profile struct {
+	Name       string
+	AuthMethod string
+	URL        string
+	Tenant     string
+	User       string
+}
+
+// Client makes platform API calls with a profile's authentication
+type Client struct {
+	profile Profile
+}
+
+// ErrProfileNotFound is returned by New when the requested profile doesn't exist in the config file
+var ErrProfileNotFound = errors.New("profile not found")
+
+var (
+	mu         sync.Mutex
+	configured *Options // the options of the first client, which determine the process-wide config
+)
+
+// New returns a client for a profile. Only one config file and profile can be used in a process;
+// New returns an error if asked for a different one than the first client's.
+func New(opts Options) (*Client, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if configured != nil {
+		if configured.ConfigFile != opts.ConfigFile || configured.Profile != opts.Profile {
+			return nil, fmt.Errorf("fsoc is already configured with config file %q and profile %q", configured.ConfigFile, configured.Profile)
+		}
+	} else {
+		if err := configure(opts); err != nil {
+			return nil, err
+		}
+		o := opts
+		configured = &o
+	}
+
+	ctx, err := config.LoadCurrentContext()
+	if err != nil {
+		return nil, err
+	}
+	if ctx == nil {
+		return nil, fmt.Errorf("%w: %q", ErrProfileNotFound, config.GetCurrentProfileName())
+	}
+	return &Client{profile: Profile{
+		Name:       ctx.Name,
+		AuthMethod: ctx.AuthMethod,
+		URL:        ctx.URL,
+		Tenant:     ctx.Tenant,
+		User:       ctx.User,
+	}}, nil
+}
+
+func configure(opts Options) error {
+	file := opts.ConfigFile
+	if file == "" {
+		file = config.DefaultConfigFilePath()
+	}
+	if strings.HasPrefix(file, "~") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return fmt.Errorf("failed to determine the home directory: %w", err)
+		}
+		file = home + file[1:]
+	}
+	viper.SetConfigFile(file)
+	viper.SetConfigType("yaml")
+	if err := viper.ReadInConfig(); err != nil {
+		return fmt.Errorf("failed to read the fsoc config file %q: %w", file, err)
+	}
+	if opts.Profile != "" {
+		config.SetSelectedProfile(opts.Profile)
+	}
+	api.SetQuiet(!opts.ShowProgress)
+	return nil
+}
+
+// Profile returns the client's profile
+func (c *Client) Profile() Profile {
+	return c.profile
+}
+
+// Login logs in with the profile's authentication method, if not already logged in. API calls log in
+// as needed, so calling Login is only necessary to check the credentials upfront.
+func (c *Client) Login() error {
+	return api.Login()
+}
+
+// Get makes a GET request to a platform API path, e.g., "/solnmgmt/v1beta/solutions", and decodes the
+// JSON response into out
+func (c *Client) Get(path string, out any) error {
+	return api.JSONGet(path, out, nil)
+}
+
+// GetCollection gets all pages of a collection from a platform API path, decoding the items into out,
+// which receives an object with "items" and "total" fields
+func (c *Client) GetCollection(path string, out any) error {
+	return api.JSONGetCollection(path, out, nil)
+}
+
+// Post makes a POST request with a JSON body to a platform API path, decoding the JSON response into out
+func (c *Client) Post(path string, body any, out any) error {
+	return api.JSONPost(path, body, out, nil)
+}
+
+// Put makes a PUT request with a JSON body to a platform API path, decoding the JSON response into out
+func (c *Client) Put(path string, body any, out any) error {
+	return api.JSONPut(path, body, out, nil)
+}
+
+// Patch makes a PATCH request with a JSON body to a platform API path, decoding the JSON response into out
+func (c *Client) Patch(path string, body any, out any) error {
+	return api.JSONPatch(path, body, out, nil)
+}
+
+// Delete makes a DELETE request to a platform API path, decoding the JSON response, if any, into out
+func (c *Client) Delete(path string, out any) error {
+	return api.JSONDelete(path, out, nil)
+}
+
+// Do makes a request with any method and additional headers, e.g., for APIs that require a specific
+// content type or layer headers
+func (c *Client) Do(method string, path string, body any, out any, headers map[string]string) error {
+	if method == "" {
+		method = http.MethodGet
+	}
+	return api.JSONRequest(method, path, body, out, &api.Options{Headers: headers})
+}
@@ -0,0 +1,108 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fsocsdk
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"method": r.Method,
+			"path":   r.URL.Path,
+			"layer":  r.Header.Get("layer-id"),
+		})
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	t.Setenv("FSOC_CONFIG_DIR", dir)
+	configFile := filepath.Join(dir, "fsoc.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte(`contexts:
+  - name: default
+    auth_method: none
+    url: https://default.example.com
+  - name: test
+    auth_method: none
+    url: `+server.URL+`
+    tenant: tn1
+current_context: default
+`), 0600))
+
+	_, err := New(Options{ConfigFile: filepath.Join(dir, "missing.yaml")})
+	assert.Error(t, err)
+
+	client, err := New(Options{ConfigFile: configFile, Profile: "test"})
+	require.NoError(t, err)
+	assert.Equal(t, Profile{Name: "test", AuthMethod: "none", URL: server.URL, Tenant: "tn1"}, client.Profile())
+
+	var out map[string]string
+	require.NoError(t, client.Get("/objstore/v1beta/objects", &out))
+	assert.Equal(t, map[string]string{"method": "GET", "path": "/objstore/v1beta/objects", "layer": ""}, out)
+	require.NoError(t, client.Do(http.MethodPost, "/knowledge-store/v1/objects", map[string]any{}, &out, map[string]string{"layer-id": "tn1"}))
+	assert.Equal(t, map[string]string{"method": "POST", "path": "/knowledge-store/v1/objects", "layer": "tn1"}, out)
+
+	// the process-wide config can't be changed
+	_, err = New(Options{ConfigFile: configFile, Profile: "default"})
+	assert.Error(t, err)
+	_, err = New(Options{ConfigFile: configFile, Profile: "test"})
+	assert.NoError(t, err)
+}
+
+func TestClientErrors(t *testing.T) {
+	// the tests use their own process-wide config
+	reset := func() {
+		mu.Lock()
+		configured = nil
+		viper.Reset()
+		mu.Unlock()
+	}
+	reset()
+	t.Cleanup(reset)
+
+	dir := t.TempDir()
+	t.Setenv("FSOC_CONFIG_DIR", dir)
+
+	// a config file whose contexts can't be decoded
+	malformed := filepath.Join(dir, "malformed.yaml")
+	require.NoError(t, os.WriteFile(malformed, []byte("contexts: not-a-list\n"), 0600))
+	_, err := New(Options{ConfigFile: malformed, Profile: "test"})
+	assert.Error(t, err)
+	reset()
+
+	// a profile with an invalid URL
+	badURL := filepath.Join(dir, "bad-url.yaml")
+	require.NoError(t, os.WriteFile(badURL, []byte(`contexts:
+  - name: test
+    auth_method: none
+    url: "http://[::1"
+current_context: test
+`), 0600))
+	client, err := New(Options{ConfigFile: badURL, Profile: "test"})
+	require.NoError(t, err)
+	var out map[string]any
+	assert.Error(t, client.Get("/objstore/v1beta/objects", &out))
+}
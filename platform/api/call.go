@@ -18,17 +18,22 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/apex/log"
 
 	"github.com/cisco-open/fsoc/cmd/config"
+	"github.com/cisco-open/fsoc/cmdkit/offline"
+	"github.com/cisco-open/fsoc/exitcode"
 )
 
 // --- Public Interface -----------------------------------------------------
@@ -36,6 +41,35 @@ import (
 type Options struct {
 	Headers         map[string]string
 	ResponseHeaders map[string][]string // headers as returned by the call
+	ResponseStatus  int                 // status code of the response, also when the call fails (0 if there was no response)
+	UploadProgress  ProgressFunc        // if set, reports the progress of sending the request body (replaces the spinner)
+	Resources       []string            // identifiers of the changed resources that are not in the path, for the local history
+	ReadOnly        bool                // true for requests that don't change resources despite the method (e.g., queries), not recorded in the history
+	Timeout         time.Duration       // if not zero, limits the duration of the call, in addition to the command's timeout (see SetContext)
+}
+
+// IsNotFound returns true if a call made with options failed because the requested resource does not
+// exist, whether or not the response was a problem object
+func IsNotFound(err error, options *Options) bool {
+	return err != nil && options != nil && options.ResponseStatus == http.StatusNotFound
+}
+
+// StreamBody is a request body read from a source that can be opened more than once (e.g., a file), for
+// uploading large payloads without loading them into memory. The caller provides the Content-Type header.
+type StreamBody struct {
+	Open func() (io.ReadCloser, error) // called for each attempt of the request
+	Size int64                         // the number of bytes the source provides
+}
+
+// CallObserver is notified of each completed API call, with the response status (0 if there was no
+// response) and the error, if any; it is used for the self-instrumentation of fsoc
+type CallObserver func(method string, path string, started time.Time, statusCode int, err error)
+
+var callObserver CallObserver
+
+// SetCallObserver sets the observer notified of the completed API calls (nil for none)
+func SetCallObserver(observer CallObserver) {
+	callObserver = observer
 }
 
 // JSONGet performs a GET request and parses the response as JSON
@@ -58,6 +92,11 @@ func HTTPPost(path string, body []byte, out any, options *Options) error {
 	return httpRequest("POST", path, body, out, options)
 }
 
+// HTTPPostStream performs a POST request with a streamed body (see StreamBody) - Accept and Content-Type headers are provided by the caller
+func HTTPPostStream(path string, body *StreamBody, out any, options *Options) error {
+	return httpRequest("POST", path, body, out, options)
+}
+
 // HTTPGet performs a GET request with HTTP command and response - Accept and Content-Type headers are provided by the caller
 func HTTPGet(path string, out any, options *Options) error {
 	return httpRequest("GET", path, nil, out, options)
@@ -81,13 +120,15 @@ func JSONRequest(method string, path string, body any, out any, options *Options
 
 // --- Internal methods -----------------------------------------------------
 
-func prepareHTTPRequest(cfg *config.Context, client *http.Client, method string, path string, body any, headers map[string]string) (*http.Request, error) {
+func prepareHTTPRequest(cfg *config.Context, client *http.Client, method string, path string, body any, headers map[string]string, progress ProgressFunc) (*http.Request, error) {
 	// body will be JSONified if a body is given but no Content-Type is provided
 	// (if a content type is provided, we assume the body is in the desired format)
 	jsonify := body != nil && (headers == nil || headers["Content-Type"] == "")
 
 	// prepare a body reader
 	var bodyReader io.Reader = nil
+	var bodySize int64
+	_, streamed := body.(*StreamBody)
 	if jsonify {
 		// marshal body data to JSON
 		bodyBytes, err := json.Marshal(body)
@@ -95,20 +136,39 @@ func prepareHTTPRequest(cfg *config.Context, client *http.Client, method string,
 			return nil, fmt.Errorf("Failed to marshal body data: %w", err)
 		}
 		bodyReader = bytes.NewReader(bodyBytes)
+		bodySize = int64(len(bodyBytes))
+	} else if stream, ok := body.(*StreamBody); ok {
+		// read the body data from the stream, which the request closes once sent
+		rc, err := stream.Open()
+		if err != nil {
+			return nil, fmt.Errorf("Failed to open the request body: %w", err)
+		}
+		bodyReader = rc
+		bodySize = stream.Size
+		if progress != nil {
+			bodyReader = struct {
+				io.Reader
+				io.Closer
+			}{newProgressReader(rc, bodySize, progress), rc}
+		}
 	} else if body != nil {
 		// provide body data as a io.Reader
 		bodyBytes, ok := body.([]byte)
 		if !ok {
-			return nil, fmt.Errorf("(bug) HTTP request body type must be []byte if Content-Type is provided, found %T instead", body)
+			return nil, fmt.Errorf("(bug) HTTP request body type must be []byte or *StreamBody if Content-Type is provided, found %T instead", body)
 		}
 		bodyReader = bytes.NewReader(bodyBytes)
+		bodySize = int64(len(bodyBytes))
+	}
+	if bodyReader != nil && progress != nil && !streamed {
+		bodyReader = newProgressReader(bodyReader, bodySize, progress)
 	}
 
 	// create HTTP request
 	path, query, _ := strings.Cut(path, "?")
 	url, err := url.Parse(cfg.URL)
 	if err != nil {
-		log.Fatalf("Failed to parse the url provided in context (%q): %v", cfg.URL, err)
+		return nil, fmt.Errorf("Failed to parse the url provided in context (%q): %w", cfg.URL, err)
 	}
 	url.Path = path
 	url.RawQuery = query
@@ -116,6 +176,9 @@ func prepareHTTPRequest(cfg *config.Context, client *http.Client, method string,
 	if err != nil {
 		return nil, fmt.Errorf("Failed to create a request for %q: %w", url.String(), err)
 	}
+	if bodyReader != nil && (progress != nil || streamed) {
+		req.ContentLength = bodySize // not detected automatically through the progress reader or stream
+	}
 
 	// add headers that are not already provided
 	if jsonify {
@@ -144,10 +207,35 @@ func prepareHTTPRequest(cfg *config.Context, client *http.Client, method string,
 	return req, nil
 }
 
-func httpRequest(method string, path string, body any, out any, options *Options) error {
+func httpRequest(method string, path string, body any, out any, options *Options) (err error) {
 	log.WithFields(log.Fields{"method": method, "path": path}).Info("Calling FSO platform API")
 
-	callCtx := newCallContext()
+	var statusCode int
+	if options != nil {
+		options.ResponseStatus = 0
+	}
+	if observer := callObserver; observer != nil {
+		started := time.Now()
+		defer func() { observer(method, path, started, statusCode, err) }()
+	}
+
+	// classify the failure for the exit code, in case the command fails with the error
+	loginFailed := false
+	defer func() {
+		if err != nil {
+			exitcode.Record(err, callExitCode(statusCode, loginFailed, err))
+		}
+	}()
+
+	// fail immediately, without logging in, if network access is disabled
+	if err := offline.Check(fmt.Sprintf("API call %s %s", method, path)); err != nil {
+		return err
+	}
+
+	callCtx, err := newCallContext()
+	if err != nil {
+		return err
+	}
 	cfg := callCtx.cfg               // quick access
 	defer callCtx.stopSpinner(false) // ensure the spinner is not running when returning (belt & suspenders)
 
@@ -156,31 +244,46 @@ func httpRequest(method string, path string, body any, out any, options *Options
 		options = &Options{}
 	}
 
+	// limit the duration of the call, if requested
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		callCtx.goContext, cancel = context.WithTimeout(callCtx.goContext, options.Timeout)
+		defer cancel()
+	}
+
 	// force login if no token
 	if cfg.Token == "" {
 		log.Info("No auth token available, trying to log in")
 		if err := login(callCtx); err != nil {
+			loginFailed = true
 			return err
 		}
 		cfg = callCtx.cfg // may have changed across login
 	}
 
-	// create http client for the request
-	client := &http.Client{}
+	// use the shared http client for the request
+	client := httpClient()
 
 	// build HTTP request
-	req, err := prepareHTTPRequest(cfg, client, method, path, body, options.Headers)
+	req, err := prepareHTTPRequest(cfg, client, method, path, body, options.Headers, options.UploadProgress)
 	if err != nil {
 		return err // assume error messages provide sufficient info
 	}
 
+	// the upload progress, if reported, replaces the spinner
+	if options.UploadProgress != nil {
+		callCtx.noSpinner = true
+	}
+
 	// execute request, speculatively, assuming the auth token is valid
 	callCtx.startSpinner(fmt.Sprintf("Platform API call (%v %v)", req.Method, urlDisplayPath(req.URL)))
-	resp, err := client.Do(req)
+	sent := time.Now()
+	resp, err := client.Do(req.WithContext(callCtx.goContext))
 	if err != nil {
 		// nb: spinner will be stopped by defer
-		return fmt.Errorf("%v request to %q failed: %w", method, req.URL.String(), err)
+		return requestError(callCtx, req, err)
 	}
+	checkClockSkew(cfg.Name, resp, sent, time.Now())
 
 	// collect response body (whether success or error)
 	var respBytes []byte
@@ -196,22 +299,25 @@ func httpRequest(method string, path string, body any, out any, options *Options
 		log.Warn("Current token is no longer valid; trying to refresh")
 		err := login(callCtx)
 		if err != nil {
+			loginFailed = true
 			return fmt.Errorf("Failed to login: %w", err)
 		}
 		cfg = callCtx.cfg // may have changed across login
 
 		// retry the request
 		log.Info("Retrying the request with the refreshed token")
-		req, err = prepareHTTPRequest(cfg, client, method, path, body, options.Headers)
+		req, err = prepareHTTPRequest(cfg, client, method, path, body, options.Headers, options.UploadProgress)
 		if err != nil {
 			return err // error should have enough context
 		}
 		callCtx.startSpinner(fmt.Sprintf("Platform API call, retry after login (%v %v)", req.Method, urlDisplayPath(req.URL)))
-		resp, err = client.Do(req)
+		sent = time.Now()
+		resp, err = client.Do(req.WithContext(callCtx.goContext))
 		// leave the spinner until the outcome is finalized, return will stop/fail it
 		if err != nil {
-			return fmt.Errorf("%v request to %q failed: %w", method, req.URL.String(), err)
+			return requestError(callCtx, req, err)
 		}
+		checkClockSkew(cfg.Name, resp, sent, time.Now())
 
 		// collect response body (whether success or error)
 		defer resp.Body.Close()
@@ -221,6 +327,12 @@ func httpRequest(method string, path string, body any, out any, options *Options
 		}
 	}
 
+	statusCode = resp.StatusCode
+	if options != nil {
+		options.ResponseStatus = resp.StatusCode
+	}
+	recordHistory(cfg, method, path, options, resp.StatusCode)
+
 	// return if API call response indicates error
 	if resp.StatusCode/100 != 2 {
 		callCtx.stopSpinner(false) // if still running
@@ -266,6 +378,32 @@ func httpRequest(method string, path string, body any, out any, options *Options
 	return nil
 }
 
+// requestError returns the error for a request that failed without a response
+func requestError(callCtx *callContext, req *http.Request, err error) error {
+	if errors.Is(callCtx.goContext.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%v request to %q timed out: %w", req.Method, req.URL.String(), context.DeadlineExceeded)
+	}
+	if errors.Is(callCtx.goContext.Err(), context.Canceled) {
+		return fmt.Errorf("%v request to %q interrupted: %w", req.Method, req.URL.String(), context.Canceled)
+	}
+	return fmt.Errorf("%v request to %q failed: %w", req.Method, req.URL.String(), err)
+}
+
+// callExitCode returns the exit code for a failed API call
+func callExitCode(statusCode int, loginFailed bool, err error) int {
+	switch {
+	case exitcode.IsTimeout(err) || errors.Is(baseContext.Err(), context.DeadlineExceeded):
+		return exitcode.Timeout
+	case errors.Is(err, context.Canceled) || errors.Is(baseContext.Err(), context.Canceled):
+		return exitcode.Interrupted
+	case loginFailed:
+		return exitcode.Auth
+	case statusCode != 0:
+		return exitcode.ForStatus(statusCode)
+	}
+	return exitcode.General
+}
+
 // parseError creates an error from HTTP response data
 // method creates either an error with wrapped response body
 // or a Problem struct in case the response is of type "application/problem+json"
@@ -1,12 +1,20 @@
 package api
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
 	"github.com/cisco-open/fsoc/cmd/config"
+	"github.com/cisco-open/fsoc/exitcode"
 )
 
 func TestPrepareHTTPRequest(t *testing.T) {
@@ -14,7 +22,7 @@ func TestPrepareHTTPRequest(t *testing.T) {
 	cfg := &config.Context{
 		URL: "http://localhost:8080",
 	}
-	req, err := prepareHTTPRequest(cfg, client, "POST", "/test/path/1", nil, nil)
+	req, err := prepareHTTPRequest(cfg, client, "POST", "/test/path/1", nil, nil, nil)
 	assert.Nil(t, err)
 	assert.Equal(t, "http://localhost:8080/test/path/1", req.URL.String())
 }
@@ -24,7 +32,58 @@ func TestPrepareJSONRequest(t *testing.T) {
 	cfg := &config.Context{
 		URL: "http://localhost:8080",
 	}
-	req, err := prepareHTTPRequest(cfg, client, "POST", "/test/path/1", nil, nil)
+	req, err := prepareHTTPRequest(cfg, client, "POST", "/test/path/1", nil, nil, nil)
 	assert.Nil(t, err)
 	assert.Equal(t, "http://localhost:8080/test/path/1", req.URL.String())
 }
+
+func TestPrepareStreamRequest(t *testing.T) {
+	opened := 0
+	body := &StreamBody{
+		Open: func() (io.ReadCloser, error) {
+			opened++
+			return io.NopCloser(strings.NewReader("payload")), nil
+		},
+		Size: 7,
+	}
+	headers := map[string]string{"Content-Type": "application/octet-stream"}
+	for i := 1; i <= 2; i++ { // each attempt reopens the body
+		req, err := prepareHTTPRequest(&config.Context{URL: "http://localhost:8080"}, &http.Client{}, "POST", "/upload", body, headers, nil)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(7), req.ContentLength)
+		data, err := io.ReadAll(req.Body)
+		assert.Nil(t, err)
+		assert.Equal(t, "payload", string(data))
+		assert.Equal(t, i, opened)
+	}
+}
+
+func TestRequestTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	SetContext(ctx)
+	defer SetContext(nil)
+
+	callCtx := &callContext{goContext: baseContext}
+	req, err := prepareHTTPRequest(&config.Context{URL: server.URL}, &http.Client{}, "GET", "/slow", nil, nil, nil)
+	assert.Nil(t, err)
+	_, err = http.DefaultClient.Do(req.WithContext(callCtx.goContext))
+	err = requestError(callCtx, req, err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Contains(t, err.Error(), "timed out")
+	assert.Equal(t, exitcode.Timeout, callExitCode(0, false, err))
+}
+
+func TestIsNotFound(t *testing.T) {
+	err := errors.New("not found") // e.g., a response that isn't a problem object
+	assert.True(t, IsNotFound(err, &Options{ResponseStatus: http.StatusNotFound}))
+	assert.True(t, IsNotFound(fmt.Errorf("wrapped: %w", Problem{Status: http.StatusNotFound}), &Options{ResponseStatus: http.StatusNotFound}))
+	assert.False(t, IsNotFound(err, &Options{ResponseStatus: http.StatusForbidden}))
+	assert.False(t, IsNotFound(nil, &Options{ResponseStatus: http.StatusNotFound}))
+	assert.False(t, IsNotFound(err, nil))
+}
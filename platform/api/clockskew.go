@@ -0,0 +1,91 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/apex/log"
+)
+
+// ClockSkewThreshold is the difference between the local clock and the server's clock above
+// which a warning is displayed, as it is likely to cause token validation failures
+const ClockSkewThreshold = 30 * time.Second
+
+// clockSkews holds the most recently measured clock skew for each profile
+var clockSkews = struct {
+	sync.Mutex
+	skews  map[string]time.Duration
+	warned map[string]bool
+}{skews: map[string]time.Duration{}, warned: map[string]bool{}}
+
+// GetClockSkew returns the difference between the local clock and the clock of the server
+// of the specified profile (positive if the local clock is ahead), as measured during the
+// last API call made with the profile. Returns false if no measurement is available.
+func GetClockSkew(profile string) (time.Duration, bool) {
+	clockSkews.Lock()
+	defer clockSkews.Unlock()
+	skew, found := clockSkews.skews[profile]
+	return skew, found
+}
+
+// checkClockSkew measures the clock skew using the Date header of a response received for a
+// request sent at the specified time, warning once per profile if the skew exceeds the threshold
+func checkClockSkew(profile string, resp *http.Response, sent time.Time, received time.Time) {
+	skew, ok := measureClockSkew(resp.Header, sent, received)
+	if !ok {
+		return
+	}
+
+	clockSkews.Lock()
+	defer clockSkews.Unlock()
+	clockSkews.skews[profile] = skew
+	if abs(skew) > ClockSkewThreshold && !clockSkews.warned[profile] {
+		clockSkews.warned[profile] = true
+		log.WithFields(log.Fields{"profile": profile, "skew": skew}).
+			Warnf("The local clock differs from the platform's clock by %v; this may cause authentication failures, please synchronize your clock", skew.Round(time.Second))
+	}
+}
+
+// measureClockSkew computes the clock skew from the Date response header, assuming the server
+// generated the response halfway between sending the request and receiving the response
+func measureClockSkew(header http.Header, sent time.Time, received time.Time) (time.Duration, bool) {
+	dateStr := header.Get("Date")
+	if dateStr == "" {
+		return 0, false
+	}
+	serverTime, err := http.ParseTime(dateStr)
+	if err != nil {
+		log.Infof("Failed to parse the Date header %q: %v", dateStr, err)
+		return 0, false
+	}
+	localTime := sent.Add(received.Sub(sent) / 2)
+
+	// the Date header has a resolution of one second, ignore differences within it
+	skew := localTime.Sub(serverTime)
+	if abs(skew) < time.Second {
+		return 0, true
+	}
+	return skew, true
+}
+
+func abs(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
@@ -0,0 +1,57 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMeasureClockSkew(t *testing.T) {
+	serverTime := time.Date(2023, 4, 1, 12, 0, 0, 0, time.UTC)
+	header := http.Header{}
+	header.Set("Date", serverTime.Format(http.TimeFormat))
+
+	// local clock ahead by 2 minutes, 1s round trip
+	sent := serverTime.Add(2*time.Minute - 500*time.Millisecond)
+	skew, ok := measureClockSkew(header, sent, sent.Add(time.Second))
+	assert.True(t, ok)
+	assert.Equal(t, 2*time.Minute, skew)
+
+	// within the header's resolution
+	skew, ok = measureClockSkew(header, serverTime.Add(300*time.Millisecond), serverTime.Add(700*time.Millisecond))
+	assert.True(t, ok)
+	assert.Equal(t, time.Duration(0), skew)
+
+	_, ok = measureClockSkew(http.Header{}, sent, sent)
+	assert.False(t, ok)
+}
+
+func TestCheckClockSkew(t *testing.T) {
+	serverTime := time.Now().Add(-time.Hour).UTC()
+	resp := &http.Response{Header: http.Header{}}
+	resp.Header.Set("Date", serverTime.Format(http.TimeFormat))
+
+	_, found := GetClockSkew("skewed")
+	assert.False(t, found)
+
+	checkClockSkew("skewed", resp, time.Now(), time.Now())
+	skew, found := GetClockSkew("skewed")
+	assert.True(t, found)
+	assert.InDelta(t, time.Hour.Seconds(), skew.Seconds(), 2)
+}
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/apex/log"
 	"github.com/peterhellberg/link"
@@ -31,8 +32,19 @@ const (
 )
 
 type dataPage struct {
-	Items []any `json:"items"`
-	Total int   `json:"total"`
+	Items     []any `json:"items"`
+	Total     int   `json:"total"`
+	Truncated bool  `json:"truncated,omitempty"`
+}
+
+// maxPagingTime is the wall-clock budget for retrieving all pages of a collection (0 means no limit)
+var maxPagingTime time.Duration
+
+// SetMaxPagingTime sets the wall-clock budget for retrieving all pages of a collection.
+// Once the budget is exceeded, no more pages are requested and the collection is marked
+// as truncated. Zero (the default) means no limit.
+func SetMaxPagingTime(d time.Duration) {
+	maxPagingTime = d
 }
 
 // JSONGetCollection performs a GET request and parses the response as JSON,
@@ -47,32 +59,65 @@ func JSONGetCollection(path string, out any, options *Options) error {
 		return fmt.Errorf("bug: request for collection at %q does not provide buffer for data (type %T found instead of *any)", path, out)
 	}
 
+	var result dataPage
+	var expected int
+	truncated, err := VisitCollection(path, options, func(items []any, total int) error {
+		if result.Items == nil {
+			// initialize slice for the full result size
+			result.Items = make([]any, 0, total)
+		}
+		result.Items = append(result.Items, items...)
+		expected = total
+		return nil
+	})
+	if err != nil {
+		if len(result.Items) > 0 {
+			return fmt.Errorf("%v. All data discarded", err)
+		}
+		return err
+	}
+	result.Truncated = truncated
+	result.Total = len(result.Items)
+	if result.Total != expected && !result.Truncated {
+		log.Warnf("Collection at %q returned %v items vs. expected %v items", path, result.Total, expected)
+	}
+	*outPtr = &result
+
+	return nil
+}
+
+// VisitCollection performs GET requests for the pages of a collection, like JSONGetCollection, calling
+// the handler with the items of each page as soon as the page arrives, together with the total number of
+// items reported by the page. This allows displaying large collections without accumulating them.
+// An error returned by the handler stops the retrieval and is returned. VisitCollection returns true if
+// the collection was truncated because the paging time budget was exhausted (see SetMaxPagingTime).
+func VisitCollection(path string, options *Options, handle func(items []any, total int) error) (bool, error) {
 	subOptions := Options{}
 	if options != nil {
 		subOptions = *options // shallow copy
 	}
 
-	var result dataPage
-
 	var page dataPage
 	var pageNo int
+	start := time.Now()
 	for pageNo = 0; true; pageNo += 1 {
 		// request collection
+		page = dataPage{}
 		err := httpRequest("GET", path, nil, &page, &subOptions)
+		if options != nil {
+			options.ResponseStatus = subOptions.ResponseStatus // e.g., for IsNotFound
+		}
 		if err != nil {
 			if pageNo > 0 {
-				return fmt.Errorf("Error retrieving non-first page #%v in collection at %q: %v. All data discarded", pageNo+1, path, err)
+				return false, fmt.Errorf("Error retrieving non-first page #%v in collection at %q: %v", pageNo+1, path, err)
 			}
-			return err
+			return false, err
 		}
 
-		// transfer received items
-		//log.Infof("Collection page #%v returned %v items, with total of %v", pageNo+1, len(page.Items), page.Total)
-		if result.Items == nil {
-			// initialize slice for the full result size
-			result.Items = make([]any, 0, page.Total)
+		// pass on received items
+		if err := handle(page.Items, page.Total); err != nil {
+			return false, err
 		}
-		result.Items = append(result.Items, page.Items...)
 
 		// break if no more pages (no response headers, no links or no next link)
 		if subOptions.ResponseHeaders == nil {
@@ -87,28 +132,28 @@ func JSONGetCollection(path string, out any, options *Options) error {
 			break
 		}
 
+		// stop if the time budget is exhausted
+		if maxPagingTime > 0 && time.Since(start) >= maxPagingTime {
+			log.Warnf("Stopped retrieving collection at %q after %v (max time exceeded); output is truncated to %v page(s) of %v items", path, time.Since(start).Round(time.Millisecond), pageNo+1, page.Total)
+			return true, nil
+		}
+
 		// compute path to the next page, working around incomplete paths usually returned by APIs
 		// This is done by keeping the original path up to the query string and just replacing the query string
 		log.Infof("Collection page #%v at %q returned %v items and indicated that more are available at %q for a total of %v", pageNo+1, path, len(page.Items), next, page.Total)
 		nextUrl, err := url.Parse(next.String())
 		if err != nil {
-			return fmt.Errorf("Failed to parse collection iterator link(s) %v: %v ", links, err)
+			return false, fmt.Errorf("Failed to parse collection iterator link(s) %v: %v ", links, err)
 		}
 		nextQuery := nextUrl.RawQuery
 		nextUrl, err = url.Parse(path)
 		if err != nil {
-			return fmt.Errorf("Failed to parse path %q: %v", path, err)
+			return false, fmt.Errorf("Failed to parse path %q: %v", path, err)
 		}
 		nextUrl.RawQuery = nextQuery
 		path = nextUrl.String()
 	}
 	log.Infof("Collection page #%v at %q returned %v items (last page)", pageNo+1, path, len(page.Items))
 
-	result.Total = len(result.Items)
-	if result.Total != page.Total {
-		log.Warnf("Collection at %q returned %v items vs. expected %v items", path, result.Total, page.Total)
-	}
-	*outPtr = &result
-
-	return nil
+	return false, nil
 }
@@ -0,0 +1,190 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/apex/log"
+)
+
+// compressThreshold is the size of a request body, in bytes, above which it is compressed
+const compressThreshold = 8 * 1024
+
+// compression enables the compression of requests and responses, see SetCompression
+var compression = true
+
+// SetCompression enables (the default) or disables the compression of API requests and
+// responses: when enabled, gzip and deflate responses are accepted and large request
+// bodies are sent gzip-compressed, e.g., to cut the transfer time on slow links
+func SetCompression(enabled bool) {
+	compression = enabled
+}
+
+// uncompressedHosts are the hosts that rejected a compressed request body, which are
+// sent uncompressed request bodies for the rest of the command
+var uncompressedHosts = struct {
+	sync.Mutex
+	hosts map[string]bool
+}{hosts: map[string]bool{}}
+
+// precompressedTypes are the content types whose data is already compressed
+var precompressedTypes = map[string]bool{
+	"application/zip":     true,
+	"application/gzip":    true,
+	"application/x-gzip":  true,
+	"multipart/form-data": true, // used for uploading archives
+}
+
+// compressionTransport is a round tripper that negotiates the compression of requests and
+// responses (see SetCompression). Response compression is negotiated with Accept-Encoding.
+// Since there is no negotiation for request compression, compressed request bodies are
+// resent uncompressed if the server rejects them with 415 Unsupported Media Type (RFC 7694).
+type compressionTransport struct {
+	base http.RoundTripper
+}
+
+// RoundTrip executes the request with the base transport, compressing it and decompressing
+// the response as needed
+func (t *compressionTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !compression {
+		return t.base.RoundTrip(req)
+	}
+	if req.Header.Get("Accept-Encoding") == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("Accept-Encoding", "gzip, deflate")
+	}
+
+	compressed, err := compressRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if compressed == nil {
+		return decompressResponse(t.base.RoundTrip(req))
+	}
+	resp, err := t.base.RoundTrip(compressed)
+	if err != nil || resp.StatusCode != http.StatusUnsupportedMediaType {
+		return decompressResponse(resp, err)
+	}
+
+	// resend uncompressed to a server that doesn't accept compressed request bodies
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	uncompressedHosts.Lock()
+	uncompressedHosts.hosts[req.URL.Host] = true
+	uncompressedHosts.Unlock()
+	log.WithField("host", req.URL.Host).Info("Server does not accept compressed requests, resending uncompressed")
+	req.Body, err = req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	return decompressResponse(t.base.RoundTrip(req))
+}
+
+// compressRequest returns a gzip-compressed copy of the request, or nil if the request body
+// should be sent uncompressed: it is small, already compressed or encoded, cannot be read
+// again if the server rejects it, or the server is known not to accept compressed bodies
+func compressRequest(req *http.Request) (*http.Request, error) {
+	if req.Body == nil || req.GetBody == nil || req.ContentLength < compressThreshold || req.Header.Get("Content-Encoding") != "" {
+		return nil, nil
+	}
+	if mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type")); precompressedTypes[mediaType] {
+		return nil, nil
+	}
+	uncompressedHosts.Lock()
+	skip := uncompressedHosts.hosts[req.URL.Host]
+	uncompressedHosts.Unlock()
+	if skip {
+		return nil, nil
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := io.Copy(zw, req.Body); err != nil {
+		return nil, fmt.Errorf("failed to compress the request body: %w", err)
+	}
+	req.Body.Close()
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress the request body: %w", err)
+	}
+	var err error
+	if req.Body, err = req.GetBody(); err != nil { // restore the body for an uncompressed resend
+		return nil, err
+	}
+
+	log.WithFields(log.Fields{"size": req.ContentLength, "compressed_size": buf.Len()}).Debug("Compressed the request body")
+	compressed := req.Clone(req.Context())
+	data := buf.Bytes()
+	compressed.Body = io.NopCloser(bytes.NewReader(data))
+	compressed.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(data)), nil }
+	compressed.ContentLength = int64(len(data))
+	compressed.Header.Set("Content-Encoding", "gzip")
+	return compressed, nil
+}
+
+// decompressResponse replaces the body of a gzip or deflate encoded response with the decoded body
+func decompressResponse(resp *http.Response, err error) (*http.Response, error) {
+	if err != nil {
+		return resp, err
+	}
+	var body io.Reader
+	switch strings.ToLower(resp.Header.Get("Content-Encoding")) {
+	case "gzip", "x-gzip":
+		zr, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			resp.Body.Close()
+			return nil, fmt.Errorf("failed to decompress the gzip response: %w", err)
+		}
+		body = zr
+	case "deflate":
+		body, err = newDeflateReader(resp.Body)
+		if err != nil {
+			resp.Body.Close()
+			return nil, fmt.Errorf("failed to decompress the deflate response: %w", err)
+		}
+	default:
+		return resp, nil
+	}
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{body, resp.Body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return resp, nil
+}
+
+// newDeflateReader returns a reader of deflate encoded data, which is zlib-wrapped per the HTTP
+// spec, but some servers send the raw deflate stream instead
+func newDeflateReader(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	header, err := br.Peek(2)
+	if err == nil && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(br)
+	}
+	return flate.NewReader(br), nil
+}
@@ -0,0 +1,128 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompressedRequestAndResponse(t *testing.T) {
+	payload := strings.Repeat("a", 2*compressThreshold)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "gzip", r.Header.Get("Content-Encoding"))
+		assert.Contains(t, r.Header.Get("Accept-Encoding"), "gzip")
+		zr, err := gzip.NewReader(r.Body)
+		assert.Nil(t, err)
+		data, _ := io.ReadAll(zr)
+		assert.Equal(t, payload, string(data))
+
+		w.Header().Set("Content-Encoding", "gzip")
+		zw := gzip.NewWriter(w)
+		_, _ = zw.Write([]byte("response"))
+		zw.Close()
+	}))
+	defer server.Close()
+
+	resp, err := httpClient().Post(server.URL, "application/json", bytes.NewReader([]byte(payload)))
+	assert.Nil(t, err)
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "response", string(data))
+}
+
+func TestCompressedRequestRejected(t *testing.T) {
+	payload := strings.Repeat("b", 2*compressThreshold)
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.Header.Get("Content-Encoding") != "" {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+		data, _ := io.ReadAll(r.Body)
+		assert.Equal(t, payload, string(data))
+	}))
+	defer server.Close()
+
+	for i := 0; i < 2; i++ {
+		resp, err := httpClient().Post(server.URL, "application/json", bytes.NewReader([]byte(payload)))
+		assert.Nil(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+	assert.Equal(t, 3, calls) // compressed, resent uncompressed, then uncompressed only
+}
+
+func TestSmallAndPrecompressedRequests(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get("Content-Encoding"))
+	}))
+	defer server.Close()
+
+	for _, tc := range []struct {
+		contentType string
+		size        int
+	}{
+		{"application/json", compressThreshold - 1},
+		{"application/zip", 2 * compressThreshold},
+	} {
+		resp, err := httpClient().Post(server.URL, tc.contentType, bytes.NewReader(make([]byte, tc.size)))
+		assert.Nil(t, err)
+		resp.Body.Close()
+	}
+}
+
+func TestDeflateResponse(t *testing.T) {
+	var wrapped, raw bytes.Buffer
+	zw := zlib.NewWriter(&wrapped)
+	_, _ = zw.Write([]byte("zlib"))
+	zw.Close()
+	fw, _ := flate.NewWriter(&raw, flate.DefaultCompression)
+	_, _ = fw.Write([]byte("raw deflate"))
+	fw.Close()
+
+	for expected, encoded := range map[string][]byte{"zlib": wrapped.Bytes(), "raw deflate": raw.Bytes()} {
+		r, err := newDeflateReader(bytes.NewReader(encoded))
+		assert.Nil(t, err)
+		data, err := io.ReadAll(r)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, string(data))
+	}
+}
+
+func TestCompressionDisabled(t *testing.T) {
+	SetCompression(false)
+	defer SetCompression(true)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get("Accept-Encoding"))
+		assert.Empty(t, r.Header.Get("Content-Encoding"))
+	}))
+	defer server.Close()
+
+	resp, err := httpClient().Post(server.URL, "application/json", bytes.NewReader(make([]byte, 2*compressThreshold)))
+	assert.Nil(t, err)
+	resp.Body.Close()
+}
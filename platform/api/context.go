@@ -16,74 +16,68 @@ package api
 
 import (
 	"context"
-	"os"
-	"strings"
-	"time"
+	"errors"
 
 	"github.com/apex/log"
-	"github.com/briandowns/spinner"
-	"github.com/fatih/color"
 
 	"github.com/cisco-open/fsoc/cmd/config"
+	"github.com/cisco-open/fsoc/cmdkit/progress"
+	"github.com/cisco-open/fsoc/exitcode"
 )
 
 type callContext struct {
 	goContext context.Context
 	cfg       *config.Context
-	spinner   *spinner.Spinner
+	spinner   *progress.Spinner
+	noSpinner bool // e.g., when the upload progress is displayed instead
 }
 
-var statusChar = map[bool]string{
-	false: color.RedString("\u00d7"),   // cross mark
-	true:  color.GreenString("\u2713"), // checkmark
+// SetQuiet disables the progress indicators displayed during API calls, e.g., for scripts
+func SetQuiet(q bool) {
+	progress.SetQuiet(q)
 }
 
-func newCallContext() *callContext {
+// baseContext is the context of the API calls, e.g., with the deadline of the command
+var baseContext = context.Background()
+
+// SetContext sets the context of the API calls, e.g., with a deadline that limits the time of the
+// command; calls that don't complete before the deadline fail with a timeout error
+func SetContext(ctx context.Context) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	baseContext = ctx
+}
+
+func newCallContext() (*callContext, error) {
 	// get current config context
-	cfg := config.GetCurrentContext()
+	cfg, err := config.LoadCurrentContext()
+	if err != nil {
+		return nil, &exitcode.Error{Code: exitcode.ConfigMissing, Err: err}
+	}
 	if cfg == nil {
-		log.Fatal("Missing context; use 'fsoc config set' to configure your context")
-		panic("unreachable") // keep golintci happy (until it recognizes apex/log fatals)
+		return nil, &exitcode.Error{Code: exitcode.ConfigMissing, Err: errors.New("Missing context; use 'fsoc config set' to configure your context")}
 	}
 	log.WithFields(log.Fields{"context": cfg.Name, "server": cfg.Server, "tenant": cfg.Tenant}).Info("Using context")
 
 	// prepare call context
-	callCtx := callContext{
-		context.Background(),
-		cfg,
-		spinner.New(spinner.CharSets[21], 50*time.Millisecond, spinner.WithWriterFile(os.Stderr)),
-	}
-
-	return &callCtx
+	return &callContext{goContext: baseContext, cfg: cfg}, nil
 }
 
+// startSpinner displays a spinner for a step of the API call; it is silent while the spinner of the
+// operation making the call is displayed (see progress.Start)
 func (c *callContext) startSpinner(msg string) {
-	if c.spinner != nil {
-		if msg != "" {
-			c.spinner.Suffix = " " + msg + " in progress"
-			c.spinner.FinalMSG = statusChar[false] + " " + msg + "\n" // jic
-		} else {
-			c.spinner.Suffix = ""
-			c.spinner.FinalMSG = ""
-		}
-		_ = c.spinner.Color("cyan")
-		c.spinner.Start()
+	if c.noSpinner {
+		return
 	}
+	c.spinner.Hide() // jic
+	c.spinner = progress.Start(msg)
 }
 
 func (c *callContext) stopSpinner(ok bool) {
-	if c.spinner != nil {
-		_, msg, parsed := strings.Cut(c.spinner.FinalMSG, " ") // first blank after mark
-		if parsed {
-			c.spinner.FinalMSG = statusChar[ok] + " " + msg
-		}
-		c.spinner.Stop()
-	}
+	c.spinner.Stop(ok)
 }
 
 func (c *callContext) stopSpinnerHide() {
-	if c.spinner != nil {
-		c.spinner.FinalMSG = ""
-		c.spinner.Stop()
-	}
+	c.spinner.Hide()
 }
@@ -0,0 +1,188 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apex/log"
+
+	"github.com/cisco-open/fsoc/cmd/config"
+)
+
+// Local history of the API calls that change platform resources, kept in the config directory as
+// JSON lines, so that users can find out what they changed with which profile
+
+const (
+	historyFileName = "history.jsonl"
+	maxHistorySize  = 4 << 20 // once exceeded, the older half of the history is discarded
+)
+
+// HistoryEntry is an API call recorded in the local history
+type HistoryEntry struct {
+	Time      time.Time `json:"time"`
+	Profile   string    `json:"profile"`
+	Tenant    string    `json:"tenant,omitempty"`
+	Method    string    `json:"method"`
+	Path      string    `json:"path"`
+	Layer     string    `json:"layer,omitempty"`     // layer-type/layer-id, for knowledge store calls
+	Resources []string  `json:"resources,omitempty"` // identifiers of the target resources, e.g., preferences:theme/dark
+	Status    int       `json:"status"`
+}
+
+// historyLock serializes the writes of concurrent calls
+var historyLock sync.Mutex
+
+// HistoryPath returns the path of the local history file
+func HistoryPath() string {
+	return filepath.Join(config.GetConfigDir(), historyFileName)
+}
+
+// ReadHistory returns the entries of the local history, oldest first
+func ReadHistory() ([]HistoryEntry, error) {
+	f, err := os.Open(HistoryPath())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return []HistoryEntry{}, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	entries := []HistoryEntry{}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry HistoryEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			log.Warnf("Skipping invalid entry in the history file %q: %v", HistoryPath(), err)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// MatchesResource returns true if the entry has a target resource with the identifier, its object ID
+// (e.g., "dark" for preferences:theme/dark) or its type (e.g., "preferences:theme")
+func (e *HistoryEntry) MatchesResource(id string) bool {
+	for _, r := range e.Resources {
+		if r == id || strings.HasSuffix(r, "/"+id) || strings.HasPrefix(r, id+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// recordHistory appends a call that changes resources (i.e., other than GET) to the local history.
+// Failures to record are logged but do not fail the call.
+func recordHistory(cfg *config.Context, method string, path string, options *Options, status int) {
+	if method == "GET" || options.ReadOnly {
+		return
+	}
+	entry := HistoryEntry{
+		Time:      time.Now().UTC(),
+		Profile:   cfg.Name,
+		Tenant:    cfg.Tenant,
+		Method:    method,
+		Path:      path,
+		Resources: options.Resources,
+		Status:    status,
+	}
+	if len(entry.Resources) == 0 {
+		entry.Resources = resourcesOf(path)
+	}
+	if layerType := options.Headers["layer-type"]; layerType != "" {
+		entry.Layer = layerType + "/" + options.Headers["layer-id"]
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		log.Warnf("Failed to record the call in the history: %v", err)
+		return
+	}
+
+	historyLock.Lock()
+	defer historyLock.Unlock()
+	if err := os.MkdirAll(config.GetConfigDir(), 0700); err != nil {
+		log.Warnf("Failed to record the call in the history: %v", err)
+		return
+	}
+	path = HistoryPath()
+	trimHistory(path)
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		log.Warnf("Failed to record the call in the history: %v", err)
+		return
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		log.Warnf("Failed to record the call in the history: %v", err)
+	}
+}
+
+// trimHistory discards the older half of the history file if it exceeds the maximum size
+func trimHistory(path string) {
+	fi, err := os.Stat(path)
+	if err != nil || fi.Size() <= maxHistorySize {
+		return
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	data = data[len(data)/2:]
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		data = data[i+1:]
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		log.Warnf("Failed to trim the history file %q: %v", path, err)
+	}
+}
+
+// resourcesOf returns the identifiers of the resources targeted by an API path, e.g., the knowledge
+// object type and ID
+func resourcesOf(path string) []string {
+	u, err := url.Parse(path)
+	if err != nil {
+		return nil
+	}
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	for i := range segments {
+		if s, err := url.PathUnescape(segments[i]); err == nil {
+			segments[i] = s
+		}
+	}
+	switch {
+	case len(segments) >= 4 && segments[0] == "objstore" && segments[2] == "objects":
+		if len(segments) == 4 {
+			return []string{segments[3]} // the object ID is not known, e.g., when creating objects
+		}
+		return []string{segments[3] + "/" + segments[4]}
+	case len(segments) >= 4 && segments[0] == "solnmgmt" && segments[2] == "solutions":
+		return []string{"extensibility:solution/" + segments[3]}
+	case len(segments) >= 5 && segments[0] == "administration" && segments[2] == "clients" && segments[3] == "services":
+		return []string{"service-principal/" + segments[4]}
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cisco-open/fsoc/cmd/config"
+)
+
+func TestResourcesOf(t *testing.T) {
+	assert.Equal(t, []string{"preferences:theme/dark"}, resourcesOf("objstore/v1beta/objects/preferences:theme/dark"))
+	assert.Equal(t, []string{"preferences:theme/dark"}, resourcesOf("objstore/v1beta/objects/preferences:theme/dark/restore?x=1"))
+	assert.Equal(t, []string{"preferences:theme"}, resourcesOf("objstore/v1beta/objects/preferences:theme"))
+	assert.Equal(t, []string{"extensibility:solution/spacefleet"}, resourcesOf("solnmgmt/v1beta/solutions/spacefleet"))
+	assert.Equal(t, []string{"service-principal/sp 1"}, resourcesOf("administration/v1beta/clients/services/sp%201/rotate-secret"))
+	assert.Nil(t, resourcesOf("solnmgmt/v1beta/solutions"))
+}
+
+func TestRecordHistory(t *testing.T) {
+	t.Setenv("FSOC_CONFIG_DIR", t.TempDir())
+	cfg := &config.Context{Name: "prod", Tenant: "tn1"}
+
+	recordHistory(cfg, "GET", "objstore/v1beta/objects/preferences:theme/dark", &Options{}, 200)
+	recordHistory(cfg, "POST", "/monitoring/v1/query/execute", &Options{ReadOnly: true}, 200)
+	recordHistory(cfg, "PUT", "objstore/v1beta/objects/preferences:theme/dark", &Options{Headers: map[string]string{"layer-type": "TENANT", "layer-id": "tn1"}}, 200)
+	recordHistory(cfg, "POST", "solnmgmt/v1beta/solutions", &Options{Resources: []string{"extensibility:solution/spacefleet"}}, 500)
+
+	entries, err := ReadHistory()
+	require.Nil(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "PUT", entries[0].Method)
+	assert.Equal(t, "prod", entries[0].Profile)
+	assert.Equal(t, "tn1", entries[0].Tenant)
+	assert.Equal(t, "TENANT/tn1", entries[0].Layer)
+	assert.Equal(t, 500, entries[1].Status)
+
+	assert.True(t, entries[0].MatchesResource("dark"))
+	assert.True(t, entries[0].MatchesResource("preferences:theme"))
+	assert.True(t, entries[0].MatchesResource("preferences:theme/dark"))
+	assert.False(t, entries[0].MatchesResource("light"))
+	assert.True(t, entries[1].MatchesResource("spacefleet"))
+}
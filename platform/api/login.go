@@ -22,6 +22,7 @@ import (
 	"github.com/apex/log"
 
 	"github.com/cisco-open/fsoc/cmd/config"
+	"github.com/cisco-open/fsoc/cmdkit/offline"
 )
 
 // requiredSettings defines what config.Context fields are required for each authentication method
@@ -51,12 +52,58 @@ var fieldToFlag = map[string]string{
 // Login respects different access profile types (when supported) to provide the correct
 // login mechanism for each.
 func Login() error {
-	callCtx := newCallContext()
+	if err := offline.Check("Logging in"); err != nil {
+		return err
+	}
+
+	callCtx, err := newCallContext()
+	if err != nil {
+		return err
+	}
 	defer callCtx.stopSpinner(false) // ensure not running when returning
 
 	return login(callCtx)
 }
 
+// RefreshLogin obtains a new access token without interaction and saves it: using the refresh
+// token for OAuth profiles, or logging in again with the credentials of service and agent principals.
+// Unlike Login, it fails rather than falling back to an interactive login if the token can't be refreshed.
+func RefreshLogin() error {
+	if err := offline.Check("Refreshing the token"); err != nil {
+		return err
+	}
+	callCtx, err := newCallContext()
+	if err != nil {
+		return err
+	}
+	defer callCtx.stopSpinner(false) // ensure not running when returning
+
+	cfg := callCtx.cfg
+	if err := checkConfigForAuth(cfg); err != nil {
+		return err
+	}
+
+	var authErr error
+	switch cfg.AuthMethod {
+	case config.AuthMethodOAuth:
+		if cfg.RefreshToken == "" || cfg.Tenant == "" {
+			return fmt.Errorf("there is no session to refresh; log in with \"fsoc login\" first")
+		}
+		authErr = oauthRefreshToken(callCtx)
+	case config.AuthMethodServicePrincipal:
+		authErr = servicePrincipalLogin(callCtx)
+	case config.AuthMethodAgentPrincipal:
+		authErr = agentPrincipalLogin(callCtx)
+	default:
+		return fmt.Errorf("the %q authentication method doesn't support refreshing the token", cfg.AuthMethod)
+	}
+	if authErr != nil {
+		return authErr
+	}
+
+	return config.SaveCurrentContext(cfg)
+}
+
 func login(callCtx *callContext) error {
 	log.Infof("Login is forced in order to get a valid access token")
 
@@ -81,17 +128,23 @@ func login(callCtx *callContext) error {
 	case config.AuthMethodOAuth:
 		authErr = oauthLogin(callCtx)
 	default:
-		panic(fmt.Sprintf("bug: unhandled authentication method %q", cfg.AuthMethod))
+		return fmt.Errorf("bug: unhandled authentication method %q", cfg.AuthMethod)
 	}
 	if authErr != nil {
 		return authErr
 	}
 
 	// update current context with logged in credentials (token(s)) to use
-	config.ReplaceCurrentContext(cfg)
+	if err := config.SaveCurrentContext(cfg); err != nil {
+		return err
+	}
 
 	// reload context
-	callCtx.cfg = config.GetCurrentContext()
+	reloaded, err := config.LoadCurrentContext()
+	if err != nil {
+		return err
+	}
+	callCtx.cfg = reloaded
 
 	return nil
 }
@@ -19,3 +19,11 @@ func TestRecursiveWalkThroughStruct(t *testing.T) {
 	fields := nonZeroStructFields(&ctx)
 	assert.ElementsMatch(t, fields, []string{"Name", "AuthMethod", "LocalAuthOptions", "LocalAuthOptions.AppdTid"})
 }
+
+func TestParseOAuthError(t *testing.T) {
+	assert.EqualError(t, parseOAuthError([]byte(`{"error":"invalid_grant"}`)), "token request rejected: invalid_grant")
+	assert.EqualError(t, parseOAuthError([]byte(`{"error":"invalid_grant","error_description":"refresh token expired"}`)),
+		"token request rejected: refresh token expired (invalid_grant)")
+	assert.NoError(t, parseOAuthError([]byte(`{"title":"Forbidden","status":403}`)))
+	assert.NoError(t, parseOAuthError([]byte(`not json`)))
+}
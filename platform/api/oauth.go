@@ -213,8 +213,8 @@ func getAuthorizationCodes(ctx *callContext, url string) (*authCodes, error) {
 func exchangeCodeForToken(ctx *callContext, conf *oauth2.Config, pkce pkce.Code, auth *authCodes) (*appTokens, error) {
 	log.Infof("Exchanging authorization codes for access token")
 
-	// create http client for the request
-	client := &http.Client{}
+	// use the shared http client for the request
+	client := httpClient()
 
 	// prepare urlencoded data body
 	values := url.Values{}
@@ -226,7 +226,7 @@ func exchangeCodeForToken(ctx *callContext, conf *oauth2.Config, pkce pkce.Code,
 	bodyReader := bytes.NewReader([]byte(values.Encode()))
 
 	// create a POST HTTP request
-	req, err := http.NewRequest("POST", conf.Endpoint.TokenURL, bodyReader)
+	req, err := http.NewRequestWithContext(ctx.goContext, "POST", conf.Endpoint.TokenURL, bodyReader)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to create a request %q: %v", conf.Endpoint.TokenURL, err.Error())
 	}
@@ -284,8 +284,8 @@ func exchangeCodeForToken(ctx *callContext, conf *oauth2.Config, pkce pkce.Code,
 func oauthRefreshToken(ctx *callContext) error {
 	log.Infof("Trying to get a new access token using the refresh token")
 
-	// create http client for the request
-	client := &http.Client{}
+	// use the shared http client for the request
+	client := httpClient()
 
 	// prepare urlencoded data body
 	values := url.Values{}
@@ -297,7 +297,7 @@ func oauthRefreshToken(ctx *callContext) error {
 
 	// create a POST HTTP request
 	tokenUri := oauthUriWithSuffix(ctx.cfg, oauth2TokenUriSuffix)
-	req, err := http.NewRequest("POST", tokenUri, bodyReader)
+	req, err := http.NewRequestWithContext(ctx.goContext, "POST", tokenUri, bodyReader)
 	if err != nil {
 		return fmt.Errorf("Failed to create a token refresh request %q: %v", tokenUri, err)
 	}
@@ -328,6 +328,9 @@ func oauthRefreshToken(ctx *callContext) error {
 
 	// parse response body in case of error (special parsing logic, tolerate non-JSON responses)
 	if resp.StatusCode/100 != 2 {
+		if err := parseOAuthError(respBytes); err != nil {
+			return err
+		}
 		return parseIntoError(resp, respBytes)
 	}
 
@@ -344,6 +347,22 @@ func oauthRefreshToken(ctx *callContext) error {
 	return nil
 }
 
+// parseOAuthError returns the error in an OAuth error response (RFC 6749, section 5.2), or nil
+// if the response is not one
+func parseOAuthError(respBytes []byte) error {
+	var oauthErr struct {
+		Error       string `json:"error"`
+		Description string `json:"error_description"`
+	}
+	if err := json.Unmarshal(respBytes, &oauthErr); err != nil || oauthErr.Error == "" {
+		return nil
+	}
+	if oauthErr.Description == "" {
+		return fmt.Errorf("token request rejected: %s", oauthErr.Error)
+	}
+	return fmt.Errorf("token request rejected: %s (%s)", oauthErr.Description, oauthErr.Error)
+}
+
 func oauthUriWithSuffix(ctx *config.Context, suffix string) string {
 	uri, err := url.JoinPath(ctx.URL, "auth", ctx.Tenant, oauth2ClientId, suffix)
 	if err != nil {
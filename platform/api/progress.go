@@ -0,0 +1,43 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import "io"
+
+// ProgressFunc is called as the request body is being sent, with the number of bytes sent so far
+// and the total size of the body
+type ProgressFunc func(sent int64, total int64)
+
+// progressReader reports the progress of reading the request body as it is being uploaded
+type progressReader struct {
+	reader   io.Reader
+	sent     int64
+	total    int64
+	progress ProgressFunc
+}
+
+func newProgressReader(r io.Reader, total int64, progress ProgressFunc) *progressReader {
+	progress(0, total)
+	return &progressReader{reader: r, total: total, progress: progress}
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		r.sent += int64(n)
+		r.progress(r.sent, r.total)
+	}
+	return n, err
+}
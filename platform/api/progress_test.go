@@ -0,0 +1,37 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgressReader(t *testing.T) {
+	data := bytes.Repeat([]byte("x"), 10000)
+	var reports [][2]int64
+	r := newProgressReader(bytes.NewReader(data), int64(len(data)), func(sent, total int64) {
+		reports = append(reports, [2]int64{sent, total})
+	})
+
+	read, err := io.ReadAll(r)
+	assert.Nil(t, err)
+	assert.Equal(t, data, read)
+	assert.Equal(t, [2]int64{0, 10000}, reports[0])
+	assert.Equal(t, [2]int64{10000, 10000}, reports[len(reports)-1])
+}
@@ -126,12 +126,12 @@ func agentOrServicePrincipalLogin(ctx *callContext, principalType string, creden
 	// create a HTTP request
 	url, err := url.Parse(ctx.cfg.URL)
 	if err != nil {
-		log.Fatalf("Failed to parse the url provided in context. URL: %s, err: %s", ctx.cfg.URL, err)
+		return fmt.Errorf("Failed to parse the url provided in context (%q): %w", ctx.cfg.URL, err)
 	}
 	url.Path = "auth/" + ctx.cfg.Tenant + "/default/oauth2/token"
 
-	client := &http.Client{}
-	req, err := http.NewRequest("POST", url.String(), strings.NewReader("grant_type=client_credentials")) //TODO: urlencode data!
+	client := httpClient()
+	req, err := http.NewRequestWithContext(ctx.goContext, "POST", url.String(), strings.NewReader("grant_type=client_credentials")) //TODO: urlencode data!
 	if err != nil {
 		return fmt.Errorf("Failed to create a request for %q: %v", url.String(), err)
 	}
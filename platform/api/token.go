@@ -0,0 +1,99 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/apex/log"
+
+	"github.com/cisco-open/fsoc/cmd/config"
+)
+
+// TokenExpiry returns the expiration time of a JWT token, from its "exp" claim; the token is not verified
+func TokenExpiry(token string) (time.Time, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, fmt.Errorf("not a JWT token")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid JWT payload: %w", err)
+	}
+	var claims struct {
+		Exp *float64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return time.Time{}, fmt.Errorf("invalid JWT claims: %w", err)
+	}
+	if claims.Exp == nil {
+		return time.Time{}, fmt.Errorf("the token has no expiration claim")
+	}
+	return time.Unix(int64(*claims.Exp), 0), nil
+}
+
+// CurrentToken returns the access token of the current profile, logging in first (which refreshes
+// the token when possible) if there is no token or it expires within minValidity. Unless interactive
+// is set, the token is only refreshed (see RefreshLogin), without falling back to a browser login.
+// The expiration of opaque, non-JWT tokens is unknown, so they are returned as they are, with a zero
+// expiration time.
+func CurrentToken(minValidity time.Duration, interactive bool) (string, time.Time, error) {
+	cfg, err := config.LoadCurrentContext()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	if cfg == nil {
+		return "", time.Time{}, fmt.Errorf("fsoc is not configured, please run 'fsoc config set' first")
+	}
+	switch cfg.AuthMethod {
+	case config.AuthMethodNone, config.AuthMethodLocal:
+		return "", time.Time{}, fmt.Errorf("the %q authentication method doesn't use access tokens", cfg.AuthMethod)
+	}
+
+	if cfg.Token != "" {
+		expires, err := TokenExpiry(cfg.Token)
+		if err != nil {
+			return cfg.Token, time.Time{}, nil // opaque token
+		}
+		if time.Until(expires) > minValidity {
+			return cfg.Token, expires, nil
+		}
+		if cfg.AuthMethod == config.AuthMethodJWT {
+			return "", time.Time{}, fmt.Errorf("the token expires at %v; obtain a new token and set it with \"fsoc config set --token TOKEN\"", expires.Local().Format(time.RFC3339))
+		}
+		log.Infof("The access token expires at %v, logging in to obtain a new one", expires.Local().Format(time.RFC3339))
+	} else if cfg.AuthMethod == config.AuthMethodJWT {
+		return "", time.Time{}, fmt.Errorf("no token configured; set it with \"fsoc config set --token TOKEN\"")
+	}
+
+	login := Login
+	if !interactive {
+		login = RefreshLogin
+	}
+	if err := login(); err != nil {
+		return "", time.Time{}, err
+	}
+	cfg, err = config.LoadCurrentContext()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	token := cfg.Token
+	expires, _ := TokenExpiry(token) // zero time if unknown
+	return token, expires, nil
+}
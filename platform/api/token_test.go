@@ -0,0 +1,39 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testToken(claims string) string {
+	return "eyJhbGciOiJub25lIn0." + base64.RawURLEncoding.EncodeToString([]byte(claims)) + ".sig"
+}
+
+func TestTokenExpiry(t *testing.T) {
+	expires, err := TokenExpiry(testToken(`{"sub":"x","exp":1685613600}`))
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2023, 6, 1, 10, 0, 0, 0, time.UTC), expires.UTC())
+
+	for _, token := range []string{"opaque", testToken(`{"sub":"x"}`), "a.!!.c", testToken("not json")} {
+		_, err := TokenExpiry(token)
+		assert.Error(t, err, token)
+	}
+}
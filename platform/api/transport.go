@@ -0,0 +1,125 @@
+// Copyright 2023 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"github.com/apex/log"
+
+	"github.com/cisco-open/fsoc/cmdkit/offline"
+)
+
+// Limits of the connection pool shared by all API calls
+const (
+	maxIdleConnsPerHost = 16
+	idleConnTimeout     = 90 * time.Second
+)
+
+// sharedClient is the HTTP client used for all calls to the platform, so that connections
+// (including HTTP/2 connections, which multiplex concurrent requests) are kept alive and
+// reused across the calls a command makes instead of being established for each call
+var sharedClient = &http.Client{Transport: newTransport()}
+
+// connStats counts the connections used by the API calls, logged at debug level
+var connStats struct {
+	sync.Mutex
+	requests int // requests that obtained a connection
+	opened   int // requests that established a new connection
+	reused   int // requests that reused a pooled connection
+}
+
+// newTransport returns the transport of the shared client: the default transport with
+// a larger pool of idle connections, since API calls are made to a small number of hosts,
+// and with the compression handled by compressionTransport rather than by the default transport
+func newTransport() http.RoundTripper {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = maxIdleConnsPerHost
+	transport.IdleConnTimeout = idleConnTimeout
+	transport.ForceAttemptHTTP2 = true
+	transport.DisableCompression = true
+	return &offlineTransport{base: &compressionTransport{base: &statsTransport{base: transport}}}
+}
+
+// httpClient returns the shared HTTP client for API calls
+func httpClient() *http.Client {
+	return sharedClient
+}
+
+// offlineTransport is a round tripper that refuses all requests in offline mode, as a safeguard
+// for the requests that are not made through httpRequest, e.g., by the login flows
+type offlineTransport struct {
+	base http.RoundTripper
+}
+
+// RoundTrip executes the request with the base transport, unless the offline mode is enabled
+func (t *offlineTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := offline.Check("Request to " + req.URL.Host); err != nil {
+		return nil, err
+	}
+	return t.base.RoundTrip(req)
+}
+
+// statsTransport is a round tripper that tracks connection reuse (see connStats)
+type statsTransport struct {
+	base http.RoundTripper
+}
+
+// RoundTrip executes the request with the base transport, tracing which connection it uses
+func (t *statsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var info httptrace.GotConnInfo
+	gotConn := false
+	trace := &httptrace.ClientTrace{
+		GotConn: func(i httptrace.GotConnInfo) {
+			info = i
+			gotConn = true
+		},
+	}
+	resp, err := t.base.RoundTrip(req.WithContext(httptrace.WithClientTrace(req.Context(), trace)))
+	if gotConn {
+		proto := ""
+		if resp != nil {
+			proto = resp.Proto
+		}
+		recordConnection(req, info, proto)
+	}
+	return resp, err
+}
+
+// recordConnection updates the connection stats with a request's connection and logs them
+func recordConnection(req *http.Request, info httptrace.GotConnInfo, proto string) {
+	connStats.Lock()
+	connStats.requests++
+	if info.Reused {
+		connStats.reused++
+	} else {
+		connStats.opened++
+	}
+	requests, opened, reused := connStats.requests, connStats.opened, connStats.reused
+	connStats.Unlock()
+
+	log.WithFields(log.Fields{
+		"host":        req.URL.Host,
+		"protocol":    proto,
+		"reused":      info.Reused,
+		"idle_time":   info.IdleTime,
+		"requests":    requests,
+		"connections": opened,
+		"reuses":      reused,
+	}).Debug("API connection")
+}
@@ -0,0 +1,52 @@
+package melt
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ParseFsocData parses a fsoc telemetry data model (see "fsoc melt model"), which must have entities
+func ParseFsocData(data []byte) (*FsocData, error) {
+	var fsoData *FsocData
+	if err := yaml.Unmarshal(data, &fsoData); err != nil {
+		return nil, err
+	}
+	if fsoData == nil || len(fsoData.Melt) == 0 {
+		return nil, fmt.Errorf("no entities found under \"melt\"")
+	}
+	return fsoData, nil
+}
+
+// FillDefaults completes the telemetry for sending it at the specified time: metrics without data points
+// get random values for the 5 minutes before it, and logs, events and spans without timestamps get the time
+func (d *FsocData) FillDefaults(now time.Time) {
+	for _, entity := range d.Melt {
+		entity.SetAttribute("telemetry.sdk.name", "fsoc-melt")
+		for _, m := range entity.Metrics {
+			et := now
+			if len(m.DataPoints) == 0 {
+				for i := 1; i < 6; i++ {
+					st := et.Add(time.Minute * -1)
+					et = st
+					m.AddDataPoint(st.UnixNano(), et.UnixNano(), rand.Float64()*50)
+				}
+			}
+		}
+		for _, l := range entity.Logs {
+			if l.Timestamp == 0 {
+				l.Timestamp = now.UnixNano()
+			}
+		}
+		for _, s := range entity.Spans {
+			if s.StartTime == 0 {
+				s.StartTime = now.UnixNano()
+			}
+			if s.EndTime == 0 {
+				s.EndTime = s.StartTime
+			}
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package melt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFsocData(t *testing.T) {
+	data, err := ParseFsocData([]byte(`
+melt:
+  - typename: spacefleet:ship
+    attributes:
+      name: enterprise
+    metrics:
+      - typename: spacefleet:fuel
+    logs:
+      - body: launched
+`))
+	require.Nil(t, err)
+	require.Len(t, data.Melt, 1)
+
+	now := time.Unix(1700000000, 0)
+	data.FillDefaults(now)
+	entity := data.Melt[0]
+	assert.Equal(t, "fsoc-melt", entity.Attributes["telemetry.sdk.name"])
+	assert.Len(t, entity.Metrics[0].DataPoints, 5)
+	assert.Equal(t, now.UnixNano(), entity.Logs[0].Timestamp)
+
+	_, err = ParseFsocData([]byte("melt: []"))
+	assert.NotNil(t, err)
+}
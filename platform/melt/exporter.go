@@ -1,6 +1,7 @@
 package melt
 
 import (
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 
@@ -313,10 +314,10 @@ func (exp *Exporter) createOtelLog(l *Log) *logs.LogRecord {
 func (exp *Exporter) createOtelSpan(t *Span) *spans.Span {
 	ots := &spans.Span{
 		Name:              t.Name,
-		TraceId:           []byte(t.TraceID),
-		SpanId:            []byte(t.SpanID),
+		TraceId:           idBytes(t.TraceID),
+		SpanId:            idBytes(t.SpanID),
 		TraceState:        t.TraceState,
-		ParentSpanId:      []byte(t.ParentSpanID),
+		ParentSpanId:      idBytes(t.ParentSpanID),
 		Kind:              spans.Span_SpanKind(t.Kind),
 		StartTimeUnixNano: uint64(t.StartTime),
 		EndTimeUnixNano:   uint64(t.EndTime),
@@ -335,8 +336,8 @@ func (exp *Exporter) createOtelSpan(t *Span) *spans.Span {
 	// links
 	for _, l := range t.Links {
 		ots.Links = append(ots.Links, &spans.Span_Link{
-			TraceId:    []byte(l.TraceID),
-			SpanId:     []byte(l.SpanID),
+			TraceId:    idBytes(l.TraceID),
+			SpanId:     idBytes(l.SpanID),
 			TraceState: l.TraceState,
 			Attributes: toKeyValueList(l.Attributes),
 		})
@@ -352,6 +353,14 @@ func (exp *Exporter) createOtelSpan(t *Span) *spans.Span {
 	return ots
 }
 
+// idBytes returns the bytes of a trace or span id given in hex, as in OTLP JSON, or as is otherwise
+func idBytes(id string) []byte {
+	if b, err := hex.DecodeString(id); err == nil && (len(b) == 8 || len(b) == 16) {
+		return b
+	}
+	return []byte(id)
+}
+
 func (exp *Exporter) exportHTTP(path string, m protoreflect.ProtoMessage) error {
 	options := api.Options{
 		Headers: map[string]string{
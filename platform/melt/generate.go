@@ -0,0 +1,460 @@
+package melt
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// MaxGeneratedDataPoints - limit on the number of metric data points, log records and spans that a generator
+// spec can produce, to catch specs that are accidentally too large
+const MaxGeneratedDataPoints = 1_000_000
+
+// value patterns for generated metrics
+const (
+	PatternRandom   = "random"
+	PatternSine     = "sine"
+	PatternRamp     = "ramp"
+	PatternConstant = "constant"
+)
+
+// GeneratorSpec - declarative spec of synthetic telemetry to generate
+type GeneratorSpec struct {
+	TimeRange string        `yaml:"timerange,omitempty"` // how far back the telemetry goes, e.g., 1h (default 15m)
+	Interval  string        `yaml:"interval,omitempty"`  // interval between metric data points (default 1m)
+	Entities  []*EntitySpec `yaml:"entities"`
+}
+
+// EntitySpec - spec of entities of a type and their telemetry
+type EntitySpec struct {
+	TypeName   string            `yaml:"typename"`
+	Count      int               `yaml:"count,omitempty"` // number of entities (default 1)
+	Attributes map[string]string `yaml:"attributes"`      // "{index}" in values is replaced with the entity number
+	Metrics    []*MetricSpec     `yaml:"metrics,omitempty"`
+	Logs       []*LogSpec        `yaml:"logs,omitempty"`
+	Events     []*EventSpec      `yaml:"events,omitempty"`
+	Spans      []*SpanSpec       `yaml:"spans,omitempty"`
+}
+
+// MetricSpec - spec of a metric reported by each entity, with one time series per combination of dimension values
+type MetricSpec struct {
+	TypeName    string              `yaml:"typename"`
+	ContentType string              `yaml:"contenttype,omitempty"` // gauge (default) or sum
+	Type        string              `yaml:"type,omitempty"`        // double (default) or long
+	Unit        string              `yaml:"unit,omitempty"`
+	Min         float64             `yaml:"min,omitempty"`
+	Max         float64             `yaml:"max,omitempty"`     // default 100 if neither min nor max is set
+	Pattern     string              `yaml:"pattern,omitempty"` // random (default), sine, ramp or constant
+	Jitter      float64             `yaml:"jitter,omitempty"`  // random variation, as a fraction of max-min
+	Dimensions  map[string][]string `yaml:"dimensions,omitempty"`
+}
+
+// LogSpec - spec of log records emitted by each entity, at random times within the time range
+type LogSpec struct {
+	Body       string            `yaml:"body"` // "{index}" is replaced with the entity number, "{n}" with the record number
+	Severity   string            `yaml:"severity,omitempty"`
+	Count      int               `yaml:"count,omitempty"` // per entity (default 1)
+	Attributes map[string]string `yaml:"attributes,omitempty"`
+}
+
+// EventSpec - spec of events emitted by each entity, at random times within the time range
+type EventSpec struct {
+	TypeName   string            `yaml:"typename"`
+	Count      int               `yaml:"count,omitempty"` // per entity (default 1)
+	Attributes map[string]string `yaml:"attributes,omitempty"`
+}
+
+// SpanSpec - spec of the spans of each entity, each starting a trace at a random time within the time range
+type SpanSpec struct {
+	Name        string            `yaml:"name"`
+	Kind        string            `yaml:"kind,omitempty"`        // internal, server (default), client, producer or consumer
+	Count       int               `yaml:"count,omitempty"`       // per entity (default 1)
+	MinDuration string            `yaml:"minduration,omitempty"` // default 10ms
+	MaxDuration string            `yaml:"maxduration,omitempty"` // default 10 times the minimum
+	ErrorRate   float64           `yaml:"errorrate,omitempty"`   // fraction of spans with error status
+	Attributes  map[string]string `yaml:"attributes,omitempty"`
+}
+
+var spanKinds = map[string]SpanKind{
+	"internal": SpanKindInternal,
+	"server":   SpanKindServer,
+	"client":   SpanKindClient,
+	"producer": SpanKindProducer,
+	"consumer": SpanKindConsumer,
+}
+
+// ParseGeneratorSpec - parses and validates a generator spec, filling in the defaults
+func ParseGeneratorSpec(data []byte) (*GeneratorSpec, error) {
+	var spec GeneratorSpec
+	if err := yaml.UnmarshalStrict(data, &spec); err != nil {
+		return nil, err
+	}
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	return &spec, nil
+}
+
+// Validate - validates the spec, filling in the defaults
+func (s *GeneratorSpec) Validate() error {
+	if s.TimeRange == "" {
+		s.TimeRange = "15m"
+	}
+	if s.Interval == "" {
+		s.Interval = "1m"
+	}
+	timeRange, interval, err := s.durations()
+	if err != nil {
+		return err
+	}
+	if interval > timeRange {
+		return fmt.Errorf("interval %v is longer than the time range %v", interval, timeRange)
+	}
+	if len(s.Entities) == 0 {
+		return fmt.Errorf("no entities found under \"entities\"")
+	}
+
+	points := int64(timeRange / interval)
+	total := int64(0)
+	for i, e := range s.Entities {
+		if err := e.validate(); err != nil {
+			return fmt.Errorf("entities[%d] (%s): %w", i, e.TypeName, err)
+		}
+		perEntity := int64(0)
+		for _, m := range e.Metrics {
+			perEntity += int64(m.series()) * points
+		}
+		for _, l := range e.Logs {
+			perEntity += int64(l.Count)
+		}
+		for _, ev := range e.Events {
+			perEntity += int64(ev.Count)
+		}
+		for _, sp := range e.Spans {
+			perEntity += int64(sp.Count)
+		}
+		total += perEntity * int64(e.Count)
+	}
+	if total > MaxGeneratedDataPoints {
+		return fmt.Errorf("the spec generates %d data points, log records and spans, more than the limit of %d; reduce the counts, the time range or the dimensions", total, MaxGeneratedDataPoints)
+	}
+	return nil
+}
+
+func (s *GeneratorSpec) durations() (timeRange time.Duration, interval time.Duration, err error) {
+	timeRange, err = time.ParseDuration(s.TimeRange)
+	if err != nil || timeRange <= 0 {
+		return 0, 0, fmt.Errorf("invalid timerange %q", s.TimeRange)
+	}
+	interval, err = time.ParseDuration(s.Interval)
+	if err != nil || interval <= 0 {
+		return 0, 0, fmt.Errorf("invalid interval %q", s.Interval)
+	}
+	return timeRange, interval, nil
+}
+
+func (e *EntitySpec) validate() error {
+	if e.TypeName == "" {
+		return fmt.Errorf("missing typename")
+	}
+	if e.Count < 0 {
+		return fmt.Errorf("invalid count %d", e.Count)
+	}
+	if e.Count == 0 {
+		e.Count = 1
+	}
+	for _, m := range e.Metrics {
+		if err := m.validate(); err != nil {
+			return fmt.Errorf("metric %q: %w", m.TypeName, err)
+		}
+	}
+	for _, l := range e.Logs {
+		if l.Count < 0 {
+			return fmt.Errorf("log %q: invalid count %d", l.Body, l.Count)
+		}
+		if l.Count == 0 {
+			l.Count = 1
+		}
+	}
+	for _, ev := range e.Events {
+		if ev.TypeName == "" {
+			return fmt.Errorf("event with missing typename")
+		}
+		if ev.Count < 0 {
+			return fmt.Errorf("event %q: invalid count %d", ev.TypeName, ev.Count)
+		}
+		if ev.Count == 0 {
+			ev.Count = 1
+		}
+	}
+	for _, sp := range e.Spans {
+		if err := sp.validate(); err != nil {
+			return fmt.Errorf("span %q: %w", sp.Name, err)
+		}
+	}
+	return nil
+}
+
+func (m *MetricSpec) validate() error {
+	if m.TypeName == "" {
+		return fmt.Errorf("missing typename")
+	}
+	if m.ContentType == "" {
+		m.ContentType = "gauge"
+	}
+	if m.ContentType != "gauge" && m.ContentType != "sum" {
+		return fmt.Errorf("invalid contenttype %q, must be gauge or sum", m.ContentType)
+	}
+	if m.Type == "" {
+		m.Type = "double"
+	}
+	if m.Type != "double" && m.Type != "long" {
+		return fmt.Errorf("invalid type %q, must be double or long", m.Type)
+	}
+	if m.Max == 0 && m.Min == 0 {
+		m.Max = 100
+	}
+	if m.Max < m.Min {
+		return fmt.Errorf("max %v is less than min %v", m.Max, m.Min)
+	}
+	if m.Pattern == "" {
+		m.Pattern = PatternRandom
+	}
+	switch m.Pattern {
+	case PatternRandom, PatternSine, PatternRamp, PatternConstant:
+	default:
+		return fmt.Errorf("invalid pattern %q, must be one of %s, %s, %s or %s", m.Pattern, PatternRandom, PatternSine, PatternRamp, PatternConstant)
+	}
+	if m.Jitter < 0 || m.Jitter > 1 {
+		return fmt.Errorf("invalid jitter %v, must be between 0 and 1", m.Jitter)
+	}
+	for name, values := range m.Dimensions {
+		if len(values) == 0 {
+			return fmt.Errorf("dimension %q has no values", name)
+		}
+	}
+	return nil
+}
+
+func (sp *SpanSpec) validate() error {
+	if sp.Name == "" {
+		return fmt.Errorf("missing name")
+	}
+	if sp.Count < 0 {
+		return fmt.Errorf("invalid count %d", sp.Count)
+	}
+	if sp.Count == 0 {
+		sp.Count = 1
+	}
+	if sp.Kind == "" {
+		sp.Kind = "server"
+	}
+	if _, ok := spanKinds[sp.Kind]; !ok {
+		return fmt.Errorf("invalid kind %q", sp.Kind)
+	}
+	if sp.ErrorRate < 0 || sp.ErrorRate > 1 {
+		return fmt.Errorf("invalid errorrate %v, must be between 0 and 1", sp.ErrorRate)
+	}
+	if sp.MinDuration == "" {
+		sp.MinDuration = "10ms"
+	}
+	min, err := time.ParseDuration(sp.MinDuration)
+	if err != nil || min < 0 {
+		return fmt.Errorf("invalid minduration %q", sp.MinDuration)
+	}
+	if sp.MaxDuration == "" {
+		sp.MaxDuration = (10 * min).String()
+	}
+	max, err := time.ParseDuration(sp.MaxDuration)
+	if err != nil || max < min {
+		return fmt.Errorf("invalid maxduration %q", sp.MaxDuration)
+	}
+	return nil
+}
+
+// series returns the number of time series of the metric, i.e., the number of dimension value combinations
+func (m *MetricSpec) series() int {
+	n := 1
+	for _, values := range m.Dimensions {
+		n *= len(values)
+	}
+	return n
+}
+
+// dimensionCombinations returns the attributes of each of the time series of the metric
+func (m *MetricSpec) dimensionCombinations() []map[string]string {
+	combinations := []map[string]string{{}}
+	for _, name := range sortedKeys(m.Dimensions) {
+		next := make([]map[string]string, 0, len(combinations)*len(m.Dimensions[name]))
+		for _, c := range combinations {
+			for _, value := range m.Dimensions[name] {
+				attrs := make(map[string]string, len(c)+1)
+				for k, v := range c {
+					attrs[k] = v
+				}
+				attrs[name] = value
+				next = append(next, attrs)
+			}
+		}
+		combinations = next
+	}
+	return combinations
+}
+
+// Generate - generates the telemetry of the spec for the time range ending at the given time. The spec must
+// have been validated. The same random source seed produces the same telemetry.
+func (s *GeneratorSpec) Generate(end time.Time, rnd *rand.Rand) *FsocData {
+	timeRange, interval, _ := s.durations()
+	start := end.Add(-timeRange)
+	g := &generator{start: start, end: end, interval: interval, rnd: rnd}
+
+	data := &FsocData{Melt: []*Entity{}}
+	for _, es := range s.Entities {
+		for i := 1; i <= es.Count; i++ {
+			data.Melt = append(data.Melt, g.entity(es, i))
+		}
+	}
+	return data
+}
+
+type generator struct {
+	start    time.Time
+	end      time.Time
+	interval time.Duration
+	rnd      *rand.Rand
+}
+
+func (g *generator) entity(es *EntitySpec, index int) *Entity {
+	e := NewEntity(es.TypeName)
+	for k, v := range es.Attributes {
+		e.SetAttribute(k, expandIndex(v, index))
+	}
+	for _, ms := range es.Metrics {
+		for _, attrs := range ms.dimensionCombinations() {
+			e.AddMetric(g.metric(ms, attrs))
+		}
+	}
+	for _, ls := range es.Logs {
+		for n := 1; n <= ls.Count; n++ {
+			l := NewLog()
+			l.Body = strings.ReplaceAll(expandIndex(ls.Body, index), "{n}", strconv.Itoa(n))
+			l.Severity = ls.Severity
+			l.Timestamp = g.randomTime().UnixNano()
+			for k, v := range ls.Attributes {
+				l.SetAttribute(k, expandIndex(v, index))
+			}
+			e.AddLog(l)
+		}
+	}
+	for _, evs := range es.Events {
+		for n := 1; n <= evs.Count; n++ {
+			ev := NewEvent(evs.TypeName)
+			ev.Timestamp = g.randomTime().UnixNano()
+			for k, v := range evs.Attributes {
+				ev.SetAttribute(k, expandIndex(v, index))
+			}
+			e.AddLog(ev)
+		}
+	}
+	for _, ss := range es.Spans {
+		for n := 1; n <= ss.Count; n++ {
+			e.AddSpan(g.span(ss, index))
+		}
+	}
+	return e
+}
+
+func (g *generator) metric(ms *MetricSpec, attrs map[string]string) *Metric {
+	m := NewMetric(ms.TypeName, ms.Unit, ms.ContentType, ms.Type)
+	for k, v := range attrs {
+		m.SetAttribute(k, v)
+	}
+	if ms.ContentType == "sum" {
+		m.IsMonotonic = true
+		m.AggregationTemporality = AggregationTemporalityDelta
+	}
+
+	// the phase of the sine pattern differs per series, so that the series are distinguishable
+	phase := g.rnd.Float64() * 2 * math.Pi
+	total := g.end.Sub(g.start)
+	for t := g.start; !t.Add(g.interval).After(g.end); t = t.Add(g.interval) {
+		progress := float64(t.Sub(g.start)) / float64(total)
+		m.AddDataPoint(t.UnixNano(), t.Add(g.interval).UnixNano(), g.value(ms, progress, phase))
+	}
+	return m
+}
+
+// value returns the value of a metric at a point within the time range (progress from 0 to 1)
+func (g *generator) value(ms *MetricSpec, progress float64, phase float64) float64 {
+	span := ms.Max - ms.Min
+	var v float64
+	switch ms.Pattern {
+	case PatternConstant:
+		v = ms.Min + span/2
+	case PatternRamp:
+		v = ms.Min + span*progress
+	case PatternSine:
+		v = ms.Min + span/2 + span/2*math.Sin(2*math.Pi*progress+phase)
+	default:
+		v = ms.Min + span*g.rnd.Float64()
+	}
+	if ms.Jitter > 0 {
+		v += (g.rnd.Float64()*2 - 1) * ms.Jitter * span
+	}
+	v = math.Max(ms.Min, math.Min(ms.Max, v))
+	if ms.Type == "long" {
+		v = math.Round(v)
+	}
+	return v
+}
+
+func (g *generator) span(ss *SpanSpec, index int) *Span {
+	min, _ := time.ParseDuration(ss.MinDuration)
+	max, _ := time.ParseDuration(ss.MaxDuration)
+	duration := min + time.Duration(g.rnd.Int63n(int64(max-min)+1))
+
+	s := NewSpan(g.randomID(16), g.randomID(8), ss.Name)
+	s.Kind = spanKinds[ss.Kind]
+	s.StartTime = g.randomTime().UnixNano()
+	s.EndTime = s.StartTime + int64(duration)
+	for k, v := range ss.Attributes {
+		s.SetAttribute(k, expandIndex(v, index))
+	}
+	if g.rnd.Float64() < ss.ErrorRate {
+		s.SetStatus("synthetic error", SpanStatusCodeError)
+	} else {
+		s.SetStatus("", SpanStatusCodeOK)
+	}
+	return s
+}
+
+func (g *generator) randomTime() time.Time {
+	return g.start.Add(time.Duration(g.rnd.Int63n(int64(g.end.Sub(g.start)))))
+}
+
+// randomID returns a random trace or span id, hex encoded
+func (g *generator) randomID(size int) string {
+	b := make([]byte, size)
+	_, _ = g.rnd.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func expandIndex(s string, index int) string {
+	return strings.ReplaceAll(s, "{index}", strconv.Itoa(index))
+}
+
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,113 @@
+package melt
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testGeneratorSpec = `
+timerange: 10m
+interval: 1m
+entities:
+- typename: k8s:workload
+  count: 3
+  attributes:
+    k8s.workload.name: cart-{index}
+  metrics:
+  - typename: k8s:cpu_usage
+    min: 1
+    max: 4
+    pattern: sine
+    jitter: 0.1
+    dimensions:
+      container: [app, sidecar]
+      zone: [a, b, c]
+  - typename: k8s:restarts
+    contenttype: sum
+    type: long
+    min: 0
+    max: 3
+  logs:
+  - body: "order {n} of cart-{index}"
+    severity: INFO
+    count: 5
+  events:
+  - typename: k8s:pod_restart
+    count: 2
+  spans:
+  - name: GET /cart
+    count: 4
+    minduration: 50ms
+    maxduration: 500ms
+    errorrate: 1
+`
+
+func TestGenerate(t *testing.T) {
+	spec, err := ParseGeneratorSpec([]byte(testGeneratorSpec))
+	require.NoError(t, err)
+
+	end := time.Unix(1690000000, 0)
+	data := spec.Generate(end, rand.New(rand.NewSource(1)))
+	require.Len(t, data.Melt, 3)
+
+	e := data.Melt[1]
+	assert.Equal(t, "k8s:workload", e.TypeName)
+	assert.Equal(t, "cart-2", e.Attributes["k8s.workload.name"])
+
+	// 2x3 series of cpu usage and one of restarts, each with 10 points
+	require.Len(t, e.Metrics, 7)
+	for _, m := range e.Metrics {
+		require.Len(t, m.DataPoints, 10)
+		assert.Equal(t, end.Add(-10*time.Minute).UnixNano(), m.DataPoints[0].StartTime)
+		assert.Equal(t, end.UnixNano(), m.DataPoints[9].EndTime)
+		for _, dp := range m.DataPoints {
+			if m.TypeName == "k8s:cpu_usage" {
+				assert.True(t, dp.Value >= 1 && dp.Value <= 4, "value %v out of range", dp.Value)
+			} else {
+				assert.Equal(t, float64(int(dp.Value)), dp.Value)
+			}
+		}
+	}
+	assert.NotEmpty(t, e.Metrics[0].Attributes["container"])
+	assert.NotEmpty(t, e.Metrics[0].Attributes["zone"])
+	assert.True(t, e.Metrics[6].IsMonotonic)
+
+	require.Len(t, e.Logs, 7)
+	assert.Equal(t, "order 1 of cart-2", e.Logs[0].Body)
+	assert.True(t, e.Logs[6].IsEvent)
+
+	require.Len(t, e.Spans, 4)
+	for _, s := range e.Spans {
+		assert.Len(t, s.TraceID, 32)
+		assert.Len(t, s.SpanID, 16)
+		d := time.Duration(s.EndTime - s.StartTime)
+		assert.True(t, d >= 50*time.Millisecond && d <= 500*time.Millisecond, "duration %v out of range", d)
+		assert.Equal(t, SpanStatusCodeError, s.Status.Code)
+	}
+
+	// the same seed generates the same telemetry
+	again := spec.Generate(end, rand.New(rand.NewSource(1)))
+	assert.Equal(t, data, again)
+}
+
+func TestParseGeneratorSpecErrors(t *testing.T) {
+	tests := map[string]string{
+		"no entities":      "timerange: 1h",
+		"unknown field":    "entities:\n- typename: a:b\n  bogus: 1",
+		"invalid interval": "interval: 1x\nentities:\n- typename: a:b",
+		"long interval":    "timerange: 1m\ninterval: 1h\nentities:\n- typename: a:b",
+		"missing typename": "entities:\n- count: 2",
+		"bad pattern":      "entities:\n- typename: a:b\n  metrics:\n  - typename: c:d\n    pattern: zigzag",
+		"bad range":        "entities:\n- typename: a:b\n  metrics:\n  - typename: c:d\n    min: 5\n    max: 1",
+		"bad span kind":    "entities:\n- typename: a:b\n  spans:\n  - name: x\n    kind: sideways",
+		"too large":        "timerange: 24h\ninterval: 1s\nentities:\n- typename: a:b\n  count: 20\n  metrics:\n  - typename: c:d",
+	}
+	for name, spec := range tests {
+		_, err := ParseGeneratorSpec([]byte(spec))
+		assert.Error(t, err, name)
+	}
+}
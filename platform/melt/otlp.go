@@ -0,0 +1,193 @@
+package melt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	colllogs "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	collmetrics "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	collspans "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// OTLPData - telemetry decoded from the OTLP JSON encoding or received over OTLP, ready to be exported as is;
+// any of the requests may be nil
+type OTLPData struct {
+	Metrics *collmetrics.ExportMetricsServiceRequest
+	Logs    *colllogs.ExportLogsServiceRequest
+	Spans   *collspans.ExportTraceServiceRequest
+}
+
+// OTLPCounts - number of data items in OTLP data
+type OTLPCounts struct {
+	Metrics    int `json:"metrics"`
+	LogRecords int `json:"logRecords"`
+	Events     int `json:"events"` // log records that are events
+	Spans      int `json:"spans"`
+}
+
+// the OTLP JSON encoding uses hex for the ids, while the protobuf JSON mapping uses base64
+var otlpHexIDKeys = map[string]bool{"traceId": true, "spanId": true, "parentSpanId": true}
+
+// IsOTLPJSON - returns true if the data looks like OTLP JSON, i.e., an export request with resource metrics,
+// logs or spans
+func IsOTLPJSON(data []byte) bool {
+	var top map[string]json.RawMessage
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if err := dec.Decode(&top); err != nil {
+		return false
+	}
+	_, m := top["resourceMetrics"]
+	_, l := top["resourceLogs"]
+	_, s := top["resourceSpans"]
+	return m || l || s
+}
+
+// ParseOTLPJSON - parses OTLP JSON data: one or more export requests (e.g., JSON lines written by the
+// collector's file exporter), each with resource metrics, logs and/or spans
+func ParseOTLPJSON(data []byte) (*OTLPData, error) {
+	d := &OTLPData{
+		Metrics: &collmetrics.ExportMetricsServiceRequest{},
+		Logs:    &colllogs.ExportLogsServiceRequest{},
+		Spans:   &collspans.ExportTraceServiceRequest{},
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	for n := 1; ; n++ {
+		var top map[string]any
+		err := dec.Decode(&top)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid JSON in request %d: %w", n, err)
+		}
+
+		found := false
+		for key, value := range top {
+			if key != "resourceMetrics" && key != "resourceLogs" && key != "resourceSpans" {
+				continue
+			}
+			found = true
+			if err := convertHexIDs(value); err != nil {
+				return nil, fmt.Errorf("invalid %s in request %d: %w", key, n, err)
+			}
+			b, err := json.Marshal(map[string]any{key: value})
+			if err != nil {
+				return nil, err
+			}
+			if err := d.merge(key, b); err != nil {
+				return nil, fmt.Errorf("invalid %s in request %d: %w", key, n, err)
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("request %d has no resourceMetrics, resourceLogs or resourceSpans", n)
+		}
+	}
+	return d, nil
+}
+
+// merge adds the resource metrics, logs or spans in the protobuf JSON data to the OTLP data
+func (d *OTLPData) merge(key string, b []byte) error {
+	switch key {
+	case "resourceMetrics":
+		m := &collmetrics.ExportMetricsServiceRequest{}
+		if err := protojson.Unmarshal(b, m); err != nil {
+			return err
+		}
+		d.Metrics.ResourceMetrics = append(d.Metrics.ResourceMetrics, m.ResourceMetrics...)
+	case "resourceLogs":
+		l := &colllogs.ExportLogsServiceRequest{}
+		if err := protojson.Unmarshal(b, l); err != nil {
+			return err
+		}
+		d.Logs.ResourceLogs = append(d.Logs.ResourceLogs, l.ResourceLogs...)
+	case "resourceSpans":
+		s := &collspans.ExportTraceServiceRequest{}
+		if err := protojson.Unmarshal(b, s); err != nil {
+			return err
+		}
+		d.Spans.ResourceSpans = append(d.Spans.ResourceSpans, s.ResourceSpans...)
+	}
+	return nil
+}
+
+// convertHexIDs replaces the hex trace and span ids in a decoded JSON value with their base64 form
+func convertHexIDs(v any) error {
+	switch val := v.(type) {
+	case map[string]any:
+		for k, item := range val {
+			if s, ok := item.(string); ok && otlpHexIDKeys[k] {
+				b, err := hex.DecodeString(s)
+				if err != nil {
+					return fmt.Errorf("%s %q is not hex encoded", k, s)
+				}
+				val[k] = base64.StdEncoding.EncodeToString(b)
+				continue
+			}
+			if err := convertHexIDs(item); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, item := range val {
+			if err := convertHexIDs(item); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Counts - returns the number of metrics, log records, events and spans
+func (d *OTLPData) Counts() OTLPCounts {
+	var c OTLPCounts
+	for _, rm := range d.Metrics.GetResourceMetrics() {
+		for _, sm := range rm.ScopeMetrics {
+			c.Metrics += len(sm.Metrics)
+		}
+	}
+	for _, rl := range d.Logs.GetResourceLogs() {
+		for _, sl := range rl.ScopeLogs {
+			for _, lr := range sl.LogRecords {
+				c.LogRecords++
+				for _, a := range lr.Attributes {
+					if a.Key == keyAppdIsEvent && a.Value.GetStringValue() == "true" {
+						c.Events++
+					}
+				}
+			}
+		}
+	}
+	for _, rs := range d.Spans.GetResourceSpans() {
+		for _, ss := range rs.ScopeSpans {
+			c.Spans += len(ss.Spans)
+		}
+	}
+	return c
+}
+
+// ExportOTLP - export OTLP data as is
+func (exp *Exporter) ExportOTLP(d *OTLPData) error {
+	if len(d.Metrics.GetResourceMetrics()) > 0 {
+		if err := exp.exportHTTP(pathMetrics, d.Metrics); err != nil {
+			return fmt.Errorf("Failed to export metrics: %w", err)
+		}
+	}
+	if len(d.Logs.GetResourceLogs()) > 0 {
+		if err := exp.exportHTTP(pathLogs, d.Logs); err != nil {
+			return fmt.Errorf("Failed to export logs: %w", err)
+		}
+	}
+	if len(d.Spans.GetResourceSpans()) > 0 {
+		if err := exp.exportHTTP(pathSpans, d.Spans); err != nil {
+			return fmt.Errorf("Failed to export spans: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package melt
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testOTLPJSON = `{"resourceMetrics":[{"resource":{"attributes":[{"key":"service.name","value":{"stringValue":"cart"}}]},
+ "scopeMetrics":[{"metrics":[{"name":"cart.size","gauge":{"dataPoints":[{"timeUnixNano":"1690000000000000000","asInt":"3"}]}}]}]}]}
+{"resourceLogs":[{"scopeLogs":[{"logRecords":[
+  {"timeUnixNano":"1690000000000000000","body":{"stringValue":"checkout"}},
+  {"body":{"stringValue":"deployed"},"attributes":[{"key":"appd.isevent","value":{"stringValue":"true"}}]}]}]}]}
+{"resourceSpans":[{"scopeSpans":[{"spans":[{"traceId":"5b8efff798038103d269b633813fc60c","spanId":"eee19b7ec3c1b174",
+  "parentSpanId":"eee19b7ec3c1b173","name":"checkout","kind":2,"startTimeUnixNano":"1690000000000000000","endTimeUnixNano":"1690000001000000000"}]}]}]}
+`
+
+func TestParseOTLPJSON(t *testing.T) {
+	assert.True(t, IsOTLPJSON([]byte(testOTLPJSON)))
+	assert.False(t, IsOTLPJSON([]byte("melt:\n- typename: k8s:cluster\n")))
+	assert.False(t, IsOTLPJSON([]byte(`{"melt":[]}`)))
+
+	d, err := ParseOTLPJSON([]byte(testOTLPJSON))
+	require.NoError(t, err)
+	assert.Equal(t, OTLPCounts{Metrics: 1, LogRecords: 2, Events: 1, Spans: 1}, d.Counts())
+
+	m := d.Metrics.ResourceMetrics[0].ScopeMetrics[0].Metrics[0]
+	assert.Equal(t, "cart.size", m.Name)
+	assert.Equal(t, int64(3), m.GetGauge().DataPoints[0].GetAsInt())
+
+	span := d.Spans.ResourceSpans[0].ScopeSpans[0].Spans[0]
+	assert.Equal(t, "5b8efff798038103d269b633813fc60c", hex.EncodeToString(span.TraceId))
+	assert.Equal(t, "eee19b7ec3c1b174", hex.EncodeToString(span.SpanId))
+	assert.Equal(t, "eee19b7ec3c1b173", hex.EncodeToString(span.ParentSpanId))
+	assert.Equal(t, uint64(1690000001000000000), span.EndTimeUnixNano)
+}
+
+func TestParseOTLPJSONErrors(t *testing.T) {
+	_, err := ParseOTLPJSON([]byte(`{"resourceSpans":[{"scopeSpans":[{"spans":[{"traceId":"not-hex"}]}]}]}`))
+	assert.ErrorContains(t, err, "not hex encoded")
+
+	_, err = ParseOTLPJSON([]byte(`{"resourceMetrics":[]}` + "\n" + `{"other":1}`))
+	assert.ErrorContains(t, err, "request 2")
+
+	_, err = ParseOTLPJSON([]byte(`{"resourceLogs":[{"scopeLogs":[{"logRecords":[{"bogus":1}]}]}]}`))
+	assert.Error(t, err)
+}
+
+func TestIDBytes(t *testing.T) {
+	assert.Equal(t, []byte{0xee, 0xe1, 0x9b, 0x7e, 0xc3, 0xc1, 0xb1, 0x74}, idBytes("eee19b7ec3c1b174"))
+	assert.Equal(t, []byte("span-1"), idBytes("span-1"))
+}
@@ -0,0 +1,163 @@
+package melt
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	colllogs "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	collmetrics "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	collspans "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	_ "google.golang.org/grpc/encoding/gzip" // accept gzip-compressed gRPC requests
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// maxReceiveSize limits the size of the OTLP/HTTP requests accepted by the receiver
+const maxReceiveSize = 64 << 20
+
+// OTLP/HTTP paths of the signals
+const (
+	ReceiverPathMetrics = "/v1/metrics"
+	ReceiverPathLogs    = "/v1/logs"
+	ReceiverPathTraces  = "/v1/traces"
+)
+
+// Receiver - OTLP receiver, over gRPC and HTTP, that passes the received telemetry to a handler,
+// e.g., to forward it to the platform
+type Receiver struct {
+	// Handle is called with each received request; the protocol is "grpc" or "http". An error
+	// is returned to the sender as a retryable failure.
+	Handle func(protocol string, d *OTLPData) error
+}
+
+// RegisterGRPC - registers the OTLP metrics, logs and trace services with a gRPC server
+func (r *Receiver) RegisterGRPC(s *grpc.Server) {
+	collmetrics.RegisterMetricsServiceServer(s, &metricsService{r: r})
+	colllogs.RegisterLogsServiceServer(s, &logsService{r: r})
+	collspans.RegisterTraceServiceServer(s, &traceService{r: r})
+}
+
+type metricsService struct {
+	collmetrics.UnimplementedMetricsServiceServer
+	r *Receiver
+}
+
+func (s *metricsService) Export(ctx context.Context, req *collmetrics.ExportMetricsServiceRequest) (*collmetrics.ExportMetricsServiceResponse, error) {
+	if err := s.r.Handle("grpc", &OTLPData{Metrics: req}); err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	return &collmetrics.ExportMetricsServiceResponse{}, nil
+}
+
+type logsService struct {
+	colllogs.UnimplementedLogsServiceServer
+	r *Receiver
+}
+
+func (s *logsService) Export(ctx context.Context, req *colllogs.ExportLogsServiceRequest) (*colllogs.ExportLogsServiceResponse, error) {
+	if err := s.r.Handle("grpc", &OTLPData{Logs: req}); err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	return &colllogs.ExportLogsServiceResponse{}, nil
+}
+
+type traceService struct {
+	collspans.UnimplementedTraceServiceServer
+	r *Receiver
+}
+
+func (s *traceService) Export(ctx context.Context, req *collspans.ExportTraceServiceRequest) (*collspans.ExportTraceServiceResponse, error) {
+	if err := s.r.Handle("grpc", &OTLPData{Spans: req}); err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	return &collspans.ExportTraceServiceResponse{}, nil
+}
+
+// ServeHTTP - receives OTLP/HTTP requests, in the protobuf or JSON encoding
+func (r *Receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var msg, resp proto.Message
+	switch req.URL.Path {
+	case ReceiverPathMetrics:
+		msg, resp = &collmetrics.ExportMetricsServiceRequest{}, &collmetrics.ExportMetricsServiceResponse{}
+	case ReceiverPathLogs:
+		msg, resp = &colllogs.ExportLogsServiceRequest{}, &colllogs.ExportLogsServiceResponse{}
+	case ReceiverPathTraces:
+		msg, resp = &collspans.ExportTraceServiceRequest{}, &collspans.ExportTraceServiceResponse{}
+	default:
+		http.NotFound(w, req)
+		return
+	}
+	if req.Method != http.MethodPost {
+		http.Error(w, "only POST is supported", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := readOTLPBody(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	isJSON := mediaType == "application/json"
+
+	var d *OTLPData
+	if isJSON {
+		d, err = ParseOTLPJSON(body)
+	} else {
+		err = proto.Unmarshal(body, msg)
+		d = otlpDataOf(msg)
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid OTLP request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if err := r.Handle("http", d); err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	if isJSON {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("{}"))
+		return
+	}
+	b, _ := proto.Marshal(resp)
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	_, _ = w.Write(b)
+}
+
+func readOTLPBody(req *http.Request) ([]byte, error) {
+	var body io.Reader = http.MaxBytesReader(nil, req.Body, maxReceiveSize)
+	switch req.Header.Get("Content-Encoding") {
+	case "", "identity":
+	case "gzip":
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip body: %w", err)
+		}
+		defer gz.Close()
+		body = io.LimitReader(gz, maxReceiveSize)
+	default:
+		return nil, fmt.Errorf("unsupported Content-Encoding %q", req.Header.Get("Content-Encoding"))
+	}
+	return io.ReadAll(body)
+}
+
+func otlpDataOf(msg proto.Message) *OTLPData {
+	switch m := msg.(type) {
+	case *collmetrics.ExportMetricsServiceRequest:
+		return &OTLPData{Metrics: m}
+	case *colllogs.ExportLogsServiceRequest:
+		return &OTLPData{Logs: m}
+	case *collspans.ExportTraceServiceRequest:
+		return &OTLPData{Spans: m}
+	}
+	return &OTLPData{}
+}
@@ -0,0 +1,116 @@
+package melt
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	collspans "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	spans "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+)
+
+func testTraceRequest() *collspans.ExportTraceServiceRequest {
+	return &collspans.ExportTraceServiceRequest{ResourceSpans: []*spans.ResourceSpans{{
+		ScopeSpans: []*spans.ScopeSpans{{Spans: []*spans.Span{{Name: "a"}, {Name: "b"}}}},
+	}}}
+}
+
+func TestReceiverHTTP(t *testing.T) {
+	var received []*OTLPData
+	var failWith error
+	r := &Receiver{Handle: func(protocol string, d *OTLPData) error {
+		assert.Equal(t, "http", protocol)
+		received = append(received, d)
+		return failWith
+	}}
+
+	// protobuf, gzip-compressed
+	body, err := proto.Marshal(testTraceRequest())
+	require.NoError(t, err)
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	_, _ = zw.Write(body)
+	require.NoError(t, zw.Close())
+	req := httptest.NewRequest(http.MethodPost, ReceiverPathTraces, &gz)
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("Content-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/x-protobuf", w.Header().Get("Content-Type"))
+	require.Len(t, received, 1)
+	assert.Equal(t, OTLPCounts{Spans: 2}, received[0].Counts())
+
+	// JSON
+	req = httptest.NewRequest(http.MethodPost, ReceiverPathLogs, bytes.NewBufferString(`{"resourceLogs":[{"scopeLogs":[{"logRecords":[{"body":{"stringValue":"hi"}}]}]}]}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "{}", w.Body.String())
+	require.Len(t, received, 2)
+	assert.Equal(t, OTLPCounts{LogRecords: 1}, received[1].Counts())
+
+	// errors
+	for _, tc := range []struct {
+		method, path, body string
+		status             int
+	}{
+		{http.MethodPost, "/v1/other", "", http.StatusNotFound},
+		{http.MethodGet, ReceiverPathMetrics, "", http.StatusMethodNotAllowed},
+		{http.MethodPost, ReceiverPathMetrics, "\xff\xff", http.StatusBadRequest},
+	} {
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, bytes.NewBufferString(tc.body)))
+		assert.Equal(t, tc.status, w.Code, tc.path)
+	}
+
+	failWith = errors.New("platform unavailable")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, ReceiverPathTraces, bytes.NewReader(body)))
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+}
+
+func TestReceiverGRPC(t *testing.T) {
+	var failWith error
+	var counts OTLPCounts
+	r := &Receiver{Handle: func(protocol string, d *OTLPData) error {
+		assert.Equal(t, "grpc", protocol)
+		counts = d.Counts()
+		return failWith
+	}}
+
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	r.RegisterGRPC(server)
+	go func() { _ = server.Serve(listener) }()
+	defer server.Stop()
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	client := collspans.NewTraceServiceClient(conn)
+
+	_, err = client.Export(context.Background(), testTraceRequest())
+	require.NoError(t, err)
+	assert.Equal(t, OTLPCounts{Spans: 2}, counts)
+
+	failWith = errors.New("platform unavailable")
+	_, err = client.Export(context.Background(), testTraceRequest())
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}